@@ -6,7 +6,15 @@ go build -o bin/stories-service ./cmd/stories-service
 echo "Building Ephemeral Worker..."
 go build -o bin/ephemeral-worker ./cmd/ephemeral-worker
 
+echo "Building Seeder..."
+go build -o bin/seed ./cmd/seed
+
+echo "Building Admin CLI..."
+go build -o bin/storiesctl ./cmd/storiesctl
+
 echo "Build completed successfully!"
 echo "Run the services with:"
 echo "  ./bin/stories-service (for the main API)"
 echo "  ./bin/ephemeral-worker (for the worker)"
+echo "  ./bin/seed (to load demo data)"
+echo "  ./bin/storiesctl (for admin tasks)"
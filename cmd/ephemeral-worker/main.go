@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"os"
@@ -9,25 +10,46 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/archive"
+	"github.com/princekumarofficial/stories-service/internal/cache"
 	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/emailnotify"
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/exports"
+	"github.com/princekumarofficial/stories-service/internal/impressions"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	"github.com/princekumarofficial/stories-service/internal/mailer"
+	"github.com/princekumarofficial/stories-service/internal/mediasync"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/recap"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/startup"
+	"github.com/princekumarofficial/stories-service/internal/storage"
 	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/types"
 )
 
+// expiryWarningWindow is how long before expiry authors are warned about their stories
+const expiryWarningWindow = time.Hour
+
 type EphemeralWorker struct {
-	storage  *postgres.Postgres
-	interval time.Duration
-	logger   *slog.Logger
+	storage   storage.StoryStore
+	publisher events.Publisher
+	interval  time.Duration
+	logger    *slog.Logger
 }
 
-func NewEphemeralWorker(storage *postgres.Postgres, interval time.Duration) *EphemeralWorker {
+func NewEphemeralWorker(storage storage.StoryStore, publisher events.Publisher, interval time.Duration) *EphemeralWorker {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 
 	return &EphemeralWorker{
-		storage:  storage,
-		interval: interval,
-		logger:   logger,
+		storage:   storage,
+		publisher: publisher,
+		interval:  interval,
+		logger:    logger,
 	}
 }
 
@@ -39,6 +61,7 @@ func (ew *EphemeralWorker) Start(ctx context.Context) {
 		"interval", ew.interval.String())
 
 	// Run once immediately on startup
+	ew.processExpiringStories(ctx)
 	ew.processExpiredStories(ctx)
 
 	for {
@@ -47,45 +70,187 @@ func (ew *EphemeralWorker) Start(ctx context.Context) {
 			ew.logger.Info("Ephemeral worker shutting down")
 			return
 		case <-ticker.C:
+			ew.processExpiringStories(ctx)
 			ew.processExpiredStories(ctx)
 		}
 	}
 }
 
+func (ew *EphemeralWorker) processExpiringStories(ctx context.Context) {
+	startTime := time.Now()
+	failures := 0
+	defer func() { metrics.ObserveWorkerBatch(metrics.JobExpiryWarnings, startTime, failures) }()
+
+	stories, err := ew.storage.ClaimExpiringStories(expiryWarningWindow)
+	if err != nil {
+		failures++
+		ew.logger.Error("Failed to claim expiring stories",
+			"error", err.Error(),
+			"duration_ms", time.Since(startTime).Milliseconds())
+		return
+	}
+
+	warned := 0
+	for _, story := range stories {
+		err := ew.publisher.PublishStoryExpiring(story.ID, story.AuthorID, story.ExpiresAt)
+		if err == nil {
+			warned++
+			continue
+		}
+
+		// Unsent warnings are claimed again on the next run, until the story expires
+		if !errors.Is(err, events.ErrNotConnected) {
+			failures++
+			ew.logger.Error("Failed to publish story expiring event",
+				"story_id", story.ID,
+				"error", err.Error())
+		}
+		if err := ew.storage.ReleaseExpiryWarning(story.ID); err != nil {
+			failures++
+			ew.logger.Error("Failed to release story expiry warning",
+				"story_id", story.ID,
+				"error", err.Error())
+		}
+	}
+
+	if warned > 0 {
+		ew.logger.Info("Sent story expiry warnings",
+			"stories_warned", warned,
+			"duration_ms", time.Since(startTime).Milliseconds())
+	}
+}
+
 func (ew *EphemeralWorker) processExpiredStories(ctx context.Context) {
 	startTime := time.Now()
+	failures := 0
+	defer func() { metrics.ObserveWorkerBatch(metrics.JobExpire, startTime, failures) }()
 	
 	ew.logger.Info("Starting expired stories cleanup")
 
-	count, err := ew.storage.SoftDeleteExpiredStories()
+	stories, err := ew.storage.SoftDeleteExpiredStories()
 	if err != nil {
+		failures++
 		ew.logger.Error("Failed to process expired stories",
 			"error", err.Error(),
 			"duration_ms", time.Since(startTime).Milliseconds())
 		return
 	}
 
+	metrics.StoriesExpired(len(stories))
+	failures += ew.publishExpiredStories(stories)
+
 	duration := time.Since(startTime)
 	
 	ew.logger.Info("Completed expired stories cleanup",
-		"stories_deleted", count,
+		"stories_deleted", len(stories),
 		"duration_ms", duration.Milliseconds(),
 		"duration", duration.String())
 }
 
+// publishExpiredStories tells the authors and audiences of expired stories
+// that they are gone. It returns how many lookups and events failed.
+func (ew *EphemeralWorker) publishExpiredStories(stories []types.Story) int {
+	failures := 0
+	for _, story := range stories {
+		audience, err := ew.storage.GetStoryAudience(story.ID)
+		if err != nil {
+			failures++
+			ew.logger.Error("Failed to get audience for expired story",
+				"story_id", story.ID,
+				"author_id", story.AuthorID,
+				"error", err.Error())
+		}
+
+		if err := ew.publisher.PublishStoryRemoved(types.EventStoryExpired, story, audience); err != nil {
+			failures++
+			ew.logger.Error("Failed to publish story expired event",
+				"story_id", story.ID,
+				"error", err.Error())
+		}
+	}
+	return failures
+}
+
 func main() {
 	// Load config
 	cfg := config.MustLoad()
 
+	// Retry each dependency until it is ready or attempts run out
+	policy := startup.PolicyFromConfig(cfg.Startup)
+
 	// Initialize database connection
-	storage, err := postgres.NewPostgres(cfg)
+	var storage *postgres.Postgres
+	err := policy.Retry(context.Background(), "postgres", func(context.Context) error {
+		var err error
+		storage, err = postgres.NewPostgres(cfg)
+		return err
+	}, nil)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	slog.Info("Connected to Postgres database")
 
+	// Initialize Redis client for relaying events to the stories service
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	err = policy.Retry(context.Background(), "redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	}, nil)
+	if err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+	slog.Info("Connected to Redis")
+	redisKeys := cache.NewKeys(cfg.Redis.KeyPrefix)
+
+	eventPublisher := events.NewEventPublisher(events.NewRedisRelay(redisClient, redisKeys))
+
 	// Create worker with 1-minute interval
-	worker := NewEphemeralWorker(storage, time.Minute)
+	worker := NewEphemeralWorker(storage, eventPublisher, time.Minute)
+
+	// Run queued background work: emails, media deletions, exports and recaps
+	queue := jobs.NewQueue(storage.GetDB(), cfg.Jobs)
+	jobWorker := jobs.NewWorker(storage.GetDB(), cfg.Jobs)
+
+	// Email opted-in users about new followers and their weekly stats,
+	// queueing the emails so they are retried while the SMTP server is down
+	emailMailer, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer:", err)
+	}
+	emailWorker := emailnotify.NewWorker(storage, mailer.NewQueued(queue), cfg)
+	jobWorker.Handle(mailer.JobSend, mailer.SendJob(emailMailer))
+
+	// Compare bucket objects with upload records
+	var mediaSvc *mediaService.Service
+	err = policy.Retry(context.Background(), "minio", func(context.Context) error {
+		var err error
+		mediaSvc, err = mediaService.NewService(cfg)
+		return err
+	}, nil)
+	if err != nil {
+		log.Fatal("Failed to initialize media service:", err)
+	}
+	reconciler := mediasync.NewReconciler(storage, mediaSvc, redisClient, redisKeys, cfg.Media)
+	jobWorker.Handle(mediaService.JobDelete, mediaSvc.DeleteJob())
+	jobWorker.Handle(exports.JobStats, exports.StatsJob(storage, mediaSvc))
+
+	// Recap each creator's week once it ends
+	jobWorker.Handle(recap.JobWeekly, recap.WeeklyJob(storage, queue))
+	jobWorker.Handle(recap.JobCreator, recap.CreatorJob(storage, eventPublisher, emailWorker))
+	if err := recap.Schedule(context.Background(), queue, time.Now()); err != nil {
+		slog.Error("Failed to schedule weekly recaps", slog.String("error", err.Error()))
+	}
+
+	// Move stories long gone from feeds to the cold archive
+	archiver := archive.NewArchiver(storage, mediaSvc, cfg.Archive)
+
+	// Write the impressions the stories service buffered in Redis
+	flusher := impressions.NewFlusher(storage, redisClient, redisKeys, cfg.Impressions)
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -101,8 +266,28 @@ func main() {
 		cancel()
 	}()
 
-	// Start the worker
+	// Export the workers' metrics for Prometheus
+	exporter := metrics.NewExporter(cfg.Metrics.Worker)
+	go exporter.Start(ctx)
+
+	// Start the workers
+	go jobWorker.Start(ctx)
+	go emailWorker.Start(ctx)
+	go reconciler.Start(ctx)
+	go flusher.Start(ctx)
+	if cfg.Archive.Enabled {
+		go archiver.Start(ctx)
+	}
 	worker.Start(ctx)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := eventPublisher.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to deliver pending events", slog.String("error", err.Error()))
+	}
+	if err := exporter.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to stop metrics exporter", slog.String("error", err.Error()))
+	}
 	
 	slog.Info("Ephemeral worker stopped")
 }
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strings"
+)
+
+// goReserved are names a parameter cannot take in a generated method, as
+// they are Go keywords or used by the method body
+var goReserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"c": true, "ctx": true, "body": true, "query": true, "opts": true,
+	"context": true, "url": true, "strconv": true,
+}
+
+// pathParamPattern matches the {name} placeholders of a path
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// renderGo renders api.go of the Go client
+func renderGo(a *api) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{"context": true}
+
+	fmt.Fprintf(&body, "// Version is the API version the client was generated for\nconst Version = %q\n\n", a.Version)
+
+	for _, m := range a.Models {
+		writeGoModel(&body, m)
+	}
+	for _, e := range a.Operations {
+		writeGoEndpoint(&body, e, imports)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/sdkgen from docs/swagger.json. DO NOT EDIT.\n\n")
+	out.WriteString("package storiesclient\n\nimport (\n")
+	for _, pkg := range sortedKeys(imports) {
+		fmt.Fprintf(&out, "\t%q\n", pkg)
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated Go code does not compile: %w", err)
+	}
+	return formatted, nil
+}
+
+// writeGoModel writes a model as a struct, or as a string type with a
+// constant per value for an enum
+func writeGoModel(w *bytes.Buffer, m model) {
+	doc := m.Description
+	if doc == "" {
+		doc = "is the " + m.Source + " model of the API"
+	}
+	writeComment(w, "", m.Name+" "+doc)
+
+	if len(m.Enum) > 0 {
+		fmt.Fprintf(w, "type %s string\n\nconst (\n", m.Name)
+		for _, v := range m.Enum {
+			fmt.Fprintf(w, "\t%s %s = %q", v.Name, m.Name, v.Value)
+			if v.Description != "" {
+				fmt.Fprintf(w, " // %s", v.Description)
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString(")\n\n")
+		return
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n", m.Name)
+	for _, f := range m.Fields {
+		typ := goType(f.Type)
+		tag := f.JSONName
+		if !f.Required {
+			tag += ",omitempty"
+			// Zero is a valid coordinate, so a missing one must be told apart
+			if f.Type.Kind == "number" {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(w, "\t%s %s `json:%q`", pascal(f.JSONName), typ, tag)
+		if f.Description != "" {
+			fmt.Fprintf(w, " // %s", f.Description)
+		}
+		w.WriteString("\n")
+	}
+	w.WriteString("}\n\n")
+}
+
+// writeGoEndpoint writes an operation as a Client method, preceded by the
+// struct of its optional query parameters if it has any
+func writeGoEndpoint(w *bytes.Buffer, e endpoint, imports map[string]bool) {
+	name := pascal(e.ID)
+
+	var optional []param
+	for _, p := range e.Query {
+		if !p.Required {
+			optional = append(optional, p)
+		}
+	}
+	if len(optional) > 0 {
+		writeComment(w, "", name+"Options holds the optional parameters of "+name+"; zero values are not sent")
+		fmt.Fprintf(w, "type %sOptions struct {\n", name)
+		for _, p := range optional {
+			fmt.Fprintf(w, "\t%s %s", pascal(p.Name), goType(p.Type))
+			if p.Description != "" {
+				fmt.Fprintf(w, " // %s", p.Description)
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString("}\n\n")
+	}
+
+	writeComment(w, "", fmt.Sprintf("%s calls %s %s (%s)", name, e.Method, e.Path, e.Summary))
+	if e.Description != "" && e.Description != e.Summary {
+		w.WriteString("//\n")
+		writeComment(w, "", sentence(e.Description))
+	}
+	if e.Auth {
+		w.WriteString("//\n// Requires a client with a token.\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range e.PathParams {
+		args = append(args, goParamName(p.Name)+" string")
+	}
+	for _, p := range e.Query {
+		if p.Required {
+			args = append(args, goParamName(p.Name)+" "+goType(p.Type))
+		}
+	}
+	if e.Body != nil {
+		args = append(args, "body "+goType(e.Body))
+	}
+	if len(optional) > 0 {
+		args = append(args, "opts *"+name+"Options")
+	}
+
+	result := "error"
+	if e.Result != nil {
+		result = "(" + goType(e.Result) + ", error)"
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), result)
+
+	queryArg := "nil"
+	if len(e.Query) > 0 {
+		imports["net/url"] = true
+		queryArg = "query"
+		w.WriteString("\tquery := url.Values{}\n")
+		for _, p := range e.Query {
+			if p.Required {
+				fmt.Fprintf(w, "\tquery.Set(%q, %s)\n", p.Name, goQueryValue(p.Type.Kind, goParamName(p.Name), imports))
+			}
+		}
+		if len(optional) > 0 {
+			w.WriteString("\tif opts != nil {\n")
+			for _, p := range optional {
+				field := "opts." + pascal(p.Name)
+				fmt.Fprintf(w, "\t\tif %s {\n\t\t\tquery.Set(%q, %s)\n\t\t}\n", goNonZero(p.Type.Kind, field), p.Name, goQueryValue(p.Type.Kind, field, imports))
+			}
+			w.WriteString("\t}\n")
+		}
+	}
+
+	bodyArg := "nil"
+	if e.Body != nil {
+		bodyArg = "body"
+	}
+
+	callFunc := "callRaw"
+	if e.Enveloped {
+		callFunc = "call"
+	}
+	path := goPath(e.Path, imports)
+	if e.Result != nil {
+		fmt.Fprintf(w, "\treturn %s[%s](ctx, c, %q, %s, %s, %s)\n", callFunc, goType(e.Result), e.Method, path, queryArg, bodyArg)
+	} else {
+		fmt.Fprintf(w, "\t_, err := %s[any](ctx, c, %q, %s, %s, %s)\n\treturn err\n", callFunc, e.Method, path, queryArg, bodyArg)
+	}
+	w.WriteString("}\n\n")
+}
+
+// goType renders a type as a Go type expression
+func goType(t *typeRef) string {
+	switch t.Kind {
+	case "string":
+		return "string"
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "ref":
+		return t.Ref
+	case "array":
+		return "[]" + goType(t.Elem)
+	case "map":
+		return "map[string]" + goType(t.Elem)
+	}
+	return "any"
+}
+
+// goPath renders a path as a Go string expression that escapes each path
+// parameter
+func goPath(path string, imports map[string]bool) string {
+	matches := pathParamPattern.FindAllStringSubmatchIndex(path, -1)
+	if len(matches) == 0 {
+		return fmt.Sprintf("%q", path)
+	}
+	imports["net/url"] = true
+
+	var parts []string
+	last := 0
+	for _, m := range matches {
+		if m[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", path[last:m[0]]))
+		}
+		parts = append(parts, "url.PathEscape("+goParamName(path[m[2]:m[3]])+")")
+		last = m[1]
+	}
+	if last < len(path) {
+		parts = append(parts, fmt.Sprintf("%q", path[last:]))
+	}
+	return strings.Join(parts, "+")
+}
+
+// goQueryValue renders the expression formatting a query parameter value
+func goQueryValue(kind, expr string, imports map[string]bool) string {
+	switch kind {
+	case "integer":
+		imports["strconv"] = true
+		return "strconv.FormatInt(" + expr + ", 10)"
+	case "number":
+		imports["strconv"] = true
+		return "strconv.FormatFloat(" + expr + ", 'f', -1, 64)"
+	case "boolean":
+		imports["strconv"] = true
+		return "strconv.FormatBool(" + expr + ")"
+	}
+	return expr
+}
+
+// goNonZero renders the condition that a value is not the zero value of its kind
+func goNonZero(kind, expr string) string {
+	switch kind {
+	case "integer", "number":
+		return expr + " != 0"
+	case "boolean":
+		return expr
+	}
+	return expr + ` != ""`
+}
+
+// goParamName converts a parameter name to a Go identifier, e.g. user_id
+// becomes userID
+func goParamName(name string) string {
+	ident := camel(name, true)
+	if goReserved[ident] {
+		return ident + "Param"
+	}
+	return ident
+}
+
+// writeComment writes text as a comment wrapped at 80 columns, each line
+// starting with indent
+func writeComment(w *bytes.Buffer, indent, text string) {
+	for _, line := range wrap(text, 80-len(indent)-3) {
+		fmt.Fprintf(w, "%s// %s\n", indent, line)
+	}
+}
+
+// sentence ends text with a period unless it ends in punctuation already, so
+// gofmt does not take a one-line paragraph for a heading
+func sentence(text string) string {
+	if strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?") {
+		return text
+	}
+	return text + "."
+}
+
+// wrap breaks text into lines of at most width characters, except for
+// words longer than that
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,76 @@
+// Command sdkgen generates the Go and TypeScript clients in sdk/ from the
+// Swagger document that generate-docs.sh builds out of the handler
+// annotations. Every JSON operation needs an @ID, which names its client
+// method.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// output is a generated file
+type output struct {
+	path string
+	data []byte
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/swagger.json", "Swagger document to generate the clients from")
+	goDir := flag.String("go", "sdk/go/storiesclient", "Directory of the Go client package")
+	tsDir := flag.String("ts", "sdk/typescript", "Directory of the TypeScript client package")
+	check := flag.Bool("check", false, "Only report generated files that are out of date, and exit 1 if any are")
+	flag.Parse()
+
+	a, err := loadAPI(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to load API: %v", err)
+	}
+
+	outputs, err := generate(a, *goDir, *tsDir)
+	if err != nil {
+		log.Fatalf("Failed to generate clients: %v", err)
+	}
+
+	stale := 0
+	for _, out := range outputs {
+		if *check {
+			current, err := os.ReadFile(out.path)
+			if err != nil || !bytes.Equal(current, out.data) {
+				fmt.Fprintf(os.Stderr, "%s is out of date, run ./generate-docs.sh\n", out.path)
+				stale++
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(out.path), 0o755); err != nil {
+			log.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(out.path, out.data, 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", out.path, err)
+		}
+		log.Printf("Wrote %s", out.path)
+	}
+
+	if stale > 0 {
+		os.Exit(1)
+	}
+}
+
+// generate renders every client file
+func generate(a *api, goDir, tsDir string) ([]output, error) {
+	goSource, err := renderGo(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return []output{
+		{path: filepath.Join(goDir, "api.go"), data: goSource},
+		{path: filepath.Join(tsDir, "src", "index.ts"), data: renderTypeScript(a)},
+		{path: filepath.Join(tsDir, "package.json"), data: renderPackageJSON(a)},
+	}, nil
+}
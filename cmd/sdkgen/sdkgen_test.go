@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedClientsAreUpToDate(t *testing.T) {
+	root := filepath.Join("..", "..")
+	a, err := loadAPI(filepath.Join(root, "docs", "swagger.json"))
+	if err != nil {
+		t.Fatalf("loadAPI() error = %v", err)
+	}
+
+	outputs, err := generate(a, filepath.Join(root, "sdk", "go", "storiesclient"), filepath.Join(root, "sdk", "typescript"))
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	for _, out := range outputs {
+		current, err := os.ReadFile(out.path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", out.path, err)
+		}
+		if !bytes.Equal(current, out.data) {
+			t.Errorf("%s is out of date, run ./generate-docs.sh", out.path)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		pascal  string
+		camelGo string
+		camelTS string
+		goParam string
+	}{
+		{name: "user_id", pascal: "UserID", camelGo: "userID", camelTS: "userId", goParam: "userID"},
+		{name: "audience_user_ids", pascal: "AudienceUserIDs", camelGo: "audienceUserIDs", camelTS: "audienceUserIds", goParam: "audienceUserIDs"},
+		{name: "createUploadURL", pascal: "CreateUploadURL", camelGo: "createUploadURL", camelTS: "createUploadUrl", goParam: "createUploadURL"},
+		{name: "url", pascal: "URL", camelGo: "url", camelTS: "url", goParam: "urlParam"},
+		{name: "type", pascal: "Type", camelGo: "type", camelTS: "type", goParam: "typeParam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pascal(tt.name); got != tt.pascal {
+				t.Errorf("pascal() = %q, want %q", got, tt.pascal)
+			}
+			if got := camel(tt.name, true); got != tt.camelGo {
+				t.Errorf("camel(goStyle) = %q, want %q", got, tt.camelGo)
+			}
+			if got := camel(tt.name, false); got != tt.camelTS {
+				t.Errorf("camel() = %q, want %q", got, tt.camelTS)
+			}
+			if got := goParamName(tt.name); got != tt.goParam {
+				t.Errorf("goParamName() = %q, want %q", got, tt.goParam)
+			}
+		})
+	}
+}
+
+func TestBuildAPIRequiresOperationIDs(t *testing.T) {
+	doc := &spec{Paths: map[string]map[string]*operation{
+		"/feed": {"get": {Summary: "Get stories feed"}},
+	}}
+
+	if _, err := buildAPI(doc); err == nil {
+		t.Fatal("buildAPI() error = nil, want an error for the operation without an @ID")
+	}
+}
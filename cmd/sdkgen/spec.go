@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Swagger 2.0 documents, as far as swag produces them
+
+type spec struct {
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths       map[string]map[string]*operation `json:"paths"`
+	Definitions map[string]*schema               `json:"definitions"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description"`
+	Produces    []string              `json:"produces"`
+	Security    []map[string][]string `json:"security"`
+	Parameters  []parameter           `json:"parameters"`
+	Responses   map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []string           `json:"enum"`
+	Required             []string           `json:"required"`
+	EnumNames            []string           `json:"x-enum-varnames"`
+	EnumDescriptions     []string           `json:"x-enum-descriptions"`
+}
+
+// envelopeRef is the {status, message, data} response every JSON endpoint
+// wraps its data in
+const envelopeRef = "#/definitions/response.Response"
+
+// nonJSONParams switch an operation to a response format the clients do not
+// decode, such as the NDJSON feed stream, so they are not offered
+var nonJSONParams = map[string]bool{"stream": true}
+
+// The language-neutral API the emitters render
+
+// api is everything a client needs, in a stable order
+type api struct {
+	Title      string
+	Version    string
+	Models     []model
+	Operations []endpoint
+}
+
+// model is a named object or string enum
+type model struct {
+	Name        string
+	Source      string // the definition it was built from, e.g. types.Story
+	Description string
+	Enum        []enumValue
+	Fields      []field
+}
+
+type enumValue struct {
+	Name, Value, Description string
+}
+
+type field struct {
+	JSONName    string
+	Type        *typeRef
+	Required    bool
+	Description string
+}
+
+// typeRef is a field, parameter or result type. Kind is string, integer,
+// number, boolean, any, ref (to Ref), array or map (of Elem).
+type typeRef struct {
+	Kind string
+	Ref  string
+	Elem *typeRef
+}
+
+// endpoint is one operation of the API
+type endpoint struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Auth        bool
+	PathParams  []param
+	Query       []param // required ones first
+	Body        *typeRef
+	Result      *typeRef // nil when the response carries no data
+	Enveloped   bool
+}
+
+type param struct {
+	Name        string
+	Type        *typeRef
+	Required    bool
+	Description string
+}
+
+// loadAPI reads a Swagger document and builds the API from it
+func loadAPI(path string) (*api, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var doc spec
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	return buildAPI(&doc)
+}
+
+// buildAPI converts a Swagger document. Operations that do not produce JSON
+// are left out, and one documented under several methods is generated once,
+// for the first method in alphabetical order.
+func buildAPI(doc *spec) (*api, error) {
+	a := &api{Title: doc.Info.Title, Version: doc.Info.Version}
+
+	names := make(map[string]string)
+	for _, key := range sortedKeys(doc.Definitions) {
+		if key == "response.Response" {
+			continue
+		}
+		name := modelName(key)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("definitions %s and %s both map to %s", other, key, name)
+		}
+		names[name] = key
+
+		def := doc.Definitions[key]
+		m := model{Name: name, Source: key, Description: def.Description}
+		for i, value := range def.Enum {
+			enumName := name + pascal(strings.ToLower(value))
+			if i < len(def.EnumNames) {
+				enumName = def.EnumNames[i]
+			}
+			var enumDescription string
+			if i < len(def.EnumDescriptions) {
+				enumDescription = def.EnumDescriptions[i]
+			}
+			m.Enum = append(m.Enum, enumValue{Name: enumName, Value: value, Description: enumDescription})
+		}
+		for _, prop := range sortedKeys(def.Properties) {
+			t, err := resolve(def.Properties[prop])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", key, prop, err)
+			}
+			m.Fields = append(m.Fields, field{
+				JSONName:    prop,
+				Type:        t,
+				Required:    slices.Contains(def.Required, prop),
+				Description: description(def.Properties[prop]),
+			})
+		}
+		a.Models = append(a.Models, m)
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range sortedKeys(doc.Paths) {
+		for _, method := range sortedKeys(doc.Paths[path]) {
+			op := doc.Paths[path][method]
+			if !producesJSON(op) || seen[op.OperationID] {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s has no @ID", strings.ToUpper(method), path)
+			}
+			seen[op.OperationID] = true
+
+			e, err := buildEndpoint(path, method, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op.OperationID, err)
+			}
+			a.Operations = append(a.Operations, e)
+		}
+	}
+	sort.Slice(a.Operations, func(i, j int) bool { return a.Operations[i].ID < a.Operations[j].ID })
+
+	return a, nil
+}
+
+// buildEndpoint converts one operation
+func buildEndpoint(path, method string, op *operation) (endpoint, error) {
+	e := endpoint{
+		ID:          op.OperationID,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Auth:        len(op.Security) > 0,
+	}
+
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			e.PathParams = append(e.PathParams, param{Name: p.Name, Type: &typeRef{Kind: "string"}, Required: true, Description: p.Description})
+		case "query":
+			if nonJSONParams[p.Name] {
+				continue
+			}
+			e.Query = append(e.Query, param{Name: p.Name, Type: &typeRef{Kind: scalarKind(p.Type)}, Required: p.Required, Description: p.Description})
+		case "body":
+			t, err := resolve(p.Schema)
+			if err != nil {
+				return e, fmt.Errorf("body: %w", err)
+			}
+			e.Body = t
+		case "header":
+			// The tenant header is a client option
+		}
+	}
+	sort.SliceStable(e.Query, func(i, j int) bool { return e.Query[i].Required && !e.Query[j].Required })
+
+	for _, status := range []string{"200", "201"} {
+		resp, ok := op.Responses[status]
+		if !ok || resp.Schema == nil {
+			continue
+		}
+
+		s := resp.Schema
+		switch {
+		case len(s.AllOf) == 2 && s.AllOf[0].Ref == envelopeRef:
+			t, err := resolve(s.AllOf[1].Properties["data"])
+			if err != nil {
+				return e, fmt.Errorf("response: %w", err)
+			}
+			e.Result, e.Enveloped = t, true
+		case s.Ref == envelopeRef:
+			e.Enveloped = true
+		default:
+			t, err := resolve(s)
+			if err != nil {
+				return e, fmt.Errorf("response: %w", err)
+			}
+			e.Result = t
+		}
+		break
+	}
+
+	return e, nil
+}
+
+// resolve converts a schema to a type
+func resolve(s *schema) (*typeRef, error) {
+	if s == nil {
+		return &typeRef{Kind: "any"}, nil
+	}
+	if s.Ref != "" {
+		return &typeRef{Kind: "ref", Ref: modelName(strings.TrimPrefix(s.Ref, "#/definitions/"))}, nil
+	}
+	// swag wraps references that carry a description in allOf
+	if len(s.AllOf) == 1 {
+		return resolve(s.AllOf[0])
+	}
+
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+		return &typeRef{Kind: s.Type}, nil
+	case "array":
+		elem, err := resolve(s.Items)
+		if err != nil {
+			return nil, err
+		}
+		return &typeRef{Kind: "array", Elem: elem}, nil
+	case "object", "":
+		if len(s.AdditionalProperties) == 0 || string(s.AdditionalProperties) == "true" {
+			if s.Type == "" || len(s.AdditionalProperties) == 0 {
+				return &typeRef{Kind: "any"}, nil
+			}
+			return &typeRef{Kind: "map", Elem: &typeRef{Kind: "any"}}, nil
+		}
+		var values schema
+		if err := json.Unmarshal(s.AdditionalProperties, &values); err != nil {
+			return nil, fmt.Errorf("invalid additionalProperties: %w", err)
+		}
+		elem, err := resolve(&values)
+		if err != nil {
+			return nil, err
+		}
+		return &typeRef{Kind: "map", Elem: elem}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// scalarKind maps a query parameter type to a type kind
+func scalarKind(swaggerType string) string {
+	switch swaggerType {
+	case "integer", "number", "boolean":
+		return swaggerType
+	}
+	return "string"
+}
+
+// producesJSON reports whether an operation answers with JSON; operations
+// that list nothing default to JSON
+func producesJSON(op *operation) bool {
+	return len(op.Produces) == 0 || slices.Contains(op.Produces, "application/json")
+}
+
+// description returns a schema's description, or that of the type it wraps
+func description(s *schema) string {
+	if s.Description == "" && len(s.AllOf) == 1 {
+		return s.AllOf[0].Description
+	}
+	return s.Description
+}
+
+// modelName drops the Go package from a definition name, e.g. types.Story
+// becomes Story
+func modelName(definition string) string {
+	if i := strings.LastIndex(definition, "."); i >= 0 {
+		return definition[i+1:]
+	}
+	return definition
+}
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]string{
+	"id": "ID", "ids": "IDs", "url": "URL", "ip": "IP", "api": "API",
+	"html": "HTML", "json": "JSON", "ttl": "TTL", "jwt": "JWT",
+}
+
+// pascal converts snake_case or camelCase to a Go exported name, e.g.
+// user_id becomes UserID
+func pascal(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		if upper, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// camel converts a name to lower camelCase, e.g. user_id becomes userId, or
+// userID in Go
+func camel(name string, goStyle bool) string {
+	parts := words(name)
+	var b strings.Builder
+	for i, word := range parts {
+		switch {
+		case i == 0:
+			b.WriteString(strings.ToLower(word))
+		case goStyle && initialisms[strings.ToLower(word)] != "":
+			b.WriteString(initialisms[strings.ToLower(word)])
+		default:
+			b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+		}
+	}
+	return b.String()
+}
+
+// words splits a name on underscores, dashes and lower-to-upper case changes
+func words(name string) []string {
+	var parts []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-':
+			if len(current) > 0 {
+				parts = append(parts, string(current))
+			}
+			current = nil
+			continue
+		case i > 0 && isUpper(r) && !isUpper(runes[i-1]) && len(current) > 0:
+			parts = append(parts, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		parts = append(parts, string(current))
+	}
+	return parts
+}
+
+func isUpper(r rune) bool {
+	return r >= 'A' && r <= 'Z'
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
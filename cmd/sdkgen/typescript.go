@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tsRuntime is the fixed part of the TypeScript client: its options, error
+// type and transport. It uses the generated FieldError model.
+const tsRuntime = `/** Thrown for responses with a non-2xx status */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    message: string,
+    readonly errors: FieldError[] = [],
+  ) {
+    super(` + "`stories API returned ${status}: ${message}`" + `);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  /** Where the API is served, e.g. "http://localhost:8080" */
+  baseUrl: string;
+  /** Bearer token sent with every request, as returned by login */
+  token?: string;
+  /** Tenant every request is made in instead of the default one */
+  tenantId?: string;
+  /** fetch implementation, defaults to the global one */
+  fetch?: typeof fetch;
+}
+
+type Query = Record<string, string | number | boolean | undefined>;
+
+/** Calls the Stories Service API */
+export class StoriesClient {
+  private readonly options: ClientOptions;
+
+  constructor(options: ClientOptions) {
+    this.options = { ...options, baseUrl: options.baseUrl.replace(/\/+$/, "") };
+  }
+
+  /** Returns a copy of the client that authenticates with token */
+  withToken(token: string): StoriesClient {
+    return new StoriesClient({ ...this.options, token });
+  }
+
+  private async request<T>(method: string, path: string, enveloped: boolean, query?: Query, body?: unknown): Promise<T> {
+    let url = this.options.baseUrl + path;
+    if (query) {
+      const params = new URLSearchParams();
+      for (const [key, value] of Object.entries(query)) {
+        if (value !== undefined) {
+          params.set(key, String(value));
+        }
+      }
+      const encoded = params.toString();
+      if (encoded) {
+        url += "?" + encoded;
+      }
+    }
+
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    if (this.options.token) {
+      headers["Authorization"] = "Bearer " + this.options.token;
+    }
+    if (this.options.tenantId) {
+      headers["X-Tenant-ID"] = this.options.tenantId;
+    }
+
+    const doFetch = this.options.fetch ?? fetch;
+    const resp = await doFetch(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    const payload = await resp.json().catch(() => undefined);
+    if (!resp.ok) {
+      throw new ApiError(resp.status, payload?.error || resp.statusText, payload?.errors ?? []);
+    }
+    return (enveloped ? payload?.data : payload) as T;
+  }
+`
+
+// renderTypeScript renders src/index.ts of the TypeScript client
+func renderTypeScript(a *api) []byte {
+	var w bytes.Buffer
+	w.WriteString("// Code generated by cmd/sdkgen from docs/swagger.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&w, "/** The API version the client was generated for */\nexport const VERSION = %q;\n\n", a.Version)
+
+	for _, m := range a.Models {
+		writeTSModel(&w, m)
+	}
+
+	w.WriteString(tsRuntime)
+	for _, e := range a.Operations {
+		w.WriteString("\n")
+		writeTSEndpoint(&w, e)
+	}
+	w.WriteString("}\n")
+
+	return w.Bytes()
+}
+
+// writeTSModel writes a model as an interface, or as a union of string
+// literals for an enum
+func writeTSModel(w *bytes.Buffer, m model) {
+	if m.Description != "" {
+		writeJSDoc(w, "", m.Description)
+	}
+
+	if len(m.Enum) > 0 {
+		values := make([]string, len(m.Enum))
+		for i, v := range m.Enum {
+			values[i] = tsString(v.Value)
+		}
+		fmt.Fprintf(w, "export type %s = %s;\n\n", m.Name, strings.Join(values, " | "))
+		return
+	}
+
+	fmt.Fprintf(w, "export interface %s {\n", m.Name)
+	for _, f := range m.Fields {
+		if f.Description != "" {
+			writeJSDoc(w, "  ", f.Description)
+		}
+		optional := "?"
+		if f.Required {
+			optional = ""
+		}
+		fmt.Fprintf(w, "  %s%s: %s;\n", f.JSONName, optional, tsType(f.Type))
+	}
+	w.WriteString("}\n\n")
+}
+
+// writeTSEndpoint writes an operation as a StoriesClient method. Optional
+// query parameters are passed in a trailing options object.
+func writeTSEndpoint(w *bytes.Buffer, e endpoint) {
+	doc := e.Summary
+	if e.Description != "" && e.Description != e.Summary {
+		doc += ". " + e.Description
+	}
+	writeJSDoc(w, "  ", fmt.Sprintf("%s %s: %s", e.Method, e.Path, doc))
+
+	var args, query, optional []string
+	for _, p := range e.PathParams {
+		args = append(args, camel(p.Name, false)+": string")
+	}
+	for _, p := range e.Query {
+		name := camel(p.Name, false)
+		if p.Required {
+			args = append(args, name+": "+tsType(p.Type))
+			query = append(query, fmt.Sprintf("%s: %s", tsKey(p.Name), name))
+			continue
+		}
+		optional = append(optional, fmt.Sprintf("%s?: %s", name, tsType(p.Type)))
+		query = append(query, fmt.Sprintf("%s: options.%s", tsKey(p.Name), name))
+	}
+	if e.Body != nil {
+		args = append(args, "body: "+tsType(e.Body))
+	}
+	if len(optional) > 0 {
+		args = append(args, "options: { "+strings.Join(optional, "; ")+" } = {}")
+	}
+
+	result := "void"
+	if e.Result != nil {
+		result = tsType(e.Result)
+	}
+
+	path := pathParamPattern.ReplaceAllStringFunc(e.Path, func(placeholder string) string {
+		return "${encodeURIComponent(" + camel(strings.Trim(placeholder, "{}"), false) + ")}"
+	})
+
+	callArgs := []string{tsString(e.Method), "`" + path + "`", fmt.Sprint(e.Enveloped)}
+	if len(query) > 0 || e.Body != nil {
+		queryArg := "undefined"
+		if len(query) > 0 {
+			queryArg = "{ " + strings.Join(query, ", ") + " }"
+		}
+		callArgs = append(callArgs, queryArg)
+	}
+	if e.Body != nil {
+		callArgs = append(callArgs, "body")
+	}
+
+	fmt.Fprintf(w, "  %s(%s): Promise<%s> {\n", e.ID, strings.Join(args, ", "), result)
+	fmt.Fprintf(w, "    return this.request<%s>(%s);\n", result, strings.Join(callArgs, ", "))
+	w.WriteString("  }\n")
+}
+
+// tsType renders a type as a TypeScript type expression
+func tsType(t *typeRef) string {
+	switch t.Kind {
+	case "string", "number", "boolean":
+		return t.Kind
+	case "integer":
+		return "number"
+	case "ref":
+		return t.Ref
+	case "array":
+		elem := tsType(t.Elem)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case "map":
+		return "Record<string, " + tsType(t.Elem) + ">"
+	}
+	return "unknown"
+}
+
+// tsKey renders a property name, quoting it unless it is an identifier
+func tsKey(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || isUpper(r) || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9')) {
+			return tsString(name)
+		}
+	}
+	return name
+}
+
+// tsString renders a string literal
+func tsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// writeJSDoc writes text as a JSDoc comment, on one line if it fits
+func writeJSDoc(w *bytes.Buffer, indent, text string) {
+	lines := wrap(text, 80-len(indent)-3)
+	if len(lines) == 1 && len(indent)+len(lines[0]) <= 74 {
+		fmt.Fprintf(w, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(w, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(w, "%s */\n", indent)
+}
+
+// renderPackageJSON renders package.json of the TypeScript client, versioned
+// like the API
+func renderPackageJSON(a *api) []byte {
+	pkg := map[string]any{
+		"name":        "@stories-service/client",
+		"version":     a.Version,
+		"description": "TypeScript client for the " + a.Title + ", generated by cmd/sdkgen",
+		"type":        "module",
+		"main":        "dist/index.js",
+		"types":       "dist/index.d.ts",
+		"files":       []string{"dist"},
+		"scripts": map[string]string{
+			"build":          "tsc",
+			"prepublishOnly": "tsc",
+		},
+		"devDependencies": map[string]string{
+			"typescript": "^5.4.0",
+		},
+	}
+
+	data, _ := json.MarshalIndent(pkg, "", "  ")
+	return append(data, '\n')
+}
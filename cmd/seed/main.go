@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/password"
+)
+
+// demoNames are the demo users, in the order they are created
+var demoNames = []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"}
+
+// demoReactions are handed out round-robin when demo users react to stories
+var demoReactions = []types.ReactionType{
+	types.ReactionThumbsUp,
+	types.ReactionHeart,
+	types.ReactionLaugh,
+	types.ReactionSurprised,
+	types.ReactionSad,
+	types.ReactionFire,
+}
+
+// demoUser is a seeded account
+type demoUser struct {
+	Name  string
+	Email string
+	ID    string
+}
+
+// Seeder fills the configured database with demo users, follows, stories,
+// views and reactions. Running it again reuses what is already there, adding
+// only the stories that have expired since.
+type Seeder struct {
+	storage  storage.Storage
+	tenantID string
+	password string
+	domain   string
+}
+
+// NewSeeder creates a seeder whose users all join tenantID and share the given password
+func NewSeeder(storage storage.Storage, tenantID, password, domain string) *Seeder {
+	return &Seeder{
+		storage:  storage,
+		tenantID: tenantID,
+		password: password,
+		domain:   domain,
+	}
+}
+
+// Run seeds count demo users and their activity and returns the users
+func (s *Seeder) Run(count int) ([]demoUser, error) {
+	users, err := s.seedUsers(count)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.seedFollows(users); err != nil {
+		return nil, err
+	}
+
+	storyIDs, err := s.seedStories(users)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.seedActivity(users, storyIDs); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// seedUsers creates the demo users, reusing any that already exist so the
+// seeder can be run repeatedly
+func (s *Seeder) seedUsers(count int) ([]demoUser, error) {
+	hashedPassword, err := password.HashPassword(s.password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	users := make([]demoUser, 0, count)
+	for i := 0; i < count; i++ {
+		name := demoNames[i]
+		email := fmt.Sprintf("%s@%s", name, s.domain)
+
+		userID, _, err := s.storage.GetUserByEmail(s.tenantID, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			userID, err = s.storage.CreateUser(s.tenantID, email, hashedPassword)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed user %s: %w", email, err)
+		}
+
+		users = append(users, demoUser{Name: name, Email: email, ID: userID})
+	}
+
+	slog.Info("Seeded users", slog.Int("count", len(users)))
+	return users, nil
+}
+
+// seedFollows has every user follow the next two users, so neighbours are
+// mutuals with the user in between and everyone has followers
+func (s *Seeder) seedFollows(users []demoUser) error {
+	follows := 0
+	for i, user := range users {
+		for offset := 1; offset <= 2 && offset < len(users); offset++ {
+			followed := users[(i+offset)%len(users)]
+			if _, err := s.storage.FollowUser(user.ID, followed.ID); err != nil {
+				return fmt.Errorf("failed to follow %s -> %s: %w", user.Name, followed.Name, err)
+			}
+			follows++
+		}
+	}
+
+	slog.Info("Seeded follows", slog.Int("count", follows))
+	return nil
+}
+
+// seedStories posts one story per visibility for every user, reusing the
+// live ones an earlier run posted. Private stories are shared with the user's
+// first follower.
+func (s *Seeder) seedStories(users []demoUser) ([]string, error) {
+	var storyIDs []string
+	created := 0
+	for i, user := range users {
+		follower := users[(i+len(users)-1)%len(users)]
+
+		existing, err := s.liveStories(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stories of %s: %w", user.Name, err)
+		}
+
+		stories := []types.StoryPostRequest{
+			{
+				Text:       fmt.Sprintf("Hello world from %s!", user.Name),
+				Visibility: types.VisibilityPublic,
+			},
+			{
+				Text:       fmt.Sprintf("%s's story for followers", user.Name),
+				Visibility: types.VisibilityFollowers,
+			},
+			{
+				Text:       fmt.Sprintf("%s's story for friends", user.Name),
+				Visibility: types.VisibilityFriends,
+			},
+			{
+				Text:            fmt.Sprintf("A secret from %s to %s", user.Name, follower.Name),
+				Visibility:      types.VisibilityPrivate,
+				AudienceUserIDs: []string{follower.ID},
+			},
+		}
+
+		for _, story := range stories {
+			storyID, ok := existing[story.Text]
+			if !ok {
+				storyID, err = s.storage.CreateStory(user.ID, story)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create %s story for %s: %w", story.Visibility, user.Name, err)
+				}
+				created++
+			}
+			storyIDs = append(storyIDs, storyID)
+		}
+	}
+
+	slog.Info("Seeded stories", slog.Int("count", len(storyIDs)), slog.Int("created", created))
+	return storyIDs, nil
+}
+
+// liveStories returns the IDs of the stories the user has posted that are
+// still live, by text
+func (s *Seeder) liveStories(userID string) (map[string]string, error) {
+	stories, err := s.storage.GetStoriesForUser(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]string)
+	for _, story := range stories {
+		if story.AuthorID == userID {
+			live[story.Text] = story.ID
+		}
+	}
+	return live, nil
+}
+
+// seedActivity has every user view the stories they can see and react to
+// every other one, unless they wrote it and self-reactions are not allowed
+func (s *Seeder) seedActivity(users []demoUser, storyIDs []string) error {
+	views, reactions := 0, 0
+	for _, user := range users {
+		for i, storyID := range storyIDs {
+			canView, err := s.storage.CanUserViewStory(storyID, user.ID)
+			if err != nil {
+				return fmt.Errorf("failed to check story %s for %s: %w", storyID, user.Name, err)
+			}
+			if !canView {
+				continue
+			}
+
+			if err := s.storage.RecordStoryView(storyID, user.ID); err != nil {
+				return fmt.Errorf("failed to view story %s as %s: %w", storyID, user.Name, err)
+			}
+			views++
+
+			if i%2 == 0 {
+				emoji := demoReactions[(i+views)%len(demoReactions)]
+				_, err := s.storage.AddReaction(storyID, user.ID, emoji)
+				if errors.Is(err, storage.ErrSelfReaction) {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("failed to react to story %s as %s: %w", storyID, user.Name, err)
+				}
+				reactions++
+			}
+		}
+	}
+
+	slog.Info("Seeded activity", slog.Int("views", views), slog.Int("reactions", reactions))
+	return nil
+}
+
+func main() {
+	// Flags are parsed together with -config by config.MustLoad
+	userCount := flag.Int("users", 6, fmt.Sprintf("Number of demo users to create (max %d)", len(demoNames)))
+	demoPassword := flag.String("password", "password123", "Password for every demo user")
+	domain := flag.String("domain", "example.com", "Email domain for demo users")
+	tenantID := flag.String("tenant", tenant.Default, "Tenant the demo users join")
+
+	// Load config
+	cfg := config.MustLoad()
+
+	// MustLoad skips flag parsing when CONFIG_PATH is set
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	if *userCount < 2 || *userCount > len(demoNames) {
+		log.Fatalf("-users must be between 2 and %d", len(demoNames))
+	}
+	if !tenant.Valid(*tenantID) {
+		log.Fatalf("invalid -tenant %q", *tenantID)
+	}
+
+	// Initialize database connection
+	storage, err := postgres.NewPostgres(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	slog.Info("Connected to Postgres database")
+
+	users, err := NewSeeder(storage, *tenantID, *demoPassword, *domain).Run(*userCount)
+	if err != nil {
+		log.Fatal("Failed to seed database:", err)
+	}
+
+	fmt.Println("Seeded demo users (password: " + *demoPassword + "):")
+	for _, user := range users {
+		fmt.Printf("  %-6s id=%-4s %s\n", user.Name, user.ID, user.Email)
+	}
+}
@@ -0,0 +1,56 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+)
+
+func TestSeederIntegration(t *testing.T) {
+	store := testutil.StartPostgres(t, testutil.NewConfig())
+	seeder := NewSeeder(store, tenant.Default, "password123", "seed.example.com")
+
+	// run seeds the demo data and returns the users and the IDs of the
+	// stories each has posted
+	run := func() ([]demoUser, map[string][]string) {
+		t.Helper()
+		users, err := seeder.Run(4)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		stories := make(map[string][]string)
+		for _, user := range users {
+			feed, err := store.GetStoriesForUser(context.Background(), user.ID)
+			if err != nil {
+				t.Fatalf("GetStoriesForUser failed: %v", err)
+			}
+			for _, story := range feed {
+				if story.AuthorID == user.ID {
+					stories[user.ID] = append(stories[user.ID], story.ID)
+				}
+			}
+		}
+		return users, stories
+	}
+
+	firstUsers, firstStories := run()
+	for _, user := range firstUsers {
+		if len(firstStories[user.ID]) != 4 {
+			t.Errorf("Expected %s to have 4 stories, got %d", user.Name, len(firstStories[user.ID]))
+		}
+	}
+
+	// Running again reuses the users and stories instead of adding more
+	secondUsers, secondStories := run()
+	if !reflect.DeepEqual(firstUsers, secondUsers) {
+		t.Errorf("Expected the same users, got %+v then %+v", firstUsers, secondUsers)
+	}
+	if !reflect.DeepEqual(firstStories, secondStories) {
+		t.Errorf("Expected the same stories, got %v then %v", firstStories, secondStories)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
@@ -12,23 +13,21 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/princekumarofficial/stories-service/docs"
-	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/princekumarofficial/stories-service/internal/cache"
 	"github.com/princekumarofficial/stories-service/internal/config"
 	"github.com/princekumarofficial/stories-service/internal/events"
-	"github.com/princekumarofficial/stories-service/internal/http/handlers/media"
-	"github.com/princekumarofficial/stories-service/internal/http/handlers/stories"
-	"github.com/princekumarofficial/stories-service/internal/http/handlers/users"
-	wsHandler "github.com/princekumarofficial/stories-service/internal/http/handlers/websocket"
-	"github.com/princekumarofficial/stories-service/internal/http/middleware"
-	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/http/router"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/startup"
 	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
 	"github.com/princekumarofficial/stories-service/internal/websocket"
+	"github.com/princekumarofficial/stories-service/internal/wsticket"
 )
 
 // @title Stories Service API
-// @version 1.0
+// @version 1.0.0
 // @description A simple stories service API
 // @BasePath /
 // @securityDefinitions.apikey BearerAuth
@@ -37,136 +36,197 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	// `stories-service migrate` only migrates the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		if err := migrate(config.MustLoad()); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		return
+	}
+
 	// load config
 	cfg := config.MustLoad()
 
+	// Log feed queries slower than the configured threshold
+	metrics.SetSlowQueryThreshold(time.Duration(cfg.Metrics.SlowQueryThreshold) * time.Millisecond)
+
+	// Stop on a shutdown signal, including while still connecting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The gate serves /health and /ready from the start and everything else
+	// once the router is up
+	gate := startup.NewGate(startup.PolicyFromConfig(cfg.Startup))
+	server := http.Server{
+		Addr:    cfg.HTTPServer.Address,
+		Handler: gate,
+	}
+
+	// Serve until the server fails or a shutdown signal arrives, so the
+	// cleanup below runs either way
+	serverErr := make(chan error, 1)
+	serve := func() {
+		log.Println("server started on", cfg.HTTPServer.Address)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	// In degraded mode, answer health checks while dependencies come up
+	if cfg.Startup.Degraded {
+		serve()
+	}
+
+	// Retry each dependency until it is ready or attempts run out
+	connect := func(name string, fn func(context.Context) error) {
+		if err := gate.Connect(ctx, name, fn); err != nil {
+			slog.Error("Failed to connect", slog.String("dependency", name), slog.String("error", err.Error()))
+			server.Close()
+			os.Exit(1)
+		}
+		slog.Info("Connected", slog.String("dependency", name))
+	}
+
 	// Initialize Redis client
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.Redis.Address,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
 	})
-
-	// Test Redis connection
-	ctx := context.Background()
-	_, err := redisClient.Ping(ctx).Result()
-	if err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
-	}
-	slog.Info("Connected to Redis")
+	redisKeys := cache.NewKeys(cfg.Redis.KeyPrefix)
+	connect("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
 
 	// database setup
-	storage, err := postgres.NewPostgres(cfg)
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
-	}
-	slog.Info("Connected to Postgres database")
+	var storage *postgres.Postgres
+	connect("postgres", func(context.Context) error {
+		var err error
+		storage, err = postgres.NewPostgres(cfg)
+		return err
+	})
 
 	// Initialize media service
-	mediaService, err := mediaService.NewService(cfg)
-	if err != nil {
-		log.Fatal("Failed to initialize media service:", err)
-	}
-	slog.Info("Connected to MinIO")
+	var mediaService *media.Service
+	connect("minio", func(context.Context) error {
+		var err error
+		mediaService, err = media.NewService(cfg)
+		return err
+	})
 
 	// Initialize WebSocket hub
-	hub := websocket.NewHub()
+	hub := websocket.NewHub().
+		WithConnectionLimits(cfg.WebSocket.MaxConnectionsPerUser, cfg.WebSocket.MaxConnectionsPerIP).
+		WithSlowConsumerTimeout(time.Duration(cfg.WebSocket.SlowConsumerTimeout) * time.Second)
 	go hub.Run()
 	slog.Info("WebSocket hub started")
 
-	// Initialize event publisher
-	eventPublisher := events.NewEventPublisher(hub)
-
-	// Initialize handlers
-	mediaHandlers := media.NewMediaHandlers(mediaService)
-
-	// Initialize rate limiting
-	rateLimitConfig := middleware.NewRateLimitConfig(redisClient)
-
-	// Initialize caching layer
-	cacheService := cache.NewCacheService(storage, redisClient)
-	optimizedQuery := cache.NewOptimizedFeedQuery(storage.GetDB())
-	slog.Info("Cache service initialized")
-
-	// setup server
-	router := http.NewServeMux()
+	// Cache in front of the database for the background components; the
+	// router builds its own for requests
+	cacheService := cache.NewCacheService(storage, redisClient, redisKeys, cache.TTLsFromConfig(cfg.Cache))
 
-	// Create auth middleware
-	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret)
-
-	router.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Hello, World!"))
-	})
-
-	// WebSocket route
-	router.HandleFunc("GET /ws", wsHandler.WebSocketHandler(hub, cfg.JWTSecret))
-
-	// Protected routes with rate limiting
-	router.Handle("POST /stories", authMiddleware(rateLimitConfig.RateLimitedHandler("stories", stories.PostStory(cacheService))))
-	router.Handle("GET /stories/{id}", authMiddleware(http.HandlerFunc(stories.GetStory(cacheService))))
-	router.Handle("GET /feed", authMiddleware(http.HandlerFunc(stories.CachedFeed(cacheService))))
-	router.Handle("GET /feed/optimized", authMiddleware(http.HandlerFunc(stories.OptimizedFeed(cacheService, optimizedQuery))))
-	router.Handle("POST /stories/{id}/view", authMiddleware(http.HandlerFunc(stories.ViewStoryWithEvents(cacheService, eventPublisher))))
-	router.Handle("POST /stories/{id}/reactions", authMiddleware(rateLimitConfig.RateLimitedHandler("reactions", stories.AddReactionWithEvents(cacheService, eventPublisher))))
-	router.Handle("GET /me/stats", authMiddleware(http.HandlerFunc(users.GetStats(cacheService))))
-
-	// Follow/Unfollow routes
-	router.Handle("POST /follow/{user_id}", authMiddleware(http.HandlerFunc(users.FollowUser(cacheService))))
-	router.Handle("DELETE /follow/{user_id}", authMiddleware(http.HandlerFunc(users.UnfollowUser(cacheService))))
-
-	// Media routes (protected)
-	router.Handle("POST /media/upload-url", authMiddleware(http.HandlerFunc(mediaHandlers.GenerateUploadURL())))
-	router.Handle("GET /media", authMiddleware(http.HandlerFunc(mediaHandlers.ListUserMedia())))
-	router.Handle("GET /media/{object_key}/info", authMiddleware(http.HandlerFunc(mediaHandlers.GetMediaInfo())))
-	router.Handle("GET /media/{object_key}/download-url", authMiddleware(http.HandlerFunc(mediaHandlers.GenerateDownloadURL())))
-	router.Handle("DELETE /media/{object_key}", authMiddleware(http.HandlerFunc(mediaHandlers.DeleteMedia())))
-
-	// Public routes
-	router.Handle("POST /signup", http.HandlerFunc(users.SignUp(storage)))
-	router.Handle("POST /login", http.HandlerFunc(users.Login(storage, cfg.JWTSecret)))
-
-	// Cache monitoring endpoints (for development/admin)
-	router.Handle("GET /cache/stats", http.HandlerFunc(cache.GetCacheStats(redisClient)))
-	router.Handle("DELETE /cache/clear", http.HandlerFunc(cache.ClearCache(redisClient)))
+	// Initialize event publisher, holding notifications back during quiet hours
+	// and keeping hidden view receipts quiet
+	eventPublisher := events.NewEventPublisher(hub).WithQuietHours(cacheService).WithViewReceipts(storage)
+	if cfg.WebSocket.BatchReactions {
+		eventPublisher.WithReactionBatching(time.Duration(cfg.WebSocket.ReactionBatchWindow) * time.Millisecond)
+	}
 
-	// Documentation
-	router.Handle("GET /docs/", httpSwagger.WrapHandler)
+	// Send digests of the notifications queued during quiet hours to connected
+	// users once those end
+	digester := events.NewDigester(cacheService, eventPublisher, hub, time.Minute)
+	digestCtx, stopDigester := context.WithCancel(context.Background())
+	defer stopDigester()
+	go digester.Start(digestCtx)
+
+	// Forward events relayed from other processes (e.g. the ephemeral worker)
+	eventRelay := events.NewRedisRelay(redisClient, redisKeys)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	go eventRelay.Subscribe(relayCtx, hub)
+
+	// Initialize WebSocket connection tickets
+	ticketIssuer := wsticket.NewIssuer(redisClient, redisKeys, cfg.JWTSecret, time.Duration(cfg.WebSocket.TicketTTL)*time.Second)
+
+	// Warm followees and feeds of users logging in or connecting
+	warmer := cache.NewWarmer(cacheService)
+	warmCtx, stopWarmer := context.WithCancel(context.Background())
+	defer stopWarmer()
+	go warmer.Start(warmCtx)
+
+	// Stream live service metrics to admins subscribed to the ops topic
+	var opsReporter *websocket.OpsReporter
+	opsCtx, stopOps := context.WithCancel(context.Background())
+	defer stopOps()
+	if cfg.WebSocket.OpsInterval > 0 {
+		opsReporter = websocket.NewOpsReporter(hub, time.Duration(cfg.WebSocket.OpsInterval)*time.Second).WithAdmins(storage)
+		go opsReporter.Start(opsCtx)
+	}
 
 	// setup router
+	handler := router.New(router.Dependencies{
+		Config:       cfg,
+		Storage:      storage,
+		Redis:        redisClient,
+		Media:        mediaService,
+		Hub:          hub,
+		Publisher:    eventPublisher,
+		TicketIssuer: ticketIssuer,
+		Warmer:       warmer,
+		Ops:          opsReporter,
+		Digester:     digester,
+	})
 
-	server := http.Server{
-		Addr:    cfg.HTTPServer.Address,
-		Handler: router,
+	gate.Open(handler)
+	if !cfg.Startup.Degraded {
+		serve()
 	}
 
-	log.Println("server started on", cfg.HTTPServer.Address)
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down server...")
+	case err := <-serverErr:
+		slog.Error("server failed", slog.String("error", err.Error()))
+		exitCode = 1
+	}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	go func() {
-		err := server.ListenAndServe()
-		if err != nil {
-			log.Fatalf("failed to start server: %s", err)
-		}
-	}()
+	// Stop accepting requests first, then tear down what handlers depend on
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to gracefully shutdown server", slog.String("error", err.Error()))
+		exitCode = 1
+	}
 
-	<-done
+	stopRelay()
+	stopWarmer()
+	stopOps()
+	stopDigester()
+	if err := eventPublisher.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to deliver pending events", slog.String("error", err.Error()))
+	}
+	if err := hub.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to shutdown WebSocket hub", slog.String("error", err.Error()))
+		exitCode = 1
+	}
 
-	slog.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if err := storage.Close(); err != nil {
+		slog.Error("failed to close database connection", slog.String("error", err.Error()))
+		exitCode = 1
+	}
 
-	// Close Redis connection
 	if err := redisClient.Close(); err != nil {
 		slog.Error("failed to close Redis connection", slog.String("error", err.Error()))
-	}
-
-	err = server.Shutdown(ctx)
-	if err != nil {
-		slog.Error("failed to gracefully shutdown server", slog.String("error", err.Error()))
-		return
+		exitCode = 1
 	}
 
 	slog.Info("Server stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/startup"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+)
+
+// migrate creates and updates the database schema, so deployments with
+// pgsql.auto_migrate off can run schema changes once, before starting any API
+// instance
+func migrate(cfg *config.Config) error {
+	// Connect without migrating, then migrate explicitly
+	connectCfg := *cfg
+	connectCfg.PGSQL.AutoMigrate = false
+
+	var storage *postgres.Postgres
+	err := startup.PolicyFromConfig(cfg.Startup).Retry(context.Background(), "postgres", func(context.Context) error {
+		var err error
+		storage, err = postgres.NewPostgres(&connectCfg)
+		return err
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	started := time.Now()
+	if err := storage.CreateTables(); err != nil {
+		return err
+	}
+	slog.Info("Database schema migrated", slog.Duration("duration", time.Since(started)))
+	return nil
+}
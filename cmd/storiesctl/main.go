@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/experiments"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/utils/password"
+)
+
+// command is a storiesctl subcommand
+type command struct {
+	name  string
+	usage string
+	run   func(app *App, args []string) error
+}
+
+var commands = []command{
+	{"create-admin", "[-tenant ID] -email EMAIL [-password PASSWORD]  create an admin user, or promote an existing one", (*App).createAdmin},
+	{"revoke-tokens", "-user ID | -jti ID                              revoke all tokens of a user, or a single token", (*App).revokeTokens},
+	{"expire-story", "STORY_ID                                        expire a story immediately", (*App).expireStory},
+	{"clear-cache", "[-tenant ID] [-user ID] [KEY...]                delete cache keys, or every cache entry of a user", (*App).clearCache},
+	{"dump-user", "-user ID | [-tenant ID] -email EMAIL            print a user's account, graph, stats and stories as JSON", (*App).dumpUser},
+	{"experiment", "NAME [-enabled] [-treatment N] [-salt S]        show or change an experiment on every instance; -reset restores the config", (*App).experiment},
+}
+
+// App holds the stores the admin commands operate on
+type App struct {
+	storage     *cache.CacheService
+	redis       *redis.Client
+	keys        cache.Keys
+	revocations *revocation.Store
+	experiments *experiments.Assigner
+	tokenTTL    time.Duration
+}
+
+// userDump is everything dump-user prints about a user
+type userDump struct {
+	User      users.User      `json:"user"`
+	Stats     users.UserStats `json:"stats"`
+	Followees []string        `json:"followees"`
+	Followers []string        `json:"followers"`
+	Stories   []types.Story   `json:"stories"`
+}
+
+// createAdmin creates an admin user, or grants admin rights to an existing one
+func (a *App) createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	tenantID := fs.String("tenant", tenant.Default, "Tenant of the admin user")
+	email := fs.String("email", "", "Email of the admin user")
+	plainPassword := fs.String("password", "", "Password, required when the user does not exist yet")
+	fs.Parse(args)
+
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+	if !tenant.Valid(*tenantID) {
+		return fmt.Errorf("invalid -tenant %q", *tenantID)
+	}
+
+	userID, _, err := a.storage.GetUserByEmail(*tenantID, *email)
+	if errors.Is(err, sql.ErrNoRows) {
+		if len(*plainPassword) < 6 {
+			return fmt.Errorf("-password of at least 6 characters is required to create a new user")
+		}
+
+		hashedPassword, err := password.HashPassword(*plainPassword)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		userID, err = a.storage.CreateUser(*tenantID, *email, hashedPassword)
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		fmt.Printf("Created user %s (id=%s)\n", *email, userID)
+	} else if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	if err := a.storage.SetUserAdmin(userID, true); err != nil {
+		return fmt.Errorf("failed to grant admin rights: %w", err)
+	}
+
+	// The admin scope is granted at login
+	fmt.Printf("User %s (id=%s) is now an admin; they must log in again to use admin routes\n", *email, userID)
+	return nil
+}
+
+// revokeTokens revokes every token issued to a user so far, or a single token by ID
+func (a *App) revokeTokens(args []string) error {
+	fs := flag.NewFlagSet("revoke-tokens", flag.ExitOnError)
+	userID := fs.String("user", "", "Revoke every token issued to this user ID")
+	tokenID := fs.String("jti", "", "Revoke the token with this ID")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	switch {
+	case *userID != "" && *tokenID == "":
+		if err := a.revocations.RevokeUser(ctx, *userID); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked all tokens issued to user %s\n", *userID)
+	case *tokenID != "" && *userID == "":
+		// The token's expiry is unknown here, so deny it for the longest a token can live
+		if err := a.revocations.RevokeToken(ctx, *tokenID, time.Now().Add(a.tokenTTL)); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked token %s\n", *tokenID)
+	default:
+		return fmt.Errorf("exactly one of -user or -jti is required")
+	}
+
+	return nil
+}
+
+// expireStory expires a story immediately and drops it from the caches
+func (a *App) expireStory(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one story ID")
+	}
+
+	story, err := a.storage.ExpireStory(args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("story %s not found or already expired", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to expire story: %w", err)
+	}
+
+	fmt.Printf("Expired story %s by user %s\n", story.ID, story.AuthorID)
+	return nil
+}
+
+// clearCache deletes the given cache keys, named without the environment and
+// tenant prefixes, and, with -user, the user's cached followees, feed and stats
+func (a *App) clearCache(args []string) error {
+	fs := flag.NewFlagSet("clear-cache", flag.ExitOnError)
+	tenantID := fs.String("tenant", tenant.Default, "Tenant whose cache keys are cleared")
+	userID := fs.String("user", "", "Clear every cache entry of this user ID")
+	fs.Parse(args)
+
+	if !tenant.Valid(*tenantID) {
+		return fmt.Errorf("invalid -tenant %q", *tenantID)
+	}
+
+	keys := fs.Args()
+	if *userID == "" && len(keys) == 0 {
+		return fmt.Errorf("either -user or at least one key is required")
+	}
+
+	ctx := context.Background()
+	if *userID != "" {
+		a.storage.ForTenant(*tenantID).InvalidateUserCache(ctx, *userID)
+		fmt.Printf("Cleared cache entries of user %s\n", *userID)
+	}
+
+	if len(keys) > 0 {
+		prefix := a.keys.ForTenant(*tenantID).Prefix()
+		for i, key := range keys {
+			keys[i] = prefix + key
+		}
+
+		deleted, err := a.redis.Del(ctx, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to delete keys: %w", err)
+		}
+		fmt.Printf("Deleted %d of %d keys\n", deleted, len(keys))
+	}
+
+	return nil
+}
+
+// dumpUser prints everything stored about a user as JSON
+func (a *App) dumpUser(args []string) error {
+	fs := flag.NewFlagSet("dump-user", flag.ExitOnError)
+	tenantID := fs.String("tenant", tenant.Default, "Tenant to look -email up in")
+	userID := fs.String("user", "", "ID of the user to dump")
+	email := fs.String("email", "", "Email of the user to dump")
+	fs.Parse(args)
+
+	if (*userID == "") == (*email == "") {
+		return fmt.Errorf("exactly one of -user or -email is required")
+	}
+
+	if *email != "" {
+		id, _, err := a.storage.GetUserByEmail(*tenantID, *email)
+		if err != nil {
+			return fmt.Errorf("failed to look up user: %w", err)
+		}
+		*userID = id
+	}
+
+	var dump userDump
+	var err error
+	if dump.User, err = a.storage.GetUserByID(*userID); err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if dump.Stats, err = a.storage.GetUserStats(*userID); err != nil {
+		return fmt.Errorf("failed to get user stats: %w", err)
+	}
+	if dump.Followees, err = a.storage.GetUserFollowees(*userID); err != nil {
+		return fmt.Errorf("failed to get followees: %w", err)
+	}
+	if dump.Followers, err = a.storage.GetUserFollowers(*userID); err != nil {
+		return fmt.Errorf("failed to get followers: %w", err)
+	}
+	if dump.Stories, err = a.storage.GetStoriesByAuthor(*userID); err != nil {
+		return fmt.Errorf("failed to get stories: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
+
+// experiment prints an experiment's settings in force, after changing the
+// given ones or, with -reset, going back to the configured ones
+func (a *App) experiment(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected an experiment name")
+	}
+	name := args[0]
+
+	ctx := context.Background()
+	current, _, err := a.experiments.Settings(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("experiment", flag.ExitOnError)
+	enabled := fs.Bool("enabled", current.Enabled, "Run the experiment")
+	treatment := fs.Int("treatment", current.Treatment, "Percent of users given the treatment")
+	salt := fs.String("salt", current.Salt, "Salt of the split; changing it reshuffles users")
+	reset := fs.Bool("reset", false, "Go back to the configured settings")
+	fs.Parse(args[1:])
+
+	changed := false
+	fs.Visit(func(f *flag.Flag) { changed = changed || f.Name != "reset" })
+	switch {
+	case *reset && changed:
+		return fmt.Errorf("-reset cannot be combined with other settings")
+	case *reset:
+		if err := a.experiments.Reset(ctx, name); err != nil {
+			return err
+		}
+	case changed:
+		if err := a.experiments.Set(ctx, name, config.Experiment{Enabled: *enabled, Treatment: *treatment, Salt: *salt}); err != nil {
+			return err
+		}
+	}
+
+	settings, runtime, err := a.experiments.Settings(ctx, name)
+	if err != nil {
+		return err
+	}
+	source := "configured"
+	if runtime {
+		source = "changed at runtime"
+	}
+	fmt.Printf("%s: enabled=%t treatment=%d salt=%q (%s)\n", name, settings.Enabled, settings.Treatment, settings.Salt, source)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: storiesctl [-config PATH] COMMAND [ARGS]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func main() {
+	flag.Usage = usage
+
+	// Load config; -config is parsed here too
+	cfg := config.MustLoad()
+
+	// MustLoad skips flag parsing when CONFIG_PATH is set
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			selected = &commands[i]
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	// Initialize Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+
+	// Initialize database connection
+	db, err := postgres.NewPostgres(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	tokens := jwt.OptionsFromConfig(cfg)
+	redisKeys := cache.NewKeys(cfg.Redis.KeyPrefix)
+	app := &App{
+		storage:     cache.NewCacheService(db, redisClient, redisKeys, cache.TTLsFromConfig(cfg.Cache)),
+		redis:       redisClient,
+		keys:        redisKeys,
+		revocations: revocation.NewStore(redisClient, redisKeys, tokens),
+		experiments: experiments.NewAssigner(redisClient, redisKeys, db, cfg.Experiments),
+		tokenTTL:    tokens.TTL,
+	}
+
+	if err := selected.run(app, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", selected.name, err)
+		os.Exit(1)
+	}
+}
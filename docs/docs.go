@@ -15,35 +15,61 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/feed": {
+        "/admin/abuse/flags": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Get the accounts in your tenant that the abuse detector shadow-throttled for following, unfollowing or viewing far more than people do in an hour, newest first. Open flags are returned unless reviewed is set. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "stories"
+                    "admin"
                 ],
-                "summary": "Get stories feed",
-                "responses": {}
-            }
-        },
-        "/feed/cached": {
-            "get": {
-                "security": [
+                "summary": "List abuse flags",
+                "operationId": "listAbuseFlags",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "boolean",
+                        "description": "Return reviewed flags instead of open ones",
+                        "name": "reviewed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Flags to return, 1 to 200",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
-                "description": "Get stories feed with Redis caching (30-60s TTL)",
-                "tags": [
-                    "stories"
-                ],
-                "summary": "Get cached stories feed",
                 "responses": {
                     "200": {
-                        "description": "Cached feed retrieved successfully",
+                        "description": "Abuse flags",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.AbuseFlag"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -54,6 +80,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -63,23 +95,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/feed/optimized": {
-            "get": {
+        "/admin/abuse/flags/{id}/review": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get stories feed with caching and preloaded metadata to avoid N+1 queries",
+                "description": "Mark an open abuse flag in your tenant as reviewed by you. The account stays throttled until the throttle runs out or is lifted with DELETE /admin/users/{user_id}/throttle. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "stories"
+                    "admin"
+                ],
+                "summary": "Review an abuse flag",
+                "operationId": "reviewAbuseFlag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Abuse flag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get optimized stories feed",
                 "responses": {
                     "200": {
-                        "description": "Optimized feed retrieved successfully",
+                        "description": "Flag reviewed",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.AbuseFlag"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -88,6 +145,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "No open flag with this ID in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -97,30 +166,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/follow/{user_id}": {
+        "/admin/announcements": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Follow another user to see their FRIENDS visibility stories",
+                "description": "Send a system.announcement event, such as a maintenance notice or feature flags, to every connected client of your tenant or only to the given user_ids, which must all be users of your tenant. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Follow a user",
+                "summary": "Broadcast a system announcement",
+                "operationId": "sendAnnouncement",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID to follow",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Announcement",
+                        "name": "announcement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.AnnouncementRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User followed successfully",
+                        "description": "Announcement sent",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -137,6 +215,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "A user is not in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -144,38 +234,49 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/archive/sweep": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Unfollow a user to stop seeing their FRIENDS visibility stories",
+                "description": "Run the archive job for your tenant now, moving stories deleted or expired longer ago than archive.after out of the database into the cold archive. With dry_run=true nothing is archived or deleted; the report counts the stories that would be, estimates the objects they would fill and lists the first 100 of their IDs. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Unfollow a user",
+                "summary": "Archive due stories now",
+                "operationId": "sweepArchive",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID to unfollow",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Only report what would be archived",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User unfollowed successfully",
+                        "description": "Archive sweep report",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
-                        "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/archive.Report"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -184,14 +285,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "404": {
-                        "description": "Follow relationship not found",
-                        "schema": {
-                            "$ref": "#/definitions/response.Response"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden - not an admin",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -199,38 +294,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Authenticate a user and return JWT token",
-                "consumes": [
-                    "application/json"
+        "/admin/impersonations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get the audit trail of admins impersonating users in your tenant, newest first: every impersonation token issued, with its reason, and every request made with one. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Authenticate a user",
+                "summary": "List impersonations",
+                "operationId": "listImpersonations",
                 "parameters": [
                     {
-                        "description": "User login details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/users.SignInRequest"
-                        }
+                        "type": "string",
+                        "description": "Only entries about this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Entries to return, 1 to 200",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User authenticated successfully with token",
+                        "description": "Audit trail",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.ImpersonationEvent"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -244,30 +358,63 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
                     }
                 }
             }
         },
-        "/me/stats": {
-            "get": {
+        "/admin/media/gc": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get user statistics including posts, views, unique viewers, and reaction breakdown for the last 7 days",
+                "description": "Reconcile your tenant's media bucket with its upload records now, settling uploads whose URL expired and deleting uploads left unconfirmed past the TTL along with their objects, whether or not the job is configured to delete them. With dry_run=true nothing is changed; the report counts what would be and lists up to 100 of the uploads that would be deleted. The report is not kept as the job's last one. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
+                ],
+                "summary": "Collect media garbage now",
+                "operationId": "collectMediaGarbage",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be deleted",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user statistics",
                 "responses": {
                     "200": {
-                        "description": "User statistics",
+                        "description": "Reconciliation report",
                         "schema": {
-                            "$ref": "#/definitions/users.UserStats"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.ReconciliationReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -276,8 +423,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden - not an admin",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -285,29 +432,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/media": {
+        "/admin/media/reconciliation": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List all media files uploaded by the authenticated user",
+                "description": "Get what the last run of the media reconciliation job found in your tenant: objects in the bucket with no upload record, confirmed uploads whose object is gone, and uploads left unconfirmed past the TTL. Orphan lists hold at most 100 entries each; the counts are complete. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "media"
+                    "admin"
                 ],
-                "summary": "List user media files",
+                "summary": "Get the media reconciliation report",
+                "operationId": "getMediaReconciliation",
                 "responses": {
                     "200": {
-                        "description": "Media files retrieved successfully",
+                        "description": "Reconciliation report",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/media.MediaInfoResponse"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.ReconciliationReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -316,6 +473,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "The job has not run yet",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -325,50 +494,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/media/upload-url": {
-            "post": {
+        "/admin/stats/clients": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generate a presigned URL for uploading media files",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Count the active sessions of your tenant by the platform and client version they logged in with, and the distinct devices behind them, to see who still runs an old app before retiring behavior it depends on. Sessions from before clients were recorded are left out. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "media"
-                ],
-                "summary": "Generate presigned upload URL",
-                "parameters": [
-                    {
-                        "description": "Upload URL request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/media.UploadURLRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Get client platform and version stats",
+                "operationId": "getClientStats",
                 "responses": {
                     "200": {
-                        "description": "Upload URL generated successfully",
+                        "description": "Client breakdown",
                         "schema": {
-                            "$ref": "#/definitions/media.UploadURLResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/session.ClientBreakdown"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden - not an admin",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -382,48 +550,70 @@ const docTemplate = `{
                 }
             }
         },
-        "/media/{object_key}": {
-            "delete": {
+        "/admin/stories/{id}/restore": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a specific media file",
+                "description": "Move a story of your tenant back from the cold archive into the database, under its old ID and deleted or expired as it was when archived, so it shows up in its author's history again. Its audience is restored without members who have since left; its views and reactions are not. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "media"
+                    "admin"
                 ],
-                "summary": "Delete media file",
+                "summary": "Restore an archived story",
+                "operationId": "restoreArchivedStory",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Object key",
-                        "name": "object_key",
+                        "description": "Story ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Media file deleted successfully",
+                        "description": "Story restored",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.Story"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden - not an admin",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
                     "404": {
-                        "description": "Media not found",
+                        "description": "Story not archived in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "The story's author no longer exists",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -437,42 +627,60 @@ const docTemplate = `{
                 }
             }
         },
-        "/media/{object_key}/download-url": {
-            "get": {
+        "/admin/users/{user_id}/impersonate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generate a presigned URL for downloading media files",
+                "description": "Issue a short-lived token to act as a user in your tenant, to reproduce a problem they reported with their feed or what they can see. The token is read-only: it carries no scopes and only GET requests are served with it. Its act claim names you, responses to it carry an X-Impersonated-By header, and issuing it and every request made with it are recorded in the audit trail. Admins cannot be impersonated. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "media"
+                    "admin"
                 ],
-                "summary": "Generate presigned download URL",
+                "summary": "Impersonate a user",
+                "operationId": "impersonateUser",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Object key",
-                        "name": "object_key",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Expiration time in seconds (default: 3600)",
-                        "name": "expires",
-                        "in": "query"
+                        "description": "Reason and lifetime",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.ImpersonationRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Download URL generated successfully",
+                    "201": {
+                        "description": "Impersonation token issued",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.ImpersonationToken"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -487,8 +695,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin, or the user is one",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "404": {
-                        "description": "Media not found",
+                        "description": "User not found in your tenant",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -502,35 +716,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/media/{object_key}/info": {
-            "get": {
+        "/admin/users/{user_id}/throttle": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get information about a specific media file",
+                "description": "End the shadow throttle the abuse detector put on a user in your tenant and reset their hourly counts, so their follows, unfollows and views take effect again. Lifting a user who is not throttled does nothing. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "media"
+                    "admin"
                 ],
-                "summary": "Get media file information",
+                "summary": "Lift an abuse throttle",
+                "operationId": "liftThrottle",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Object key",
-                        "name": "object_key",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Media information retrieved successfully",
+                        "description": "Throttle lifted",
                         "schema": {
-                            "$ref": "#/definitions/media.MediaInfoResponse"
+                            "$ref": "#/definitions/response.Response"
                         }
                     },
                     "400": {
@@ -545,8 +760,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "404": {
-                        "description": "Media not found",
+                        "description": "User not found in your tenant",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -560,42 +781,95 @@ const docTemplate = `{
                 }
             }
         },
-        "/signup": {
-            "post": {
-                "description": "Register a new user account",
-                "consumes": [
-                    "application/json"
+        "/feed": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get the newest stories visible to the user, newest first and then by ID, a page at a time: pass the X-Next-Cursor header of a response as cursor to get the following page. With stream=true the whole feed is sent as newline-delimited JSON (application/x-ndjson), one story per line, as it is read from the database, and limit and cursor do not apply; a failure partway through ends the stream with an error line. With media_urls=true each story with media carries a presigned media_url, valid for media.feed_url_ttl seconds, so its media can be fetched without calling /media/{object_key}/download-url. While the feed ranking experiment runs, some users get stories ranked by how much they engage with each author and by age instead of newest first; X-Experiments names the variant served. Ranked feeds are paged by position: their X-Next-Cursor continues from the ranking of the first page. Streamed feeds are always newest first.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "users"
+                    "stories"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get stories feed",
+                "operationId": "getFeed",
                 "parameters": [
                     {
-                        "description": "User registration details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/users.SignUpRequest"
-                        }
+                        "type": "boolean",
+                        "description": "Stream the feed as newline-delimited JSON",
+                        "name": "stream",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stories to return, up to feed.max_limit (default feed.default_limit)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include presigned media URLs",
+                        "name": "media_urls",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated story fields to return, such as id,media_key,author (default all)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                    "200": {
+                        "description": "Stories fetched successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.Story"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "X-Experiments": {
+                                "type": "string",
+                                "description": "feed_ranking=chronological or feed_ranking=ranked while the feed ranking experiment runs"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, sent while more stories follow"
                             }
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid limit, cursor or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -609,47 +883,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/stories": {
-            "post": {
+        "/feed/changes": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new story with authentication required",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the stories added to the user's feed after since, and those it held at since that have been deleted or expired since, so polling clients need not refetch the whole feed. Pass the returned cursor as since on the next poll; it trails the present by a few seconds so no change is skipped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "stories"
                 ],
-                "summary": "Create a new story",
+                "summary": "Get feed changes",
+                "operationId": "getFeedChanges",
                 "parameters": [
                     {
-                        "description": "Story content",
-                        "name": "story",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.StoryPostRequest"
-                        }
+                        "type": "string",
+                        "description": "RFC 3339 timestamp or the cursor from a previous response",
+                        "name": "since",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Story created successfully",
+                    "200": {
+                        "description": "Feed changes fetched successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.FeedChanges"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Missing or invalid since",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -669,36 +948,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/stories/{id}": {
+        "/feed/optimized": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a specific story by its ID with permission checks based on visibility and graph",
+                "description": "Get stories feed with caching and preloaded metadata to avoid N+1 queries, newest first and then by ID, a page at a time: pass the X-Next-Cursor header of a response as cursor to get the following page. With media_urls=true each story with media carries a presigned media_url, valid for media.feed_url_ttl seconds, so its media can be fetched without calling /media/{object_key}/download-url.",
                 "tags": [
                     "stories"
                 ],
-                "summary": "Get a story by ID",
+                "summary": "Get optimized stories feed",
+                "operationId": "getOptimizedFeed",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stories to return, up to feed.max_limit (default feed.default_limit)",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Story ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include presigned media URLs",
+                        "name": "media_urls",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated story fields to return, such as id,media_key,author (default all)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Story retrieved successfully",
+                        "description": "Optimized feed retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.StoryWithMeta"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, sent while more stories follow"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid limit, cursor or unknown field",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -709,14 +1027,55 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden - no permission to view this story",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
+                    }
+                }
+            }
+        },
+        "/feed/trays": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get one entry per followed author with active stories the user may see: their story count, latest story time, avatar and whether every story has been seen. Authors with unseen stories come first, then by latest story.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Get story trays",
+                "operationId": "getFeedTrays",
+                "responses": {
+                    "200": {
+                        "description": "Story trays fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.FeedTray"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
-                    "404": {
-                        "description": "Story not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -730,14 +1089,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/stories/{id}/reactions": {
+        "/follow/batch": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add an emoji reaction to a story and send real-time notification to author",
+                "description": "Follow up to 100 users at once, such as the matches of a contact import, reporting the outcome for each distinct ID in request order. Users gaining a follower receive a single user.followed event without followed_id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -745,32 +1104,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stories"
+                    "users"
                 ],
-                "summary": "Add a reaction to a story with real-time notifications",
+                "summary": "Follow several users",
+                "operationId": "followUsers",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Story ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Reaction details",
-                        "name": "reaction",
+                        "description": "Users to follow",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/types.ReactionRequest"
+                            "$ref": "#/definitions/types.FollowBatchRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reaction added successfully",
+                        "description": "Outcome for each user",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.FollowResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -785,8 +1153,73 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
-                    "404": {
-                        "description": "Story not found",
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unfollow up to 100 users at once, reporting the outcome for each distinct ID in request order. Users losing a follower receive a single user.unfollowed event without followed_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unfollow several users",
+                "operationId": "unfollowUsers",
+                "parameters": [
+                    {
+                        "description": "Users to unfollow",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.FollowBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Outcome for each user",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.FollowResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -800,36 +1233,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/stories/{id}/view": {
+        "/follow/{user_id}": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Record that a user has viewed a story (idempotent - one view per user) and send real-time notification to author",
+                "description": "Follow another user to see their FOLLOWERS visibility stories, and their FRIENDS stories once they follow back. They receive a user.followed event unless you already followed them, in which case nothing changes. You cannot follow yourself.",
                 "tags": [
-                    "stories"
+                    "users"
                 ],
-                "summary": "Record a story view with real-time notifications",
+                "summary": "Follow a user",
+                "operationId": "followUser",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Story ID",
-                        "name": "id",
+                        "description": "User ID to follow",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "View recorded successfully",
+                        "description": "User followed successfully",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request, or following yourself",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -841,7 +1275,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Story not found",
+                        "description": "User not found in your tenant",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -853,142 +1287,6385 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "media.MediaInfoResponse": {
-            "type": "object",
-            "properties": {
-                "content_type": {
-                    "type": "string"
-                },
-                "media_url": {
-                    "type": "string"
-                },
-                "object_key": {
-                    "type": "string"
-                },
-                "size": {
-                    "type": "integer"
-                },
-                "uploaded_at": {
-                    "type": "string"
-                }
-            }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unfollow a user to stop seeing their FOLLOWERS and FRIENDS visibility stories. They receive a user.unfollowed event.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unfollow a user",
+                "operationId": "unfollowUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID to unfollow",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User unfollowed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Follow relationship not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the groups you are a member of, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "List your story groups",
+                "operationId": "listGroups",
+                "responses": {
+                    "200": {
+                        "description": "Groups fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.StoryGroup"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a group you own, with the users in member_ids as its first members. Members post GROUP stories into it with its ID as group_id, and only members see them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Create a story group",
+                "operationId": "createGroup",
+                "parameters": [
+                    {
+                        "description": "Group name and members",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Group created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.StoryGroup"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the members of a group you belong to, earliest to join first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "List a group's members",
+                "operationId": "listGroupMembers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group members fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.GroupMember"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add users in your tenant to a group you own. They see its stories from then on, including those posted before they joined; users already in it are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Add members to a group",
+                "operationId": "addGroupMembers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Users to add",
+                        "name": "members",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.GroupMembersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group members added successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the group owner",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Group or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Leave a group by removing yourself, or, as its owner, remove another member. The owner cannot leave. Stories a removed member posted stay in the group.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Remove a member from a group",
+                "operationId": "removeGroupMember",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group member removed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the group owner, or the owner leaving",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Group or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/stories": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the active stories posted into a group you belong to, newest first. With media_urls=true each story with media carries a presigned media_url, as in the feed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Get a group's stories",
+                "operationId": "getGroupStories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include presigned media URLs",
+                        "name": "media_urls",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group stories fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.Story"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Authenticate a user and return a JWT bearer token with its expiry and the user's profile. The client platform, version and device ID, from the body or the X-Client-* and X-Device-ID headers, are stored with the session and counted in GET /admin/stats/clients.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Authenticate a user",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant to log in to (defaults to the default tenant)",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Platform of the app logging in, when not sent in the body",
+                        "name": "X-Client-Platform",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the app logging in, when not sent in the body",
+                        "name": "X-Client-Version",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stable identifier of the device, when not sent in the body",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "User login details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.SignInRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User authenticated successfully with token",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.LoginResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's account together with their follower, following and active story counts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get my profile",
+                "operationId": "getMe",
+                "responses": {
+                    "200": {
+                        "description": "User profile",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.Profile"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/avatar": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the http(s) URL of the picture shown as avatar_url on your profile, feed stories, trays, viewer lists and contact discovery, or send an empty avatar_url to remove it. The service stores the URL as sent; feeds and trays already cached keep the old avatar until they expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set my avatar",
+                "operationId": "setAvatar",
+                "parameters": [
+                    {
+                        "description": "Avatar",
+                        "name": "avatar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.AvatarRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Avatar updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.AvatarRequest"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/hidden-authors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the IDs of the users whose stories you hid from your feed, latest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List hidden authors",
+                "operationId": "listHiddenAuthors",
+                "responses": {
+                    "200": {
+                        "description": "Hidden authors fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/limits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get every rate limit the service enforces with how many actions you have left, so clients can throttle themselves before being turned away with 429. Limits counted per IP are reported for the IP of this request. Rate-limited routes also send X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the full limit is back) on every response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get my rate limits",
+                "operationId": "getMyLimits",
+                "responses": {
+                    "200": {
+                        "description": "Rate limits, by action",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.RateLimit"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notification-settings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the user's quiet hours, during which view and reaction notifications are held back for a daily digest, and which emails they opted in to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get notification settings",
+                "operationId": "getNotificationSettings",
+                "responses": {
+                    "200": {
+                        "description": "Notification settings",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.NotificationSettings"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set quiet hours as HH:MM local times in an IANA time zone; a start after the end spans midnight, and empty times disable quiet hours. Notifications queued during quiet hours are sent as a digest once they end. New follower and weekly stats emails are opt-in.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update notification settings",
+                "operationId": "updateNotificationSettings",
+                "parameters": [
+                    {
+                        "description": "Notification settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.NotificationSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification settings updated",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/privacy-settings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get whether the user hides their view receipts and is hidden from contact discovery",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get privacy settings",
+                "operationId": "getPrivacySettings",
+                "responses": {
+                    "200": {
+                        "description": "Privacy settings",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.PrivacySettings"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "With hide_view_receipts on, the user's views still count in authors' stats, but they are left out of viewer lists and authors get no story.viewed event for them. With hide_from_discovery on, contact discovery never matches the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update privacy settings",
+                "operationId": "updatePrivacySettings",
+                "parameters": [
+                    {
+                        "description": "Privacy settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.PrivacySettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Privacy settings updated",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/public-key": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Publish or replace the public key authors wrap the content keys of encrypted stories with. The service stores it as sent; keys are generated and kept on the device, and stories encrypted for a replaced key can only be read with the old private key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Publish my public key",
+                "operationId": "setPublicKey",
+                "parameters": [
+                    {
+                        "description": "Public key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.PublicKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Public key published",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.PublicKey"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/recaps": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List your recaps of past weeks, newest first. A recap is made for each week, Monday to Sunday UTC, in which you posted a story, once the week is over. It holds the stories you posted, their views and unique viewers, your top story, and your followers at the end of the week with the change since your previous recap.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List weekly recaps",
+                "operationId": "getWeeklyRecaps",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "Number of recaps, 1 to 52",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Weekly recaps retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.WeeklyRecap"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the sessions (one per login) whose tokens are still valid, with device name, IP and when each was last seen. The session of the calling token is marked current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List my sessions",
+                "operationId": "listSessions",
+                "responses": {
+                    "200": {
+                        "description": "Active sessions, most recently seen first",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/session.Session"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke one of the authenticated user's sessions; its token is rejected from then on. Revoking the current session logs the caller out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke a session",
+                "operationId": "revokeSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/settings/stories": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the visibility, audience and lifetime your stories get when posted without one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Get story settings",
+                "operationId": "getStorySettings",
+                "responses": {
+                    "200": {
+                        "description": "Story settings",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.StorySettings"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set what POST /stories uses when a story leaves it out: default_visibility (any but GROUP), default_audience_user_ids for PRIVATE stories sent without audience_user_ids, such as your close friends, and default_expires_in_hours (1 to 48). Fields left out keep their value; an empty visibility or audience, or 0 hours, clears the default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Update story settings",
+                "operationId": "updateStorySettings",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.StorySettingsUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story settings updated",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.StorySettings"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user statistics including posts, views, unique viewers, link clicks, and reaction breakdown for the last 7 days",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user statistics",
+                "operationId": "getStats",
+                "responses": {
+                    "200": {
+                        "description": "User statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.UserStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/stats/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download a CSV with one row per story and day (UTC) that story had activity: views, unique viewers, reactions, link clicks, impressions and reshares. Expired and deleted stories are included. The range defaults to the last 30 days and spans at most 366.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export creator insights",
+                "operationId": "exportStats",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD; defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV of daily story metrics",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported format or invalid range",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/stats/exports": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Build the CSV of GET /me/stats/export in the background, for ranges too large to download in one request. Poll the export until its status is done, then fetch its download_url. The range defaults to the last 30 days and spans at most 366.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Start a creator insights export",
+                "operationId": "startStatsExport",
+                "parameters": [
+                    {
+                        "description": "Days to export",
+                        "name": "range",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.StatsExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Export queued",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.StatsExport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/stats/exports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status of an export started with POST /me/stats/exports. Once done, download_url links to the CSV until expires_at; fetch the export again for a new link. Exports are forgotten some time after they finish.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a creator insights export",
+                "operationId": "getStatsExport",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.StatsExport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the API tokens the authenticated user has not revoked, newest first. The tokens themselves are not returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List my API tokens",
+                "operationId": "listAPITokens",
+                "responses": {
+                    "200": {
+                        "description": "API tokens, newest first",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.APIToken"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a long-lived, scoped token for bots and integrations, sent as a bearer token like a JWT. Tokens with the read scope may make GET requests and those with the post scope may create stories and upload their media. The token is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create an API token",
+                "operationId": "createAPIToken",
+                "parameters": [
+                    {
+                        "description": "Token name, scopes and lifetime",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.APITokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "API token created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.APIToken"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke one of the authenticated user's API tokens; it is rejected from then on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke an API token",
+                "operationId": "revokeAPIToken",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API token revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "API token not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the media files uploaded by the authenticated user in the tenant, in object key order, a page at a time. Files are listed from the upload records, so uploads whose file has not been seen yet or that failed are left out. Pass next_continuation_token from a response as continuation_token to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "List user media files",
+                "operationId": "listMedia",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, 1 to 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from the previous page",
+                        "name": "continuation_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media files retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.MediaListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or continuation token",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm that the file for an object key returned by /media/upload-url has been uploaded. Unconfirmed uploads may be deleted by the media reconciliation job once they are old enough.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Confirm a media upload",
+                "operationId": "confirmUpload",
+                "parameters": [
+                    {
+                        "description": "Uploaded object",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/media.ConfirmUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload confirmed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.MediaUpload"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or the file is larger than allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "No such upload, or the file has not been uploaded",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "The upload failed; request a new upload URL",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/upload-url": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a presigned URL for uploading media files",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Generate presigned upload URL",
+                "operationId": "createUploadURL",
+                "parameters": [
+                    {
+                        "description": "Upload URL request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/media.UploadURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload URL generated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.UploadURLResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{object_key}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific media file. The file is removed from storage by a background job shortly after.",
+                "tags": [
+                    "media"
+                ],
+                "summary": "Delete media file",
+                "operationId": "deleteMedia",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key",
+                        "name": "object_key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media file deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Media not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{object_key}/download-url": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a presigned URL for downloading media files",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Generate presigned download URL",
+                "operationId": "getDownloadURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key",
+                        "name": "object_key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiration time in seconds (default: 3600)",
+                        "name": "expires",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Download URL generated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.DownloadURLResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Media not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{object_key}/info": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get information about a specific media file",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Get media file information",
+                "operationId": "getMediaInfo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key",
+                        "name": "object_key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Media information retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.MediaInfoResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Media not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{object_key}/status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the state of an upload started with /media/upload-url: initiated until the file is found in the bucket, then uploaded, and confirmed once /media/confirm is called. Uploads whose URL expired without a file, and files larger than allowed, are failed and need a new upload URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Get upload status",
+                "operationId": "getUploadStatus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key",
+                        "name": "object_key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/media.UploadStatusResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "No such upload",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/oembed": {
+            "get": {
+                "description": "oEmbed 1.0 link response for the preview page of a public story or a share link, with the author name and media thumbnail. Only the json format is supported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preview"
+                ],
+                "summary": "oEmbed for a story link",
+                "operationId": "getOEmbed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story preview or share link URL",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "oEmbed response",
+                        "schema": {
+                            "$ref": "#/definitions/preview.OEmbed"
+                        }
+                    },
+                    "401": {
+                        "description": "The share link requires sign-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not a public or shared story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "501": {
+                        "description": "Unsupported format",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/{token}": {
+            "get": {
+                "description": "View the story a share link points to, bypassing the story's visibility. No authentication is needed unless the author required sign-in for the link. Each successful open counts as a view of the link.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "View a shared story",
+                "operationId": "getSharedStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.Story"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - the link requires sign-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Share link not found, expired or revoked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Register a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a new user",
+                "operationId": "signUp",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant to register in (defaults to the default tenant)",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "User registration details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.SignUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new story with authentication required. A media_key must be an upload you started with POST /media/upload-url and confirmed with POST /media/confirm. A PRIVATE story may instead be end-to-end encrypted: leave text, link_url, place_name, latitude and longitude empty and send the ciphertext in encrypted, with the content key wrapped for yourself and each audience member using the keys from GET /users/{user_id}/public-key. Encrypt any media with the same key before uploading it. A GROUP story is posted into one of your groups, named in group_id, and only its members see it. A story left without visibility, audience_user_ids (PRIVATE only) or expires_in_hours gets the defaults from PATCH /me/settings/stories; stories expire after 24 hours unless either sets otherwise. allow_reshare, allow_reply and allow_screenshot_notification default to true; turning them off stops others resharing or replying to the story, and stops story.screenshotted events for it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Create a new story",
+                "operationId": "createStory",
+                "parameters": [
+                    {
+                        "description": "Story content",
+                        "name": "story",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.StoryPostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Story created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a member of the group",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Media upload not confirmed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/impressions/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that stories appeared in your tray, whether or not you opened them; opening one is recorded with POST /stories/{id}/view. Send the stories shown since the last batch, up to 100 at a time. Impressions are buffered and written every few seconds, once per story, so they reach creator insights with a delay; stories you cannot see are dropped then.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Record story impressions",
+                "operationId": "recordImpressions",
+                "parameters": [
+                    {
+                        "description": "Stories shown",
+                        "name": "impressions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ImpressionBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Impressions accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.ImpressionBatchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/nearby": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get active public stories tagged within a radius of a location, closest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Get nearby public stories",
+                "operationId": "getNearbyStories",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lng",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Search radius in meters (default: 5000, max: 50000)",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated story fields to return, such as id,media_key,author (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Nearby stories fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.Story"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a specific story by its ID with permission checks based on visibility and graph. The ETag header holds its version, to send in If-Match when editing it. A story with max_views_per_user the user has viewed that many times comes back with consumed set and no text, media_key or link_url.",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Get a story by ID",
+                "operationId": "getStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated story fields to return, such as id,media_key,author (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.Story"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete one of your own stories. Its followers and your other devices receive a story.deleted event.",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Delete a story",
+                "operationId": "deleteStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Edit the text, link or audience of one of your active stories. Send the version you are editing, from the story's ETag header or version field, in If-Match (or as version in the body); if the story was edited since, nothing changes and 409 is returned with the current version in ETag, so edits from two devices never silently overwrite each other. Fields left out keep their value, and a new visibility replaces the audience and group, so send audience_user_ids or group_id with it as when posting. Users a new visibility shuts out are sent story.revoked if connected, and the story leaves every cached feed at once. Encrypted stories cannot be edited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Edit a story",
+                "operationId": "updateStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version being edited, such as \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "story",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.StoryUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story updated successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.Story"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story author, or not a member of the group",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Story edited since that version",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "428": {
+                        "description": "No version sent",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/envelope": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the ciphertext of an end-to-end encrypted story with its content key wrapped for you. Unwrap the key with your private key, then decrypt the text and the story's media with it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Get an encrypted story's envelope",
+                "operationId": "getStoryEnvelope",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story envelope",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.StoryEnvelope"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found, not encrypted or without a key for you",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Story viewed as many times as it allows",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/highlight": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Keep one of your own stories in your highlights (the action offered by story.expiring events)",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Add a story to highlights",
+                "operationId": "addToHighlights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story added to highlights",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/link/click": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that a user opened the swipe-up link attached to a story",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Record a story link click",
+                "operationId": "recordLinkClick",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link click recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - story has no link",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/preview": {
+            "get": {
+                "description": "HTML page with OpenGraph and Twitter card tags describing a public story, its author and media, so links to it unfurl on other platforms. Other stories are reported as not found.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "preview"
+                ],
+                "summary": "Preview a public story",
+                "operationId": "getStoryPreview",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Preview page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/reactions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add an emoji reaction to a story and send real-time notification to author. A user has one reaction per story; a new one replaces it and the replaced emoji is returned as previous_emoji.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Add a reaction to a story with real-time notifications",
+                "operationId": "addReaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reaction details",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reaction added successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.ReactionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story, or it is your own and self-reactions are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the authenticated user's reaction to a story and send a story.unreacted event to the author so live counters stay current. The removed emoji is returned as previous_emoji.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Remove your reaction to a story",
+                "operationId": "removeReaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reaction removed successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.ReactionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story or reaction not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/reply": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Send the author of a story you can see a private reply, delivered as a story.replied event. Replies are not stored: one the author cannot get right away, during their quiet hours or while they are offline, is queued for their digest, and a reply that can be neither sent nor queued is reported as not delivered. Your own stories and stories whose author turned off allow_reply cannot be replied to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Reply to a story",
+                "operationId": "replyToStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reply text",
+                        "name": "reply",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ReplyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reply sent or queued for the author's digest",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story, your own story, or replies turned off",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Reply not delivered",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/reshare": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Share another user's active public story as a new story of your own, with an optional caption in text and your choice of audience, which may be one of your groups. The reshare shows the original's media and names it in parent_story_id. Resharing a reshare shares the original it points to; your own stories, stories whose author turned off allow_reshare, and stories you have an active reshare of, cannot be reshared. The original's author gets a story.reshared event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Reshare a story",
+                "operationId": "reshareStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Caption and audience",
+                        "name": "reshare",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ReshareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Story reshared successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.ReshareResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not a public story by someone else, resharing turned off, or not a member of the group",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Already reshared",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/screenshot": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report that you took a screenshot or screen recording of a story you can see. The first report of each kind per story is stored for the author's insights and, unless the story turned off allow_screenshot_notification, sends them a story.screenshotted event, subject to quiet hours and the digest; repeats and reports on your own stories change nothing. Only available while the server has screenshot signals turned on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Report a story screenshot",
+                "operationId": "recordScreenshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "How the story was captured",
+                        "name": "screenshot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ScreenshotRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Screenshot recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found, or screenshot signals turned off",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/share-link": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a signed link that lets anyone holding it view the story, whatever its visibility, until the link expires, is revoked or the story ends. With require_login only signed-in users can open it. Stories limiting how often each viewer may open them cannot be shared by link.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Create a story share link",
+                "operationId": "createShareLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Share link options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ShareLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Share link created successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/types.ShareLink"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or a view-limited story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story's author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/share-links": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every share link created for the story, including expired and revoked ones, with how many times each was opened",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "List story share links",
+                "operationId": "listShareLinks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Share links fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.ShareLink"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story's author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/share-links/{link_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a share link so it can no longer be opened; the story's other links keep working",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Revoke a story share link",
+                "operationId": "revokeShareLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link ID",
+                        "name": "link_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Share link revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story's author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story or share link not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/view": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that a user has viewed a story (idempotent - one view per user) and send real-time notification to author, unless the viewer hides their view receipts. On stories with max_views_per_user every view counts as a replay, and views beyond the limit return 410.",
+                "tags": [
+                    "stories"
+                ],
+                "summary": "Record a story view with real-time notifications",
+                "operationId": "viewStory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "View recorded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - no permission to view this story",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Story viewed as many times as it allows",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/stories/{id}/viewers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the users who viewed the story, latest first. Viewers who hide their view receipts are left out, though their views still count in stats.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stories"
+                ],
+                "summary": "List story viewers",
+                "operationId": "listStoryViewers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Story viewers fetched successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/types.StoryViewer"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the story's author",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Story not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/unsubscribe": {
+            "get": {
+                "description": "HTML page opened by the signed link included in every notification email, asking to confirm with a POST to the same link. Opening it changes nothing.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Confirm unsubscribing from an email notification",
+                "operationId": "unsubscribePage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "new_followers",
+                            "weekly_stats",
+                            "weekly_recap"
+                        ],
+                        "type": "string",
+                        "description": "Email kind",
+                        "name": "kind",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from the email link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid unsubscribe link",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Turn off an email notification using the signed link included in every notification email. Mail clients post here directly for one-click unsubscribes (RFC 8058, with a List-Unsubscribe=One-Click body); the page the link opens posts here once confirmed. Browsers asking for HTML get a page saying it is done.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unsubscribe from an email notification",
+                "operationId": "unsubscribe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "new_followers",
+                            "weekly_stats",
+                            "weekly_recap"
+                        ],
+                        "type": "string",
+                        "description": "Email kind",
+                        "name": "kind",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from the email link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unsubscribed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid unsubscribe link",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/discover": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Match up to 500 contacts, each sent as the hex SHA-256 of the email address trimmed and lowercased, against users of the caller's tenant, so clients can suggest who to follow without uploading raw contacts. Users who set hide_from_discovery are never matched, and neither is the caller.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Find contacts who use the service",
+                "operationId": "discoverUsers",
+                "parameters": [
+                    {
+                        "description": "Hashed contacts",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.DiscoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching users",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/users.DiscoveredUser"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a user's public profile in your tenant: follower and following counts, active public story count, and whether you follow them and they follow you",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user's profile",
+                "operationId": "getUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User profile",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.PublicProfile"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/hide-stories": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Leave a user's stories out of your feed, trays and feed changes without unfollowing them, so they are not told. Their stories can still be opened directly. Hiding them again is a no-op.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Hide a user's stories",
+                "operationId": "hideStories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID to hide",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stories hidden successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Bring a user's stories whom you hid back into your feed.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unhide a user's stories",
+                "operationId": "unhideStories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID to unhide",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stories unhidden successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User's stories are not hidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/presence": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get whether a user currently has a live WebSocket connection and when they were last seen. Only available to the user and their followers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Get user presence",
+                "operationId": "getPresence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User presence",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/websocket.Presence"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found or not followed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/public-key": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the public key a user in your tenant published, to wrap the content key of an encrypted story for them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user's public key",
+                "operationId": "getPublicKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Public key",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/users.PublicKey"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "No public key published in your tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get connected client count, queued broadcasts, and delivered/dropped event counters. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Get WebSocket hub statistics",
+                "operationId": "getHubStats",
+                "responses": {
+                    "200": {
+                        "description": "Hub statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/websocket.HubStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/ticket": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue a short-lived, single-use ticket to pass as ?ticket= when connecting to /ws, so the JWT never appears in a URL",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Issue WebSocket ticket",
+                "operationId": "issueTicket",
+                "responses": {
+                    "200": {
+                        "description": "Connection ticket",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/wsticket.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "archive.Report": {
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "description": "stories moved to the archive and deleted from the database",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "objects": {
+                    "description": "archive objects written",
+                    "type": "integer"
+                },
+                "story_ids": {
+                    "description": "in a dry run, the first stories that would be archived, oldest first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenants": {
+                    "type": "integer"
+                }
+            }
+        },
+        "media.ConfirmUploadRequest": {
+            "type": "object",
+            "required": [
+                "object_key"
+            ],
+            "properties": {
+                "object_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.DownloadURLResponse": {
+            "type": "object",
+            "properties": {
+                "download_url": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer"
+                },
+                "object_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.MediaInfoResponse": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "media_url": {
+                    "type": "string"
+                },
+                "object_key": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.MediaListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/media.MediaInfoResponse"
+                    }
+                },
+                "next_continuation_token": {
+                    "description": "pass as continuation_token for the next page; absent on the last",
+                    "type": "string"
+                }
+            }
+        },
+        "media.MediaUpload": {
+            "type": "object",
+            "properties": {
+                "confirmed_at": {
+                    "description": "nil until confirmed",
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object_key": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "0 until the file is found",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "initiated",
+                        "uploaded",
+                        "confirmed",
+                        "failed"
+                    ]
+                },
+                "tenant_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.Orphan": {
+            "type": "object",
+            "properties": {
+                "object_key": {
+                    "type": "string"
+                },
+                "since": {
+                    "description": "when the object was last modified or the record created",
+                    "type": "string"
+                },
+                "tenant_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.ReconciliationReport": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "description": "a sample of them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/media.Orphan"
+                    }
+                },
+                "deleted_count": {
+                    "description": "of those, how many were deleted",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "nothing was changed; expired and deleted are what would have been",
+                    "type": "boolean"
+                },
+                "errors": {
+                    "description": "tenants or objects that could not be checked",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expired_count": {
+                    "description": "initiated uploads whose URL expired unused, now failed",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "missing": {
+                    "description": "a sample of them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/media.Orphan"
+                    }
+                },
+                "missing_count": {
+                    "description": "confirmed uploads whose object is gone",
+                    "type": "integer"
+                },
+                "objects_scanned": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "tenants": {
+                    "type": "integer"
+                },
+                "unconfirmed_count": {
+                    "description": "uploads left unconfirmed past the TTL",
+                    "type": "integer"
+                },
+                "untracked": {
+                    "description": "a sample of them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/media.Orphan"
+                    }
+                },
+                "untracked_count": {
+                    "description": "objects in the bucket without an upload record",
+                    "type": "integer"
+                },
+                "uploads_scanned": {
+                    "type": "integer"
+                }
+            }
+        },
+        "media.UploadStatusResponse": {
+            "type": "object",
+            "properties": {
+                "confirmed_at": {
+                    "description": "nil until confirmed",
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object_key": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "0 until the file is found",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "initiated",
+                        "uploaded",
+                        "confirmed",
+                        "failed"
+                    ]
+                },
+                "tenant_id": {
+                    "type": "string"
+                },
+                "upload_url_expires_at": {
+                    "description": "an initiated upload past this needs a new upload URL",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.UploadURLRequest": {
+            "type": "object",
+            "required": [
+                "content_type"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "media.UploadURLResponse": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer"
+                },
+                "max_file_size": {
+                    "type": "integer"
+                },
+                "object_key": {
+                    "type": "string"
+                },
+                "upload_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "preview.OEmbed": {
+            "type": "object",
+            "properties": {
+                "author_name": {
+                    "type": "string"
+                },
+                "provider_name": {
+                    "type": "string"
+                },
+                "provider_url": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "session.ClientBreakdown": {
+            "type": "object",
+            "properties": {
+                "platforms": {
+                    "description": "most sessions first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/session.PlatformUsage"
+                    }
+                },
+                "sessions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "session.PlatformUsage": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "description": "distinct device IDs; sessions without one are not counted",
+                    "type": "integer"
+                },
+                "platform": {
+                    "type": "string",
+                    "enum": [
+                        "ios",
+                        "android",
+                        "web",
+                        "desktop",
+                        "other",
+                        "unknown"
+                    ]
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "versions": {
+                    "description": "most sessions first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/session.VersionUsage"
+                    }
+                }
+            }
+        },
+        "session.Session": {
+            "type": "object",
+            "properties": {
+                "client_version": {
+                    "description": "empty when the client did not report one",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "whether the request was made with this session's token",
+                    "type": "boolean"
+                },
+                "device_id": {
+                    "description": "empty when the client did not report one",
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string",
+                    "enum": [
+                        "ios",
+                        "android",
+                        "web",
+                        "desktop",
+                        "other",
+                        "unknown"
+                    ]
+                }
+            }
+        },
+        "session.VersionUsage": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "description": "distinct device IDs; sessions without one are not counted",
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "empty for sessions that did not report one",
+                    "type": "string"
+                }
+            }
+        },
+        "types.AnnouncementRequest": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "flags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "level": {
+                    "description": "defaults to info",
+                    "type": "string",
+                    "enum": [
+                        "info",
+                        "warning",
+                        "critical"
+                    ]
+                },
+                "message": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "user_ids": {
+                    "type": "array",
+                    "maxItems": 10000,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.CreateGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "member_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "types.EncryptedContent": {
+            "type": "object",
+            "required": [
+                "algorithm",
+                "ciphertext",
+                "nonce",
+                "recipient_keys"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "chosen by the clients, such as xchacha20poly1305",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "ciphertext": {
+                    "type": "string",
+                    "maxLength": 65536
+                },
+                "nonce": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "recipient_keys": {
+                    "description": "one per audience member and one for the author",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/types.RecipientKey"
+                    }
+                }
+            }
+        },
+        "types.FeedChanges": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "newest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Story"
+                    }
+                },
+                "cursor": {
+                    "description": "pass as since on the next poll",
+                    "type": "string"
+                },
+                "removed": {
+                    "description": "stories the feed held at that point that are gone now",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.RemovedStory"
+                    }
+                }
+            }
+        },
+        "types.FeedTray": {
+            "type": "object",
+            "properties": {
+                "author_email": {
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "avatar_url": {
+                    "description": "empty when the author has no avatar",
+                    "type": "string"
+                },
+                "latest_story_at": {
+                    "type": "string"
+                },
+                "seen": {
+                    "description": "whether the user has viewed every story",
+                    "type": "boolean"
+                },
+                "story_count": {
+                    "type": "integer"
+                },
+                "unseen_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.FollowBatchRequest": {
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "user_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.FollowResult": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "followed",
+                        "already_following",
+                        "unfollowed",
+                        "not_following",
+                        "self",
+                        "not_found"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.GroupMember": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.GroupMembersRequest": {
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "user_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ImpressionBatchRequest": {
+            "type": "object",
+            "required": [
+                "story_ids"
+            ],
+            "properties": {
+                "story_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ImpressionBatchResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "description": "distinct stories buffered; ones the user cannot see are dropped when they are written",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ReactionRequest": {
+            "type": "object",
+            "required": [
+                "emoji"
+            ],
+            "properties": {
+                "emoji": {
+                    "$ref": "#/definitions/types.ReactionType"
+                }
+            }
+        },
+        "types.ReactionResponse": {
+            "type": "object",
+            "properties": {
+                "emoji": {
+                    "$ref": "#/definitions/types.ReactionType"
+                },
+                "previous_emoji": {
+                    "description": "The reaction it replaced, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ReactionType"
+                        }
+                    ]
+                },
+                "story_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ReactionType": {
+            "type": "string",
+            "enum": [
+                "👍",
+                "❤️",
+                "😂",
+                "😮",
+                "😢",
+                "🔥"
+            ],
+            "x-enum-varnames": [
+                "ReactionThumbsUp",
+                "ReactionHeart",
+                "ReactionLaugh",
+                "ReactionSurprised",
+                "ReactionSad",
+                "ReactionFire"
+            ]
+        },
+        "types.RecipientKey": {
+            "type": "object",
+            "required": [
+                "user_id",
+                "wrapped_key"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                },
+                "wrapped_key": {
+                    "type": "string",
+                    "maxLength": 1024
+                }
+            }
+        },
+        "types.RemovedStory": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "deleted",
+                        "expired"
+                    ]
+                },
+                "removed_at": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ReplyRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "types.ReshareRequest": {
+            "type": "object",
+            "required": [
+                "visibility"
+            ],
+            "properties": {
+                "audience_user_ids": {
+                    "description": "PRIVATE reshares only, and required for them",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "group_id": {
+                    "description": "GROUP reshares only",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/types.Visibility"
+                }
+            }
+        },
+        "types.ReshareResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "parent_story_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScreenshotRequest": {
+            "type": "object",
+            "required": [
+                "kind"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "screenshot",
+                        "screen_recording"
+                    ]
+                }
+            }
+        },
+        "types.ShareLink": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "require_login": {
+                    "description": "Only signed-in users may open it",
+                    "type": "boolean"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "view_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ShareLinkRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in_hours": {
+                    "description": "Defaults to 24",
+                    "type": "integer",
+                    "maximum": 168,
+                    "minimum": 1
+                },
+                "require_login": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.Story": {
+            "type": "object",
+            "properties": {
+                "allow_reply": {
+                    "description": "false makes POST /stories/{id}/reply return 403",
+                    "type": "boolean"
+                },
+                "allow_reshare": {
+                    "description": "What the author lets viewers do with the story, chosen when posting it",
+                    "type": "boolean"
+                },
+                "allow_screenshot_notification": {
+                    "description": "tell the author when a viewer reports a screenshot",
+                    "type": "boolean"
+                },
+                "author": {
+                    "description": "only in feeds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.StoryAuthor"
+                        }
+                    ]
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "consumed": {
+                    "description": "Set wherever the viewer is sent the story once they used up their views; text, media_key and link_url are then empty",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "nil until deleted",
+                    "type": "string"
+                },
+                "encrypted": {
+                    "description": "text is empty; recipients fetch the envelope instead",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "description": "group a GROUP story was posted into",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "link_url": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "max_views_per_user": {
+                    "description": "How many times each viewer may open the story, 1 for view-once; 0 for no limit",
+                    "type": "integer"
+                },
+                "media_key": {
+                    "type": "string"
+                },
+                "media_url": {
+                    "description": "Presigned download link for media_key, only in feeds asked for with media_urls=true",
+                    "type": "string"
+                },
+                "media_url_expires_at": {
+                    "description": "unix seconds",
+                    "type": "integer"
+                },
+                "parent_story_id": {
+                    "description": "story this one reshares, with its media",
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped by every edit; send it back in If-Match to edit the story",
+                    "type": "integer"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/types.Visibility"
+                }
+            }
+        },
+        "types.StoryAuthor": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "empty when the author has no avatar",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "utc_offset": {
+                    "description": "the author's offset from UTC when they posted, such as +05:30; omitted without a time zone in their notification settings",
+                    "type": "string"
+                }
+            }
+        },
+        "types.StoryEnvelope": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "ciphertext": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "type": "string"
+                },
+                "wrapped_key": {
+                    "description": "the content key wrapped for the caller",
+                    "type": "string"
+                }
+            }
+        },
+        "types.StoryGroup": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "member_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.StoryPostRequest": {
+            "type": "object",
+            "properties": {
+                "allow_reply": {
+                    "type": "boolean"
+                },
+                "allow_reshare": {
+                    "description": "Story permissions, each allowed when left out",
+                    "type": "boolean"
+                },
+                "allow_screenshot_notification": {
+                    "type": "boolean"
+                },
+                "audience_user_ids": {
+                    "description": "PRIVATE stories only, and required for them; your default audience when left out",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "encrypted": {
+                    "description": "PRIVATE stories only; text, link_url and location must then be empty",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.EncryptedContent"
+                        }
+                    ]
+                },
+                "expires_in_hours": {
+                    "description": "your default lifetime when left out, else 24 hours",
+                    "type": "integer",
+                    "maximum": 48,
+                    "minimum": 1
+                },
+                "group_id": {
+                    "description": "GROUP stories only, one of the author's groups",
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "link_url": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "max_views_per_user": {
+                    "description": "1 for view-once; no limit when left out",
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "media_key": {
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "text": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "your default visibility when left out",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.Visibility"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.StorySettings": {
+            "type": "object",
+            "properties": {
+                "default_audience_user_ids": {
+                    "description": "for PRIVATE stories, such as close friends",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "default_expires_in_hours": {
+                    "description": "0 for 24 hours",
+                    "type": "integer"
+                },
+                "default_visibility": {
+                    "description": "empty when every story must name one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.Visibility"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.StorySettingsUpdate": {
+            "type": "object",
+            "properties": {
+                "default_audience_user_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "default_expires_in_hours": {
+                    "type": "integer",
+                    "maximum": 48,
+                    "minimum": 0
+                },
+                "default_visibility": {
+                    "$ref": "#/definitions/types.Visibility"
+                }
+            }
         },
-        "media.UploadURLRequest": {
+        "types.StoryUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "audience_user_ids": {
+                    "description": "PRIVATE only, and required for it",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "group_id": {
+                    "description": "GROUP only",
+                    "type": "string"
+                },
+                "link_url": {
+                    "description": "empty removes the link",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "the version being edited, for clients that cannot send If-Match",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "visibility": {
+                    "$ref": "#/definitions/types.Visibility"
+                }
+            }
+        },
+        "types.StoryViewer": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "empty when the viewer has no avatar",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "viewed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.StoryWithMeta": {
+            "type": "object",
+            "properties": {
+                "allow_reply": {
+                    "description": "false makes POST /stories/{id}/reply return 403",
+                    "type": "boolean"
+                },
+                "allow_reshare": {
+                    "description": "What the author lets viewers do with the story, chosen when posting it",
+                    "type": "boolean"
+                },
+                "allow_screenshot_notification": {
+                    "description": "tell the author when a viewer reports a screenshot",
+                    "type": "boolean"
+                },
+                "author": {
+                    "description": "only in feeds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.StoryAuthor"
+                        }
+                    ]
+                },
+                "author_email": {
+                    "description": "Author information",
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "consumed": {
+                    "description": "Set wherever the viewer is sent the story once they used up their views; text, media_key and link_url are then empty",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "nil until deleted",
+                    "type": "string"
+                },
+                "encrypted": {
+                    "description": "text is empty; recipients fetch the envelope instead",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "description": "group a GROUP story was posted into",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "link_url": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "max_views_per_user": {
+                    "description": "How many times each viewer may open the story, 1 for view-once; 0 for no limit",
+                    "type": "integer"
+                },
+                "media_key": {
+                    "type": "string"
+                },
+                "media_url": {
+                    "description": "Presigned download link for media_key, only in feeds asked for with media_urls=true",
+                    "type": "string"
+                },
+                "media_url_expires_at": {
+                    "description": "unix seconds",
+                    "type": "integer"
+                },
+                "parent_story_id": {
+                    "description": "story this one reshares, with its media",
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string"
+                },
+                "reaction_breakdown": {
+                    "description": "Reaction count per emoji",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "reaction_count": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "user_has_viewed": {
+                    "description": "User-specific flags",
+                    "type": "boolean"
+                },
+                "user_reaction": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped by every edit; send it back in If-Match to edit the story",
+                    "type": "integer"
+                },
+                "view_count": {
+                    "description": "Story statistics",
+                    "type": "integer"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/types.Visibility"
+                }
+            }
+        },
+        "types.Visibility": {
+            "type": "string",
+            "enum": [
+                "PUBLIC",
+                "FOLLOWERS",
+                "FRIENDS",
+                "PRIVATE",
+                "GROUP"
+            ],
+            "x-enum-comments": {
+                "VisibilityFollowers": "Anyone who follows the author",
+                "VisibilityFriends": "Mutual follows only",
+                "VisibilityGroup": "Members of the story's group"
+            },
+            "x-enum-descriptions": [
+                "",
+                "Anyone who follows the author",
+                "Mutual follows only",
+                "",
+                "Members of the story's group"
+            ],
+            "x-enum-varnames": [
+                "VisibilityPublic",
+                "VisibilityFollowers",
+                "VisibilityFriends",
+                "VisibilityPrivate",
+                "VisibilityGroup"
+            ]
+        },
+        "users.APIToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "empty when it does not expire",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "empty when never used",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "description": "Only set in the response that creates it",
+                    "type": "string"
+                }
+            }
+        },
+        "users.APITokenRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "Never expires when empty",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "users.AbuseFlag": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "follow",
+                        "unfollow",
+                        "view"
+                    ]
+                },
+                "count": {
+                    "description": "actions in the hour before the account was throttled",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "throttled_until": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.AvatarRequest": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "users.DiscoverRequest": {
+            "type": "object",
+            "required": [
+                "hashes"
+            ],
+            "properties": {
+                "hashes": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "users.DiscoveredUser": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "empty when the user has no avatar",
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "the contact hash that matched",
+                    "type": "string"
+                },
+                "is_following": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.ImpersonationEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "admin_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "Set when the token is used",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Set when the token is used",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Set when the token is issued",
+                    "type": "string"
+                },
+                "token_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.ImpersonationRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Recorded in the audit trail, such as a ticket number",
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "ttl_minutes": {
+                    "description": "Defaults to 15",
+                    "type": "integer",
+                    "maximum": 60,
+                    "minimum": 1
+                }
+            }
+        },
+        "users.ImpersonationToken": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "RFC 3339",
+                    "type": "string"
+                },
+                "impersonator_id": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "RFC 3339",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/users.User"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.NotificationSettings": {
             "type": "object",
-            "required": [
-                "content_type"
-            ],
             "properties": {
-                "content_type": {
+                "email_new_followers": {
+                    "type": "boolean"
+                },
+                "email_weekly_recap": {
+                    "type": "boolean"
+                },
+                "email_weekly_stats": {
+                    "type": "boolean"
+                },
+                "quiet_hours_end": {
+                    "type": "string"
+                },
+                "quiet_hours_start": {
+                    "type": "string"
+                },
+                "timezone": {
                     "type": "string"
                 }
             }
         },
-        "media.UploadURLResponse": {
+        "users.PrivacySettings": {
             "type": "object",
             "properties": {
-                "content_type": {
+                "hide_from_discovery": {
+                    "description": "never matched by contact discovery",
+                    "type": "boolean"
+                },
+                "hide_view_receipts": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "users.Profile": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "empty when the user has no avatar",
                     "type": "string"
                 },
-                "expires_at": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "followers": {
                     "type": "integer"
                 },
-                "max_file_size": {
+                "following": {
                     "type": "integer"
                 },
-                "object_key": {
+                "id": {
                     "type": "string"
                 },
-                "upload_url": {
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "stories": {
+                    "description": "Active stories",
+                    "type": "integer"
+                },
+                "tenant_id": {
                     "type": "string"
                 }
             }
         },
-        "response.Response": {
+        "users.PublicKey": {
             "type": "object",
             "properties": {
-                "data": {},
-                "error": {
+                "algorithm": {
+                    "description": "chosen by the clients, such as x25519",
                     "type": "string"
                 },
-                "message": {
+                "key": {
+                    "description": "base64",
                     "type": "string"
                 },
-                "status": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "types.ReactionRequest": {
+        "users.PublicKeyRequest": {
             "type": "object",
             "required": [
-                "emoji"
+                "algorithm",
+                "key"
             ],
             "properties": {
-                "emoji": {
-                    "$ref": "#/definitions/types.ReactionType"
+                "algorithm": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "key": {
+                    "type": "string",
+                    "maxLength": 1024
                 }
             }
         },
-        "types.ReactionType": {
-            "type": "string",
-            "enum": [
-                "👍",
-                "❤️",
-                "😂",
-                "😮",
-                "😢",
-                "🔥"
-            ],
-            "x-enum-varnames": [
-                "ReactionThumbsUp",
-                "ReactionHeart",
-                "ReactionLaugh",
-                "ReactionSurprised",
-                "ReactionSad",
-                "ReactionFire"
-            ]
-        },
-        "types.StoryPostRequest": {
+        "users.PublicProfile": {
             "type": "object",
-            "required": [
-                "audience_user_ids",
-                "visibility"
-            ],
             "properties": {
-                "audience_user_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "avatar_url": {
+                    "description": "empty when the user has no avatar",
+                    "type": "string"
                 },
-                "media_key": {
+                "created_at": {
                     "type": "string"
                 },
-                "text": {
+                "email": {
                     "type": "string"
                 },
-                "visibility": {
-                    "$ref": "#/definitions/types.Visibility"
+                "followers": {
+                    "type": "integer"
+                },
+                "following": {
+                    "type": "integer"
+                },
+                "follows_you": {
+                    "description": "They follow the viewer",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_following": {
+                    "description": "The viewer follows them",
+                    "type": "boolean"
+                },
+                "public_stories": {
+                    "description": "Active public stories",
+                    "type": "integer"
                 }
             }
         },
-        "types.Visibility": {
-            "type": "string",
-            "enum": [
-                "PUBLIC",
-                "FRIENDS",
-                "PRIVATE"
-            ],
-            "x-enum-varnames": [
-                "VisibilityPublic",
-                "VisibilityFriends",
-                "VisibilityPrivate"
-            ]
+        "users.RateLimit": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "limit": {
+                    "description": "Actions allowed in a burst",
+                    "type": "integer"
+                },
+                "per": {
+                    "description": "user or ip",
+                    "type": "string"
+                },
+                "remaining": {
+                    "description": "Actions allowed right now",
+                    "type": "integer"
+                },
+                "reset_seconds": {
+                    "description": "Until the full limit is available again",
+                    "type": "integer"
+                },
+                "window_seconds": {
+                    "description": "How long the full limit takes to refill",
+                    "type": "integer"
+                }
+            }
+        },
+        "users.RecapStory": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "reactions": {
+                    "type": "integer"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
         },
         "users.SignInRequest": {
             "type": "object",
@@ -997,12 +7674,38 @@ const docTemplate = `{
                 "password"
             ],
             "properties": {
+                "client_version": {
+                    "description": "defaults to the X-Client-Version header",
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "device_id": {
+                    "description": "a stable identifier of the device; defaults to the X-Device-ID header",
+                    "type": "string",
+                    "maxLength": 128
+                },
+                "device_name": {
+                    "description": "shown in the session list; defaults to the User-Agent",
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "email": {
                     "type": "string"
                 },
                 "password": {
                     "type": "string",
                     "minLength": 6
+                },
+                "platform": {
+                    "description": "defaults to the X-Client-Platform header",
+                    "type": "string",
+                    "enum": [
+                        "ios",
+                        "android",
+                        "web",
+                        "desktop",
+                        "other"
+                    ]
                 }
             }
         },
@@ -1022,18 +7725,105 @@ const docTemplate = `{
                 }
             }
         },
+        "users.StatsExport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "the CSV file, once done",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "when download_url stops working, Unix seconds",
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "queued again while waiting to retry a failed attempt",
+                    "type": "string",
+                    "enum": [
+                        "queued",
+                        "running",
+                        "done",
+                        "failed"
+                    ]
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.StatsExportRequest": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "first day, YYYY-MM-DD; defaults to 29 days before to",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "last day, YYYY-MM-DD; defaults to today",
+                    "type": "string"
+                }
+            }
+        },
+        "users.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "tenant_id": {
+                    "type": "string"
+                }
+            }
+        },
         "users.UserStats": {
             "type": "object",
             "properties": {
+                "impressions": {
+                    "description": "times a story appeared in a tray, once per user and story",
+                    "type": "integer"
+                },
+                "link_clicks": {
+                    "type": "integer"
+                },
                 "posted": {
                     "type": "integer"
                 },
+                "reach": {
+                    "description": "users whose tray showed any of the stories; compare with unique_viewers, who opened one",
+                    "type": "integer"
+                },
                 "reaction_counts": {
                     "type": "object",
                     "additionalProperties": {
                         "type": "integer"
                     }
                 },
+                "reshares": {
+                    "description": "stories sharing one of the user's, not counting deleted ones",
+                    "type": "integer"
+                },
+                "screenshots": {
+                    "description": "screenshots and screen recordings reported, once per user, story and kind",
+                    "type": "integer"
+                },
                 "unique_viewers": {
                     "type": "integer"
                 },
@@ -1041,6 +7831,124 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "users.WeeklyRecap": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "follower_delta": {
+                    "description": "since the previous recap; for the first, followers gained during the week",
+                    "type": "integer"
+                },
+                "followers": {
+                    "description": "when the recap was made",
+                    "type": "integer"
+                },
+                "posted": {
+                    "description": "stories posted during the week, including since expired ones",
+                    "type": "integer"
+                },
+                "reach": {
+                    "description": "users whose tray showed any of the stories during the week",
+                    "type": "integer"
+                },
+                "top_story": {
+                    "description": "the most viewed story; absent when no story was viewed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.RecapStory"
+                        }
+                    ]
+                },
+                "views": {
+                    "description": "views during the week of any of the creator's stories",
+                    "type": "integer"
+                },
+                "week_end": {
+                    "description": "Sunday, YYYY-MM-DD",
+                    "type": "string"
+                },
+                "week_start": {
+                    "description": "Monday, YYYY-MM-DD",
+                    "type": "string"
+                }
+            }
+        },
+        "websocket.HubStats": {
+            "type": "object",
+            "properties": {
+                "broadcasts": {
+                    "description": "broadcasts to users handled, not counting topics",
+                    "type": "integer"
+                },
+                "connected_clients": {
+                    "type": "integer"
+                },
+                "connected_users": {
+                    "type": "integer"
+                },
+                "delivered_events": {
+                    "type": "integer"
+                },
+                "dropped_broadcasts": {
+                    "type": "integer"
+                },
+                "dropped_events": {
+                    "type": "integer"
+                },
+                "full_queues": {
+                    "description": "connected clients whose send queue is full",
+                    "type": "integer"
+                },
+                "max_queue_depth": {
+                    "description": "deepest send queue among connected clients",
+                    "type": "integer"
+                },
+                "max_write_latency_ms": {
+                    "description": "slowest last write among connected clients",
+                    "type": "integer"
+                },
+                "queued_broadcasts": {
+                    "type": "integer"
+                },
+                "reaped_clients": {
+                    "type": "integer"
+                },
+                "rejected_connections": {
+                    "description": "turned away at a connection cap",
+                    "type": "integer"
+                },
+                "slow_consumer_disconnects": {
+                    "type": "integer"
+                }
+            }
+        },
+        "websocket.Presence": {
+            "type": "object",
+            "properties": {
+                "last_seen": {
+                    "type": "string"
+                },
+                "online": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "wsticket.Ticket": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "integer"
+                },
+                "ticket": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -1055,7 +7963,7 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
+	Version:          "1.0.0",
 	Host:             "",
 	BasePath:         "/",
 	Schemes:          []string{},
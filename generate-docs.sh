@@ -2,12 +2,19 @@
 
 # Generate Swagger documentation
 echo "Generating Swagger documentation..."
-swag init -g cmd/stories-service/main.go
+# Annotated handlers are searched directly; the types they reference are
+# found by following their imports, so every type keeps its package name
+swag init -g main.go -o docs --parseDependency --parseInternal \
+  -d ./cmd/stories-service,$(ls -d ./internal/http/handlers/*/ | sed 's#/$##' | paste -sd, -)
 
 # Fix the generated docs.go file by removing incompatible fields
 echo "Fixing docs.go compatibility issues..."
 sed -i '/LeftDelim:/d' docs/docs.go
 sed -i '/RightDelim:/d' docs/docs.go
 
+# Regenerate the Go and TypeScript clients from the new spec
+echo "Generating client SDKs..."
+go run ./cmd/sdkgen
+
 echo "Swagger docs generated and fixed successfully!"
 echo "Access the docs at: http://localhost:8080/swagger/"
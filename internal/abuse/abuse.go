@@ -0,0 +1,163 @@
+package abuse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// Actions the detector counts
+const (
+	ActionFollow   = "follow"
+	ActionUnfollow = "unfollow"
+	ActionView     = "view"
+)
+
+// Actions lists every action the detector counts
+var Actions = []string{ActionFollow, ActionUnfollow, ActionView}
+
+// window is how far back the counters look
+const window = time.Hour
+
+// countScript adds ARGV[4] actions to a user's sliding counter and returns how
+// many the counter holds within the window. Entries that left the window are
+// dropped first, and the counter goes away a window after its last entry.
+var countScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1] - ARGV[2])
+for i = 1, tonumber(ARGV[4]) do
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[3] .. "-" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return redis.call("ZCARD", KEYS[1])
+`)
+
+// Detector counts each user's follows, unfollows and views over the last hour
+// in Redis. An account going over the limit for an action is throttled for a
+// while and flagged for an admin to review. The detector does not turn
+// requests away itself; see middleware.ShadowThrottle.
+type Detector struct {
+	redis       *redis.Client
+	keys        cache.Keys
+	store       storage.AbuseStore
+	limits      map[string]int
+	throttleFor time.Duration
+}
+
+// NewDetector creates a detector with the configured limits, recording flags
+// in store and keeping its counters under keys. A disabled config yields a
+// detector that never throttles.
+func NewDetector(redisClient *redis.Client, keys cache.Keys, store storage.AbuseStore, cfg config.Abuse) *Detector {
+	limits := make(map[string]int)
+	if cfg.Enabled {
+		limits[ActionFollow] = cfg.FollowsPerHour
+		limits[ActionUnfollow] = cfg.UnfollowsPerHour
+		limits[ActionView] = cfg.ViewsPerHour
+	}
+
+	return &Detector{
+		redis:       redisClient,
+		keys:        keys,
+		store:       store,
+		limits:      limits,
+		throttleFor: time.Duration(cfg.ThrottleFor) * time.Second,
+	}
+}
+
+// Throttled reports whether the user is throttled
+func (d *Detector) Throttled(ctx context.Context, userID string) (bool, error) {
+	n, err := d.redis.Exists(ctx, d.throttleKey(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check throttle: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Record counts an action by the user and reports whether it took them over
+// the action's limit, in which case they are now throttled. Actions without a
+// limit are not counted.
+func (d *Detector) Record(ctx context.Context, userID, action string) (bool, error) {
+	return d.RecordN(ctx, userID, action, 1)
+}
+
+// RecordN is Record for n actions at once, such as the follows of a batch
+func (d *Detector) RecordN(ctx context.Context, userID, action string, n int) (bool, error) {
+	limit := d.limits[action]
+	if limit <= 0 || n <= 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int64())
+	count, err := countScript.Run(ctx, d.redis, []string{d.counterKey(userID, action)},
+		now.UnixMilli(), window.Milliseconds(), member, n).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to count %s: %w", action, err)
+	}
+	if count <= limit {
+		return false, nil
+	}
+
+	return true, d.throttle(ctx, userID, action, count)
+}
+
+// throttle throttles the user and, unless they already were, flags them for
+// review. A flag that cannot be recorded is logged; the throttle stands.
+func (d *Detector) throttle(ctx context.Context, userID, action string, count int) error {
+	until := time.Now().Add(d.throttleFor).UTC()
+	set, err := d.redis.SetNX(ctx, d.throttleKey(userID), action, d.throttleFor).Result()
+	if err != nil {
+		return fmt.Errorf("failed to throttle: %w", err)
+	}
+	if !set {
+		return nil
+	}
+
+	metrics.AbuseThrottled(action)
+	slog.Warn("Account throttled for abuse",
+		slog.String("user_id", userID),
+		slog.String("action", action),
+		slog.Int("count", count),
+		slog.Time("until", until))
+
+	flag := users.AbuseFlag{
+		UserID:         userID,
+		Action:         action,
+		Count:          count,
+		ThrottledUntil: types.FormatTime(until),
+	}
+	if err := d.store.FlagAbuse(flag); err != nil {
+		slog.Error("Failed to flag account for abuse review", slog.String("error", err.Error()), slog.String("user_id", userID))
+	}
+	return nil
+}
+
+// Lift ends the user's throttle and clears their counters, so they start
+// afresh
+func (d *Detector) Lift(ctx context.Context, userID string) error {
+	keys := []string{d.throttleKey(userID)}
+	for _, action := range Actions {
+		keys = append(keys, d.counterKey(userID, action))
+	}
+	if err := d.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to lift throttle: %w", err)
+	}
+	return nil
+}
+
+func (d *Detector) throttleKey(userID string) string {
+	return d.keys.Key(cache.AbuseThrottledKey, userID)
+}
+
+func (d *Detector) counterKey(userID, action string) string {
+	return d.keys.Key(cache.AbuseKey, action, userID)
+}
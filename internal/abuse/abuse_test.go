@@ -0,0 +1,209 @@
+package abuse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// fakeStore records the flags raised, failing while err is set
+type fakeStore struct {
+	flags []users.AbuseFlag
+	err   error
+}
+
+func (s *fakeStore) FlagAbuse(flag users.AbuseFlag) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.flags = append(s.flags, flag)
+	return nil
+}
+
+func (s *fakeStore) GetAbuseFlags(adminID string, reviewed bool, limit int) ([]users.AbuseFlag, error) {
+	return s.flags, nil
+}
+
+func (s *fakeStore) ReviewAbuseFlag(adminID, flagID string) (users.AbuseFlag, error) {
+	return users.AbuseFlag{}, nil
+}
+
+func newDetector(t *testing.T, cfg config.Abuse) (*Detector, *fakeStore, *miniredis.Miniredis) {
+	t.Helper()
+	redisClient, mr := redistest.New(t)
+
+	store := &fakeStore{}
+	return NewDetector(redisClient, cache.NewKeys(""), store, cfg), store, mr
+}
+
+var testConfig = config.Abuse{Enabled: true, FollowsPerHour: 3, UnfollowsPerHour: 3, ViewsPerHour: 5, ThrottleFor: 600}
+
+func TestDetector_ThrottlesOverLimit(t *testing.T) {
+	detector, store, mr := newDetector(t, testConfig)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		throttled, err := detector.Record(ctx, "42", ActionFollow)
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if throttled {
+			t.Fatalf("Expected follow %d within the limit, got throttled", i+1)
+		}
+	}
+	if throttled, _ := detector.Throttled(ctx, "42"); throttled {
+		t.Fatal("Expected no throttle within the limit, got one")
+	}
+
+	throttled, err := detector.Record(ctx, "42", ActionFollow)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if !throttled {
+		t.Fatal("Expected the fourth follow to throttle, got none")
+	}
+	if throttled, _ := detector.Throttled(ctx, "42"); !throttled {
+		t.Error("Expected the account throttled, got none")
+	}
+	if ttl := mr.TTL(detector.throttleKey("42")); ttl.Seconds() != 600 {
+		t.Errorf("Expected the throttle to last 600s, got %v", ttl)
+	}
+
+	// Going further over the limit does not flag the account again
+	if _, err := detector.Record(ctx, "42", ActionFollow); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if len(store.flags) != 1 {
+		t.Fatalf("Expected 1 flag, got %d", len(store.flags))
+	}
+	if flag := store.flags[0]; flag.UserID != "42" || flag.Action != ActionFollow || flag.Count != 4 || flag.ThrottledUntil == "" {
+		t.Errorf("Expected user 42 flagged for 4 follows, got %+v", flag)
+	}
+
+	// Other users and other actions are counted apart
+	if throttled, _ := detector.Throttled(ctx, "7"); throttled {
+		t.Error("Expected user 7 not throttled, got throttled")
+	}
+	if throttled, _ := detector.Record(ctx, "7", ActionUnfollow); throttled {
+		t.Error("Expected user 7's first unfollow not to throttle, got throttled")
+	}
+}
+
+func TestDetector_WindowSlides(t *testing.T) {
+	detector, _, mr := newDetector(t, testConfig)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := detector.Record(ctx, "42", ActionUnfollow); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if n, _ := mr.ZMembers(detector.counterKey("42", ActionUnfollow)); len(n) != 3 {
+		t.Fatalf("Expected 3 counted unfollows, got %d", len(n))
+	}
+
+	// The counter expires a window after its last entry
+	mr.FastForward(window)
+	throttled, err := detector.Record(ctx, "42", ActionUnfollow)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if throttled {
+		t.Error("Expected unfollows from an hour ago not to count, got throttled")
+	}
+}
+
+func TestDetector_Lift(t *testing.T) {
+	detector, _, mr := newDetector(t, testConfig)
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		if _, err := detector.Record(ctx, "42", ActionView); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if throttled, _ := detector.Throttled(ctx, "42"); !throttled {
+		t.Fatal("Expected the account throttled, got none")
+	}
+
+	if err := detector.Lift(ctx, "42"); err != nil {
+		t.Fatalf("Lift failed: %v", err)
+	}
+	if throttled, _ := detector.Throttled(ctx, "42"); throttled {
+		t.Error("Expected the throttle lifted, got throttled")
+	}
+	if mr.Exists(detector.counterKey("42", ActionView)) {
+		t.Error("Expected the view counter cleared, got it kept")
+	}
+	if throttled, _ := detector.Record(ctx, "42", ActionView); throttled {
+		t.Error("Expected a fresh count after lifting, got throttled")
+	}
+}
+
+func TestDetector_FlagFailureKeepsThrottle(t *testing.T) {
+	detector, store, _ := newDetector(t, testConfig)
+	store.err = errors.New("database down")
+	ctx := context.Background()
+
+	var throttled bool
+	for i := 0; i < 4; i++ {
+		var err error
+		if throttled, err = detector.Record(ctx, "42", ActionFollow); err != nil {
+			t.Fatalf("Expected a failed flag not to fail Record, got %v", err)
+		}
+	}
+	if !throttled {
+		t.Error("Expected the account throttled, got none")
+	}
+}
+
+func TestDetector_Disabled(t *testing.T) {
+	cfg := testConfig
+	cfg.Enabled = false
+	detector, store, mr := newDetector(t, cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		throttled, err := detector.Record(ctx, "42", ActionView)
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if throttled {
+			t.Fatal("Expected a disabled detector never to throttle, got throttled")
+		}
+	}
+	if len(store.flags) != 0 || len(mr.Keys()) != 0 {
+		t.Errorf("Expected nothing counted or flagged, got %d flags and keys %v", len(store.flags), mr.Keys())
+	}
+}
+
+func TestDetector_RecordNCountsEachAction(t *testing.T) {
+	detector, store, _ := newDetector(t, testConfig)
+	ctx := context.Background()
+
+	throttled, err := detector.RecordN(ctx, "42", ActionFollow, 3)
+	if err != nil {
+		t.Fatalf("RecordN failed: %v", err)
+	}
+	if throttled {
+		t.Fatal("Expected a batch of 3 follows within the limit, got throttled")
+	}
+
+	// One more follow in a batch of two goes over
+	throttled, err = detector.RecordN(ctx, "42", ActionFollow, 2)
+	if err != nil {
+		t.Fatalf("RecordN failed: %v", err)
+	}
+	if !throttled {
+		t.Fatal("Expected 5 follows to throttle, got none")
+	}
+	if len(store.flags) != 1 || store.flags[0].Count != 5 {
+		t.Errorf("Expected user 42 flagged for 5 follows, got %+v", store.flags)
+	}
+}
@@ -0,0 +1,84 @@
+// Package apitoken mints the long-lived API tokens users create for bots and
+// integrations that cannot log in interactively, and decides which requests
+// each token scope allows.
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+// Prefix starts every API token, telling them apart from JWTs
+const Prefix = "stk_"
+
+// Scopes an API token can be granted
+const (
+	ScopeRead = "read" // GET requests
+	ScopePost = "post" // Creating stories and uploading their media
+)
+
+// postRoutes are the route patterns the post scope allows
+var postRoutes = map[string]bool{
+	"POST /stories":          true,
+	"POST /media/upload-url": true,
+	"POST /media/confirm":    true,
+}
+
+// Grants returns the scopes, as carried in JWTs, that a token with the given
+// API token scopes holds, so routes requiring a scope accept it
+func Grants(scopes []string) []string {
+	var granted []string
+	for _, scope := range scopes {
+		if scope == ScopePost {
+			granted = append(granted, jwt.ScopeStoriesWrite, jwt.ScopeMediaWrite)
+		}
+	}
+	return granted
+}
+
+// Generate returns a new API token and the hash it is stored under. Only the
+// hash is kept, so the token can be shown once.
+func Generate() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token = Prefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hash an API token is stored and looked up under
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken reports whether a bearer token is an API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// Allows reports whether a token with the given scopes may make a request.
+// The request must have been matched by a ServeMux, which sets its pattern.
+func Allows(scopes []string, r *http.Request) bool {
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeRead:
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				return true
+			}
+		case ScopePost:
+			if postRoutes[r.Pattern] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package apitoken
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+func TestGenerate(t *testing.T) {
+	token, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !IsAPIToken(token) {
+		t.Errorf("token %q does not start with %q", token, Prefix)
+	}
+	if hash != Hash(token) {
+		t.Errorf("hash = %q, want Hash(token) = %q", hash, Hash(token))
+	}
+
+	other, _, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if other == token {
+		t.Error("Generate() returned the same token twice")
+	}
+}
+
+func TestIsAPIToken(t *testing.T) {
+	if IsAPIToken("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Error("IsAPIToken() = true for a JWT")
+	}
+}
+
+func TestAllows(t *testing.T) {
+	// route serves a request through a mux so its pattern is set
+	route := func(pattern, method, target string, scopes []string) bool {
+		var allowed bool
+		mux := http.NewServeMux()
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			allowed = Allows(scopes, r)
+		})
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+		return allowed
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		method  string
+		target  string
+		scopes  []string
+		want    bool
+	}{
+		{"read allows GET", "GET /feed", http.MethodGet, "/feed", []string{ScopeRead}, true},
+		{"read denies POST", "POST /stories", http.MethodPost, "/stories", []string{ScopeRead}, false},
+		{"post allows creating stories", "POST /stories", http.MethodPost, "/stories", []string{ScopePost}, true},
+		{"post allows media uploads", "POST /media/upload-url", http.MethodPost, "/media/upload-url", []string{ScopePost}, true},
+		{"post allows confirming uploads", "POST /media/confirm", http.MethodPost, "/media/confirm", []string{ScopePost}, true},
+		{"post denies reading", "GET /feed", http.MethodGet, "/feed", []string{ScopePost}, false},
+		{"post denies other writes", "DELETE /stories/{id}", http.MethodDelete, "/stories/1", []string{ScopePost}, false},
+		{"both", "GET /me", http.MethodGet, "/me", []string{ScopePost, ScopeRead}, true},
+		{"unknown scope", "GET /me", http.MethodGet, "/me", []string{"admin"}, false},
+		{"no scopes", "GET /me", http.MethodGet, "/me", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := route(tt.pattern, tt.method, tt.target, tt.scopes); got != tt.want {
+				t.Errorf("Allows(%v, %s %s) = %v, want %v", tt.scopes, tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrants(t *testing.T) {
+	if got := Grants([]string{ScopeRead}); len(got) != 0 {
+		t.Errorf("Grants(read) = %v, want no scopes", got)
+	}
+	if got := Grants([]string{ScopeRead, ScopePost}); !slices.Equal(got, jwt.UserScopes) {
+		t.Errorf("Grants(read, post) = %v, want %v", got, jwt.UserScopes)
+	}
+}
@@ -0,0 +1,342 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// contentType is that of archive objects: gzipped JSON, one story per line
+const contentType = "application/gzip"
+
+// maxPreview caps the story IDs a dry run lists
+const maxPreview = 100
+
+// ErrNotArchived is returned by Restore for a story that is not in the
+// archive, or is in another tenant's
+var ErrNotArchived = errors.New("story is not archived")
+
+// Store is the part of the data layer the archiver uses
+type Store interface {
+	storage.ArchiveStore
+	GetTenantIDs() ([]string, error)
+}
+
+// Bucket is the part of a tenant's archive bucket the archiver uses
+type Bucket interface {
+	PutObject(ctx context.Context, objectKey string, data []byte, contentType string) error
+	GetObject(ctx context.Context, objectKey string) ([]byte, error)
+}
+
+// Report is what one run of the archive job did, or in a dry run would have
+// done
+type Report struct {
+	Tenants  int      `json:"tenants"`
+	Archived int      `json:"archived"` // stories moved to the archive and deleted from the database
+	Objects  int      `json:"objects"`  // archive objects written
+	DryRun   bool     `json:"dry_run,omitempty"`
+	StoryIDs []string `json:"story_ids,omitempty"` // in a dry run, the first stories that would be archived, oldest first
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// Archiver moves stories deleted or expired long ago out of the database into
+// gzipped JSON lines in each tenant's archive bucket, keeping the hot tables
+// small, and restores them on request. Each archived story keeps its
+// metadata, audience and interaction counts, and points at its media, which
+// stays in the media bucket.
+type Archiver struct {
+	store       Store
+	bucket      func(tenantID string) (Bucket, error)
+	mediaBucket func(tenantID string) string
+	interval    time.Duration
+	olderThan   time.Duration
+	batchSize   int
+	dryRun      bool
+}
+
+// NewArchiver creates an archiver writing to the buckets named by the archive
+// config on the media service's storage
+func NewArchiver(store Store, service *mediaService.Service, cfg config.Archive) *Archiver {
+	cold := service.WithBucket(cfg.BucketName)
+	return &Archiver{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			return cold.ForTenant(tenantID)
+		},
+		mediaBucket: func(tenantID string) string {
+			return tenant.BucketName(service.BucketName(), tenantID)
+		},
+		interval:  time.Duration(cfg.Interval) * time.Second,
+		olderThan: time.Duration(cfg.After) * time.Second,
+		batchSize: cfg.BatchSize,
+		dryRun:    cfg.DryRun,
+	}
+}
+
+// Start archives every interval until the context is cancelled
+func (a *Archiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	slog.Info("Story archiver started", slog.String("interval", a.interval.String()), slog.String("after", a.olderThan.String()))
+
+	for {
+		start := time.Now()
+		report := a.RunOnce(ctx)
+		metrics.ObserveWorkerBatch(metrics.JobArchive, start, len(report.Errors))
+		slog.Info("Archived stories",
+			slog.Int("tenants", report.Tenants),
+			slog.Int("archived", report.Archived),
+			slog.Int("objects", report.Objects),
+			slog.Bool("dry_run", report.DryRun),
+			slog.Int("errors", len(report.Errors)))
+		for _, err := range report.Errors {
+			slog.Error("Failed to archive stories", slog.String("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Story archiver shutting down")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce archives every tenant's due stories and returns what it did. A
+// tenant that fails is recorded in the report's errors and skipped. With
+// archive.dry_run set nothing is archived and the report says what would be.
+func (a *Archiver) RunOnce(ctx context.Context) Report {
+	report := Report{DryRun: a.dryRun}
+
+	tenantIDs, err := a.store.GetTenantIDs()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("list tenants: %s", err))
+	}
+
+	for _, tenantID := range tenantIDs {
+		a.sweepTenant(ctx, tenantID, &report)
+	}
+	return report
+}
+
+// Sweep archives the tenant's due stories now and returns what it did, or
+// with dryRun only what it would do
+func (a *Archiver) Sweep(ctx context.Context, tenantID string, dryRun bool) Report {
+	report := Report{DryRun: dryRun}
+	a.sweepTenant(ctx, tenantID, &report)
+	return report
+}
+
+// sweepTenant adds the tenant to the report, recording its failure in the
+// report's errors
+func (a *Archiver) sweepTenant(ctx context.Context, tenantID string, report *Report) {
+	sweep := a.archiveTenant
+	if report.DryRun {
+		sweep = a.previewTenant
+	}
+	if err := sweep(ctx, tenantID, report); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("tenant %s: %s", tenantID, err))
+	}
+	report.Tenants++
+}
+
+// previewTenant adds what archiving the tenant's due stories would do to the
+// report, touching nothing
+func (a *Archiver) previewTenant(ctx context.Context, tenantID string, report *Report) error {
+	count, err := a.store.CountArchivableStories(tenantID, a.olderThan)
+	if err != nil {
+		return fmt.Errorf("count stories: %w", err)
+	}
+	report.Archived += count
+	report.Objects += (count + a.batchSize - 1) / a.batchSize
+
+	if room := maxPreview - len(report.StoryIDs); room > 0 && count > 0 {
+		stories, err := a.store.GetArchivableStories(tenantID, a.olderThan, room)
+		if err != nil {
+			return fmt.Errorf("list stories: %w", err)
+		}
+		for _, story := range stories {
+			report.StoryIDs = append(report.StoryIDs, story.ID)
+		}
+	}
+	return nil
+}
+
+// archiveTenant archives one tenant's due stories a batch at a time
+func (a *Archiver) archiveTenant(ctx context.Context, tenantID string, report *Report) error {
+	var bucket Bucket
+	for ctx.Err() == nil {
+		stories, err := a.store.GetArchivableStories(tenantID, a.olderThan, a.batchSize)
+		if err != nil {
+			return fmt.Errorf("list stories: %w", err)
+		}
+		if len(stories) == 0 {
+			return nil
+		}
+
+		if bucket == nil {
+			if bucket, err = a.bucket(tenantID); err != nil {
+				return err
+			}
+		}
+
+		storyIDs := make([]string, len(stories))
+		for i := range stories {
+			storyIDs[i] = stories[i].ID
+			if stories[i].MediaKey != "" {
+				stories[i].MediaBucket = a.mediaBucket(tenantID)
+			}
+		}
+
+		data, err := Encode(stories)
+		if err != nil {
+			return err
+		}
+
+		// The stories are only deleted once the object holding them is
+		// stored. If recording that fails they stay and go into a later
+		// object, which their archive entry then points at.
+		objectKey := fmt.Sprintf("stories/%s/%s-%s.jsonl.gz", time.Now().UTC().Format("2006/01/02"), storyIDs[0], storyIDs[len(storyIDs)-1])
+		if err := bucket.PutObject(ctx, objectKey, data, contentType); err != nil {
+			return fmt.Errorf("store %s: %w", objectKey, err)
+		}
+		report.Objects++
+
+		if err := a.store.MarkStoriesArchived(tenantID, objectKey, storyIDs); err != nil {
+			return fmt.Errorf("mark stories archived in %s: %w", objectKey, err)
+		}
+		report.Archived += len(stories)
+
+		if len(stories) < a.batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// Restore puts an archived story of the tenant back into the database as it
+// was when archived, deleted or expired as it was then. Returns ErrNotArchived
+// if the tenant has no such archived story, and storage.ErrUserNotFound if its
+// author is gone.
+func (a *Archiver) Restore(ctx context.Context, tenantID, storyID string) (types.Story, error) {
+	entry, err := a.store.GetArchiveEntry(storyID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && entry.TenantID != tenantID) {
+		return types.Story{}, ErrNotArchived
+	}
+	if err != nil {
+		return types.Story{}, err
+	}
+
+	bucket, err := a.bucket(tenantID)
+	if err != nil {
+		return types.Story{}, err
+	}
+	data, err := bucket.GetObject(ctx, entry.ObjectKey)
+	if err != nil {
+		return types.Story{}, fmt.Errorf("read %s: %w", entry.ObjectKey, err)
+	}
+
+	var archived *types.ArchivedStory
+	err = Decode(data, func(story types.ArchivedStory) error {
+		if story.ID == storyID {
+			archived = &story
+		}
+		return nil
+	})
+	if err != nil {
+		return types.Story{}, fmt.Errorf("read %s: %w", entry.ObjectKey, err)
+	}
+	if archived == nil {
+		return types.Story{}, fmt.Errorf("story %s is missing from %s", storyID, entry.ObjectKey)
+	}
+
+	return a.store.RestoreArchivedStory(*archived)
+}
+
+// Encode writes stories as gzipped JSON, one story per line
+func Encode(stories []types.ArchivedStory) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, story := range stories {
+		if err := enc.Encode(story); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// legacyTimeLayout is Postgres' text format for timestamps, which archives
+// written while story timestamps were strings may hold
+const legacyTimeLayout = "2006-01-02 15:04:05.999999"
+
+// Decode calls fn with each story of an archive object written by Encode,
+// stopping at the first error. Objects written while story timestamps were
+// strings are read too, and stories archived before they had permissions
+// allow everything, as the columns default to.
+func Decode(data []byte, fn func(types.ArchivedStory) error) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	lines := bufio.NewScanner(zr)
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		story := types.ArchivedStory{Story: types.Story{AllowReshare: true, AllowReply: true, AllowScreenshotNotification: true}}
+		if err := json.Unmarshal(lines.Bytes(), &story); err != nil {
+			line, upgradeErr := upgradeTimestamps(lines.Bytes())
+			if upgradeErr != nil || json.Unmarshal(line, &story) != nil {
+				return err
+			}
+		}
+		if err := fn(story); err != nil {
+			return err
+		}
+	}
+	return lines.Err()
+}
+
+// upgradeTimestamps rewrites the string timestamps of a legacy archive line
+// as time.Time encodes them: Postgres' text format becomes RFC 3339, and the
+// empty deleted_at of a story never deleted becomes null
+func upgradeTimestamps(line []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"created_at", "expires_at", "deleted_at"} {
+		var value string
+		if json.Unmarshal(fields[name], &value) != nil {
+			continue
+		}
+		if value == "" {
+			fields[name] = json.RawMessage("null")
+			continue
+		}
+		if at, err := time.Parse(legacyTimeLayout, value); err == nil {
+			fields[name], _ = json.Marshal(at)
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,248 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// fakeStore serves the archivable stories of each tenant and records what is
+// archived and restored
+type fakeStore struct {
+	stories  map[string][]types.ArchivedStory
+	entries  map[string]types.ArchiveEntry
+	restored []types.ArchivedStory
+	failMark bool
+}
+
+func (s *fakeStore) GetTenantIDs() ([]string, error) {
+	return []string{"default", "acme"}, nil
+}
+
+func (s *fakeStore) GetArchivableStories(tenantID string, olderThan time.Duration, limit int) ([]types.ArchivedStory, error) {
+	stories := s.stories[tenantID]
+	return stories[:min(limit, len(stories))], nil
+}
+
+func (s *fakeStore) CountArchivableStories(tenantID string, olderThan time.Duration) (int, error) {
+	return len(s.stories[tenantID]), nil
+}
+
+func (s *fakeStore) MarkStoriesArchived(tenantID, objectKey string, storyIDs []string) error {
+	if s.failMark {
+		return errors.New("connection reset")
+	}
+	for _, id := range storyIDs {
+		s.entries[id] = types.ArchiveEntry{StoryID: id, TenantID: tenantID, ObjectKey: objectKey}
+	}
+	s.stories[tenantID] = slices.DeleteFunc(s.stories[tenantID], func(story types.ArchivedStory) bool {
+		return slices.Contains(storyIDs, story.ID)
+	})
+	return nil
+}
+
+func (s *fakeStore) GetArchiveEntry(storyID string) (types.ArchiveEntry, error) {
+	entry, ok := s.entries[storyID]
+	if !ok {
+		return entry, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (s *fakeStore) RestoreArchivedStory(story types.ArchivedStory) (types.Story, error) {
+	s.restored = append(s.restored, story)
+	return story.Story, nil
+}
+
+// fakeBucket keeps objects in memory
+type fakeBucket map[string][]byte
+
+func (b fakeBucket) PutObject(ctx context.Context, objectKey string, data []byte, contentType string) error {
+	b[objectKey] = data
+	return nil
+}
+
+func (b fakeBucket) GetObject(ctx context.Context, objectKey string) ([]byte, error) {
+	data, ok := b[objectKey]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return data, nil
+}
+
+func newArchiver(store *fakeStore, buckets map[string]fakeBucket) *Archiver {
+	return &Archiver{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			return buckets[tenantID], nil
+		},
+		mediaBucket: func(tenantID string) string {
+			return "stories-media-" + tenantID
+		},
+		batchSize: 2,
+	}
+}
+
+func archivedStory(id string) types.ArchivedStory {
+	return types.ArchivedStory{Story: types.Story{ID: id, AuthorID: "1", Visibility: types.VisibilityPublic}}
+}
+
+func TestArchiver_RunOnce(t *testing.T) {
+	withMedia := archivedStory("3")
+	withMedia.MediaKey = "users/1/media/photo.jpg"
+
+	store := &fakeStore{
+		stories: map[string][]types.ArchivedStory{
+			"default": {archivedStory("1"), archivedStory("2"), withMedia},
+		},
+		entries: make(map[string]types.ArchiveEntry),
+	}
+	buckets := map[string]fakeBucket{"default": {}, "acme": {}}
+
+	report := newArchiver(store, buckets).RunOnce(context.Background())
+
+	if report.Tenants != 2 || report.Archived != 3 || report.Objects != 2 || len(report.Errors) != 0 {
+		t.Errorf("Expected 3 stories archived in 2 objects across 2 tenants, got %+v", report)
+	}
+	if len(store.stories["default"]) != 0 {
+		t.Errorf("Expected every story to leave the database, got %v", store.stories["default"])
+	}
+	if len(buckets["default"]) != 2 || len(buckets["acme"]) != 0 {
+		t.Errorf("Expected 2 objects in the default tenant's bucket only, got %d and %d", len(buckets["default"]), len(buckets["acme"]))
+	}
+
+	var decoded []types.ArchivedStory
+	if err := Decode(buckets["default"][store.entries["3"].ObjectKey], func(story types.ArchivedStory) error {
+		decoded = append(decoded, story)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to decode archive object: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].ID != "3" || decoded[0].MediaBucket != "stories-media-default" {
+		t.Errorf("Expected story 3 pointing at its media bucket, got %+v", decoded)
+	}
+}
+
+func TestArchiver_RunOnceKeepsStoriesOnFailure(t *testing.T) {
+	store := &fakeStore{
+		stories:  map[string][]types.ArchivedStory{"default": {archivedStory("1")}},
+		entries:  make(map[string]types.ArchiveEntry),
+		failMark: true,
+	}
+
+	report := newArchiver(store, map[string]fakeBucket{"default": {}}).RunOnce(context.Background())
+
+	if report.Archived != 0 || len(report.Errors) != 1 {
+		t.Errorf("Expected nothing archived and the failure reported, got %+v", report)
+	}
+	if len(store.stories["default"]) != 1 {
+		t.Errorf("Expected the story to stay in the database, got %v", store.stories["default"])
+	}
+}
+
+func TestArchiver_DryRun(t *testing.T) {
+	store := &fakeStore{
+		stories: map[string][]types.ArchivedStory{
+			"default": {archivedStory("1"), archivedStory("2"), archivedStory("3")},
+			"acme":    {archivedStory("4")},
+		},
+		entries: make(map[string]types.ArchiveEntry),
+	}
+	buckets := map[string]fakeBucket{"default": {}, "acme": {}}
+	archiver := newArchiver(store, buckets)
+	archiver.dryRun = true
+
+	report := archiver.RunOnce(context.Background())
+
+	if !report.DryRun || report.Tenants != 2 || report.Archived != 4 || report.Objects != 3 {
+		t.Errorf("Expected 4 stories in 3 objects across 2 tenants to be previewed, got %+v", report)
+	}
+	if !slices.Equal(report.StoryIDs, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Expected the previewed story IDs, got %v", report.StoryIDs)
+	}
+	if len(store.stories["default"]) != 3 || len(store.entries) != 0 || len(buckets["default"]) != 0 {
+		t.Errorf("Expected a dry run to change nothing, got stories %v, entries %v and %d objects", store.stories["default"], store.entries, len(buckets["default"]))
+	}
+
+	// An explicit sweep of one tenant
+	report = archiver.Sweep(context.Background(), "acme", false)
+	if report.DryRun || report.Tenants != 1 || report.Archived != 1 || len(report.StoryIDs) != 0 {
+		t.Errorf("Expected acme's story archived, got %+v", report)
+	}
+	if len(store.stories["acme"]) != 0 || len(store.stories["default"]) != 3 {
+		t.Errorf("Expected only acme's story to leave the database, got %v", store.stories)
+	}
+}
+
+func TestArchiver_Restore(t *testing.T) {
+	store := &fakeStore{
+		stories: map[string][]types.ArchivedStory{"default": {archivedStory("1"), archivedStory("2")}},
+		entries: make(map[string]types.ArchiveEntry),
+	}
+	archiver := newArchiver(store, map[string]fakeBucket{"default": {}, "acme": {}})
+	archiver.RunOnce(context.Background())
+
+	story, err := archiver.Restore(context.Background(), "default", "2")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if story.ID != "2" || len(store.restored) != 1 || store.restored[0].ID != "2" {
+		t.Errorf("Expected story 2 restored, got %+v", store.restored)
+	}
+
+	cases := map[string]struct {
+		tenantID string
+		storyID  string
+	}{
+		"never archived":    {"default", "9"},
+		"in another tenant": {"acme", "1"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := archiver.Restore(context.Background(), tc.tenantID, tc.storyID); !errors.Is(err, ErrNotArchived) {
+				t.Errorf("Expected ErrNotArchived, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecode_LegacyTimestamps(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"id":"1","created_at":"2024-03-09 16:35:01.12","expires_at":"2024-03-10T16:35:01.12Z","deleted_at":"","tenant_id":"default"}` + "\n"))
+	zw.Write([]byte(`{"id":"2","created_at":"2024-03-09 16:35:01","expires_at":"2024-03-10 16:35:01","deleted_at":"2024-03-10 16:35:02.5","tenant_id":"default"}` + "\n"))
+	zw.Close()
+
+	var decoded []types.ArchivedStory
+	if err := Decode(buf.Bytes(), func(story types.ArchivedStory) error {
+		decoded = append(decoded, story)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to decode legacy archive object: %v", err)
+	}
+
+	created := time.Date(2024, 3, 9, 16, 35, 1, 120000000, time.UTC)
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 stories, got %d", len(decoded))
+	}
+	if !decoded[0].CreatedAt.Equal(created) || !decoded[0].ExpiresAt.Equal(created.AddDate(0, 0, 1)) || decoded[0].DeletedAt != nil {
+		t.Errorf("Expected story 1's timestamps upgraded, got %+v", decoded[0].Story)
+	}
+	deleted := time.Date(2024, 3, 10, 16, 35, 2, 500000000, time.UTC)
+	if decoded[1].DeletedAt == nil || !decoded[1].DeletedAt.Equal(deleted) || decoded[1].TenantID != "default" {
+		t.Errorf("Expected story 2's deletion time upgraded, got %+v", decoded[1])
+	}
+	// Both were archived before stories had permissions
+	for _, story := range decoded {
+		if !story.AllowReshare || !story.AllowReply || !story.AllowScreenshotNotification {
+			t.Errorf("Expected story %s to allow everything, got %+v", story.ID, story.Story)
+		}
+	}
+}
@@ -3,59 +3,135 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"math/rand/v2"
+	"slices"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
 	"github.com/princekumarofficial/stories-service/internal/storage"
 	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
 )
 
-// CacheService wraps storage with Redis caching
+// CacheService wraps storage with Redis caching. Keys are namespaced by
+// tenant; use ForTenant to get a service scoped to a request's tenant.
 type CacheService struct {
 	storage storage.Storage
 	redis   *redis.Client
+	keys    Keys
+	ttls    TTLs
 }
 
-// NewCacheService creates a new cache service
-func NewCacheService(storage storage.Storage, redisClient *redis.Client) *CacheService {
+var _ storage.Storage = (*CacheService)(nil)
+
+// NewCacheService creates a new cache service building its keys with keys
+// and keeping entries for ttls
+func NewCacheService(storage storage.Storage, redisClient *redis.Client, keys Keys, ttls TTLs) *CacheService {
 	return &CacheService{
 		storage: storage,
 		redis:   redisClient,
+		keys:    keys,
+		ttls:    ttls,
 	}
 }
 
-// Cache key patterns
-const (
-	UserFolloweesKey = "user:followees:%s" // user:followees:userID
-	FeedCacheKey     = "feed:user:%s"      // feed:user:userID
-	StoryKey         = "story:%s"          // story:storyID
-	UserStatsKey     = "user:stats:%s"     // user:stats:userID
-)
+// ForTenant returns a copy of the service whose cache keys are scoped to tenantID
+func (c *CacheService) ForTenant(tenantID string) *CacheService {
+	scoped := *c
+	scoped.keys = c.keys.ForTenant(tenantID)
+	return &scoped
+}
 
-// Cache durations
-const (
-	FolloweesCacheDuration = 5 * time.Minute  // Followees don't change often
-	FeedCacheDuration      = 45 * time.Second // Hot feed cache (30-60s)
-	StoryCacheDuration     = 10 * time.Minute // Individual stories
-	StatsCacheDuration     = 2 * time.Minute  // User stats
-)
+// InTx runs fn as a unit of work of the underlying storage, handing it a
+// service over the unit that caches and invalidates like this one. Entries
+// are invalidated as each write is made, before the commit, so a read racing
+// the commit may cache rows it replaces until the entry expires.
+func (c *CacheService) InTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	return c.storage.InTx(ctx, func(tx storage.Storage) error {
+		unit := *c
+		unit.storage = tx
+		return fn(&unit)
+	})
+}
+
+// key builds the cache key in ns for id in the service's tenant
+func (c *CacheService) key(ns Namespace, id string) string {
+	return c.keys.Key(ns, id)
+}
+
+// lookup counts a read of the cache in ns for /cache/stats and Prometheus.
+// Reads failing in Redis count as misses, since they fall through to storage.
+func lookup(ns Namespace, hit bool) {
+	if hit {
+		metrics.CacheLookup(string(ns), metrics.ResultHit)
+	} else {
+		metrics.CacheLookup(string(ns), metrics.ResultMiss)
+	}
+}
+
+// TTLs are how long each kind of cache entry is kept
+type TTLs struct {
+	Followees     time.Duration // Followees don't change often
+	Feed          time.Duration // Hot feed cache
+	Story         time.Duration // Individual stories
+	Stats         time.Duration // User stats
+	Profile       time.Duration // Public profile counts
+	Hidden        time.Duration // Hidden authors, dropped when they change
+	Affinity      time.Duration // Author affinity for ranked feeds, never dropped
+	Notifications time.Duration // Notification settings, dropped when they change
+	Jitter        float64       // Fraction each TTL is randomly lengthened or shortened by
+}
+
+// TTLsFromConfig returns the configured cache TTLs
+func TTLsFromConfig(cfg config.Cache) TTLs {
+	seconds := func(n int) time.Duration { return time.Duration(n) * time.Second }
+	return TTLs{
+		Followees:     seconds(cfg.FolloweesTTL),
+		Feed:          seconds(cfg.FeedTTL),
+		Story:         seconds(cfg.StoryTTL),
+		Stats:         seconds(cfg.StatsTTL),
+		Profile:       seconds(cfg.ProfileTTL),
+		Hidden:        seconds(cfg.HiddenTTL),
+		Affinity:      seconds(cfg.AffinityTTL),
+		Notifications: seconds(cfg.NotificationsTTL),
+		Jitter:        float64(cfg.Jitter) / 100,
+	}
+}
+
+// ttl returns base lengthened or shortened at random by up to the jitter, so
+// entries cached at the same moment, such as the feeds of users who all
+// opened the app after a push, do not all expire and get rebuilt together
+func (c *CacheService) ttl(base time.Duration) time.Duration {
+	if c.ttls.Jitter <= 0 {
+		return base
+	}
+	spread := float64(base) * c.ttls.Jitter
+	if jittered := base + time.Duration((2*rand.Float64()-1)*spread); jittered > 0 {
+		return jittered
+	}
+	return base
+}
 
 // GetUserFollowees returns cached followee IDs or fetches from DB
 func (c *CacheService) GetUserFollowees(userID string) ([]string, error) {
 	ctx := context.Background()
-	key := fmt.Sprintf(UserFolloweesKey, userID)
+	key := c.key(UserFolloweesKey, userID)
 
 	// Try cache first
 	cached, err := c.redis.Get(ctx, key).Result()
 	if err == nil {
 		var followees []string
 		if err := json.Unmarshal([]byte(cached), &followees); err == nil {
+			lookup(UserFolloweesKey, true)
 			return followees, nil
 		}
 	}
 
 	// Cache miss - fetch from database
+	lookup(UserFolloweesKey, false)
 	followees, err := c.storage.GetUserFollowees(userID)
 	if err != nil {
 		return nil, err
@@ -63,90 +139,206 @@ func (c *CacheService) GetUserFollowees(userID string) ([]string, error) {
 
 	// Cache the result
 	data, _ := json.Marshal(followees)
-	c.redis.Set(ctx, key, data, FolloweesCacheDuration)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Followees))
 
 	return followees, nil
 }
 
+// GetHiddenAuthors returns the cached IDs of the authors the user hid or
+// fetches them from DB
+func (c *CacheService) GetHiddenAuthors(userID string) ([]string, error) {
+	ctx := context.Background()
+	key := c.key(HiddenAuthorsKey, userID)
+
+	cached, err := c.redis.Get(ctx, key).Result()
+	if err == nil {
+		var authorIDs []string
+		if err := json.Unmarshal([]byte(cached), &authorIDs); err == nil {
+			lookup(HiddenAuthorsKey, true)
+			return authorIDs, nil
+		}
+	}
+
+	lookup(HiddenAuthorsKey, false)
+	authorIDs, err := c.storage.GetHiddenAuthors(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(authorIDs)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Hidden))
+
+	return authorIDs, nil
+}
+
+// HideStories drops the user's cached hidden authors and feed, which the
+// author's stories leave
+func (c *CacheService) HideStories(userID, authorID string) error {
+	err := c.storage.HideStories(userID, authorID)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c.redis.Del(ctx, c.key(HiddenAuthorsKey, userID))
+	c.InvalidateFeedCaches(ctx, []string{userID})
+	return nil
+}
+
+// UnhideStories drops the user's cached hidden authors and feed, which the
+// author's stories return to
+func (c *CacheService) UnhideStories(userID, authorID string) error {
+	err := c.storage.UnhideStories(userID, authorID)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c.redis.Del(ctx, c.key(HiddenAuthorsKey, userID))
+	c.InvalidateFeedCaches(ctx, []string{userID})
+	return nil
+}
+
+// GetAuthorAffinity returns the user's cached engagement per author or
+// fetches it from DB. It is not invalidated: feeds ranked by it may lag a new
+// view or reaction by up to the affinity TTL.
+func (c *CacheService) GetAuthorAffinity(userID string) (map[string]int, error) {
+	ctx := context.Background()
+	key := c.key(AffinityKey, userID)
+
+	cached, err := c.redis.Get(ctx, key).Result()
+	if err == nil {
+		var affinity map[string]int
+		if err := json.Unmarshal([]byte(cached), &affinity); err == nil {
+			lookup(AffinityKey, true)
+			return affinity, nil
+		}
+	}
+
+	lookup(AffinityKey, false)
+	affinity, err := c.storage.GetAuthorAffinity(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(affinity)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Affinity))
+
+	return affinity, nil
+}
+
+// Experiment exposures are not cached
+func (c *CacheService) RecordExposure(experiment, variant, userID string) error {
+	return c.storage.RecordExposure(experiment, variant, userID)
+}
+
 func (c *CacheService) GetUserFollowers(userID string) ([]string, error) {
 	// For now, just pass through to storage since this is less frequently accessed
 	return c.storage.GetUserFollowers(userID)
 }
 
-// GetCachedFeed returns cached feed or fetches from DB
-func (c *CacheService) GetCachedFeed(ctx context.Context, userID string) ([]types.Story, error) {
-	key := fmt.Sprintf(FeedCacheKey, userID)
+// GetCachedFeed returns cached feed or fetches from DB, reporting whether it
+// was served from the cache
+func (c *CacheService) GetCachedFeed(ctx context.Context, userID string) ([]types.Story, bool, error) {
+	// Without a version the feed is neither read from nor written to the cache
+	key, keyErr := c.versionedKey(ctx, FeedCacheKey, userID)
 
 	// Try cache first
-	cached, err := c.redis.Get(ctx, key).Result()
-	if err == nil {
-		var stories []types.Story
-		if err := json.Unmarshal([]byte(cached), &stories); err == nil {
-			return stories, nil
+	if keyErr == nil {
+		if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+			var stories []types.Story
+			if err := json.Unmarshal([]byte(cached), &stories); err == nil {
+				lookup(FeedCacheKey, true)
+				return stories, true, nil
+			}
 		}
 	}
 
 	// Cache miss - fetch from database (with optimizations)
-	stories, err := c.storage.GetStoriesForUser(userID)
+	lookup(FeedCacheKey, false)
+	stories, err := c.storage.GetStoriesForUser(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// Cache the result for 30-60 seconds
-	data, _ := json.Marshal(stories)
-	c.redis.Set(ctx, key, data, FeedCacheDuration)
+	if keyErr == nil {
+		data, _ := json.Marshal(stories)
+		c.redis.Set(ctx, key, data, c.ttl(c.ttls.Feed))
+	}
 
-	return stories, nil
+	return stories, false, nil
 }
 
-// InvalidateUserCache clears user-related caches
-func (c *CacheService) InvalidateUserCache(ctx context.Context, userID string) {
-	keys := []string{
-		fmt.Sprintf(UserFolloweesKey, userID),
-		fmt.Sprintf(FeedCacheKey, userID),
-		fmt.Sprintf(UserStatsKey, userID),
+// GetCachedFeedTrays returns the cached story trays or fetches them from DB.
+// They share the feed's short lifetime, since seen status changes with every view.
+func (c *CacheService) GetCachedFeedTrays(ctx context.Context, userID string) ([]types.FeedTray, error) {
+	key, keyErr := c.versionedKey(ctx, FeedTraysKey, userID)
+
+	// Try cache first
+	if keyErr == nil {
+		if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+			var trays []types.FeedTray
+			if err := json.Unmarshal([]byte(cached), &trays); err == nil {
+				lookup(FeedTraysKey, true)
+				return trays, nil
+			}
+		}
 	}
 
-	for _, key := range keys {
-		c.redis.Del(ctx, key)
+	// Cache miss - run the aggregate query
+	lookup(FeedTraysKey, false)
+	trays, err := c.storage.GetFeedTrays(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
+
+	if keyErr == nil {
+		data, _ := json.Marshal(trays)
+		c.redis.Set(ctx, key, data, c.ttl(c.ttls.Feed))
+	}
+
+	return trays, nil
 }
 
-// InvalidateFeedCaches clears feed caches for multiple users
-func (c *CacheService) InvalidateFeedCaches(ctx context.Context, userIDs []string) {
-	if len(userIDs) == 0 {
-		return
+// InvalidateUserCache clears user-related caches
+func (c *CacheService) InvalidateUserCache(ctx context.Context, userID string) {
+	keys := []string{
+		c.key(UserFolloweesKey, userID),
+		c.key(UserStatsKey, userID),
+		c.key(UserProfileKey, userID),
 	}
 
-	keys := make([]string, len(userIDs))
-	for i, userID := range userIDs {
-		keys[i] = fmt.Sprintf(FeedCacheKey, userID)
+	for _, key := range keys {
+		c.redis.Del(ctx, key)
 	}
 
-	c.redis.Del(ctx, keys...)
+	c.InvalidateFeedCaches(ctx, []string{userID})
 }
 
 // CacheStory caches an individual story
 func (c *CacheService) CacheStory(ctx context.Context, story types.Story) {
-	key := fmt.Sprintf(StoryKey, story.ID)
+	key := c.key(StoryKey, story.ID)
 	data, _ := json.Marshal(story)
-	c.redis.Set(ctx, key, data, StoryCacheDuration)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Story))
 }
 
 // GetCachedStory returns cached story or fetches from DB
 func (c *CacheService) GetCachedStory(ctx context.Context, storyID string) (types.Story, error) {
-	key := fmt.Sprintf(StoryKey, storyID)
+	key := c.key(StoryKey, storyID)
 
 	// Try cache first
 	cached, err := c.redis.Get(ctx, key).Result()
 	if err == nil {
 		var story types.Story
 		if err := json.Unmarshal([]byte(cached), &story); err == nil {
+			lookup(StoryKey, true)
 			return story, nil
 		}
 	}
 
 	// Cache miss - fetch from database
+	lookup(StoryKey, false)
 	story, err := c.storage.GetStoryByID(storyID)
 	if err != nil {
 		return story, err
@@ -158,52 +350,131 @@ func (c *CacheService) GetCachedStory(ctx context.Context, storyID string) (type
 	return story, nil
 }
 
+// GetCachedStories returns the stories with the given IDs in the same order,
+// reading every cached story with a single MGET and loading the misses from
+// the database in one query, then caching them in one pipeline. Unknown and
+// deleted IDs are skipped, as by GetStoriesByIDs.
+func (c *CacheService) GetCachedStories(ctx context.Context, storyIDs []string) ([]types.Story, error) {
+	if len(storyIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(storyIDs))
+	for i, storyID := range storyIDs {
+		keys[i] = c.key(StoryKey, storyID)
+	}
+
+	found := make(map[string]types.Story, len(storyIDs))
+	var misses []string
+
+	// A failed read only means every story is loaded from the database
+	cached, err := c.redis.MGet(ctx, keys...).Result()
+	for i, storyID := range storyIDs {
+		var story types.Story
+		if err == nil {
+			if data, ok := cached[i].(string); ok && json.Unmarshal([]byte(data), &story) == nil {
+				lookup(StoryKey, true)
+				found[storyID] = story
+				continue
+			}
+		}
+		lookup(StoryKey, false)
+		misses = append(misses, storyID)
+	}
+
+	if len(misses) > 0 {
+		// Cache misses - fetch them from the database together
+		loaded, err := c.storage.GetStoriesByIDs(misses)
+		if err != nil {
+			return nil, err
+		}
+
+		pipe := c.redis.Pipeline()
+		for _, story := range loaded {
+			found[story.ID] = story
+			data, _ := json.Marshal(story)
+			pipe.Set(ctx, c.key(StoryKey, story.ID), data, c.ttl(c.ttls.Story))
+		}
+		pipe.Exec(ctx)
+	}
+
+	stories := make([]types.Story, 0, len(found))
+	for _, storyID := range storyIDs {
+		if story, ok := found[storyID]; ok {
+			stories = append(stories, story)
+		}
+	}
+
+	return stories, nil
+}
+
 // GetCachedUserStats returns cached user stats or fetches from DB
-func (c *CacheService) GetCachedUserStats(ctx context.Context, userID string) (int, int, int, map[string]int, error) {
-	key := fmt.Sprintf(UserStatsKey, userID)
+func (c *CacheService) GetCachedUserStats(ctx context.Context, userID string) (users.UserStats, error) {
+	key := c.key(UserStatsKey, userID)
 
 	// Try cache first
 	cached, err := c.redis.Get(ctx, key).Result()
 	if err == nil {
-		var stats struct {
-			Posted         int            `json:"posted"`
-			Views          int            `json:"views"`
-			UniqueViewers  int            `json:"unique_viewers"`
-			ReactionCounts map[string]int `json:"reaction_counts"`
-		}
+		var stats users.UserStats
 		if err := json.Unmarshal([]byte(cached), &stats); err == nil {
-			return stats.Posted, stats.Views, stats.UniqueViewers, stats.ReactionCounts, nil
+			lookup(UserStatsKey, true)
+			return stats, nil
 		}
 	}
 
 	// Cache miss - fetch from database
-	posted, views, uniqueViewers, reactionCounts, err := c.storage.GetUserStats(userID)
+	lookup(UserStatsKey, false)
+	stats, err := c.storage.GetUserStats(userID)
 	if err != nil {
-		return 0, 0, 0, nil, err
+		return users.UserStats{}, err
 	}
 
 	// Cache the result
-	stats := struct {
-		Posted         int            `json:"posted"`
-		Views          int            `json:"views"`
-		UniqueViewers  int            `json:"unique_viewers"`
-		ReactionCounts map[string]int `json:"reaction_counts"`
-	}{
-		Posted:         posted,
-		Views:          views,
-		UniqueViewers:  uniqueViewers,
-		ReactionCounts: reactionCounts,
+	data, _ := json.Marshal(stats)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Stats))
+
+	return stats, nil
+}
+
+// GetCachedPublicProfile returns a user's public profile as viewerID sees it.
+// Counts are cached per user, since profiles are in the tenant's cache only
+// once loaded for a viewer in that tenant; the follow flags come from the
+// cached followee lists of both users, so they are always the viewer's own.
+func (c *CacheService) GetCachedPublicProfile(ctx context.Context, viewerID, userID string) (users.PublicProfile, error) {
+	key := c.key(UserProfileKey, userID)
+
+	var profile users.PublicProfile
+	cached, err := c.redis.Get(ctx, key).Result()
+	hit := err == nil && json.Unmarshal([]byte(cached), &profile) == nil
+	lookup(UserProfileKey, hit)
+	if !hit {
+		// Cache miss - fetch from database
+		profile, err = c.storage.GetPublicProfile(viewerID, userID)
+		if err != nil {
+			return users.PublicProfile{}, err
+		}
+
+		data, _ := json.Marshal(profile)
+		c.redis.Set(ctx, key, data, c.ttl(c.ttls.Profile))
 	}
 
-	data, _ := json.Marshal(stats)
-	c.redis.Set(ctx, key, data, StatsCacheDuration)
+	viewerFollowees, err := c.GetUserFollowees(viewerID)
+	if err != nil {
+		return users.PublicProfile{}, err
+	}
+	userFollowees, err := c.GetUserFollowees(userID)
+	if err != nil {
+		return users.PublicProfile{}, err
+	}
+	profile.IsFollowing = slices.Contains(viewerFollowees, userID)
+	profile.FollowsYou = slices.Contains(userFollowees, viewerID)
 
-	return posted, views, uniqueViewers, reactionCounts, nil
+	return profile, nil
 }
 
 // Methods to pass through to storage (implement storage.Storage interface)
-func (c *CacheService) CreateStory(authorID, text, mediaKey string, visibility types.Visibility, audienceUserIDs []string) (string, error) {
-	storyID, err := c.storage.CreateStory(authorID, text, mediaKey, visibility, audienceUserIDs)
+func (c *CacheService) CreateStory(authorID string, story types.StoryPostRequest) (string, error) {
+	storyID, err := c.storage.CreateStory(authorID, story)
 	if err != nil {
 		return "", err
 	}
@@ -212,35 +483,202 @@ func (c *CacheService) CreateStory(authorID, text, mediaKey string, visibility t
 	ctx := context.Background()
 	c.InvalidateUserCache(ctx, authorID)
 
-	// Invalidate feed caches for followers if public/friends story
-	if visibility == types.VisibilityPublic || visibility == types.VisibilityFriends {
-		followers, _ := c.GetUserFollowers(authorID)
-		c.InvalidateFeedCaches(ctx, followers)
-	}
+	// Followers' feeds include the author's epoch, so one bump covers them all
+	c.InvalidateAuthorFeeds(ctx, authorID)
 
-	// Invalidate specific users for private stories
-	if visibility == types.VisibilityPrivate {
-		c.InvalidateFeedCaches(ctx, audienceUserIDs)
+	// The audience of a private story need not follow the author, and nor
+	// need the members of its group
+	switch story.Visibility {
+	case types.VisibilityPrivate:
+		c.InvalidateFeedCaches(ctx, story.AudienceUserIDs)
+	case types.VisibilityGroup:
+		c.invalidateGroupFeeds(ctx, story.GroupID)
 	}
 
 	return storyID, nil
 }
 
-func (c *CacheService) CreateUser(email, password string) (string, error) {
-	return c.storage.CreateUser(email, password)
+func (c *CacheService) GetStorySettings(userID string) (types.StorySettings, error) {
+	return c.storage.GetStorySettings(userID)
+}
+
+func (c *CacheService) UpdateStorySettings(userID string, update types.StorySettingsUpdate) (types.StorySettings, error) {
+	return c.storage.UpdateStorySettings(userID, update)
+}
+
+// ReshareStory invalidates like CreateStory, and the original author's stats
+// so the reshare counts right away
+func (c *CacheService) ReshareStory(userID, storyID string, reshare types.ReshareRequest) (string, types.Story, error) {
+	reshareID, original, err := c.storage.ReshareStory(userID, storyID, reshare)
+	if err != nil {
+		return "", original, err
+	}
+
+	ctx := context.Background()
+	c.InvalidateUserCache(ctx, userID)
+	c.InvalidateAuthorFeeds(ctx, userID)
+	switch reshare.Visibility {
+	case types.VisibilityPrivate:
+		c.InvalidateFeedCaches(ctx, reshare.AudienceUserIDs)
+	case types.VisibilityGroup:
+		c.invalidateGroupFeeds(ctx, reshare.GroupID)
+	}
+	c.redis.Del(ctx, c.key(UserStatsKey, original.AuthorID))
+
+	return reshareID, original, nil
+}
+
+// UpdateStory drops the cached story and the feeds that show it as edited.
+// When the new visibility shuts anyone out, every feed of the tenant is
+// dropped at once, since users who do not follow the author may have had the
+// story in theirs.
+func (c *CacheService) UpdateStory(storyID string, version int, update types.StoryUpdateRequest) (types.Story, []string, error) {
+	story, revoked, err := c.storage.UpdateStory(storyID, version, update)
+	if err != nil {
+		return story, revoked, err
+	}
+
+	ctx := context.Background()
+	c.redis.Del(ctx, c.key(StoryKey, story.ID))
+	c.InvalidateUserCache(ctx, story.AuthorID)
+	c.InvalidateAuthorFeeds(ctx, story.AuthorID)
+	switch story.Visibility {
+	case types.VisibilityPrivate:
+		c.InvalidateFeedCaches(ctx, update.AudienceUserIDs)
+	case types.VisibilityGroup:
+		c.invalidateGroupFeeds(ctx, story.GroupID)
+	}
+	if len(revoked) > 0 {
+		c.InvalidateTenantFeeds(ctx)
+	}
+
+	return story, revoked, nil
+}
+
+// invalidateGroupFeeds makes the cached feeds of a group's members stale
+func (c *CacheService) invalidateGroupFeeds(ctx context.Context, groupID string) {
+	members, err := c.storage.GetGroupMembers(groupID)
+	if err != nil {
+		return
+	}
+
+	memberIDs := make([]string, len(members))
+	for i, member := range members {
+		memberIDs[i] = member.UserID
+	}
+	c.InvalidateFeedCaches(ctx, memberIDs)
+}
+
+func (c *CacheService) CreateGroup(ownerID, name string, memberIDs []string) (types.StoryGroup, error) {
+	return c.storage.CreateGroup(ownerID, name, memberIDs)
+}
+
+func (c *CacheService) GetGroups(userID string) ([]types.StoryGroup, error) {
+	return c.storage.GetGroups(userID)
+}
+
+func (c *CacheService) GetGroup(groupID, userID string) (types.StoryGroup, error) {
+	return c.storage.GetGroup(groupID, userID)
+}
+
+func (c *CacheService) GetGroupMembers(groupID string) ([]types.GroupMember, error) {
+	return c.storage.GetGroupMembers(groupID)
+}
+
+// AddGroupMembers invalidates the new members' feeds, which now show the
+// group's stories
+func (c *CacheService) AddGroupMembers(groupID, ownerID string, userIDs []string) error {
+	err := c.storage.AddGroupMembers(groupID, ownerID, userIDs)
+	if err != nil {
+		return err
+	}
+
+	c.InvalidateFeedCaches(context.Background(), userIDs)
+	return nil
+}
+
+// RemoveGroupMember invalidates the removed member's feed, which no longer
+// shows the group's stories
+func (c *CacheService) RemoveGroupMember(groupID, actorID, userID string) error {
+	err := c.storage.RemoveGroupMember(groupID, actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	c.InvalidateFeedCaches(context.Background(), []string{userID})
+	return nil
+}
+
+func (c *CacheService) GetGroupStories(groupID, userID string) ([]types.Story, error) {
+	return c.storage.GetGroupStories(groupID, userID)
 }
 
-func (c *CacheService) GetUserByEmail(email string) (string, string, error) {
-	return c.storage.GetUserByEmail(email)
+func (c *CacheService) CreateUser(tenantID, email, password string) (string, error) {
+	return c.storage.CreateUser(tenantID, email, password)
 }
 
-func (c *CacheService) GetAllPublicStories() ([]types.Story, error) {
-	return c.storage.GetAllPublicStories()
+func (c *CacheService) GetUserByEmail(tenantID, email string) (string, string, error) {
+	return c.storage.GetUserByEmail(tenantID, email)
 }
 
-func (c *CacheService) GetStoriesForUser(userID string) ([]types.Story, error) {
+func (c *CacheService) GetUserByID(userID string) (users.User, error) {
+	return c.storage.GetUserByID(userID)
+}
+
+func (c *CacheService) GetUserProfile(userID string) (users.Profile, error) {
+	return c.storage.GetUserProfile(userID)
+}
+
+func (c *CacheService) GetPublicProfile(viewerID, userID string) (users.PublicProfile, error) {
 	ctx := context.Background()
-	return c.GetCachedFeed(ctx, userID)
+	return c.GetCachedPublicProfile(ctx, viewerID, userID)
+}
+
+func (c *CacheService) DiscoverUsers(userID string, hashes []string) ([]users.DiscoveredUser, error) {
+	return c.storage.DiscoverUsers(userID, hashes)
+}
+
+func (c *CacheService) GetTenantUsers(tenantID string, userIDs []string) ([]string, error) {
+	return c.storage.GetTenantUsers(tenantID, userIDs)
+}
+
+// SetAvatarURL drops the user's cached profile. Feeds and trays that already
+// carry the old avatar keep it until they expire.
+func (c *CacheService) SetAvatarURL(userID, avatarURL string) error {
+	if err := c.storage.SetAvatarURL(userID, avatarURL); err != nil {
+		return err
+	}
+
+	c.redis.Del(context.Background(), c.key(UserProfileKey, userID))
+	return nil
+}
+
+func (c *CacheService) SetUserAdmin(userID string, isAdmin bool) error {
+	return c.storage.SetUserAdmin(userID, isAdmin)
+}
+
+func (c *CacheService) GetAllPublicStories(tenantID string) ([]types.Story, error) {
+	return c.storage.GetAllPublicStories(tenantID)
+}
+
+func (c *CacheService) GetStoriesForUser(ctx context.Context, userID string) ([]types.Story, error) {
+	stories, _, err := c.GetCachedFeed(ctx, userID)
+	return stories, err
+}
+
+// StreamStoriesForUser bypasses the feed cache: streaming is for feeds too
+// large to buffer, which are too large to cache as well
+func (c *CacheService) StreamStoriesForUser(ctx context.Context, userID string, fn func(types.Story) error) error {
+	return c.storage.StreamStoriesForUser(ctx, userID, fn)
+}
+
+func (c *CacheService) GetFeedTrays(ctx context.Context, userID string) ([]types.FeedTray, error) {
+	return c.GetCachedFeedTrays(ctx, userID)
+}
+
+// GetFeedChanges is not cached: each poll asks about a different window
+func (c *CacheService) GetFeedChanges(ctx context.Context, userID string, since time.Time) (types.FeedChanges, error) {
+	return c.storage.GetFeedChanges(ctx, userID, since)
 }
 
 func (c *CacheService) GetStoryByID(storyID string) (types.Story, error) {
@@ -248,35 +686,175 @@ func (c *CacheService) GetStoryByID(storyID string) (types.Story, error) {
 	return c.GetCachedStory(ctx, storyID)
 }
 
+// GetStoryForViewer serves stories without a view limit from the story cache,
+// since only limited stories look different to each viewer
+func (c *CacheService) GetStoryForViewer(storyID, viewerID string) (types.Story, error) {
+	story, err := c.GetStoryByID(storyID)
+	if err != nil || story.MaxViewsPerUser == 0 {
+		return story, err
+	}
+	return c.storage.GetStoryForViewer(storyID, viewerID)
+}
+
+func (c *CacheService) GetStoriesByIDs(storyIDs []string) ([]types.Story, error) {
+	ctx := context.Background()
+	return c.GetCachedStories(ctx, storyIDs)
+}
+
+func (c *CacheService) GetNearbyPublicStories(ctx context.Context, tenantID, viewerID string, lat, lng, radiusMeters float64) ([]types.Story, error) {
+	return c.storage.GetNearbyPublicStories(ctx, tenantID, viewerID, lat, lng, radiusMeters)
+}
+
 func (c *CacheService) CanUserViewStory(storyID, userID string) (bool, error) {
 	return c.storage.CanUserViewStory(storyID, userID)
 }
 
+func (c *CacheService) GetStoryAudience(storyID string) ([]string, error) {
+	return c.storage.GetStoryAudience(storyID)
+}
+
 func (c *CacheService) RecordStoryView(storyID, viewerID string) error {
-	return c.storage.RecordStoryView(storyID, viewerID)
+	err := c.storage.RecordStoryView(storyID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	// The viewed story may have been the last unseen one in its tray
+	ctx := context.Background()
+	if key, err := c.versionedKey(ctx, FeedTraysKey, viewerID); err == nil {
+		c.redis.Del(ctx, key)
+	}
+
+	// A view-limited story may now be used up, which the viewer's cached
+	// feed must not keep showing
+	if story, err := c.GetStoryByID(storyID); err != nil || story.MaxViewsPerUser > 0 {
+		c.InvalidateFeedCaches(ctx, []string{viewerID})
+	}
+
+	return nil
 }
 
-func (c *CacheService) AddReaction(storyID, userID string, emoji types.ReactionType) error {
-	return c.storage.AddReaction(storyID, userID, emoji)
+// GetStoryViewers is not cached so authors see views as they happen
+func (c *CacheService) GetStoryViewers(storyID string) ([]types.StoryViewer, error) {
+	return c.storage.GetStoryViewers(storyID)
 }
 
-func (c *CacheService) GetUserStats(userID string) (int, int, int, map[string]int, error) {
-	ctx := context.Background()
-	return c.GetCachedUserStats(ctx, userID)
+func (c *CacheService) AddReaction(storyID, userID string, emoji types.ReactionType) (types.ReactionType, error) {
+	previous, err := c.storage.AddReaction(storyID, userID, emoji)
+	if err != nil {
+		return "", err
+	}
+
+	c.invalidateAuthorStats(storyID)
+	return previous, nil
 }
 
-func (c *CacheService) FollowUser(followerID, followedID string) error {
-	err := c.storage.FollowUser(followerID, followedID)
+func (c *CacheService) RemoveReaction(storyID, userID string) (types.ReactionType, error) {
+	removed, err := c.storage.RemoveReaction(storyID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	c.invalidateAuthorStats(storyID)
+	return removed, nil
+}
+
+func (c *CacheService) RecordScreenshot(storyID, userID, kind string) (bool, error) {
+	first, err := c.storage.RecordScreenshot(storyID, userID, kind)
+	if err != nil || !first {
+		return first, err
+	}
+
+	// Invalidate the author's stats so the screenshot shows up in their insights
+	c.invalidateAuthorStats(storyID)
+	return true, nil
+}
+
+func (c *CacheService) RecordLinkClick(storyID, userID string) error {
+	err := c.storage.RecordLinkClick(storyID, userID)
 	if err != nil {
 		return err
 	}
 
+	// Invalidate the author's stats so the click shows up in their insights
+	c.invalidateAuthorStats(storyID)
+
+	return nil
+}
+
+// Impressions are written in bulk from the Redis buffer; authors' cached stats
+// pick them up when they expire rather than being dropped per story.
+func (c *CacheService) RecordImpressions(impressions []types.Impression) error {
+	return c.storage.RecordImpressions(impressions)
+}
+
+// invalidateAuthorStats drops the cached stats of a story's author, so their
+// insights reflect a reaction or click on it
+func (c *CacheService) invalidateAuthorStats(storyID string) {
+	story, err := c.GetStoryByID(storyID)
+	if err == nil {
+		c.redis.Del(context.Background(), c.key(UserStatsKey, story.AuthorID))
+	}
+}
+
+func (c *CacheService) CreateShareLink(storyID string, requireLogin bool, validFor time.Duration) (types.ShareLink, error) {
+	return c.storage.CreateShareLink(storyID, requireLogin, validFor)
+}
+
+func (c *CacheService) GetShareLinks(storyID string) ([]types.ShareLink, error) {
+	return c.storage.GetShareLinks(storyID)
+}
+
+func (c *CacheService) RevokeShareLink(storyID, linkID string) error {
+	return c.storage.RevokeShareLink(storyID, linkID)
+}
+
+func (c *CacheService) GetActiveShareLink(linkID string) (types.ShareLink, error) {
+	return c.storage.GetActiveShareLink(linkID)
+}
+
+func (c *CacheService) RecordShareLinkView(linkID string) error {
+	return c.storage.RecordShareLinkView(linkID)
+}
+
+func (c *CacheService) CreateAPIToken(userID, name, tokenHash string, scopes []string, validFor time.Duration) (users.APIToken, error) {
+	return c.storage.CreateAPIToken(userID, name, tokenHash, scopes, validFor)
+}
+
+func (c *CacheService) GetAPITokens(userID string) ([]users.APIToken, error) {
+	return c.storage.GetAPITokens(userID)
+}
+
+func (c *CacheService) RevokeAPIToken(userID, tokenID string) error {
+	return c.storage.RevokeAPIToken(userID, tokenID)
+}
+
+func (c *CacheService) UseAPIToken(tokenHash string) (users.APITokenOwner, error) {
+	return c.storage.UseAPIToken(tokenHash)
+}
+
+func (c *CacheService) GetUserStats(userID string) (users.UserStats, error) {
+	ctx := context.Background()
+	return c.GetCachedUserStats(ctx, userID)
+}
+
+// StreamDailyStoryMetrics is not cached: exports are rare and cover any range
+func (c *CacheService) StreamDailyStoryMetrics(ctx context.Context, userID string, from, to time.Time, fn func(users.DailyStoryMetrics) error) error {
+	return c.storage.StreamDailyStoryMetrics(ctx, userID, from, to, fn)
+}
+
+func (c *CacheService) FollowUser(followerID, followedID string) (bool, error) {
+	followed, err := c.storage.FollowUser(followerID, followedID)
+	if err != nil || !followed {
+		return followed, err
+	}
+
 	// Invalidate relevant caches
 	ctx := context.Background()
 	c.InvalidateUserCache(ctx, followerID) // Follower's feed will change
 	c.InvalidateUserCache(ctx, followedID) // Followed user's follower list changed
 
-	return nil
+	return true, nil
 }
 
 func (c *CacheService) UnfollowUser(followerID, followedID string) error {
@@ -293,10 +871,316 @@ func (c *CacheService) UnfollowUser(followerID, followedID string) error {
 	return nil
 }
 
+func (c *CacheService) FollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	results, err := c.storage.FollowUsers(followerID, followedIDs)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateFollows(followerID, results, types.FollowFollowed)
+	return results, nil
+}
+
+func (c *CacheService) UnfollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	results, err := c.storage.UnfollowUsers(followerID, followedIDs)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateFollows(followerID, results, types.FollowUnfollowed)
+	return results, nil
+}
+
+// invalidateFollows drops the caches a batch follow or unfollow changed: the
+// follower's, and those of the users whose result has status
+func (c *CacheService) invalidateFollows(followerID string, results []types.FollowResult, status string) {
+	ctx := context.Background()
+	changed := false
+	for _, result := range results {
+		if result.Status == status {
+			c.InvalidateUserCache(ctx, result.UserID)
+			changed = true
+		}
+	}
+	if changed {
+		c.InvalidateUserCache(ctx, followerID)
+	}
+}
+
 func (c *CacheService) IsFollowing(followerID, followedID string) (bool, error) {
 	return c.storage.IsFollowing(followerID, followedID)
 }
 
-func (c *CacheService) SoftDeleteExpiredStories() (int, error) {
+func (c *CacheService) SoftDeleteExpiredStories() ([]types.Story, error) {
 	return c.storage.SoftDeleteExpiredStories()
 }
+
+func (c *CacheService) DeleteStory(storyID string) (types.Story, error) {
+	story, err := c.storage.DeleteStory(storyID)
+	if err != nil {
+		return types.Story{}, err
+	}
+
+	c.dropStory(story)
+	return story, nil
+}
+
+func (c *CacheService) ExpireStory(storyID string) (types.Story, error) {
+	story, err := c.storage.ExpireStory(storyID)
+	if err != nil {
+		return types.Story{}, err
+	}
+
+	c.dropStory(story)
+	return story, nil
+}
+
+// dropStory removes a story that is no longer active from the caches that may
+// still serve it; feeds of a private story's audience who do not follow the
+// author age out within the feed TTL
+func (c *CacheService) dropStory(story types.Story) {
+	ctx := context.Background()
+	c.redis.Del(ctx, c.key(StoryKey, story.ID))
+	c.InvalidateUserCache(ctx, story.AuthorID)
+	c.InvalidateAuthorFeeds(ctx, story.AuthorID)
+}
+
+func (c *CacheService) ClaimExpiringStories(within time.Duration) ([]types.Story, error) {
+	return c.storage.ClaimExpiringStories(within)
+}
+
+func (c *CacheService) ReleaseExpiryWarning(storyID string) error {
+	return c.storage.ReleaseExpiryWarning(storyID)
+}
+
+func (c *CacheService) AddStoryToHighlights(storyID, userID string) error {
+	return c.storage.AddStoryToHighlights(storyID, userID)
+}
+
+func (c *CacheService) GetStoriesByAuthor(authorID string) ([]types.Story, error) {
+	return c.storage.GetStoriesByAuthor(authorID)
+}
+
+// Public keys and story envelopes are not cached, so a replaced key is used
+// straight away
+
+func (c *CacheService) SetPublicKey(userID string, key users.PublicKeyRequest) (users.PublicKey, error) {
+	return c.storage.SetPublicKey(userID, key)
+}
+
+func (c *CacheService) GetPublicKey(viewerID, userID string) (users.PublicKey, error) {
+	return c.storage.GetPublicKey(viewerID, userID)
+}
+
+func (c *CacheService) GetStoryEnvelope(storyID, userID string) (types.StoryEnvelope, error) {
+	return c.storage.GetStoryEnvelope(storyID, userID)
+}
+
+// The impersonation audit trail is not cached
+
+func (c *CacheService) RecordImpersonation(event users.ImpersonationEvent) error {
+	return c.storage.RecordImpersonation(event)
+}
+
+func (c *CacheService) GetImpersonationEvents(adminID, userID string, limit int) ([]users.ImpersonationEvent, error) {
+	return c.storage.GetImpersonationEvents(adminID, userID, limit)
+}
+
+// Abuse flags are not cached
+
+func (c *CacheService) FlagAbuse(flag users.AbuseFlag) error {
+	return c.storage.FlagAbuse(flag)
+}
+
+func (c *CacheService) GetAbuseFlags(adminID string, reviewed bool, limit int) ([]users.AbuseFlag, error) {
+	return c.storage.GetAbuseFlags(adminID, reviewed, limit)
+}
+
+func (c *CacheService) ReviewAbuseFlag(adminID, flagID string) (users.AbuseFlag, error) {
+	return c.storage.ReviewAbuseFlag(adminID, flagID)
+}
+
+// The archive is not cached; archived stories left every feed long ago
+
+func (c *CacheService) GetArchivableStories(tenantID string, olderThan time.Duration, limit int) ([]types.ArchivedStory, error) {
+	return c.storage.GetArchivableStories(tenantID, olderThan, limit)
+}
+
+func (c *CacheService) CountArchivableStories(tenantID string, olderThan time.Duration) (int, error) {
+	return c.storage.CountArchivableStories(tenantID, olderThan)
+}
+
+func (c *CacheService) MarkStoriesArchived(tenantID, objectKey string, storyIDs []string) error {
+	return c.storage.MarkStoriesArchived(tenantID, objectKey, storyIDs)
+}
+
+func (c *CacheService) GetArchiveEntry(storyID string) (types.ArchiveEntry, error) {
+	return c.storage.GetArchiveEntry(storyID)
+}
+
+func (c *CacheService) RestoreArchivedStory(story types.ArchivedStory) (types.Story, error) {
+	return c.storage.RestoreArchivedStory(story)
+}
+
+// Media upload records are not cached
+
+func (c *CacheService) CreateMediaUpload(userID, objectKey, contentType string) error {
+	return c.storage.CreateMediaUpload(userID, objectKey, contentType)
+}
+
+func (c *CacheService) ConfirmMediaUpload(userID, objectKey string, size int64) (media.MediaUpload, error) {
+	return c.storage.ConfirmMediaUpload(userID, objectKey, size)
+}
+
+func (c *CacheService) GetMediaUpload(userID, objectKey string) (media.MediaUpload, error) {
+	return c.storage.GetMediaUpload(userID, objectKey)
+}
+
+func (c *CacheService) SetMediaUploadStatus(objectKey, status string, size int64) (media.MediaUpload, error) {
+	return c.storage.SetMediaUploadStatus(objectKey, status, size)
+}
+
+func (c *CacheService) GetMediaUploads(tenantID string) ([]media.MediaUpload, error) {
+	return c.storage.GetMediaUploads(tenantID)
+}
+
+func (c *CacheService) GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) {
+	return c.storage.GetUserMediaUploads(ctx, tenantID, userID, after, limit)
+}
+
+func (c *CacheService) DeleteMediaUpload(objectKey string) error {
+	return c.storage.DeleteMediaUpload(objectKey)
+}
+
+func (c *CacheService) GetTenantIDs() ([]string, error) {
+	return c.storage.GetTenantIDs()
+}
+
+func (c *CacheService) GetPrivacySettings(userID string) (users.PrivacySettings, error) {
+	return c.storage.GetPrivacySettings(userID)
+}
+
+func (c *CacheService) SetPrivacySettings(userID string, settings users.PrivacySettings) error {
+	return c.storage.SetPrivacySettings(userID, settings)
+}
+
+// GetNotificationSettings returns the user's cached notification settings or
+// fetches them from DB. The event publisher checks them for every
+// notification without knowing the user's tenant, so they are cached in the
+// tenant the user belongs to rather than the one c is scoped to. When that
+// tenant cannot be looked up they are read from DB uncached.
+func (c *CacheService) GetNotificationSettings(userID string) (users.NotificationSettings, error) {
+	ctx := context.Background()
+	key, err := c.notificationSettingsKey(ctx, userID)
+	if err != nil {
+		return c.storage.GetNotificationSettings(userID)
+	}
+
+	cached, err := c.redis.Get(ctx, key).Result()
+	if err == nil {
+		var settings users.NotificationSettings
+		if err := json.Unmarshal([]byte(cached), &settings); err == nil {
+			lookup(NotificationSettingsKey, true)
+			return settings, nil
+		}
+	}
+
+	lookup(NotificationSettingsKey, false)
+	settings, err := c.storage.GetNotificationSettings(userID)
+	if err != nil {
+		return users.NotificationSettings{}, err
+	}
+
+	data, _ := json.Marshal(settings)
+	c.redis.Set(ctx, key, data, c.ttl(c.ttls.Notifications))
+
+	return settings, nil
+}
+
+// SetNotificationSettings drops the user's cached notification settings
+func (c *CacheService) SetNotificationSettings(userID string, settings users.NotificationSettings) error {
+	err := c.storage.SetNotificationSettings(userID, settings)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	key, err := c.notificationSettingsKey(ctx, userID)
+	if err != nil {
+		return err
+	}
+	c.redis.Del(ctx, key)
+	return nil
+}
+
+// notificationSettingsKey builds the key of the user's notification settings
+// in the tenant they belong to
+func (c *CacheService) notificationSettingsKey(ctx context.Context, userID string) (string, error) {
+	tenantID, err := c.userTenant(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return c.keys.ForTenant(tenantID).Key(NotificationSettingsKey, userID), nil
+}
+
+// userTenant returns the tenant the user belongs to. Users never move, so it
+// is cached under a key shared by every tenant; user IDs are unique across
+// tenants.
+func (c *CacheService) userTenant(ctx context.Context, userID string) (string, error) {
+	key := Keys{env: c.keys.env}.Key(UserTenantKey, userID)
+	if tenantID, err := c.redis.Get(ctx, key).Result(); err == nil {
+		lookup(UserTenantKey, true)
+		return tenantID, nil
+	}
+
+	lookup(UserTenantKey, false)
+	user, err := c.storage.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	c.redis.Set(ctx, key, user.TenantID, c.ttl(c.ttls.Notifications))
+	return user.TenantID, nil
+}
+
+func (c *CacheService) QueueNotification(userID string, event *types.Event) error {
+	return c.storage.QueueNotification(userID, event)
+}
+
+func (c *CacheService) GetQueuedNotificationUsers() (map[string]users.NotificationSettings, error) {
+	return c.storage.GetQueuedNotificationUsers()
+}
+
+func (c *CacheService) ClaimQueuedNotifications(userID string) (map[types.EventType]int, error) {
+	return c.storage.ClaimQueuedNotifications(userID)
+}
+
+func (c *CacheService) GetFollowerDigests(limit int) ([]users.FollowerDigest, error) {
+	return c.storage.GetFollowerDigests(limit)
+}
+
+func (c *CacheService) MarkFollowersEmailed(userID string, until time.Time) error {
+	return c.storage.MarkFollowersEmailed(userID, until)
+}
+
+func (c *CacheService) GetWeeklyStatsRecipients(limit int) ([]users.EmailRecipient, error) {
+	return c.storage.GetWeeklyStatsRecipients(limit)
+}
+
+func (c *CacheService) MarkWeeklyStatsEmailed(userID string) error {
+	return c.storage.MarkWeeklyStatsEmailed(userID)
+}
+
+func (c *CacheService) GetActiveCreators(ctx context.Context, weekStart time.Time) ([]string, error) {
+	return c.storage.GetActiveCreators(ctx, weekStart)
+}
+
+func (c *CacheService) SaveWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) {
+	return c.storage.SaveWeeklyRecap(ctx, userID, weekStart)
+}
+
+func (c *CacheService) MarkWeeklyRecapDelivered(ctx context.Context, userID string, weekStart time.Time) error {
+	return c.storage.MarkWeeklyRecapDelivered(ctx, userID, weekStart)
+}
+
+func (c *CacheService) GetWeeklyRecaps(ctx context.Context, userID string, limit int) ([]users.WeeklyRecap, error) {
+	return c.storage.GetWeeklyRecaps(ctx, userID, limit)
+}
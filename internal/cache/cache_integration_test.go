@@ -0,0 +1,189 @@
+//go:build integration
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+func TestCacheServiceIntegration(t *testing.T) {
+	cfg := testutil.NewConfig()
+	store := testutil.StartPostgres(t, cfg)
+	redisClient, _ := testutil.StartRedis(t, cfg)
+	cacheService := cache.NewCacheService(store, redisClient, cache.NewKeys(""), cache.TTLsFromConfig(cfg.Cache))
+	ctx := context.Background()
+
+	authorEmail := testutil.UniqueEmail("author")
+	author := testutil.CreateUser(t, store, authorEmail)
+	follower := testutil.CreateUser(t, store, testutil.UniqueEmail("follower"))
+
+	t.Run("FollowInvalidatesFeed", func(t *testing.T) {
+		followers := testutil.CreateStory(t, cacheService, author, types.VisibilityFollowers)
+
+		// Prime the follower's feed cache before they follow the author
+		stories, err := cacheService.GetStoriesForUser(ctx, follower)
+		if err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+		if slices.Contains(testutil.StoryIDs(stories), followers) {
+			t.Fatal("Expected followers story to be hidden before following")
+		}
+
+		testutil.Follow(t, cacheService, follower, author)
+
+		stories, err = cacheService.GetStoriesForUser(ctx, follower)
+		if err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+		if !slices.Contains(testutil.StoryIDs(stories), followers) {
+			t.Errorf("Expected followers story in feed after following, got %v", testutil.StoryIDs(stories))
+		}
+	})
+
+	t.Run("CreateStoryInvalidatesFollowerFeeds", func(t *testing.T) {
+		if _, err := cacheService.GetStoriesForUser(ctx, follower); err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+
+		public := testutil.CreateStory(t, cacheService, author, types.VisibilityPublic)
+
+		stories, err := cacheService.GetStoriesForUser(ctx, follower)
+		if err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+		if !slices.Contains(testutil.StoryIDs(stories), public) {
+			t.Errorf("Expected new story in follower feed, got %v", testutil.StoryIDs(stories))
+		}
+	})
+
+	t.Run("PublicProfile", func(t *testing.T) {
+		viewer := testutil.CreateUser(t, store, testutil.UniqueEmail("viewer"))
+
+		// Prime the cached profile before anything changes
+		before, err := cacheService.GetPublicProfile(viewer, author)
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		if before.IsFollowing || before.FollowsYou {
+			t.Fatalf("Expected no follow relationship yet, got %+v", before)
+		}
+
+		testutil.Follow(t, cacheService, viewer, author)
+		testutil.CreateStory(t, cacheService, author, types.VisibilityPublic)
+		testutil.CreateStory(t, cacheService, author, types.VisibilityPrivate)
+
+		after, err := cacheService.GetPublicProfile(viewer, author)
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		if !after.IsFollowing || after.FollowsYou {
+			t.Errorf("Expected the viewer to follow the author only, got %+v", after)
+		}
+		if after.Followers != before.Followers+1 || after.PublicStories != before.PublicStories+1 {
+			t.Errorf("Expected one more follower and public story, got %+v then %+v", before, after)
+		}
+
+		// Seen from the author's side, the follow flags swap
+		self, err := cacheService.GetPublicProfile(author, viewer)
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		if self.IsFollowing || !self.FollowsYou {
+			t.Errorf("Expected the viewer to follow the author, got %+v", self)
+		}
+
+		// Each tenant has its own cache, so the cached profile stays in the author's
+		outsider := testutil.CreateTenantUser(t, store, "other-tenant", testutil.UniqueEmail("outsider"))
+		if _, err := cacheService.ForTenant("other-tenant").GetPublicProfile(outsider, author); !errors.Is(err, storage.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound across tenants, got %v", err)
+		}
+	})
+
+	t.Run("GetCachedStories", func(t *testing.T) {
+		cached := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+		uncached := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+		deleted := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+		if _, err := store.DeleteStory(deleted); err != nil {
+			t.Fatalf("DeleteStory failed: %v", err)
+		}
+
+		if _, err := cacheService.GetCachedStory(ctx, cached); err != nil {
+			t.Fatalf("GetCachedStory failed: %v", err)
+		}
+
+		stories, err := cacheService.GetCachedStories(ctx, []string{uncached, deleted, cached})
+		if err != nil {
+			t.Fatalf("GetCachedStories failed: %v", err)
+		}
+		if ids := testutil.StoryIDs(stories); !slices.Equal(ids, []string{uncached, cached}) {
+			t.Errorf("Expected active stories in request order, got %v", ids)
+		}
+
+		// The miss was backfilled, so it is now served from the cache
+		if n, err := redisClient.Exists(ctx, cache.NewKeys("").Key(cache.StoryKey, uncached)).Result(); err != nil || n != 1 {
+			t.Errorf("Expected story %s to be cached, got %d (%v)", uncached, n, err)
+		}
+	})
+
+	t.Run("FeedAuthors", func(t *testing.T) {
+		// A new story drops the cached feed, so it is read from the database
+		// and then from the cache, and both carry each story's author
+		public := testutil.CreateStory(t, cacheService, author, types.VisibilityPublic)
+		for _, source := range []string{"database", "cache"} {
+			stories, err := cacheService.GetStoriesForUser(ctx, follower)
+			if err != nil {
+				t.Fatalf("GetStoriesForUser failed: %v", err)
+			}
+			if !slices.Contains(testutil.StoryIDs(stories), public) {
+				t.Fatalf("Expected story %s in the feed from the %s", public, source)
+			}
+			for _, story := range stories {
+				if story.Author == nil || story.Author.ID != story.AuthorID {
+					t.Errorf("Expected story %s from the %s to carry its author, got %+v", story.ID, source, story.Author)
+				} else if story.AuthorID == author && story.Author.Email != authorEmail {
+					t.Errorf("Expected author email %s, got %s", authorEmail, story.Author.Email)
+				}
+			}
+		}
+	})
+
+	t.Run("OptimizedFeed", func(t *testing.T) {
+		public := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+		if err := store.RecordStoryView(public, follower); err != nil {
+			t.Fatalf("RecordStoryView failed: %v", err)
+		}
+		if _, err := store.AddReaction(public, follower, types.ReactionHeart); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+
+		stories, err := cache.NewOptimizedFeedQuery(store.GetDB()).GetOptimizedFeedForUser(context.Background(), follower, nil, 50)
+		if err != nil {
+			t.Fatalf("GetOptimizedFeedForUser failed: %v", err)
+		}
+
+		for _, story := range stories {
+			if story.ID != public {
+				continue
+			}
+			if story.ViewCount != 1 || story.ReactionCount != 1 || !story.UserHasViewed || story.UserReaction != string(types.ReactionHeart) {
+				t.Errorf("Unexpected story metadata: %+v", story)
+			}
+			if len(story.ReactionBreakdown) != 1 || story.ReactionBreakdown[string(types.ReactionHeart)] != 1 {
+				t.Errorf("Expected one heart in the reaction breakdown, got %v", story.ReactionBreakdown)
+			}
+			if story.Author == nil || story.Author.ID != author || story.Author.Email != authorEmail {
+				t.Errorf("Expected the author's profile, got %+v", story.Author)
+			}
+			return
+		}
+		t.Errorf("Expected story %s in optimized feed", public)
+	})
+}
@@ -0,0 +1,361 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage/mocks"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"go.uber.org/mock/gomock"
+)
+
+// testTTLs are the default TTLs without jitter, so tests see exact expiries
+var testTTLs = TTLs{
+	Followees:     5 * time.Minute,
+	Feed:          45 * time.Second,
+	Story:         10 * time.Minute,
+	Stats:         2 * time.Minute,
+	Profile:       2 * time.Minute,
+	Hidden:        5 * time.Minute,
+	Affinity:      10 * time.Minute,
+	Notifications: 5 * time.Minute,
+}
+
+func TestTTLsFromConfig(t *testing.T) {
+	ttls := TTLsFromConfig(config.Cache{FeedTTL: 45, StoryTTL: 600, Jitter: 10})
+
+	if ttls.Feed != 45*time.Second || ttls.Story != 10*time.Minute {
+		t.Errorf("Expected TTLs in seconds, got feed %s and story %s", ttls.Feed, ttls.Story)
+	}
+	if ttls.Jitter != 0.1 {
+		t.Errorf("Expected a jitter of 0.1, got %v", ttls.Jitter)
+	}
+}
+
+func TestTTL_Jitter(t *testing.T) {
+	c := &CacheService{ttls: TTLs{Jitter: 0.1}}
+
+	seen := make(map[time.Duration]bool)
+	for range 100 {
+		ttl := c.ttl(time.Minute)
+		if ttl < 54*time.Second || ttl > 66*time.Second {
+			t.Fatalf("Expected a TTL within 10%% of a minute, got %s", ttl)
+		}
+		seen[ttl] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected jittered TTLs to differ, got %v", seen)
+	}
+
+	c.ttls.Jitter = 0
+	if ttl := c.ttl(time.Minute); ttl != time.Minute {
+		t.Errorf("Expected no jitter to keep the TTL, got %s", ttl)
+	}
+
+	c.ttls.Jitter = 2
+	for range 100 {
+		if ttl := c.ttl(time.Minute); ttl <= 0 {
+			t.Fatalf("Expected a jitter over 100%% never to give a TTL of %s", ttl)
+		}
+	}
+}
+
+// testStories are the active stories the storage mocks serve, by ID
+var testStories = map[string]types.Story{
+	"1": {ID: "1", AuthorID: "author"},
+	"2": {ID: "2", AuthorID: "author"},
+}
+
+// testFollowees is the follow graph the storage mocks serve
+var testFollowees = map[string][]string{"reader": {"author"}}
+
+var errDatabaseDown = errors.New("database down")
+
+func setupCacheTest(t *testing.T) (*CacheService, *mocks.MockStorage, *miniredis.Miniredis) {
+	redisClient, mr := redistest.New(t)
+	store := mocks.NewMockStorage(gomock.NewController(t))
+	return NewCacheService(store, redisClient, NewKeys(""), testTTLs), store, mr
+}
+
+// serveFeeds lets the mock serve followees and feeds as often as asked, for
+// tests that only look at what the cache holds
+func serveFeeds(store *mocks.MockStorage) {
+	store.EXPECT().GetUserFollowees(gomock.Any()).DoAndReturn(func(userID string) ([]string, error) {
+		return testFollowees[userID], nil
+	}).AnyTimes()
+	store.EXPECT().GetStoriesForUser(gomock.Any(), gomock.Any()).Return([]types.Story{testStories["1"]}, nil).AnyTimes()
+}
+
+func TestGetUserFollowees_CachesResult(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+
+	// Only the miss is loaded
+	store.EXPECT().GetUserFollowees("reader").Return([]string{"author"}, nil)
+
+	for range 2 {
+		followees, err := c.GetUserFollowees("reader")
+		if err != nil {
+			t.Fatalf("GetUserFollowees failed: %v", err)
+		}
+		if !slices.Equal(followees, []string{"author"}) {
+			t.Errorf("Expected [author], got %v", followees)
+		}
+	}
+	if ttl := mr.TTL(c.key(UserFolloweesKey, "reader")); ttl != testTTLs.Followees {
+		t.Errorf("Expected followees cached for %s, got %s", testTTLs.Followees, ttl)
+	}
+}
+
+func TestGetCachedStory(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	ctx := context.Background()
+	key := c.key(StoryKey, "1")
+	before := metrics.CacheLookups()[string(StoryKey)]
+
+	read := func(wantQuery bool) {
+		t.Helper()
+		if wantQuery {
+			store.EXPECT().GetStoryByID("1").Return(testStories["1"], nil)
+		}
+		story, err := c.GetCachedStory(ctx, "1")
+		if err != nil {
+			t.Fatalf("GetCachedStory failed: %v", err)
+		}
+		if story.ID != "1" || story.AuthorID != "author" {
+			t.Errorf("Expected story 1 by author, got %+v", story)
+		}
+	}
+
+	read(true)  // miss
+	read(false) // hit
+
+	// A corrupt entry is read through and replaced
+	mr.Set(key, "{not json")
+	read(true)
+	if cached, _ := mr.Get(key); !json.Valid([]byte(cached)) {
+		t.Errorf("Expected the corrupt entry to be replaced, got %q", cached)
+	}
+	read(false)
+
+	// The corrupt entry counts as a miss
+	after := metrics.CacheLookups()[string(StoryKey)]
+	if hits, misses := after.Hits-before.Hits, after.Misses-before.Misses; hits != 2 || misses != 2 {
+		t.Errorf("Expected 2 hits and 2 misses, got %d and %d", hits, misses)
+	}
+}
+
+func TestGetCachedStory_StorageError(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	store.EXPECT().GetStoryByID("1").Return(types.Story{}, errDatabaseDown)
+
+	if _, err := c.GetCachedStory(context.Background(), "1"); !errors.Is(err, errDatabaseDown) {
+		t.Errorf("Expected the storage error, got %v", err)
+	}
+	if mr.Exists(c.key(StoryKey, "1")) {
+		t.Error("Expected nothing to be cached after a failed load")
+	}
+}
+
+func TestGetCachedStories_LoadsMissesTogether(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	ctx := context.Background()
+
+	c.CacheStory(ctx, testStories["2"])
+	mr.Set(c.key(StoryKey, "corrupt"), "{not json")
+
+	// The misses are loaded in one query, and only once
+	store.EXPECT().GetStoriesByIDs([]string{"1", "gone", "corrupt"}).Return([]types.Story{testStories["1"]}, nil)
+
+	stories, err := c.GetCachedStories(ctx, []string{"1", "gone", "2", "corrupt"})
+	if err != nil {
+		t.Fatalf("GetCachedStories failed: %v", err)
+	}
+	var ids []string
+	for _, story := range stories {
+		ids = append(ids, story.ID)
+	}
+	if !slices.Equal(ids, []string{"1", "2"}) {
+		t.Errorf("Expected active stories in request order, got %v", ids)
+	}
+	if !mr.Exists(c.key(StoryKey, "1")) {
+		t.Error("Expected the loaded story to be cached")
+	}
+
+	if _, err := c.GetCachedStories(ctx, []string{"1", "2"}); err != nil {
+		t.Fatalf("GetCachedStories failed: %v", err)
+	}
+}
+
+func TestGetCachedUserStats(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	ctx := context.Background()
+	want := users.UserStats{Posted: 2, Views: 10}
+
+	// Only the miss is loaded
+	store.EXPECT().GetUserStats("author").Return(want, nil)
+
+	for range 2 {
+		stats, err := c.GetCachedUserStats(ctx, "author")
+		if err != nil {
+			t.Fatalf("GetCachedUserStats failed: %v", err)
+		}
+		if stats.Posted != 2 || stats.Views != 10 {
+			t.Errorf("Expected %+v, got %+v", want, stats)
+		}
+	}
+	if ttl := mr.TTL(c.key(UserStatsKey, "author")); ttl != testTTLs.Stats {
+		t.Errorf("Expected stats cached for %s, got %s", testTTLs.Stats, ttl)
+	}
+
+	// New stories change the author's stats, so they are loaded again
+	store.EXPECT().CreateStory("author", gomock.Any()).Return("3", nil)
+	if _, err := c.CreateStory("author", types.StoryPostRequest{Visibility: types.VisibilityPublic}); err != nil {
+		t.Fatalf("CreateStory failed: %v", err)
+	}
+	store.EXPECT().GetUserStats("author").Return(want, nil)
+	if _, err := c.GetCachedUserStats(ctx, "author"); err != nil {
+		t.Fatalf("GetCachedUserStats failed: %v", err)
+	}
+}
+
+func TestGetNotificationSettings(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	quiet := users.NotificationSettings{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"}
+
+	// Only the miss is loaded, whichever tenant the reader is scoped to, and
+	// cached in the user's own tenant
+	store.EXPECT().GetUserByID("author").Return(users.User{ID: "author", TenantID: "acme"}, nil)
+	store.EXPECT().GetNotificationSettings("author").Return(quiet, nil)
+
+	for _, scoped := range []*CacheService{c, c.ForTenant("acme")} {
+		settings, err := scoped.GetNotificationSettings("author")
+		if err != nil {
+			t.Fatalf("GetNotificationSettings failed: %v", err)
+		}
+		if settings != quiet {
+			t.Errorf("Expected %+v, got %+v", quiet, settings)
+		}
+	}
+	if ttl := mr.TTL(c.keys.ForTenant("acme").Key(NotificationSettingsKey, "author")); ttl != testTTLs.Notifications {
+		t.Errorf("Expected settings cached in the user's tenant for %s, got %s", testTTLs.Notifications, ttl)
+	}
+
+	// Updating them from any tenant's cache, as unsubscribe links do from the
+	// default one, drops the entry the publisher reads
+	store.EXPECT().SetNotificationSettings("author", users.NotificationSettings{}).Return(nil)
+	if err := c.SetNotificationSettings("author", users.NotificationSettings{}); err != nil {
+		t.Fatalf("SetNotificationSettings failed: %v", err)
+	}
+	store.EXPECT().GetNotificationSettings("author").Return(users.NotificationSettings{}, nil)
+	if settings, err := c.GetNotificationSettings("author"); err != nil || settings.QuietHoursStart != "" {
+		t.Errorf("Expected the updated settings, got %+v, %v", settings, err)
+	}
+}
+
+func TestCreateStory_Invalidation(t *testing.T) {
+	c, store, _ := setupCacheTest(t)
+	ctx := context.Background()
+	serveFeeds(store)
+
+	feed := func(userID string, wantHit bool) {
+		t.Helper()
+		if _, hit, err := c.GetCachedFeed(ctx, userID); err != nil {
+			t.Fatalf("GetCachedFeed failed: %v", err)
+		} else if hit != wantHit {
+			t.Errorf("Expected %s's feed hit=%v", userID, wantHit)
+		}
+	}
+
+	feed("reader", false)
+	feed("reader", true)
+	feed("friend", false)
+	feed("friend", true)
+
+	// The reader follows the author; the friend only sees the private story
+	store.EXPECT().CreateStory("author", gomock.Any()).Return("3", nil)
+	if _, err := c.CreateStory("author", types.StoryPostRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{"friend"}}); err != nil {
+		t.Fatalf("CreateStory failed: %v", err)
+	}
+	feed("reader", false)
+	feed("friend", false)
+
+	// A failed write leaves the caches alone
+	store.EXPECT().CreateStory("author", gomock.Any()).Return("", errDatabaseDown)
+	if _, err := c.CreateStory("author", types.StoryPostRequest{Visibility: types.VisibilityPublic}); err == nil {
+		t.Fatal("Expected CreateStory to fail")
+	}
+	feed("reader", true)
+}
+
+func TestFollowUser_Invalidation(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	ctx := context.Background()
+	serveFeeds(store)
+	store.EXPECT().GetUserStats(gomock.Any()).Return(users.UserStats{}, nil).AnyTimes()
+
+	cache := func() {
+		for _, userID := range []string{"reader", "author"} {
+			if _, err := c.GetUserFollowees(userID); err != nil {
+				t.Fatalf("GetUserFollowees failed: %v", err)
+			}
+			if _, err := c.GetCachedUserStats(ctx, userID); err != nil {
+				t.Fatalf("GetCachedUserStats failed: %v", err)
+			}
+		}
+	}
+	cached := func(userID string) bool {
+		return mr.Exists(c.key(UserFolloweesKey, userID)) && mr.Exists(c.key(UserStatsKey, userID))
+	}
+
+	cache()
+	store.EXPECT().FollowUser("reader", "author").Return(true, nil)
+	if followed, err := c.FollowUser("reader", "author"); err != nil || !followed {
+		t.Fatalf("Expected a new follow, got %v, %v", followed, err)
+	}
+	if cached("reader") || cached("author") {
+		t.Error("Expected a new follow to drop both users' caches")
+	}
+
+	// Following again changes nothing, so nothing is dropped
+	cache()
+	store.EXPECT().FollowUser("reader", "author").Return(false, nil)
+	if followed, err := c.FollowUser("reader", "author"); err != nil || followed {
+		t.Fatalf("Expected an existing follow, got %v, %v", followed, err)
+	}
+	if !cached("reader") || !cached("author") {
+		t.Error("Expected an existing follow to keep both users' caches")
+	}
+}
+
+func TestFollowUsers_InvalidatesChangedUsers(t *testing.T) {
+	c, store, mr := setupCacheTest(t)
+	serveFeeds(store)
+
+	for _, userID := range []string{"reader", "new", "known"} {
+		if _, err := c.GetUserFollowees(userID); err != nil {
+			t.Fatalf("GetUserFollowees failed: %v", err)
+		}
+	}
+
+	store.EXPECT().FollowUsers("reader", []string{"new", "known"}).Return([]types.FollowResult{
+		{UserID: "new", Status: types.FollowFollowed},
+		{UserID: "known", Status: types.FollowAlreadyFollowing},
+	}, nil)
+	if _, err := c.FollowUsers("reader", []string{"new", "known"}); err != nil {
+		t.Fatalf("FollowUsers failed: %v", err)
+	}
+	for userID, want := range map[string]bool{"reader": false, "new": false, "known": true} {
+		if got := mr.Exists(c.key(UserFolloweesKey, userID)); got != want {
+			t.Errorf("Expected %s's followees cached=%v, got %v", userID, want, got)
+		}
+	}
+}
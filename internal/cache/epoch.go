@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Feed and tray caches are versioned rather than deleted. Their keys end in a
+// version hashed from epoch counters, so bumping a counter makes every key
+// built from it unreachable and the stale entries simply expire. Each user
+// has two counters: their author epoch, bumped when their stories change and
+// part of the version of every follower's feed, and their feed epoch, bumped
+// when only their own feed needs rebuilding. Their keys are in AuthorEpochKey
+// and FeedEpochKey. A third counter, in TenantEpochKey, is part of the version
+// of every feed in the tenant, for changes that may reach any of them.
+
+// EpochDuration keeps epochs far longer than the entries they version, so an
+// epoch never resets while a key built from an earlier value is still cached
+const EpochDuration = 24 * time.Hour
+
+// versionedKey returns the current key of userID's cache entry in ns,
+// whose version covers the tenant epoch, the user's feed epoch and the author
+// epochs of the user and everyone they follow. Reading it costs one MGET, so
+// invalidating a feed never has to touch the feeds of an author's followers
+// one by one.
+func (c *CacheService) versionedKey(ctx context.Context, ns Namespace, userID string) (string, error) {
+	followees, err := c.GetUserFollowees(userID)
+	if err != nil {
+		return "", err
+	}
+
+	authors := append([]string{userID}, followees...)
+	slices.Sort(authors)
+
+	epochKeys := make([]string, 0, len(authors)+2)
+	epochKeys = append(epochKeys, c.keys.Key(TenantEpochKey), c.key(FeedEpochKey, userID))
+	for _, authorID := range authors {
+		epochKeys = append(epochKeys, c.key(AuthorEpochKey, authorID))
+	}
+
+	epochs, err := c.redis.MGet(ctx, epochKeys...).Result()
+	if err != nil {
+		return "", err
+	}
+
+	version := fnv.New64a()
+	for i, epochKey := range epochKeys {
+		fmt.Fprintf(version, "%s=%v;", epochKey, epochs[i])
+	}
+
+	return c.key(ns, userID) + ":" + strconv.FormatUint(version.Sum64(), 36), nil
+}
+
+// InvalidateAuthorFeeds makes every cached feed and tray that may show
+// authorID's stories stale with a single INCR, however many followers they have
+func (c *CacheService) InvalidateAuthorFeeds(ctx context.Context, authorID string) {
+	c.bumpEpochs(ctx, AuthorEpochKey, []string{authorID})
+}
+
+// InvalidateTenantFeeds makes every cached feed and tray of the tenant stale,
+// for a change that may show in feeds of users who do not follow its author,
+// such as a public story becoming private
+func (c *CacheService) InvalidateTenantFeeds(ctx context.Context) {
+	key := c.keys.Key(TenantEpochKey)
+	pipe := c.redis.Pipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, EpochDuration)
+	pipe.Exec(ctx)
+}
+
+// InvalidateFeedCaches makes the cached feeds and trays of userIDs stale
+func (c *CacheService) InvalidateFeedCaches(ctx context.Context, userIDs []string) {
+	c.bumpEpochs(ctx, FeedEpochKey, userIDs)
+}
+
+// bumpEpochs increments the epochs in ns of userIDs in one pipeline
+func (c *CacheService) bumpEpochs(ctx context.Context, ns Namespace, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	pipe := c.redis.Pipeline()
+	for _, userID := range userIDs {
+		key := c.key(ns, userID)
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, EpochDuration)
+	}
+	pipe.Exec(ctx)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/storage/mocks"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"go.uber.org/mock/gomock"
+)
+
+func setupEpochTest(t *testing.T) (*CacheService, *mocks.MockStorage) {
+	redisClient, _ := redistest.New(t)
+
+	store := mocks.NewMockStorage(gomock.NewController(t))
+	store.EXPECT().GetUserFollowees(gomock.Any()).DoAndReturn(func(userID string) ([]string, error) {
+		return testFollowees[userID], nil
+	}).AnyTimes()
+	return NewCacheService(store, redisClient, NewKeys(""), testTTLs), store
+}
+
+func TestVersionedFeed_Invalidation(t *testing.T) {
+	c, store := setupEpochTest(t)
+	ctx := context.Background()
+
+	// Only misses query the feed
+	read := func(wantHit bool) {
+		t.Helper()
+		if !wantHit {
+			store.EXPECT().GetStoriesForUser(gomock.Any(), "reader").Return([]types.Story{testStories["1"]}, nil)
+		}
+		if _, hit, err := c.GetCachedFeed(ctx, "reader"); err != nil {
+			t.Fatalf("GetCachedFeed failed: %v", err)
+		} else if hit != wantHit {
+			t.Fatalf("Expected hit=%v", wantHit)
+		}
+	}
+
+	read(false)
+	read(true)
+
+	// Authors the reader does not follow leave the feed alone
+	c.InvalidateAuthorFeeds(ctx, "stranger")
+	read(true)
+
+	c.InvalidateAuthorFeeds(ctx, "author")
+	read(false)
+	read(true)
+
+	c.InvalidateFeedCaches(ctx, []string{"reader"})
+	read(false)
+}
+
+func TestVersionedFeed_TenantsAreSeparate(t *testing.T) {
+	c, _ := setupEpochTest(t)
+	ctx := context.Background()
+
+	key, err := c.versionedKey(ctx, FeedCacheKey, "reader")
+	if err != nil {
+		t.Fatalf("versionedKey failed: %v", err)
+	}
+
+	c.ForTenant("acme").InvalidateAuthorFeeds(ctx, "author")
+	if again, _ := c.versionedKey(ctx, FeedCacheKey, "reader"); again != key {
+		t.Errorf("Expected another tenant's epochs to leave the key alone, got %s then %s", key, again)
+	}
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+)
+
+// Namespace is the leading part of a family of Redis keys. Every key the
+// service uses is built from one of these by Keys, so this is the full list.
+type Namespace string
+
+// Cache entries, keyed by user ID unless noted
+const (
+	UserFolloweesKey        Namespace = "user:followees"
+	FeedCacheKey            Namespace = "feed:user"
+	FeedTraysKey            Namespace = "feed:trays"
+	StoryKey                Namespace = "story" // story ID
+	UserStatsKey            Namespace = "user:stats"
+	UserProfileKey          Namespace = "user:profile"
+	HiddenAuthorsKey        Namespace = "user:hidden"
+	AffinityKey             Namespace = "user:affinity"
+	NotificationSettingsKey Namespace = "user:notifications"
+	UserTenantKey           Namespace = "user:tenant" // user ID, shared by every tenant
+	AuthorEpochKey          Namespace = "epoch:author"
+	FeedEpochKey            Namespace = "epoch:feed"
+	TenantEpochKey          Namespace = "epoch:tenant" // no ID; one per tenant
+)
+
+// Keys of the other components sharing the Redis instance
+const (
+	RateLimitKey            Namespace = "rate_limit"          // user ID, action
+	RateLimitWarnedKey      Namespace = "rate_limit:warned"   // user ID, action
+	AbuseKey                Namespace = "abuse"               // action, user ID
+	AbuseThrottledKey       Namespace = "abuse:throttled"     // user ID
+	ExposureKey             Namespace = "experiment:exposed"  // experiment, user ID
+	ExperimentKey           Namespace = "experiment:settings" // experiment; settings changed at runtime
+	SessionKey              Namespace = "session"             // session ID
+	UserSessionsKey         Namespace = "sessions"            // user ID
+	SessionClientsKey       Namespace = "session-clients"     // no ID; one per tenant
+	RevokedTokenKey         Namespace = "revoked_token"       // token ID
+	RevokedUserKey          Namespace = "revoked_user"        // user ID
+	WSTicketKey             Namespace = "ws_ticket"           // ticket ID
+	MediaURLKey             Namespace = "media-url"           // bucket, object key
+	ImpressionsBufferKey    Namespace = "impressions:buffer"
+	ImpressionsFlushingKey  Namespace = "impressions:flushing"
+	ReconciliationReportKey Namespace = "media:reconciliation:report" // no ID; one per tenant
+	RelayChannel            Namespace = "events:relay"                // pub/sub channel
+)
+
+// namespaces lists every namespace above, for telling which one a key is in
+var namespaces = []Namespace{
+	UserFolloweesKey, FeedCacheKey, FeedTraysKey, StoryKey, UserStatsKey, UserProfileKey,
+	HiddenAuthorsKey, AffinityKey, NotificationSettingsKey, UserTenantKey, AuthorEpochKey, FeedEpochKey, TenantEpochKey,
+	RateLimitKey, RateLimitWarnedKey, AbuseKey, AbuseThrottledKey, ExposureKey, ExperimentKey,
+	SessionKey, UserSessionsKey, SessionClientsKey, RevokedTokenKey, RevokedUserKey, WSTicketKey,
+	MediaURLKey, ImpressionsBufferKey, ImpressionsFlushingKey, ReconciliationReportKey,
+	RelayChannel,
+}
+
+// versions holds the version of each namespace whose stored format has
+// changed. Bumping one moves its keys to "<namespace>:v<N>:...", so entries
+// written in the old format are never read again and simply expire.
+var versions = map[Namespace]int{
+	FeedCacheKey:   4, // v2: stories carry their author; v3: timestamps in types.TimeLayout, authors their UTC offset; v4: deleted_at is null until deleted
+	StoryKey:       3, // v2: timestamps in types.TimeLayout; v3: deleted_at is null until deleted
+	UserProfileKey: 2, // v2: timestamps in types.TimeLayout
+}
+
+// Keys builds the Redis keys of one environment and tenant. Environments
+// sharing a Redis instance are kept apart by the redis.key_prefix setting;
+// tenants other than the default one by their ID.
+type Keys struct {
+	env    string
+	tenant string
+}
+
+// NewKeys returns the key builder of the environment whose keys start with
+// prefix, which may be empty
+func NewKeys(prefix string) Keys {
+	if prefix == "" {
+		return Keys{}
+	}
+	return Keys{env: prefix + ":"}
+}
+
+// ForTenant returns a copy of k building tenantID's keys
+func (k Keys) ForTenant(tenantID string) Keys {
+	k.tenant = tenant.KeyPrefix(tenantID)
+	return k
+}
+
+// Key returns the key in ns identified by ids, joined by colons
+func (k Keys) Key(ns Namespace, ids ...string) string {
+	key := k.namespace(ns)
+	if len(ids) > 0 {
+		key += ":" + strings.Join(ids, ":")
+	}
+	return key
+}
+
+// Pattern returns the KEYS/SCAN pattern matching every key in ns
+func (k Keys) Pattern(ns Namespace) string {
+	return k.namespace(ns) + ":*"
+}
+
+// Prefix returns the prefix shared by every key k builds
+func (k Keys) Prefix() string {
+	return k.env + k.tenant
+}
+
+func (k Keys) namespace(ns Namespace) string {
+	key := k.Prefix() + string(ns)
+	if v := versions[ns]; v > 1 {
+		key += ":v" + strconv.Itoa(v)
+	}
+	return key
+}
+
+// NamespaceOf returns the namespace of key, which may belong to any tenant of
+// k's environment. It reports false for keys of other environments and keys
+// in no namespace.
+func (k Keys) NamespaceOf(key string) (Namespace, bool) {
+	rest, ok := strings.CutPrefix(key, k.env)
+	if !ok {
+		return "", false
+	}
+	if tenantKey, ok := strings.CutPrefix(rest, "tenant:"); ok {
+		if _, tenantKey, ok = strings.Cut(tenantKey, ":"); ok {
+			rest = tenantKey
+		}
+	}
+
+	// Namespaces may nest, as rate_limit:warned does in rate_limit
+	var longest Namespace
+	for _, ns := range namespaces {
+		if (rest == string(ns) || strings.HasPrefix(rest, string(ns)+":")) && len(ns) > len(longest) {
+			longest = ns
+		}
+	}
+	return longest, longest != ""
+}
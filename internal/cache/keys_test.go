@@ -0,0 +1,80 @@
+package cache
+
+import "testing"
+
+func TestKeys_Key(t *testing.T) {
+	for name, tc := range map[string]struct {
+		keys Keys
+		ns   Namespace
+		ids  []string
+		want string
+	}{
+		"no prefix":       {NewKeys(""), StoryKey, []string{"42"}, "story:v3:42"},
+		"env prefix":      {NewKeys("staging"), StoryKey, []string{"42"}, "staging:story:v3:42"},
+		"default tenant":  {NewKeys("staging").ForTenant("default"), StoryKey, []string{"42"}, "staging:story:v3:42"},
+		"tenant":          {NewKeys("staging").ForTenant("acme"), UserStatsKey, []string{"7"}, "staging:tenant:acme:user:stats:7"},
+		"several ids":     {NewKeys(""), RateLimitKey, []string{"7", "stories"}, "rate_limit:7:stories"},
+		"no ids":          {NewKeys("staging"), RelayChannel, nil, "staging:events:relay"},
+		"tenant replaced": {NewKeys("").ForTenant("acme").ForTenant("globex"), StoryKey, []string{"1"}, "tenant:globex:story:v3:1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.keys.Key(tc.ns, tc.ids...); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestKeys_Pattern(t *testing.T) {
+	keys := NewKeys("staging").ForTenant("acme")
+
+	if got := keys.Pattern(StoryKey); got != "staging:tenant:acme:story:v3:*" {
+		t.Errorf("Expected the tenant's story pattern, got %q", got)
+	}
+	if got := keys.Prefix(); got != "staging:tenant:acme:" {
+		t.Errorf("Expected the environment and tenant prefix, got %q", got)
+	}
+}
+
+func TestKeys_Version(t *testing.T) {
+	versions[AbuseKey] = 2
+	t.Cleanup(func() { delete(versions, AbuseKey) })
+
+	keys := NewKeys("staging")
+	if got := keys.Key(AbuseKey, "follow", "42"); got != "staging:abuse:v2:follow:42" {
+		t.Errorf("Expected a versioned key, got %q", got)
+	}
+	if got := keys.Pattern(AbuseKey); got != "staging:abuse:v2:*" {
+		t.Errorf("Expected a versioned pattern, got %q", got)
+	}
+	if got := keys.Key(UserStatsKey, "7"); got != "staging:user:stats:7" {
+		t.Errorf("Expected other namespaces unversioned, got %q", got)
+	}
+}
+
+func TestKeys_NamespaceOf(t *testing.T) {
+	keys := NewKeys("staging")
+
+	for key, want := range map[string]Namespace{
+		"staging:story:v3:42":                   StoryKey,
+		"staging:tenant:acme:user:stats:7":      UserStatsKey,
+		"staging:rate_limit:7:stories":          RateLimitKey,
+		"staging:rate_limit:warned:7:stories":   RateLimitWarnedKey,
+		"staging:events:relay":                  RelayChannel,
+		"staging:impressions:buffer":            ImpressionsBufferKey,
+		"staging:tenant:acme:feed:user:v4:9":    FeedCacheKey,
+		"staging:tenant:acme:feed:trays:9":      FeedTraysKey,
+		"staging:tenant:acme:epoch:author:9":    AuthorEpochKey,
+		"staging:tenant:acme:media-url:b:users": MediaURLKey,
+	} {
+		if got, ok := keys.NamespaceOf(key); !ok || got != want {
+			t.Errorf("Expected %q in %q, got %q", key, want, got)
+		}
+	}
+
+	for _, key := range []string{"production:story:v3:42", "staging:unknown:1", "staging:storyline"} {
+		if ns, ok := keys.NamespaceOf(key); ok {
+			t.Errorf("Expected %q in no namespace, got %q", key, ns)
+		}
+	}
+}
@@ -2,27 +2,74 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 // CacheStats represents cache performance statistics
 type CacheStats struct {
-	RedisConnected bool              `json:"redis_connected"`
-	RedisInfo      map[string]string `json:"redis_info"`
-	CacheKeys      []string          `json:"cache_keys_sample"`
-	KeyCount       int               `json:"total_keys"`
+	RedisConnected bool                        `json:"redis_connected"`
+	RedisInfo      map[string]string           `json:"redis_info"`      // fields of the memory and stats INFO sections
+	Redis          *RedisStats                 `json:"redis,omitempty"` // left out when INFO fails
+	Lookups        map[string]CacheLookupStats `json:"lookups"`         // reads on this instance since it started, by namespace
+	KeyPatterns    map[string]int              `json:"key_patterns"`    // sampled keys by namespace, "other" for keys of no namespace
+	KeysSampled    int                         `json:"keys_sampled"`
+	SampleComplete bool                        `json:"sample_complete"` // every key of the environment was sampled
+	CacheKeys      []string                    `json:"cache_keys_sample"`
+	KeyCount       int                         `json:"total_keys"`
 }
 
-// GetCacheStats returns cache performance statistics
-func GetCacheStats(redisClient *redis.Client) http.HandlerFunc {
+// RedisStats is what INFO says about Redis memory and keyspace lookups, for
+// the whole instance rather than one environment
+type RedisStats struct {
+	UsedMemory         int64   `json:"used_memory_bytes"`
+	UsedMemoryPeak     int64   `json:"used_memory_peak_bytes"`
+	MaxMemory          int64   `json:"max_memory_bytes"` // 0 when unlimited
+	MaxMemoryPolicy    string  `json:"max_memory_policy"`
+	FragmentationRatio float64 `json:"fragmentation_ratio"`
+	KeyspaceHits       int64   `json:"keyspace_hits"`
+	KeyspaceMisses     int64   `json:"keyspace_misses"`
+	HitRate            float64 `json:"hit_rate"` // hits over lookups, 0 before any
+	EvictedKeys        int64   `json:"evicted_keys"`
+	ExpiredKeys        int64   `json:"expired_keys"`
+}
+
+// CacheLookupStats counts the reads of one cache
+type CacheLookupStats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits over reads, 0 before any
+}
+
+// keySampleSize is how many keys GetCacheStats scans at most to count keys by
+// namespace, so the stats stay cheap on a large Redis
+const keySampleSize = 10000
+
+// GetCacheStats returns cache performance statistics for the environment of keys
+func GetCacheStats(redisClient *redis.Client, keys Keys) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.Background()
 		stats := CacheStats{
 			RedisConnected: true,
 			RedisInfo:      make(map[string]string),
+			Lookups:        make(map[string]CacheLookupStats),
+			KeyPatterns:    make(map[string]int),
+		}
+
+		for cache, counts := range metrics.CacheLookups() {
+			stats.Lookups[cache] = CacheLookupStats{
+				Hits:    counts.Hits,
+				Misses:  counts.Misses,
+				HitRate: ratio(counts.Hits, counts.Hits+counts.Misses),
+			}
 		}
 
 		// Test Redis connection
@@ -33,20 +80,34 @@ func GetCacheStats(redisClient *redis.Client) http.HandlerFunc {
 			return
 		}
 
-		// Get Redis INFO
-		infoResult := redisClient.Info(ctx, "memory", "stats")
-		if infoResult.Err() == nil {
-			// Parse basic info
-			stats.RedisInfo["info"] = "available"
+		// Get Redis INFO, a section at a time since Redis before 7 takes only one
+		infoOK := true
+		for _, section := range []string{"memory", "stats"} {
+			info, err := redisClient.Info(ctx, section).Result()
+			if err != nil {
+				infoOK = false
+				continue
+			}
+			maps.Copy(stats.RedisInfo, ParseRedisInfo(info))
+		}
+		if infoOK {
+			redisStats := redisStatsFromInfo(stats.RedisInfo)
+			stats.Redis = &redisStats
 		}
 
-		// Get cache keys (sample)
-		keys := redisClient.Keys(ctx, "user:*")
-		if keys.Err() == nil {
-			stats.CacheKeys = keys.Val()
-			if len(stats.CacheKeys) > 10 {
-				stats.CacheKeys = stats.CacheKeys[:10] // Show only first 10
+		// Count a sample of the environment's keys by namespace
+		sampled, complete, err := sampleKeys(ctx, redisClient, keys.Prefix()+"*", keySampleSize)
+		if err == nil {
+			stats.KeysSampled = len(sampled)
+			stats.SampleComplete = complete
+			for _, key := range sampled {
+				pattern := "other"
+				if ns, ok := keys.NamespaceOf(key); ok {
+					pattern = string(ns)
+				}
+				stats.KeyPatterns[pattern]++
 			}
+			stats.CacheKeys = sampled[:min(len(sampled), 10)] // Show only first 10
 		}
 
 		// Get total key count
@@ -59,39 +120,131 @@ func GetCacheStats(redisClient *redis.Client) http.HandlerFunc {
 	}
 }
 
-// ClearCache endpoint for administrative purposes
-func ClearCache(redisClient *redis.Client) http.HandlerFunc {
+// ParseRedisInfo returns the fields of INFO output by name. Section headers
+// and blank lines are skipped.
+func ParseRedisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// redisStatsFromInfo reads the memory and stats fields of INFO. Fields a Redis
+// version does not report are left zero.
+func redisStatsFromInfo(fields map[string]string) RedisStats {
+	integer := func(name string) int64 {
+		n, _ := strconv.ParseInt(fields[name], 10, 64)
+		return n
+	}
+	ratioField, _ := strconv.ParseFloat(fields["mem_fragmentation_ratio"], 64)
+
+	stats := RedisStats{
+		UsedMemory:         integer("used_memory"),
+		UsedMemoryPeak:     integer("used_memory_peak"),
+		MaxMemory:          integer("maxmemory"),
+		MaxMemoryPolicy:    fields["maxmemory_policy"],
+		FragmentationRatio: ratioField,
+		KeyspaceHits:       integer("keyspace_hits"),
+		KeyspaceMisses:     integer("keyspace_misses"),
+		EvictedKeys:        integer("evicted_keys"),
+		ExpiredKeys:        integer("expired_keys"),
+	}
+	stats.HitRate = ratio(uint64(stats.KeyspaceHits), uint64(stats.KeyspaceHits+stats.KeyspaceMisses))
+	return stats
+}
+
+// ratio returns part over whole, or 0 when whole is 0
+func ratio(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
+
+// sampleKeys scans keys matching pattern until limit keys are found, without
+// blocking Redis like KEYS, and reports whether the scan got through them all
+func sampleKeys(ctx context.Context, redisClient *redis.Client, pattern string, limit int) ([]string, bool, error) {
+	var sampled []string
+	var cursor uint64
+	for {
+		batch, next, err := redisClient.Scan(ctx, cursor, pattern, 1000).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		sampled = append(sampled, batch...)
+		if len(sampled) >= limit {
+			return sampled[:limit], next == 0 && len(sampled) == limit, nil
+		}
+		if next == 0 {
+			return sampled, true, nil
+		}
+		cursor = next
+	}
+}
+
+// ClearCache endpoint for administrative purposes. Only keys of the
+// environment of keys are cleared. With dry_run=true it only reports the keys
+// that would be deleted.
+func ClearCache(redisClient *redis.Client, keys Keys) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.Background()
 
 		// Get cache type from query parameter
 		cacheType := r.URL.Query().Get("type")
 
+		// Scope the pattern to a tenant's keys when one is given
+		scope := keys
+		if tenantID := r.URL.Query().Get("tenant"); tenantID != "" {
+			if !tenant.Valid(tenantID) {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid tenant ID %q", tenantID)))
+				return
+			}
+			scope = keys.ForTenant(tenantID)
+		}
+
 		var pattern string
 		switch cacheType {
 		case "feed":
-			pattern = "feed:*"
+			pattern = scope.Prefix() + "feed:*"
 		case "followees":
-			pattern = "user:followees:*"
+			pattern = scope.Pattern(UserFolloweesKey)
 		case "stats":
-			pattern = "user:stats:*"
+			pattern = scope.Pattern(UserStatsKey)
 		case "stories":
-			pattern = "story:*"
+			pattern = scope.Pattern(StoryKey)
 		case "all":
-			pattern = "*"
+			pattern = scope.Prefix() + "*"
 		default:
-			pattern = "feed:*" // Default to feed cache
+			pattern = scope.Prefix() + "feed:*" // Default to feed cache
 		}
 
 		// Delete matching keys
-		keys := redisClient.Keys(ctx, pattern)
-		if keys.Err() != nil {
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(keys.Err()))
+		matched := redisClient.Keys(ctx, pattern)
+		if matched.Err() != nil {
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(matched.Err()))
+			return
+		}
+
+		if r.URL.Query().Get("dry_run") == "true" {
+			result := map[string]interface{}{
+				"pattern":      pattern,
+				"dry_run":      true,
+				"matched_keys": len(matched.Val()),
+				"keys_sample":  matched.Val()[:min(len(matched.Val()), 5)], // Show first 5 keys that would be deleted
+			}
+			response.WriteJSON(w, http.StatusOK, response.RequestOK("Cache not cleared (dry run)", result))
 			return
 		}
 
-		if len(keys.Val()) > 0 {
-			deleted := redisClient.Del(ctx, keys.Val()...)
+		if len(matched.Val()) > 0 {
+			deleted := redisClient.Del(ctx, matched.Val()...)
 			if deleted.Err() != nil {
 				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(deleted.Err()))
 				return
@@ -100,7 +253,7 @@ func ClearCache(redisClient *redis.Client) http.HandlerFunc {
 			result := map[string]interface{}{
 				"pattern":      pattern,
 				"deleted_keys": deleted.Val(),
-				"keys_sample":  keys.Val()[:min(len(keys.Val()), 5)], // Show first 5 deleted keys
+				"keys_sample":  matched.Val()[:min(len(matched.Val()), 5)], // Show first 5 deleted keys
 			}
 			response.WriteJSON(w, http.StatusOK, response.RequestOK("Cache cleared successfully", result))
 		} else {
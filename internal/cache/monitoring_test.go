@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+func TestParseRedisInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_peak:2097152\r\nmaxmemory:0\r\n" +
+		"maxmemory_policy:allkeys-lru\r\nmem_fragmentation_ratio:1.25\r\n\r\n" +
+		"# Stats\r\nkeyspace_hits:75\r\nkeyspace_misses:25\r\nevicted_keys:3\r\nexpired_keys:40\r\n"
+
+	fields := ParseRedisInfo(info)
+	if len(fields) != 9 || fields["maxmemory_policy"] != "allkeys-lru" {
+		t.Fatalf("Expected 9 fields without headers, got %v", fields)
+	}
+
+	stats := redisStatsFromInfo(fields)
+	want := RedisStats{
+		UsedMemory:         1048576,
+		UsedMemoryPeak:     2097152,
+		MaxMemoryPolicy:    "allkeys-lru",
+		FragmentationRatio: 1.25,
+		KeyspaceHits:       75,
+		KeyspaceMisses:     25,
+		HitRate:            0.75,
+		EvictedKeys:        3,
+		ExpiredKeys:        40,
+	}
+	if stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+
+	if stats := redisStatsFromInfo(ParseRedisInfo("# Stats\r\n")); stats.HitRate != 0 {
+		t.Errorf("Expected no hit rate before any lookups, got %v", stats.HitRate)
+	}
+}
+
+func TestGetCacheStats(t *testing.T) {
+	redisClient, mr := redistest.New(t)
+
+	keys := NewKeys("staging")
+	for _, key := range []string{
+		keys.Key(StoryKey, "1"),
+		keys.Key(StoryKey, "2"),
+		keys.ForTenant("acme").Key(StoryKey, "3"),
+		keys.Key(UserStatsKey, "7"),
+		"staging:leftover",
+		"production:story:v3:1",
+	} {
+		mr.Set(key, "{}")
+	}
+
+	w := httptest.NewRecorder()
+	GetCacheStats(redisClient, keys)(w, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var body response.Envelope[CacheStats]
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	stats := body.Data
+
+	// Only the environment's keys are sampled
+	if stats.KeysSampled != 5 || !stats.SampleComplete || stats.KeyCount != 6 {
+		t.Errorf("Expected 5 of 6 keys sampled completely, got %d of %d (complete %v)", stats.KeysSampled, stats.KeyCount, stats.SampleComplete)
+	}
+	want := map[string]int{string(StoryKey): 3, string(UserStatsKey): 1, "other": 1}
+	if len(stats.KeyPatterns) != len(want) {
+		t.Errorf("Expected %v, got %v", want, stats.KeyPatterns)
+	}
+	for pattern, count := range want {
+		if stats.KeyPatterns[pattern] != count {
+			t.Errorf("Expected %d %s keys, got %d", count, pattern, stats.KeyPatterns[pattern])
+		}
+	}
+}
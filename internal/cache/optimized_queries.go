@@ -3,8 +3,13 @@ package cache
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	sq "github.com/Masterminds/squirrel"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
 	"github.com/princekumarofficial/stories-service/internal/types"
 )
 
@@ -18,82 +23,126 @@ func NewOptimizedFeedQuery(db *sql.DB) *OptimizedFeedQuery {
 	return &OptimizedFeedQuery{db: db}
 }
 
-// GetOptimizedFeedForUser returns feed with preloaded author data and counters
-// This avoids N+1 queries by joining all necessary data in a single query
-func (ofq *OptimizedFeedQuery) GetOptimizedFeedForUser(ctx context.Context, userID string) ([]types.StoryWithMeta, error) {
-	query := `
-	WITH user_stories AS (
-		SELECT DISTINCT s.id, s.author_id, s.text, s.media_key, s.visibility, s.created_at, s.expires_at, s.deleted_at
-		FROM stories s
-		LEFT JOIN story_audience sa ON s.id = sa.story_id
-		LEFT JOIN follows f ON s.author_id = f.followed_id
-		WHERE 
-			s.deleted_at IS NULL 
-			AND s.expires_at > NOW()  -- Only non-expired stories
-			AND (
-				s.visibility = 'PUBLIC'
-				OR (s.visibility = 'FRIENDS' AND f.follower_id = $1::integer)
-				OR (s.visibility = 'PRIVATE' AND sa.user_id = $1)
-				OR s.author_id = $1::integer
-			)
-	),
-	story_stats AS (
-		SELECT 
-			s.id as story_id,
-			COUNT(DISTINCT sv.viewer_id) as view_count,
-			COUNT(DISTINCT r.user_id) as reaction_count,
-			COALESCE(
-				JSON_OBJECT_AGG(
-					r.reaction_type, 
-					reaction_type_count
-				) FILTER (WHERE r.reaction_type IS NOT NULL), 
-				'{}'::json
-			) as reaction_breakdown
-		FROM user_stories s
-		LEFT JOIN story_views sv ON s.id = sv.story_id
-		LEFT JOIN (
-			SELECT 
-				story_id, 
-				reaction_type, 
-				COUNT(*) as reaction_type_count
-			FROM reactions 
-			GROUP BY story_id, reaction_type
-		) r ON s.id = r.story_id
-		GROUP BY s.id
+// storyStats aggregates view and reaction counts for the stories in source,
+// which is aliased s. It is nested in a CTE, so it uses the plain sq builder.
+func storyStats(source string) sq.SelectBuilder {
+	return sq.Select(
+		"s.id AS story_id",
+		"(SELECT COUNT(DISTINCT sv.viewer_id) FROM story_views sv WHERE sv.story_id = s.id) AS view_count",
+		"(SELECT COUNT(*) FROM reactions r WHERE r.story_id = s.id) AS reaction_count",
+		`(SELECT COALESCE(JSON_OBJECT_AGG(rc.reaction_type, rc.reaction_type_count), '{}'::json)
+			FROM (
+				SELECT reaction_type, COUNT(*) AS reaction_type_count
+				FROM reactions
+				WHERE story_id = s.id
+				GROUP BY reaction_type
+			) rc) AS reaction_breakdown`,
+	).From(source + " s")
+}
+
+// selectStoriesWithMeta selects story columns as userID sees them plus author,
+// stats and viewer flags for stories aliased us, joined to a story_stats CTE
+func selectStoriesWithMeta(userID string) sq.SelectBuilder {
+	return postgres.StatementBuilder.
+		Select(postgres.StoryColumns("us")...).
+		Column(postgres.ConsumedBy("us", userID)).
+		Columns(
+			// Author email (for display)
+			"u.email AS author_email",
+		).
+		// Author profile, as in the basic feed
+		Columns(postgres.AuthorColumns...).
+		Columns(
+			// Story stats
+			"COALESCE(ss.view_count, 0) AS view_count",
+			"COALESCE(ss.reaction_count, 0) AS reaction_count",
+			"COALESCE(ss.reaction_breakdown::text, '{}') AS reaction_breakdown",
+		).
+		// User interaction flags
+		Column("EXISTS(SELECT 1 FROM story_views sv2 WHERE sv2.story_id = us.id AND sv2.viewer_id = ?) AS user_has_viewed", userID).
+		Column("COALESCE((SELECT reaction_type FROM reactions r2 WHERE r2.story_id = us.id AND r2.user_id = ?), '') AS user_reaction", userID).
+		LeftJoin("users u ON us.author_id = u.id").
+		LeftJoin("story_stats ss ON us.id = ss.story_id")
+}
+
+// jsonCounts scans a JSON object of counts, such as a reaction breakdown
+type jsonCounts map[string]int
+
+func (c *jsonCounts) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*c = jsonCounts{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into counts", src)
+	}
+
+	counts := jsonCounts{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return fmt.Errorf("invalid counts JSON: %w", err)
+	}
+	*c = counts
+	return nil
+}
+
+// storyWithMetaFields returns scan destinations for selectStoriesWithMeta.
+// Once scanned, the story's author still needs its ID set by withAuthorID.
+func storyWithMetaFields(story *types.StoryWithMeta) []any {
+	fields := append(postgres.StoryFields(&story.Story), postgres.ConsumedField(&story.Story), &story.AuthorEmail)
+	fields = append(fields, postgres.AuthorFields(&story.Story)...)
+	return append(fields,
+		&story.ViewCount,
+		&story.ReactionCount,
+		(*jsonCounts)(&story.ReactionBreakdown),
+		&story.UserHasViewed,
+		&story.UserReaction,
 	)
-	SELECT 
-		us.id,
-		us.author_id,
-		us.text,
-		us.media_key,
-		us.visibility,
-		us.created_at,
-		us.expires_at,
-		COALESCE(us.deleted_at::TEXT, '') as deleted_at,
-		-- Author email (for display)
-		u.email as author_email,
-		-- Story stats
-		COALESCE(ss.view_count, 0) as view_count,
-		COALESCE(ss.reaction_count, 0) as reaction_count,
-		COALESCE(ss.reaction_breakdown::text, '{}') as reaction_breakdown,
-		-- User interaction flags
-		EXISTS(
-			SELECT 1 FROM story_views sv2 
-			WHERE sv2.story_id = us.id AND sv2.viewer_id = $1
-		) as user_has_viewed,
-		COALESCE(
-			(SELECT reaction_type FROM reactions r2 
-			 WHERE r2.story_id = us.id AND r2.user_id = $1), 
-			''
-		) as user_reaction
-	FROM user_stories us
-	LEFT JOIN users u ON us.author_id = u.id
-	LEFT JOIN story_stats ss ON us.id = ss.story_id
-	ORDER BY us.created_at DESC
-	LIMIT 50  -- Reasonable feed limit
-	`
-
-	rows, err := ofq.db.QueryContext(ctx, query, userID)
+}
+
+// withAuthorID completes the author scanned by storyWithMetaFields
+func withAuthorID(story types.StoryWithMeta) types.StoryWithMeta {
+	story.Author.ID = story.AuthorID
+	return story
+}
+
+// GetOptimizedFeedForUser returns up to limit stories of the feed with
+// preloaded author data and counters, starting after cursor unless it is nil
+// This avoids N+1 queries by joining all necessary data in a single query
+func (ofq *OptimizedFeedQuery) GetOptimizedFeedForUser(ctx context.Context, userID string, cursor *types.FeedCursor, limit int) ([]types.StoryWithMeta, error) {
+	start := time.Now()
+	stories, err := ofq.queryOptimizedFeed(ctx, userID, cursor, limit)
+	metrics.ObserveQuery("optimized_feed", start, len(stories), err)
+	return stories, err
+}
+
+// queryOptimizedFeed runs the optimized feed CTE for GetOptimizedFeedForUser
+func (ofq *OptimizedFeedQuery) queryOptimizedFeed(ctx context.Context, userID string, cursor *types.FeedCursor, limit int) ([]types.StoryWithMeta, error) {
+	userStories := sq.Select("s.*").
+		From("stories s").
+		Where(sq.Eq{"s.deleted_at": nil}).
+		Where("s.expires_at > NOW()"). // Only non-expired stories
+		Where(postgres.InFeedOf(userID))
+	if cursor != nil {
+		userStories = userStories.Where(postgres.AfterCursor("s", *cursor))
+	}
+
+	query := selectStoriesWithMeta(userID).
+		PrefixExpr(sq.Expr("WITH user_stories AS (?), story_stats AS (?)", userStories, storyStats("user_stories"))).
+		From("user_stories us").
+		OrderBy(postgres.FeedOrder("us")...).
+		Limit(uint64(limit))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build optimized feed query: %w", err)
+	}
+
+	rows, err := ofq.db.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch optimized feed: %w", err)
 	}
@@ -102,35 +151,10 @@ func (ofq *OptimizedFeedQuery) GetOptimizedFeedForUser(ctx context.Context, user
 	var stories []types.StoryWithMeta
 	for rows.Next() {
 		var story types.StoryWithMeta
-		var reactionBreakdownJSON string
-
-		err := rows.Scan(
-			&story.ID,
-			&story.AuthorID,
-			&story.Text,
-			&story.MediaKey,
-			&story.Visibility,
-			&story.CreatedAt,
-			&story.ExpiresAt,
-			&story.DeletedAt,
-			&story.AuthorEmail,
-			&story.ViewCount,
-			&story.ReactionCount,
-			&reactionBreakdownJSON,
-			&story.UserHasViewed,
-			&story.UserReaction,
-		)
-		if err != nil {
+		if err := rows.Scan(storyWithMetaFields(&story)...); err != nil {
 			return nil, fmt.Errorf("failed to scan story: %w", err)
 		}
-
-		// Parse reaction breakdown JSON
-		if reactionBreakdownJSON != "" && reactionBreakdownJSON != "{}" {
-			// You can implement JSON parsing here if needed
-			// For now, we'll store it as a string
-		}
-
-		stories = append(stories, story)
+		stories = append(stories, withAuthorID(story))
 	}
 
 	if err = rows.Err(); err != nil {
@@ -142,86 +166,22 @@ func (ofq *OptimizedFeedQuery) GetOptimizedFeedForUser(ctx context.Context, user
 
 // GetOptimizedStoryByID returns a single story with all metadata
 func (ofq *OptimizedFeedQuery) GetOptimizedStoryByID(ctx context.Context, storyID, userID string) (types.StoryWithMeta, error) {
-	query := `
-	WITH story_stats AS (
-		SELECT 
-			s.id as story_id,
-			COUNT(DISTINCT sv.viewer_id) as view_count,
-			COUNT(DISTINCT r.user_id) as reaction_count,
-			COALESCE(
-				JSON_OBJECT_AGG(
-					r.reaction_type, 
-					reaction_type_count
-				) FILTER (WHERE r.reaction_type IS NOT NULL), 
-				'{}'::json
-			) as reaction_breakdown
-		FROM stories s
-		LEFT JOIN story_views sv ON s.id = sv.story_id
-		LEFT JOIN (
-			SELECT 
-				story_id, 
-				reaction_type, 
-				COUNT(*) as reaction_type_count
-			FROM reactions 
-			GROUP BY story_id, reaction_type
-		) r ON s.id = r.story_id
-		WHERE s.id = $1
-		GROUP BY s.id
-	)
-	SELECT 
-		s.id,
-		s.author_id,
-		s.text,
-		s.media_key,
-		s.visibility,
-		s.created_at,
-		s.expires_at,
-		COALESCE(s.deleted_at::TEXT, '') as deleted_at,
-		-- Author email (for display)
-		u.email as author_email,
-		-- Story stats
-		COALESCE(ss.view_count, 0) as view_count,
-		COALESCE(ss.reaction_count, 0) as reaction_count,
-		COALESCE(ss.reaction_breakdown::text, '{}') as reaction_breakdown,
-		-- User interaction flags
-		EXISTS(
-			SELECT 1 FROM story_views sv2 
-			WHERE sv2.story_id = s.id AND sv2.viewer_id = $2
-		) as user_has_viewed,
-		COALESCE(
-			(SELECT reaction_type FROM reactions r2 
-			 WHERE r2.story_id = s.id AND r2.user_id = $2), 
-			''
-		) as user_reaction
-	FROM stories s
-	LEFT JOIN users u ON s.author_id = u.id
-	LEFT JOIN story_stats ss ON s.id = ss.story_id
-	WHERE s.id = $1 AND s.deleted_at IS NULL
-	`
+	query := selectStoriesWithMeta(userID).
+		PrefixExpr(sq.Expr("WITH story_stats AS (?)", storyStats("stories").Where(sq.Eq{"s.id": storyID}))).
+		From("stories us").
+		Where(sq.Eq{"us.id": storyID, "us.deleted_at": nil})
 
 	var story types.StoryWithMeta
-	var reactionBreakdownJSON string
-
-	err := ofq.db.QueryRowContext(ctx, query, storyID, userID).Scan(
-		&story.ID,
-		&story.AuthorID,
-		&story.Text,
-		&story.MediaKey,
-		&story.Visibility,
-		&story.CreatedAt,
-		&story.ExpiresAt,
-		&story.DeletedAt,
-		&story.AuthorEmail,
-		&story.ViewCount,
-		&story.ReactionCount,
-		&reactionBreakdownJSON,
-		&story.UserHasViewed,
-		&story.UserReaction,
-	)
 
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return story, fmt.Errorf("failed to build optimized story query: %w", err)
+	}
+
+	err = ofq.db.QueryRowContext(ctx, sqlStr, args...).Scan(storyWithMetaFields(&story)...)
 	if err != nil {
 		return story, fmt.Errorf("failed to fetch optimized story: %w", err)
 	}
 
-	return story, nil
+	return withAuthorID(story), nil
 }
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// WarmQueueSize is how many users can wait to be warmed; further requests
+// are dropped until the worker catches up
+const WarmQueueSize = 256
+
+// Warmer loads a user's followee list and first feed page into the cache in
+// the background, so the first feed request after opening the app is a hit
+type Warmer struct {
+	cache *CacheService
+	queue chan string
+}
+
+// NewWarmer creates a warmer for cache; call Start to begin warming
+func NewWarmer(cache *CacheService) *Warmer {
+	return &Warmer{
+		cache: cache,
+		queue: make(chan string, WarmQueueSize),
+	}
+}
+
+// Warm queues userID to be warmed without blocking, reporting whether it was
+// queued
+func (w *Warmer) Warm(userID string) bool {
+	select {
+	case w.queue <- userID:
+		return true
+	default:
+		slog.Warn("Cache warm queue full, skipping user", slog.String("user_id", userID))
+		return false
+	}
+}
+
+// Start warms queued users until the context is cancelled
+func (w *Warmer) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case userID := <-w.queue:
+			if err := w.warm(ctx, userID); err != nil {
+				slog.Error("Failed to warm cache", slog.String("user_id", userID), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// warm loads userID's followees and feed into the cache of their tenant
+func (w *Warmer) warm(ctx context.Context, userID string) error {
+	user, err := w.cache.storage.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	scoped := w.cache.ForTenant(user.TenantID)
+	if _, err := scoped.GetUserFollowees(userID); err != nil {
+		return fmt.Errorf("failed to warm followees: %w", err)
+	}
+	if _, _, err := scoped.GetCachedFeed(ctx, userID); err != nil {
+		return fmt.Errorf("failed to warm feed: %w", err)
+	}
+	return nil
+}
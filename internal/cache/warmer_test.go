@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+func TestWarmer_WarmsFeed(t *testing.T) {
+	c, store := setupEpochTest(t)
+	ctx := context.Background()
+
+	// Warming loads the feed once, and the request after it loads nothing
+	store.EXPECT().GetUserByID("reader").Return(users.User{ID: "reader"}, nil)
+	store.EXPECT().GetStoriesForUser(ctx, "reader").Return([]types.Story{testStories["1"]}, nil)
+
+	if err := NewWarmer(c).warm(ctx, "reader"); err != nil {
+		t.Fatalf("warm failed: %v", err)
+	}
+
+	if _, hit, err := c.GetCachedFeed(ctx, "reader"); err != nil {
+		t.Fatalf("GetCachedFeed failed: %v", err)
+	} else if !hit {
+		t.Error("Expected the first feed request after warming to be a cache hit")
+	}
+}
+
+func TestWarmer_DropsWhenFull(t *testing.T) {
+	c, _ := setupEpochTest(t)
+	w := NewWarmer(c)
+
+	for i := 0; i < WarmQueueSize; i++ {
+		if !w.Warm("reader") {
+			t.Fatalf("Expected request %d to be queued", i)
+		}
+	}
+	if w.Warm("reader") {
+		t.Error("Expected request to be dropped once the queue is full")
+	}
+}
@@ -9,26 +9,43 @@ import (
 )
 
 type Config struct {
-	Env        string     `yaml:"env" env-required:"true" env-default:"production"`
-	PGSQL      PQSQL      `yaml:"pgsql" env-required:"true"`
-	HTTPServer HTTPServer `yaml:"http_server" env-required:"true"`
-	JWTSecret  string     `yaml:"jwt_secret" env-required:"true" env-default:"super_secret_key"`
-	MinIO      MinIO      `yaml:"minio" env-required:"true"`
-	Media      Media      `yaml:"media" env-required:"true"`
-	Redis      Redis      `yaml:"redis" env-required:"true"`
+	Env          string       `yaml:"env" env-required:"true" env-default:"production"`
+	PGSQL        PQSQL        `yaml:"pgsql" env-required:"true"`
+	HTTPServer   HTTPServer   `yaml:"http_server" env-required:"true"`
+	JWTSecret    string       `yaml:"jwt_secret" env-required:"true" env-default:"super_secret_key"`
+	MinIO        MinIO        `yaml:"minio" env-required:"true"`
+	Media        Media        `yaml:"media" env-required:"true"`
+	Redis        Redis        `yaml:"redis" env-required:"true"`
+	Cache        Cache        `yaml:"cache"`
+	Links        Links        `yaml:"links"`
+	WebSocket    WebSocket    `yaml:"websocket"`
+	Mail         Mail         `yaml:"mail"`
+	JWT          JWT          `yaml:"jwt"`
+	Metrics      Metrics      `yaml:"metrics"`
+	Concurrency  Concurrency  `yaml:"concurrency"`
+	Interactions Interactions `yaml:"interactions"`
+	Startup      Startup      `yaml:"startup"`
+	Feed         Feed         `yaml:"feed"`
+	Archive      Archive      `yaml:"archive"`
+	Impressions  Impressions  `yaml:"impressions"`
+	Jobs         Jobs         `yaml:"jobs"`
+	Abuse        Abuse        `yaml:"abuse"`
+	Experiments  Experiments  `yaml:"experiments"`
 }
 
 type HTTPServer struct {
-	Address string `yaml:"address" env-required:"true" env-default:"localhost:8080"`
+	Address        string `yaml:"address" env-required:"true" env-default:"localhost:8080"`
+	RequestTimeout int    `yaml:"request_timeout" env-default:"5"` // seconds feed and nearby queries may take before they are cancelled
 }
 
 type PQSQL struct {
-	Host     string `yaml:"host" env-required:"true" env-default:"localhost"`
-	Port     string `yaml:"port" env-required:"true" env-default:"5432"`
-	User     string `yaml:"user" env-required:"true" env-default:"postgres"`
-	Password string `yaml:"password" env-required:"true" env-default:"password"`
-	DBName   string `yaml:"dbname" env-required:"true" env-default:"stories_db"`
-	SSLMode  string `yaml:"sslmode" env-required:"true" env-default:"disable"`
+	Host        string `yaml:"host" env-required:"true" env-default:"localhost"`
+	Port        string `yaml:"port" env-required:"true" env-default:"5432"`
+	User        string `yaml:"user" env-required:"true" env-default:"postgres"`
+	Password    string `yaml:"password" env-required:"true" env-default:"password"`
+	DBName      string `yaml:"dbname" env-required:"true" env-default:"stories_db"`
+	SSLMode     string `yaml:"sslmode" env-required:"true" env-default:"disable"`
+	AutoMigrate bool   `yaml:"auto_migrate" env-default:"true"` // create and update the schema on connecting; false leaves it to `stories-service migrate`
 }
 
 type MinIO struct {
@@ -40,15 +57,169 @@ type MinIO struct {
 }
 
 type Media struct {
-	MaxFileSize      int64    `yaml:"max_file_size" env-default:"10485760"` // 10MB default
-	AllowedMimeTypes []string `yaml:"allowed_mime_types" env-default:"image/jpeg,image/png,image/gif,video/mp4,video/mpeg"`
-	PresignedURLTTL  int      `yaml:"presigned_url_ttl" env-default:"3600"` // 1 hour default in seconds
+	MaxFileSize      int64     `yaml:"max_file_size" env-default:"10485760"` // 10MB default
+	AllowedMimeTypes []string  `yaml:"allowed_mime_types" env-default:"image/jpeg,image/png,image/gif,video/mp4,video/mpeg"`
+	PresignedURLTTL  int       `yaml:"presigned_url_ttl" env-default:"3600"` // 1 hour default in seconds
+	FeedURLTTL       int       `yaml:"feed_url_ttl" env-default:"900"`       // seconds media URLs in feeds stay valid
+	Reconcile        Reconcile `yaml:"reconcile"`
+}
+
+// Reconcile configures the job comparing bucket objects with upload records
+type Reconcile struct {
+	Interval          int  `yaml:"interval" env-default:"3600"`            // seconds between runs
+	DeleteUnconfirmed bool `yaml:"delete_unconfirmed" env-default:"false"` // delete uploads left unconfirmed past the TTL
+	UnconfirmedTTL    int  `yaml:"unconfirmed_ttl" env-default:"86400"`    // seconds an upload may stay unconfirmed
+	DryRun            bool `yaml:"dry_run" env-default:"false"`            // only report what each run would mark and delete
 }
 
 type Redis struct {
 	Address  string `yaml:"address" env-required:"true" env-default:"localhost:6379"`
 	Password string `yaml:"password" env-default:""`
 	DB       int    `yaml:"db" env-default:"0"`
+	// KeyPrefix starts every key and channel the service uses, keeping
+	// environments that share a Redis instance apart; empty for none
+	KeyPrefix string `yaml:"key_prefix" env-default:""`
+}
+
+// Cache configures how long Redis keeps each kind of cached entry, in seconds
+type Cache struct {
+	FolloweesTTL     int `yaml:"followees_ttl" env-default:"300"`
+	FeedTTL          int `yaml:"feed_ttl" env-default:"45"`
+	StoryTTL         int `yaml:"story_ttl" env-default:"600"`
+	StatsTTL         int `yaml:"stats_ttl" env-default:"120"`
+	ProfileTTL       int `yaml:"profile_ttl" env-default:"120"`
+	HiddenTTL        int `yaml:"hidden_ttl" env-default:"300"`
+	AffinityTTL      int `yaml:"affinity_ttl" env-default:"600"`
+	NotificationsTTL int `yaml:"notifications_ttl" env-default:"300"`
+	Jitter           int `yaml:"jitter" env-default:"10"` // percent each TTL is randomly lengthened or shortened by, so entries cached together expire apart
+}
+
+type Links struct {
+	AllowedDomains []string `yaml:"allowed_domains"` // empty allows any domain not blocked
+	BlockedDomains []string `yaml:"blocked_domains"`
+}
+
+type WebSocket struct {
+	TicketTTL             int  `yaml:"ticket_ttl" env-default:"30"`              // seconds a connection ticket stays valid
+	BatchReactions        bool `yaml:"batch_reactions" env-default:"false"`      // summarize bursts of reactions into one frame per author
+	ReactionBatchWindow   int  `yaml:"reaction_batch_window" env-default:"2000"` // milliseconds reactions are gathered for
+	MaxConnectionsPerUser int  `yaml:"max_connections_per_user" env-default:"5"` // connections one user may hold at once; 0 is unlimited
+	MaxConnectionsPerIP   int  `yaml:"max_connections_per_ip" env-default:"50"`  // connections one IP may hold at once; 0 is unlimited
+	ConnectRate           int  `yaml:"connect_rate" env-default:"30"`            // connection attempts per minute per IP; 0 is unlimited
+	SlowConsumerTimeout   int  `yaml:"slow_consumer_timeout" env-default:"10"`   // seconds a client's send queue may stay full before it is disconnected
+	OpsInterval           int  `yaml:"ops_interval" env-default:"5"`             // seconds between snapshots on the ops topic; 0 turns the topic off
+}
+
+type JWT struct {
+	Issuer   string `yaml:"issuer" env-default:"stories-service"`
+	Audience string `yaml:"audience" env-default:"stories-service"`
+	TTL      int    `yaml:"ttl" env-default:"86400"` // seconds an issued token stays valid
+	Leeway   int    `yaml:"leeway" env-default:"30"` // seconds of clock skew tolerated on exp and iat
+}
+
+type Metrics struct {
+	SlowQueryThreshold int           `yaml:"slow_query_threshold" env-default:"250"` // milliseconds above which feed queries are logged; 0 disables
+	Worker             WorkerMetrics `yaml:"worker"`
+}
+
+// WorkerMetrics configures how the ephemeral worker exports its metrics:
+// served on a local port, pushed to a Prometheus Pushgateway, or both
+type WorkerMetrics struct {
+	Address      string `yaml:"address"`                            // address to serve OpenMetrics on at /metrics, e.g. ":9091"; empty serves nothing
+	Pushgateway  string `yaml:"pushgateway"`                        // Pushgateway URL, e.g. "http://pushgateway:9091"; empty pushes nothing
+	PushInterval int    `yaml:"push_interval" env-default:"15"`     // seconds between pushes; 15 when not positive
+	Job          string `yaml:"job" env-default:"ephemeral-worker"` // job label pushed metrics are grouped under
+}
+
+type Concurrency struct {
+	MaxInFlight int            `yaml:"max_in_flight" env-default:"0"` // requests served at once across all routes; 0 is unlimited
+	Routes      map[string]int `yaml:"routes"`                        // requests served at once per named route; missing or 0 is unlimited
+	RetryAfter  int            `yaml:"retry_after" env-default:"1"`   // seconds clients are told to wait when saturated
+}
+
+type Interactions struct {
+	CountSelfViews     bool `yaml:"count_self_views" env-default:"false"`     // record authors' views of their own stories and count them in stats
+	AllowSelfReactions bool `yaml:"allow_self_reactions" env-default:"false"` // let authors react to their own stories
+	ScreenshotSignals  bool `yaml:"screenshot_signals" env-default:"false"`   // accept clients' screenshot and screen recording reports and tell authors
+}
+
+// Archive configures the job moving long-gone stories out of the database
+// into a cold bucket
+type Archive struct {
+	Enabled    bool   `yaml:"enabled" env-default:"false"`               // run the job in the worker
+	BucketName string `yaml:"bucket_name" env-default:"stories-archive"` // suffixed per tenant like the media bucket
+	Interval   int    `yaml:"interval" env-default:"3600"`               // seconds between runs
+	After      int    `yaml:"after" env-default:"604800"`                // seconds a story stays in the database after expiring or being deleted
+	BatchSize  int    `yaml:"batch_size" env-default:"500"`              // stories per archive object
+	DryRun     bool   `yaml:"dry_run" env-default:"false"`               // only log what each run would archive and delete
+}
+
+// Impressions configures how tray impressions are buffered in Redis before the
+// worker writes them to the database
+type Impressions struct {
+	FlushInterval int `yaml:"flush_interval" env-default:"30"` // seconds between flushes
+	BatchSize     int `yaml:"batch_size" env-default:"1000"`   // impressions written per transaction
+}
+
+// Jobs configures the background job queue and the workers running it
+type Jobs struct {
+	PollInterval int `yaml:"poll_interval" env-default:"5"`  // seconds between checks for due jobs
+	BatchSize    int `yaml:"batch_size" env-default:"10"`    // jobs a worker claims per check
+	MaxAttempts  int `yaml:"max_attempts" env-default:"5"`   // runs before a failing job is given up on
+	Backoff      int `yaml:"backoff" env-default:"30"`       // seconds before the first retry, doubled for each further one
+	Lease        int `yaml:"lease" env-default:"300"`        // seconds a job may run before another worker takes it over
+	Retention    int `yaml:"retention" env-default:"604800"` // seconds finished and failed jobs are kept
+}
+
+// Abuse configures when accounts following, unfollowing or viewing far more
+// than people do are shadow-throttled and flagged for review. A limit of 0
+// turns detection off for its action.
+type Abuse struct {
+	Enabled          bool `yaml:"enabled" env-default:"true"`
+	FollowsPerHour   int  `yaml:"follows_per_hour" env-default:"300"`
+	UnfollowsPerHour int  `yaml:"unfollows_per_hour" env-default:"300"`
+	ViewsPerHour     int  `yaml:"views_per_hour" env-default:"6000"`
+	ThrottleFor      int  `yaml:"throttle_for" env-default:"86400"` // seconds an account stays throttled unless an admin lifts it
+}
+
+// Experiments configures the server-side experiments. Users are split between
+// an experiment's variants by a hash of their ID, so each keeps theirs for as
+// long as the experiment runs.
+type Experiments struct {
+	FeedRanking Experiment `yaml:"feed_ranking"` // chronological (control) or ranked GET /feed
+}
+
+// Experiment configures one experiment with a control and a treatment variant
+type Experiment struct {
+	Enabled   bool   `yaml:"enabled" env-default:"false"` // while off everyone gets the control and nobody is counted as exposed
+	Treatment int    `yaml:"treatment" env-default:"50"`  // percent of users given the treatment
+	Salt      string `yaml:"salt"`                        // changing it reshuffles users between variants
+}
+
+// Feed configures how many stories a feed page holds, and how far behind the
+// present /feed/changes reads
+type Feed struct {
+	DefaultLimit int `yaml:"default_limit" env-default:"50"` // stories per page when the client sends no limit
+	MaxLimit     int `yaml:"max_limit" env-default:"200"`    // largest limit clients may ask for
+	ChangesLag   int `yaml:"changes_lag" env-default:"5"`    // seconds; longer than any story write takes to commit
+}
+
+// Startup configures connecting to Postgres, Redis and MinIO at startup
+type Startup struct {
+	RetryAttempts  int  `yaml:"retry_attempts" env-default:"10"`   // connection attempts per dependency; 0 retries until shutdown
+	InitialBackoff int  `yaml:"initial_backoff" env-default:"500"` // milliseconds before the first retry, doubled after each one
+	MaxBackoff     int  `yaml:"max_backoff" env-default:"10000"`   // milliseconds retries back off to at most
+	Degraded       bool `yaml:"degraded" env-default:"false"`      // serve /health and /ready while connecting, and 503 to everything else
+}
+
+type Mail struct {
+	SMTPAddress string `yaml:"smtp_address"` // host:port; empty logs emails instead of sending them
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	From        string `yaml:"from" env-default:"Stories <no-reply@stories.local>"`
+	PublicURL   string `yaml:"public_url" env-default:"http://localhost:8080"` // base URL of unsubscribe and story share links
+	Interval    int    `yaml:"interval" env-default:"900"`                     // seconds between email notification runs
+	BatchSize   int    `yaml:"batch_size" env-default:"100"`                   // users emailed per kind and run
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,141 @@
+// Package emailnotify sends the email notifications users opt in to, such as
+// new followers, weekly creator stats and weekly recaps
+package emailnotify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// Kind identifies an email notification users can opt in to
+type Kind string
+
+const (
+	KindNewFollowers Kind = "new_followers"
+	KindWeeklyStats  Kind = "weekly_stats"
+	KindWeeklyRecap  Kind = "weekly_recap"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// pages holds the email template of each kind, wrapped in the shared layout
+var pages = map[Kind]*template.Template{
+	KindNewFollowers: template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/new_followers.html")),
+	KindWeeklyStats:  template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/weekly_stats.html")),
+	KindWeeklyRecap:  template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/weekly_recap.html")),
+}
+
+// page is what email templates render
+type page struct {
+	Subject        string
+	Kind           string
+	UnsubscribeURL string
+	Data           any
+}
+
+// render renders the email of the given kind
+func render(kind Kind, subject, unsubscribeURL string, data any) (string, error) {
+	tmpl, ok := pages[kind]
+	if !ok {
+		return "", fmt.Errorf("no email template for %q", kind)
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.ExecuteTemplate(&buf, "layout", page{
+		Subject:        subject,
+		Kind:           kind.label(),
+		UnsubscribeURL: unsubscribeURL,
+		Data:           data,
+	})
+	return buf.String(), err
+}
+
+// label names the kind in email footers
+func (k Kind) label() string {
+	switch k {
+	case KindNewFollowers:
+		return "new follower"
+	case KindWeeklyStats:
+		return "weekly stats"
+	case KindWeeklyRecap:
+		return "weekly recap"
+	default:
+		return string(k)
+	}
+}
+
+// Unsubscribe turns the kind off in settings, reporting false for unknown kinds
+func (k Kind) Unsubscribe(settings *users.NotificationSettings) bool {
+	switch k {
+	case KindNewFollowers:
+		settings.EmailNewFollowers = false
+	case KindWeeklyStats:
+		settings.EmailWeeklyStats = false
+	case KindWeeklyRecap:
+		settings.EmailWeeklyRecap = false
+	default:
+		return false
+	}
+	return true
+}
+
+// UnsubscribeToken signs an unsubscribe link, so links cannot be forged for
+// other users or kinds
+func UnsubscribeToken(secret, userID string, kind Kind) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(userID + ":" + string(kind)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidUnsubscribeToken reports whether token signs the user's unsubscribe link for kind
+func ValidUnsubscribeToken(secret, userID string, kind Kind, token string) bool {
+	return hmac.Equal([]byte(token), []byte(UnsubscribeToken(secret, userID, kind)))
+}
+
+// unsubscribePage is the page unsubscribe links open in a browser
+var unsubscribePage = template.Must(template.ParseFS(templateFS, "templates/unsubscribe.html"))
+
+// UnsubscribeState is what the unsubscribe page shows
+type UnsubscribeState int
+
+const (
+	UnsubscribeConfirm UnsubscribeState = iota // asks to confirm by posting to the link
+	UnsubscribeDone                            // reports that the email was turned off
+	UnsubscribeInvalid                         // reports that the link is not valid
+)
+
+// RenderUnsubscribePage writes the unsubscribe page for kind. Opening a link
+// only asks for confirmation, which posts to action, so link scanners that
+// follow every URL in an email cannot unsubscribe anyone.
+func RenderUnsubscribePage(w io.Writer, state UnsubscribeState, kind Kind, action string) error {
+	return unsubscribePage.ExecuteTemplate(w, "unsubscribe", struct {
+		Kind          string
+		Action        string
+		Done, Invalid bool
+	}{
+		Kind:    kind.label(),
+		Action:  action,
+		Done:    state == UnsubscribeDone,
+		Invalid: state == UnsubscribeInvalid,
+	})
+}
+
+// UnsubscribeURL returns the one-click unsubscribe link for the user and kind
+func UnsubscribeURL(publicURL, secret, userID string, kind Kind) string {
+	query := url.Values{
+		"user":  {userID},
+		"kind":  {string(kind)},
+		"token": {UnsubscribeToken(secret, userID, kind)},
+	}
+	return publicURL + "/unsubscribe?" + query.Encode()
+}
@@ -0,0 +1,158 @@
+package emailnotify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/mailer"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// Store is the data the email worker reads and updates
+type Store interface {
+	storage.EmailStore
+	GetUserStats(userID string) (users.UserStats, error)
+}
+
+// Worker periodically emails opted-in users. Each run sends at most batchSize
+// emails of each kind; users whose email fails are retried on the next run.
+type Worker struct {
+	store     Store
+	mailer    mailer.Mailer
+	publicURL string
+	secret    string
+	interval  time.Duration
+	batchSize int
+}
+
+// NewWorker creates an email worker; unsubscribe links are signed with the JWT secret
+func NewWorker(store Store, m mailer.Mailer, cfg *config.Config) *Worker {
+	return &Worker{
+		store:     store,
+		mailer:    m,
+		publicURL: cfg.Mail.PublicURL,
+		secret:    cfg.JWTSecret,
+		interval:  time.Duration(cfg.Mail.Interval) * time.Second,
+		batchSize: cfg.Mail.BatchSize,
+	}
+}
+
+// Start sends emails every interval until the context is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Email worker started", slog.String("interval", w.interval.String()))
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Email worker shutting down")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends one batch of each kind of email
+func (w *Worker) RunOnce(ctx context.Context) {
+	w.sendNewFollowers(ctx)
+	w.sendWeeklyStats(ctx)
+}
+
+// sendNewFollowers emails users about every follower gained since their last
+// new followers email, batching follows into a single email
+func (w *Worker) sendNewFollowers(ctx context.Context) {
+	digests, err := w.store.GetFollowerDigests(w.batchSize)
+	if err != nil {
+		slog.Error("Failed to get new follower digests", slog.String("error", err.Error()))
+		return
+	}
+
+	sent := 0
+	for _, digest := range digests {
+		subject := fmt.Sprintf("You have %d new followers", digest.NewFollowers)
+		if digest.NewFollowers == 1 {
+			subject = "You have a new follower"
+		}
+
+		if err := w.send(ctx, digest.EmailRecipient, KindNewFollowers, subject, digest); err != nil {
+			slog.Error("Failed to send new followers email", slog.String("user_id", digest.UserID), slog.String("error", err.Error()))
+			continue
+		}
+
+		if err := w.store.MarkFollowersEmailed(digest.UserID, digest.Until); err != nil {
+			slog.Error("Failed to record new followers email", slog.String("user_id", digest.UserID), slog.String("error", err.Error()))
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		slog.Info("Sent new followers emails", slog.Int("count", sent))
+	}
+}
+
+// sendWeeklyStats emails users their stats for the last 7 days, once a week
+func (w *Worker) sendWeeklyStats(ctx context.Context) {
+	recipients, err := w.store.GetWeeklyStatsRecipients(w.batchSize)
+	if err != nil {
+		slog.Error("Failed to get weekly stats recipients", slog.String("error", err.Error()))
+		return
+	}
+
+	sent := 0
+	for _, recipient := range recipients {
+		stats, err := w.store.GetUserStats(recipient.UserID)
+		if err != nil {
+			slog.Error("Failed to get user stats", slog.String("user_id", recipient.UserID), slog.String("error", err.Error()))
+			continue
+		}
+
+		if err := w.send(ctx, recipient, KindWeeklyStats, "Your week on Stories", stats); err != nil {
+			slog.Error("Failed to send weekly stats email", slog.String("user_id", recipient.UserID), slog.String("error", err.Error()))
+			continue
+		}
+
+		if err := w.store.MarkWeeklyStatsEmailed(recipient.UserID); err != nil {
+			slog.Error("Failed to record weekly stats email", slog.String("user_id", recipient.UserID), slog.String("error", err.Error()))
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		slog.Info("Sent weekly stats emails", slog.Int("count", sent))
+	}
+}
+
+// SendWeeklyRecap emails a creator their weekly recap
+func (w *Worker) SendWeeklyRecap(ctx context.Context, to users.EmailRecipient, recap users.WeeklyRecap) error {
+	return w.send(ctx, to, KindWeeklyRecap, "Your weekly recap", recap)
+}
+
+// send renders and sends an email of the given kind with its unsubscribe link
+func (w *Worker) send(ctx context.Context, to users.EmailRecipient, kind Kind, subject string, data any) error {
+	unsubscribeURL := UnsubscribeURL(w.publicURL, w.secret, to.UserID, kind)
+
+	html, err := render(kind, subject, unsubscribeURL, data)
+	if err != nil {
+		return err
+	}
+
+	return w.mailer.Send(ctx, mailer.Message{
+		To:      to.Email,
+		Subject: subject,
+		HTML:    html,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
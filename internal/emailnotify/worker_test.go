@@ -0,0 +1,179 @@
+package emailnotify
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/mailer"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// fakeStore serves fixed recipients and records what was marked as emailed
+type fakeStore struct {
+	digests          []users.FollowerDigest
+	statsRecipients  []users.EmailRecipient
+	followersEmailed map[string]time.Time
+	statsEmailed     []string
+}
+
+func (s *fakeStore) GetFollowerDigests(limit int) ([]users.FollowerDigest, error) {
+	return s.digests, nil
+}
+
+func (s *fakeStore) MarkFollowersEmailed(userID string, until time.Time) error {
+	s.followersEmailed[userID] = until
+	return nil
+}
+
+func (s *fakeStore) GetWeeklyStatsRecipients(limit int) ([]users.EmailRecipient, error) {
+	return s.statsRecipients, nil
+}
+
+func (s *fakeStore) MarkWeeklyStatsEmailed(userID string) error {
+	s.statsEmailed = append(s.statsEmailed, userID)
+	return nil
+}
+
+func (s *fakeStore) GetUserStats(userID string) (users.UserStats, error) {
+	return users.UserStats{Posted: 4, Views: 120, UniqueViewers: 37, ReactionCounts: map[string]int{"🔥": 9}}, nil
+}
+
+// fakeMailer records sent messages and fails for one address
+type fakeMailer struct {
+	sent   []mailer.Message
+	failTo string
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if msg.To == m.failTo {
+		return errors.New("mailbox unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestWorker_RunOnce(t *testing.T) {
+	until := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		digests: []users.FollowerDigest{
+			{EmailRecipient: users.EmailRecipient{UserID: "1", Email: "ana@example.com"}, NewFollowers: 3, Until: until},
+			{EmailRecipient: users.EmailRecipient{UserID: "2", Email: "bounce@example.com"}, NewFollowers: 1, Until: until},
+		},
+		statsRecipients:  []users.EmailRecipient{{UserID: "3", Email: "cy@example.com"}},
+		followersEmailed: make(map[string]time.Time),
+	}
+	m := &fakeMailer{failTo: "bounce@example.com"}
+
+	cfg := &config.Config{JWTSecret: "secret", Mail: config.Mail{PublicURL: "https://stories.example", BatchSize: 10}}
+	NewWorker(store, m, cfg).RunOnce(context.Background())
+
+	if len(m.sent) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(m.sent))
+	}
+
+	followers := m.sent[0]
+	if followers.To != "ana@example.com" || followers.Subject != "You have 3 new followers" {
+		t.Errorf("Unexpected new followers email to %s: %q", followers.To, followers.Subject)
+	}
+	if !strings.Contains(followers.HTML, "You have 3 new followers") {
+		t.Errorf("Expected the follower count in the email body:\n%s", followers.HTML)
+	}
+
+	// The failed email is retried next run, so only the delivered one is marked
+	if len(store.followersEmailed) != 1 || !store.followersEmailed["1"].Equal(until) {
+		t.Errorf("Expected only user 1 marked as emailed up to %s, got %v", until, store.followersEmailed)
+	}
+
+	stats := m.sent[1]
+	if stats.To != "cy@example.com" || !strings.Contains(stats.HTML, "<strong>120</strong>") || !strings.Contains(stats.HTML, "🔥 9") {
+		t.Errorf("Expected weekly stats for cy@example.com, got %s:\n%s", stats.To, stats.HTML)
+	}
+	if len(store.statsEmailed) != 1 || store.statsEmailed[0] != "3" {
+		t.Errorf("Expected user 3 marked as sent weekly stats, got %v", store.statsEmailed)
+	}
+
+	// Every email carries a working unsubscribe link for its own kind
+	link := strings.Trim(stats.Headers["List-Unsubscribe"], "<>")
+	if !strings.Contains(stats.HTML, strings.ReplaceAll(link, "&", "&amp;")) {
+		t.Errorf("Expected the unsubscribe link %s in the email body", link)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Invalid unsubscribe link %q: %v", link, err)
+	}
+	query := parsed.Query()
+	if query.Get("user") != "3" || Kind(query.Get("kind")) != KindWeeklyStats {
+		t.Errorf("Unexpected unsubscribe link %s", link)
+	}
+	if !ValidUnsubscribeToken("secret", "3", KindWeeklyStats, query.Get("token")) {
+		t.Error("Expected the unsubscribe token to be valid")
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	token := UnsubscribeToken("secret", "42", KindNewFollowers)
+
+	if !ValidUnsubscribeToken("secret", "42", KindNewFollowers, token) {
+		t.Error("Expected token to be valid for its user and kind")
+	}
+	if ValidUnsubscribeToken("secret", "43", KindNewFollowers, token) {
+		t.Error("Expected token to be rejected for another user")
+	}
+	if ValidUnsubscribeToken("secret", "42", KindWeeklyStats, token) {
+		t.Error("Expected token to be rejected for another kind")
+	}
+	if ValidUnsubscribeToken("other", "42", KindNewFollowers, token) {
+		t.Error("Expected token to be rejected under another secret")
+	}
+}
+
+func TestRenderUnsubscribePage(t *testing.T) {
+	for state, want := range map[UnsubscribeState]string{
+		UnsubscribeConfirm: `<form method="post" action="/unsubscribe?kind=weekly_stats&amp;token=t&amp;user=3">`,
+		UnsubscribeDone:    "You will no longer receive weekly stats emails.",
+		UnsubscribeInvalid: "This unsubscribe link is not valid.",
+	} {
+		var page strings.Builder
+		if err := RenderUnsubscribePage(&page, state, KindWeeklyStats, "/unsubscribe?kind=weekly_stats&token=t&user=3"); err != nil {
+			t.Fatalf("RenderUnsubscribePage failed: %v", err)
+		}
+		if !strings.Contains(page.String(), want) {
+			t.Errorf("Expected page %d to contain %q, got %s", state, want, page.String())
+		}
+		if state != UnsubscribeConfirm && strings.Contains(page.String(), "<form") {
+			t.Errorf("Expected page %d to offer no form", state)
+		}
+	}
+}
+
+func TestWorker_SendWeeklyRecap(t *testing.T) {
+	m := &fakeMailer{}
+	cfg := &config.Config{JWTSecret: "secret", Mail: config.Mail{PublicURL: "https://stories.example"}}
+	recap := users.WeeklyRecap{
+		WeekStart: "2024-04-22", WeekEnd: "2024-04-28", Posted: 5, Views: 310, Reach: 144,
+		Followers: 80, FollowerDelta: -2, TopStory: &users.RecapStory{ID: "9", Views: 1, Reactions: 12},
+	}
+
+	err := NewWorker(&fakeStore{}, m, cfg).SendWeeklyRecap(context.Background(), users.EmailRecipient{UserID: "4", Email: "di@example.com"}, recap)
+	if err != nil {
+		t.Fatalf("SendWeeklyRecap failed: %v", err)
+	}
+	if len(m.sent) != 1 || m.sent[0].To != "di@example.com" {
+		t.Fatalf("Expected one email to di@example.com, got %+v", m.sent)
+	}
+
+	html := m.sent[0].HTML
+	for _, want := range []string{"2024-04-22 to 2024-04-28", "<strong>310</strong>", "<strong>80</strong> (-2)", "1 view and 12 reactions", "weekly recap emails"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in the recap email:\n%s", want, html)
+		}
+	}
+	if !strings.Contains(m.sent[0].Headers["List-Unsubscribe"], "kind=weekly_recap") {
+		t.Errorf("Expected a weekly recap unsubscribe link, got %q", m.sent[0].Headers["List-Unsubscribe"])
+	}
+}
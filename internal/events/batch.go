@@ -0,0 +1,97 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// reactionBatcher gathers the reactions to each author's stories into one
+// summarized frame per window. The first reaction in a quiet period is sent
+// right away and opens a window; reactions arriving while it is open are
+// held and sent together when it closes, which opens the next window. A
+// window that closes with nothing held ends the burst.
+type reactionBatcher struct {
+	window time.Duration
+	send   func(userID string, event *types.Event) error
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*reactionBatch // by author, while a window is open
+}
+
+// reactionBatch holds the reactions received during one window
+type reactionBatch struct {
+	since   time.Time
+	total   int
+	stories []types.StoryReactionCount
+}
+
+func newReactionBatcher(window time.Duration, send func(userID string, event *types.Event) error, now func() time.Time) *reactionBatcher {
+	return &reactionBatcher{
+		window:  window,
+		send:    send,
+		now:     now,
+		pending: make(map[string]*reactionBatch),
+	}
+}
+
+// add records a reaction to authorID's story. It reports whether the reaction
+// should be sent on its own because no window was open.
+func (b *reactionBatcher) add(authorID, storyID string, emoji types.ReactionType) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch, open := b.pending[authorID]
+	if !open {
+		b.open(authorID)
+		return true
+	}
+
+	batch.total++
+	for i := range batch.stories {
+		if batch.stories[i].StoryID == storyID {
+			batch.stories[i].Count++
+			batch.stories[i].Emojis[emoji]++
+			return false
+		}
+	}
+	batch.stories = append(batch.stories, types.StoryReactionCount{
+		StoryID: storyID,
+		Count:   1,
+		Emojis:  map[types.ReactionType]int{emoji: 1},
+	})
+	return false
+}
+
+// open starts a window for authorID; b.mu must be held
+func (b *reactionBatcher) open(authorID string) {
+	b.pending[authorID] = &reactionBatch{since: b.now()}
+	time.AfterFunc(b.window, func() { b.flush(authorID) })
+}
+
+// flush closes authorID's window, sending what it held
+func (b *reactionBatcher) flush(authorID string) {
+	b.mu.Lock()
+	batch, open := b.pending[authorID]
+	if !open {
+		b.mu.Unlock()
+		return
+	}
+	if batch.total == 0 {
+		delete(b.pending, authorID)
+		b.mu.Unlock()
+		return
+	}
+	// Keep batching while the burst lasts
+	b.open(authorID)
+	b.mu.Unlock()
+
+	event := types.NewEvent(types.EventReactionBatch, &types.ReactionBatchEvent{
+		Total:   batch.total,
+		Stories: batch.stories,
+		Since:   types.FormatTime(batch.since),
+	})
+	b.send(authorID, event)
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/storage"
+)
+
+// Digester sends users the digest of the notifications queued during their
+// quiet hours. It runs next to the WebSocket hub so that queued notifications
+// are only claimed while their user is connected: users who are offline when
+// their quiet hours end keep them queued and get the digest once they
+// connect.
+type Digester struct {
+	notifications storage.NotificationStore
+	publisher     Publisher
+	hub           WebSocketHub
+	interval      time.Duration
+	now           func() time.Time
+}
+
+// NewDigester creates a digester that looks for digests due to connected
+// users every interval
+func NewDigester(notifications storage.NotificationStore, publisher Publisher, hub WebSocketHub, interval time.Duration) *Digester {
+	return &Digester{
+		notifications: notifications,
+		publisher:     publisher,
+		hub:           hub,
+		interval:      interval,
+		now:           time.Now,
+	}
+}
+
+// Start sends due digests every interval until the context is cancelled
+func (d *Digester) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.sendDue()
+		}
+	}
+}
+
+// sendDue sends a digest to every connected user with queued notifications
+// whose quiet hours are over
+func (d *Digester) sendDue() {
+	pending, err := d.notifications.GetQueuedNotificationUsers()
+	if err != nil {
+		slog.Error("Failed to list queued notifications", slog.String("error", err.Error()))
+		return
+	}
+
+	now := d.now()
+	for userID, settings := range pending {
+		if settings.InQuietHours(now) {
+			continue
+		}
+		if err := d.send(userID); err != nil {
+			slog.Error("Failed to send notification digest",
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Send sends the user their digest if they are connected and out of their
+// quiet hours. It is called when the user connects, so users who were
+// offline when their quiet hours ended get their digest then.
+func (d *Digester) Send(userID string) error {
+	if d == nil {
+		return nil
+	}
+
+	settings, err := d.notifications.GetNotificationSettings(userID)
+	if err != nil {
+		return err
+	}
+	if settings.InQuietHours(d.now()) {
+		return nil
+	}
+	return d.send(userID)
+}
+
+// send claims the user's queued notifications and publishes them as a
+// digest, leaving them queued while the user is not connected
+func (d *Digester) send(userID string) error {
+	if !d.hub.IsUserConnected(userID) {
+		return nil
+	}
+
+	counts, err := d.notifications.ClaimQueuedNotifications(userID)
+	if err != nil {
+		return err
+	}
+	return d.publisher.PublishDigest(userID, counts)
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+func TestDigester_OfflineWhenQuietHoursEnd(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), offline: map[string]bool{"sleeper": true}}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: map[string][]*types.Event{
+			"sleeper": {
+				types.NewEvent(types.EventStoryViewed, nil),
+				types.NewEvent(types.EventStoryViewed, nil),
+			},
+		},
+	}
+
+	digester := NewDigester(notifications, NewEventPublisher(hub), hub, time.Minute)
+	digester.now = func() time.Time {
+		return time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	}
+
+	// Quiet hours are over but the user is offline, so nothing is claimed
+	digester.sendDue()
+	if hub.received("sleeper") != 0 || len(notifications.queued["sleeper"]) != 2 {
+		t.Fatalf("Expected the notifications to stay queued while offline, sent %d queued %d",
+			hub.received("sleeper"), len(notifications.queued["sleeper"]))
+	}
+
+	// Connecting sends the digest
+	hub.offline["sleeper"] = false
+	if err := digester.Send("sleeper"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if hub.received("sleeper") != 1 || len(notifications.queued["sleeper"]) != 0 {
+		t.Fatalf("Expected the digest on connect, sent %d queued %d",
+			hub.received("sleeper"), len(notifications.queued["sleeper"]))
+	}
+	digest, ok := hub.sent["sleeper"][0].Data.(*types.DigestEvent)
+	if !ok || digest.Counts[types.EventStoryViewed] != 2 {
+		t.Errorf("Expected a digest of 2 views, got %+v", hub.sent["sleeper"][0].Data)
+	}
+
+	// The digest is only sent once
+	digester.sendDue()
+	if err := digester.Send("sleeper"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if hub.received("sleeper") != 1 {
+		t.Errorf("Expected one digest, got %d events", hub.received("sleeper"))
+	}
+}
+
+func TestDigester_QuietHours(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: map[string][]*types.Event{
+			"sleeper": {types.NewEvent(types.EventStoryReacted, nil)},
+		},
+	}
+
+	digester := NewDigester(notifications, NewEventPublisher(hub), hub, time.Minute)
+	digester.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	}
+
+	digester.sendDue()
+	if err := digester.Send("sleeper"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if hub.received("sleeper") != 0 || len(notifications.queued["sleeper"]) != 1 {
+		t.Errorf("Expected no digest during quiet hours, sent %d queued %d",
+			hub.received("sleeper"), len(notifications.queued["sleeper"]))
+	}
+}
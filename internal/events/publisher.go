@@ -1,34 +1,171 @@
 package events
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
 	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
 )
 
 // Publisher interface for publishing events
 type Publisher interface {
 	PublishStoryViewed(storyID, viewerID, authorID string) error
 	PublishStoryReacted(storyID, userID, authorID string, emoji types.ReactionType) error
+	PublishStoryUnreacted(storyID, userID, authorID string, emoji types.ReactionType) error
+	PublishStoryExpiring(storyID, authorID string, expiresAt time.Time) error
+	PublishStoryRemoved(eventType types.EventType, story types.Story, audienceIDs []string) error
+	PublishStoryRevoked(story types.Story, userIDs []string) error
+	PublishUserFollowed(followerID, followedID string) error
+	PublishUserUnfollowed(followerID, followedID string) error
+	PublishUsersFollowed(followerID string, followedIDs []string) error
+	PublishUsersUnfollowed(followerID string, followedIDs []string) error
+	PublishDigest(userID string, counts map[types.EventType]int) error
+	PublishAnnouncement(announcement *types.AnnouncementEvent, tenantID string, userIDs []string) error
 }
 
+// ErrReplyNotDelivered is returned for a story reply that could be neither sent
+// to the author nor queued for their digest
+var ErrReplyNotDelivered = errors.New("reply not delivered")
+
+// ErrNotConnected is returned for an event that is only sent to a connected
+// user when the user is not connected
+var ErrNotConnected = errors.New("user not connected")
+
 // EventPublisher implements the Publisher interface
 type EventPublisher struct {
-	hub WebSocketHub
+	hub           WebSocketHub
+	notifications storage.NotificationStore // nil unless quiet hours are enabled
+	privacy       storage.PrivacyStore      // nil unless view receipt settings are honored
+	reactions     *reactionBatcher          // nil unless reaction batching is enabled
+	retries       *retryQueue
+	now           func() time.Time
 }
 
 // WebSocketHub interface for the WebSocket hub
 type WebSocketHub interface {
-	BroadcastToUser(userID string, event *types.Event)
-	BroadcastToUsers(userIDs []string, event *types.Event)
+	BroadcastToUser(userID string, event *types.Event) error
+	BroadcastToUsers(userIDs []string, event *types.Event) error
+	BroadcastToTenant(tenantID string, event *types.Event) error
 	IsUserConnected(userID string) bool
 }
 
 // NewEventPublisher creates a new event publisher
 func NewEventPublisher(hub WebSocketHub) *EventPublisher {
 	return &EventPublisher{
-		hub: hub,
+		hub:     hub,
+		retries: newRetryQueue(),
+		now:     time.Now,
+	}
+}
+
+// WithQuietHours makes the publisher queue view and reaction notifications
+// for users in their quiet hours instead of delivering them, for the digest.
+// Settings are read for every notification, so notifications should cache them.
+func (p *EventPublisher) WithQuietHours(notifications storage.NotificationStore) *EventPublisher {
+	p.notifications = notifications
+	return p
+}
+
+// WithViewReceipts makes the publisher skip story.viewed events for viewers who
+// hide their view receipts
+func (p *EventPublisher) WithViewReceipts(privacy storage.PrivacyStore) *EventPublisher {
+	p.privacy = privacy
+	return p
+}
+
+// WithReactionBatching makes the publisher summarize the reactions an author
+// receives within window into one frame instead of sending one per reaction
+func (p *EventPublisher) WithReactionBatching(window time.Duration) *EventPublisher {
+	p.reactions = newReactionBatcher(window, p.notify, func() time.Time { return p.now() })
+	return p
+}
+
+// publish hands an event to send, retrying failed deliveries with backoff.
+// Events that cannot be serialized or delivered are dead-lettered, and only
+// an event dead-lettered straight away is reported as an error.
+func (p *EventPublisher) publish(event *types.Event, recipients []string, send func() error) error {
+	d := &delivery{event: event, recipients: recipients, send: send}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return p.retries.deadLetter(d, fmt.Errorf("failed to serialize event: %w", err))
 	}
+	d.payload = payload
+	return p.retries.run(d)
+}
+
+// Shutdown makes a last attempt at the deliveries waiting to be retried, until
+// ctx is done, and dead-letters those that fail or are left. Deliveries that
+// fail after it are dead-lettered straight away.
+func (p *EventPublisher) Shutdown(ctx context.Context) error {
+	return p.retries.shutdown(ctx)
+}
+
+// deliver sends an event to a connected user, or queues it for their digest
+// while they are in their quiet hours
+func (p *EventPublisher) deliver(userID string, event *types.Event) error {
+	return p.publish(event, []string{userID}, func() error {
+		quiet, err := p.inQuietHours(userID)
+		if err != nil {
+			return err
+		}
+		if quiet {
+			return p.notifications.QueueNotification(userID, event)
+		}
+		return p.send(userID, event)
+	})
+}
+
+// notify sends an event to the user if they are connected, ignoring quiet hours
+func (p *EventPublisher) notify(userID string, event *types.Event) error {
+	return p.publish(event, []string{userID}, func() error {
+		return p.send(userID, event)
+	})
+}
+
+// inQuietHours reports whether notifications for userID are being held back
+func (p *EventPublisher) inQuietHours(userID string) (bool, error) {
+	if p.notifications == nil {
+		return false, nil
+	}
+
+	settings, err := p.notifications.GetNotificationSettings(userID)
+	if err != nil {
+		return false, err
+	}
+	return settings.InQuietHours(p.now()), nil
+}
+
+// hidesViewReceipts reports whether userID's views must not be announced
+func (p *EventPublisher) hidesViewReceipts(userID string) (bool, error) {
+	if p.privacy == nil {
+		return false, nil
+	}
+
+	settings, err := p.privacy.GetPrivacySettings(userID)
+	if err != nil {
+		return false, err
+	}
+	return settings.HideViewReceipts, nil
+}
+
+// send broadcasts an event to the user if they are connected
+func (p *EventPublisher) send(userID string, event *types.Event) error {
+	// Only send if the user is connected
+	if !p.hub.IsUserConnected(userID) {
+		return nil
+	}
+
+	return p.hub.BroadcastToUser(userID, event)
 }
 
 // PublishStoryViewed publishes a story viewed event to the story author
@@ -38,21 +175,24 @@ func (p *EventPublisher) PublishStoryViewed(storyID, viewerID, authorID string)
 		return nil
 	}
 
-	// Only send if the author is connected
-	if !p.hub.IsUserConnected(authorID) {
+	// Nor if the viewer hides their view receipts; when that cannot be told,
+	// err on the side of their privacy
+	hidden, err := p.hidesViewReceipts(viewerID)
+	if err != nil {
+		return fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+	if hidden {
 		return nil
 	}
 
 	eventData := &types.StoryViewedEvent{
 		StoryID:  storyID,
 		ViewerID: viewerID,
-		ViewedAt: time.Now().UTC().Format(time.RFC3339),
+		ViewedAt: types.FormatTime(time.Now()),
 	}
 
 	event := types.NewEvent(types.EventStoryViewed, eventData)
-	p.hub.BroadcastToUser(authorID, event)
-
-	return nil
+	return p.deliver(authorID, event)
 }
 
 // PublishStoryReacted publishes a story reacted event to the story author
@@ -62,20 +202,325 @@ func (p *EventPublisher) PublishStoryReacted(storyID, userID, authorID string, e
 		return nil
 	}
 
-	// Only send if the author is connected
+	eventData := &types.StoryReactedEvent{
+		StoryID:   storyID,
+		UserID:    userID,
+		Emoji:     emoji,
+		ReactedAt: types.FormatTime(time.Now()),
+	}
+
+	event := types.NewEvent(types.EventStoryReacted, eventData)
+	if p.reactions == nil {
+		return p.deliver(authorID, event)
+	}
+
+	// Reactions held back for the digest are counted there, not batched.
+	// A failed lookup is retried by deliver, which also skips batching.
+	quiet, err := p.inQuietHours(authorID)
+	if err != nil || quiet {
+		return p.deliver(authorID, event)
+	}
+
 	if !p.hub.IsUserConnected(authorID) {
 		return nil
 	}
+	if p.reactions.add(authorID, storyID, emoji) {
+		return p.notify(authorID, event)
+	}
+	return nil
+}
 
-	eventData := &types.StoryReactedEvent{
+// PublishStoryUnreacted tells the story author a reaction was taken back so
+// live reaction counts stay current. Like user.unfollowed it is meant for
+// clients, so it ignores quiet hours and reaction batching.
+func (p *EventPublisher) PublishStoryUnreacted(storyID, userID, authorID string, emoji types.ReactionType) error {
+	if userID == authorID {
+		return nil
+	}
+
+	event := types.NewEvent(types.EventStoryUnreacted, &types.StoryUnreactedEvent{
+		StoryID:     storyID,
+		UserID:      userID,
+		Emoji:       emoji,
+		UnreactedAt: types.FormatTime(time.Now()),
+	})
+	return p.notify(authorID, event)
+}
+
+// PublishStoryReshared tells the author of a story that userID reshared it,
+// or queues it for their digest during quiet hours
+func (p *EventPublisher) PublishStoryReshared(storyID, reshareID, userID, authorID string) error {
+	event := types.NewEvent(types.EventStoryReshared, &types.StoryResharedEvent{
+		StoryID:    storyID,
+		ReshareID:  reshareID,
+		UserID:     userID,
+		ResharedAt: types.FormatTime(time.Now()),
+	})
+	return p.deliver(authorID, event)
+}
+
+// PublishStoryScreenshotted tells the author of a story that userID captured
+// it on screen, or queues it for their digest during quiet hours
+func (p *EventPublisher) PublishStoryScreenshotted(storyID, userID, authorID, kind string) error {
+	event := types.NewEvent(types.EventStoryScreenshot, &types.StoryScreenshotEvent{
+		StoryID:    storyID,
+		UserID:     userID,
+		Kind:       kind,
+		CapturedAt: types.FormatTime(time.Now()),
+	})
+	return p.deliver(authorID, event)
+}
+
+// PublishStoryReplied sends the author of a story a reply to it from userID.
+// Replies are not stored, so one the author cannot be sent right away, during
+// their quiet hours or while they are offline, is queued for their digest.
+// Unlike other notifications it is not retried in the background: it returns
+// ErrReplyNotDelivered when the reply can be neither sent nor queued.
+func (p *EventPublisher) PublishStoryReplied(storyID, userID, authorID, text string) error {
+	event := types.NewEvent(types.EventStoryReplied, &types.StoryRepliedEvent{
 		StoryID:   storyID,
 		UserID:    userID,
-		Emoji:     emoji,
-		ReactedAt: time.Now().UTC().Format(time.RFC3339),
+		Text:      text,
+		RepliedAt: types.FormatTime(time.Now()),
+	})
+
+	quiet, err := p.inQuietHours(authorID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get notification settings: %v", ErrReplyNotDelivered, err)
+	}
+	if !quiet && p.hub.IsUserConnected(authorID) {
+		err := p.hub.BroadcastToUser(authorID, event)
+		if err == nil {
+			metrics.ObserveEvent(string(event.Type), metrics.EventDelivered)
+			return nil
+		}
+		slog.Warn("Failed to send story reply, queueing it for the digest",
+			slog.String("user_id", authorID),
+			slog.String("error", err.Error()))
 	}
 
-	event := types.NewEvent(types.EventStoryReacted, eventData)
-	p.hub.BroadcastToUser(authorID, event)
+	if p.notifications == nil {
+		return ErrReplyNotDelivered
+	}
+	if err := p.notifications.QueueNotification(authorID, event); err != nil {
+		return fmt.Errorf("%w: failed to queue it: %v", ErrReplyNotDelivered, err)
+	}
+	return nil
+}
 
+// PublishStoryExpiring warns the story author that their story is about to expire
+// and offers a one-tap action to keep it in their highlights. The warning is
+// only useful before the story expires, so it ignores quiet hours. It is not
+// retried in the background: ErrNotConnected or a failed send is returned so
+// the caller can warn the author again later.
+func (p *EventPublisher) PublishStoryExpiring(storyID, authorID string, expiresAt time.Time) error {
+	if !p.hub.IsUserConnected(authorID) {
+		return ErrNotConnected
+	}
+
+	eventData := &types.StoryExpiringEvent{
+		StoryID:   storyID,
+		ExpiresAt: types.FormatTime(expiresAt),
+		Actions: []types.EventAction{
+			{
+				Type:   "add_to_highlights",
+				Method: "POST",
+				Path:   fmt.Sprintf("/stories/%s/highlight", storyID),
+			},
+		},
+	}
+
+	event := types.NewEvent(types.EventStoryExpiring, eventData)
+	if err := p.hub.BroadcastToUser(authorID, event); err != nil {
+		return err
+	}
+	metrics.ObserveEvent(string(event.Type), metrics.EventDelivered)
 	return nil
 }
+
+// PublishStoryRemoved tells everyone who may be showing a story that it is
+// gone, with eventType story.deleted or story.expired: the author's other
+// devices and audienceIDs, the users the story's visibility let see it (see
+// storage.StoryStore.GetStoryAudience). It keeps clients in sync rather than
+// notifying anyone, so it ignores quiet hours.
+func (p *EventPublisher) PublishStoryRemoved(eventType types.EventType, story types.Story, audienceIDs []string) error {
+	recipients := append([]string{story.AuthorID}, audienceIDs...)
+
+	event := types.NewEvent(eventType, &types.StoryRemovedEvent{
+		StoryID:  story.ID,
+		AuthorID: story.AuthorID,
+	})
+	return p.publish(event, recipients, func() error {
+		return p.hub.BroadcastToUsers(recipients, event)
+	})
+}
+
+// PublishStoryRevoked tells the connected users among userIDs, who could see
+// the story before its author changed its visibility, that they no longer
+// can. Like story removals it keeps clients in sync, so it ignores quiet
+// hours; users who are not connected get the story from no feed anyway.
+func (p *EventPublisher) PublishStoryRevoked(story types.Story, userIDs []string) error {
+	recipients := slices.DeleteFunc(slices.Clone(userIDs), func(userID string) bool {
+		return !p.hub.IsUserConnected(userID)
+	})
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	event := types.NewEvent(types.EventStoryRevoked, &types.StoryRemovedEvent{
+		StoryID:  story.ID,
+		AuthorID: story.AuthorID,
+	})
+	return p.publish(event, recipients, func() error {
+		return p.hub.BroadcastToUsers(recipients, event)
+	})
+}
+
+// PublishUserFollowed tells a user they have a new follower, or queues it for
+// their digest during quiet hours
+func (p *EventPublisher) PublishUserFollowed(followerID, followedID string) error {
+	event := types.NewEvent(types.EventUserFollowed, &types.FollowEvent{
+		FollowerID: followerID,
+		FollowedID: followedID,
+	})
+	return p.deliver(followedID, event)
+}
+
+// PublishUserUnfollowed tells a user they lost a follower so follower counts
+// stay current. It is meant for clients rather than people, so like story
+// removals it ignores quiet hours and never shows up in the digest.
+func (p *EventPublisher) PublishUserUnfollowed(followerID, followedID string) error {
+	event := types.NewEvent(types.EventUserUnfollowed, &types.FollowEvent{
+		FollowerID: followerID,
+		FollowedID: followedID,
+	})
+	return p.notify(followedID, event)
+}
+
+// PublishUsersFollowed tells everyone a batch follow reached that they have a
+// new follower with a single event, queued instead for those in their quiet
+// hours
+func (p *EventPublisher) PublishUsersFollowed(followerID string, followedIDs []string) error {
+	event := types.NewEvent(types.EventUserFollowed, &types.FollowEvent{FollowerID: followerID})
+
+	var awake []string
+	var errs []error
+	for _, userID := range followedIDs {
+		quiet, err := p.inQuietHours(userID)
+		if err == nil && quiet {
+			err = p.notifications.QueueNotification(userID, event)
+		} else if err == nil {
+			awake = append(awake, userID)
+		}
+		errs = append(errs, err)
+	}
+
+	if len(awake) > 0 {
+		errs = append(errs, p.publish(event, awake, func() error {
+			return p.hub.BroadcastToUsers(awake, event)
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// PublishUsersUnfollowed tells everyone a batch unfollow reached that they
+// lost a follower with a single event, ignoring quiet hours like
+// PublishUserUnfollowed
+func (p *EventPublisher) PublishUsersUnfollowed(followerID string, followedIDs []string) error {
+	if len(followedIDs) == 0 {
+		return nil
+	}
+	event := types.NewEvent(types.EventUserUnfollowed, &types.FollowEvent{FollowerID: followerID})
+	return p.publish(event, followedIDs, func() error {
+		return p.hub.BroadcastToUsers(followedIDs, event)
+	})
+}
+
+// PublishAnnouncement sends an operator announcement to the given users, or to
+// every connected client of tenantID when there are none. Announcements are
+// not personal notifications, so they ignore quiet hours.
+func (p *EventPublisher) PublishAnnouncement(announcement *types.AnnouncementEvent, tenantID string, userIDs []string) error {
+	event := types.NewEvent(types.EventAnnouncement, announcement)
+	return p.publish(event, userIDs, func() error {
+		if len(userIDs) == 0 {
+			return p.hub.BroadcastToTenant(tenantID, event)
+		}
+		return p.hub.BroadcastToUsers(userIDs, event)
+	})
+}
+
+// PublishRateLimitWarning warns a user that they are close to a rate limit.
+// Clients act on it rather than people, so it ignores quiet hours.
+func (p *EventPublisher) PublishRateLimitWarning(userID string, warning *types.RateLimitWarningEvent) error {
+	event := types.NewEvent(types.EventRateLimitWarning, warning)
+	return p.notify(userID, event)
+}
+
+// PublishWeeklyRecap tells a creator their recap of last week is ready. The
+// recap stays available from GET /me/recaps, so users who are not connected
+// miss nothing.
+func (p *EventPublisher) PublishWeeklyRecap(userID string, recap *users.WeeklyRecap) error {
+	event := types.NewEvent(types.EventWeeklyRecap, recap)
+	return p.notify(userID, event)
+}
+
+// digestNouns names each event type in digest summaries, in summary order
+var digestNouns = []struct {
+	eventType        types.EventType
+	singular, plural string
+}{
+	{types.EventStoryViewed, "view", "views"},
+	{types.EventStoryReacted, "reaction", "reactions"},
+	{types.EventStoryReshared, "reshare", "reshares"},
+	{types.EventStoryScreenshot, "screenshot", "screenshots"},
+	{types.EventStoryReplied, "reply", "replies"},
+	{types.EventUserFollowed, "new follower", "new followers"},
+}
+
+// PublishDigest sends the user a summary of the notifications held back
+// during their quiet hours
+func (p *EventPublisher) PublishDigest(userID string, counts map[types.EventType]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	// Only send if the user is connected
+	if !p.hub.IsUserConnected(userID) {
+		return nil
+	}
+
+	eventData := &types.DigestEvent{
+		Counts:  counts,
+		Summary: DigestSummary(counts),
+	}
+
+	event := types.NewEvent(types.EventDigest, eventData)
+	return p.notify(userID, event)
+}
+
+// DigestSummary describes notification counts for people, e.g. "12 views,
+// 3 reactions". Event types without a noun are listed by name.
+func DigestSummary(counts map[types.EventType]int) string {
+	var parts []string
+	named := make(map[types.EventType]bool)
+	for _, noun := range digestNouns {
+		named[noun.eventType] = true
+		switch count := counts[noun.eventType]; count {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+noun.singular)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", count, noun.plural))
+		}
+	}
+
+	var others []string
+	for eventType, count := range counts {
+		if !named[eventType] && count > 0 {
+			others = append(others, fmt.Sprintf("%d %s", count, eventType))
+		}
+	}
+	sort.Strings(others)
+
+	return strings.Join(append(parts, others...), ", ")
+}
@@ -0,0 +1,587 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// fakeHub records broadcast events per user, failing the first failures
+// broadcasts. Users in offline are not connected.
+type fakeHub struct {
+	mu       sync.Mutex
+	sent     map[string][]*types.Event
+	failures int
+	offline  map[string]bool
+}
+
+func (h *fakeHub) BroadcastToUser(userID string, event *types.Event) error {
+	return h.BroadcastToUsers([]string{userID}, event)
+}
+
+func (h *fakeHub) BroadcastToUsers(userIDs []string, event *types.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.failures > 0 {
+		h.failures--
+		return errors.New("hub unavailable")
+	}
+	for _, userID := range userIDs {
+		h.sent[userID] = append(h.sent[userID], event)
+	}
+	return nil
+}
+
+func (h *fakeHub) BroadcastToTenant(tenantID string, event *types.Event) error {
+	return h.BroadcastToUser("*"+tenantID, event)
+}
+
+// received returns how many events userID has been sent
+func (h *fakeHub) received(userID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sent[userID])
+}
+
+func (h *fakeHub) IsUserConnected(userID string) bool {
+	return !h.offline[userID]
+}
+
+// fakeNotifications keeps settings and queued events in memory
+type fakeNotifications struct {
+	storage.NotificationStore
+	settings map[string]users.NotificationSettings
+	queued   map[string][]*types.Event
+}
+
+func (n *fakeNotifications) GetNotificationSettings(userID string) (users.NotificationSettings, error) {
+	return n.settings[userID], nil
+}
+
+func (n *fakeNotifications) QueueNotification(userID string, event *types.Event) error {
+	n.queued[userID] = append(n.queued[userID], event)
+	return nil
+}
+
+func (n *fakeNotifications) GetQueuedNotificationUsers() (map[string]users.NotificationSettings, error) {
+	pending := make(map[string]users.NotificationSettings)
+	for userID := range n.queued {
+		pending[userID] = n.settings[userID]
+	}
+	return pending, nil
+}
+
+func (n *fakeNotifications) ClaimQueuedNotifications(userID string) (map[types.EventType]int, error) {
+	counts := make(map[types.EventType]int)
+	for _, event := range n.queued[userID] {
+		counts[event.Type]++
+	}
+	delete(n.queued, userID)
+	return counts, nil
+}
+
+// fakePrivacy keeps privacy settings in memory
+type fakePrivacy struct {
+	storage.PrivacyStore
+	settings map[string]users.PrivacySettings
+}
+
+func (p *fakePrivacy) GetPrivacySettings(userID string) (users.PrivacySettings, error) {
+	return p.settings[userID], nil
+}
+
+func TestEventPublisher_QuietHours(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Europe/Paris"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC) // 01:30 in Paris
+	}
+
+	if err := publisher.PublishStoryViewed("1", "viewer", "sleeper"); err != nil {
+		t.Fatalf("PublishStoryViewed failed: %v", err)
+	}
+	if err := publisher.PublishStoryReacted("1", "viewer", "awake", types.ReactionFire); err != nil {
+		t.Fatalf("PublishStoryReacted failed: %v", err)
+	}
+
+	if len(hub.sent["sleeper"]) != 0 || len(notifications.queued["sleeper"]) != 1 {
+		t.Errorf("Expected the view to be queued during quiet hours, sent %d queued %d",
+			len(hub.sent["sleeper"]), len(notifications.queued["sleeper"]))
+	}
+	if len(hub.sent["awake"]) != 1 || len(notifications.queued["awake"]) != 0 {
+		t.Errorf("Expected the reaction to be delivered, sent %d queued %d",
+			len(hub.sent["awake"]), len(notifications.queued["awake"]))
+	}
+}
+
+func TestEventPublisher_HiddenViewReceipts(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	privacy := &fakePrivacy{settings: map[string]users.PrivacySettings{
+		"lurker": {HideViewReceipts: true},
+	}}
+	publisher := NewEventPublisher(hub).WithViewReceipts(privacy)
+
+	if err := publisher.PublishStoryViewed("1", "lurker", "author"); err != nil {
+		t.Fatalf("PublishStoryViewed failed: %v", err)
+	}
+	if got := hub.received("author"); got != 0 {
+		t.Errorf("Expected no event for a hidden view, got %d", got)
+	}
+
+	if err := publisher.PublishStoryViewed("1", "viewer", "author"); err != nil {
+		t.Fatalf("PublishStoryViewed failed: %v", err)
+	}
+	if got := hub.received("author"); got != 1 {
+		t.Errorf("Expected one event for a visible view, got %d", got)
+	}
+}
+
+func TestEventPublisher_ReactionBatching(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	// Windows are closed by hand below; the hour keeps the timers out of the way
+	publisher := NewEventPublisher(hub).WithReactionBatching(time.Hour)
+
+	react := func(storyID string, emoji types.ReactionType) {
+		t.Helper()
+		if err := publisher.PublishStoryReacted(storyID, "fan", "author", emoji); err != nil {
+			t.Fatalf("PublishStoryReacted failed: %v", err)
+		}
+	}
+
+	// The first reaction goes out at once and opens a window
+	react("1", types.ReactionFire)
+	if len(hub.sent["author"]) != 1 || hub.sent["author"][0].Type != types.EventStoryReacted {
+		t.Fatalf("Expected the first reaction to be sent on its own, got %+v", hub.sent["author"])
+	}
+
+	// Reactions within the window are held
+	react("1", types.ReactionFire)
+	react("2", types.ReactionHeart)
+	react("1", types.ReactionLaugh)
+	if len(hub.sent["author"]) != 1 {
+		t.Fatalf("Expected reactions within the window to be held, got %d frames", len(hub.sent["author"]))
+	}
+
+	publisher.reactions.flush("author")
+	if len(hub.sent["author"]) != 2 {
+		t.Fatalf("Expected one summarized frame when the window closes, got %d frames", len(hub.sent["author"]))
+	}
+	event := hub.sent["author"][1]
+	batch, ok := event.Data.(*types.ReactionBatchEvent)
+	if event.Type != types.EventReactionBatch || !ok {
+		t.Fatalf("Expected a %s event, got %+v", types.EventReactionBatch, event)
+	}
+	if batch.Total != 3 || len(batch.Stories) != 2 {
+		t.Fatalf("Expected 3 reactions on 2 stories, got %+v", batch)
+	}
+	if batch.Stories[0].StoryID != "1" || batch.Stories[0].Count != 2 ||
+		batch.Stories[0].Emojis[types.ReactionFire] != 1 || batch.Stories[0].Emojis[types.ReactionLaugh] != 1 {
+		t.Errorf("Unexpected counts for story 1: %+v", batch.Stories[0])
+	}
+	if batch.Stories[1].StoryID != "2" || batch.Stories[1].Count != 1 {
+		t.Errorf("Unexpected counts for story 2: %+v", batch.Stories[1])
+	}
+
+	// A window that closes empty ends the burst, so the next reaction is sent at once
+	publisher.reactions.flush("author")
+	react("2", types.ReactionSad)
+	if len(hub.sent["author"]) != 3 || hub.sent["author"][2].Type != types.EventStoryReacted {
+		t.Errorf("Expected a reaction after the burst to be sent on its own, got %d frames", len(hub.sent["author"]))
+	}
+}
+
+func TestEventPublisher_PublishStoryRemoved(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	publisher := NewEventPublisher(hub)
+
+	story := types.Story{ID: "1", AuthorID: "author", Visibility: types.VisibilityFriends}
+	if err := publisher.PublishStoryRemoved(types.EventStoryDeleted, story, []string{"f1", "f2"}); err != nil {
+		t.Fatalf("PublishStoryRemoved failed: %v", err)
+	}
+	for _, userID := range []string{"author", "f1", "f2"} {
+		if len(hub.sent[userID]) != 1 || hub.sent[userID][0].Type != types.EventStoryDeleted {
+			t.Errorf("Expected %s to get story.deleted, got %v", userID, hub.sent[userID])
+		}
+	}
+
+	// A story no one else could see is only removed from the author's devices
+	private := types.Story{ID: "2", AuthorID: "author", Visibility: types.VisibilityPrivate}
+	if err := publisher.PublishStoryRemoved(types.EventStoryExpired, private, nil); err != nil {
+		t.Fatalf("PublishStoryRemoved failed: %v", err)
+	}
+	if len(hub.sent["author"]) != 2 || len(hub.sent["f1"]) != 1 {
+		t.Errorf("Expected story.expired for the author only, got author %d f1 %d",
+			len(hub.sent["author"]), len(hub.sent["f1"]))
+	}
+}
+
+func TestEventPublisher_PublishStoryRevoked(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), offline: map[string]bool{"u2": true}}
+	publisher := NewEventPublisher(hub)
+
+	story := types.Story{ID: "1", AuthorID: "author", Visibility: types.VisibilityPrivate}
+	if err := publisher.PublishStoryRevoked(story, []string{"u1", "u2"}); err != nil {
+		t.Fatalf("PublishStoryRevoked failed: %v", err)
+	}
+	if len(hub.sent["u1"]) != 1 || hub.sent["u1"][0].Type != types.EventStoryRevoked {
+		t.Fatalf("Expected u1 to get story.revoked, got %v", hub.sent["u1"])
+	}
+	if data := hub.sent["u1"][0].Data.(*types.StoryRemovedEvent); data.StoryID != "1" || data.AuthorID != "author" {
+		t.Errorf("Unexpected story.revoked data: %+v", data)
+	}
+	// Neither the offline user nor the author hears about it
+	if hub.received("u2") != 0 || hub.received("author") != 0 {
+		t.Errorf("Expected only u1 to be sent story.revoked, got u2 %d author %d",
+			hub.received("u2"), hub.received("author"))
+	}
+
+	// No one connected, no broadcast
+	hub.failures = 1
+	if err := publisher.PublishStoryRevoked(story, []string{"u2"}); err != nil || hub.failures != 1 {
+		t.Errorf("Expected nothing broadcast with no one connected, got %v", err)
+	}
+}
+
+func TestEventPublisher_FollowEvents(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	if err := publisher.PublishUserFollowed("fan", "awake"); err != nil {
+		t.Fatalf("PublishUserFollowed failed: %v", err)
+	}
+	if err := publisher.PublishUserFollowed("fan", "sleeper"); err != nil {
+		t.Fatalf("PublishUserFollowed failed: %v", err)
+	}
+	if err := publisher.PublishUserUnfollowed("fan", "sleeper"); err != nil {
+		t.Fatalf("PublishUserUnfollowed failed: %v", err)
+	}
+
+	if len(hub.sent["awake"]) != 1 || hub.sent["awake"][0].Type != types.EventUserFollowed {
+		t.Errorf("Expected user.followed to be delivered, got %v", hub.sent["awake"])
+	}
+	// New followers wait for the digest, lost ones are synced right away
+	if len(notifications.queued["sleeper"]) != 1 || notifications.queued["sleeper"][0].Type != types.EventUserFollowed {
+		t.Errorf("Expected user.followed to be queued during quiet hours, got %v", notifications.queued["sleeper"])
+	}
+	if len(hub.sent["sleeper"]) != 1 || hub.sent["sleeper"][0].Type != types.EventUserUnfollowed {
+		t.Errorf("Expected user.unfollowed to be sent during quiet hours, got %v", hub.sent["sleeper"])
+	}
+}
+
+func TestEventPublisher_BatchFollowEvents(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	if err := publisher.PublishUsersFollowed("fan", []string{"a", "b", "sleeper"}); err != nil {
+		t.Fatalf("PublishUsersFollowed failed: %v", err)
+	}
+	if hub.received("a") != 1 || hub.received("b") != 1 || hub.received("sleeper") != 0 {
+		t.Errorf("Expected user.followed for awake users only, got %v", hub.sent)
+	}
+	if hub.sent["a"][0] != hub.sent["b"][0] {
+		t.Error("Expected a single event shared by every followed user")
+	}
+	if data := hub.sent["a"][0].Data.(*types.FollowEvent); data.FollowerID != "fan" || data.FollowedID != "" {
+		t.Errorf("Expected the follower without a followed user, got %+v", data)
+	}
+	if len(notifications.queued["sleeper"]) != 1 {
+		t.Errorf("Expected user.followed to be queued during quiet hours, got %v", notifications.queued["sleeper"])
+	}
+
+	if err := publisher.PublishUsersUnfollowed("fan", []string{"a", "sleeper"}); err != nil {
+		t.Fatalf("PublishUsersUnfollowed failed: %v", err)
+	}
+	if hub.received("a") != 2 || hub.received("sleeper") != 1 || hub.received("b") != 1 {
+		t.Errorf("Expected user.unfollowed for unfollowed users regardless of quiet hours, got %v", hub.sent)
+	}
+}
+
+func TestEventPublisher_StoryUnreacted(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Europe/Paris"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications).WithReactionBatching(time.Hour)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	if err := publisher.PublishStoryUnreacted("1", "fan", "sleeper", types.ReactionFire); err != nil {
+		t.Fatalf("PublishStoryUnreacted failed: %v", err)
+	}
+	if err := publisher.PublishStoryUnreacted("2", "author", "author", types.ReactionHeart); err != nil {
+		t.Fatalf("PublishStoryUnreacted failed: %v", err)
+	}
+
+	// Counters are synced right away, even during quiet hours
+	if len(hub.sent["sleeper"]) != 1 || hub.sent["sleeper"][0].Type != types.EventStoryUnreacted || len(notifications.queued["sleeper"]) != 0 {
+		t.Fatalf("Expected story.unreacted to be sent during quiet hours, got %v", hub.sent["sleeper"])
+	}
+	if data := hub.sent["sleeper"][0].Data.(*types.StoryUnreactedEvent); data.StoryID != "1" || data.UserID != "fan" || data.Emoji != types.ReactionFire {
+		t.Errorf("Unexpected story.unreacted payload: %+v", data)
+	}
+	if len(hub.sent["author"]) != 0 {
+		t.Errorf("Expected no event for removing a reaction to one's own story, got %v", hub.sent["author"])
+	}
+}
+
+func TestEventPublisher_StoryReshared(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	if err := publisher.PublishStoryReshared("1", "2", "fan", "awake"); err != nil {
+		t.Fatalf("PublishStoryReshared failed: %v", err)
+	}
+	if err := publisher.PublishStoryReshared("3", "4", "fan", "sleeper"); err != nil {
+		t.Fatalf("PublishStoryReshared failed: %v", err)
+	}
+
+	if len(hub.sent["awake"]) != 1 || hub.sent["awake"][0].Type != types.EventStoryReshared {
+		t.Fatalf("Expected story.reshared to be delivered, got %v", hub.sent["awake"])
+	}
+	if data := hub.sent["awake"][0].Data.(*types.StoryResharedEvent); data.StoryID != "1" || data.ReshareID != "2" || data.UserID != "fan" {
+		t.Errorf("Unexpected story.reshared payload: %+v", data)
+	}
+	if len(notifications.queued["sleeper"]) != 1 || len(hub.sent["sleeper"]) != 0 {
+		t.Errorf("Expected story.reshared to be queued during quiet hours, sent %d queued %d",
+			len(hub.sent["sleeper"]), len(notifications.queued["sleeper"]))
+	}
+}
+
+func TestEventPublisher_StoryScreenshotted(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event)}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	if err := publisher.PublishStoryScreenshotted("1", "fan", "awake", types.CaptureRecording); err != nil {
+		t.Fatalf("PublishStoryScreenshotted failed: %v", err)
+	}
+	if err := publisher.PublishStoryScreenshotted("2", "fan", "sleeper", types.CaptureScreenshot); err != nil {
+		t.Fatalf("PublishStoryScreenshotted failed: %v", err)
+	}
+
+	if len(hub.sent["awake"]) != 1 || hub.sent["awake"][0].Type != types.EventStoryScreenshot {
+		t.Fatalf("Expected story.screenshotted to be delivered, got %v", hub.sent["awake"])
+	}
+	if data := hub.sent["awake"][0].Data.(*types.StoryScreenshotEvent); data.StoryID != "1" || data.UserID != "fan" || data.Kind != types.CaptureRecording {
+		t.Errorf("Unexpected story.screenshotted payload: %+v", data)
+	}
+	if len(notifications.queued["sleeper"]) != 1 || len(hub.sent["sleeper"]) != 0 {
+		t.Errorf("Expected story.screenshotted to be queued during quiet hours, sent %d queued %d",
+			len(hub.sent["sleeper"]), len(notifications.queued["sleeper"]))
+	}
+}
+
+func TestEventPublisher_StoryReplied(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), offline: map[string]bool{"away": true}}
+	notifications := &fakeNotifications{
+		settings: map[string]users.NotificationSettings{
+			"sleeper": {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"},
+		},
+		queued: make(map[string][]*types.Event),
+	}
+	publisher := NewEventPublisher(hub).WithQuietHours(notifications)
+	publisher.now = func() time.Time {
+		return time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	}
+
+	for _, authorID := range []string{"awake", "sleeper", "away"} {
+		if err := publisher.PublishStoryReplied("1", "fan", authorID, "nice"); err != nil {
+			t.Fatalf("PublishStoryReplied to %s failed: %v", authorID, err)
+		}
+	}
+
+	if len(hub.sent["awake"]) != 1 || hub.sent["awake"][0].Type != types.EventStoryReplied {
+		t.Fatalf("Expected story.replied to be delivered, got %v", hub.sent["awake"])
+	}
+	for _, authorID := range []string{"sleeper", "away"} {
+		if len(notifications.queued[authorID]) != 1 || len(hub.sent[authorID]) != 0 {
+			t.Errorf("Expected the reply to %s to be queued, sent %d queued %d",
+				authorID, len(hub.sent[authorID]), len(notifications.queued[authorID]))
+		}
+	}
+
+	// Without a digest to queue it for, a reply to an offline author is reported
+	publisher = NewEventPublisher(hub)
+	if err := publisher.PublishStoryReplied("1", "fan", "away", "nice"); !errors.Is(err, ErrReplyNotDelivered) {
+		t.Errorf("Expected ErrReplyNotDelivered, got %v", err)
+	}
+}
+
+func TestEventPublisher_StoryExpiring(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), offline: map[string]bool{"away": true}}
+	publisher := NewEventPublisher(hub)
+	expiresAt := time.Now().Add(time.Hour)
+
+	if err := publisher.PublishStoryExpiring("1", "author", expiresAt); err != nil {
+		t.Fatalf("PublishStoryExpiring failed: %v", err)
+	}
+	if len(hub.sent["author"]) != 1 || hub.sent["author"][0].Type != types.EventStoryExpiring {
+		t.Errorf("Expected story.expiring to be delivered, got %v", hub.sent["author"])
+	}
+
+	// Warnings that are not sent are reported, so they can be sent again later
+	if err := publisher.PublishStoryExpiring("2", "away", expiresAt); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected for an offline author, got %v", err)
+	}
+	hub.failures = 1
+	if err := publisher.PublishStoryExpiring("1", "author", expiresAt); err == nil {
+		t.Error("Expected a failed send to be reported")
+	}
+}
+
+func TestEventPublisher_RetriesFailedDelivery(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), failures: 2}
+	publisher := NewEventPublisher(hub)
+	publisher.retries.backoff = time.Millisecond
+
+	if err := publisher.PublishUserUnfollowed("fan", "author"); err != nil {
+		t.Fatalf("Expected the failed delivery to be retried, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hub.received("author") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the event to be delivered on the third attempt")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventPublisher_DeadLetters(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), failures: 1}
+	publisher := NewEventPublisher(hub)
+	publisher.retries.maxAttempts = 1
+
+	if err := publisher.PublishUserUnfollowed("fan", "author"); err == nil {
+		t.Error("Expected an error once the event runs out of attempts")
+	}
+
+	// Events that cannot be serialized are never handed to the hub
+	event := types.NewEvent(types.EventAnnouncement, map[string]any{"bad": make(chan int)})
+	err := publisher.publish(event, nil, func() error {
+		t.Error("Expected an unserializable event not to be sent")
+		return nil
+	})
+	if err == nil {
+		t.Error("Expected an error for an unserializable event")
+	}
+	if hub.received("author") != 0 {
+		t.Errorf("Expected nothing to be delivered, got %d events", hub.received("author"))
+	}
+}
+
+func TestEventPublisher_ShutdownDrainsRetries(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), failures: 1}
+	publisher := NewEventPublisher(hub)
+	publisher.retries.backoff = time.Hour
+
+	if err := publisher.PublishUserUnfollowed("fan", "author"); err != nil {
+		t.Fatalf("Expected the failed delivery to be retried, got %v", err)
+	}
+	if err := publisher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if hub.received("author") != 1 {
+		t.Errorf("Expected the pending retry to be delivered on shutdown, got %d events", hub.received("author"))
+	}
+
+	// Once shut down, failures are not retried
+	hub.failures = 1
+	if err := publisher.PublishUserUnfollowed("fan", "author"); err == nil {
+		t.Error("Expected a failed delivery after shutdown to be dead-lettered")
+	}
+}
+
+func TestEventPublisher_ShutdownDeadline(t *testing.T) {
+	hub := &fakeHub{sent: make(map[string][]*types.Event), failures: 1}
+	publisher := NewEventPublisher(hub)
+	publisher.retries.backoff = time.Hour
+
+	if err := publisher.PublishUserUnfollowed("fan", "author"); err != nil {
+		t.Fatalf("Expected the failed delivery to be retried, got %v", err)
+	}
+
+	// Retries the deadline leaves no time for are dead-lettered, not sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := publisher.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if hub.received("author") != 0 {
+		t.Errorf("Expected nothing to be delivered, got %d events", hub.received("author"))
+	}
+}
+
+func TestDigestSummary(t *testing.T) {
+	cases := []struct {
+		counts map[types.EventType]int
+		want   string
+	}{
+		{map[types.EventType]int{types.EventStoryViewed: 12, types.EventStoryReacted: 3}, "12 views, 3 reactions"},
+		{map[types.EventType]int{types.EventStoryReacted: 1}, "1 reaction"},
+		{map[types.EventType]int{types.EventStoryViewed: 2, types.EventUserFollowed: 1}, "2 views, 1 new follower"},
+		{map[types.EventType]int{types.EventUserFollowed: 2, types.EventStoryReshared: 1}, "1 reshare, 2 new followers"},
+		{map[types.EventType]int{types.EventStoryScreenshot: 2, types.EventStoryReacted: 1}, "1 reaction, 2 screenshots"},
+		{map[types.EventType]int{types.EventStoryViewed: 1, "story.shared": 2}, "1 view, 2 story.shared"},
+	}
+
+	for _, tc := range cases {
+		if got := DigestSummary(tc.counts); got != tc.want {
+			t.Errorf("Expected %q, got %q", tc.want, got)
+		}
+	}
+}
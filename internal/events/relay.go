@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// relayMessage is the payload published on the relay channel
+type relayMessage struct {
+	UserIDs []string     `json:"user_ids"`
+	All     bool         `json:"all,omitempty"`    // every connected client of Tenant rather than UserIDs
+	Tenant  string       `json:"tenant,omitempty"` // with All, the tenant whose clients get the event
+	Event   *types.Event `json:"event"`
+}
+
+// RedisRelay forwards events over Redis pub/sub so that processes without a
+// WebSocket hub (such as the ephemeral worker) can notify connected users.
+// It implements WebSocketHub, so it can back an EventPublisher directly.
+type RedisRelay struct {
+	redis   *redis.Client
+	channel string
+}
+
+// NewRedisRelay creates a new Redis event relay on the relay channel of keys'
+// environment, so environments sharing a Redis never see each other's events
+func NewRedisRelay(redisClient *redis.Client, keys cache.Keys) *RedisRelay {
+	return &RedisRelay{
+		redis:   redisClient,
+		channel: keys.Key(cache.RelayChannel),
+	}
+}
+
+// BroadcastToUser relays an event to a specific user
+func (r *RedisRelay) BroadcastToUser(userID string, event *types.Event) error {
+	return r.BroadcastToUsers([]string{userID}, event)
+}
+
+// BroadcastToUsers relays an event to specific users
+func (r *RedisRelay) BroadcastToUsers(userIDs []string, event *types.Event) error {
+	return r.publish(relayMessage{UserIDs: userIDs, Event: event})
+}
+
+// BroadcastToTenant relays an event to every client of tenantID connected to
+// any subscribed hub
+func (r *RedisRelay) BroadcastToTenant(tenantID string, event *types.Event) error {
+	return r.publish(relayMessage{All: true, Tenant: tenantID, Event: event})
+}
+
+// publish sends a message on the relay channel
+func (r *RedisRelay) publish(message relayMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode relayed event: %w", err)
+	}
+
+	if err := r.redis.Publish(context.Background(), r.channel, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish relayed event: %w", err)
+	}
+	return nil
+}
+
+// IsUserConnected always reports true: connection state lives in the hub on
+// the subscribing side, which drops events for users that are not connected
+func (r *RedisRelay) IsUserConnected(userID string) bool {
+	return true
+}
+
+// Subscribe forwards relayed events to the given hub until the context is cancelled
+func (r *RedisRelay) Subscribe(ctx context.Context, hub WebSocketHub) {
+	pubsub := r.redis.Subscribe(ctx, r.channel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var message relayMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				slog.Error("Failed to decode relayed event", slog.String("error", err.Error()))
+				continue
+			}
+
+			var err error
+			if message.All {
+				err = hub.BroadcastToTenant(message.Tenant, message.Event)
+			} else {
+				err = hub.BroadcastToUsers(message.UserIDs, message.Event)
+			}
+			if err != nil {
+				slog.Error("Failed to forward relayed event",
+					slog.String("event_type", string(message.Event.Type)),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
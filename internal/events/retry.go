@@ -0,0 +1,170 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+const (
+	// Attempts made to deliver an event before it is dead-lettered
+	maxDeliveryAttempts = 5
+
+	// Delay before the first retry; it doubles with every further attempt
+	retryBackoff = 200 * time.Millisecond
+
+	// Events waiting to be retried at once; failures beyond it are dead-lettered
+	maxPendingRetries = 1000
+)
+
+// Reasons a failed delivery cannot wait for a retry
+var (
+	errRetryQueueFull = errors.New("retry queue is full")
+	errShuttingDown   = errors.New("publisher is shutting down")
+)
+
+// delivery is an event and the function that hands it to its recipients
+type delivery struct {
+	event      *types.Event
+	payload    []byte   // the event encoded, for the dead-letter log
+	recipients []string // empty for broadcasts to every client
+	send       func() error
+	attempts   int
+}
+
+// retryQueue redelivers failed events with exponential backoff and logs the
+// ones it gives up on to the dead-letter log
+type retryQueue struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxPending  int
+
+	mu      sync.Mutex
+	pending map[*delivery]*time.Timer // retries waiting for their backoff
+	closed  bool                      // set by shutdown; nothing more is scheduled
+}
+
+func newRetryQueue() *retryQueue {
+	return &retryQueue{
+		maxAttempts: maxDeliveryAttempts,
+		backoff:     retryBackoff,
+		maxPending:  maxPendingRetries,
+		pending:     make(map[*delivery]*time.Timer),
+	}
+}
+
+// run attempts a delivery, scheduling a retry if it fails. It only returns an
+// error when the event is dead-lettered straight away.
+func (q *retryQueue) run(d *delivery) error {
+	d.attempts++
+	err := d.send()
+	if err == nil {
+		metrics.ObserveEvent(string(d.event.Type), metrics.EventDelivered)
+		return nil
+	}
+
+	if d.attempts >= q.maxAttempts {
+		return q.deadLetter(d, err)
+	}
+
+	// The retry may start as soon as it is scheduled, so d is not read after
+	attempt, delay := d.attempts, q.backoff<<(d.attempts-1)
+	if scheduleErr := q.schedule(d, delay); scheduleErr != nil {
+		return q.deadLetter(d, fmt.Errorf("%w: %w", scheduleErr, err))
+	}
+
+	metrics.ObserveEvent(string(d.event.Type), metrics.EventRetried)
+	slog.Warn("Event delivery failed, retrying",
+		slog.String("event_type", string(d.event.Type)),
+		slog.Int("attempt", attempt),
+		slog.Duration("retry_in", delay),
+		slog.String("error", err.Error()))
+	return nil
+}
+
+// schedule runs d again after delay, unless the queue is full or shut down
+func (q *retryQueue) schedule(d *delivery, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errShuttingDown
+	}
+	if len(q.pending) >= q.maxPending {
+		return errRetryQueueFull
+	}
+	q.pending[d] = time.AfterFunc(delay, func() {
+		if q.take(d) {
+			q.run(d)
+		}
+	})
+	metrics.SetEventRetriesPending(len(q.pending))
+	return nil
+}
+
+// take removes d from the pending retries, reporting whether it was still
+// pending rather than taken by shutdown
+func (q *retryQueue) take(d *delivery) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[d]; !ok {
+		return false
+	}
+	delete(q.pending, d)
+	metrics.SetEventRetriesPending(len(q.pending))
+	return true
+}
+
+// shutdown stops scheduling retries and makes a last attempt at each one
+// still waiting for its backoff, without waiting, until ctx is done. Those
+// that fail again, or that ctx leaves no time for, are dead-lettered; ctx's
+// error is returned if any were left.
+func (q *retryQueue) shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	var waiting []*delivery
+	for d, timer := range q.pending {
+		// A timer that already fired runs its retry itself
+		if timer.Stop() {
+			waiting = append(waiting, d)
+			delete(q.pending, d)
+		}
+	}
+	metrics.SetEventRetriesPending(len(q.pending))
+	q.mu.Unlock()
+
+	for _, d := range waiting {
+		if ctx.Err() != nil {
+			q.deadLetter(d, errShuttingDown)
+			continue
+		}
+		q.run(d)
+	}
+	return ctx.Err()
+}
+
+// deadLetter logs an event that will not be delivered, with its payload when
+// it could be encoded, and returns why it was given up on
+func (q *retryQueue) deadLetter(d *delivery, err error) error {
+	metrics.ObserveEvent(string(d.event.Type), metrics.EventDeadLettered)
+
+	payload := string(d.payload)
+	if d.payload == nil {
+		payload = fmt.Sprintf("%+v", d.event)
+	}
+	slog.Error("Event dead-lettered",
+		slog.String("event_type", string(d.event.Type)),
+		slog.Any("recipients", d.recipients),
+		slog.Int("attempts", d.attempts),
+		slog.String("payload", payload),
+		slog.String("error", err.Error()))
+
+	return fmt.Errorf("event %s dead-lettered after %d attempts: %w", d.event.Type, d.attempts, err)
+}
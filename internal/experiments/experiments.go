@@ -0,0 +1,223 @@
+package experiments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+)
+
+// Experiments and their variants
+const (
+	FeedRanking = "feed_ranking"
+
+	VariantChronological = "chronological" // newest first, the control
+	VariantRanked        = "ranked"        // ordered by Rank
+)
+
+// Header tells clients which variant of an experiment a response was produced
+// under, as experiment=variant
+const Header = "X-Experiments"
+
+// exposureTTL is how long an exposure is remembered before the user counts as
+// exposed again in the metrics; the database keeps only the first
+const exposureTTL = 24 * time.Hour
+
+// settingsRefresh is how often each instance reads the settings changed at
+// runtime from Redis, so changes reach every instance within it
+const settingsRefresh = 30 * time.Second
+
+// ErrUnknownExperiment is returned by Set and Reset for experiments that are
+// not configured
+var ErrUnknownExperiment = errors.New("unknown experiment")
+
+// experiment is a configured experiment with its control and treatment
+type experiment struct {
+	control   string
+	treatment string
+	cfg       config.Experiment
+}
+
+// settings are the settings of an experiment last read from Redis
+type settings struct {
+	cfg    config.Experiment
+	readAt time.Time
+}
+
+// Assigner splits users between the variants of the configured experiments
+// and records when they are served one. Assignment is a hash of the user ID,
+// so it needs no storage and a user keeps their variant on every instance.
+// An experiment's settings can be changed at runtime with Set, which every
+// instance picks up within settingsRefresh; until then, and after Reset, the
+// configured settings apply.
+type Assigner struct {
+	redis       *redis.Client
+	keys        cache.Keys
+	store       storage.ExperimentStore
+	experiments map[string]experiment
+	refresh     time.Duration
+
+	mu      sync.Mutex
+	current map[string]settings
+}
+
+// NewAssigner creates an assigner for the configured experiments, recording
+// exposures in store and under keys
+func NewAssigner(redisClient *redis.Client, keys cache.Keys, store storage.ExperimentStore, cfg config.Experiments) *Assigner {
+	return &Assigner{
+		redis: redisClient,
+		keys:  keys,
+		store: store,
+		experiments: map[string]experiment{
+			FeedRanking: {control: VariantChronological, treatment: VariantRanked, cfg: cfg.FeedRanking},
+		},
+		refresh: settingsRefresh,
+		current: make(map[string]settings),
+	}
+}
+
+// Variant returns the user's variant of the named experiment and whether the
+// experiment is running. Users of a disabled experiment get its control.
+func (a *Assigner) Variant(ctx context.Context, name, userID string) (string, bool) {
+	exp, ok := a.experiments[name]
+	if !ok {
+		return "", false
+	}
+	cfg := a.settings(ctx, name, exp)
+	if !cfg.Enabled {
+		return exp.control, false
+	}
+
+	if bucket(name, cfg.Salt, userID) < cfg.Treatment {
+		return exp.treatment, true
+	}
+	return exp.control, true
+}
+
+// Settings returns the named experiment's settings now in force and whether
+// they were changed at runtime
+func (a *Assigner) Settings(ctx context.Context, name string) (config.Experiment, bool, error) {
+	exp, ok := a.experiments[name]
+	if !ok {
+		return config.Experiment{}, false, ErrUnknownExperiment
+	}
+	return a.load(ctx, name, exp)
+}
+
+// Set changes the named experiment's settings on every instance
+func (a *Assigner) Set(ctx context.Context, name string, cfg config.Experiment) error {
+	if _, ok := a.experiments[name]; !ok {
+		return ErrUnknownExperiment
+	}
+	if cfg.Treatment < 0 || cfg.Treatment > 100 {
+		return fmt.Errorf("treatment must be between 0 and 100, got %d", cfg.Treatment)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := a.redis.Set(ctx, a.keys.Key(cache.ExperimentKey, name), data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save experiment settings: %w", err)
+	}
+	a.remember(name, cfg)
+	return nil
+}
+
+// Reset drops the named experiment's runtime settings, so the configured ones
+// apply again
+func (a *Assigner) Reset(ctx context.Context, name string) error {
+	exp, ok := a.experiments[name]
+	if !ok {
+		return ErrUnknownExperiment
+	}
+	if err := a.redis.Del(ctx, a.keys.Key(cache.ExperimentKey, name)).Err(); err != nil {
+		return fmt.Errorf("failed to reset experiment settings: %w", err)
+	}
+	a.remember(name, exp.cfg)
+	return nil
+}
+
+// settings returns the settings of exp in force, reading them from Redis at
+// most once per refresh. While Redis cannot be reached the settings last read
+// are kept, or the configured ones before any were.
+func (a *Assigner) settings(ctx context.Context, name string, exp experiment) config.Experiment {
+	a.mu.Lock()
+	current, ok := a.current[name]
+	a.mu.Unlock()
+	if ok && time.Since(current.readAt) < a.refresh {
+		return current.cfg
+	}
+
+	cfg, _, err := a.load(ctx, name, exp)
+	if err != nil {
+		slog.Warn("Failed to read experiment settings", slog.String("error", err.Error()), slog.String("experiment", name))
+		if ok {
+			cfg = current.cfg
+		}
+	}
+	a.remember(name, cfg)
+	return cfg
+}
+
+// load reads the settings of exp from Redis, which are the configured ones
+// unless they were changed at runtime
+func (a *Assigner) load(ctx context.Context, name string, exp experiment) (config.Experiment, bool, error) {
+	data, err := a.redis.Get(ctx, a.keys.Key(cache.ExperimentKey, name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return exp.cfg, false, nil
+	}
+	if err != nil {
+		return exp.cfg, false, err
+	}
+
+	var cfg config.Experiment
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return exp.cfg, false, fmt.Errorf("invalid experiment settings: %w", err)
+	}
+	return cfg, true, nil
+}
+
+// remember keeps cfg as the named experiment's settings until the next refresh
+func (a *Assigner) remember(name string, cfg config.Experiment) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.current[name] = settings{cfg: cfg, readAt: time.Now()}
+}
+
+// Expose records that the user was served variant of the named experiment.
+// Each user is counted at most once a day, and the database keeps their first
+// exposure. Failures are logged and never fail the request.
+func (a *Assigner) Expose(ctx context.Context, name, variant, userID string) {
+	first, err := a.redis.SetNX(ctx, a.keys.Key(cache.ExposureKey, name, userID), variant, exposureTTL).Result()
+	if err != nil {
+		slog.Warn("Failed to check experiment exposure", slog.String("error", err.Error()), slog.String("experiment", name))
+		return
+	}
+	if !first {
+		return
+	}
+
+	metrics.ExperimentExposed(name, variant)
+	if err := a.store.RecordExposure(name, variant, userID); err != nil {
+		slog.Error("Failed to record experiment exposure", slog.String("error", err.Error()), slog.String("experiment", name), slog.String("user_id", userID))
+	}
+}
+
+// bucket hashes the user into one of 100 buckets. Each experiment and salt
+// hashes differently, so being treated in one says nothing about another.
+func bucket(name, salt, userID string) int {
+	h := fnv.New64a()
+	h.Write([]byte(name + ":" + salt + ":" + userID))
+	return int(h.Sum64() % 100)
+}
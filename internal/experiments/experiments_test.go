@@ -0,0 +1,200 @@
+package experiments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+)
+
+// fakeStore records the exposures written, failing while err is set
+type fakeStore struct {
+	exposures []string
+	err       error
+}
+
+func (s *fakeStore) RecordExposure(experiment, variant, userID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.exposures = append(s.exposures, experiment+"/"+variant+"/"+userID)
+	return nil
+}
+
+func (s *fakeStore) GetAuthorAffinity(userID string) (map[string]int, error) {
+	return nil, nil
+}
+
+func newAssigner(t *testing.T, cfg config.Experiment) (*Assigner, *fakeStore, *miniredis.Miniredis) {
+	t.Helper()
+	redisClient, mr := redistest.New(t)
+
+	store := &fakeStore{}
+	return NewAssigner(redisClient, cache.NewKeys(""), store, config.Experiments{FeedRanking: cfg}), store, mr
+}
+
+func TestAssigner_Variant(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Experiment
+		wantRunning bool
+		wantRanked  [2]int // bounds on how many of 1000 users are ranked
+	}{
+		{"disabled", config.Experiment{Enabled: false, Treatment: 50}, false, [2]int{0, 0}},
+		{"half", config.Experiment{Enabled: true, Treatment: 50}, true, [2]int{430, 570}},
+		{"nobody treated", config.Experiment{Enabled: true, Treatment: 0}, true, [2]int{0, 0}},
+		{"everyone treated", config.Experiment{Enabled: true, Treatment: 100}, true, [2]int{1000, 1000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assigner, _, _ := newAssigner(t, tt.cfg)
+
+			ranked := 0
+			for i := range 1000 {
+				userID := fmt.Sprint(i + 1)
+				variant, running := assigner.Variant(context.Background(), FeedRanking, userID)
+				if running != tt.wantRunning {
+					t.Fatalf("Variant() running = %v, want %v", running, tt.wantRunning)
+				}
+				if again, _ := assigner.Variant(context.Background(), FeedRanking, userID); again != variant {
+					t.Fatalf("User %s moved from %s to %s", userID, variant, again)
+				}
+				if variant == VariantRanked {
+					ranked++
+				}
+			}
+			if ranked < tt.wantRanked[0] || ranked > tt.wantRanked[1] {
+				t.Errorf("Expected %d to %d ranked users, got %d", tt.wantRanked[0], tt.wantRanked[1], ranked)
+			}
+		})
+	}
+}
+
+func TestAssigner_SaltReshuffles(t *testing.T) {
+	first, _, _ := newAssigner(t, config.Experiment{Enabled: true, Treatment: 50})
+	second, _, _ := newAssigner(t, config.Experiment{Enabled: true, Treatment: 50, Salt: "round-2"})
+
+	moved := 0
+	for i := range 1000 {
+		userID := fmt.Sprint(i + 1)
+		a, _ := first.Variant(context.Background(), FeedRanking, userID)
+		b, _ := second.Variant(context.Background(), FeedRanking, userID)
+		if a != b {
+			moved++
+		}
+	}
+	if moved < 400 || moved > 600 {
+		t.Errorf("Expected about half the users to change variant with a new salt, got %d", moved)
+	}
+}
+
+func TestAssigner_UnknownExperiment(t *testing.T) {
+	assigner, _, _ := newAssigner(t, config.Experiment{Enabled: true, Treatment: 50})
+
+	if variant, running := assigner.Variant(context.Background(), "nope", "1"); running || variant != "" {
+		t.Errorf("Expected no variant of an unknown experiment, got %q, %v", variant, running)
+	}
+}
+
+func TestAssigner_ExposeOncePerDay(t *testing.T) {
+	assigner, store, mr := newAssigner(t, config.Experiment{Enabled: true, Treatment: 50})
+	ctx := context.Background()
+
+	for range 3 {
+		assigner.Expose(ctx, FeedRanking, VariantRanked, "42")
+	}
+	if len(store.exposures) != 1 || store.exposures[0] != "feed_ranking/ranked/42" {
+		t.Fatalf("Expected one exposure of user 42, got %v", store.exposures)
+	}
+
+	mr.FastForward(exposureTTL)
+	assigner.Expose(ctx, FeedRanking, VariantRanked, "42")
+	if len(store.exposures) != 2 {
+		t.Errorf("Expected the user to be exposed again a day later, got %v", store.exposures)
+	}
+}
+
+func TestAssigner_ExposeSurvivesFailures(t *testing.T) {
+	assigner, store, mr := newAssigner(t, config.Experiment{Enabled: true, Treatment: 50})
+	ctx := context.Background()
+
+	store.err = errors.New("database down")
+	assigner.Expose(ctx, FeedRanking, VariantRanked, "42")
+	if len(store.exposures) != 0 {
+		t.Errorf("Expected no exposure while the store fails, got %v", store.exposures)
+	}
+
+	store.err = nil
+	mr.Close()
+	assigner.Expose(ctx, FeedRanking, VariantRanked, "7")
+	if len(store.exposures) != 0 {
+		t.Errorf("Expected no exposure without Redis, got %v", store.exposures)
+	}
+}
+
+func TestAssigner_RuntimeSettings(t *testing.T) {
+	assigner, _, mr := newAssigner(t, config.Experiment{Enabled: false, Treatment: 50})
+	other, _, _ := newAssigner(t, config.Experiment{Enabled: false, Treatment: 50})
+	other.redis = assigner.redis
+	ctx := context.Background()
+
+	if _, running := other.Variant(ctx, FeedRanking, "1"); running {
+		t.Fatal("Expected the configured experiment to be off")
+	}
+
+	if err := assigner.Set(ctx, FeedRanking, config.Experiment{Enabled: true, Treatment: 100}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if variant, running := assigner.Variant(ctx, FeedRanking, "1"); !running || variant != VariantRanked {
+		t.Errorf("Expected the new settings on the instance that set them, got %q, %v", variant, running)
+	}
+
+	// Other instances keep the settings they read until the next refresh
+	if _, running := other.Variant(ctx, FeedRanking, "1"); running {
+		t.Error("Expected another instance to keep its settings until the refresh")
+	}
+	other.refresh = 0
+	if variant, running := other.Variant(ctx, FeedRanking, "1"); !running || variant != VariantRanked {
+		t.Errorf("Expected another instance to pick up the new settings, got %q, %v", variant, running)
+	}
+	if cfg, changed, err := other.Settings(ctx, FeedRanking); err != nil || !changed || cfg.Treatment != 100 {
+		t.Errorf("Expected the changed settings, got %+v, %v, %v", cfg, changed, err)
+	}
+
+	// Without Redis the settings last read stay in force
+	mr.Close()
+	if _, running := other.Variant(ctx, FeedRanking, "1"); !running {
+		t.Error("Expected the last settings read to stay in force without Redis")
+	}
+
+	if err := assigner.Set(ctx, "nope", config.Experiment{}); !errors.Is(err, ErrUnknownExperiment) {
+		t.Errorf("Expected ErrUnknownExperiment, got %v", err)
+	}
+	if err := assigner.Set(ctx, FeedRanking, config.Experiment{Treatment: 101}); err == nil {
+		t.Error("Expected a treatment over 100 to be rejected")
+	}
+}
+
+func TestAssigner_Reset(t *testing.T) {
+	assigner, _, _ := newAssigner(t, config.Experiment{Enabled: true, Treatment: 0})
+	ctx := context.Background()
+
+	if err := assigner.Set(ctx, FeedRanking, config.Experiment{Enabled: false}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := assigner.Reset(ctx, FeedRanking); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if _, running := assigner.Variant(ctx, FeedRanking, "1"); !running {
+		t.Error("Expected the configured settings back after a reset")
+	}
+	if _, changed, err := assigner.Settings(ctx, FeedRanking); err != nil || changed {
+		t.Errorf("Expected no runtime settings after a reset, got %v, %v", changed, err)
+	}
+}
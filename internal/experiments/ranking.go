@@ -0,0 +1,36 @@
+package experiments
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// gravity is how fast ranked stories sink with age; higher favors recency
+// over affinity
+const gravity = 1.5
+
+// Rank orders a feed for the ranked variant of FeedRanking. Each story scores
+// (1 + affinity with its author) / (hours since it was posted + 2)^gravity,
+// so stories by authors the user engages with rise while every story still
+// sinks as it ages. Equal scores keep the feed's order, and stories is left
+// untouched.
+func Rank(stories []types.Story, affinity map[string]int, now time.Time) []types.Story {
+	scores := make(map[string]float64, len(stories))
+	for _, story := range stories {
+		age := 0.0
+		if !story.CreatedAt.IsZero() {
+			age = max(now.Sub(story.CreatedAt).Hours(), 0)
+		}
+		scores[story.ID] = float64(1+affinity[story.AuthorID]) / math.Pow(age+2, gravity)
+	}
+
+	ranked := slices.Clone(stories)
+	slices.SortStableFunc(ranked, func(a, b types.Story) int {
+		return cmp.Compare(scores[b.ID], scores[a.ID])
+	})
+	return ranked
+}
@@ -0,0 +1,70 @@
+package experiments
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	story := func(id, authorID string, age time.Duration) types.Story {
+		return types.Story{ID: id, AuthorID: authorID, CreatedAt: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name     string
+		stories  []types.Story
+		affinity map[string]int
+		want     []string
+	}{
+		{
+			name:    "without affinity newest first",
+			stories: []types.Story{story("old", "a", 5*time.Hour), story("new", "b", time.Hour)},
+			want:    []string{"new", "old"},
+		},
+		{
+			name:     "affinity lifts an older story",
+			stories:  []types.Story{story("new", "stranger", time.Hour), story("old", "friend", 4*time.Hour)},
+			affinity: map[string]int{"friend": 10},
+			want:     []string{"old", "new"},
+		},
+		{
+			name:     "age still wins over a little affinity",
+			stories:  []types.Story{story("new", "stranger", 0), story("old", "friend", 20*time.Hour)},
+			affinity: map[string]int{"friend": 2},
+			want:     []string{"new", "old"},
+		},
+		{
+			name:    "ties keep the feed's order",
+			stories: []types.Story{story("first", "a", time.Hour), story("second", "b", time.Hour)},
+			want:    []string{"first", "second"},
+		},
+		{
+			name:    "unparseable timestamps count as new",
+			stories: []types.Story{story("dated", "a", 3*time.Hour), {ID: "undated", AuthorID: "b"}},
+			want:    []string{"undated", "dated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := slices.Clone(tt.stories)
+
+			ranked := Rank(tt.stories, tt.affinity, now)
+
+			var got []string
+			for _, s := range ranked {
+				got = append(got, s.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Rank() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.stories, original) {
+				t.Error("Rank() modified the feed it was given")
+			}
+		})
+	}
+}
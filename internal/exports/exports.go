@@ -0,0 +1,90 @@
+// Package exports builds creator insights exports: CSV files of each story's
+// daily activity, either streamed to the client or written to the user's
+// tenant bucket by a background job.
+package exports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// JobStats is the kind of job that writes a stats export to the bucket
+const JobStats = "export.stats"
+
+// Header names the columns of an insights export
+var Header = []string{"day", "story_id", "views", "unique_viewers", "reactions", "link_clicks", "impressions", "reshares", "screenshots"}
+
+// Row formats m as a row under Header
+func Row(m users.DailyStoryMetrics) []string {
+	return []string{
+		m.Day, m.StoryID, strconv.Itoa(m.Views), strconv.Itoa(m.UniqueViewers),
+		strconv.Itoa(m.Reactions), strconv.Itoa(m.LinkClicks), strconv.Itoa(m.Impressions), strconv.Itoa(m.Reshares),
+		strconv.Itoa(m.Screenshots),
+	}
+}
+
+// StatsPayload is the payload of a JobStats job. From and To are YYYY-MM-DD
+// days, both included.
+type StatsPayload struct {
+	TenantID string `json:"tenant_id"`
+	UserID   string `json:"user_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// ObjectKey is where the export made by job jobID for userID is stored. It is
+// outside users/ so media reconciliation does not report it as untracked.
+func ObjectKey(userID, jobID string) string {
+	return fmt.Sprintf("exports/%s/%s.csv", userID, jobID)
+}
+
+// StatsJob returns a job handler writing stats exports from store to the
+// tenant buckets of media. A retried job overwrites the file of the failed
+// attempt.
+func StatsJob(store storage.UserStore, media *mediaService.Service) jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var payload StatsPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		from, err := time.Parse(time.DateOnly, payload.From)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid from day: %w", err))
+		}
+		to, err := time.Parse(time.DateOnly, payload.To)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid to day: %w", err))
+		}
+
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(Header); err != nil {
+			return err
+		}
+		err = store.StreamDailyStoryMetrics(ctx, payload.UserID, from, to, func(m users.DailyStoryMetrics) error {
+			return writer.Write(Row(m))
+		})
+		if err != nil {
+			return fmt.Errorf("read metrics: %w", err)
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		bucket, err := media.ForTenant(payload.TenantID)
+		if err != nil {
+			return err
+		}
+		return bucket.PutObject(ctx, ObjectKey(payload.UserID, job.ID), buf.Bytes(), "text/csv")
+	}
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// abuseFlagLimits bounds the page size of GET /admin/abuse/flags
+var abuseFlagLimits = request.Limits{Default: 50, Max: 200}
+
+// ThrottleLifter ends the throttle the abuse detector put on an account
+type ThrottleLifter interface {
+	Lift(ctx context.Context, userID string) error
+}
+
+// AbuseFlags returns the accounts the abuse detector flagged
+// @Summary List abuse flags
+// @ID listAbuseFlags
+// @Description Get the accounts in your tenant that the abuse detector shadow-throttled for following, unfollowing or viewing far more than people do in an hour, newest first. Open flags are returned unless reviewed is set. Admins only.
+// @Tags admin
+// @Produce json
+// @Param reviewed query bool false "Return reviewed flags instead of open ones"
+// @Param limit query int false "Flags to return, 1 to 200" default(50)
+// @Success 200 {object} response.Response{data=[]users.AbuseFlag} "Abuse flags"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/abuse/flags [get]
+func AbuseFlags(store storage.AbuseStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limit, ok := abuseFlagLimits.Limit(w, r)
+		if !ok {
+			return
+		}
+		reviewed := r.URL.Query().Get("reviewed") == "true"
+
+		flags, err := store.GetAbuseFlags(adminID, reviewed, limit)
+		if err != nil {
+			slog.Error("Failed to get abuse flags", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetAbuseFlags)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Abuse flags retrieved", flags))
+	}
+}
+
+// ReviewAbuseFlag closes an abuse flag
+// @Summary Review an abuse flag
+// @ID reviewAbuseFlag
+// @Description Mark an open abuse flag in your tenant as reviewed by you. The account stays throttled until the throttle runs out or is lifted with DELETE /admin/users/{user_id}/throttle. Admins only.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Abuse flag ID"
+// @Success 200 {object} response.Response{data=users.AbuseFlag} "Flag reviewed"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 404 {object} response.Response "No open flag with this ID in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/abuse/flags/{id}/review [post]
+func ReviewAbuseFlag(store storage.AbuseStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		flagID := r.PathValue("id")
+		if _, err := strconv.Atoi(flagID); err != nil {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAbuseFlagNotFound)))
+			return
+		}
+
+		flag, err := store.ReviewAbuseFlag(adminID, flagID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAbuseFlagNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to review abuse flag", slog.String("error", err.Error()), slog.String("flag_id", flagID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToReviewAbuseFlag)))
+			return
+		}
+
+		slog.Info("Abuse flag reviewed", slog.String("admin_id", adminID), slog.String("flag_id", flagID))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Abuse flag reviewed", flag))
+	}
+}
+
+// LiftThrottle ends an account's abuse throttle
+// @Summary Lift an abuse throttle
+// @ID liftThrottle
+// @Description End the shadow throttle the abuse detector put on a user in your tenant and reset their hourly counts, so their follows, unfollows and views take effect again. Lifting a user who is not throttled does nothing. Admins only.
+// @Tags admin
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} response.Response "Throttle lifted"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 404 {object} response.Response "User not found in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/users/{user_id}/throttle [delete]
+func LiftThrottle(store storage.UserStore, lifter ThrottleLifter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.PathValue("user_id")
+		if userID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		user, err := store.GetUserByID(userID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && user.TenantID != tenant.FromContext(r.Context())) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get user to lift throttle", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToLiftThrottle)))
+			return
+		}
+
+		if err := lifter.Lift(r.Context(), userID); err != nil {
+			slog.Error("Failed to lift throttle", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToLiftThrottle)))
+			return
+		}
+
+		adminID, _ := middleware.GetUserIDFromContext(r.Context())
+		slog.Info("Abuse throttle lifted", slog.String("admin_id", adminID), slog.String("user_id", userID))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Throttle lifted", nil))
+	}
+}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/mediasync"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// Announce handles broadcasting a system announcement
+// @Summary Broadcast a system announcement
+// @ID sendAnnouncement
+// @Description Send a system.announcement event, such as a maintenance notice or feature flags, to every connected client of your tenant or only to the given user_ids, which must all be users of your tenant. Admins only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param announcement body types.AnnouncementRequest true "Announcement"
+// @Success 200 {object} response.Response "Announcement sent"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 404 {object} response.Response "A user is not in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/announcements [post]
+func Announce(store storage.UserStore, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := request.DecodeJSON[types.AnnouncementRequest](w, r)
+		if !ok {
+			return
+		}
+
+		level := req.Level
+		if level == "" {
+			level = types.AnnouncementInfo
+		}
+
+		announcement := &types.AnnouncementEvent{
+			Title:   req.Title,
+			Message: req.Message,
+			Level:   level,
+			Flags:   req.Flags,
+		}
+		tenantID := tenant.FromContext(r.Context())
+		if len(req.UserIDs) > 0 {
+			found, err := store.GetTenantUsers(tenantID, req.UserIDs)
+			if err != nil {
+				slog.Error("Failed to get announcement recipients", slog.String("error", err.Error()))
+				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToSendAnnouncement)))
+				return
+			}
+			// Admins can only reach users of their own tenant
+			if !containsAll(found, req.UserIDs) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+				return
+			}
+		}
+
+		if err := publisher.PublishAnnouncement(announcement, tenantID, req.UserIDs); err != nil {
+			slog.Error("Failed to publish announcement", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToSendAnnouncement)))
+			return
+		}
+
+		adminID, _ := middleware.GetUserIDFromContext(r.Context())
+		slog.Info("System announcement sent",
+			slog.String("admin_id", adminID),
+			slog.String("level", level),
+			slog.Int("targeted_users", len(req.UserIDs)))
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Announcement sent", nil))
+	}
+}
+
+// containsAll reports whether every ID of userIDs is one of found
+func containsAll(found, userIDs []string) bool {
+	known := make(map[string]bool, len(found))
+	for _, userID := range found {
+		known[userID] = true
+	}
+	for _, userID := range userIDs {
+		if !known[userID] {
+			return false
+		}
+	}
+	return true
+}
+
+// MediaReconciliation returns the report of the last media reconciliation run
+// @Summary Get the media reconciliation report
+// @ID getMediaReconciliation
+// @Description Get what the last run of the media reconciliation job found in your tenant: objects in the bucket with no upload record, confirmed uploads whose object is gone, and uploads left unconfirmed past the TTL. Orphan lists hold at most 100 entries each; the counts are complete. Admins only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} response.Response{data=media.ReconciliationReport} "Reconciliation report"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 404 {object} response.Response "The job has not run yet"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/media/reconciliation [get]
+func MediaReconciliation(redisClient *redis.Client, keys cache.Keys) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var report media.ReconciliationReport
+		report, err := mediasync.LoadReport(r.Context(), redisClient, keys, tenant.FromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, mediasync.ErrNoReport) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNoReconciliationReport)))
+				return
+			}
+			slog.Error("Failed to load media reconciliation report", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetReconciliationReport)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reconciliation report retrieved", report))
+	}
+}
+
+// MediaCollector deletes a tenant's stale media uploads on demand
+type MediaCollector interface {
+	CollectGarbage(ctx context.Context, tenantID string, dryRun bool) media.ReconciliationReport
+}
+
+// CollectMedia reconciles the admin's tenant's media now, deleting stale uploads
+// @Summary Collect media garbage now
+// @ID collectMediaGarbage
+// @Description Reconcile your tenant's media bucket with its upload records now, settling uploads whose URL expired and deleting uploads left unconfirmed past the TTL along with their objects, whether or not the job is configured to delete them. With dry_run=true nothing is changed; the report counts what would be and lists up to 100 of the uploads that would be deleted. The report is not kept as the job's last one. Admins only.
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Only report what would be deleted"
+// @Success 200 {object} response.Response{data=media.ReconciliationReport} "Reconciliation report"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Security BearerAuth
+// @Router /admin/media/gc [post]
+func CollectMedia(collector MediaCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+		report := collector.CollectGarbage(r.Context(), tenant.FromContext(r.Context()), dryRun)
+
+		slog.Info("Media garbage collected",
+			slog.Bool("dry_run", dryRun),
+			slog.Int("deleted", report.DeletedCount),
+			slog.Int("errors", len(report.Errors)))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Media garbage collected", report))
+	}
+}
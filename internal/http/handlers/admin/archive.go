@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/archive"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// StoryRestorer brings archived stories back
+type StoryRestorer interface {
+	Restore(ctx context.Context, tenantID, storyID string) (types.Story, error)
+}
+
+// ArchiveSweeper archives a tenant's due stories on demand
+type ArchiveSweeper interface {
+	Sweep(ctx context.Context, tenantID string, dryRun bool) archive.Report
+}
+
+// SweepArchive runs the archive job for the admin's tenant now
+// @Summary Archive due stories now
+// @ID sweepArchive
+// @Description Run the archive job for your tenant now, moving stories deleted or expired longer ago than archive.after out of the database into the cold archive. With dry_run=true nothing is archived or deleted; the report counts the stories that would be, estimates the objects they would fill and lists the first 100 of their IDs. Admins only.
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Only report what would be archived"
+// @Success 200 {object} response.Response{data=archive.Report} "Archive sweep report"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Security BearerAuth
+// @Router /admin/archive/sweep [post]
+func SweepArchive(sweeper ArchiveSweeper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+		report := sweeper.Sweep(r.Context(), tenant.FromContext(r.Context()), dryRun)
+
+		slog.Info("Archive sweep run",
+			slog.Bool("dry_run", dryRun),
+			slog.Int("archived", report.Archived),
+			slog.Int("errors", len(report.Errors)))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Archive sweep complete", report))
+	}
+}
+
+// RestoreStory restores an archived story
+// @Summary Restore an archived story
+// @ID restoreArchivedStory
+// @Description Move a story of your tenant back from the cold archive into the database, under its old ID and deleted or expired as it was when archived, so it shows up in its author's history again. Its audience is restored without members who have since left; its views and reactions are not. Admins only.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response{data=types.Story} "Story restored"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 404 {object} response.Response "Story not archived in your tenant"
+// @Failure 409 {object} response.Response "The story's author no longer exists"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/stories/{id}/restore [post]
+func RestoreStory(restorer StoryRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		story, err := restorer.Restore(r.Context(), tenant.FromContext(r.Context()), storyID)
+		switch {
+		case errors.Is(err, archive.ErrNotArchived):
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotArchived)))
+			return
+		case errors.Is(err, storage.ErrUserNotFound):
+			response.WriteJSON(w, http.StatusConflict, response.GeneralError(i18n.Error(r.Context(), i18n.MsgArchivedStoryAuthorGone)))
+			return
+		case err != nil:
+			slog.Error("Failed to restore archived story", slog.String("error", err.Error()), slog.String("story_id", storyID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRestoreStory)))
+			return
+		}
+
+		slog.Info("Archived story restored", slog.String("story_id", storyID))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story restored", story))
+	}
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ClientStats returns how the tenant's active sessions split across client
+// platforms and versions
+// @Summary Get client platform and version stats
+// @ID getClientStats
+// @Description Count the active sessions of your tenant by the platform and client version they logged in with, and the distinct devices behind them, to see who still runs an old app before retiring behavior it depends on. Sessions from before clients were recorded are left out. Admins only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} response.Response{data=session.ClientBreakdown} "Client breakdown"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/stats/clients [get]
+func ClientStats(sessions *session.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		breakdown, err := sessions.ClientBreakdown(r.Context(), tenant.FromContext(r.Context()))
+		if err != nil {
+			slog.Error("Failed to get client stats", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetClientStats)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Client stats retrieved", breakdown))
+	}
+}
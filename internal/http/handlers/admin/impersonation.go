@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// defaultImpersonationTTL is how long an impersonation token lasts when the
+// request does not say
+const defaultImpersonationTTL = 15 * time.Minute
+
+// Page sizes of the impersonation audit trail
+const (
+	defaultImpersonationLimit = 50
+	maxImpersonationLimit     = 200
+)
+
+// ImpersonationStore is what Impersonate reads users from and audits to
+type ImpersonationStore interface {
+	storage.UserStore
+	storage.AuditStore
+}
+
+// Impersonate issues a token to act as a user
+// @Summary Impersonate a user
+// @ID impersonateUser
+// @Description Issue a short-lived token to act as a user in your tenant, to reproduce a problem they reported with their feed or what they can see. The token is read-only: it carries no scopes and only GET requests are served with it. Its act claim names you, responses to it carry an X-Impersonated-By header, and issuing it and every request made with it are recorded in the audit trail. Admins cannot be impersonated. Admins only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param request body users.ImpersonationRequest true "Reason and lifetime"
+// @Success 201 {object} response.Response{data=users.ImpersonationToken} "Impersonation token issued"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin, or the user is one"
+// @Failure 404 {object} response.Response "User not found in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/users/{user_id}/impersonate [post]
+func Impersonate(store ImpersonationStore, tokens jwt.Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		userID := r.PathValue("user_id")
+		if userID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[users.ImpersonationRequest](w, r)
+		if !ok {
+			return
+		}
+
+		target, err := store.GetUserByID(userID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && target.TenantID != tenant.FromContext(r.Context())) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get user to impersonate", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToImpersonate)))
+			return
+		}
+		if target.IsAdmin {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgCannotImpersonateAdmin)))
+			return
+		}
+
+		ttl := defaultImpersonationTTL
+		if req.TTLMinutes != 0 {
+			ttl = time.Duration(req.TTLMinutes) * time.Minute
+		}
+		token, claims, err := jwt.CreateImpersonationToken(userID, target.TenantID, adminID, ttl, tokens)
+		if err != nil {
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGenerateToken)))
+			return
+		}
+
+		// The token is only handed out once its issue is on record
+		err = store.RecordImpersonation(users.ImpersonationEvent{
+			TokenID: claims.TokenID,
+			AdminID: adminID,
+			UserID:  userID,
+			Action:  users.ImpersonationIssued,
+			Reason:  req.Reason,
+		})
+		if err != nil {
+			slog.Error("Failed to audit impersonation", slog.String("error", err.Error()), slog.String("admin_id", adminID), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToImpersonate)))
+			return
+		}
+
+		slog.Warn("Impersonation token issued",
+			slog.String("admin_id", adminID),
+			slog.String("user_id", userID),
+			slog.String("token_id", claims.TokenID),
+			slog.String("ttl", ttl.String()))
+
+		response.WriteJSON(w, http.StatusCreated, response.RequestOK("Impersonation token issued", users.ImpersonationToken{
+			Token:          token,
+			TokenType:      "Bearer",
+			ExpiresAt:      types.FormatTime(claims.ExpiresAt),
+			UserID:         userID,
+			ImpersonatorID: adminID,
+		}))
+	}
+}
+
+// Impersonations returns the impersonation audit trail
+// @Summary List impersonations
+// @ID listImpersonations
+// @Description Get the audit trail of admins impersonating users in your tenant, newest first: every impersonation token issued, with its reason, and every request made with one. Admins only.
+// @Tags admin
+// @Produce json
+// @Param user_id query string false "Only entries about this user"
+// @Param limit query int false "Entries to return, 1 to 200" default(50)
+// @Success 200 {object} response.Response{data=[]users.ImpersonationEvent} "Audit trail"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /admin/impersonations [get]
+func Impersonations(store storage.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limit := defaultImpersonationLimit
+		if param := r.URL.Query().Get("limit"); param != "" {
+			var err error
+			limit, err = strconv.Atoi(param)
+			if err != nil || limit < 1 || limit > maxImpersonationLimit {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidImpersonationLimit)))
+				return
+			}
+		}
+
+		events, err := store.GetImpersonationEvents(adminID, r.URL.Query().Get("user_id"), limit)
+		if err != nil {
+			slog.Error("Failed to get impersonation audit trail", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetImpersonations)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Impersonations retrieved", events))
+	}
+}
@@ -1,19 +1,28 @@
 package media
 
 import (
-	"encoding/json"
+	"database/sql"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
 	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 type MediaHandlers struct {
 	mediaService *mediaService.Service
+	store        storage.MediaStore
+	queue        *jobs.Queue
 }
 
 type UploadURLRequest struct {
@@ -28,6 +37,12 @@ type UploadURLResponse struct {
 	ContentType string `json:"content_type"`
 }
 
+type DownloadURLResponse struct {
+	ObjectKey   string `json:"object_key"`
+	DownloadURL string `json:"download_url"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
 type MediaInfoResponse struct {
 	ObjectKey   string    `json:"object_key"`
 	Size        int64     `json:"size"`
@@ -36,21 +51,48 @@ type MediaInfoResponse struct {
 	MediaURL    string    `json:"media_url"`
 }
 
-// NewMediaHandlers creates a new media handlers instance
-func NewMediaHandlers(mediaService *mediaService.Service) *MediaHandlers {
+// UploadStatusResponse is the state of an upload, for clients resuming
+// uploads after a restart
+type UploadStatusResponse struct {
+	media.MediaUpload
+	UploadURLExpiresAt int64 `json:"upload_url_expires_at"` // an initiated upload past this needs a new upload URL
+}
+
+type MediaListResponse struct {
+	Items                 []MediaInfoResponse `json:"items"`
+	NextContinuationToken string              `json:"next_continuation_token,omitempty"` // pass as continuation_token for the next page; absent on the last
+}
+
+// NewMediaHandlers creates a new media handlers instance, recording uploads
+// in store and queueing object deletions on queue
+func NewMediaHandlers(mediaService *mediaService.Service, store storage.MediaStore, queue *jobs.Queue) *MediaHandlers {
 	return &MediaHandlers{
 		mediaService: mediaService,
+		store:        store,
+		queue:        queue,
 	}
 }
 
+// tenantService returns the media service for the request's tenant, writing
+// an error response if its bucket cannot be used
+func (h *MediaHandlers) tenantService(w http.ResponseWriter, r *http.Request) (*mediaService.Service, bool) {
+	service, err := h.mediaService.ForTenant(tenant.FromContext(r.Context()))
+	if err != nil {
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		return nil, false
+	}
+	return service, true
+}
+
 // GenerateUploadURL generates a presigned URL for media upload
 // @Summary Generate presigned upload URL
+// @ID createUploadURL
 // @Description Generate a presigned URL for uploading media files
 // @Tags media
 // @Accept json
 // @Produce json
 // @Param request body UploadURLRequest true "Upload URL request"
-// @Success 200 {object} UploadURLResponse "Upload URL generated successfully"
+// @Success 200 {object} response.Response{data=UploadURLResponse} "Upload URL generated successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 500 {object} response.Response "Internal server error"
@@ -61,23 +103,35 @@ func (h *MediaHandlers) GenerateUploadURL() http.HandlerFunc {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
 			return
 		}
 
-		var req UploadURLRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid request body")))
+		req, ok := request.DecodeJSON[UploadURLRequest](w, r)
+		if !ok {
 			return
 		}
 
 		// Generate presigned upload URL
-		uploadInfo, err := h.mediaService.GeneratePresignedUploadURL(userID, req.ContentType)
+		uploadInfo, err := service.GeneratePresignedUploadURL(userID, req.ContentType)
 		if err != nil {
 			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
+		// Record the upload so the reconciliation job can tell it from an
+		// orphaned object
+		if err := h.store.CreateMediaUpload(userID, uploadInfo.ObjectKey, uploadInfo.ContentType); err != nil {
+			slog.Error("Failed to record media upload", slog.String("error", err.Error()), slog.String("object_key", uploadInfo.ObjectKey))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRecordUpload)))
+			return
+		}
+
 		resp := UploadURLResponse{
 			ObjectKey:   uploadInfo.ObjectKey,
 			UploadURL:   uploadInfo.UploadURL,
@@ -90,13 +144,183 @@ func (h *MediaHandlers) GenerateUploadURL() http.HandlerFunc {
 	}
 }
 
+// ConfirmUpload confirms that the client finished uploading a media file
+// @Summary Confirm a media upload
+// @ID confirmUpload
+// @Description Confirm that the file for an object key returned by /media/upload-url has been uploaded. Unconfirmed uploads may be deleted by the media reconciliation job once they are old enough.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param request body media.ConfirmUploadRequest true "Uploaded object"
+// @Success 200 {object} response.Response{data=media.MediaUpload} "Upload confirmed"
+// @Failure 400 {object} response.Response "Bad request, or the file is larger than allowed"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "No such upload, or the file has not been uploaded"
+// @Failure 409 {object} response.Response "The upload failed; request a new upload URL"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /media/confirm [post]
+func (h *MediaHandlers) ConfirmUpload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
+			return
+		}
+
+		req, ok := request.DecodeJSON[media.ConfirmUploadRequest](w, r)
+		if !ok {
+			return
+		}
+
+		upload, ok := h.upload(w, r, userID, req.ObjectKey)
+		if !ok {
+			return
+		}
+		if upload.Status == media.UploadFailed {
+			response.WriteJSON(w, http.StatusConflict, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUploadFailed)))
+			return
+		}
+
+		// The object must actually be in the bucket
+		objInfo, err := service.GetObjectInfo(req.ObjectKey)
+		if err != nil {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotFound)))
+			return
+		}
+
+		// Presigned uploads cannot limit their size, so check it here
+		if objInfo.Size > service.MaxFileSize() {
+			if _, err := h.store.SetMediaUploadStatus(req.ObjectKey, media.UploadFailed, objInfo.Size); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				slog.Error("Failed to record failed media upload", slog.String("error", err.Error()), slog.String("object_key", req.ObjectKey))
+			}
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUploadTooLarge)))
+			return
+		}
+
+		upload, err = h.store.ConfirmMediaUpload(userID, req.ObjectKey, objInfo.Size)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotFound)))
+				return
+			}
+			slog.Error("Failed to confirm media upload", slog.String("error", err.Error()), slog.String("object_key", req.ObjectKey))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToConfirmUpload)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Upload confirmed", upload))
+	}
+}
+
+// GetUploadStatus reports the state of an upload
+// @Summary Get upload status
+// @ID getUploadStatus
+// @Description Get the state of an upload started with /media/upload-url: initiated until the file is found in the bucket, then uploaded, and confirmed once /media/confirm is called. Uploads whose URL expired without a file, and files larger than allowed, are failed and need a new upload URL.
+// @Tags media
+// @Produce json
+// @Param object_key path string true "Object key"
+// @Success 200 {object} response.Response{data=UploadStatusResponse} "Upload status"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "No such upload"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /media/{object_key}/status [get]
+func (h *MediaHandlers) GetUploadStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
+			return
+		}
+
+		objectKey := r.PathValue("object_key")
+		upload, ok := h.upload(w, r, userID, objectKey)
+		if !ok {
+			return
+		}
+
+		upload, err := h.refreshStatus(service, upload)
+		if err != nil {
+			slog.Error("Failed to refresh upload status", slog.String("error", err.Error()), slog.String("object_key", objectKey))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetUploadStatus)))
+			return
+		}
+
+		resp := UploadStatusResponse{
+			MediaUpload:        upload,
+			UploadURLExpiresAt: upload.CreatedAt.Add(service.UploadURLTTL()).Unix(),
+		}
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Upload status retrieved", resp))
+	}
+}
+
+// upload returns the user's upload of objectKey, writing an error response if
+// there is none
+func (h *MediaHandlers) upload(w http.ResponseWriter, r *http.Request, userID, objectKey string) (media.MediaUpload, bool) {
+	upload, err := h.store.GetMediaUpload(userID, objectKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotFound)))
+			return upload, false
+		}
+		slog.Error("Failed to get media upload", slog.String("error", err.Error()), slog.String("object_key", objectKey))
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetUploadStatus)))
+		return upload, false
+	}
+	return upload, true
+}
+
+// refreshStatus looks for the file of an upload that is neither confirmed nor
+// failed and records what it finds: uploaded once the file is there, failed if
+// the file is too large or the upload URL expired without one
+func (h *MediaHandlers) refreshStatus(service *mediaService.Service, upload media.MediaUpload) (media.MediaUpload, error) {
+	if upload.Status != media.UploadInitiated && upload.Status != media.UploadUploaded {
+		return upload, nil
+	}
+
+	status, size := upload.Status, upload.Size
+	objInfo, err := service.GetObjectInfo(upload.ObjectKey)
+	switch {
+	case err != nil && !mediaService.IsNotFound(err):
+		return upload, err
+	case err == nil && objInfo.Size > service.MaxFileSize():
+		status, size = media.UploadFailed, objInfo.Size
+	case err == nil:
+		status, size = media.UploadUploaded, objInfo.Size
+	case time.Since(upload.CreatedAt) > service.UploadURLTTL():
+		status = media.UploadFailed
+	}
+	if status == upload.Status && size == upload.Size {
+		return upload, nil
+	}
+
+	updated, err := h.store.SetMediaUploadStatus(upload.ObjectKey, status, size)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Confirmed or failed in the meantime
+		return h.store.GetMediaUpload(upload.UserID, upload.ObjectKey)
+	}
+	return updated, err
+}
+
 // GetMediaInfo retrieves information about a media file
 // @Summary Get media file information
+// @ID getMediaInfo
 // @Description Get information about a specific media file
 // @Tags media
 // @Produce json
 // @Param object_key path string true "Object key"
-// @Success 200 {object} MediaInfoResponse "Media information retrieved successfully"
+// @Success 200 {object} response.Response{data=MediaInfoResponse} "Media information retrieved successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 404 {object} response.Response "Media not found"
@@ -108,14 +332,19 @@ func (h *MediaHandlers) GetMediaInfo() http.HandlerFunc {
 		// Extract user ID from context
 		_, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
 			return
 		}
 
 		// Get object key from URL path
 		objectKey := r.URL.Path[len("/media/"):]
 		if objectKey == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("object key is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgObjectKeyRequired)))
 			return
 		}
 
@@ -125,14 +354,14 @@ func (h *MediaHandlers) GetMediaInfo() http.HandlerFunc {
 		}
 
 		// Get object information
-		objInfo, err := h.mediaService.GetObjectInfo(objectKey)
+		objInfo, err := service.GetObjectInfo(objectKey)
 		if err != nil {
-			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("media not found")))
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotFound)))
 			return
 		}
 
 		// Generate media URL
-		mediaURL := h.mediaService.GetMediaURL(objectKey)
+		mediaURL := service.GetMediaURL(objectKey)
 
 		resp := MediaInfoResponse{
 			ObjectKey:   objectKey,
@@ -148,12 +377,13 @@ func (h *MediaHandlers) GetMediaInfo() http.HandlerFunc {
 
 // GenerateDownloadURL generates a presigned URL for media download
 // @Summary Generate presigned download URL
+// @ID getDownloadURL
 // @Description Generate a presigned URL for downloading media files
 // @Tags media
 // @Produce json
 // @Param object_key path string true "Object key"
 // @Param expires query int false "Expiration time in seconds (default: 3600)"
-// @Success 200 {object} map[string]interface{} "Download URL generated successfully"
+// @Success 200 {object} response.Response{data=DownloadURLResponse} "Download URL generated successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 404 {object} response.Response "Media not found"
@@ -165,14 +395,19 @@ func (h *MediaHandlers) GenerateDownloadURL() http.HandlerFunc {
 		// Extract user ID from context
 		_, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
 			return
 		}
 
 		// Get object key from URL path
 		objectKey := r.URL.Path[len("/media/"):]
 		if objectKey == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("object key is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgObjectKeyRequired)))
 			return
 		}
 
@@ -191,28 +426,32 @@ func (h *MediaHandlers) GenerateDownloadURL() http.HandlerFunc {
 		}
 
 		// Generate presigned download URL
-		downloadURL, err := h.mediaService.GeneratePresignedDownloadURL(objectKey, time.Duration(expires)*time.Second)
+		downloadURL, err := service.GeneratePresignedDownloadURL(objectKey, time.Duration(expires)*time.Second)
 		if err != nil {
-			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("failed to generate download URL")))
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGenerateDownload)))
 			return
 		}
 
-		resp := map[string]interface{}{
-			"object_key":   objectKey,
-			"download_url": downloadURL.String(),
-			"expires_at":   time.Now().Add(time.Duration(expires) * time.Second).Unix(),
+		resp := DownloadURLResponse{
+			ObjectKey:   objectKey,
+			DownloadURL: downloadURL.String(),
+			ExpiresAt:   time.Now().Add(time.Duration(expires) * time.Second).Unix(),
 		}
 
 		response.WriteJSON(w, http.StatusOK, response.RequestOK("Download URL generated successfully", resp))
 	}
 }
 
-// ListUserMedia lists all media files for the authenticated user
+// ListUserMedia lists the authenticated user's media files a page at a time
 // @Summary List user media files
-// @Description List all media files uploaded by the authenticated user
+// @ID listMedia
+// @Description List the media files uploaded by the authenticated user in the tenant, in object key order, a page at a time. Files are listed from the upload records, so uploads whose file has not been seen yet or that failed are left out. Pass next_continuation_token from a response as continuation_token to get the following page.
 // @Tags media
 // @Produce json
-// @Success 200 {array} MediaInfoResponse "Media files retrieved successfully"
+// @Param limit query int false "Page size, 1 to 1000" default(50)
+// @Param continuation_token query string false "Token from the previous page"
+// @Success 200 {object} response.Response{data=MediaListResponse} "Media files retrieved successfully"
+// @Failure 400 {object} response.Response "Invalid limit or continuation token"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
@@ -222,26 +461,52 @@ func (h *MediaHandlers) ListUserMedia() http.HandlerFunc {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
-		// List user media files
-		objects, err := h.mediaService.ListUserMedia(userID)
+		limit := mediaService.DefaultListLimit
+		if param := r.URL.Query().Get("limit"); param != "" {
+			var err error
+			limit, err = strconv.Atoi(param)
+			if err != nil || limit < 1 || limit > mediaService.MaxListLimit {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidMediaListLimit)))
+				return
+			}
+		}
+
+		service, ok := h.tenantService(w, r)
+		if !ok {
+			return
+		}
+
+		// List a page of the user's media files
+		page, err := mediaService.ListUserMedia(r.Context(), h.store, tenant.FromContext(r.Context()), userID, r.URL.Query().Get("continuation_token"), limit)
 		if err != nil {
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to list media files")))
+			if errors.Is(err, mediaService.ErrInvalidContinuationToken) {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidContinuationToken)))
+				return
+			}
+			slog.Error("Failed to list media", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToListMedia)))
 			return
 		}
 
-		var mediaFiles []MediaInfoResponse
-		for _, obj := range objects {
-			mediaURL := h.mediaService.GetMediaURL(obj.Key)
-			mediaFiles = append(mediaFiles, MediaInfoResponse{
-				ObjectKey:   obj.Key,
-				Size:        obj.Size,
-				ContentType: obj.ContentType,
-				UploadedAt:  obj.LastModified,
-				MediaURL:    mediaURL,
+		mediaFiles := MediaListResponse{
+			Items:                 []MediaInfoResponse{},
+			NextContinuationToken: page.NextContinuationToken,
+		}
+		for _, upload := range page.Uploads {
+			uploadedAt := upload.CreatedAt
+			if upload.ConfirmedAt != nil {
+				uploadedAt = *upload.ConfirmedAt
+			}
+			mediaFiles.Items = append(mediaFiles.Items, MediaInfoResponse{
+				ObjectKey:   upload.ObjectKey,
+				Size:        upload.Size,
+				ContentType: upload.ContentType,
+				UploadedAt:  uploadedAt,
+				MediaURL:    service.GetMediaURL(upload.ObjectKey),
 			})
 		}
 
@@ -251,7 +516,8 @@ func (h *MediaHandlers) ListUserMedia() http.HandlerFunc {
 
 // DeleteMedia deletes a media file
 // @Summary Delete media file
-// @Description Delete a specific media file
+// @ID deleteMedia
+// @Description Delete a specific media file. The file is removed from storage by a background job shortly after.
 // @Tags media
 // @Param object_key path string true "Object key"
 // @Success 200 {object} response.Response "Media file deleted successfully"
@@ -266,31 +532,41 @@ func (h *MediaHandlers) DeleteMedia() http.HandlerFunc {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		// Get object key from URL path
 		objectKey := r.URL.Path[len("/media/"):]
 		if objectKey == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("object key is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgObjectKeyRequired)))
 			return
 		}
 
 		// Verify that the object belongs to the user (basic security check)
 		expectedPrefix := "users/" + userID + "/media/"
 		if len(objectKey) < len(expectedPrefix) || objectKey[:len(expectedPrefix)] != expectedPrefix {
-			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(errors.New("access denied")))
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAccessDenied)))
 			return
 		}
 
-		// Delete the object
-		err := h.mediaService.DeleteObject(objectKey)
+		// Delete the object in the background, so a storage outage delays
+		// the deletion rather than failing it
+		_, err := h.queue.Enqueue(r.Context(), mediaService.JobDelete, mediaService.DeletePayload{
+			TenantID:  tenant.FromContext(r.Context()),
+			ObjectKey: objectKey,
+		})
 		if err != nil {
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to delete media file")))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToDeleteMedia)))
 			return
 		}
 
+		// The object goes either way; a leftover record is reported by
+		// the reconciliation job
+		if err := h.store.DeleteMediaUpload(objectKey); err != nil {
+			slog.Warn("Failed to delete media upload record", slog.String("error", err.Error()), slog.String("object_key", objectKey))
+		}
+
 		response.WriteJSON(w, http.StatusOK, response.RequestOK("Media file deleted successfully", nil))
 	}
 }
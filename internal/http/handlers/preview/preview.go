@@ -0,0 +1,223 @@
+package preview
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/preview"
+	"github.com/princekumarofficial/stories-service/internal/sharelink"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// errNotEmbeddable is returned when an oEmbed URL does not name a public or
+// shared story of this service
+var errNotEmbeddable = errors.New("not an embeddable story URL")
+
+// errLoginRequired is returned when embedding a share link that requires sign-in
+var errLoginRequired = errors.New("share link requires sign-in")
+
+// PublicStory handles the preview page of a public story
+// @Summary Preview a public story
+// @ID getStoryPreview
+// @Description HTML page with OpenGraph and Twitter card tags describing a public story, its author and media, so links to it unfurl on other platforms. Other stories are reported as not found.
+// @Tags preview
+// @Produce html
+// @Param id path string true "Story ID"
+// @Success 200 {string} string "Preview page"
+// @Failure 404 {string} string "Story not found"
+// @Router /stories/{id}/preview [get]
+func PublicStory(store storage.Storage, builder *preview.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meta, err := publicStory(store, builder, r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				render(w, http.StatusNotFound, builder.Placeholder(i18n.T(r.Context(), i18n.MsgStoryNotFound)))
+				return
+			}
+			slog.Error("Failed to build story preview", slog.String("error", err.Error()))
+			render(w, http.StatusInternalServerError, builder.Placeholder(http.StatusText(http.StatusInternalServerError)))
+			return
+		}
+
+		render(w, http.StatusOK, meta)
+	}
+}
+
+// SharedStory handles the preview page behind a share link. Opening it counts
+// as a view of the link, like the JSON response does.
+func SharedStory(store storage.Storage, signer *sharelink.Signer, builder *preview.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.PathValue("token")
+
+		link, meta, err := sharedStory(store, signer, builder, token)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				render(w, http.StatusNotFound, builder.Placeholder(i18n.T(r.Context(), i18n.MsgShareLinkNotFound)))
+				return
+			}
+			slog.Error("Failed to build shared story preview", slog.String("error", err.Error()))
+			render(w, http.StatusInternalServerError, builder.Placeholder(http.StatusText(http.StatusInternalServerError)))
+			return
+		}
+
+		// Unfurlers are never signed in, so links that require it only
+		// reveal that there is something to sign in for
+		if _, signedIn := middleware.GetUserIDFromContext(r.Context()); link.RequireLogin && !signedIn {
+			render(w, http.StatusUnauthorized, builder.Placeholder(i18n.T(r.Context(), i18n.MsgShareLinkLoginRequired)))
+			return
+		}
+
+		if err := store.RecordShareLinkView(link.ID); err != nil {
+			slog.Error("Failed to record share link view", slog.String("error", err.Error()), slog.String("link_id", link.ID))
+		}
+
+		render(w, http.StatusOK, meta)
+	}
+}
+
+// OEmbed handles oEmbed discovery for story links
+// @Summary oEmbed for a story link
+// @ID getOEmbed
+// @Description oEmbed 1.0 link response for the preview page of a public story or a share link, with the author name and media thumbnail. Only the json format is supported.
+// @Tags preview
+// @Produce json
+// @Param url query string true "Story preview or share link URL"
+// @Param format query string false "Response format (json)"
+// @Success 200 {object} preview.OEmbed "oEmbed response"
+// @Failure 401 {object} response.Response "The share link requires sign-in"
+// @Failure 404 {object} response.Response "Not a public or shared story"
+// @Failure 501 {object} response.Response "Unsupported format"
+// @Router /oembed [get]
+func OEmbed(store storage.Storage, signer *sharelink.Signer, builder *preview.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != "json" {
+			response.WriteJSON(w, http.StatusNotImplemented, response.GeneralError(i18n.Error(r.Context(), i18n.MsgOEmbedFormatUnsupported)))
+			return
+		}
+
+		meta, err := embeddedStory(store, signer, builder, query.Get("url"))
+		switch {
+		case errors.Is(err, errNotEmbeddable), errors.Is(err, sql.ErrNoRows):
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgOEmbedURLInvalid)))
+			return
+		case errors.Is(err, errLoginRequired):
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgShareLinkLoginRequired)))
+			return
+		case err != nil:
+			slog.Error("Failed to build oEmbed response", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, builder.OEmbed(meta))
+	}
+}
+
+// HTMLOr serves page to clients that ask for HTML, such as browsers and link
+// unfurlers, and api to everyone else
+func HTMLOr(page, api http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if acceptsHTML(r.Header.Get("Accept")) {
+			page.ServeHTTP(w, r)
+			return
+		}
+		api.ServeHTTP(w, r)
+	})
+}
+
+// embeddedStory describes the story an oEmbed URL links to. The URL must be on
+// this service's public host.
+func embeddedStory(store storage.Storage, signer *sharelink.Signer, builder *preview.Builder, rawURL string) (preview.Meta, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return preview.Meta{}, errNotEmbeddable
+	}
+	if public, err := url.Parse(builder.PublicURL()); err != nil || !strings.EqualFold(target.Host, public.Host) {
+		return preview.Meta{}, errNotEmbeddable
+	}
+
+	if token, ok := strings.CutPrefix(target.Path, "/shared/"); ok && token != "" && !strings.Contains(token, "/") {
+		link, meta, err := sharedStory(store, signer, builder, token)
+		if err != nil {
+			return preview.Meta{}, err
+		}
+		if link.RequireLogin {
+			return preview.Meta{}, errLoginRequired
+		}
+		return meta, nil
+	}
+
+	if rest, ok := strings.CutPrefix(target.Path, "/stories/"); ok {
+		if storyID, ok := strings.CutSuffix(rest, "/preview"); ok && storyID != "" && !strings.Contains(storyID, "/") {
+			return publicStory(store, builder, storyID)
+		}
+	}
+
+	return preview.Meta{}, errNotEmbeddable
+}
+
+// publicStory describes a public story, or returns sql.ErrNoRows for any other
+// and for stories limiting how often each viewer may open them
+func publicStory(store storage.Storage, builder *preview.Builder, storyID string) (preview.Meta, error) {
+	story, err := store.GetStoryByID(storyID)
+	if err != nil {
+		return preview.Meta{}, err
+	}
+	if story.Visibility != types.VisibilityPublic || story.MaxViewsPerUser > 0 {
+		return preview.Meta{}, sql.ErrNoRows
+	}
+
+	author, err := store.GetUserByID(story.AuthorID)
+	if err != nil {
+		return preview.Meta{}, err
+	}
+
+	return builder.Story(story, author, preview.StoryPath(storyID)), nil
+}
+
+// sharedStory describes the story behind a share link token, returning
+// sql.ErrNoRows for forged, revoked and expired links
+func sharedStory(store storage.Storage, signer *sharelink.Signer, builder *preview.Builder, token string) (types.ShareLink, preview.Meta, error) {
+	link, story, err := sharelink.Open(store, signer, token)
+	if errors.Is(err, sharelink.ErrInvalidToken) {
+		return types.ShareLink{}, preview.Meta{}, sql.ErrNoRows
+	} else if err != nil {
+		return types.ShareLink{}, preview.Meta{}, err
+	}
+
+	author, err := store.GetUserByID(story.AuthorID)
+	if err != nil {
+		return types.ShareLink{}, preview.Meta{}, err
+	}
+
+	return link, builder.Story(story, author, "/shared/"+token), nil
+}
+
+// acceptsHTML reports whether an Accept header lists HTML
+func acceptsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+			return true
+		}
+	}
+	return false
+}
+
+func render(w http.ResponseWriter, status int, meta preview.Meta) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := preview.Render(w, meta); err != nil {
+		slog.Error("Failed to render story preview", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,141 @@
+package stories
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/services/links"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// storyETag is the entity tag of a story's current version, as sent in the
+// ETag header and expected back in If-Match
+func storyETag(story types.Story) string {
+	return strconv.Quote(strconv.Itoa(story.Version))
+}
+
+// editedVersion returns the story version a client is editing, from If-Match
+// or else the request body. A missing version is answered with 428 and a
+// malformed If-Match with 400; on failure it writes the error response and
+// returns false.
+func editedVersion(w http.ResponseWriter, r *http.Request, bodyVersion int) (int, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		if bodyVersion == 0 {
+			response.WriteJSON(w, http.StatusPreconditionRequired, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryVersionRequired)))
+			return 0, false
+		}
+		return bodyVersion, true
+	}
+
+	tag, err := strconv.Unquote(ifMatch)
+	if err == nil {
+		if version, err := strconv.Atoi(tag); err == nil && version > 0 {
+			return version, true
+		}
+	}
+
+	response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidIfMatch)))
+	return 0, false
+}
+
+// UpdateStory handles editing a story
+// @Summary Edit a story
+// @ID updateStory
+// @Description Edit the text, link or audience of one of your active stories. Send the version you are editing, from the story's ETag header or version field, in If-Match (or as version in the body); if the story was edited since, nothing changes and 409 is returned with the current version in ETag, so edits from two devices never silently overwrite each other. Fields left out keep their value, and a new visibility replaces the audience and group, so send audience_user_ids or group_id with it as when posting. Users a new visibility shuts out are sent story.revoked if connected, and the story leaves every cached feed at once. Encrypted stories cannot be edited.
+// @Tags stories
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param If-Match header string false "Version being edited, such as \"3\""
+// @Param story body types.StoryUpdateRequest true "Fields to change"
+// @Success 200 {object} response.Response{data=types.Story} "Story updated successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story author, or not a member of the group"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 409 {object} response.Response "Story edited since that version"
+// @Failure 428 {object} response.Response "No version sent"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id} [patch]
+func UpdateStory(store storage.StoryStore, linkValidator *links.Validator, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		story, ok := authoredStory(w, r, store, i18n.MsgOnlyAuthorEdit)
+		if !ok {
+			return
+		}
+
+		update, ok := request.DecodeJSON[types.StoryUpdateRequest](w, r)
+		if !ok {
+			return
+		}
+
+		version, ok := editedVersion(w, r, update.Version)
+		if !ok {
+			return
+		}
+
+		if story.Encrypted {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgEncryptedStoryNotEditable)))
+			return
+		}
+
+		// The audience and group only change along with the visibility
+		if update.Visibility == "" {
+			if len(update.AudienceUserIDs) > 0 || update.GroupID != "" {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAudienceRequiresVisibility)))
+				return
+			}
+		} else if !checkGroup(w, r, update.Visibility, update.GroupID) {
+			return
+		}
+
+		if update.LinkURL != nil && *update.LinkURL != "" {
+			if err := linkValidator.Validate(*update.LinkURL); err != nil {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+		}
+
+		updated, revoked, err := store.UpdateStory(story.ID, version, update)
+		switch {
+		case errors.Is(err, storage.ErrVersionConflict):
+			w.Header().Set("ETag", storyETag(updated))
+			response.WriteJSON(w, http.StatusConflict, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryVersionConflict)))
+			return
+		case errors.Is(err, sql.ErrNoRows):
+			// Deleted or expired since it was read
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
+			return
+		case errors.Is(err, storage.ErrNotGroupMember):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNotGroupMember)))
+			return
+		case err != nil:
+			slog.Error("Failed to update story", slog.String("error", err.Error()), slog.String("story_id", story.ID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateStory)))
+			return
+		}
+
+		// Tell clients that may be showing the story to users it no longer
+		// reaches to drop it (fire and forget)
+		if len(revoked) > 0 {
+			go func() {
+				if err := eventPublisher.PublishStoryRevoked(updated, revoked); err != nil {
+					slog.Error("Failed to publish story revoked event", slog.String("error", err.Error()), slog.String("story_id", updated.ID))
+				}
+			}()
+		}
+
+		w.Header().Set("ETag", storyETag(updated))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story updated successfully", updated))
+	}
+}
@@ -0,0 +1,95 @@
+package stories
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// checkEncryption rejects an encrypted story that is not PRIVATE, carries
+// plaintext or a location, or does not wrap its key for exactly the author and
+// audience, writing a 400 response and returning false. Unencrypted stories
+// pass.
+func checkEncryption(w http.ResponseWriter, r *http.Request, authorID string, story types.StoryPostRequest) bool {
+	if story.Encrypted == nil {
+		return true
+	}
+
+	var msg i18n.MessageKey
+	switch {
+	case story.Visibility != types.VisibilityPrivate:
+		msg = i18n.MsgEncryptionRequiresPrivate
+	case story.Text != "" || story.LinkURL != "":
+		msg = i18n.MsgEncryptedStoryPlaintext
+	case story.PlaceName != "" || story.Latitude != nil || story.Longitude != nil:
+		msg = i18n.MsgEncryptedStoryLocation
+	case !story.Encrypted.CoversExactly(authorID, story.AudienceUserIDs):
+		msg = i18n.MsgRecipientKeysMismatch
+	default:
+		return true
+	}
+
+	response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), msg)))
+	return false
+}
+
+// StoryEnvelope returns an encrypted story's content for the caller
+// @Summary Get an encrypted story's envelope
+// @ID getStoryEnvelope
+// @Description Get the ciphertext of an end-to-end encrypted story with its content key wrapped for you. Unwrap the key with your private key, then decrypt the text and the story's media with it.
+// @Tags stories
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response{data=types.StoryEnvelope} "Story envelope"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Story not found, not encrypted or without a key for you"
+// @Failure 410 {object} response.Response "Story viewed as many times as it allows"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/envelope [get]
+func StoryEnvelope(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		story, ok := visibleStory(w, r, store, storyID, userID)
+		if !ok {
+			return
+		}
+		if story.Consumed {
+			response.WriteJSON(w, http.StatusGone, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryConsumed)))
+			return
+		}
+
+		envelope, err := store.GetStoryEnvelope(storyID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgEnvelopeNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get story envelope", slog.String("error", err.Error()), slog.String("story_id", storyID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetEnvelope)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Story envelope retrieved successfully", envelope))
+	}
+}
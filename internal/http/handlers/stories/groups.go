@@ -0,0 +1,311 @@
+package stories
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// checkGroup rejects a story whose group_id does not match its visibility:
+// GROUP stories must name a group and other stories must not. It writes a
+// 400 response and returns false.
+func checkGroup(w http.ResponseWriter, r *http.Request, visibility types.Visibility, groupID string) bool {
+	if (visibility == types.VisibilityGroup) == (groupID != "") {
+		return true
+	}
+
+	response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupIDMismatch)))
+	return false
+}
+
+// CreateGroup handles creating a story group
+// @Summary Create a story group
+// @ID createGroup
+// @Description Create a group you own, with the users in member_ids as its first members. Members post GROUP stories into it with its ID as group_id, and only members see them.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body types.CreateGroupRequest true "Group name and members"
+// @Success 201 {object} response.Response{data=types.StoryGroup} "Group created successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups [post]
+func CreateGroup(store storage.GroupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.CreateGroupRequest](w, r)
+		if !ok {
+			return
+		}
+
+		group, err := store.CreateGroup(userID, req.Name, req.MemberIDs)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to create group", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToCreateGroup)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusCreated, response.OK("Group created successfully", group))
+	}
+}
+
+// ListGroups handles listing the caller's story groups
+// @Summary List your story groups
+// @ID listGroups
+// @Description List the groups you are a member of, newest first.
+// @Tags groups
+// @Produce json
+// @Success 200 {object} response.Response{data=[]types.StoryGroup} "Groups fetched successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups [get]
+func ListGroups(store storage.GroupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		groups, err := store.GetGroups(userID)
+		if err != nil {
+			slog.Error("Failed to get groups", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetGroups)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Groups fetched successfully", groups))
+	}
+}
+
+// GroupMembers handles listing a story group's members
+// @Summary List a group's members
+// @ID listGroupMembers
+// @Description List the members of a group you belong to, earliest to join first.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} response.Response{data=[]types.GroupMember} "Group members fetched successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Group not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups/{id}/members [get]
+func GroupMembers(store storage.GroupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		groupID := r.PathValue("id")
+		if groupID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupIDRequired)))
+			return
+		}
+
+		// Only members may see who else is in a group
+		_, err := store.GetGroup(groupID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get group", slog.String("error", err.Error()), slog.String("group_id", groupID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetGroups)))
+			return
+		}
+
+		members, err := store.GetGroupMembers(groupID)
+		if err != nil {
+			slog.Error("Failed to get group members", slog.String("error", err.Error()), slog.String("group_id", groupID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetGroups)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Group members fetched successfully", members))
+	}
+}
+
+// AddGroupMembers handles adding users to a story group
+// @Summary Add members to a group
+// @ID addGroupMembers
+// @Description Add users in your tenant to a group you own. They see its stories from then on, including those posted before they joined; users already in it are skipped.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param members body types.GroupMembersRequest true "Users to add"
+// @Success 200 {object} response.Response "Group members added successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not the group owner"
+// @Failure 404 {object} response.Response "Group or user not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups/{id}/members [post]
+func AddGroupMembers(store storage.GroupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		groupID := r.PathValue("id")
+		if groupID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupIDRequired)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.GroupMembersRequest](w, r)
+		if !ok {
+			return
+		}
+
+		err := store.AddGroupMembers(groupID, userID, req.UserIDs)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupNotFound)))
+			return
+		case errors.Is(err, storage.ErrNotGroupOwner):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNotGroupOwner)))
+			return
+		case errors.Is(err, storage.ErrUserNotFound):
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		case err != nil:
+			slog.Error("Failed to add group members", slog.String("error", err.Error()), slog.String("group_id", groupID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateGroupMembers)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Group members added successfully", nil))
+	}
+}
+
+// RemoveGroupMember handles removing a user from a story group
+// @Summary Remove a member from a group
+// @ID removeGroupMember
+// @Description Leave a group by removing yourself, or, as its owner, remove another member. The owner cannot leave. Stories a removed member posted stay in the group.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} response.Response "Group member removed successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not the group owner, or the owner leaving"
+// @Failure 404 {object} response.Response "Group or member not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups/{id}/members/{user_id} [delete]
+func RemoveGroupMember(store storage.GroupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		groupID := r.PathValue("id")
+		memberID := r.PathValue("user_id")
+		if groupID == "" || memberID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupIDRequired)))
+			return
+		}
+
+		err := store.RemoveGroupMember(groupID, userID, memberID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows) && memberID == userID:
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupNotFound)))
+			return
+		case errors.Is(err, sql.ErrNoRows):
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupMemberNotFound)))
+			return
+		case errors.Is(err, storage.ErrNotGroupOwner):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNotGroupOwner)))
+			return
+		case errors.Is(err, storage.ErrGroupOwnerLeaving):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupOwnerCannotLeave)))
+			return
+		case err != nil:
+			slog.Error("Failed to remove group member", slog.String("error", err.Error()), slog.String("group_id", groupID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateGroupMembers)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Group member removed successfully", nil))
+	}
+}
+
+// GroupStories handles a story group's feed
+// @Summary Get a group's stories
+// @ID getGroupStories
+// @Description Get the active stories posted into a group you belong to, newest first. With media_urls=true each story with media carries a presigned media_url, as in the feed.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param media_urls query bool false "Include presigned media URLs"
+// @Success 200 {object} response.Response{data=[]types.Story} "Group stories fetched successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Group not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /groups/{id}/stories [get]
+func GroupStories(store storage.GroupStore, mediaURLs MediaURLResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		groupID := r.PathValue("id")
+		if groupID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupIDRequired)))
+			return
+		}
+
+		stories, err := store.GetGroupStories(groupID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgGroupNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get group stories", slog.String("error", err.Error()), slog.String("group_id", groupID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetGroupStories)))
+			return
+		}
+
+		if wantsMediaURLs(r) {
+			attachMediaURLs(r, mediaURLs, stories, func(s *types.Story) *types.Story { return s })
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Group stories fetched successfully", stories))
+	}
+}
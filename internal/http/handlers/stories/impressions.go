@@ -0,0 +1,52 @@
+package stories
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/impressions"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// RecordImpressions buffers that stories appeared in the caller's tray
+// @Summary Record story impressions
+// @ID recordImpressions
+// @Description Record that stories appeared in your tray, whether or not you opened them; opening one is recorded with POST /stories/{id}/view. Send the stories shown since the last batch, up to 100 at a time. Impressions are buffered and written every few seconds, once per story, so they reach creator insights with a delay; stories you cannot see are dropped then.
+// @Tags stories
+// @Accept json
+// @Produce json
+// @Param impressions body types.ImpressionBatchRequest true "Stories shown"
+// @Success 202 {object} response.Response{data=types.ImpressionBatchResponse} "Impressions accepted"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/impressions/batch [post]
+func RecordImpressions(buffer *impressions.Buffer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.ImpressionBatchRequest](w, r)
+		if !ok {
+			return
+		}
+
+		accepted, err := buffer.Add(r.Context(), userID, req.StoryIDs, time.Now())
+		if err != nil {
+			slog.Error("Failed to buffer impressions", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRecordImpressions)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusAccepted, response.RequestOK("Impressions accepted", types.ImpressionBatchResponse{Accepted: accepted}))
+	}
+}
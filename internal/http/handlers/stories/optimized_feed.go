@@ -1,67 +1,342 @@
 package stories
 
 import (
-	"errors"
+	"context"
+	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/experiments"
 	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
 	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
+// MediaURLResolver presigns download URLs for the media of many stories at once
+type MediaURLResolver interface {
+	Resolve(ctx context.Context, tenantID string, objectKeys []string) (map[string]mediaService.DownloadURL, error)
+}
+
+// wantsMediaURLs reports whether the request asks for media URLs in the feed
+func wantsMediaURLs(r *http.Request) bool {
+	return r.URL.Query().Get("media_urls") == "true"
+}
+
+// attachMediaURLs sets the media URL of each story with media, resolving them
+// in one batch. The stories are served without URLs if that fails.
+func attachMediaURLs[S any](r *http.Request, resolver MediaURLResolver, stories []S, story func(*S) *types.Story) {
+	keys := make([]string, 0, len(stories))
+	for i := range stories {
+		keys = append(keys, story(&stories[i]).MediaKey)
+	}
+
+	urls, err := resolver.Resolve(r.Context(), tenant.FromContext(r.Context()), keys)
+	if err != nil {
+		slog.Warn("Failed to resolve feed media URLs", slog.String("error", err.Error()))
+		return
+	}
+
+	for i := range stories {
+		s := story(&stories[i])
+		if url, ok := urls[s.MediaKey]; ok {
+			s.MediaURL = url.URL
+			s.MediaURLExpiresAt = url.ExpiresAt
+		}
+	}
+}
+
 // OptimizedFeed handles the optimized stories feed endpoint with caching and N+1 avoidance
 // @Summary Get optimized stories feed
-// @Description Get stories feed with caching and preloaded metadata to avoid N+1 queries
+// @ID getOptimizedFeed
+// @Description Get stories feed with caching and preloaded metadata to avoid N+1 queries, newest first and then by ID, a page at a time: pass the X-Next-Cursor header of a response as cursor to get the following page. With media_urls=true each story with media carries a presigned media_url, valid for media.feed_url_ttl seconds, so its media can be fetched without calling /media/{object_key}/download-url.
 // @Tags stories
 // @Security BearerAuth
-// @Success 200 {object} response.Response "Optimized feed retrieved successfully"
+// @Param limit query int false "Stories to return, up to feed.max_limit (default feed.default_limit)"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param media_urls query bool false "Include presigned media URLs"
+// @Param fields query string false "Comma-separated story fields to return, such as id,media_key,author (default all)"
+// @Success 200 {object} response.Response{data=[]types.StoryWithMeta} "Optimized feed retrieved successfully"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, sent while more stories follow"
+// @Failure 400 {object} response.Response "Invalid limit, cursor or unknown field"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /feed/optimized [get]
-func OptimizedFeed(cacheService *cache.CacheService, optimizedQuery *cache.OptimizedFeedQuery) http.HandlerFunc {
+func OptimizedFeed(cacheService *cache.CacheService, optimizedQuery *cache.OptimizedFeedQuery, limits request.Limits, mediaURLs MediaURLResolver) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limit, ok := limits.Limit(w, r)
+		if !ok {
+			return
+		}
+		cursor, ok := feedCursor(w, r)
+		if !ok {
+			return
+		}
+		fields, ok := request.ParseFields(w, r, types.StoryWithMeta{})
+		if !ok {
 			return
 		}
 
+		start := time.Now()
+
 		// First try to get cached feed
-		cachedStories, err := cacheService.GetCachedFeed(r.Context(), userID)
+		cachedStories, hit, err := cacheService.GetCachedFeed(r.Context(), userID)
 		if err == nil && len(cachedStories) > 0 {
-			response.WriteJSON(w, http.StatusOK, response.RequestOK("Cached feed retrieved successfully", cachedStories))
+			cachedStories = feedPage(w, cachedStories, cursor, limit, func(s *types.Story) *types.Story { return s })
+			result := metrics.ResultMiss
+			if hit {
+				result = metrics.ResultHit
+			}
+			if wantsMediaURLs(r) {
+				attachMediaURLs(r, mediaURLs, cachedStories, func(s *types.Story) *types.Story { return s })
+			}
+			metrics.ObserveFeed("feed_optimized", result, start, len(cachedStories))
+			writeFields(w, fields, "Cached feed retrieved successfully", cachedStories)
 			return
 		}
 
-		// Cache miss or empty - fetch optimized feed with all metadata
-		optimizedStories, err := optimizedQuery.GetOptimizedFeedForUser(r.Context(), userID)
+		// Cache miss or empty - fetch optimized feed with all metadata, and
+		// one story more to tell whether another page follows
+		optimizedStories, err := optimizedQuery.GetOptimizedFeedForUser(r.Context(), userID, cursor, limit+1)
 		if err != nil {
+			metrics.ObserveFeed("feed_optimized", metrics.ResultError, start, 0)
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
+		optimizedStories = feedPage(w, optimizedStories, nil, limit, func(s *types.StoryWithMeta) *types.Story { return &s.Story })
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Optimized feed retrieved successfully", optimizedStories))
+		if wantsMediaURLs(r) {
+			attachMediaURLs(r, mediaURLs, optimizedStories, func(s *types.StoryWithMeta) *types.Story { return &s.Story })
+		}
+		metrics.ObserveFeed("feed_optimized", metrics.ResultMiss, start, len(optimizedStories))
+		writeFields(w, fields, "Optimized feed retrieved successfully", optimizedStories)
 	}
 }
 
-func CachedFeed(cacheService storage.Storage) http.HandlerFunc {
+// CachedFeed serves the feed documented on Feed from the feed cache. While
+// the feed ranking experiment runs, users in its ranked variant get the feed
+// ordered by experiments.Rank, paged with a types.RankCursor, and every page
+// names its variant in the experiments header.
+func CachedFeed(cacheService *cache.CacheService, limits request.Limits, mediaURLs MediaURLResolver, assigner *experiments.Assigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
-		// This will use the cache service which automatically handles caching
-		stories, err := cacheService.GetStoriesForUser(userID)
+		fields, ok := request.ParseFields(w, r, types.Story{})
+		if !ok {
+			return
+		}
+
+		if r.URL.Query().Get("stream") == "true" {
+			streamFeed(w, r, cacheService, userID, mediaURLs, fields)
+			return
+		}
+
+		limit, ok := limits.Limit(w, r)
+		if !ok {
+			return
+		}
+		variant, running := assigner.Variant(r.Context(), experiments.FeedRanking, userID)
+		ranked := running && variant == experiments.VariantRanked
+		cursor, rankCursor, ok := feedCursors(w, r, ranked)
+		if !ok {
+			return
+		}
+
+		start := time.Now()
+		stories, hit, err := cacheService.GetCachedFeed(r.Context(), userID)
 		if err != nil {
+			metrics.ObserveFeed("feed", metrics.ResultError, start, 0)
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Cached feed retrieved successfully", stories))
+		result := metrics.ResultMiss
+		if hit {
+			result = metrics.ResultHit
+		}
+		byStory := func(s *types.Story) *types.Story { return s }
+		if running {
+			assigner.Expose(r.Context(), experiments.FeedRanking, variant, userID)
+			w.Header().Set(experiments.Header, experiments.FeedRanking+"="+variant)
+		}
+		if ranked {
+			stories = rankedPage(w, rankFeed(cacheService, userID, stories, rankCursor.RankedAt), rankCursor, limit)
+		} else {
+			stories = feedPage(w, stories, cursor, limit, byStory)
+		}
+		if wantsMediaURLs(r) {
+			attachMediaURLs(r, mediaURLs, stories, byStory)
+		}
+		metrics.ObserveFeed("feed", result, start, len(stories))
+		writeFields(w, fields, "Cached feed retrieved successfully", stories)
+	}
+}
+
+// rankFeed orders the user's feed by experiments.Rank as at the given time.
+// Without the user's affinity the feed is ranked by age alone.
+func rankFeed(cacheService *cache.CacheService, userID string, stories []types.Story, at time.Time) []types.Story {
+	affinity, err := cacheService.GetAuthorAffinity(userID)
+	if err != nil {
+		slog.Warn("Failed to get author affinity for ranked feed", slog.String("error", err.Error()), slog.String("user_id", userID))
+	}
+	return experiments.Rank(stories, affinity, at)
+}
+
+// writeFields writes data with only the fields the request selected
+func writeFields(w http.ResponseWriter, fields request.Fields, message string, data any) {
+	selected, err := fields.Select(data)
+	if err != nil {
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, response.RequestOK(message, selected))
+}
+
+// nextCursorHeader carries the cursor of the next page of a feed, sent while
+// more stories follow
+const nextCursorHeader = "X-Next-Cursor"
+
+// feedCursor reads the cursor query parameter, nil when there is none. An
+// invalid cursor gets a 400 response and false, so handlers can simply return.
+func feedCursor(w http.ResponseWriter, r *http.Request) (*types.FeedCursor, bool) {
+	param := r.URL.Query().Get("cursor")
+	if param == "" {
+		return nil, true
+	}
+	cursor, err := types.ParseFeedCursor(param)
+	if err != nil {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidFeedCursor)))
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// feedCursors reads the cursor query parameter of a ranked feed or of a
+// chronological one. A cursor of the other kind, left from before the user's
+// variant changed, starts again from the first page; the first page of a
+// ranked feed is ranked now. An invalid cursor gets a 400 response and false,
+// so handlers can simply return.
+func feedCursors(w http.ResponseWriter, r *http.Request, ranked bool) (*types.FeedCursor, types.RankCursor, bool) {
+	param := r.URL.Query().Get("cursor")
+	rankCursor, rankErr := types.ParseRankCursor(param)
+	if ranked {
+		if rankErr != nil {
+			rankCursor = types.RankCursor{RankedAt: time.Now()}
+		}
+		if _, err := types.ParseFeedCursor(param); rankErr != nil && err != nil && param != "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidFeedCursor)))
+			return nil, rankCursor, false
+		}
+		return nil, rankCursor, true
+	}
+
+	if rankErr == nil {
+		return nil, rankCursor, true
+	}
+	cursor, ok := feedCursor(w, r)
+	return cursor, rankCursor, ok
+}
+
+// rankedPage returns the limit stories of a ranked feed after the cursor's
+// offset and, when more follow, sets the next cursor header to continue after
+// them
+func rankedPage(w http.ResponseWriter, stories []types.Story, cursor types.RankCursor, limit int) []types.Story {
+	stories = stories[min(cursor.Offset, len(stories)):]
+	if len(stories) > limit {
+		stories = stories[:limit]
+		next := types.RankCursor{RankedAt: cursor.RankedAt, Offset: cursor.Offset + limit}
+		w.Header().Set(nextCursorHeader, next.String())
+	}
+	return stories
+}
+
+// afterCursor drops the stories of a feed, in types.FeedCursor order, up to
+// and including cursor. The cursor's story need not still be in the feed.
+func afterCursor[S any](stories []S, cursor *types.FeedCursor, story func(*S) *types.Story) []S {
+	if cursor == nil {
+		return stories
+	}
+	i := slices.IndexFunc(stories, func(s S) bool { return cursor.Precedes(*story(&s)) })
+	if i < 0 {
+		return stories[len(stories):]
+	}
+	return stories[i:]
+}
+
+// feedPage returns the first limit stories of a feed after cursor and, when
+// more follow, sets the next cursor header to continue from the last of them
+func feedPage[S any](w http.ResponseWriter, stories []S, cursor *types.FeedCursor, limit int, story func(*S) *types.Story) []S {
+	stories = afterCursor(stories, cursor, story)
+	if len(stories) > limit {
+		stories = stories[:limit]
+		w.Header().Set(nextCursorHeader, types.CursorAt(*story(&stories[limit-1])).String())
+	}
+	return stories
+}
+
+// streamFeed writes the user's feed as newline-delimited JSON, one story per
+// line, as rows are scanned instead of buffering the whole feed. The response
+// starts with the first story, so a query that fails before then still gets a
+// 500; a later failure ends the stream with an error line. Media URLs, when
+// asked for, are resolved a story at a time, and each line carries only the
+// selected fields.
+func streamFeed(w http.ResponseWriter, r *http.Request, store storage.StoryStore, userID string, mediaURLs MediaURLResolver, fields request.Fields) {
+	start := time.Now()
+	withURLs := wantsMediaURLs(r)
+
+	var stream *response.NDJSONStream
+	count := 0
+	err := store.StreamStoriesForUser(r.Context(), userID, func(story types.Story) error {
+		if stream == nil {
+			stream = response.NewNDJSONStream(w)
+		}
+		count++
+		if withURLs && story.MediaKey != "" {
+			one := []types.Story{story}
+			attachMediaURLs(r, mediaURLs, one, func(s *types.Story) *types.Story { return s })
+			story = one[0]
+		}
+		line, err := fields.Select(story)
+		if err != nil {
+			return err
+		}
+		return stream.Write(line)
+	})
+	if err != nil {
+		metrics.ObserveFeed("feed_stream", metrics.ResultError, start, count)
+		slog.Error("Failed to stream feed", slog.String("error", err.Error()), slog.String("user_id", userID), slog.Int("sent", count))
+		if stream == nil {
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		stream.Write(response.GeneralError(err))
+		stream.Flush()
+		return
+	}
+
+	if stream == nil {
+		// An empty feed is an empty stream
+		stream = response.NewNDJSONStream(w)
 	}
+	stream.Flush()
+	metrics.ObserveFeed("feed_stream", metrics.ResultMiss, start, count)
 }
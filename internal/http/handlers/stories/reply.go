@@ -0,0 +1,78 @@
+package stories
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ReplyToStory handles a private reply to someone else's story
+// @Summary Reply to a story
+// @ID replyToStory
+// @Description Send the author of a story you can see a private reply, delivered as a story.replied event. Replies are not stored: one the author cannot get right away, during their quiet hours or while they are offline, is queued for their digest, and a reply that can be neither sent nor queued is reported as not delivered. Your own stories and stories whose author turned off allow_reply cannot be replied to.
+// @Tags stories
+// @Accept json
+// @Param id path string true "Story ID"
+// @Param reply body types.ReplyRequest true "Reply text"
+// @Success 200 {object} response.Response "Reply sent or queued for the author's digest"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story, your own story, or replies turned off"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 503 {object} response.Response "Reply not delivered"
+// @Security BearerAuth
+// @Router /stories/{id}/reply [post]
+func ReplyToStory(store storage.StoryStore, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		reply, ok := request.DecodeJSON[types.ReplyRequest](w, r)
+		if !ok {
+			return
+		}
+
+		// Only stories the user can see may be replied to
+		story, ok := visibleStory(w, r, store, storyID, userID)
+		if !ok {
+			return
+		}
+
+		if story.AuthorID == userID {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgSelfReplyNotAllowed)))
+			return
+		}
+		if !story.AllowReply {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgReplyNotAllowed)))
+			return
+		}
+
+		// The event is the only copy of the reply, so success is only reported
+		// once it has been sent or queued
+		if err := eventPublisher.PublishStoryReplied(storyID, userID, story.AuthorID, reply.Text); err != nil {
+			slog.Error("Failed to deliver story reply",
+				slog.String("story_id", storyID),
+				slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusServiceUnavailable, response.GeneralError(i18n.Error(r.Context(), i18n.MsgReplyNotDelivered)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reply sent successfully", nil))
+	}
+}
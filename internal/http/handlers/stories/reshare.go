@@ -0,0 +1,99 @@
+package stories
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ReshareStory handles sharing someone else's public story to your audience
+// @Summary Reshare a story
+// @ID reshareStory
+// @Description Share another user's active public story as a new story of your own, with an optional caption in text and your choice of audience, which may be one of your groups. The reshare shows the original's media and names it in parent_story_id. Resharing a reshare shares the original it points to; your own stories, stories whose author turned off allow_reshare, and stories you have an active reshare of, cannot be reshared. The original's author gets a story.reshared event.
+// @Tags stories
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param reshare body types.ReshareRequest true "Caption and audience"
+// @Success 201 {object} response.Response{data=types.ReshareResponse} "Story reshared successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not a public story by someone else, resharing turned off, or not a member of the group"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 409 {object} response.Response "Already reshared"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/reshare [post]
+func ReshareStory(store storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		reshare, ok := request.DecodeJSON[types.ReshareRequest](w, r)
+		if !ok {
+			return
+		}
+
+		if !checkGroup(w, r, reshare.Visibility, reshare.GroupID) {
+			return
+		}
+
+		// Only stories the user can see may be reshared
+		if _, ok := visibleStory(w, r, store, storyID, userID); !ok {
+			return
+		}
+
+		reshareID, original, err := store.ReshareStory(userID, storyID, reshare)
+		switch {
+		case errors.Is(err, storage.ErrReshareNotPublic):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgReshareNotPublic)))
+			return
+		case errors.Is(err, storage.ErrReshareNotAllowed):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgReshareNotAllowed)))
+			return
+		case errors.Is(err, storage.ErrSelfReshare):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgSelfReshareNotAllowed)))
+			return
+		case errors.Is(err, storage.ErrNotGroupMember):
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNotGroupMember)))
+			return
+		case errors.Is(err, storage.ErrAlreadyReshared):
+			response.WriteJSON(w, http.StatusConflict, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAlreadyReshared)))
+			return
+		case err != nil:
+			slog.Error("Failed to reshare story", slog.String("error", err.Error()), slog.String("story_id", storyID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToReshareStory)))
+			return
+		}
+
+		// Publish real-time event (fire and forget)
+		go func() {
+			err := eventPublisher.PublishStoryReshared(original.ID, reshareID, userID, original.AuthorID)
+			if err != nil {
+				slog.Error("Failed to publish story reshared event", slog.String("error", err.Error()))
+			}
+		}()
+
+		response.WriteJSON(w, http.StatusCreated, response.OK("Story reshared successfully", types.ReshareResponse{
+			ID:            reshareID,
+			ParentStoryID: original.ID,
+		}))
+	}
+}
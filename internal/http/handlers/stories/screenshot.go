@@ -0,0 +1,83 @@
+package stories
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// RecordScreenshot handles a client reporting that the user captured a story
+// @Summary Report a story screenshot
+// @ID recordScreenshot
+// @Description Report that you took a screenshot or screen recording of a story you can see. The first report of each kind per story is stored for the author's insights and, unless the story turned off allow_screenshot_notification, sends them a story.screenshotted event, subject to quiet hours and the digest; repeats and reports on your own stories change nothing. Only available while the server has screenshot signals turned on.
+// @Tags stories
+// @Accept json
+// @Param id path string true "Story ID"
+// @Param screenshot body types.ScreenshotRequest true "How the story was captured"
+// @Success 200 {object} response.Response "Screenshot recorded successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story"
+// @Failure 404 {object} response.Response "Story not found, or screenshot signals turned off"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/screenshot [post]
+func RecordScreenshot(store storage.Storage, eventPublisher *events.EventPublisher, enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		// Screenshot reports say what viewers do on their own devices, so
+		// servers only take them when turned on
+		if !enabled {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgScreenshotSignalsDisabled)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		screenshot, ok := request.DecodeJSON[types.ScreenshotRequest](w, r)
+		if !ok {
+			return
+		}
+
+		// Only stories the user can see may be reported
+		story, ok := visibleStory(w, r, store, storyID, userID)
+		if !ok {
+			return
+		}
+
+		first, err := store.RecordScreenshot(storyID, userID, screenshot.Kind)
+		if err != nil {
+			slog.Error("Failed to record screenshot", slog.String("error", err.Error()), slog.String("story_id", storyID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRecordScreenshot)))
+			return
+		}
+
+		// Publish real-time event (fire and forget)
+		if first && story.AllowScreenshotNotification {
+			go func() {
+				err := eventPublisher.PublishStoryScreenshotted(storyID, userID, story.AuthorID, screenshot.Kind)
+				if err != nil {
+					slog.Error("Failed to publish story screenshotted event", slog.String("error", err.Error()))
+				}
+			}()
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Screenshot recorded successfully", nil))
+	}
+}
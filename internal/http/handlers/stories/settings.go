@@ -0,0 +1,81 @@
+package stories
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// GetStorySettings returns the defaults applied to the caller's new stories
+// @Summary Get story settings
+// @ID getStorySettings
+// @Description Get the visibility, audience and lifetime your stories get when posted without one
+// @Tags stories
+// @Produce json
+// @Success 200 {object} response.Response{data=types.StorySettings} "Story settings"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/settings/stories [get]
+func GetStorySettings(store storage.StoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		settings, err := store.GetStorySettings(userID)
+		if err != nil {
+			slog.Error("Failed to get story settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetStorySettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Story settings retrieved", settings))
+	}
+}
+
+// UpdateStorySettings changes the defaults applied to the caller's new stories
+// @Summary Update story settings
+// @ID updateStorySettings
+// @Description Set what POST /stories uses when a story leaves it out: default_visibility (any but GROUP), default_audience_user_ids for PRIVATE stories sent without audience_user_ids, such as your close friends, and default_expires_in_hours (1 to 48). Fields left out keep their value; an empty visibility or audience, or 0 hours, clears the default.
+// @Tags stories
+// @Accept json
+// @Produce json
+// @Param settings body types.StorySettingsUpdate true "Settings to change"
+// @Success 200 {object} response.Response{data=types.StorySettings} "Story settings updated"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/settings/stories [patch]
+func UpdateStorySettings(store storage.StoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		update, ok := request.DecodeJSON[types.StorySettingsUpdate](w, r)
+		if !ok {
+			return
+		}
+
+		settings, err := store.UpdateStorySettings(userID, update)
+		if err != nil {
+			slog.Error("Failed to update story settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateStorySettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story settings updated", settings))
+	}
+}
@@ -0,0 +1,216 @@
+package stories
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/sharelink"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// defaultShareLinkHours is how long a share link stays valid unless the author
+// asks otherwise; links never outlive their story either way
+const defaultShareLinkHours = 24
+
+// CreateShareLink handles creating a public link to a story
+// @Summary Create a story share link
+// @ID createShareLink
+// @Description Create a signed link that lets anyone holding it view the story, whatever its visibility, until the link expires, is revoked or the story ends. With require_login only signed-in users can open it. Stories limiting how often each viewer may open them cannot be shared by link.
+// @Tags stories
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param request body types.ShareLinkRequest true "Share link options"
+// @Success 201 {object} response.Response{data=types.ShareLink} "Share link created successfully"
+// @Failure 400 {object} response.Response "Bad request, or a view-limited story"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story's author"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/share-link [post]
+func CreateShareLink(store storage.Storage, signer *sharelink.Signer, publicURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		story, ok := authoredStory(w, r, store, i18n.MsgOnlyAuthorShare)
+		if !ok {
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.ShareLinkRequest](w, r)
+		if !ok {
+			return
+		}
+
+		// Anyone holding a link could open the story as often as they like
+		if story.MaxViewsPerUser > 0 {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgViewLimitedNotShareable)))
+			return
+		}
+
+		hours := req.ExpiresInHours
+		if hours == 0 {
+			hours = defaultShareLinkHours
+		}
+
+		link, err := store.CreateShareLink(story.ID, req.RequireLogin, time.Duration(hours)*time.Hour)
+		if err != nil {
+			slog.Error("Failed to create share link", slog.String("error", err.Error()), slog.String("story_id", story.ID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToShareStory)))
+			return
+		}
+		link.URL = signer.URL(publicURL, link.ID)
+
+		response.WriteJSON(w, http.StatusCreated, response.RequestOK("Share link created successfully", link))
+	}
+}
+
+// ListShareLinks handles listing a story's share links
+// @Summary List story share links
+// @ID listShareLinks
+// @Description List every share link created for the story, including expired and revoked ones, with how many times each was opened
+// @Tags stories
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response{data=[]types.ShareLink} "Share links fetched successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story's author"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/share-links [get]
+func ListShareLinks(store storage.Storage, signer *sharelink.Signer, publicURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		story, ok := authoredStory(w, r, store, i18n.MsgOnlyAuthorShare)
+		if !ok {
+			return
+		}
+
+		links, err := store.GetShareLinks(story.ID)
+		if err != nil {
+			slog.Error("Failed to get share links", slog.String("error", err.Error()), slog.String("story_id", story.ID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		for i := range links {
+			links[i].URL = signer.URL(publicURL, links[i].ID)
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Share links fetched successfully", links))
+	}
+}
+
+// RevokeShareLink handles revoking one of a story's share links
+// @Summary Revoke a story share link
+// @ID revokeShareLink
+// @Description Revoke a share link so it can no longer be opened; the story's other links keep working
+// @Tags stories
+// @Param id path string true "Story ID"
+// @Param link_id path string true "Share link ID"
+// @Success 200 {object} response.Response "Share link revoked successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story's author"
+// @Failure 404 {object} response.Response "Story or share link not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/share-links/{link_id} [delete]
+func RevokeShareLink(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		story, ok := authoredStory(w, r, store, i18n.MsgOnlyAuthorShare)
+		if !ok {
+			return
+		}
+
+		err := store.RevokeShareLink(story.ID, r.PathValue("link_id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgShareLinkNotFound)))
+				return
+			}
+			slog.Error("Failed to revoke share link", slog.String("error", err.Error()), slog.String("story_id", story.ID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Share link revoked successfully", nil))
+	}
+}
+
+// SharedStory handles opening a story through a share link
+// @Summary View a shared story
+// @ID getSharedStory
+// @Description View the story a share link points to, bypassing the story's visibility. No authentication is needed unless the author required sign-in for the link. Each successful open counts as a view of the link.
+// @Tags stories
+// @Produce json
+// @Param token path string true "Share link token"
+// @Success 200 {object} response.Response{data=types.Story} "Story retrieved successfully"
+// @Failure 401 {object} response.Response "Unauthorized - the link requires sign-in"
+// @Failure 404 {object} response.Response "Share link not found, expired or revoked"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /shared/{token} [get]
+func SharedStory(store storage.Storage, signer *sharelink.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link, story, err := sharelink.Open(store, signer, r.PathValue("token"))
+		if err != nil {
+			if errors.Is(err, sharelink.ErrInvalidToken) || errors.Is(err, sql.ErrNoRows) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgShareLinkNotFound)))
+				return
+			}
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		if _, signedIn := middleware.GetUserIDFromContext(r.Context()); link.RequireLogin && !signedIn {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgShareLinkLoginRequired)))
+			return
+		}
+
+		// A lost count should not keep the viewer from the story
+		if err := store.RecordShareLinkView(link.ID); err != nil {
+			slog.Error("Failed to record share link view", slog.String("error", err.Error()), slog.String("link_id", link.ID))
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story retrieved successfully", story))
+	}
+}
+
+// authoredStory loads the story named in the path for a handler only its
+// author may use, answering anyone else with the forbidden message. On
+// failure it writes the error response and returns false.
+func authoredStory(w http.ResponseWriter, r *http.Request, store storage.StoryStore, forbidden i18n.MessageKey) (types.Story, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+		return types.Story{}, false
+	}
+
+	storyID := r.PathValue("id")
+	if storyID == "" {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+		return types.Story{}, false
+	}
+
+	story, err := store.GetStoryByID(storyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
+			return types.Story{}, false
+		}
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		return types.Story{}, false
+	}
+
+	if story.AuthorID != userID {
+		response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), forbidden)))
+		return types.Story{}, false
+	}
+
+	return story, true
+}
@@ -2,417 +2,870 @@ package stories
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/princekumarofficial/stories-service/internal/events"
 	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/services/links"
 	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
 	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 // Feed handles the stories feed endpoint
 // @Summary Get stories feed
+// @ID getFeed
+// @Description Get the newest stories visible to the user, newest first and then by ID, a page at a time: pass the X-Next-Cursor header of a response as cursor to get the following page. With stream=true the whole feed is sent as newline-delimited JSON (application/x-ndjson), one story per line, as it is read from the database, and limit and cursor do not apply; a failure partway through ends the stream with an error line. With media_urls=true each story with media carries a presigned media_url, valid for media.feed_url_ttl seconds, so its media can be fetched without calling /media/{object_key}/download-url. While the feed ranking experiment runs, some users get stories ranked by how much they engage with each author and by age instead of newest first; X-Experiments names the variant served. Ranked feeds are paged by position: their X-Next-Cursor continues from the ranking of the first page. Streamed feeds are always newest first.
 // @Tags stories
+// @Produce json
+// @Produce application/x-ndjson
+// @Param stream query bool false "Stream the feed as newline-delimited JSON"
+// @Param limit query int false "Stories to return, up to feed.max_limit (default feed.default_limit)"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param media_urls query bool false "Include presigned media URLs"
+// @Param fields query string false "Comma-separated story fields to return, such as id,media_key,author (default all)"
+// @Success 200 {object} response.Response{data=[]types.Story} "Stories fetched successfully"
+// @Header 200 {string} X-Experiments "feed_ranking=chronological or feed_ranking=ranked while the feed ranking experiment runs"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, sent while more stories follow"
+// @Failure 400 {object} response.Response "Invalid limit, cursor or unknown field"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
 // @Router /feed [get]
-func Feed(storage storage.Storage) http.HandlerFunc {
+func Feed(storage storage.StoryStore, limits request.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract user ID from context
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limit, ok := limits.Limit(w, r)
+		if !ok {
+			return
+		}
+		cursor, ok := feedCursor(w, r)
+		if !ok {
+			return
+		}
+		fields, ok := request.ParseFields(w, r, types.Story{})
+		if !ok {
+			return
+		}
+
+		stories, err := storage.GetStoriesForUser(r.Context(), userID)
+		if err != nil {
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		stories = feedPage(w, stories, cursor, limit, func(s *types.Story) *types.Story { return s })
+		writeFields(w, fields, "Stories fetched successfully", stories)
+	}
+}
+
+// FeedTrays handles the story tray endpoint
+// @Summary Get story trays
+// @ID getFeedTrays
+// @Description Get one entry per followed author with active stories the user may see: their story count, latest story time, avatar and whether every story has been seen. Authors with unseen stories come first, then by latest story.
+// @Tags stories
+// @Produce json
+// @Success 200 {object} response.Response{data=[]types.FeedTray} "Story trays fetched successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /feed/trays [get]
+func FeedTrays(storage storage.StoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		trays, err := storage.GetFeedTrays(r.Context(), userID)
+		if err != nil {
+			slog.Error("Failed to get story trays", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetFeedTrays)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story trays fetched successfully", trays))
+	}
+}
+
+// FeedChanges handles the feed diff endpoint
+// @Summary Get feed changes
+// @ID getFeedChanges
+// @Description Get the stories added to the user's feed after since, and those it held at since that have been deleted or expired since, so polling clients need not refetch the whole feed. Pass the returned cursor as since on the next poll; it trails the present by a few seconds so no change is skipped.
+// @Tags stories
+// @Produce json
+// @Param since query string true "RFC 3339 timestamp or the cursor from a previous response"
+// @Success 200 {object} response.Response{data=types.FeedChanges} "Feed changes fetched successfully"
+// @Failure 400 {object} response.Response "Missing or invalid since"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /feed/changes [get]
+func FeedChanges(storage storage.StoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		// Cursors are RFC 3339 timestamps themselves, so one parse covers both
+		since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+		if err != nil {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidSince)))
+			return
+		}
+
+		changes, err := storage.GetFeedChanges(r.Context(), userID, since)
+		if err != nil {
+			slog.Error("Failed to get feed changes", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetFeedChanges)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Feed changes fetched successfully", changes))
+	}
+}
+
+// Nearby search radius bounds in meters
+const (
+	defaultNearbyRadius = 5000
+	maxNearbyRadius     = 50000
+)
+
+// NearbyStories handles the nearby public stories endpoint
+// @Summary Get nearby public stories
+// @ID getNearbyStories
+// @Description Get active public stories tagged within a radius of a location, closest first
+// @Tags stories
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radius query number false "Search radius in meters (default: 5000, max: 50000)"
+// @Param fields query string false "Comma-separated story fields to return, such as id,media_key,author (default all)"
+// @Success 200 {object} response.Response{data=[]types.Story} "Nearby stories fetched successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/nearby [get]
+func NearbyStories(storage storage.StoryStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		query := r.URL.Query()
+
+		lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidLatitude)))
+			return
+		}
+
+		lng, err := strconv.ParseFloat(query.Get("lng"), 64)
+		if err != nil || lng < -180 || lng > 180 {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidLongitude)))
+			return
+		}
+
+		radius := float64(defaultNearbyRadius)
+		if radiusParam := query.Get("radius"); radiusParam != "" {
+			radius, err = strconv.ParseFloat(radiusParam, 64)
+			if err != nil || radius <= 0 || radius > maxNearbyRadius {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidNearbyRadius)))
+				return
+			}
+		}
+
+		fields, ok := request.ParseFields(w, r, types.Story{})
+		if !ok {
 			return
 		}
 
-		stories, err := storage.GetStoriesForUser(userID)
+		stories, err := storage.GetNearbyPublicStories(r.Context(), tenant.FromContext(r.Context()), userID, lat, lng, radius)
 		if err != nil {
+			slog.Error("Failed to fetch nearby stories", slog.String("error", err.Error()))
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Stories fetched successfully", stories))
+		writeFields(w, fields, "Nearby stories fetched successfully", stories)
 	}
 }
 
 // PostStory handles creating a new story
 // @Summary Create a new story
-// @Description Create a new story with authentication required
+// @ID createStory
+// @Description Create a new story with authentication required. A media_key must be an upload you started with POST /media/upload-url and confirmed with POST /media/confirm. A PRIVATE story may instead be end-to-end encrypted: leave text, link_url, place_name, latitude and longitude empty and send the ciphertext in encrypted, with the content key wrapped for yourself and each audience member using the keys from GET /users/{user_id}/public-key. Encrypt any media with the same key before uploading it. A GROUP story is posted into one of your groups, named in group_id, and only its members see it. A story left without visibility, audience_user_ids (PRIVATE only) or expires_in_hours gets the defaults from PATCH /me/settings/stories; stories expire after 24 hours unless either sets otherwise. allow_reshare, allow_reply and allow_screenshot_notification default to true; turning them off stops others resharing or replying to the story, and stops story.screenshotted events for it.
 // @Tags stories
 // @Accept json
 // @Produce json
 // @Param story body types.StoryPostRequest true "Story content"
-// @Success 201 {object} map[string]string "Story created successfully"
+// @Success 201 {object} response.Response{data=map[string]string} "Story created successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not a member of the group"
+// @Failure 409 {object} response.Response "Media upload not confirmed"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
 // @Router /stories [post]
-func PostStory(storage storage.Storage) http.HandlerFunc {
+func PostStory(store storage.StoryStore, linkValidator *links.Validator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
-		var story types.StoryPostRequest
+		story, ok := request.DecodeJSON[types.StoryPostRequest](w, r)
+		if !ok {
+			return
+		}
 
-		err := json.NewDecoder(r.Body).Decode(&story)
-		if errors.Is(err, io.EOF) {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("request body cannot be empty")))
+		// Fill in what the story leaves out from the author's defaults
+		settings, err := store.GetStorySettings(userID)
+		if err != nil {
+			slog.Error("Failed to get story settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetStorySettings)))
 			return
-		} else if err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		}
+		settings.Apply(&story)
+		if story.Visibility == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgVisibilityRequired)))
+			return
+		}
+		// The defaults may leave a PRIVATE story without an audience
+		if !request.Validate(w, r, story) {
 			return
 		}
 
-		// Validate request
-		validate := validator.New()
-		err = validate.Struct(story)
-		if err != nil {
-			if ve, ok := err.(validator.ValidationErrors); ok {
-				response.WriteJSON(w, http.StatusBadRequest, response.ValidationError(ve))
+		if !checkEncryption(w, r, userID, story) {
+			return
+		}
+
+		if !checkGroup(w, r, story.Visibility, story.GroupID) {
+			return
+		}
+
+		// Validate the swipe-up link if one is attached
+		if story.LinkURL != "" {
+			if err := linkValidator.Validate(story.LinkURL); err != nil {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
 				return
 			}
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
-			return
 		}
 
-		storyID, err := storage.CreateStory(userID, story.Text, story.MediaKey, story.Visibility, story.AudienceUserIDs)
+		storyID, err := store.CreateStory(userID, story)
+		if errors.Is(err, storage.ErrMediaNotOwned) {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotOwned)))
+			return
+		}
+		if errors.Is(err, storage.ErrMediaNotConfirmed) {
+			response.WriteJSON(w, http.StatusConflict, response.GeneralError(i18n.Error(r.Context(), i18n.MsgMediaNotConfirmed)))
+			return
+		}
+		if errors.Is(err, storage.ErrNotGroupMember) {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgNotGroupMember)))
+			return
+		}
 		if err != nil {
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 		slog.Info("Story created with ID:", slog.String("story_id", storyID))
 
-		response.WriteJSON(w, http.StatusCreated, map[string]string{"id": storyID})
+		response.WriteJSON(w, http.StatusCreated, response.OK("Story created successfully", map[string]string{"id": storyID}))
+	}
+}
+
+// ViewStory handles recording a story view without notifying the author;
+// the /stories/{id}/view route uses ViewStoryWithEvents
+func ViewStory(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract user ID from context
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		// Only stories the user can see may be viewed or reacted to
+		if _, ok := visibleStory(w, r, store, storyID, userID); !ok {
+			return
+		}
+
+		err := store.RecordStoryView(storyID, userID)
+		if errors.Is(err, storage.ErrStoryConsumed) {
+			response.WriteJSON(w, http.StatusGone, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryConsumed)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to record story view", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("View recorded successfully", nil))
+	}
+}
+
+// AddReaction handles adding a reaction to a story without notifying the
+// author; the /stories/{id}/reactions route uses AddReactionWithEvents
+func AddReaction(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract user ID from context
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		reactionReq, ok := request.DecodeJSON[types.ReactionRequest](w, r)
+		if !ok {
+			return
+		}
+
+		// Only stories the user can see may be viewed or reacted to
+		if _, ok := visibleStory(w, r, store, storyID, userID); !ok {
+			return
+		}
+
+		previous, err := store.AddReaction(storyID, userID, reactionReq.Emoji)
+		if errors.Is(err, storage.ErrSelfReaction) {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgSelfReactionNotAllowed)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to add reaction", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reaction added successfully", types.ReactionResponse{
+			StoryID:       storyID,
+			Emoji:         reactionReq.Emoji,
+			PreviousEmoji: previous,
+		}))
 	}
 }
 
-// ViewStory handles recording a story view
-// @Summary Record a story view
-// @Description Record that a user has viewed a story (idempotent - one view per user)
+// GetStory handles retrieving a specific story by ID
+// @Summary Get a story by ID
+// @ID getStory
+// @Description Get a specific story by its ID with permission checks based on visibility and graph. The ETag header holds its version, to send in If-Match when editing it. A story with max_views_per_user the user has viewed that many times comes back with consumed set and no text, media_key or link_url.
+// @Tags stories
+// @Param id path string true "Story ID"
+// @Param fields query string false "Comma-separated story fields to return, such as id,media_key,author (default all)"
+// @Success 200 {object} response.Response{data=types.Story} "Story retrieved successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id} [get]
+func GetStory(storage storage.StoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract user ID from context
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+		fields, ok := request.ParseFields(w, r, types.Story{})
+		if !ok {
+			return
+		}
+
+		// Get the story if the user can view it
+		story, ok := visibleStory(w, r, storage, storyID, userID)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("ETag", storyETag(story))
+		writeFields(w, fields, "Story retrieved successfully", story)
+	}
+}
+
+// ViewStoryWithEvents handles recording a story view with real-time events
+// @Summary Record a story view with real-time notifications
+// @ID viewStory
+// @Description Record that a user has viewed a story (idempotent - one view per user) and send real-time notification to author, unless the viewer hides their view receipts. On stories with max_views_per_user every view counts as a replay, and views beyond the limit return 410.
 // @Tags stories
 // @Param id path string true "Story ID"
 // @Success 200 {object} response.Response "View recorded successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story"
 // @Failure 404 {object} response.Response "Story not found"
+// @Failure 410 {object} response.Response "Story viewed as many times as it allows"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
 // @Router /stories/{id}/view [post]
-func ViewStory(storage storage.Storage) http.HandlerFunc {
+func ViewStoryWithEvents(store storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		storyID := r.PathValue("id")
 		if storyID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("story ID is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
 			return
 		}
 
-		// Verify story exists before recording view
-		_, err := storage.GetStoryByID(storyID)
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
-				return
-			}
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		// Get story to find the author ID; only stories the user can see may
+		// be viewed or reacted to
+		story, ok := visibleStory(w, r, store, storyID, userID)
+		if !ok {
 			return
 		}
 
-		err = storage.RecordStoryView(storyID, userID)
+		// Record the view in database
+		err := store.RecordStoryView(storyID, userID)
+		if errors.Is(err, storage.ErrStoryConsumed) {
+			response.WriteJSON(w, http.StatusGone, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryConsumed)))
+			return
+		}
 		if err != nil {
 			slog.Error("Failed to record story view", slog.String("error", err.Error()))
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
+		// Publish real-time event (fire and forget)
+		go func() {
+			err := eventPublisher.PublishStoryViewed(storyID, userID, story.AuthorID)
+			if err != nil {
+				slog.Error("Failed to publish story viewed event", slog.String("error", err.Error()))
+			}
+		}()
+
 		response.WriteJSON(w, http.StatusOK, response.RequestOK("View recorded successfully", nil))
 	}
 }
 
-func isValidReactionEmoji(emoji types.ReactionType) bool {
-	switch emoji {
-	case types.ReactionThumbsUp, types.ReactionHeart, types.ReactionLaugh,
-		types.ReactionSurprised, types.ReactionSad, types.ReactionFire:
-		return true
-	default:
-		return false
+// StoryViewers handles listing who viewed a story
+// @Summary List story viewers
+// @ID listStoryViewers
+// @Description List the users who viewed the story, latest first. Viewers who hide their view receipts are left out, though their views still count in stats.
+// @Tags stories
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response{data=[]types.StoryViewer} "Story viewers fetched successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story's author"
+// @Failure 404 {object} response.Response "Story not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/viewers [get]
+func StoryViewers(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		story, ok := authoredStory(w, r, store, i18n.MsgOnlyAuthorViewers)
+		if !ok {
+			return
+		}
+
+		viewers, err := store.GetStoryViewers(story.ID)
+		if err != nil {
+			slog.Error("Failed to get story viewers", slog.String("error", err.Error()), slog.String("story_id", story.ID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetViewers)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story viewers fetched successfully", viewers))
 	}
 }
 
-// AddReaction handles adding a reaction to a story
-// @Summary Add a reaction to a story
-// @Description Add an emoji reaction to a story (replaces existing reaction if any)
+// AddReactionWithEvents handles adding a reaction to a story with real-time events
+// @Summary Add a reaction to a story with real-time notifications
+// @ID addReaction
+// @Description Add an emoji reaction to a story and send real-time notification to author. A user has one reaction per story; a new one replaces it and the replaced emoji is returned as previous_emoji.
 // @Tags stories
 // @Accept json
 // @Produce json
 // @Param id path string true "Story ID"
-// @Param reaction body types.ReactionRequest true "Reaction emoji"
-// @Success 200 {object} response.Response "Reaction added successfully"
+// @Param reaction body types.ReactionRequest true "Reaction details"
+// @Success 200 {object} response.Response{data=types.ReactionResponse} "Reaction added successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story, or it is your own and self-reactions are disabled"
 // @Failure 404 {object} response.Response "Story not found"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
 // @Router /stories/{id}/reactions [post]
-func AddReaction(storage storage.Storage) http.HandlerFunc {
+func AddReactionWithEvents(store storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		storyID := r.PathValue("id")
 		if storyID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("story ID is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
 			return
 		}
 
-		var reactionReq types.ReactionRequest
-		err := json.NewDecoder(r.Body).Decode(&reactionReq)
-		if errors.Is(err, io.EOF) {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("request body cannot be empty")))
+		reactionReq, ok := request.DecodeJSON[types.ReactionRequest](w, r)
+		if !ok {
 			return
-		} else if err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		}
+
+		// Get story to find the author ID; only stories the user can see may
+		// be viewed or reacted to
+		story, ok := visibleStory(w, r, store, storyID, userID)
+		if !ok {
 			return
 		}
 
-		// Validate the emoji
-		if !isValidReactionEmoji(reactionReq.Emoji) {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid emoji: must be one of 👍 ❤️ 😂 😮 😢 🔥")))
+		// Add reaction to database
+		previous, err := store.AddReaction(storyID, userID, reactionReq.Emoji)
+		if errors.Is(err, storage.ErrSelfReaction) {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgSelfReactionNotAllowed)))
 			return
 		}
+		if err != nil {
+			slog.Error("Failed to add reaction", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		// Publish real-time event (fire and forget)
+		go func() {
+			err := eventPublisher.PublishStoryReacted(storyID, userID, story.AuthorID, reactionReq.Emoji)
+			if err != nil {
+				slog.Error("Failed to publish story reacted event", slog.String("error", err.Error()))
+			}
+		}()
 
-		// Verify story exists before adding reaction
-		_, err = storage.GetStoryByID(storyID)
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reaction added successfully", types.ReactionResponse{
+			StoryID:       storyID,
+			Emoji:         reactionReq.Emoji,
+			PreviousEmoji: previous,
+		}))
+	}
+}
+
+// RemoveReactionWithEvents handles taking back a reaction to a story
+// @Summary Remove your reaction to a story
+// @ID removeReaction
+// @Description Remove the authenticated user's reaction to a story and send a story.unreacted event to the author so live counters stay current. The removed emoji is returned as previous_emoji.
+// @Tags stories
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response{data=types.ReactionResponse} "Reaction removed successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Story or reaction not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /stories/{id}/reactions [delete]
+func RemoveReactionWithEvents(store storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract user ID from context
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		storyID := r.PathValue("id")
+		if storyID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
+			return
+		}
+
+		// Get story to find the author ID. Reactions can be taken back even
+		// once the story is no longer visible to the user.
+		story, err := store.GetStoryByID(storyID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
 				return
 			}
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		err = storage.AddReaction(storyID, userID, reactionReq.Emoji)
+		removed, err := store.RemoveReaction(storyID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgReactionNotFound)))
+			return
+		}
 		if err != nil {
-			slog.Error("Failed to add reaction", slog.String("error", err.Error()))
+			slog.Error("Failed to remove reaction", slog.String("error", err.Error()))
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reaction added successfully", nil))
+		// Publish real-time event (fire and forget)
+		go func() {
+			err := eventPublisher.PublishStoryUnreacted(storyID, userID, story.AuthorID, removed)
+			if err != nil {
+				slog.Error("Failed to publish story unreacted event", slog.String("error", err.Error()))
+			}
+		}()
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reaction removed successfully", types.ReactionResponse{
+			StoryID:       storyID,
+			PreviousEmoji: removed,
+		}))
 	}
 }
 
-// GetStory handles retrieving a specific story by ID
-// @Summary Get a story by ID
-// @Description Get a specific story by its ID with permission checks based on visibility and graph
+// AddToHighlights handles keeping a story in the author's highlights
+// @Summary Add a story to highlights
+// @ID addToHighlights
+// @Description Keep one of your own stories in your highlights (the action offered by story.expiring events)
 // @Tags stories
 // @Param id path string true "Story ID"
-// @Success 200 {object} response.Response "Story retrieved successfully"
+// @Success 200 {object} response.Response "Story added to highlights"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
-// @Failure 403 {object} response.Response "Forbidden - no permission to view this story"
+// @Failure 403 {object} response.Response "Forbidden - not the story author"
 // @Failure 404 {object} response.Response "Story not found"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
-// @Router /stories/{id} [get]
-func GetStory(storage storage.Storage) http.HandlerFunc {
+// @Router /stories/{id}/highlight [post]
+func AddToHighlights(storage storage.StoryStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		storyID := r.PathValue("id")
 		if storyID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("story ID is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
 			return
 		}
 
-		// Check if user can view this story
-		canView, err := storage.CanUserViewStory(storyID, userID)
+		story, err := storage.GetStoryByID(storyID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
 				return
 			}
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		if !canView {
-			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(errors.New("you don't have permission to view this story")))
+		// Only the author can highlight their own story
+		if story.AuthorID != userID {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgOnlyAuthorHighlight)))
 			return
 		}
 
-		// Get the story
-		story, err := storage.GetStoryByID(storyID)
+		err = storage.AddStoryToHighlights(storyID, userID)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
-				return
-			}
+			slog.Error("Failed to add story to highlights", slog.String("error", err.Error()))
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story retrieved successfully", story))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story added to highlights", nil))
 	}
 }
 
-// ViewStoryWithEvents handles recording a story view with real-time events
-// @Summary Record a story view with real-time notifications
-// @Description Record that a user has viewed a story (idempotent - one view per user) and send real-time notification to author
+// DeleteStory handles deleting a story before it expires
+// @Summary Delete a story
+// @ID deleteStory
+// @Description Delete one of your own stories. Its followers and your other devices receive a story.deleted event.
 // @Tags stories
 // @Param id path string true "Story ID"
-// @Success 200 {object} response.Response "View recorded successfully"
+// @Success 200 {object} response.Response "Story deleted successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not the story author"
 // @Failure 404 {object} response.Response "Story not found"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
-// @Router /stories/{id}/view [post]
-func ViewStoryWithEvents(storage storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
+// @Router /stories/{id} [delete]
+func DeleteStory(storage storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		storyID := r.PathValue("id")
 		if storyID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("story ID is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
 			return
 		}
 
-		// Get story to find the author ID
 		story, err := storage.GetStoryByID(storyID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
 				return
 			}
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		// Record the view in database
-		err = storage.RecordStoryView(storyID, userID)
+		// Only the author can delete their own story
+		if story.AuthorID != userID {
+			response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgOnlyAuthorDelete)))
+			return
+		}
+
+		story, err = storage.DeleteStory(storyID)
 		if err != nil {
-			slog.Error("Failed to record story view", slog.String("error", err.Error()))
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+			// Deleted or expired since it was read
+			if errors.Is(err, sql.ErrNoRows) {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
+				return
+			}
+			slog.Error("Failed to delete story", slog.String("error", err.Error()), slog.String("story_id", storyID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToDeleteStory)))
 			return
 		}
 
 		// Publish real-time event (fire and forget)
 		go func() {
-			err := eventPublisher.PublishStoryViewed(storyID, userID, story.AuthorID)
+			audience, err := storage.GetStoryAudience(story.ID)
 			if err != nil {
-				slog.Error("Failed to publish story viewed event", slog.String("error", err.Error()))
+				slog.Error("Failed to get audience for story deleted event", slog.String("error", err.Error()))
+				return
+			}
+			if err := eventPublisher.PublishStoryRemoved(types.EventStoryDeleted, story, audience); err != nil {
+				slog.Error("Failed to publish story deleted event", slog.String("error", err.Error()))
 			}
 		}()
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("View recorded successfully", nil))
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Story deleted successfully", nil))
 	}
 }
 
-// AddReactionWithEvents handles adding a reaction to a story with real-time events
-// @Summary Add a reaction to a story with real-time notifications
-// @Description Add an emoji reaction to a story and send real-time notification to author
+// RecordLinkClick handles recording a click on a story's attached link
+// @Summary Record a story link click
+// @ID recordLinkClick
+// @Description Record that a user opened the swipe-up link attached to a story
 // @Tags stories
-// @Accept json
-// @Produce json
 // @Param id path string true "Story ID"
-// @Param reaction body types.ReactionRequest true "Reaction details"
-// @Success 200 {object} response.Response "Reaction added successfully"
-// @Failure 400 {object} response.Response "Bad request"
+// @Success 200 {object} response.Response "Link click recorded successfully"
+// @Failure 400 {object} response.Response "Bad request - story has no link"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - no permission to view this story"
 // @Failure 404 {object} response.Response "Story not found"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
-// @Router /stories/{id}/reactions [post]
-func AddReactionWithEvents(storage storage.Storage, eventPublisher *events.EventPublisher) http.HandlerFunc {
+// @Router /stories/{id}/link/click [post]
+func RecordLinkClick(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		storyID := r.PathValue("id")
 		if storyID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("story ID is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryIDRequired)))
 			return
 		}
 
-		var reactionReq types.ReactionRequest
-		err := json.NewDecoder(r.Body).Decode(&reactionReq)
-		if errors.Is(err, io.EOF) {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("request body cannot be empty")))
-			return
-		} else if err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		// Get the story if the user can view it
+		story, ok := visibleStory(w, r, storage, storyID, userID)
+		if !ok {
 			return
 		}
 
-		// Validate the emoji
-		if !isValidReactionEmoji(reactionReq.Emoji) {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("invalid emoji: must be one of 👍 ❤️ 😂 😮 😢 🔥")))
+		if story.LinkURL == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryHasNoLink)))
 			return
 		}
 
-		// Get story to find the author ID
-		story, err := storage.GetStoryByID(storyID)
+		err := storage.RecordLinkClick(storyID, userID)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("story not found")))
-				return
-			}
+			slog.Error("Failed to record link click", slog.String("error", err.Error()))
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
-		// Add reaction to database
-		err = storage.AddReaction(storyID, userID, reactionReq.Emoji)
-		if err != nil {
-			slog.Error("Failed to add reaction", slog.String("error", err.Error()))
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
-			return
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Link click recorded successfully", nil))
+	}
+}
+
+// visibleStory loads a story userID is allowed to see, consumed if they used
+// up its views. On failure it writes the error response (404 when the story
+// does not exist, 403 when its visibility hides it) and returns false.
+func visibleStory(w http.ResponseWriter, r *http.Request, store storage.StoryStore, storyID, userID string) (types.Story, bool) {
+	canView, err := store.CanUserViewStory(storyID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
+			return types.Story{}, false
 		}
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		return types.Story{}, false
+	}
 
-		// Publish real-time event (fire and forget)
-		go func() {
-			err := eventPublisher.PublishStoryReacted(storyID, userID, story.AuthorID, reactionReq.Emoji)
-			if err != nil {
-				slog.Error("Failed to publish story reacted event", slog.String("error", err.Error()))
-			}
-		}()
+	if !canView {
+		response.WriteJSON(w, http.StatusForbidden, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryForbidden)))
+		return types.Story{}, false
+	}
 
-		response.WriteJSON(w, http.StatusOK, response.RequestOK("Reaction added successfully", nil))
+	story, err := store.GetStoryForViewer(storyID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgStoryNotFound)))
+			return types.Story{}, false
+		}
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+		return types.Story{}, false
 	}
+
+	return story, true
 }
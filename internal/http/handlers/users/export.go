@@ -0,0 +1,254 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/exports"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// Insights export range bounds in days, both ends included
+const (
+	defaultExportDays = 30
+	maxExportDays     = 366
+)
+
+// ExportStats streams the user's per-story, per-day metrics as a CSV file
+// @Summary Export creator insights
+// @ID exportStats
+// @Description Download a CSV with one row per story and day (UTC) that story had activity: views, unique viewers, reactions, link clicks, impressions and reshares. Expired and deleted stories are included. The range defaults to the last 30 days and spans at most 366.
+// @Tags users
+// @Produce text/csv
+// @Param format query string false "Export format" Enums(csv)
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD; defaults to today"
+// @Success 200 {file} file "CSV of daily story metrics"
+// @Failure 400 {object} response.Response "Unsupported format or invalid range"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/stats/export [get]
+func ExportStats(storage storage.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != "csv" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgExportFormatUnsupported)))
+			return
+		}
+
+		from, to, ok := exportRange(query.Get("from"), query.Get("to"), time.Now().UTC())
+		if !ok {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidExportRange)))
+			return
+		}
+
+		// The download starts with the first row, so a query that fails
+		// before then still gets a 500
+		filename := fmt.Sprintf("story-insights-%s-to-%s.csv", from.Format(time.DateOnly), to.Format(time.DateOnly))
+		var stream *response.CSVStream
+		start := func() error {
+			var err error
+			stream, err = response.NewCSVStream(w, filename, exports.Header)
+			return err
+		}
+
+		rows := 0
+		err := storage.StreamDailyStoryMetrics(r.Context(), userID, from, to, func(m users.DailyStoryMetrics) error {
+			if stream == nil {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			rows++
+			return stream.Write(exports.Row(m))
+		})
+		if err == nil && stream == nil {
+			// No activity is a header-only file
+			err = start()
+		}
+		if err == nil {
+			err = stream.Flush()
+		}
+		if err != nil {
+			slog.Error("Failed to export stats", slog.String("error", err.Error()), slog.String("user_id", userID), slog.Int("sent", rows))
+			if stream == nil {
+				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToExportStats)))
+				return
+			}
+			// Abort the response so the client sees a failed download rather
+			// than a truncated file
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// StartStatsExport queues building an insights export in the background
+// @Summary Start a creator insights export
+// @ID startStatsExport
+// @Description Build the CSV of GET /me/stats/export in the background, for ranges too large to download in one request. Poll the export until its status is done, then fetch its download_url. The range defaults to the last 30 days and spans at most 366.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param range body users.StatsExportRequest true "Days to export"
+// @Success 202 {object} response.Response{data=users.StatsExport} "Export queued"
+// @Failure 400 {object} response.Response "Invalid range"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/stats/exports [post]
+func StartStatsExport(queue *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		exportReq, ok := request.DecodeJSON[users.StatsExportRequest](w, r)
+		if !ok {
+			return
+		}
+
+		from, to, ok := exportRange(exportReq.From, exportReq.To, time.Now().UTC())
+		if !ok {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidExportRange)))
+			return
+		}
+
+		payload := exports.StatsPayload{
+			TenantID: tenant.FromContext(r.Context()),
+			UserID:   userID,
+			From:     from.Format(time.DateOnly),
+			To:       to.Format(time.DateOnly),
+		}
+		id, err := queue.Enqueue(r.Context(), exports.JobStats, payload)
+		if err != nil {
+			slog.Error("Failed to queue stats export", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToExportStats)))
+			return
+		}
+
+		job, err := queue.Get(r.Context(), id)
+		if err != nil {
+			slog.Error("Failed to get stats export", slog.String("error", err.Error()), slog.String("export_id", id))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToExportStats)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusAccepted, response.OK("Export queued", statsExport(job, payload)))
+	}
+}
+
+// GetStatsExport returns the state of an insights export, with a link to
+// the file once it is built
+// @Summary Get a creator insights export
+// @ID getStatsExport
+// @Description Get the status of an export started with POST /me/stats/exports. Once done, download_url links to the CSV until expires_at; fetch the export again for a new link. Exports are forgotten some time after they finish.
+// @Tags users
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} response.Response{data=users.StatsExport} "Export status"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Export not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/stats/exports/{id} [get]
+func GetStatsExport(queue *jobs.Queue, media *mediaService.Service, urlTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		// Jobs of other kinds and other users' exports are not found
+		job, err := queue.Get(r.Context(), r.PathValue("id"))
+		var payload exports.StatsPayload
+		if err == nil && (job.Kind != exports.JobStats || json.Unmarshal(job.Payload, &payload) != nil || payload.UserID != userID) {
+			err = jobs.ErrNotFound
+		}
+		if errors.Is(err, jobs.ErrNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgExportNotFound)))
+			return
+		} else if err != nil {
+			slog.Error("Failed to get stats export", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToExportStats)))
+			return
+		}
+
+		export := statsExport(job, payload)
+		if job.Status == jobs.StatusDone {
+			bucket, err := media.ForTenant(payload.TenantID)
+			if err != nil {
+				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+			url, err := bucket.GeneratePresignedDownloadURL(exports.ObjectKey(userID, job.ID), urlTTL)
+			if err != nil {
+				slog.Error("Failed to sign stats export URL", slog.String("error", err.Error()), slog.String("export_id", job.ID))
+				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToExportStats)))
+				return
+			}
+			export.DownloadURL = url.String()
+			export.ExpiresAt = time.Now().Add(urlTTL).Unix()
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Export retrieved successfully", export))
+	}
+}
+
+// statsExport describes the export made by job
+func statsExport(job jobs.Job, payload exports.StatsPayload) users.StatsExport {
+	return users.StatsExport{
+		ID:        job.ID,
+		Status:    job.Status,
+		From:      payload.From,
+		To:        payload.To,
+		CreatedAt: job.CreatedAt.Format(types.TimeLayout),
+	}
+}
+
+// exportRange parses the from and to days of an export, defaulting to the
+// defaultExportDays ending today, and reports whether they form a valid range
+func exportRange(fromParam, toParam string, now time.Time) (from, to time.Time, ok bool) {
+	to = now.Truncate(24 * time.Hour)
+	if toParam != "" {
+		var err error
+		if to, err = time.Parse(time.DateOnly, toParam); err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	from = to.AddDate(0, 0, 1-defaultExportDays)
+	if fromParam != "" {
+		var err error
+		if from, err = time.Parse(time.DateOnly, fromParam); err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	if from.After(to) || to.Sub(from) >= maxExportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
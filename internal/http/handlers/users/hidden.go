@@ -0,0 +1,134 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// HideStories handles hiding an author's stories from the caller's feed
+// @Summary Hide a user's stories
+// @ID hideStories
+// @Description Leave a user's stories out of your feed, trays and feed changes without unfollowing them, so they are not told. Their stories can still be opened directly. Hiding them again is a no-op.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "User ID to hide"
+// @Success 200 {object} response.Response "Stories hidden successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /users/{id}/hide-stories [post]
+func HideStories(store storage.GraphStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		authorID := r.PathValue("id")
+		if authorID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+		if authorID == userID {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgCannotHideSelf)))
+			return
+		}
+
+		err := store.HideStories(userID, authorID)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to hide stories", slog.String("error", err.Error()), slog.String("user_id", userID), slog.String("author_id", authorID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToHideStories)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Stories hidden successfully", nil))
+	}
+}
+
+// UnhideStories handles bringing a hidden author's stories back
+// @Summary Unhide a user's stories
+// @ID unhideStories
+// @Description Bring a user's stories whom you hid back into your feed.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "User ID to unhide"
+// @Success 200 {object} response.Response "Stories unhidden successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User's stories are not hidden"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /users/{id}/hide-stories [delete]
+func UnhideStories(store storage.GraphStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		authorID := r.PathValue("id")
+		if authorID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		err := store.UnhideStories(userID, authorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAuthorNotHidden)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to unhide stories", slog.String("error", err.Error()), slog.String("user_id", userID), slog.String("author_id", authorID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUnhideStories)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Stories unhidden successfully", nil))
+	}
+}
+
+// ListHiddenAuthors handles listing the authors the caller hid
+// @Summary List hidden authors
+// @ID listHiddenAuthors
+// @Description List the IDs of the users whose stories you hid from your feed, latest first.
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=[]string} "Hidden authors fetched successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/hidden-authors [get]
+func ListHiddenAuthors(store storage.GraphStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		authorIDs, err := store.GetHiddenAuthors(userID)
+		if err != nil {
+			slog.Error("Failed to get hidden authors", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetHiddenAuthors)))
+			return
+		}
+		if authorIDs == nil {
+			authorIDs = []string{}
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Hidden authors fetched successfully", authorIDs))
+	}
+}
@@ -0,0 +1,96 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// SetPublicKey publishes the caller's public key for encrypted stories
+// @Summary Publish my public key
+// @ID setPublicKey
+// @Description Publish or replace the public key authors wrap the content keys of encrypted stories with. The service stores it as sent; keys are generated and kept on the device, and stories encrypted for a replaced key can only be read with the old private key.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param key body users.PublicKeyRequest true "Public key"
+// @Success 200 {object} response.Response{data=users.PublicKey} "Public key published"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/public-key [put]
+func SetPublicKey(store storage.EncryptionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[users.PublicKeyRequest](w, r)
+		if !ok {
+			return
+		}
+
+		key, err := store.SetPublicKey(userID, req)
+		if err != nil {
+			slog.Error("Failed to publish public key", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToSetPublicKey)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Public key published", key))
+	}
+}
+
+// GetPublicKey returns a user's public key
+// @Summary Get a user's public key
+// @ID getPublicKey
+// @Description Get the public key a user in your tenant published, to wrap the content key of an encrypted story for them
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} response.Response{data=users.PublicKey} "Public key"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "No public key published in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /users/{user_id}/public-key [get]
+func GetPublicKey(store storage.EncryptionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		userID := r.PathValue("user_id")
+		if userID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		key, err := store.GetPublicKey(viewerID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgPublicKeyNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get public key", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetPublicKey)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Public key retrieved successfully", key))
+	}
+}
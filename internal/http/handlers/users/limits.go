@@ -0,0 +1,41 @@
+package users
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// GetLimits returns how much of each rate limit the authenticated user has left
+// @Summary Get my rate limits
+// @ID getMyLimits
+// @Description Get every rate limit the service enforces with how many actions you have left, so clients can throttle themselves before being turned away with 429. Limits counted per IP are reported for the IP of this request. Rate-limited routes also send X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the full limit is back) on every response.
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=[]users.RateLimit} "Rate limits, by action"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/limits [get]
+func GetLimits(rateLimits *middleware.RateLimitConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limits, err := rateLimits.Limits(r.Context(), userID, session.ClientIP(r))
+		if err != nil {
+			slog.Error("Failed to get rate limits", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetLimits)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Rate limits retrieved successfully", limits))
+	}
+}
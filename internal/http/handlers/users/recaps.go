@@ -0,0 +1,55 @@
+package users
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/recap"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// GetWeeklyRecaps lists the caller's weekly recaps, newest first
+// @Summary List weekly recaps
+// @ID getWeeklyRecaps
+// @Description List your recaps of past weeks, newest first. A recap is made for each week, Monday to Sunday UTC, in which you posted a story, once the week is over. It holds the stories you posted, their views and unique viewers, your top story, and your followers at the end of the week with the change since your previous recap.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of recaps, 1 to 52" default(12)
+// @Success 200 {object} response.Response{data=[]users.WeeklyRecap} "Weekly recaps retrieved successfully"
+// @Failure 400 {object} response.Response "Invalid limit"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /me/recaps [get]
+func GetWeeklyRecaps(store storage.RecapStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		limit := recap.DefaultListLimit
+		if param := r.URL.Query().Get("limit"); param != "" {
+			var err error
+			limit, err = strconv.Atoi(param)
+			if err != nil || limit < 1 || limit > recap.MaxListLimit {
+				response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidRecapLimit)))
+				return
+			}
+		}
+
+		recaps, err := store.GetWeeklyRecaps(r.Context(), userID, limit)
+		if err != nil {
+			slog.Error("Failed to get weekly recaps", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetRecaps)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Weekly recaps retrieved successfully", recaps))
+	}
+}
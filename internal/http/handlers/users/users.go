@@ -1,211 +1,943 @@
 package users
 
 import (
-	"encoding/json"
+	"database/sql"
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/princekumarofficial/stories-service/internal/apitoken"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/emailnotify"
+	"github.com/princekumarofficial/stories-service/internal/events"
 	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/session"
 	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
 	"github.com/princekumarofficial/stories-service/internal/types/users"
 	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
 	"github.com/princekumarofficial/stories-service/internal/utils/password"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 // SignUp handles user registration
 // @Summary Register a new user
+// @ID signUp
 // @Description Register a new user account
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param X-Tenant-ID header string false "Tenant to register in (defaults to the default tenant)"
 // @Param user body users.SignUpRequest true "User registration details"
-// @Success 201 {object} map[string]string "User created successfully"
+// @Success 201 {object} response.Response{data=map[string]string} "User created successfully"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /signup [post]
-func SignUp(storage storage.Storage) http.HandlerFunc {
+func SignUp(storage storage.UserStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var signupReq users.SignUpRequest
-
-		err := json.NewDecoder(r.Body).Decode(&signupReq)
+		tenantID, err := tenant.FromRequest(r)
 		if err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidTenant)))
 			return
 		}
 
-		// Validate request
-		validate := validator.New()
-		err = validate.Struct(signupReq)
-		if err != nil {
-			if ve, ok := err.(validator.ValidationErrors); ok {
-				response.WriteJSON(w, http.StatusBadRequest, response.ValidationError(ve))
-				return
-			}
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		signupReq, ok := request.DecodeJSON[users.SignUpRequest](w, r)
+		if !ok {
 			return
 		}
 
 		hashedPassword, err := password.HashPassword(signupReq.Password)
 		if err != nil {
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to hash password")))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToHashPassword)))
 			return
 		}
 
-		userID, err := storage.CreateUser(signupReq.Email, hashedPassword)
+		userID, err := storage.CreateUser(tenantID, signupReq.Email, hashedPassword)
 		if err != nil {
 			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 		slog.Info("User created with ID:", slog.String("user_id", userID))
 
-		response.WriteJSON(w, http.StatusCreated, map[string]string{
+		response.WriteJSON(w, http.StatusCreated, response.OK("User created successfully", map[string]string{
 			"id": userID,
-		})
+		}))
 	}
 }
 
 // Login handles user authentication
 // @Summary Authenticate a user
-// @Description Authenticate a user and return JWT token
+// @ID login
+// @Description Authenticate a user and return a JWT bearer token with its expiry and the user's profile. The client platform, version and device ID, from the body or the X-Client-* and X-Device-ID headers, are stored with the session and counted in GET /admin/stats/clients.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param X-Tenant-ID header string false "Tenant to log in to (defaults to the default tenant)"
+// @Param X-Client-Platform header string false "Platform of the app logging in, when not sent in the body"
+// @Param X-Client-Version header string false "Version of the app logging in, when not sent in the body"
+// @Param X-Device-ID header string false "Stable identifier of the device, when not sent in the body"
 // @Param user body users.SignInRequest true "User login details"
-// @Success 200 {object} map[string]string "User authenticated successfully with token"
+// @Success 200 {object} response.Response{data=users.LoginResponse} "User authenticated successfully with token"
 // @Failure 400 {object} response.Response "Bad request"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Router /login [post]
-func Login(storage storage.Storage, JWTSecret string) http.HandlerFunc {
+func Login(storage storage.UserStore, tokens jwt.Options, sessions *session.Store, warmer *cache.Warmer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var signinReq users.SignInRequest
-
-		err := json.NewDecoder(r.Body).Decode(&signinReq)
+		tenantID, err := tenant.FromRequest(r)
 		if err != nil {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidTenant)))
 			return
 		}
 
-		// Validate request
-		validate := validator.New()
-		err = validate.Struct(signinReq)
-		if err != nil {
-			if ve, ok := err.(validator.ValidationErrors); ok {
-				response.WriteJSON(w, http.StatusBadRequest, response.ValidationError(ve))
-				return
-			}
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		signinReq, ok := request.DecodeJSON[users.SignInRequest](w, r)
+		if !ok {
 			return
 		}
 
 		// Authentication logic
-		userID, hashedPassword, err := storage.GetUserByEmail(signinReq.Email)
+		userID, hashedPassword, err := storage.GetUserByEmail(tenantID, signinReq.Email)
 		if err != nil {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("invalid email or password")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidCredentials)))
 			return
 		}
 
 		correctPassword := password.CheckPasswordHash(signinReq.Password, hashedPassword)
 		if !correctPassword {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("invalid email or password")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidCredentials)))
+			return
+		}
+		user, err := storage.GetUserByID(userID)
+		if err != nil {
+			slog.Error("Failed to get user", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetProfile)))
+			return
+		}
+
+		scopes := jwt.UserScopes
+		if user.IsAdmin {
+			scopes = append(slices.Clip(scopes), jwt.ScopeAdmin)
+		}
+		token, claims, err := jwt.CreateToken(userID, tenantID, scopes, tokens)
+		if err != nil {
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGenerateToken)))
+			return
+		}
+
+		// Fields in the body take precedence over the client headers
+		client := session.ClientFromHeaders(r)
+		if signinReq.DeviceName != "" {
+			client.DeviceName = signinReq.DeviceName
+		}
+		if signinReq.Platform != "" {
+			client.Platform = signinReq.Platform
+		}
+		if signinReq.ClientVersion != "" {
+			client.ClientVersion = signinReq.ClientVersion
+		}
+		if signinReq.DeviceID != "" {
+			client.DeviceID = signinReq.DeviceID
+		}
+		if err := sessions.Create(r.Context(), claims, client, session.ClientIP(r)); err != nil {
+			slog.Error("Failed to create session", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToCreateSession)))
+			return
+		}
+
+		// The app loads the feed right after logging in
+		warmer.Warm(userID)
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Logged in successfully", users.LoginResponse{
+			UserID:    userID,
+			Token:     token,
+			TokenType: "Bearer",
+			ExpiresAt: types.FormatTime(claims.ExpiresAt),
+			User:      user,
+		}))
+	}
+}
+
+// GetMe returns the authenticated user's profile
+// @Summary Get my profile
+// @ID getMe
+// @Description Get the authenticated user's account together with their follower, following and active story counts
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=users.Profile} "User profile"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me [get]
+func GetMe(store storage.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		profile, err := store.GetUserProfile(userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to get user profile", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetProfile)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Profile retrieved successfully", profile))
+	}
+}
+
+// GetUser returns another user's public profile
+// @Summary Get a user's profile
+// @ID getUser
+// @Description Get a user's public profile in your tenant: follower and following counts, active public story count, and whether you follow them and they follow you
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=users.PublicProfile} "User profile"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found in your tenant"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /users/{id} [get]
+func GetUser(store storage.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		userID := r.PathValue("id")
+		if userID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		profile, err := store.GetPublicProfile(viewerID, userID)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
 			return
 		}
-		token, err := jwt.CreateToken(userID, JWTSecret)
 		if err != nil {
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to generate token")))
+			slog.Error("Failed to get public profile", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetProfile)))
 			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, map[string]string{
-			"user_id": userID,
-			"token":   token,
-		})
+		response.WriteJSON(w, http.StatusOK, response.OK("Profile retrieved successfully", profile))
+	}
+}
+
+// SetAvatar sets or removes the authenticated user's avatar
+// @Summary Set my avatar
+// @ID setAvatar
+// @Description Set the http(s) URL of the picture shown as avatar_url on your profile, feed stories, trays, viewer lists and contact discovery, or send an empty avatar_url to remove it. The service stores the URL as sent; feeds and trays already cached keep the old avatar until they expire.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param avatar body users.AvatarRequest true "Avatar"
+// @Success 200 {object} response.Response{data=users.AvatarRequest} "Avatar updated"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/avatar [put]
+func SetAvatar(store storage.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[users.AvatarRequest](w, r)
+		if !ok {
+			return
+		}
+
+		err := store.SetAvatarURL(userID, req.AvatarURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to set avatar", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToSetAvatar)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Avatar updated", req))
+	}
+}
+
+// ListSessions lists the devices the authenticated user is logged in on
+// @Summary List my sessions
+// @ID listSessions
+// @Description List the sessions (one per login) whose tokens are still valid, with device name, IP and when each was last seen. The session of the calling token is marked current.
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=[]session.Session} "Active sessions, most recently seen first"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/sessions [get]
+func ListSessions(sessions *session.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		list, err := sessions.List(r.Context(), userID, middleware.GetSessionIDFromContext(r.Context()))
+		if err != nil {
+			slog.Error("Failed to list sessions", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToListSessions)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Sessions retrieved successfully", list))
+	}
+}
+
+// RevokeSession logs the authenticated user out of one device
+// @Summary Revoke a session
+// @ID revokeSession
+// @Description Revoke one of the authenticated user's sessions; its token is rejected from then on. Revoking the current session logs the caller out.
+// @Tags users
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response "Session revoked successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Session not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/sessions/{id} [delete]
+func RevokeSession(sessions *session.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		sessionID := r.PathValue("id")
+		err := sessions.Revoke(r.Context(), userID, sessionID)
+		if errors.Is(err, session.ErrNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgSessionNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to revoke session", slog.String("error", err.Error()), slog.String("user_id", userID), slog.String("session_id", sessionID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRevokeSession)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Session revoked successfully", nil))
+	}
+}
+
+// CreateAPIToken mints an API token for the authenticated user
+// @Summary Create an API token
+// @ID createAPIToken
+// @Description Create a long-lived, scoped token for bots and integrations, sent as a bearer token like a JWT. Tokens with the read scope may make GET requests and those with the post scope may create stories and upload their media. The token is only returned in this response.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body users.APITokenRequest true "Token name, scopes and lifetime"
+// @Success 201 {object} response.Response{data=users.APIToken} "API token created successfully"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens [post]
+func CreateAPIToken(storage storage.APITokenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		tokenReq, ok := request.DecodeJSON[users.APITokenRequest](w, r)
+		if !ok {
+			return
+		}
+
+		secret, hash, err := apitoken.Generate()
+		if err != nil {
+			slog.Error("Failed to generate API token", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToCreateAPIToken)))
+			return
+		}
+
+		validFor := time.Duration(tokenReq.ExpiresInDays) * 24 * time.Hour
+		token, err := storage.CreateAPIToken(userID, tokenReq.Name, hash, tokenReq.Scopes, validFor)
+		if err != nil {
+			slog.Error("Failed to create API token", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToCreateAPIToken)))
+			return
+		}
+		token.Token = secret
+
+		response.WriteJSON(w, http.StatusCreated, response.OK("API token created successfully", token))
+	}
+}
+
+// ListAPITokens lists the authenticated user's API tokens
+// @Summary List my API tokens
+// @ID listAPITokens
+// @Description List the API tokens the authenticated user has not revoked, newest first. The tokens themselves are not returned.
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=[]users.APIToken} "API tokens, newest first"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens [get]
+func ListAPITokens(storage storage.APITokenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		tokens, err := storage.GetAPITokens(userID)
+		if err != nil {
+			slog.Error("Failed to list API tokens", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToListAPITokens)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("API tokens retrieved successfully", tokens))
+	}
+}
+
+// RevokeAPIToken revokes one of the authenticated user's API tokens
+// @Summary Revoke an API token
+// @ID revokeAPIToken
+// @Description Revoke one of the authenticated user's API tokens; it is rejected from then on.
+// @Tags users
+// @Produce json
+// @Param id path string true "API token ID"
+// @Success 200 {object} response.Response "API token revoked successfully"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "API token not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens/{id} [delete]
+func RevokeAPIToken(storage storage.APITokenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		tokenID := r.PathValue("id")
+		err := storage.RevokeAPIToken(userID, tokenID)
+		if errors.Is(err, sql.ErrNoRows) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgAPITokenNotFound)))
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to revoke API token", slog.String("error", err.Error()), slog.String("user_id", userID), slog.String("token_id", tokenID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToRevokeAPIToken)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("API token revoked successfully", nil))
 	}
 }
 
 // GetStats returns user statistics for the last 7 days
 // @Summary Get user statistics
-// @Description Get user statistics including posts, views, unique viewers, and reaction breakdown for the last 7 days
+// @ID getStats
+// @Description Get user statistics including posts, views, unique viewers, link clicks, and reaction breakdown for the last 7 days
 // @Tags users
 // @Produce json
-// @Success 200 {object} users.UserStats "User statistics"
+// @Success 200 {object} response.Response{data=users.UserStats} "User statistics"
 // @Failure 401 {object} response.Response "Unauthorized"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Security BearerAuth
 // @Router /me/stats [get]
-func GetStats(storage storage.Storage) http.HandlerFunc {
+func GetStats(storage storage.UserStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get user ID from context (set by auth middleware)
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("unauthorized")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		// Get user stats from storage
-		posted, views, uniqueViewers, reactionCounts, err := storage.GetUserStats(userID)
+		stats, err := storage.GetUserStats(userID)
 		if err != nil {
 			slog.Error("Failed to get user stats", slog.String("error", err.Error()), slog.String("user_id", userID))
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to get user stats")))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetUserStats)))
 			return
 		}
 
-		// Create response
-		stats := users.UserStats{
-			Posted:         posted,
-			Views:          views,
-			UniqueViewers:  uniqueViewers,
-			ReactionCounts: reactionCounts,
+		response.WriteJSON(w, http.StatusOK, response.OK("User stats retrieved successfully", stats))
+	}
+}
+
+// GetPrivacySettings returns the user's privacy settings
+// @Summary Get privacy settings
+// @ID getPrivacySettings
+// @Description Get whether the user hides their view receipts and is hidden from contact discovery
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=users.PrivacySettings} "Privacy settings"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/privacy-settings [get]
+func GetPrivacySettings(storage storage.PrivacyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		settings, err := storage.GetPrivacySettings(userID)
+		if err != nil {
+			slog.Error("Failed to get privacy settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetPrivacySettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Privacy settings retrieved", settings))
+	}
+}
+
+// UpdatePrivacySettings replaces the user's privacy settings
+// @Summary Update privacy settings
+// @ID updatePrivacySettings
+// @Description With hide_view_receipts on, the user's views still count in authors' stats, but they are left out of viewer lists and authors get no story.viewed event for them. With hide_from_discovery on, contact discovery never matches the user.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param settings body users.PrivacySettings true "Privacy settings"
+// @Success 200 {object} response.Response "Privacy settings updated"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/privacy-settings [put]
+func UpdatePrivacySettings(storage storage.PrivacyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		settings, ok := request.DecodeJSON[users.PrivacySettings](w, r)
+		if !ok {
+			return
+		}
+
+		if err := storage.SetPrivacySettings(userID, settings); err != nil {
+			slog.Error("Failed to update privacy settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdatePrivacySettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Privacy settings updated", settings))
+	}
+}
+
+// DiscoverUsers matches the caller's hashed contacts against registered users
+// @Summary Find contacts who use the service
+// @ID discoverUsers
+// @Description Match up to 500 contacts, each sent as the hex SHA-256 of the email address trimmed and lowercased, against users of the caller's tenant, so clients can suggest who to follow without uploading raw contacts. Users who set hide_from_discovery are never matched, and neither is the caller.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body users.DiscoverRequest true "Hashed contacts"
+// @Success 200 {object} response.Response{data=[]users.DiscoveredUser} "Matching users"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 429 {object} response.Response "Too many requests"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /users/discover [post]
+func DiscoverUsers(storage storage.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[users.DiscoverRequest](w, r)
+		if !ok {
+			return
+		}
+
+		// Stored hashes are lowercase hex
+		hashes := make([]string, len(req.Hashes))
+		for i, hash := range req.Hashes {
+			hashes[i] = strings.ToLower(hash)
+		}
+
+		discovered, err := storage.DiscoverUsers(userID, hashes)
+		if err != nil {
+			slog.Error("Failed to discover users", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToDiscoverUsers)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Users discovered", discovered))
+	}
+}
+
+// GetNotificationSettings returns the user's notification settings
+// @Summary Get notification settings
+// @ID getNotificationSettings
+// @Description Get the user's quiet hours, during which view and reaction notifications are held back for a daily digest, and which emails they opted in to
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.Response{data=users.NotificationSettings} "Notification settings"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/notification-settings [get]
+func GetNotificationSettings(storage storage.NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		settings, err := storage.GetNotificationSettings(userID)
+		if err != nil {
+			slog.Error("Failed to get notification settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToGetNotificationSettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Notification settings retrieved", settings))
+	}
+}
+
+// UpdateNotificationSettings replaces the user's notification settings
+// @Summary Update notification settings
+// @ID updateNotificationSettings
+// @Description Set quiet hours as HH:MM local times in an IANA time zone; a start after the end spans midnight, and empty times disable quiet hours. Notifications queued during quiet hours are sent as a digest once they end. New follower and weekly stats emails are opt-in.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param settings body users.NotificationSettings true "Notification settings"
+// @Success 200 {object} response.Response "Notification settings updated"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /me/notification-settings [put]
+func UpdateNotificationSettings(storage storage.NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		settings, ok := request.DecodeJSON[users.NotificationSettings](w, r)
+		if !ok {
+			return
+		}
+
+		if err := storage.SetNotificationSettings(userID, settings); err != nil {
+			slog.Error("Failed to update notification settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateNotificationSettings)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Notification settings updated", settings))
+	}
+}
+
+// UnsubscribePage asks to confirm turning off an email notification from the
+// signed link in the email. It changes nothing, so mail scanners and link
+// previews that fetch the link cannot unsubscribe anyone.
+// @Summary Confirm unsubscribing from an email notification
+// @ID unsubscribePage
+// @Description HTML page opened by the signed link included in every notification email, asking to confirm with a POST to the same link. Opening it changes nothing.
+// @Tags users
+// @Produce html
+// @Param user query string true "User ID"
+// @Param kind query string true "Email kind" Enums(new_followers, weekly_stats, weekly_recap)
+// @Param token query string true "Signature from the email link"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {string} string "Invalid unsubscribe link"
+// @Router /unsubscribe [get]
+func UnsubscribePage(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, kind, ok := unsubscribeLink(r, secret)
+		if !ok {
+			renderUnsubscribePage(w, http.StatusBadRequest, emailnotify.UnsubscribeInvalid, kind, "")
+			return
 		}
 
-		response.WriteJSON(w, http.StatusOK, stats)
+		renderUnsubscribePage(w, http.StatusOK, emailnotify.UnsubscribeConfirm, kind, r.URL.RequestURI())
+	}
+}
+
+// Unsubscribe turns off an email notification from the signed link in the email
+// @Summary Unsubscribe from an email notification
+// @ID unsubscribe
+// @Description Turn off an email notification using the signed link included in every notification email. Mail clients post here directly for one-click unsubscribes (RFC 8058, with a List-Unsubscribe=One-Click body); the page the link opens posts here once confirmed. Browsers asking for HTML get a page saying it is done.
+// @Tags users
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param user query string true "User ID"
+// @Param kind query string true "Email kind" Enums(new_followers, weekly_stats, weekly_recap)
+// @Param token query string true "Signature from the email link"
+// @Success 200 {object} response.Response "Unsubscribed"
+// @Failure 400 {object} response.Response "Invalid unsubscribe link"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /unsubscribe [post]
+func Unsubscribe(storage storage.NotificationStore, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, kind, ok := unsubscribeLink(r, secret)
+		if !ok {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidUnsubscribeLink)))
+			return
+		}
+
+		settings, err := storage.GetNotificationSettings(userID)
+		if err != nil {
+			slog.Error("Failed to get notification settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateNotificationSettings)))
+			return
+		}
+
+		if !kind.Unsubscribe(&settings) {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidUnsubscribeLink)))
+			return
+		}
+
+		if err := storage.SetNotificationSettings(userID, settings); err != nil {
+			slog.Error("Failed to update notification settings", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUpdateNotificationSettings)))
+			return
+		}
+
+		// Confirmations from the unsubscribe page come from a browser
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			renderUnsubscribePage(w, http.StatusOK, emailnotify.UnsubscribeDone, kind, "")
+			return
+		}
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Unsubscribed successfully", nil))
+	}
+}
+
+// unsubscribeLink returns the user and email kind of a signed unsubscribe
+// link, reporting false if it is not valid
+func unsubscribeLink(r *http.Request, secret string) (string, emailnotify.Kind, bool) {
+	query := r.URL.Query()
+	userID := query.Get("user")
+	kind := emailnotify.Kind(query.Get("kind"))
+	if userID == "" || !emailnotify.ValidUnsubscribeToken(secret, userID, kind, query.Get("token")) {
+		return "", kind, false
+	}
+	return userID, kind, true
+}
+
+// renderUnsubscribePage writes the unsubscribe page with the given status
+func renderUnsubscribePage(w http.ResponseWriter, status int, state emailnotify.UnsubscribeState, kind emailnotify.Kind, action string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := emailnotify.RenderUnsubscribePage(w, state, kind, action); err != nil {
+		slog.Error("Failed to render unsubscribe page", slog.String("error", err.Error()))
 	}
 }
 
 // FollowUser handles following a user
 // @Summary Follow a user
-// @Description Follow another user to see their FRIENDS visibility stories
+// @ID followUser
+// @Description Follow another user to see their FOLLOWERS visibility stories, and their FRIENDS stories once they follow back. They receive a user.followed event unless you already followed them, in which case nothing changes. You cannot follow yourself.
 // @Tags users
 // @Security BearerAuth
 // @Param user_id path string true "User ID to follow"
 // @Success 200 {object} response.Response "User followed successfully"
-// @Failure 400 {object} response.Response "Bad request"
+// @Failure 400 {object} response.Response "Bad request, or following yourself"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found in your tenant"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /follow/{user_id} [post]
-func FollowUser(storage storage.Storage) http.HandlerFunc {
+func FollowUser(store storage.GraphStore, eventPublisher *events.EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context (the follower)
 		followerID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		// Get the user ID to follow from path
 		followedID := r.PathValue("user_id")
 		if followedID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("user_id is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
 			return
 		}
 
-		// Follow the user
-		err := storage.FollowUser(followerID, followedID)
+		// Follow the user; following again is a no-op
+		followed, err := store.FollowUser(followerID, followedID)
+		if errors.Is(err, storage.ErrSelfFollow) {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgCannotFollowSelf)))
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+			return
+		}
 		if err != nil {
 			slog.Error("Failed to follow user", slog.String("error", err.Error()), slog.String("follower_id", followerID), slog.String("followed_id", followedID))
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to follow user")))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToFollowUser)))
 			return
 		}
 
+		// A follow that already existed must not be announced again
+		if followed {
+			// Publish real-time event (fire and forget)
+			go func() {
+				if err := eventPublisher.PublishUserFollowed(followerID, followedID); err != nil {
+					slog.Error("Failed to publish user followed event", slog.String("error", err.Error()))
+				}
+			}()
+		}
+
 		response.WriteJSON(w, http.StatusOK, response.RequestOK("User followed successfully", nil))
 	}
 }
 
+// FollowUsers handles following several users at once
+// @Summary Follow several users
+// @ID followUsers
+// @Description Follow up to 100 users at once, such as the matches of a contact import, reporting the outcome for each distinct ID in request order. Users gaining a follower receive a single user.followed event without followed_id.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body types.FollowBatchRequest true "Users to follow"
+// @Success 200 {object} response.Response{data=[]types.FollowResult} "Outcome for each user"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /follow/batch [post]
+func FollowUsers(store storage.GraphStore, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		followerID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.FollowBatchRequest](w, r)
+		if !ok {
+			return
+		}
+
+		results, err := store.FollowUsers(followerID, req.UserIDs)
+		if err != nil {
+			slog.Error("Failed to follow users", slog.String("error", err.Error()), slog.String("follower_id", followerID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToFollowUser)))
+			return
+		}
+
+		// One event for every new follow; existing ones are not announced again
+		if followed := resultsWith(results, types.FollowFollowed); len(followed) > 0 {
+			go func() {
+				if err := eventPublisher.PublishUsersFollowed(followerID, followed); err != nil {
+					slog.Error("Failed to publish users followed event", slog.String("error", err.Error()))
+				}
+			}()
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Users followed successfully", results))
+	}
+}
+
+// UnfollowUsers handles unfollowing several users at once
+// @Summary Unfollow several users
+// @ID unfollowUsers
+// @Description Unfollow up to 100 users at once, reporting the outcome for each distinct ID in request order. Users losing a follower receive a single user.unfollowed event without followed_id.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body types.FollowBatchRequest true "Users to unfollow"
+// @Success 200 {object} response.Response{data=[]types.FollowResult} "Outcome for each user"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /follow/batch [delete]
+func UnfollowUsers(store storage.GraphStore, eventPublisher *events.EventPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		followerID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		req, ok := request.DecodeJSON[types.FollowBatchRequest](w, r)
+		if !ok {
+			return
+		}
+
+		results, err := store.UnfollowUsers(followerID, req.UserIDs)
+		if err != nil {
+			slog.Error("Failed to unfollow users", slog.String("error", err.Error()), slog.String("follower_id", followerID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUnfollowUser)))
+			return
+		}
+
+		if unfollowed := resultsWith(results, types.FollowUnfollowed); len(unfollowed) > 0 {
+			go func() {
+				if err := eventPublisher.PublishUsersUnfollowed(followerID, unfollowed); err != nil {
+					slog.Error("Failed to publish users unfollowed event", slog.String("error", err.Error()))
+				}
+			}()
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Users unfollowed successfully", results))
+	}
+}
+
+// resultsWith returns the IDs of the users whose batch result has status
+func resultsWith(results []types.FollowResult, status string) []string {
+	var userIDs []string
+	for _, result := range results {
+		if result.Status == status {
+			userIDs = append(userIDs, result.UserID)
+		}
+	}
+	return userIDs
+}
+
 // UnfollowUser handles unfollowing a user
 // @Summary Unfollow a user
-// @Description Unfollow a user to stop seeing their FRIENDS visibility stories
+// @ID unfollowUser
+// @Description Unfollow a user to stop seeing their FOLLOWERS and FRIENDS visibility stories. They receive a user.unfollowed event.
 // @Tags users
 // @Security BearerAuth
 // @Param user_id path string true "User ID to unfollow"
@@ -215,19 +947,19 @@ func FollowUser(storage storage.Storage) http.HandlerFunc {
 // @Failure 404 {object} response.Response "Follow relationship not found"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /follow/{user_id} [delete]
-func UnfollowUser(storage storage.Storage) http.HandlerFunc {
+func UnfollowUser(storage storage.GraphStore, eventPublisher *events.EventPublisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract user ID from context (the follower)
 		followerID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok {
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("user not authenticated")))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
 		// Get the user ID to unfollow from path
 		followedID := r.PathValue("user_id")
 		if followedID == "" {
-			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(errors.New("user_id is required")))
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
 			return
 		}
 
@@ -235,14 +967,21 @@ func UnfollowUser(storage storage.Storage) http.HandlerFunc {
 		err := storage.UnfollowUser(followerID, followedID)
 		if err != nil {
 			if err.Error() == "follow relationship not found" {
-				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(errors.New("follow relationship not found")))
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFollowNotFound)))
 				return
 			}
 			slog.Error("Failed to unfollow user", slog.String("error", err.Error()), slog.String("follower_id", followerID), slog.String("followed_id", followedID))
-			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(errors.New("failed to unfollow user")))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToUnfollowUser)))
 			return
 		}
 
+		// Publish real-time event (fire and forget)
+		go func() {
+			if err := eventPublisher.PublishUserUnfollowed(followerID, followedID); err != nil {
+				slog.Error("Failed to publish user unfollowed event", slog.String("error", err.Error()))
+			}
+		}()
+
 		response.WriteJSON(w, http.StatusOK, response.RequestOK("User unfollowed successfully", nil))
 	}
 }
@@ -4,16 +4,30 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/ratelimit"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 	wsClient "github.com/princekumarofficial/stories-service/internal/websocket"
+	"github.com/princekumarofficial/stories-service/internal/wsticket"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Negotiate permessage-deflate with clients that support it
+	EnableCompression: true,
+	// Clients pick the event encoding via Sec-WebSocket-Protocol
+	Subprotocols: wsClient.Subprotocols,
 	CheckOrigin: func(r *http.Request) bool {
 		// Allow connections from any origin for development
 		// In production, you should check the origin properly
@@ -21,39 +35,207 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// WebSocketHandler handles WebSocket connections
-func WebSocketHandler(hub *wsClient.Hub, jwtSecret string) http.HandlerFunc {
+// IssueTicket issues a one-time ticket for opening a WebSocket connection
+// @Summary Issue WebSocket ticket
+// @ID issueTicket
+// @Description Issue a short-lived, single-use ticket to pass as ?ticket= when connecting to /ws, so the JWT never appears in a URL
+// @Tags websocket
+// @Produce json
+// @Success 200 {object} response.Response{data=wsticket.Ticket} "Connection ticket"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /ws/ticket [post]
+func IssueTicket(issuer *wsticket.Issuer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Get JWT token from query parameter
-		token := r.URL.Query().Get("token")
-		if token == "" {
-			slog.Warn("WebSocket connection attempted without token")
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("token required")))
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
 			return
 		}
 
-		// Validate JWT token and extract user ID
-		userID, err := jwt.ExtractUserIDFromToken(token, jwtSecret)
+		ticket, err := issuer.Issue(r.Context(), userID, tenant.FromContext(r.Context()))
 		if err != nil {
-			slog.Warn("WebSocket connection attempted with invalid token", slog.String("error", err.Error()))
-			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(errors.New("invalid token")))
+			slog.Error("Failed to issue WebSocket ticket", slog.String("error", err.Error()), slog.String("user_id", userID))
+			response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgFailedToIssueTicket)))
+			return
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Ticket issued", ticket))
+	}
+}
+
+// closeWait bounds how long sending the close frame to a rejected connection may take
+const closeWait = time.Second
+
+// WebSocketHandler handles WebSocket connections. When connects is set, each
+// IP may only open as many connections a minute as it allows. Admins may pass
+// ?topic=ops to also receive ops.metrics snapshots, while ops is set. Users
+// connecting after their quiet hours ended are sent their digest by digests.
+func WebSocketHandler(hub *wsClient.Hub, issuer *wsticket.Issuer, warmer *cache.Warmer, connects *ratelimit.TokenBucket, users storage.UserStore, ops *wsClient.OpsReporter, digests *events.Digester) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		remoteIP := session.ClientIP(r)
+
+		// Turn away connection floods before doing any other work
+		var responseHeader http.Header
+		if connects != nil {
+			allowed, err := connects.Allow(r.Context(), remoteIP, "ws_connect")
+			if err != nil {
+				// Failing open keeps clients connected while Redis is down
+				slog.Warn("WebSocket connect rate check failed", slog.String("error", err.Error()))
+			} else if !allowed {
+				slog.Warn("WebSocket connection rate limited", slog.String("remote_ip", remoteIP))
+				metrics.RateLimited("ws_connect")
+				reject(w, r, wsClient.CloseRateLimited, "too many connection attempts")
+				return
+			} else if status, err := connects.Status(r.Context(), remoteIP, "ws_connect"); err == nil {
+				responseHeader = http.Header{}
+				middleware.SetRateLimitHeaders(responseHeader, status)
+			}
+		}
+
+		// Get connection ticket from query parameter
+		ticket := r.URL.Query().Get("ticket")
+		if ticket == "" {
+			slog.Warn("WebSocket connection attempted without ticket")
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgTicketNotProvided)))
+			return
+		}
+
+		// Redeem the ticket; it cannot be used again afterwards
+		userID, tenantID, err := issuer.Redeem(r.Context(), ticket)
+		if err != nil {
+			slog.Warn("WebSocket connection attempted with invalid ticket", slog.String("error", err.Error()))
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInvalidTicket)))
+			return
+		}
+
+		topic := r.URL.Query().Get("topic")
+		if topic != "" && !allowTopic(w, r, users, ops, topic, userID) {
 			return
 		}
 
 		// Upgrade connection to WebSocket
-		conn, err := upgrader.Upgrade(w, r, nil)
+		conn, err := upgrader.Upgrade(w, r, responseHeader)
 		if err != nil {
 			slog.Error("Failed to upgrade WebSocket connection", slog.String("error", err.Error()))
 			return
 		}
 
 		// Create new client and register with hub
-		client := wsClient.NewClient(conn, userID, hub)
-		hub.RegisterClient(client)
+		client := wsClient.NewClient(conn, userID, remoteIP, hub).WithTenant(tenantID).WithTopic(topic)
+		if err := hub.RegisterClient(client); err != nil {
+			code := websocket.CloseGoingAway
+			if errors.Is(err, wsClient.ErrTooManyUserConnections) || errors.Is(err, wsClient.ErrTooManyIPConnections) {
+				code = wsClient.CloseTooManyConnections
+			}
+			closeWith(conn, code, err.Error())
+			return
+		}
 
 		// Start client goroutines
 		client.Start()
 
+		// Opening the app connects, so the feed is usually requested next
+		warmer.Warm(userID)
+
+		// Notifications queued while the user was offline stay queued until now
+		go func() {
+			if err := digests.Send(userID); err != nil {
+				slog.Error("Failed to send notification digest", slog.String("error", err.Error()), slog.String("user_id", userID))
+			}
+		}()
+
 		slog.Info("WebSocket connection established", slog.String("user_id", userID))
 	}
 }
+
+// allowTopic reports whether the user may subscribe to topic, writing the
+// error response if not. Only admins may subscribe to the ops topic.
+func allowTopic(w http.ResponseWriter, r *http.Request, users storage.UserStore, ops *wsClient.OpsReporter, topic, userID string) bool {
+	if topic != wsClient.TopicOps || ops == nil {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUnknownTopic)))
+		return false
+	}
+	return middleware.RequireAdmin(w, r, users, userID)
+}
+
+// reject upgrades the request only to close the connection with the given
+// code, since WebSocket clients cannot see why an upgrade was refused
+func reject(w http.ResponseWriter, r *http.Request, code int, reason string) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	closeWith(conn, code, reason)
+}
+
+// closeWith sends a close frame with the given code and closes the connection
+func closeWith(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(closeWait))
+	conn.Close()
+}
+
+// GetHubStats returns WebSocket hub connection and delivery statistics
+// @Summary Get WebSocket hub statistics
+// @ID getHubStats
+// @Description Get connected client count, queued broadcasts, and delivered/dropped event counters. Admins only.
+// @Tags websocket
+// @Produce json
+// @Success 200 {object} response.Response{data=wsClient.HubStats} "Hub statistics"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden - not an admin"
+// @Security BearerAuth
+// @Router /ws/stats [get]
+func GetHubStats(hub *wsClient.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("WebSocket hub stats retrieved", hub.Stats()))
+	}
+}
+
+// GetPresence returns whether a user is connected and when they were last
+// seen. Only the user and their followers can see it; anyone else is told the
+// user does not exist.
+// @Summary Get user presence
+// @ID getPresence
+// @Description Get whether a user currently has a live WebSocket connection and when they were last seen. Only available to the user and their followers.
+// @Tags websocket
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} response.Response{data=wsClient.Presence} "User presence"
+// @Failure 400 {object} response.Response "Bad request"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "User not found or not followed"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Security BearerAuth
+// @Router /users/{user_id}/presence [get]
+func GetPresence(hub *wsClient.Hub, graph storage.GraphStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		viewerID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+			return
+		}
+
+		userID := r.PathValue("user_id")
+		if userID == "" {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserIDRequired)))
+			return
+		}
+
+		if userID != viewerID {
+			following, err := graph.IsFollowing(viewerID, userID)
+			if err != nil {
+				slog.Error("Failed to check follow for presence", slog.String("error", err.Error()), slog.String("user_id", userID))
+				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(i18n.Error(r.Context(), i18n.MsgInternalError)))
+				return
+			}
+			if !following {
+				response.WriteJSON(w, http.StatusNotFound, response.GeneralError(i18n.Error(r.Context(), i18n.MsgUserNotFound)))
+				return
+			}
+		}
+
+		response.WriteJSON(w, http.StatusOK, response.OK("Presence retrieved", hub.GetPresence(userID)))
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/abuse"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ShadowThrottle counts the request as action against the abuse detector
+// (assumes auth middleware ran first). Requests from throttled accounts,
+// including the one that takes an account over its limit, get the 200 with
+// message the handler would send on success but are not carried out, so the
+// account sees no sign of being throttled. If Redis cannot be reached the
+// request is let through.
+func ShadowThrottle(detector *abuse.Detector, action, message string) Middleware {
+	return shadowThrottle(detector, action, message, func(userID string, r *http.Request) (int, any) {
+		return 1, nil
+	})
+}
+
+// ShadowThrottleBatch is ShadowThrottle for the batch follow routes. Each
+// distinct user of the request's user_ids counts as one action, and a
+// throttled account gets a result for each of them: status, or self for its
+// own ID, as the handler reports them.
+func ShadowThrottleBatch(detector *abuse.Detector, action, message, status string) Middleware {
+	return shadowThrottle(detector, action, message, func(userID string, r *http.Request) (int, any) {
+		body, err := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var req types.FollowBatchRequest
+		if err != nil || json.Unmarshal(body, &req) != nil {
+			// Left to the handler to turn away
+			return 0, nil
+		}
+
+		results := []types.FollowResult{}
+		seen := make(map[string]bool, len(req.UserIDs))
+		for _, id := range req.UserIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			result := types.FollowResult{UserID: id, Status: status}
+			if id == userID {
+				result.Status = types.FollowSelf
+			}
+			results = append(results, result)
+		}
+		return len(results), results
+	})
+}
+
+// shadowThrottle counts the actions count finds in the request and, for a
+// throttled account, sends the success response with the data count returns
+// in place of the handler's
+func shadowThrottle(detector *abuse.Detector, action, message string, count func(userID string, r *http.Request) (int, any)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			n, data := count(userID, r)
+
+			throttled, err := detector.Throttled(r.Context(), userID)
+			if err == nil && !throttled {
+				throttled, err = detector.RecordN(r.Context(), userID, action, n)
+			}
+			if err != nil {
+				slog.Warn("Abuse check failed", slog.String("error", err.Error()), slog.String("action", action))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if throttled {
+				metrics.AbuseShadowed(action)
+				response.WriteJSON(w, http.StatusOK, response.RequestOK(message, data))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
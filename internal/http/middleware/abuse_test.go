@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/abuse"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+func TestShadowThrottle(t *testing.T) {
+	redisClient, mr := redistest.New(t)
+
+	cfg := config.Abuse{Enabled: true, FollowsPerHour: 2, UnfollowsPerHour: 2, ViewsPerHour: 2, ThrottleFor: 600}
+	detector := abuse.NewDetector(redisClient, cache.NewKeys(""), nil, cfg)
+
+	served := 0
+	handler := ShadowThrottle(detector, abuse.ActionFollow, "User followed successfully")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+	follow := func(userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/users/7/follow", nil)
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	follow("42")
+	follow("42")
+	if served != 2 {
+		t.Fatalf("Expected 2 follows within the limit served, got %d", served)
+	}
+
+	// The nil store is never reached once the account is already throttled
+	mr.Set("abuse:throttled:42", abuse.ActionFollow)
+	w := follow("42")
+	if served != 2 {
+		t.Errorf("Expected a throttled follow not to be served, got %d served", served)
+	}
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "User followed successfully") {
+		t.Errorf("Expected the usual success response, got %d %s", w.Code, w.Body.String())
+	}
+
+	follow("7")
+	if served != 3 {
+		t.Errorf("Expected another user's follow served, got %d served", served)
+	}
+}
+
+func TestShadowThrottleBatch(t *testing.T) {
+	redisClient, _ := redistest.New(t)
+
+	cfg := config.Abuse{Enabled: true, FollowsPerHour: 3, UnfollowsPerHour: 3, ViewsPerHour: 3, ThrottleFor: 600}
+	detector := abuse.NewDetector(redisClient, cache.NewKeys(""), &flagStore{}, cfg)
+
+	served := 0
+	handler := ShadowThrottleBatch(detector, abuse.ActionFollow, "Users followed successfully", types.FollowFollowed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Errorf("Expected the body to be left for the handler, got %v", err)
+		}
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+	follow := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/follow/batch", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, "42"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Duplicates count once
+	follow(`{"user_ids": ["1", "2", "2"]}`)
+	if served != 1 {
+		t.Fatalf("Expected a batch within the limit served, got %d", served)
+	}
+
+	// Two more follows go over the limit of 3
+	w := follow(`{"user_ids": ["3", "42", "3"]}`)
+	if served != 1 {
+		t.Errorf("Expected the batch over the limit not to be served, got %d served", served)
+	}
+	var body response.Envelope[[]types.FollowResult]
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := []types.FollowResult{{UserID: "3", Status: types.FollowFollowed}, {UserID: "42", Status: types.FollowSelf}}
+	if w.Code != http.StatusOK || !reflect.DeepEqual(body.Data, want) {
+		t.Errorf("Expected the usual results %+v, got %d %+v", want, w.Code, body.Data)
+	}
+}
+
+// flagStore accepts abuse flags and forgets them
+type flagStore struct {
+	storage.AbuseStore
+}
+
+func (s *flagStore) FlagAbuse(flag users.AbuseFlag) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// AdminOnly creates a middleware that only lets admins through; it must run
+// after AuthMiddleware. The flag is read on every request, so revoking it with
+// storiesctl takes effect right away.
+func AdminOnly(store storage.UserStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
+					i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+				return
+			}
+
+			if RequireAdmin(w, r, store, userID) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RequireAdmin reports whether userID is an admin, writing the error response
+// if not: 403 for other users, and 500 when the flag cannot be read
+func RequireAdmin(w http.ResponseWriter, r *http.Request, store storage.UserStore, userID string) bool {
+	user, err := store.GetUserByID(userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("Admin check failed", slog.String("error", err.Error()), slog.String("user_id", userID))
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgInternalError)))
+		return false
+	}
+	if err != nil || !user.IsAdmin {
+		response.WriteJSON(w, http.StatusForbidden, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgAccessDenied)))
+		return false
+	}
+	return true
+}
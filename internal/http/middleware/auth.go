@@ -2,34 +2,57 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 
+	"github.com/princekumarofficial/stories-service/internal/apitoken"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
 	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	SessionIDKey contextKey = "sessionID"
+)
+
+// AuthStore is where AuthMiddleware looks up API tokens and audits requests
+// made with impersonation tokens
+type AuthStore interface {
+	storage.APITokenStore
+	storage.AuditStore
+}
 
-// AuthMiddleware creates a middleware that validates JWT tokens and extracts user ID
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// AuthMiddleware creates a middleware that validates JWT tokens, rejects revoked
+// ones, records when their session was last seen and extracts user ID. API
+// tokens are accepted too, for the requests their scopes allow. Impersonation
+// tokens only serve reads, each of which is audited. If Redis cannot be
+// reached, valid tokens are let through unchecked for revocation, as the rate
+// limiter carries on without it.
+func AuthMiddleware(tokens jwt.Options, revocations *revocation.Store, sessions *session.Store, store AuthStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get the Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
-					errors.New("Authorization header required")))
+					i18n.Error(r.Context(), i18n.MsgAuthHeaderRequired)))
 				return
 			}
 
 			// Check if the header starts with "Bearer "
 			if !strings.HasPrefix(authHeader, "Bearer ") {
 				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
-					errors.New("Invalid authorization header format")))
+					i18n.Error(r.Context(), i18n.MsgInvalidAuthHeaderFormat)))
 				return
 			}
 
@@ -37,20 +60,53 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 			if token == "" {
 				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
-					errors.New("Token not provided")))
+					i18n.Error(r.Context(), i18n.MsgTokenNotProvided)))
+				return
+			}
+
+			if apitoken.IsAPIToken(token) {
+				serveWithAPIToken(w, r, next, store, token)
+				return
+			}
+
+			// Verify the token's signature, algorithm, issuer, audience and
+			// timestamps, and read its claims
+			claims, err := jwt.ParseToken(token, tokens)
+			if err != nil {
+				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
+					i18n.Error(r.Context(), i18n.MsgInvalidToken)))
 				return
 			}
 
-			// Extract user ID from token
-			userID, err := jwt.ExtractUserIDFromToken(token, jwtSecret)
+			// Reject tokens revoked by an admin
+			revoked, err := revocations.IsRevoked(r.Context(), claims)
 			if err != nil {
+				slog.Warn("Token revocation check failed", slog.String("error", err.Error()), slog.String("user_id", claims.UserID))
+			}
+			if revoked {
 				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
-					errors.New("Invalid token")))
+					i18n.Error(r.Context(), i18n.MsgTokenRevoked)))
 				return
 			}
 
-			// Add user ID to request context
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			if claims.Impersonated() {
+				// Impersonation tokens have no session the user could see or end
+				if !auditImpersonation(w, r, store, claims) {
+					return
+				}
+			} else if err := sessions.Touch(r.Context(), claims, session.ClientIP(r)); err != nil {
+				// A failed update only makes last seen stale, so it does not fail the request
+				slog.Warn("Failed to update session", slog.String("error", err.Error()), slog.String("user_id", claims.UserID))
+			}
+
+			// Add user ID, session, scopes, impersonator and tenant to request context
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.TokenID)
+			ctx = context.WithValue(ctx, ScopesKey, claims.Scopes)
+			if claims.Impersonated() {
+				ctx = context.WithValue(ctx, ImpersonatorKey, claims.ImpersonatorID)
+			}
+			ctx = tenant.WithTenant(ctx, claims.TenantID)
 			r = r.WithContext(ctx)
 
 			// Call the next handler
@@ -59,8 +115,60 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 	}
 }
 
+// serveWithAPIToken authenticates a request made with an API token and serves
+// it if the token's scopes allow it
+func serveWithAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, apiTokens storage.APITokenStore, token string) {
+	owner, err := apiTokens.UseAPIToken(apitoken.Hash(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgInvalidToken)))
+		return
+	}
+	if err != nil {
+		slog.Error("API token check failed", slog.String("error", err.Error()))
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgInternalError)))
+		return
+	}
+
+	if !apitoken.Allows(owner.Scopes, r) {
+		response.WriteJSON(w, http.StatusForbidden, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgTokenScopeDenied)))
+		return
+	}
+
+	// API tokens have no session
+	ctx := context.WithValue(r.Context(), UserIDKey, owner.UserID)
+	ctx = context.WithValue(ctx, ScopesKey, apitoken.Grants(owner.Scopes))
+	ctx = tenant.WithTenant(ctx, owner.TenantID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// OptionalAuth wraps an auth middleware so requests without an Authorization
+// header pass through anonymously, while those with one must still carry a
+// valid token
+func OptionalAuth(auth func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
 // GetUserIDFromContext extracts the user ID from the request context
 func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
 }
+
+// GetSessionIDFromContext extracts the session ID (the token's jti) from the
+// request context; it is empty for tokens issued before sessions were tracked
+func GetSessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(SessionIDKey).(string)
+	return sessionID
+}
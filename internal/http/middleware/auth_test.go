@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+var testTokens = jwt.Options{Secret: "test-secret", Issuer: "stories-service", Audience: "stories-api", TTL: time.Hour, Leeway: 30 * time.Second}
+
+func TestAuthMiddleware_RedisDown(t *testing.T) {
+	redisClient, mr := redistest.New(t)
+	revocations := revocation.NewStore(redisClient, cache.NewKeys(""), testTokens)
+	sessions := session.NewStore(redisClient, cache.NewKeys(""), revocations, testTokens)
+
+	var served string
+	handler := AuthMiddleware(testTokens, revocations, sessions, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served, _ = GetUserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/feed", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	token, _, err := jwt.CreateToken("42", "", jwt.UserScopes, testTokens)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	revoked, claims, err := jwt.CreateToken("7", "", jwt.UserScopes, testTokens)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if err := revocations.RevokeToken(context.Background(), claims.TokenID, claims.ExpiresAt); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if w := request(revoked); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for a revoked token, got %d", w.Code)
+	}
+
+	// Without Redis the revocation check is skipped rather than failing the request
+	mr.Close()
+	if w := request(token); w.Code != http.StatusOK || served != "42" {
+		t.Errorf("Expected user 42 served with Redis down, got %d for %q", w.Code, served)
+	}
+	if w := request("not-a-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an invalid token with Redis down, got %d", w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps a handler, adding behaviour around it
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middlewares into one, so Chain(a, b, c)(h) is a(b(c(h))):
+// requests pass through them in the order they are listed
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Then wraps a handler function with the middleware
+func (m Middleware) Then(handler http.HandlerFunc) http.Handler {
+	return m(handler)
+}
+
+// Timeout gives the request context a deadline d from now. Queries and calls
+// made with the request context give up at the deadline, so a slow dependency
+// cannot hold the request open for longer. A d of 0 sets no deadline.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tag appends name to the X-Chain header on the way in
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	handler := Chain(tag("a"), Chain(tag("b"), tag("c")), tag("d")).Then(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := strings.Join(recorder.Header().Values("X-Chain"), ","); got != "a,b,c,d" {
+		t.Errorf("Expected middlewares to run in order a,b,c,d, got %s", got)
+	}
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected the handler to run last, got status %d", recorder.Code)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	handler := Timeout(time.Second).Then(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if !ok || time.Until(deadline) > time.Second {
+		t.Errorf("Expected a deadline within a second, got %v", deadline)
+	}
+
+	handler = Timeout(0).Then(func(w http.ResponseWriter, r *http.Request) {
+		_, ok = r.Context().Deadline()
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil).WithContext(context.Background()))
+	if ok {
+		t.Error("Expected no deadline for a zero timeout")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	handler := Chain(Logging, Recover).Then(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stories/1", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after a panic, got %d", recorder.Code)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be passed on, got %v", recovered)
+		}
+	}()
+	Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ConcurrencyLimiter caps how many requests are served at once, in total and
+// per named route, so a stampede queues in clients instead of in Postgres.
+// Requests over a cap are turned away right away with 503 and Retry-After.
+type ConcurrencyLimiter struct {
+	global     chan struct{}            // nil when unlimited
+	routes     map[string]chan struct{} // by route name, only for limited routes
+	retryAfter string
+}
+
+// NewConcurrencyLimiter creates a limiter from the concurrency config
+func NewConcurrencyLimiter(cfg config.Concurrency) *ConcurrencyLimiter {
+	limiter := &ConcurrencyLimiter{
+		routes:     make(map[string]chan struct{}),
+		retryAfter: strconv.Itoa(max(cfg.RetryAfter, 1)),
+	}
+	if cfg.MaxInFlight > 0 {
+		limiter.global = make(chan struct{}, cfg.MaxInFlight)
+	}
+	for name, limit := range cfg.Routes {
+		if limit > 0 {
+			limiter.routes[name] = make(chan struct{}, limit)
+		}
+	}
+	return limiter
+}
+
+// Global caps the requests in flight across every route. WebSocket upgrades
+// are let through since their connections stay open for as long as the
+// client is around.
+func (cl *ConcurrencyLimiter) Global(next http.Handler) http.Handler {
+	if cl.global == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cl.serve(cl.global, "global", next, w, r)
+	})
+}
+
+// Route caps the requests in flight for the route called name; routes without
+// a configured limit are served as they are
+func (cl *ConcurrencyLimiter) Route(name string, next http.Handler) http.Handler {
+	slots, ok := cl.routes[name]
+	if !ok {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cl.serve(slots, name, next, w, r)
+	})
+}
+
+// Limit is Route as a middleware, for chaining
+func (cl *ConcurrencyLimiter) Limit(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return cl.Route(name, next)
+	}
+}
+
+// serve runs next if a slot is free, and rejects the request otherwise
+func (cl *ConcurrencyLimiter) serve(slots chan struct{}, scope string, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+		next.ServeHTTP(w, r)
+	default:
+		metrics.ConcurrencyRejected(scope)
+		w.Header().Set("Retry-After", cl.retryAfter)
+		response.WriteJSON(w, http.StatusServiceUnavailable, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgServerBusy)))
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+// blockingHandler holds every request until release is closed
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestConcurrencyLimiter_Route(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.Concurrency{
+		Routes:     map[string]int{"feed": 1},
+		RetryAfter: 2,
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := limiter.Route("feed", blockingHandler(started, release))
+
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/feed", nil))
+		done <- recorder.Code
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 while saturated, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected the first request to succeed, got %d", code)
+	}
+
+	// The slot is free again
+	handler = limiter.Route("feed", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the slot is released, got %d", recorder.Code)
+	}
+}
+
+func TestConcurrencyLimiter_Global(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.Concurrency{MaxInFlight: 1})
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	handler := limiter.Global(blockingHandler(started, release))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil))
+		close(done)
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/me", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 over the global cap, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected the default Retry-After of 1, got %q", got)
+	}
+
+	// WebSocket upgrades are not counted
+	upgrade := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	upgrade.Header.Set("Upgrade", "websocket")
+	go handler.ServeHTTP(httptest.NewRecorder(), upgrade)
+	<-started
+
+	close(release)
+	<-done
+}
+
+func TestConcurrencyLimiter_Unlimited(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.Concurrency{Routes: map[string]int{"feed": 0}})
+	if len(limiter.routes) != 0 || limiter.global != nil {
+		t.Error("Expected zero limits to leave routes unlimited")
+	}
+}
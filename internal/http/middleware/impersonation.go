@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ImpersonatorKey holds the admin acting as the user, for requests made with
+// an impersonation token
+const ImpersonatorKey contextKey = "impersonator"
+
+// ImpersonatedByHeader names the admin on every response to a request made
+// with an impersonation token
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// auditImpersonation records a request made with an impersonation token,
+// writing an error response and returning false if it may not be served.
+// Impersonation tokens are read-only, and a request that cannot be audited is
+// not served.
+func auditImpersonation(w http.ResponseWriter, r *http.Request, audit storage.AuditStore, claims jwt.Claims) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		slog.Warn("Impersonation token used to write",
+			slog.String("admin_id", claims.ImpersonatorID),
+			slog.String("user_id", claims.UserID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path))
+		response.WriteJSON(w, http.StatusForbidden, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgImpersonationReadOnly)))
+		return false
+	}
+
+	err := audit.RecordImpersonation(users.ImpersonationEvent{
+		TokenID: claims.TokenID,
+		AdminID: claims.ImpersonatorID,
+		UserID:  claims.UserID,
+		Action:  users.ImpersonationUsed,
+		Method:  r.Method,
+		Path:    r.URL.RequestURI(),
+	})
+	if err != nil {
+		slog.Error("Failed to audit impersonated request", slog.String("error", err.Error()), slog.String("admin_id", claims.ImpersonatorID))
+		response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(
+			i18n.Error(r.Context(), i18n.MsgInternalError)))
+		return false
+	}
+
+	w.Header().Set(ImpersonatedByHeader, claims.ImpersonatorID)
+	return true
+}
+
+// GetImpersonatorFromContext returns the admin acting as the user, or an empty
+// string unless the request was made with an impersonation token
+func GetImpersonatorFromContext(ctx context.Context) string {
+	adminID, _ := ctx.Value(ImpersonatorKey).(string)
+	return adminID
+}
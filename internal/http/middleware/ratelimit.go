@@ -1,48 +1,159 @@
 package middleware
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
 	"github.com/princekumarofficial/stories-service/internal/ratelimit"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
 	"github.com/princekumarofficial/stories-service/internal/utils/response"
 )
 
 type RateLimitConfig struct {
 	redisClient *redis.Client
+	keys        cache.Keys
 	limiters    map[string]*ratelimit.TokenBucket
+	byIP        map[string]bool // Actions counted per client IP rather than per user
+	breaker     *ratelimit.Breaker
+	warner      RateLimitWarner // nil unless users are warned before they run out
 }
 
-func NewRateLimitConfig(redisClient *redis.Client) *RateLimitConfig {
+// RateLimitWarner tells a user over WebSocket that they are close to a limit
+type RateLimitWarner interface {
+	PublishRateLimitWarning(userID string, warning *types.RateLimitWarningEvent) error
+}
+
+// rateLimitWarningPercent is how much of a per-user limit is used before the
+// user is warned
+const rateLimitWarningPercent = 80
+
+// Redis circuit breaker settings: after this many consecutive failures rate
+// limits are enforced per instance, and Redis is retried after the cooldown
+const (
+	redisBreakerThreshold = 3
+	redisBreakerCooldown  = 10 * time.Second
+)
+
+func NewRateLimitConfig(redisClient *redis.Client, keys cache.Keys) *RateLimitConfig {
+	// All limiters share one Redis, so they share its breaker
+	breaker := ratelimit.NewBreaker(redisBreakerThreshold, redisBreakerCooldown)
+
 	config := &RateLimitConfig{
 		redisClient: redisClient,
+		keys:        keys,
 		limiters:    make(map[string]*ratelimit.TokenBucket),
+		byIP:        make(map[string]bool),
+		breaker:     breaker,
 	}
 
-	// Configure rate limits for different actions
-	// POST /stories: 20/min per user
-	config.limiters["stories"] = ratelimit.NewTokenBucket(redisClient, 20, 20)
+	// Configure rate limits for different actions, per user
+	// POST /stories: 20/min
+	config.limiters["stories"] = ratelimit.NewTokenBucket(redisClient, keys, 20, 20).WithBreaker(breaker)
+
+	// POST and DELETE /stories/{id}/reactions: 60/min
+	config.limiters["reactions"] = ratelimit.NewTokenBucket(redisClient, keys, 60, 60).WithBreaker(breaker)
+
+	// Story views and link clicks: 300/min
+	config.limiters["views"] = ratelimit.NewTokenBucket(redisClient, keys, 300, 300).WithBreaker(breaker)
+
+	// POST /stories/impressions/batch: 60/min, each batch up to 100 stories
+	config.limiters["impressions"] = ratelimit.NewTokenBucket(redisClient, keys, 60, 60).WithBreaker(breaker)
+
+	// POST /users/discover: 10/min, each up to 500 contacts, so addresses cannot be probed in bulk
+	config.limiters["discovery"] = ratelimit.NewTokenBucket(redisClient, keys, 10, 10).WithBreaker(breaker)
+
+	// Every other write: 60/min
+	config.limiters["writes"] = ratelimit.NewTokenBucket(redisClient, keys, 60, 60).WithBreaker(breaker)
 
-	// POST /reactions: 60/min per user
-	config.limiters["reactions"] = ratelimit.NewTokenBucket(redisClient, 60, 60)
+	// Authenticated reads: 600/min
+	config.limiters["reads"] = ratelimit.NewTokenBucket(redisClient, keys, 600, 600).WithBreaker(breaker)
+
+	// Admin routes: 60/min
+	config.limiters["admin"] = ratelimit.NewTokenBucket(redisClient, keys, 60, 60).WithBreaker(breaker)
+
+	// POST /signup and POST /login: 20/min per IP
+	config.NewIPLimiter("login", 20)
 
 	return config
 }
 
+// WithWarnings makes per-user limits warn users through warner once they have
+// used rateLimitWarningPercent of a limit, once until the limit is full again
+func (rlc *RateLimitConfig) WithWarnings(warner RateLimitWarner) *RateLimitConfig {
+	rlc.warner = warner
+	return rlc
+}
+
+// NewIPLimiter adds a limiter for action allowing each client IP perMinute
+// actions a minute, sharing the Redis breaker of the other limiters, and
+// returns it for callers that check limits themselves
+func (rlc *RateLimitConfig) NewIPLimiter(action string, perMinute int64) *ratelimit.TokenBucket {
+	limiter := ratelimit.NewTokenBucket(rlc.redisClient, rlc.keys, perMinute, perMinute).WithBreaker(rlc.breaker)
+	rlc.limiters[action] = limiter
+	rlc.byIP[action] = true
+	return limiter
+}
+
+// Limits returns how much of every configured limit the user has left, by
+// action. Limits counted per IP are reported for clientIP.
+func (rlc *RateLimitConfig) Limits(ctx context.Context, userID, clientIP string) ([]users.RateLimit, error) {
+	actions := make([]string, 0, len(rlc.limiters))
+	for action := range rlc.limiters {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+
+	limits := make([]users.RateLimit, 0, len(actions))
+	for _, action := range actions {
+		key, per := userID, users.RateLimitPerUser
+		if rlc.byIP[action] {
+			key, per = clientIP, users.RateLimitPerIP
+		}
+
+		status, err := rlc.limiters[action].Status(ctx, key, action)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %s: %w", action, err)
+		}
+		limits = append(limits, users.RateLimit{
+			Action:        action,
+			Per:           per,
+			Limit:         status.Limit,
+			Remaining:     status.Remaining,
+			WindowSeconds: int64(status.Window.Seconds()),
+			ResetSeconds:  seconds(status.Reset),
+		})
+	}
+	return limits, nil
+}
+
+// SetRateLimitHeaders tells the client how much of a limit it has left, so it
+// can slow down before it is turned away
+func SetRateLimitHeaders(header http.Header, status ratelimit.Status) {
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(status.Limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(status.Remaining, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(seconds(status.Reset), 10))
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
 func (rlc *RateLimitConfig) RateLimitMiddleware(action string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get user ID from context (assumes auth middleware ran first)
-			userID, ok := GetUserIDFromContext(r.Context())
-			if !ok {
-				response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
-					errors.New("user not authenticated")))
-				return
-			}
-
 			// Get the appropriate rate limiter
 			limiter, exists := rlc.limiters[action]
 			if !exists {
@@ -51,51 +162,77 @@ func (rlc *RateLimitConfig) RateLimitMiddleware(action string) func(http.Handler
 				return
 			}
 
-			// Check if user is allowed to perform this action
-			allowed, err := limiter.Allow(r.Context(), userID, action)
+			// Count by client IP, or by user ID from context (assumes auth
+			// middleware ran first)
+			key := session.ClientIP(r)
+			if !rlc.byIP[action] {
+				userID, ok := GetUserIDFromContext(r.Context())
+				if !ok {
+					response.WriteJSON(w, http.StatusUnauthorized, response.GeneralError(
+						i18n.Error(r.Context(), i18n.MsgUserNotAuthenticated)))
+					return
+				}
+				key = userID
+			}
+
+			// Check if the caller is allowed to perform this action
+			allowed, err := limiter.Allow(r.Context(), key, action)
 			if err != nil {
 				response.WriteJSON(w, http.StatusInternalServerError, response.GeneralError(
 					fmt.Errorf("rate limit check failed: %w", err)))
 				return
 			}
 
-			if !allowed {
-				// Get remaining tokens for rate limit headers
-				remaining, _ := limiter.GetRemaining(r.Context(), userID, action)
-
-				// Set rate limit headers
-				w.Header().Set("X-RateLimit-Limit", getLimitForAction(action))
-				w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
-				w.Header().Set("X-RateLimit-Reset", "60") // Reset in 60 seconds (1 minute window)
+			// Headers are set on every response, so clients can throttle
+			// themselves; a failed lookup only leaves them out
+			if status, err := limiter.Status(r.Context(), key, action); err == nil {
+				SetRateLimitHeaders(w.Header(), status)
+				if allowed && !rlc.byIP[action] {
+					rlc.warn(r.Context(), key, action, status)
+				}
+			}
 
+			if !allowed {
+				metrics.RateLimited(action)
 				response.WriteJSON(w, http.StatusTooManyRequests, response.GeneralError(
-					errors.New("rate limit exceeded")))
+					i18n.Error(r.Context(), i18n.MsgRateLimitExceeded)))
 				return
 			}
 
-			// Get remaining tokens for response headers
-			remaining, _ := limiter.GetRemaining(r.Context(), userID, action)
-
-			// Set rate limit headers
-			w.Header().Set("X-RateLimit-Limit", getLimitForAction(action))
-			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
-			w.Header().Set("X-RateLimit-Reset", "60")
-
 			// Allow the request to proceed
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// Helper function to get the limit for display in headers
-func getLimitForAction(action string) string {
-	switch action {
-	case "stories":
-		return "20"
-	case "reactions":
-		return "60"
-	default:
-		return "100" // default fallback
+// warn tells userID they are close to the limit for action once they have used
+// rateLimitWarningPercent of it. Buckets refill continuously, so a user
+// hovering around the threshold is only warned again once the limit has been
+// full. A warning that cannot be sent is only logged.
+func (rlc *RateLimitConfig) warn(ctx context.Context, userID, action string, status ratelimit.Status) {
+	used := status.Limit - status.Remaining
+	if rlc.warner == nil || used*100 < status.Limit*rateLimitWarningPercent || status.Reset <= 0 {
+		return
+	}
+
+	first, err := rlc.redisClient.SetNX(ctx, rlc.keys.Key(cache.RateLimitWarnedKey, userID, action), 1, status.Reset).Result()
+	if err != nil {
+		slog.Warn("Failed to check rate limit warning", slog.String("error", err.Error()), slog.String("action", action))
+		return
+	}
+	if !first {
+		return
+	}
+
+	warning := &types.RateLimitWarningEvent{
+		Action:       action,
+		Limit:        status.Limit,
+		Remaining:    status.Remaining,
+		ResetAt:      types.FormatTime(time.Now().Add(status.Reset)),
+		ResetSeconds: seconds(status.Reset),
+	}
+	if err := rlc.warner.PublishRateLimitWarning(userID, warning); err != nil {
+		slog.Warn("Failed to send rate limit warning", slog.String("error", err.Error()), slog.String("user_id", userID), slog.String("action", action))
 	}
 }
 
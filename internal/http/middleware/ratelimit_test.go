@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	redisClient, _ := redistest.New(t)
+
+	rlc := NewRateLimitConfig(redisClient, cache.NewKeys(""))
+	handler := rlc.RateLimitedHandler("stories", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	post := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/stories", nil)
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, "42"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post()
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if limit, remaining, reset := w.Header().Get("X-RateLimit-Limit"), w.Header().Get("X-RateLimit-Remaining"), w.Header().Get("X-RateLimit-Reset"); limit != "20" || remaining != "19" || reset != "3" {
+		t.Errorf("Expected 19 of 20 left, full again in 3s, got %s of %s in %ss", remaining, limit, reset)
+	}
+
+	for i := 0; i < 19; i++ {
+		post()
+	}
+	w = post()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 past the limit, got %d", w.Code)
+	}
+	if remaining := w.Header().Get("X-RateLimit-Remaining"); remaining != "0" {
+		t.Errorf("Expected none left, got %s", remaining)
+	}
+
+	rlc.NewIPLimiter("ws_connect", 5)
+	limits, err := rlc.Limits(context.Background(), "42", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("Limits failed: %v", err)
+	}
+	byAction := make(map[string]users.RateLimit, len(limits))
+	for _, limit := range limits {
+		byAction[limit.Action] = limit
+	}
+	if len(byAction) != len(limits) || limits[0].Action != "admin" {
+		t.Errorf("Expected one limit per action sorted by action, got %+v", limits)
+	}
+	if stories := byAction["stories"]; stories.Remaining != 0 || stories.ResetSeconds != 60 {
+		t.Errorf("Expected exhausted stories, got %+v", stories)
+	}
+	if reactions := byAction["reactions"]; reactions.Remaining != 60 {
+		t.Errorf("Expected untouched reactions, got %+v", reactions)
+	}
+	if connects := byAction["ws_connect"]; connects.Per != users.RateLimitPerIP || connects.Limit != 5 {
+		t.Errorf("Expected ws_connect per IP, got %+v", connects)
+	}
+}
+
+func TestRateLimitMiddleware_PerIP(t *testing.T) {
+	redisClient, _ := redistest.New(t)
+
+	// Login is limited before anyone is authenticated
+	handler := NewRateLimitConfig(redisClient, cache.NewKeys("")).RateLimitedHandler("login", func(w http.ResponseWriter, r *http.Request) {})
+	login := func(ip string) int {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.RemoteAddr = ip + ":4321"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i := 0; i < 20; i++ {
+		if code := login("203.0.113.7"); code != http.StatusOK {
+			t.Fatalf("Expected attempt %d to be allowed, got %d", i+1, code)
+		}
+	}
+	if code := login("203.0.113.7"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 past the limit, got %d", code)
+	}
+	if code := login("198.51.100.1"); code != http.StatusOK {
+		t.Errorf("Expected another IP to be allowed, got %d", code)
+	}
+}
+
+// recordingWarner records the rate limit warnings it is asked to send
+type recordingWarner struct {
+	warnings []*types.RateLimitWarningEvent
+}
+
+func (rw *recordingWarner) PublishRateLimitWarning(userID string, warning *types.RateLimitWarningEvent) error {
+	rw.warnings = append(rw.warnings, warning)
+	return nil
+}
+
+func TestRateLimitMiddleware_Warnings(t *testing.T) {
+	redisClient, mr := redistest.New(t)
+
+	warner := &recordingWarner{}
+	rlc := NewRateLimitConfig(redisClient, cache.NewKeys("")).WithWarnings(warner)
+	handler := rlc.RateLimitedHandler("stories", func(w http.ResponseWriter, r *http.Request) {})
+	post := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/stories", nil)
+		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, "42"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// 15 of 20 is under the threshold
+	for i := 0; i < 15; i++ {
+		post()
+	}
+	if len(warner.warnings) != 0 {
+		t.Fatalf("Expected no warning under 80%%, got %+v", warner.warnings)
+	}
+
+	post()
+	if len(warner.warnings) != 1 {
+		t.Fatalf("Expected a warning at 80%%, got %d", len(warner.warnings))
+	}
+	if warning := warner.warnings[0]; warning.Action != "stories" || warning.Limit != 20 || warning.Remaining != 4 || warning.ResetSeconds != 48 || warning.ResetAt == "" {
+		t.Errorf("Expected 4 of 20 stories left, full again in 48s, got %+v", warning)
+	}
+
+	// Once warned, the user is not warned again until the limit resets
+	for i := 0; i < 5; i++ {
+		post()
+	}
+	if len(warner.warnings) != 1 {
+		t.Errorf("Expected a single warning, got %d", len(warner.warnings))
+	}
+	if ttl := mr.TTL("rate_limit:warned:42:stories"); ttl != 48*time.Second {
+		t.Errorf("Expected the warning to be remembered until the reset, got %s", ttl)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// ScopesKey holds the scopes of the token a request was made with
+const ScopesKey contextKey = "scopes"
+
+// RequireScope creates a middleware that only serves requests whose token
+// carries every one of scopes; it must run after AuthMiddleware
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := GetScopesFromContext(r.Context())
+			for _, scope := range scopes {
+				if !slices.Contains(granted, scope) {
+					response.WriteJSON(w, http.StatusForbidden, response.GeneralError(
+						i18n.Error(r.Context(), i18n.MsgTokenScopeDenied)))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetScopesFromContext extracts the scopes of the request's token from the
+// request context
+func GetScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(ScopesKey).([]string)
+	return scopes
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(jwt.ScopeStoriesWrite, jwt.ScopeMediaWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for name, tc := range map[string]struct {
+		scopes []string
+		want   int
+	}{
+		"all scopes":    {jwt.UserScopes, http.StatusNoContent},
+		"extra scopes":  {[]string{jwt.ScopeAdmin, jwt.ScopeMediaWrite, jwt.ScopeStoriesWrite}, http.StatusNoContent},
+		"missing scope": {[]string{jwt.ScopeStoriesWrite}, http.StatusForbidden},
+		"no scopes":     {nil, http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/stories", nil)
+		r = r.WithContext(context.WithValue(r.Context(), ScopesKey, tc.scopes))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d", name, tc.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package router
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
+
+	"github.com/princekumarofficial/stories-service/internal/abuse"
+	"github.com/princekumarofficial/stories-service/internal/archive"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/experiments"
+	"github.com/princekumarofficial/stories-service/internal/http/handlers/admin"
+	"github.com/princekumarofficial/stories-service/internal/http/handlers/media"
+	previewHandlers "github.com/princekumarofficial/stories-service/internal/http/handlers/preview"
+	"github.com/princekumarofficial/stories-service/internal/http/handlers/stories"
+	"github.com/princekumarofficial/stories-service/internal/http/handlers/users"
+	wsHandler "github.com/princekumarofficial/stories-service/internal/http/handlers/websocket"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/impressions"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	"github.com/princekumarofficial/stories-service/internal/mediasync"
+	"github.com/princekumarofficial/stories-service/internal/preview"
+	"github.com/princekumarofficial/stories-service/internal/ratelimit"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/services/links"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/sharelink"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/utils/request"
+	"github.com/princekumarofficial/stories-service/internal/websocket"
+	"github.com/princekumarofficial/stories-service/internal/wsticket"
+)
+
+// Dependencies holds the services the HTTP routes are wired to
+type Dependencies struct {
+	Config       *config.Config
+	Storage      *postgres.Postgres
+	Redis        *redis.Client
+	Media        *mediaService.Service
+	Hub          *websocket.Hub
+	Publisher    *events.EventPublisher
+	TicketIssuer *wsticket.Issuer
+	Warmer       *cache.Warmer
+	Ops          *websocket.OpsReporter // nil while the ops topic is off
+	Digester     *events.Digester       // nil sends no digests on connect
+}
+
+// New registers every route on a new mux and returns the root handler
+func New(deps Dependencies) http.Handler {
+	cfg := deps.Config
+	redisKeys := cache.NewKeys(cfg.Redis.KeyPrefix)
+
+	// Work that can wait, such as deleting objects, goes through the job
+	// queue so it survives restarts and is retried when it fails
+	queue := jobs.NewQueue(deps.Storage.GetDB(), cfg.Jobs)
+
+	// Initialize handlers
+	mediaHandlers := media.NewMediaHandlers(deps.Media, deps.Storage, queue)
+	linkValidator := links.NewValidator(cfg)
+
+	// Announcements and rate limit warnings go through the Redis relay so
+	// clients connected to every instance receive them, not only those on the
+	// instance handling the request
+	announcer := events.NewEventPublisher(events.NewRedisRelay(deps.Redis, redisKeys))
+
+	// Initialize rate limiting, warning users close to a limit
+	rateLimitConfig := middleware.NewRateLimitConfig(deps.Redis, redisKeys).WithWarnings(announcer)
+
+	// Limit how fast each IP may open WebSocket connections
+	var wsConnects *ratelimit.TokenBucket
+	if cfg.WebSocket.ConnectRate > 0 {
+		wsConnects = rateLimitConfig.NewIPLimiter("ws_connect", int64(cfg.WebSocket.ConnectRate))
+	}
+
+	// Initialize concurrency limits
+	concurrency := middleware.NewConcurrencyLimiter(cfg.Concurrency)
+	requestTimeout := time.Duration(cfg.HTTPServer.RequestTimeout) * time.Second
+
+	// Initialize caching layer
+	cacheService := cache.NewCacheService(deps.Storage, deps.Redis, redisKeys, cache.TTLsFromConfig(cfg.Cache))
+	optimizedQuery := cache.NewOptimizedFeedQuery(deps.Storage.GetDB())
+	feedLimits := request.Limits{Default: cfg.Feed.DefaultLimit, Max: cfg.Feed.MaxLimit}
+	feedMediaURLs := mediaService.NewURLResolver(deps.Media, deps.Redis, redisKeys, time.Duration(cfg.Media.FeedURLTTL)*time.Second)
+	impressionBuffer := impressions.NewBuffer(deps.Redis, redisKeys)
+	abuseDetector := abuse.NewDetector(deps.Redis, redisKeys, deps.Storage, cfg.Abuse)
+	feedExperiments := experiments.NewAssigner(deps.Redis, redisKeys, deps.Storage, cfg.Experiments)
+
+	router := http.NewServeMux()
+
+	// Create auth middleware
+	tokens := jwt.OptionsFromConfig(cfg)
+	revocations := revocation.NewStore(deps.Redis, redisKeys, tokens)
+	sessions := session.NewStore(deps.Redis, redisKeys, revocations, tokens)
+	authMiddleware := middleware.AuthMiddleware(tokens, revocations, sessions, deps.Storage)
+	adminOnly := middleware.AdminOnly(deps.Storage)
+	storiesWrite := middleware.RequireScope(jwt.ScopeStoriesWrite)
+	mediaWrite := middleware.RequireScope(jwt.ScopeMediaWrite)
+	adminScope := middleware.RequireScope(jwt.ScopeAdmin)
+	optionalAuth := middleware.OptionalAuth(authMiddleware)
+
+	shareLinks := sharelink.NewSigner(cfg.JWTSecret)
+	previews := preview.NewBuilder(deps.Media, cfg.Mail.PublicURL)
+
+	// tenantScoped builds a handler per request around the cache scoped to
+	// the caller's tenant, so cache keys never mix tenants
+	tenantScoped := func(build func(*cache.CacheService) http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			build(cacheService.ForTenant(tenant.FromContext(r.Context())))(w, r)
+		}
+	}
+
+	// Every route runs through a chain: protected routes authenticate the
+	// caller, then run their own checks, then count the request against the
+	// caller's limit for the action. The feed and nearby routes are also capped
+	// in concurrency and time.
+	protected := func(action string, checks ...middleware.Middleware) middleware.Middleware {
+		chain := append([]middleware.Middleware{authMiddleware}, checks...)
+		return middleware.Chain(append(chain, rateLimitConfig.RateLimitMiddleware(action))...)
+	}
+	heavy := func(route string) middleware.Middleware {
+		return middleware.Chain(protected("reads"), concurrency.Limit(route), middleware.Timeout(requestTimeout))
+	}
+	reads := protected("reads")
+	writes := protected("writes")
+	public := middleware.Chain(rateLimitConfig.RateLimitMiddleware("login"))
+
+	// Follows, unfollows and views are also counted by the abuse detector,
+	// which answers for throttled accounts without carrying them out
+	shadowThrottle := func(action, message string) middleware.Middleware {
+		return middleware.ShadowThrottle(abuseDetector, action, message)
+	}
+	shadowThrottleBatch := func(action, message, status string) middleware.Middleware {
+		return middleware.ShadowThrottleBatch(abuseDetector, action, message, status)
+	}
+
+	router.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+
+	// WebSocket routes; GET /ws limits connection attempts itself
+	router.Handle("POST /ws/ticket", writes.Then(wsHandler.IssueTicket(deps.TicketIssuer)))
+	router.HandleFunc("GET /ws", wsHandler.WebSocketHandler(deps.Hub, deps.TicketIssuer, deps.Warmer, wsConnects, deps.Storage, deps.Ops, deps.Digester))
+	router.Handle("GET /users/{user_id}/presence", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return wsHandler.GetPresence(deps.Hub, c)
+	})))
+
+	// Story routes
+	router.Handle("POST /stories", protected("stories", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.PostStory(c, linkValidator)
+	})))
+	router.Handle("GET /me/settings/stories", reads.Then(stories.GetStorySettings(deps.Storage)))
+	router.Handle("PATCH /me/settings/stories", writes.Then(stories.UpdateStorySettings(deps.Storage)))
+	router.Handle("GET /stories/nearby", heavy("stories_nearby").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.NearbyStories(c)
+	})))
+	router.Handle("GET /stories/{id}", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.GetStory(c)
+	})))
+	router.Handle("PATCH /stories/{id}", protected("writes", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.UpdateStory(c, linkValidator, deps.Publisher)
+	})))
+	router.Handle("DELETE /stories/{id}", protected("writes", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.DeleteStory(c, deps.Publisher)
+	})))
+	router.Handle("GET /feed", heavy("feed").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.CachedFeed(c, feedLimits, feedMediaURLs, feedExperiments)
+	})))
+	router.Handle("GET /feed/trays", heavy("feed_trays").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.FeedTrays(c)
+	})))
+	router.Handle("GET /feed/changes", heavy("feed_changes").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.FeedChanges(c)
+	})))
+	router.Handle("GET /feed/optimized", heavy("feed_optimized").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.OptimizedFeed(c, optimizedQuery, feedLimits, feedMediaURLs)
+	})))
+	router.Handle("POST /stories/{id}/view", middleware.Chain(protected("views"), shadowThrottle(abuse.ActionView, "View recorded successfully")).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.ViewStoryWithEvents(c, deps.Publisher)
+	})))
+	router.Handle("POST /stories/impressions/batch", protected("impressions").Then(stories.RecordImpressions(impressionBuffer)))
+	router.Handle("GET /stories/{id}/envelope", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.StoryEnvelope(c)
+	})))
+	router.Handle("GET /stories/{id}/viewers", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.StoryViewers(c)
+	})))
+	router.Handle("POST /stories/{id}/reactions", protected("reactions").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.AddReactionWithEvents(c, deps.Publisher)
+	})))
+	router.Handle("DELETE /stories/{id}/reactions", protected("reactions").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.RemoveReactionWithEvents(c, deps.Publisher)
+	})))
+	router.Handle("POST /stories/{id}/link/click", protected("views").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.RecordLinkClick(c)
+	})))
+	router.Handle("POST /stories/{id}/screenshot", protected("views").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.RecordScreenshot(c, deps.Publisher, cfg.Interactions.ScreenshotSignals)
+	})))
+	router.Handle("POST /stories/{id}/reply", protected("reactions").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.ReplyToStory(c, deps.Publisher)
+	})))
+	router.Handle("POST /stories/{id}/reshare", protected("stories", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.ReshareStory(c, deps.Publisher)
+	})))
+	router.Handle("POST /stories/{id}/highlight", protected("writes", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.AddToHighlights(c)
+	})))
+	router.Handle("POST /stories/{id}/share-link", protected("writes", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.CreateShareLink(c, shareLinks, cfg.Mail.PublicURL)
+	})))
+	router.Handle("GET /stories/{id}/share-links", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.ListShareLinks(c, shareLinks, cfg.Mail.PublicURL)
+	})))
+	router.Handle("DELETE /stories/{id}/share-links/{link_id}", protected("writes", storiesWrite).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.RevokeShareLink(c)
+	})))
+	// Share links work across tenants and without an account, so they are
+	// served from storage rather than a tenant's cache
+	// Browsers and link unfurlers asking for HTML get the story's preview page
+	router.Handle("GET /shared/{token}", optionalAuth(previewHandlers.HTMLOr(
+		previewHandlers.SharedStory(deps.Storage, shareLinks, previews),
+		stories.SharedStory(deps.Storage, shareLinks),
+	)))
+	router.Handle("GET /stories/{id}/preview", http.HandlerFunc(previewHandlers.PublicStory(deps.Storage, previews)))
+	router.Handle("GET /oembed", http.HandlerFunc(previewHandlers.OEmbed(deps.Storage, shareLinks, previews)))
+
+	// Group routes; membership changes invalidate feeds, so they go through the cache
+	router.Handle("POST /groups", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.CreateGroup(c)
+	})))
+	router.Handle("GET /groups", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.ListGroups(c)
+	})))
+	router.Handle("GET /groups/{id}/members", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.GroupMembers(c)
+	})))
+	router.Handle("POST /groups/{id}/members", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.AddGroupMembers(c)
+	})))
+	router.Handle("DELETE /groups/{id}/members/{user_id}", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.RemoveGroupMember(c)
+	})))
+	router.Handle("GET /groups/{id}/stories", heavy("group_stories").Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return stories.GroupStories(c, feedMediaURLs)
+	})))
+
+	// User routes
+	router.Handle("GET /me", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.GetMe(c)
+	})))
+	router.Handle("PUT /me/avatar", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.SetAvatar(c)
+	})))
+	router.Handle("GET /users/{id}", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.GetUser(c)
+	})))
+	// Contact discovery has its own tight limit against probing for addresses
+	router.Handle("POST /users/discover", protected("discovery").Then(users.DiscoverUsers(deps.Storage)))
+	router.Handle("GET /me/limits", middleware.Chain(authMiddleware).Then(users.GetLimits(rateLimitConfig)))
+	router.Handle("GET /me/sessions", reads.Then(users.ListSessions(sessions)))
+	router.Handle("DELETE /me/sessions/{id}", writes.Then(users.RevokeSession(sessions)))
+	router.Handle("POST /me/tokens", writes.Then(users.CreateAPIToken(deps.Storage)))
+	router.Handle("GET /me/tokens", reads.Then(users.ListAPITokens(deps.Storage)))
+	router.Handle("DELETE /me/tokens/{id}", writes.Then(users.RevokeAPIToken(deps.Storage)))
+	router.Handle("GET /me/stats", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.GetStats(c)
+	})))
+	router.Handle("GET /me/stats/export", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.ExportStats(c)
+	})))
+	router.Handle("POST /me/stats/exports", writes.Then(users.StartStatsExport(queue)))
+	router.Handle("GET /me/stats/exports/{id}", reads.Then(users.GetStatsExport(queue, deps.Media, time.Duration(cfg.Media.PresignedURLTTL)*time.Second)))
+	router.Handle("GET /me/recaps", reads.Then(users.GetWeeklyRecaps(deps.Storage)))
+	router.Handle("GET /me/privacy-settings", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.GetPrivacySettings(c)
+	})))
+	router.Handle("PUT /me/privacy-settings", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.UpdatePrivacySettings(c)
+	})))
+	router.Handle("PUT /me/public-key", writes.Then(users.SetPublicKey(deps.Storage)))
+	router.Handle("GET /users/{user_id}/public-key", reads.Then(users.GetPublicKey(deps.Storage)))
+	router.Handle("GET /me/notification-settings", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.GetNotificationSettings(c)
+	})))
+	router.Handle("PUT /me/notification-settings", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.UpdateNotificationSettings(c)
+	})))
+
+	// Follow/Unfollow routes; a batch counts as a single action for abuse detection
+	router.Handle("POST /follow/batch", middleware.Chain(writes, shadowThrottleBatch(abuse.ActionFollow, "Users followed successfully", types.FollowFollowed)).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.FollowUsers(c, deps.Publisher)
+	})))
+	router.Handle("DELETE /follow/batch", middleware.Chain(writes, shadowThrottleBatch(abuse.ActionUnfollow, "Users unfollowed successfully", types.FollowUnfollowed)).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.UnfollowUsers(c, deps.Publisher)
+	})))
+	router.Handle("POST /follow/{user_id}", middleware.Chain(writes, shadowThrottle(abuse.ActionFollow, "User followed successfully")).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.FollowUser(c, deps.Publisher)
+	})))
+	router.Handle("DELETE /follow/{user_id}", middleware.Chain(writes, shadowThrottle(abuse.ActionUnfollow, "User unfollowed successfully")).Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.UnfollowUser(c, deps.Publisher)
+	})))
+
+	// Hidden authors stay followed but are left out of the caller's feed
+	router.Handle("POST /users/{id}/hide-stories", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.HideStories(c)
+	})))
+	router.Handle("DELETE /users/{id}/hide-stories", writes.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.UnhideStories(c)
+	})))
+	router.Handle("GET /me/hidden-authors", reads.Then(tenantScoped(func(c *cache.CacheService) http.HandlerFunc {
+		return users.ListHiddenAuthors(c)
+	})))
+
+	// Media routes (protected)
+	router.Handle("POST /media/upload-url", protected("writes", mediaWrite).Then(mediaHandlers.GenerateUploadURL()))
+	router.Handle("POST /media/confirm", protected("writes", mediaWrite).Then(mediaHandlers.ConfirmUpload()))
+	router.Handle("GET /media", reads.Then(mediaHandlers.ListUserMedia()))
+	router.Handle("GET /media/{object_key}/status", reads.Then(mediaHandlers.GetUploadStatus()))
+	router.Handle("GET /media/{object_key}/info", reads.Then(mediaHandlers.GetMediaInfo()))
+	router.Handle("GET /media/{object_key}/download-url", reads.Then(mediaHandlers.GenerateDownloadURL()))
+	router.Handle("DELETE /media/{object_key}", protected("writes", mediaWrite).Then(mediaHandlers.DeleteMedia()))
+
+	// Public routes; signup and login are limited per IP
+	router.Handle("POST /signup", public.Then(users.SignUp(deps.Storage)))
+	router.Handle("POST /login", public.Then(users.Login(deps.Storage, tokens, sessions, deps.Warmer)))
+	router.Handle("GET /unsubscribe", http.HandlerFunc(users.UnsubscribePage(cfg.JWTSecret)))
+	router.Handle("POST /unsubscribe", http.HandlerFunc(users.Unsubscribe(cacheService, cfg.JWTSecret)))
+
+	// Admin routes
+	adminRoute := protected("admin", adminScope, adminOnly)
+	archiver := archive.NewArchiver(deps.Storage, deps.Media, cfg.Archive)
+	router.Handle("POST /admin/announcements", adminRoute.Then(admin.Announce(deps.Storage, announcer)))
+	router.Handle("GET /admin/media/reconciliation", adminRoute.Then(admin.MediaReconciliation(deps.Redis, redisKeys)))
+	router.Handle("POST /admin/users/{user_id}/impersonate", adminRoute.Then(admin.Impersonate(deps.Storage, tokens)))
+	router.Handle("GET /admin/impersonations", adminRoute.Then(admin.Impersonations(deps.Storage)))
+	router.Handle("GET /admin/stats/clients", adminRoute.Then(admin.ClientStats(sessions)))
+	router.Handle("GET /admin/abuse/flags", adminRoute.Then(admin.AbuseFlags(deps.Storage)))
+	router.Handle("POST /admin/abuse/flags/{id}/review", adminRoute.Then(admin.ReviewAbuseFlag(deps.Storage)))
+	router.Handle("DELETE /admin/users/{user_id}/throttle", adminRoute.Then(admin.LiftThrottle(deps.Storage, abuseDetector)))
+	router.Handle("POST /admin/media/gc", adminRoute.Then(admin.CollectMedia(mediasync.NewReconciler(deps.Storage, deps.Media, deps.Redis, redisKeys, cfg.Media))))
+	router.Handle("POST /admin/archive/sweep", adminRoute.Then(admin.SweepArchive(archiver)))
+	router.Handle("POST /admin/stories/{id}/restore", adminRoute.Then(admin.RestoreStory(archiver)))
+	router.Handle("GET /ws/stats", adminRoute.Then(wsHandler.GetHubStats(deps.Hub)))
+	router.Handle("GET /metrics", adminRoute.Then(promhttp.Handler().ServeHTTP))
+
+	// Cache monitoring endpoints (for development/admin)
+	router.Handle("GET /cache/stats", http.HandlerFunc(cache.GetCacheStats(deps.Redis, redisKeys)))
+	router.Handle("DELETE /cache/clear", http.HandlerFunc(cache.ClearCache(deps.Redis, redisKeys)))
+
+	// Documentation
+	router.Handle("GET /docs/", httpSwagger.WrapHandler)
+
+	// Every request is logged and recovered from panics, including those
+	// turned away by the global concurrency cap
+	return middleware.Chain(i18n.Middleware, middleware.Logging, middleware.Recover, concurrency.Global)(router)
+}
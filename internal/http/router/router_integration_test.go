@@ -0,0 +1,1244 @@
+//go:build integration
+
+package router_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/princekumarofficial/stories-service/internal/archive"
+	"github.com/princekumarofficial/stories-service/internal/emailnotify"
+	"github.com/princekumarofficial/stories-service/internal/http/middleware"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/session"
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+	"github.com/princekumarofficial/stories-service/internal/websocket"
+	"github.com/princekumarofficial/stories-service/internal/wsticket"
+)
+
+func TestRouterIntegration(t *testing.T) {
+	env := testutil.NewEnv(t)
+
+	authorEmail := testutil.UniqueEmail("author")
+	var authorID, authorToken string
+
+	t.Run("SignUpAndLogin", func(t *testing.T) {
+		credentials := users.SignUpRequest{Email: authorEmail, Password: testutil.DefaultPassword}
+
+		resp := env.Do(t, http.MethodPost, "/signup", "", credentials)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected signup status 201, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/login", "", credentials)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected login status 200, got %d", resp.StatusCode)
+		}
+		login := testutil.DecodeJSON[response.Envelope[users.LoginResponse]](t, resp).Data
+		authorID, authorToken = login.UserID, login.Token
+		if authorID == "" || authorToken == "" {
+			t.Fatalf("Expected user ID and token, got %+v", login)
+		}
+		if login.TokenType != "Bearer" || login.User.ID != authorID || login.User.Email != authorEmail {
+			t.Errorf("Expected a bearer token and the user's profile, got %+v", login)
+		}
+		if expiresAt, err := time.Parse(time.RFC3339, login.ExpiresAt); err != nil || !expiresAt.After(time.Now()) {
+			t.Errorf("Expected a future RFC 3339 expiry, got %q", login.ExpiresAt)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/login", "", users.SignInRequest{Email: authorEmail, Password: "wrong-password"})
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a wrong password, got %d", resp.StatusCode)
+		}
+	})
+	if authorToken == "" {
+		t.FailNow()
+	}
+
+	viewerID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("viewer"))
+	viewerToken := env.Token(t, viewerID)
+
+	t.Run("RequiresAuth", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/feed", "", nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 without a token, got %d", resp.StatusCode)
+		}
+	})
+
+	var storyID string
+
+	t.Run("PostStory", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/stories", authorToken, types.StoryPostRequest{
+			Text:            "hello from the integration suite",
+			Visibility:      types.VisibilityFollowers,
+			AudienceUserIDs: []string{},
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+		storyID = testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+
+		resp = env.Do(t, http.MethodPost, "/stories", authorToken, map[string]string{"text": "bad visibility", "visibility": "EVERYONE"})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400 for an invalid story, got %d", resp.StatusCode)
+		}
+		if body := testutil.DecodeJSON[response.Response](t, resp); len(body.Errors) == 0 {
+			t.Error("Expected field errors for an invalid story")
+		}
+
+		// Without a default, every story must name its visibility
+		resp = env.Do(t, http.MethodPost, "/stories", authorToken, map[string]string{"text": "no visibility"})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 without a visibility, got %d", resp.StatusCode)
+		}
+	})
+	if storyID == "" {
+		t.FailNow()
+	}
+
+	t.Run("FollowersStoryRequiresFollow", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/stories/"+storyID, viewerToken, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected status 403 before following, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/follow/"+authorID, viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected follow status 200, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/feed", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected feed status 200, got %d", resp.StatusCode)
+		}
+		feed := testutil.DecodeJSON[struct {
+			Data []types.Story `json:"data"`
+		}](t, resp)
+		if !slices.Contains(testutil.StoryIDs(feed.Data), storyID) {
+			t.Errorf("Expected story %s in feed, got %v", storyID, testutil.StoryIDs(feed.Data))
+		}
+	})
+
+	t.Run("FollowValidation", func(t *testing.T) {
+		cases := map[string]struct {
+			token, userID string
+			want          int
+		}{
+			"again":     {viewerToken, authorID, http.StatusOK},
+			"yourself":  {authorToken, authorID, http.StatusBadRequest},
+			"missing":   {viewerToken, "999999999", http.StatusNotFound},
+			"malformed": {viewerToken, "someone", http.StatusNotFound},
+		}
+
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				resp := env.Do(t, http.MethodPost, "/follow/"+tc.userID, tc.token, nil)
+				resp.Body.Close()
+				if resp.StatusCode != tc.want {
+					t.Errorf("Expected status %d, got %d", tc.want, resp.StatusCode)
+				}
+			})
+		}
+	})
+
+	t.Run("StoryDefaults", func(t *testing.T) {
+		posterID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("defaults"))
+		posterToken := env.Token(t, posterID)
+
+		private, hours := types.VisibilityPrivate, 6
+		resp := env.Do(t, http.MethodPatch, "/me/settings/stories", posterToken, types.StorySettingsUpdate{
+			DefaultVisibility:      &private,
+			DefaultAudienceUserIDs: &[]string{viewerID},
+			DefaultExpiresInHours:  &hours,
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/stories", posterToken, map[string]string{"text": "close friends only"})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201 with defaults, got %d", resp.StatusCode)
+		}
+		defaultedID := testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+
+		story, err := env.Storage.GetStoryByID(defaultedID)
+		if err != nil {
+			t.Fatalf("GetStoryByID failed: %v", err)
+		}
+		if story.Visibility != types.VisibilityPrivate {
+			t.Errorf("Expected the default visibility, got %s", story.Visibility)
+		}
+		if lifetime := story.ExpiresAt.Sub(story.CreatedAt); lifetime != 6*time.Hour {
+			t.Errorf("Expected the default lifetime of 6h, got %s", lifetime)
+		}
+		if canView, err := env.Storage.CanUserViewStory(defaultedID, viewerID); err != nil || !canView {
+			t.Errorf("Expected the default audience to see the story, got %v, %v", canView, err)
+		}
+
+		resp = env.Do(t, http.MethodPatch, "/me/settings/stories", posterToken, map[string]string{"default_visibility": "GROUP"})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a GROUP default, got %d", resp.StatusCode)
+		}
+
+		// An empty default audience leaves a PRIVATE story with nobody to see it
+		resp = env.Do(t, http.MethodPatch, "/me/settings/stories", posterToken, types.StorySettingsUpdate{DefaultAudienceUserIDs: &[]string{}})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/stories", posterToken, map[string]string{"text": "nobody to see this"})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 without an audience, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("EditStory", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/stories/"+storyID, authorToken, nil)
+		if etag := resp.Header.Get("ETag"); etag != `"1"` {
+			t.Fatalf("Expected ETag \"1\" before editing, got %q", etag)
+		}
+
+		text := "edited from the integration suite"
+		resp = env.Do(t, http.MethodPatch, "/stories/"+storyID, authorToken, types.StoryUpdateRequest{Text: &text})
+		if resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("Expected status 428 without a version, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPatch, "/stories/"+storyID, viewerToken, types.StoryUpdateRequest{Text: &text, Version: 1})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for another user, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPatch, "/stories/"+storyID, authorToken, types.StoryUpdateRequest{Text: &text, Version: 1})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if story := testutil.DecodeJSON[response.Envelope[types.Story]](t, resp).Data; story.Text != text || story.Version != 2 {
+			t.Errorf("Expected the edited text at version 2, got %+v", story)
+		}
+
+		// A second device still editing version 1 must not overwrite the edit
+		stale := "stale edit"
+		resp = env.Do(t, http.MethodPatch, "/stories/"+storyID, authorToken, types.StoryUpdateRequest{Text: &stale, Version: 1})
+		if resp.StatusCode != http.StatusConflict {
+			t.Fatalf("Expected status 409 for a stale version, got %d", resp.StatusCode)
+		}
+		if etag := resp.Header.Get("ETag"); etag != `"2"` {
+			t.Errorf("Expected the current ETag \"2\" with the conflict, got %q", etag)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+storyID, viewerToken, nil)
+		if story := testutil.DecodeJSON[response.Envelope[types.Story]](t, resp).Data; story.Text != text {
+			t.Errorf("Expected followers to see the edit, got %q", story.Text)
+		}
+	})
+
+	t.Run("StreamedFeed", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/feed?stream=true", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected feed status 200, got %d", resp.StatusCode)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Expected content type application/x-ndjson, got %q", ct)
+		}
+
+		var streamed []types.Story
+		decoder := json.NewDecoder(resp.Body)
+		for decoder.More() {
+			var story types.Story
+			if err := decoder.Decode(&story); err != nil {
+				t.Fatalf("Failed to decode streamed story: %v", err)
+			}
+			streamed = append(streamed, story)
+		}
+		if !slices.Contains(testutil.StoryIDs(streamed), storyID) {
+			t.Errorf("Expected story %s in streamed feed, got %v", storyID, testutil.StoryIDs(streamed))
+		}
+	})
+
+	t.Run("FeedMediaURLs", func(t *testing.T) {
+		mediaKey := "users/" + authorID + "/media/feed-photo.png"
+		if err := env.Storage.CreateMediaUpload(authorID, mediaKey, "image/png"); err != nil {
+			t.Fatalf("CreateMediaUpload failed: %v", err)
+		}
+		if _, err := env.Storage.ConfirmMediaUpload(authorID, mediaKey, 512); err != nil {
+			t.Fatalf("ConfirmMediaUpload failed: %v", err)
+		}
+		resp := env.Do(t, http.MethodPost, "/stories", authorToken, types.StoryPostRequest{
+			MediaKey:        mediaKey,
+			Visibility:      types.VisibilityFollowers,
+			AudienceUserIDs: []string{},
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+		mediaStoryID := testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+
+		feedStory := func(path string) types.Story {
+			resp := env.Do(t, http.MethodGet, path, viewerToken, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected feed status 200, got %d", resp.StatusCode)
+			}
+			feed := testutil.DecodeJSON[response.Envelope[[]types.Story]](t, resp)
+			for _, story := range feed.Data {
+				if story.ID == mediaStoryID {
+					return story
+				}
+			}
+			t.Fatalf("Expected story %s in feed, got %v", mediaStoryID, testutil.StoryIDs(feed.Data))
+			return types.Story{}
+		}
+
+		if story := feedStory("/feed"); story.MediaURL != "" {
+			t.Errorf("Expected no media URL unless asked for, got %s", story.MediaURL)
+		}
+		story := feedStory("/feed?media_urls=true")
+		if !strings.Contains(story.MediaURL, mediaKey) || story.MediaURLExpiresAt <= time.Now().Unix() {
+			t.Errorf("Expected a presigned URL for %s, got %q expiring at %d", mediaKey, story.MediaURL, story.MediaURLExpiresAt)
+		}
+		if again := feedStory("/feed?media_urls=true"); again.MediaURL != story.MediaURL {
+			t.Errorf("Expected the cached URL %s, got %s", story.MediaURL, again.MediaURL)
+		}
+	})
+
+	t.Run("FeedCursor", func(t *testing.T) {
+		poster := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("cursor-poster"))
+		reader := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("cursor-reader"))
+		testutil.Follow(t, env.Storage, reader, poster)
+		var posted []string
+		for range 5 {
+			posted = append(posted, testutil.CreateStory(t, env.Storage, poster, types.VisibilityFollowers))
+		}
+		// Stories sharing a timestamp are ordered by ID alone
+		if _, err := env.Storage.Db.Exec("UPDATE stories SET created_at = date_trunc('second', NOW()) WHERE author_id = $1", poster); err != nil {
+			t.Fatalf("Failed to align timestamps: %v", err)
+		}
+		token := env.Token(t, reader)
+
+		for _, path := range []string{"/feed", "/feed/optimized"} {
+			var got []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > 50 {
+					t.Fatalf("Expected %s to run out of pages", path)
+				}
+				resp := env.Do(t, http.MethodGet, path+"?limit=2&cursor="+url.QueryEscape(cursor), token, nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("Expected %s status 200, got %d", path, resp.StatusCode)
+				}
+				cursor = resp.Header.Get("X-Next-Cursor")
+				page := testutil.DecodeJSON[response.Envelope[[]types.Story]](t, resp)
+				if len(page.Data) > 2 {
+					t.Fatalf("Expected pages of at most 2 stories, got %d", len(page.Data))
+				}
+				for _, story := range page.Data {
+					if story.AuthorID == poster {
+						got = append(got, story.ID)
+					}
+				}
+				if cursor == "" {
+					break
+				}
+			}
+
+			want := slices.Clone(posted)
+			slices.Reverse(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("Expected %s to page through %v once each, highest ID first, got %v", path, want, got)
+			}
+		}
+
+		resp := env.Do(t, http.MethodGet, "/feed?cursor=not-a-cursor", token, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid cursor, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ViewsReactionsAndStats", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/stories/"+storyID+"/view", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected view status 200, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/stories/"+storyID+"/reactions", viewerToken, types.ReactionRequest{Emoji: types.ReactionLaugh})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected reaction status 200, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/me/stats", authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected stats status 200, got %d", resp.StatusCode)
+		}
+		stats := testutil.DecodeJSON[response.Envelope[users.UserStats]](t, resp).Data
+		if stats.Views != 1 || stats.ReactionCounts[string(types.ReactionLaugh)] != 1 {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("StatsExport", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/me/stats/export?format=csv", authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected export status 200, got %d", resp.StatusCode)
+		}
+		defer resp.Body.Close()
+		if cd := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=") {
+			t.Errorf("Expected an attachment, got %q", cd)
+		}
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read the export: %v", err)
+		}
+		today := time.Now().UTC().Format(time.DateOnly)
+		want := []string{today, storyID, "1", "1", "1", "0"}
+		if len(records) != 2 || !slices.Equal(records[1], want) {
+			t.Errorf("Expected a header and the row %v, got %v", want, records)
+		}
+
+		for _, query := range []string{"format=xlsx", "from=2024-02-01&to=2024-01-01", "from=2023-01-01&to=2024-06-01", "to=yesterday"} {
+			if resp := env.Do(t, http.MethodGet, "/me/stats/export?"+query, authorToken, nil); resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", query, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("QueuedStatsExport", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/me/stats/exports", authorToken, users.StatsExportRequest{})
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected export status 202, got %d", resp.StatusCode)
+		}
+		export := testutil.DecodeJSON[response.Envelope[users.StatsExport]](t, resp).Data
+		today := time.Now().UTC().Format(time.DateOnly)
+		if export.ID == "" || export.Status != "queued" || export.To != today || export.DownloadURL != "" {
+			t.Fatalf("Expected a queued export ending today, got %+v", export)
+		}
+
+		// Only the user who started an export can see it
+		exportPath := "/me/stats/exports/" + export.ID
+		if resp := env.Do(t, http.MethodGet, exportPath, viewerToken, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another user's export, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodGet, "/me/stats/exports/not-an-id", authorToken, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unknown export, got %d", resp.StatusCode)
+		}
+
+		env.Jobs.RunOnce(context.Background())
+
+		resp = env.Do(t, http.MethodGet, exportPath, authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected export status 200, got %d", resp.StatusCode)
+		}
+		export = testutil.DecodeJSON[response.Envelope[users.StatsExport]](t, resp).Data
+		if export.Status != "done" || export.DownloadURL == "" || export.ExpiresAt == 0 {
+			t.Fatalf("Expected a finished export with a download URL, got %+v", export)
+		}
+
+		// The file matches the streamed export of the same range
+		file, err := http.Get(export.DownloadURL)
+		if err != nil {
+			t.Fatalf("Failed to download the export: %v", err)
+		}
+		defer file.Body.Close()
+		got, err := csv.NewReader(file.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read the export: %v", err)
+		}
+		streamed := env.Do(t, http.MethodGet, "/me/stats/export?from="+export.From+"&to="+export.To, authorToken, nil)
+		want, err := csv.NewReader(streamed.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read the streamed export: %v", err)
+		}
+		if len(got) < 2 || !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("Expected the queued export to match the streamed one %v, got %v", want, got)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/me/stats/exports", authorToken, users.StatsExportRequest{From: "2024-02-01", To: "2024-01-01"})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid range, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ViewsAndReactionsRespectVisibility", func(t *testing.T) {
+		ownerID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("owner"))
+		followerID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("follower"))
+		friendID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("friend"))
+		audienceID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("audience"))
+		strangerID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("stranger"))
+		testutil.Follow(t, env.Storage, followerID, ownerID)
+		testutil.Follow(t, env.Storage, friendID, ownerID)
+		testutil.Follow(t, env.Storage, ownerID, friendID)
+
+		for name, tc := range map[string]struct {
+			visibility types.Visibility
+			audience   []string
+			allowed    string
+			denied     string
+		}{
+			"public":    {types.VisibilityPublic, nil, strangerID, ""},
+			"followers": {types.VisibilityFollowers, nil, followerID, strangerID},
+			"friends":   {types.VisibilityFriends, nil, friendID, followerID},
+			"private":   {types.VisibilityPrivate, []string{audienceID}, audienceID, friendID},
+		} {
+			storyID := testutil.CreateStory(t, env.Storage, ownerID, tc.visibility, tc.audience...)
+
+			if tc.denied != "" {
+				deniedToken := env.Token(t, tc.denied)
+				resp := env.Do(t, http.MethodPost, "/stories/"+storyID+"/view", deniedToken, nil)
+				if resp.StatusCode != http.StatusForbidden {
+					t.Errorf("%s: expected view status 403, got %d", name, resp.StatusCode)
+				}
+				resp = env.Do(t, http.MethodPost, "/stories/"+storyID+"/reactions", deniedToken, types.ReactionRequest{Emoji: types.ReactionLaugh})
+				if resp.StatusCode != http.StatusForbidden {
+					t.Errorf("%s: expected reaction status 403, got %d", name, resp.StatusCode)
+				}
+			}
+
+			allowedToken := env.Token(t, tc.allowed)
+			resp := env.Do(t, http.MethodPost, "/stories/"+storyID+"/view", allowedToken, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s: expected view status 200, got %d", name, resp.StatusCode)
+			}
+			resp = env.Do(t, http.MethodPost, "/stories/"+storyID+"/reactions", allowedToken, types.ReactionRequest{Emoji: types.ReactionLaugh})
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s: expected reaction status 200, got %d", name, resp.StatusCode)
+			}
+		}
+
+		// Only the allowed user of each story was counted
+		stats, err := env.Storage.GetUserStats(ownerID)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Views != 4 || stats.ReactionCounts[string(types.ReactionLaugh)] != 4 {
+			t.Errorf("Expected 4 views and 4 reactions, got %+v", stats)
+		}
+	})
+
+	t.Run("Me", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/me", authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		profile := testutil.DecodeJSON[response.Envelope[users.Profile]](t, resp).Data
+		if profile.ID != authorID || profile.Email != authorEmail {
+			t.Errorf("Expected the author's profile, got %+v", profile)
+		}
+		if profile.Followers != 1 || profile.Following != 0 || profile.Stories != 1 {
+			t.Errorf("Expected 1 follower, 0 followees and 1 story, got %+v", profile)
+		}
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/login", "", users.SignInRequest{Email: authorEmail, Password: testutil.DefaultPassword, DeviceName: "Second device"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected login status 200, got %d", resp.StatusCode)
+		}
+		secondToken := testutil.DecodeJSON[response.Envelope[users.LoginResponse]](t, resp).Data.Token
+
+		resp = env.Do(t, http.MethodGet, "/me/sessions", secondToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		sessions := testutil.DecodeJSON[response.Envelope[[]session.Session]](t, resp).Data
+		if len(sessions) != 2 {
+			t.Fatalf("Expected a session per login, got %+v", sessions)
+		}
+		var second session.Session
+		for _, s := range sessions {
+			if s.Current {
+				second = s
+			}
+		}
+		if second.DeviceName != "Second device" || second.IP == "" {
+			t.Fatalf("Expected the current session to be the second device, got %+v", sessions)
+		}
+
+		resp = env.Do(t, http.MethodDelete, "/me/sessions/"+second.ID, authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected revoke status 200, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodGet, "/me", secondToken, nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected the revoked session's token to be rejected, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodDelete, "/me/sessions/"+second.ID, viewerToken, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another user's session, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UserProfile", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/users/"+authorID, viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected profile status 200, got %d", resp.StatusCode)
+		}
+		profile := testutil.DecodeJSON[response.Envelope[users.PublicProfile]](t, resp).Data
+		if profile.ID != authorID || !profile.IsFollowing || profile.FollowsYou || profile.Followers < 1 {
+			t.Errorf("Expected the author's profile, followed by the viewer, got %+v", profile)
+		}
+
+		outsider := testutil.CreateTenantUser(t, env.Storage, "profile-tenant", testutil.UniqueEmail("outsider"))
+		if resp := env.Do(t, http.MethodGet, "/users/"+authorID, env.Token(t, outsider), nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 across tenants, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ShareLink", func(t *testing.T) {
+		privateID := testutil.CreateStory(t, env.Storage, authorID, types.VisibilityPrivate)
+		strangerToken := env.Token(t, testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("stranger")))
+
+		resp := env.Do(t, http.MethodPost, "/stories/"+privateID+"/share-link", viewerToken, types.ShareLinkRequest{})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected status 403 for another user's story, got %d", resp.StatusCode)
+		}
+
+		share := func(req types.ShareLinkRequest) (types.ShareLink, string) {
+			t.Helper()
+			resp := env.Do(t, http.MethodPost, "/stories/"+privateID+"/share-link", authorToken, req)
+			if resp.StatusCode != http.StatusCreated {
+				t.Fatalf("Expected share link status 201, got %d", resp.StatusCode)
+			}
+			link := testutil.DecodeJSON[struct {
+				Data types.ShareLink `json:"data"`
+			}](t, resp).Data
+			shared, err := url.Parse(link.URL)
+			if err != nil {
+				t.Fatalf("Invalid share URL %q: %v", link.URL, err)
+			}
+			return link, shared.Path
+		}
+
+		public, publicPath := share(types.ShareLinkRequest{})
+		if resp := env.Do(t, http.MethodGet, publicPath, "", nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a logged-out viewer, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodGet, publicPath, strangerToken, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a non-follower, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodGet, publicPath+"x", "", nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a forged token, got %d", resp.StatusCode)
+		}
+
+		_, loginPath := share(types.ShareLinkRequest{RequireLogin: true})
+		if resp := env.Do(t, http.MethodGet, loginPath, "", nil); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a logged-out viewer, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodGet, loginPath, strangerToken, nil); resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200 for a signed-in viewer, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodDelete, "/stories/"+privateID+"/share-links/"+public.ID, authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected revoke status 200, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodGet, publicPath, "", nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a revoked link, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+privateID+"/share-links", authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected share links status 200, got %d", resp.StatusCode)
+		}
+		links := testutil.DecodeJSON[struct {
+			Data []types.ShareLink `json:"data"`
+		}](t, resp).Data
+		for _, link := range links {
+			if link.ID == public.ID && (link.ViewCount != 2 || link.RevokedAt == "") {
+				t.Errorf("Expected the revoked link with 2 views, got %+v", link)
+			}
+		}
+		if len(links) != 2 {
+			t.Errorf("Expected 2 share links, got %d", len(links))
+		}
+	})
+
+	t.Run("StoryPreview", func(t *testing.T) {
+		publicID := testutil.CreateStory(t, env.Storage, authorID, types.VisibilityPublic)
+		privateID := testutil.CreateStory(t, env.Storage, authorID, types.VisibilityPrivate)
+
+		resp := env.Do(t, http.MethodGet, "/stories/"+publicID+"/preview", "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected preview status 200, got %d", resp.StatusCode)
+		}
+		page, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(page), `property="og:title"`) {
+			t.Errorf("Expected OpenGraph tags in the preview page")
+		}
+		if resp := env.Do(t, http.MethodGet, "/stories/"+privateID+"/preview", "", nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 previewing a private story, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/stories/"+privateID+"/share-link", authorToken, types.ShareLinkRequest{})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected share link status 201, got %d", resp.StatusCode)
+		}
+		link := testutil.DecodeJSON[struct {
+			Data types.ShareLink `json:"data"`
+		}](t, resp).Data
+		shared, err := url.Parse(link.URL)
+		if err != nil {
+			t.Fatalf("Invalid share URL %q: %v", link.URL, err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, env.Server.URL+shared.Path, nil)
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+		htmlResp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer htmlResp.Body.Close()
+		if htmlResp.StatusCode != http.StatusOK || !strings.HasPrefix(htmlResp.Header.Get("Content-Type"), "text/html") {
+			t.Errorf("Expected an HTML preview of the shared story, got %d %s", htmlResp.StatusCode, htmlResp.Header.Get("Content-Type"))
+		}
+
+		oembed := func(target string) *http.Response {
+			return env.Do(t, http.MethodGet, "/oembed?"+url.Values{"url": {target}}.Encode(), "", nil)
+		}
+		resp = oembed(link.URL)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected oEmbed status 200, got %d", resp.StatusCode)
+		}
+		if embed := testutil.DecodeJSON[map[string]any](t, resp); embed["version"] != "1.0" || embed["author_name"] == "" {
+			t.Errorf("Unexpected oEmbed response %v", embed)
+		}
+		if resp := oembed(env.Config.Mail.PublicURL + "/stories/" + publicID + "/preview"); resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected oEmbed status 200 for a public story, got %d", resp.StatusCode)
+		}
+		if resp := oembed(env.Config.Mail.PublicURL + "/stories/" + privateID + "/preview"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected oEmbed status 404 for a private story, got %d", resp.StatusCode)
+		}
+		if resp := oembed("https://elsewhere.example/stories/" + publicID + "/preview"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected oEmbed status 404 for another host, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("AdminAnnouncement", func(t *testing.T) {
+		announcement := types.AnnouncementRequest{Message: "Maintenance at 02:00 UTC", Level: types.AnnouncementWarning}
+
+		resp := env.Do(t, http.MethodPost, "/admin/announcements", viewerToken, announcement)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected status 403 for a non-admin, got %d", resp.StatusCode)
+		}
+
+		if err := env.Storage.SetUserAdmin(viewerID, true); err != nil {
+			t.Fatalf("SetUserAdmin failed: %v", err)
+		}
+		resp = env.Do(t, http.MethodPost, "/admin/announcements", viewerToken, announcement)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for an admin, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/admin/announcements", viewerToken, types.AnnouncementRequest{Level: "loud"})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid announcement, got %d", resp.StatusCode)
+		}
+
+		announcement.UserIDs = []string{authorID}
+		resp = env.Do(t, http.MethodPost, "/admin/announcements", viewerToken, announcement)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200 for a user of the tenant, got %d", resp.StatusCode)
+		}
+		outsider := testutil.CreateTenantUser(t, env.Storage, "announcement-tenant", testutil.UniqueEmail("outsider"))
+		announcement.UserIDs = []string{authorID, outsider}
+		resp = env.Do(t, http.MethodPost, "/admin/announcements", viewerToken, announcement)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a user of another tenant, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("OpsTopic", func(t *testing.T) {
+		ticket := func(token string) string {
+			resp := env.Do(t, http.MethodPost, "/ws/ticket", token, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected ticket status 200, got %d", resp.StatusCode)
+			}
+			return testutil.DecodeJSON[response.Envelope[wsticket.Ticket]](t, resp).Data.Ticket
+		}
+
+		resp := env.Do(t, http.MethodGet, "/ws?topic=ops&ticket="+ticket(authorToken), "", nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for a non-admin, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodGet, "/ws?topic=gossip&ticket="+ticket(viewerToken), "", nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown topic, got %d", resp.StatusCode)
+		}
+
+		// The viewer was made an admin by AdminAnnouncement
+		wsURL := "ws" + strings.TrimPrefix(env.Server.URL, "http") + "/ws?topic=ops&ticket=" + ticket(viewerToken)
+		conn, _, err := gorillaws.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect to the ops topic: %v", err)
+		}
+		defer conn.Close()
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("Expected an ops.metrics event, got %v", err)
+			}
+			var event struct {
+				Type types.EventType      `json:"type"`
+				Data websocket.OpsMetrics `json:"data"`
+			}
+			if err := json.Unmarshal(data, &event); err != nil || event.Type != types.EventOpsMetrics {
+				continue
+			}
+			if event.Data.Hub.ConnectedClients < 1 || event.Data.IntervalSeconds <= 0 {
+				t.Errorf("Expected a snapshot counting this connection, got %+v", event.Data)
+			}
+			break
+		}
+	})
+
+	t.Run("MediaUploadURL", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/media/upload-url", authorToken, map[string]string{"content_type": "image/png"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected upload URL status 200, got %d", resp.StatusCode)
+		}
+		var upload struct {
+			Data struct {
+				ObjectKey string `json:"object_key"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+			t.Fatalf("Failed to decode upload URL: %v", err)
+		}
+
+		// Nothing has been uploaded yet
+		statusPath := "/media/" + url.PathEscape(upload.Data.ObjectKey) + "/status"
+		resp = env.Do(t, http.MethodGet, statusPath, authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected upload status 200, got %d", resp.StatusCode)
+		}
+		var status struct {
+			Data struct {
+				Status string `json:"status"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode upload status: %v", err)
+		}
+		if status.Data.Status != "initiated" {
+			t.Errorf("Expected an initiated upload, got %q", status.Data.Status)
+		}
+		resp = env.Do(t, http.MethodPost, "/media/confirm", authorToken, map[string]string{"object_key": upload.Data.ObjectKey})
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 confirming a missing upload, got %d", resp.StatusCode)
+		}
+
+		// Only the user who started an upload can see it
+		resp = env.Do(t, http.MethodGet, statusPath, viewerToken, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another user's upload, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("DeleteMedia", func(t *testing.T) {
+		author, err := env.Storage.GetUserByID(authorID)
+		if err != nil {
+			t.Fatalf("Failed to get author: %v", err)
+		}
+		bucket, err := env.Media.ForTenant(author.TenantID)
+		if err != nil {
+			t.Fatalf("Failed to get the tenant bucket: %v", err)
+		}
+		objectKey := "users/" + authorID + "/media/deleted.png"
+		if err := bucket.PutObject(context.Background(), objectKey, []byte("png"), "image/png"); err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		mediaPath := "/media/" + url.PathEscape(objectKey)
+		if resp := env.Do(t, http.MethodDelete, mediaPath, viewerToken, nil); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 deleting another user's media, got %d", resp.StatusCode)
+		}
+		if resp := env.Do(t, http.MethodDelete, mediaPath, authorToken, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected delete status 200, got %d", resp.StatusCode)
+		}
+
+		// The object is removed by the queued job
+		if _, err := bucket.GetObjectInfo(objectKey); err != nil {
+			t.Errorf("Expected the object to remain until the job runs, got %v", err)
+		}
+		env.Jobs.RunOnce(context.Background())
+		if _, err := bucket.GetObjectInfo(objectKey); !mediaService.IsNotFound(err) {
+			t.Errorf("Expected the object to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("MediaReconciliationReport", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/admin/media/reconciliation", authorToken, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for a non-admin, got %d", resp.StatusCode)
+		}
+		// The worker never runs against the test environment
+		resp = env.Do(t, http.MethodGet, "/admin/media/reconciliation", viewerToken, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 before the first run, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("DryRuns", func(t *testing.T) {
+		ctx := context.Background()
+		if err := env.Redis.Set(ctx, "feed:dry-run", "[]", time.Hour).Err(); err != nil {
+			t.Fatalf("Failed to seed the cache: %v", err)
+		}
+		resp := env.Do(t, http.MethodDelete, "/cache/clear?type=feed&dry_run=true", "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a cache clear dry run, got %d", resp.StatusCode)
+		}
+		if n, err := env.Redis.Exists(ctx, "feed:dry-run").Result(); err != nil || n != 1 {
+			t.Errorf("Expected a dry run to keep the cached feed, got %d (%v)", n, err)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/admin/archive/sweep?dry_run=true", authorToken, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for a non-admin, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/admin/archive/sweep?dry_run=true", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for an archive sweep dry run, got %d", resp.StatusCode)
+		}
+		sweep := testutil.DecodeJSON[response.Envelope[archive.Report]](t, resp)
+		if !sweep.Data.DryRun || sweep.Data.Tenants != 1 || len(sweep.Data.Errors) != 0 {
+			t.Errorf("Expected a dry run of one tenant, got %+v", sweep.Data)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/admin/media/gc?dry_run=true", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a media GC dry run, got %d", resp.StatusCode)
+		}
+		gc := testutil.DecodeJSON[response.Envelope[media.ReconciliationReport]](t, resp)
+		if !gc.Data.DryRun || gc.Data.Tenants != 1 {
+			t.Errorf("Expected a dry run of one tenant, got %+v", gc.Data)
+		}
+	})
+
+	t.Run("EncryptedStory", func(t *testing.T) {
+		resp := env.Do(t, http.MethodGet, "/users/"+viewerID+"/public-key", authorToken, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected status 404 before a key is published, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPut, "/me/public-key", viewerToken, users.PublicKeyRequest{Algorithm: "x25519", Key: "cHVibGljLWtleQ=="})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 publishing a key, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodGet, "/users/"+viewerID+"/public-key", authorToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a published key, got %d", resp.StatusCode)
+		}
+		if key := testutil.DecodeJSON[response.Envelope[users.PublicKey]](t, resp).Data; key.Key != "cHVibGljLWtleQ==" {
+			t.Errorf("Expected the published key, got %+v", key)
+		}
+
+		encrypted := func(userIDs ...string) *types.EncryptedContent {
+			content := &types.EncryptedContent{Algorithm: "xchacha20poly1305", Ciphertext: "c2VhbGVk", Nonce: "bm9uY2U="}
+			for _, userID := range userIDs {
+				content.RecipientKeys = append(content.RecipientKeys, types.RecipientKey{UserID: userID, WrappedKey: "d3JhcHBlZA=="})
+			}
+			return content
+		}
+
+		lat, long := 48.8566, 2.3522
+		rejected := map[string]types.StoryPostRequest{
+			"public":      {Visibility: types.VisibilityPublic, AudienceUserIDs: []string{}, Encrypted: encrypted(authorID)},
+			"plaintext":   {Text: "readable", Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{viewerID}, Encrypted: encrypted(authorID, viewerID)},
+			"missing key": {Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{viewerID}, Encrypted: encrypted(authorID)},
+			"place name":  {PlaceName: "Home", Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{viewerID}, Encrypted: encrypted(authorID, viewerID)},
+			"coordinates": {Latitude: &lat, Longitude: &long, Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{viewerID}, Encrypted: encrypted(authorID, viewerID)},
+		}
+		for name, story := range rejected {
+			resp = env.Do(t, http.MethodPost, "/stories", authorToken, story)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", name, resp.StatusCode)
+			}
+		}
+
+		resp = env.Do(t, http.MethodPost, "/stories", authorToken, types.StoryPostRequest{
+			Visibility:      types.VisibilityPrivate,
+			AudienceUserIDs: []string{viewerID},
+			Encrypted:       encrypted(authorID, viewerID),
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+		encryptedID := testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+encryptedID, viewerToken, nil)
+		if story := testutil.DecodeJSON[response.Envelope[types.Story]](t, resp).Data; !story.Encrypted || story.Text != "" {
+			t.Errorf("Expected an encrypted story without text, got %+v", story)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+encryptedID+"/envelope", viewerToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected envelope status 200, got %d", resp.StatusCode)
+		}
+		envelope := testutil.DecodeJSON[response.Envelope[types.StoryEnvelope]](t, resp).Data
+		if envelope.Ciphertext != "c2VhbGVk" || envelope.WrappedKey != "d3JhcHBlZA==" || envelope.AuthorID != authorID {
+			t.Errorf("Expected the viewer's envelope, got %+v", envelope)
+		}
+
+		// Stories that are not encrypted have no envelope
+		resp = env.Do(t, http.MethodGet, "/stories/"+storyID+"/envelope", authorToken, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a plaintext story, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ViewOnceStory", func(t *testing.T) {
+		resp := env.Do(t, http.MethodPost, "/stories", authorToken, types.StoryPostRequest{
+			Text:            "once",
+			Visibility:      types.VisibilityPublic,
+			AudienceUserIDs: []string{},
+			MaxViewsPerUser: 1,
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+		onceID := testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+
+		feedStory := func(path string) types.Story {
+			resp := env.Do(t, http.MethodGet, path, viewerToken, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected %s status 200, got %d", path, resp.StatusCode)
+			}
+			feed := testutil.DecodeJSON[response.Envelope[[]types.Story]](t, resp)
+			for _, story := range feed.Data {
+				if story.ID == onceID {
+					return story
+				}
+			}
+			t.Fatalf("Expected story %s in %s, got %v", onceID, path, testutil.StoryIDs(feed.Data))
+			return types.Story{}
+		}
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+onceID, viewerToken, nil)
+		if story := testutil.DecodeJSON[response.Envelope[types.Story]](t, resp).Data; story.Consumed || story.Text != "once" || story.MaxViewsPerUser != 1 {
+			t.Errorf("Expected an unopened view-once story, got %+v", story)
+		}
+		// Cache the viewer's feed while the story is still unopened
+		if story := feedStory("/feed"); story.Consumed || story.Text != "once" {
+			t.Errorf("Expected the unopened story in the feed, got %+v", story)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/stories/"+onceID+"/view", viewerToken, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected view status 200, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/stories/"+onceID+"/view", viewerToken, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusGone {
+			t.Errorf("Expected status 410 viewing it again, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/stories/"+onceID, viewerToken, nil)
+		if story := testutil.DecodeJSON[response.Envelope[types.Story]](t, resp).Data; !story.Consumed || story.Text != "" {
+			t.Errorf("Expected a consumed story without its text, got %+v", story)
+		}
+
+		// Feeds cannot reopen it either
+		for _, path := range []string{"/feed", "/feed?media_urls=true", "/feed/optimized"} {
+			if story := feedStory(path); !story.Consumed || story.Text != "" || story.MediaURL != "" {
+				t.Errorf("Expected %s to hold the consumed story without its text, got %+v", path, story)
+			}
+		}
+		// Nor can a share link
+		resp = env.Do(t, http.MethodPost, "/stories/"+onceID+"/share-link", authorToken, types.ShareLinkRequest{})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 sharing a view-once story by link, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("StoryReplies", func(t *testing.T) {
+		noReplies := false
+		storyIDs := make(map[bool]string)
+		for _, allowReply := range []*bool{nil, &noReplies} {
+			resp := env.Do(t, http.MethodPost, "/stories", authorToken, types.StoryPostRequest{
+				Text:            "reply to me",
+				Visibility:      types.VisibilityPublic,
+				AudienceUserIDs: []string{},
+				AllowReply:      allowReply,
+			})
+			if resp.StatusCode != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+			}
+			storyIDs[allowReply == nil] = testutil.DecodeJSON[response.Envelope[map[string]string]](t, resp).Data["id"]
+		}
+
+		for _, tc := range []struct {
+			name    string
+			storyID string
+			token   string
+			status  int
+		}{
+			{"allowed", storyIDs[true], viewerToken, http.StatusOK},
+			{"turned off", storyIDs[false], viewerToken, http.StatusForbidden},
+			{"own story", storyIDs[true], authorToken, http.StatusForbidden},
+		} {
+			resp := env.Do(t, http.MethodPost, "/stories/"+tc.storyID+"/reply", tc.token, types.ReplyRequest{Text: "nice"})
+			resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("%s: expected reply status %d, got %d", tc.name, tc.status, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Presence", func(t *testing.T) {
+		watched := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("watched"))
+		follower := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("follower"))
+		testutil.Follow(t, env.Storage, follower, watched)
+
+		for _, tc := range []struct {
+			name   string
+			token  string
+			status int
+		}{
+			{"themselves", env.Token(t, watched), http.StatusOK},
+			{"follower", env.Token(t, follower), http.StatusOK},
+			{"stranger", viewerToken, http.StatusNotFound},
+		} {
+			resp := env.Do(t, http.MethodGet, "/users/"+watched+"/presence", tc.token, nil)
+			resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("%s: expected presence status %d, got %d", tc.name, tc.status, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		userID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("subscriber"))
+		if err := env.Storage.SetNotificationSettings(userID, users.NotificationSettings{EmailWeeklyStats: true}); err != nil {
+			t.Fatalf("SetNotificationSettings failed: %v", err)
+		}
+		link := emailnotify.UnsubscribeURL("", env.Config.JWTSecret, userID, emailnotify.KindWeeklyStats)
+		subscribed := func() bool {
+			t.Helper()
+			settings, err := env.Storage.GetNotificationSettings(userID)
+			if err != nil {
+				t.Fatalf("GetNotificationSettings failed: %v", err)
+			}
+			return settings.EmailWeeklyStats
+		}
+
+		// Opening the link only asks to confirm
+		resp := env.Do(t, http.MethodGet, link, "", nil)
+		page, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), `method="post"`) {
+			t.Errorf("Expected a confirmation page, got %d: %s", resp.StatusCode, page)
+		}
+		if !subscribed() {
+			t.Error("Expected opening the link to leave the email on")
+		}
+
+		resp = env.Do(t, http.MethodGet, link+"x", "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a forged link, got %d", resp.StatusCode)
+		}
+
+		// Mail clients post to it for a one-click unsubscribe
+		req, err := http.NewRequest(http.MethodPost, env.Server.URL+link, strings.NewReader("List-Unsubscribe=One-Click"))
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("One-click unsubscribe failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if subscribed() {
+			t.Error("Expected the one-click unsubscribe to turn the email off")
+		}
+	})
+
+	t.Run("Impersonation", func(t *testing.T) {
+		adminID := testutil.CreateUser(t, env.Storage, testutil.UniqueEmail("support"))
+		if err := env.Storage.SetUserAdmin(adminID, true); err != nil {
+			t.Fatalf("SetUserAdmin failed: %v", err)
+		}
+		adminToken := env.Token(t, adminID)
+		impersonate := users.ImpersonationRequest{Reason: "TICKET-42: feed is empty", TTLMinutes: 5}
+
+		resp := env.Do(t, http.MethodPost, "/admin/users/"+authorID+"/impersonate", viewerToken, impersonate)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for a non-admin, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/admin/users/"+adminID+"/impersonate", adminToken, impersonate)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 impersonating an admin, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodPost, "/admin/users/"+authorID+"/impersonate", adminToken, users.ImpersonationRequest{})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 without a reason, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodPost, "/admin/users/"+authorID+"/impersonate", adminToken, impersonate)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+		issued := testutil.DecodeJSON[response.Envelope[users.ImpersonationToken]](t, resp).Data
+		if issued.UserID != authorID || issued.ImpersonatorID != adminID {
+			t.Errorf("Expected a token for %s issued to %s, got %+v", authorID, adminID, issued)
+		}
+
+		// The token reads as the user, and nothing else
+		resp = env.Do(t, http.MethodGet, "/me", issued.Token, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 reading as the user, got %d", resp.StatusCode)
+		}
+		if by := resp.Header.Get(middleware.ImpersonatedByHeader); by != adminID {
+			t.Errorf("Expected responses to name admin %s, got %q", adminID, by)
+		}
+		resp = env.Do(t, http.MethodPost, "/stories", issued.Token, types.StoryPostRequest{Text: "as someone else", Visibility: types.VisibilityPublic})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 writing as the user, got %d", resp.StatusCode)
+		}
+		resp = env.Do(t, http.MethodGet, "/admin/impersonations", issued.Token, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for admin routes, got %d", resp.StatusCode)
+		}
+
+		resp = env.Do(t, http.MethodGet, "/admin/impersonations?user_id="+authorID, adminToken, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected audit trail status 200, got %d", resp.StatusCode)
+		}
+		trail := testutil.DecodeJSON[response.Envelope[[]users.ImpersonationEvent]](t, resp).Data
+		if len(trail) != 3 {
+			t.Fatalf("Expected the issue and two reads on record, got %+v", trail)
+		}
+		if trail[2].Action != users.ImpersonationIssued || trail[2].Reason != impersonate.Reason || trail[2].AdminID != adminID {
+			t.Errorf("Expected the issue with its reason oldest, got %+v", trail[2])
+		}
+		if trail[1].Action != users.ImpersonationUsed || trail[1].Method != http.MethodGet || trail[1].Path != "/me" {
+			t.Errorf("Expected the read of /me next, got %+v", trail[1])
+		}
+	})
+}
@@ -0,0 +1,648 @@
+package i18n
+
+// MessageKey identifies a user-facing message in the catalog
+type MessageKey string
+
+const (
+	// Authentication
+	MsgUserNotAuthenticated    MessageKey = "user_not_authenticated"
+	MsgAuthHeaderRequired      MessageKey = "auth_header_required"
+	MsgInvalidAuthHeaderFormat MessageKey = "invalid_auth_header_format"
+	MsgTokenNotProvided        MessageKey = "token_not_provided"
+	MsgInvalidToken            MessageKey = "invalid_token"
+	MsgTokenRevoked            MessageKey = "token_revoked"
+	MsgInvalidTenant           MessageKey = "invalid_tenant"
+	MsgInvalidCredentials      MessageKey = "invalid_credentials"
+	MsgFailedToHashPassword    MessageKey = "failed_to_hash_password"
+	MsgFailedToGenerateToken   MessageKey = "failed_to_generate_token"
+	MsgRateLimitExceeded       MessageKey = "rate_limit_exceeded"
+	MsgAccessDenied            MessageKey = "access_denied"
+	MsgTicketNotProvided       MessageKey = "ticket_not_provided"
+	MsgInvalidTicket           MessageKey = "invalid_ticket"
+	MsgFailedToIssueTicket     MessageKey = "failed_to_issue_ticket"
+	MsgSessionNotFound         MessageKey = "session_not_found"
+	MsgFailedToCreateSession   MessageKey = "failed_to_create_session"
+	MsgFailedToListSessions    MessageKey = "failed_to_list_sessions"
+	MsgFailedToRevokeSession   MessageKey = "failed_to_revoke_session"
+	MsgTokenScopeDenied        MessageKey = "token_scope_denied"
+	MsgAPITokenNotFound        MessageKey = "api_token_not_found"
+	MsgFailedToCreateAPIToken  MessageKey = "failed_to_create_api_token"
+	MsgFailedToListAPITokens   MessageKey = "failed_to_list_api_tokens"
+	MsgFailedToRevokeAPIToken  MessageKey = "failed_to_revoke_api_token"
+
+	// Requests
+	MsgRequestBodyEmpty MessageKey = "request_body_empty"
+	MsgServerBusy       MessageKey = "server_busy"
+
+	// Stories
+	MsgStoryIDRequired         MessageKey = "story_id_required"
+	MsgStoryNotFound           MessageKey = "story_not_found"
+	MsgStoryForbidden          MessageKey = "story_forbidden"
+	MsgStoryHasNoLink          MessageKey = "story_has_no_link"
+	MsgOnlyAuthorHighlight     MessageKey = "only_author_highlight"
+	MsgOnlyAuthorDelete        MessageKey = "only_author_delete"
+	MsgFailedToDeleteStory     MessageKey = "failed_to_delete_story"
+	MsgOnlyAuthorShare         MessageKey = "only_author_share"
+	MsgOnlyAuthorViewers       MessageKey = "only_author_viewers"
+	MsgFailedToGetViewers      MessageKey = "failed_to_get_viewers"
+	MsgSelfReactionNotAllowed  MessageKey = "self_reaction_not_allowed"
+	MsgReactionNotFound        MessageKey = "reaction_not_found"
+	MsgFailedToShareStory      MessageKey = "failed_to_share_story"
+	MsgShareLinkNotFound       MessageKey = "share_link_not_found"
+	MsgShareLinkLoginRequired  MessageKey = "share_link_login_required"
+	MsgOEmbedURLInvalid        MessageKey = "oembed_url_invalid"
+	MsgOEmbedFormatUnsupported MessageKey = "oembed_format_unsupported"
+	MsgInvalidLatitude         MessageKey = "invalid_latitude"
+	MsgInvalidLongitude        MessageKey = "invalid_longitude"
+	MsgInvalidNearbyRadius     MessageKey = "invalid_nearby_radius"
+	MsgFailedToGetFeedTrays    MessageKey = "failed_to_get_feed_trays"
+	MsgInvalidSince            MessageKey = "invalid_since"
+	MsgFailedToGetFeedChanges  MessageKey = "failed_to_get_feed_changes"
+
+	// Users
+	MsgUserIDRequired          MessageKey = "user_id_required"
+	MsgUserNotFound            MessageKey = "user_not_found"
+	MsgFollowNotFound          MessageKey = "follow_not_found"
+	MsgFailedToFollowUser      MessageKey = "failed_to_follow_user"
+	MsgFailedToUnfollowUser    MessageKey = "failed_to_unfollow_user"
+	MsgFailedToGetUserStats    MessageKey = "failed_to_get_user_stats"
+	MsgExportFormatUnsupported MessageKey = "export_format_unsupported"
+	MsgInvalidExportRange      MessageKey = "invalid_export_range"
+	MsgFailedToExportStats     MessageKey = "failed_to_export_stats"
+	MsgFailedToGetProfile      MessageKey = "failed_to_get_profile"
+
+	// Notifications
+	MsgFailedToGetPrivacySettings         MessageKey = "failed_to_get_privacy_settings"
+	MsgFailedToUpdatePrivacySettings      MessageKey = "failed_to_update_privacy_settings"
+	MsgFailedToGetNotificationSettings    MessageKey = "failed_to_get_notification_settings"
+	MsgFailedToUpdateNotificationSettings MessageKey = "failed_to_update_notification_settings"
+	MsgInvalidUnsubscribeLink             MessageKey = "invalid_unsubscribe_link"
+
+	// Media
+	MsgObjectKeyRequired               MessageKey = "object_key_required"
+	MsgMediaNotFound                   MessageKey = "media_not_found"
+	MsgFailedToListMedia               MessageKey = "failed_to_list_media"
+	MsgInvalidMediaListLimit           MessageKey = "invalid_media_list_limit"
+	MsgInvalidContinuationToken        MessageKey = "invalid_continuation_token"
+	MsgFailedToDeleteMedia             MessageKey = "failed_to_delete_media"
+	MsgFailedToGenerateDownload        MessageKey = "failed_to_generate_download_url"
+	MsgFailedToRecordUpload            MessageKey = "failed_to_record_upload"
+	MsgFailedToConfirmUpload           MessageKey = "failed_to_confirm_upload"
+	MsgUploadFailed                    MessageKey = "upload_failed"
+	MsgUploadTooLarge                  MessageKey = "upload_too_large"
+	MsgFailedToGetUploadStatus         MessageKey = "failed_to_get_upload_status"
+	MsgNoReconciliationReport          MessageKey = "no_reconciliation_report"
+	MsgFailedToGetReconciliationReport MessageKey = "failed_to_get_reconciliation_report"
+	MsgEncryptionRequiresPrivate       MessageKey = "encryption_requires_private"
+	MsgEncryptedStoryPlaintext         MessageKey = "encrypted_story_plaintext"
+	MsgRecipientKeysMismatch           MessageKey = "recipient_keys_mismatch"
+	MsgEnvelopeNotFound                MessageKey = "envelope_not_found"
+	MsgFailedToGetEnvelope             MessageKey = "failed_to_get_envelope"
+	MsgPublicKeyNotFound               MessageKey = "public_key_not_found"
+	MsgFailedToGetPublicKey            MessageKey = "failed_to_get_public_key"
+	MsgFailedToSetPublicKey            MessageKey = "failed_to_set_public_key"
+	MsgImpersonationReadOnly           MessageKey = "impersonation_read_only"
+	MsgCannotImpersonateAdmin          MessageKey = "cannot_impersonate_admin"
+	MsgFailedToImpersonate             MessageKey = "failed_to_impersonate"
+	MsgFailedToGetImpersonations       MessageKey = "failed_to_get_impersonations"
+	MsgInvalidImpersonationLimit       MessageKey = "invalid_impersonation_limit"
+	MsgFailedToGetLimits               MessageKey = "failed_to_get_limits"
+	MsgInternalError                   MessageKey = "internal_error"
+	MsgInvalidLimit                    MessageKey = "invalid_limit"
+	MsgStoryNotArchived                MessageKey = "story_not_archived"
+	MsgArchivedStoryAuthorGone         MessageKey = "archived_story_author_gone"
+	MsgFailedToRestoreStory            MessageKey = "failed_to_restore_story"
+	MsgFailedToRecordImpressions       MessageKey = "failed_to_record_impressions"
+	MsgFailedToGetClientStats          MessageKey = "failed_to_get_client_stats"
+	MsgFailedToGetAbuseFlags           MessageKey = "failed_to_get_abuse_flags"
+	MsgAbuseFlagNotFound               MessageKey = "abuse_flag_not_found"
+	MsgFailedToReviewAbuseFlag         MessageKey = "failed_to_review_abuse_flag"
+	MsgFailedToLiftThrottle            MessageKey = "failed_to_lift_throttle"
+	MsgMediaNotOwned                   MessageKey = "media_not_owned"
+	MsgMediaNotConfirmed               MessageKey = "media_not_confirmed"
+	MsgReshareNotPublic                MessageKey = "reshare_not_public"
+	MsgSelfReshareNotAllowed           MessageKey = "self_reshare_not_allowed"
+	MsgAlreadyReshared                 MessageKey = "already_reshared"
+	MsgFailedToReshareStory            MessageKey = "failed_to_reshare_story"
+	MsgGroupIDMismatch                 MessageKey = "group_id_mismatch"
+	MsgNotGroupMember                  MessageKey = "not_group_member"
+	MsgGroupIDRequired                 MessageKey = "group_id_required"
+	MsgGroupNotFound                   MessageKey = "group_not_found"
+	MsgGroupMemberNotFound             MessageKey = "group_member_not_found"
+	MsgNotGroupOwner                   MessageKey = "not_group_owner"
+	MsgGroupOwnerCannotLeave           MessageKey = "group_owner_cannot_leave"
+	MsgFailedToCreateGroup             MessageKey = "failed_to_create_group"
+	MsgFailedToGetGroups               MessageKey = "failed_to_get_groups"
+	MsgFailedToUpdateGroupMembers      MessageKey = "failed_to_update_group_members"
+	MsgFailedToGetGroupStories         MessageKey = "failed_to_get_group_stories"
+	MsgCannotHideSelf                  MessageKey = "cannot_hide_self"
+	MsgAuthorNotHidden                 MessageKey = "author_not_hidden"
+	MsgFailedToHideStories             MessageKey = "failed_to_hide_stories"
+	MsgFailedToUnhideStories           MessageKey = "failed_to_unhide_stories"
+	MsgFailedToGetHiddenAuthors        MessageKey = "failed_to_get_hidden_authors"
+	MsgOnlyAuthorEdit                  MessageKey = "only_author_edit"
+	MsgStoryVersionRequired            MessageKey = "story_version_required"
+	MsgInvalidIfMatch                  MessageKey = "invalid_if_match"
+	MsgStoryVersionConflict            MessageKey = "story_version_conflict"
+	MsgEncryptedStoryNotEditable       MessageKey = "encrypted_story_not_editable"
+	MsgAudienceRequiresVisibility      MessageKey = "audience_requires_visibility"
+	MsgFailedToUpdateStory             MessageKey = "failed_to_update_story"
+	MsgUnknownTopic                    MessageKey = "unknown_topic"
+	MsgUnknownField                    MessageKey = "unknown_field"
+	MsgCannotFollowSelf                MessageKey = "cannot_follow_self"
+	MsgFailedToDiscoverUsers           MessageKey = "failed_to_discover_users"
+	MsgVisibilityRequired              MessageKey = "visibility_required"
+	MsgFailedToGetStorySettings        MessageKey = "failed_to_get_story_settings"
+	MsgFailedToUpdateStorySettings     MessageKey = "failed_to_update_story_settings"
+	MsgExportNotFound                  MessageKey = "export_not_found"
+	MsgInvalidRecapLimit               MessageKey = "invalid_recap_limit"
+	MsgFailedToGetRecaps               MessageKey = "failed_to_get_recaps"
+	MsgInvalidFeedCursor               MessageKey = "invalid_feed_cursor"
+	MsgStoryConsumed                   MessageKey = "story_consumed"
+	MsgScreenshotSignalsDisabled       MessageKey = "screenshot_signals_disabled"
+	MsgFailedToRecordScreenshot        MessageKey = "failed_to_record_screenshot"
+	MsgReshareNotAllowed               MessageKey = "reshare_not_allowed"
+	MsgViewLimitedNotShareable         MessageKey = "view_limited_not_shareable"
+	MsgReplyNotAllowed                 MessageKey = "reply_not_allowed"
+	MsgSelfReplyNotAllowed             MessageKey = "self_reply_not_allowed"
+	MsgFailedToSendAnnouncement        MessageKey = "failed_to_send_announcement"
+	MsgReplyNotDelivered               MessageKey = "reply_not_delivered"
+	MsgEncryptedStoryLocation          MessageKey = "encrypted_story_location"
+	MsgFailedToSetAvatar               MessageKey = "failed_to_set_avatar"
+	MsgServiceStarting                 MessageKey = "service_starting"
+)
+
+// catalog holds every user-facing message per supported locale
+var catalog = map[string]map[MessageKey]string{
+	"en": {
+		MsgUserNotAuthenticated:               "user not authenticated",
+		MsgAuthHeaderRequired:                 "Authorization header required",
+		MsgInvalidAuthHeaderFormat:            "Invalid authorization header format",
+		MsgTokenNotProvided:                   "Token not provided",
+		MsgInvalidToken:                       "Invalid token",
+		MsgTokenRevoked:                       "Token has been revoked",
+		MsgInvalidTenant:                      "invalid tenant ID",
+		MsgInvalidCredentials:                 "invalid email or password",
+		MsgFailedToHashPassword:               "failed to hash password",
+		MsgFailedToGenerateToken:              "failed to generate token",
+		MsgRateLimitExceeded:                  "rate limit exceeded",
+		MsgAccessDenied:                       "access denied",
+		MsgTicketNotProvided:                  "Ticket not provided",
+		MsgInvalidTicket:                      "Invalid or expired ticket",
+		MsgFailedToIssueTicket:                "failed to issue connection ticket",
+		MsgSessionNotFound:                    "session not found",
+		MsgFailedToCreateSession:              "failed to create session",
+		MsgFailedToListSessions:               "failed to list sessions",
+		MsgFailedToRevokeSession:              "failed to revoke session",
+		MsgTokenScopeDenied:                   "token scopes do not allow this request",
+		MsgAPITokenNotFound:                   "API token not found",
+		MsgFailedToCreateAPIToken:             "failed to create API token",
+		MsgFailedToListAPITokens:              "failed to list API tokens",
+		MsgFailedToRevokeAPIToken:             "failed to revoke API token",
+		MsgRequestBodyEmpty:                   "request body cannot be empty",
+		MsgServerBusy:                         "server is busy, please retry later",
+		MsgStoryIDRequired:                    "story ID is required",
+		MsgStoryNotFound:                      "story not found",
+		MsgStoryForbidden:                     "you don't have permission to view this story",
+		MsgStoryHasNoLink:                     "story has no link",
+		MsgOnlyAuthorHighlight:                "only the author can highlight this story",
+		MsgOnlyAuthorDelete:                   "only the author can delete this story",
+		MsgFailedToDeleteStory:                "failed to delete story",
+		MsgOnlyAuthorShare:                    "only the author can share this story",
+		MsgOnlyAuthorViewers:                  "only the author can see who viewed this story",
+		MsgFailedToGetViewers:                 "failed to get story viewers",
+		MsgSelfReactionNotAllowed:             "you cannot react to your own story",
+		MsgReactionNotFound:                   "you have not reacted to this story",
+		MsgFailedToShareStory:                 "failed to share story",
+		MsgShareLinkNotFound:                  "share link not found or no longer valid",
+		MsgShareLinkLoginRequired:             "sign in to view this shared story",
+		MsgOEmbedURLInvalid:                   "url must link to a public or shared story",
+		MsgOEmbedFormatUnsupported:            "only the json format is supported",
+		MsgInvalidLatitude:                    "lat must be a number between -90 and 90",
+		MsgInvalidLongitude:                   "lng must be a number between -180 and 180",
+		MsgInvalidNearbyRadius:                "radius must be a positive number of meters up to 50000",
+		MsgFailedToGetFeedTrays:               "failed to get story trays",
+		MsgInvalidSince:                       "since must be an RFC 3339 timestamp or a cursor from a previous response",
+		MsgFailedToGetFeedChanges:             "failed to get feed changes",
+		MsgUserIDRequired:                     "user_id is required",
+		MsgUserNotFound:                       "user not found",
+		MsgFollowNotFound:                     "follow relationship not found",
+		MsgFailedToFollowUser:                 "failed to follow user",
+		MsgFailedToUnfollowUser:               "failed to unfollow user",
+		MsgFailedToGetUserStats:               "failed to get user stats",
+		MsgExportFormatUnsupported:            "only the csv format is supported",
+		MsgInvalidExportRange:                 "from and to must be YYYY-MM-DD days, from no later than to and at most 366 days apart",
+		MsgFailedToExportStats:                "failed to export stats",
+		MsgFailedToGetProfile:                 "failed to get user profile",
+		MsgFailedToGetPrivacySettings:         "failed to get privacy settings",
+		MsgFailedToUpdatePrivacySettings:      "failed to update privacy settings",
+		MsgFailedToGetNotificationSettings:    "failed to get notification settings",
+		MsgFailedToUpdateNotificationSettings: "failed to update notification settings",
+		MsgInvalidUnsubscribeLink:             "invalid unsubscribe link",
+		MsgObjectKeyRequired:                  "object key is required",
+		MsgMediaNotFound:                      "media not found",
+		MsgFailedToListMedia:                  "failed to list media files",
+		MsgInvalidMediaListLimit:              "limit must be a number from 1 to 1000",
+		MsgInvalidContinuationToken:           "invalid continuation token",
+		MsgFailedToDeleteMedia:                "failed to delete media file",
+		MsgFailedToGenerateDownload:           "failed to generate download URL",
+		MsgFailedToRecordUpload:               "failed to record the upload",
+		MsgFailedToConfirmUpload:              "failed to confirm the upload",
+		MsgUploadFailed:                       "the upload failed; request a new upload URL",
+		MsgUploadTooLarge:                     "the uploaded file is larger than allowed",
+		MsgFailedToGetUploadStatus:            "failed to get the upload status",
+		MsgNoReconciliationReport:             "the media reconciliation job has not run yet",
+		MsgFailedToGetReconciliationReport:    "failed to get the media reconciliation report",
+		MsgEncryptionRequiresPrivate:          "only PRIVATE stories can be encrypted",
+		MsgEncryptedStoryPlaintext:            "encrypted stories carry their text in the envelope; text and link_url must be empty",
+		MsgRecipientKeysMismatch:              "recipient_keys must hold exactly one key for the author and each audience member",
+		MsgEnvelopeNotFound:                   "no encrypted content for you on this story",
+		MsgFailedToGetEnvelope:                "failed to get the story envelope",
+		MsgPublicKeyNotFound:                  "the user has not published a public key",
+		MsgFailedToGetPublicKey:               "failed to get the public key",
+		MsgFailedToSetPublicKey:               "failed to publish the public key",
+		MsgImpersonationReadOnly:              "impersonation tokens can only be used to read",
+		MsgCannotImpersonateAdmin:             "admins cannot be impersonated",
+		MsgFailedToImpersonate:                "failed to issue the impersonation token",
+		MsgFailedToGetImpersonations:          "failed to get the impersonation audit trail",
+		MsgInvalidImpersonationLimit:          "limit must be a number from 1 to 200",
+		MsgFailedToGetLimits:                  "failed to get rate limits",
+		MsgInternalError:                      "internal server error",
+		MsgInvalidLimit:                       "limit must be a number from 1 to %d",
+		MsgStoryNotArchived:                   "story is not archived",
+		MsgArchivedStoryAuthorGone:            "the author of the archived story no longer exists",
+		MsgFailedToRestoreStory:               "failed to restore story",
+		MsgFailedToRecordImpressions:          "failed to record impressions",
+		MsgFailedToGetClientStats:             "failed to get client stats",
+		MsgFailedToGetAbuseFlags:              "failed to get abuse flags",
+		MsgAbuseFlagNotFound:                  "abuse flag not found",
+		MsgFailedToReviewAbuseFlag:            "failed to review abuse flag",
+		MsgFailedToLiftThrottle:               "failed to lift throttle",
+		MsgMediaNotOwned:                      "media_key is not an upload of yours",
+		MsgMediaNotConfirmed:                  "confirm the media upload before posting it",
+		MsgReshareNotPublic:                   "only active public stories can be reshared",
+		MsgSelfReshareNotAllowed:              "you cannot reshare your own story",
+		MsgAlreadyReshared:                    "you already reshared this story",
+		MsgFailedToReshareStory:               "failed to reshare story",
+		MsgGroupIDMismatch:                    "group_id is required for GROUP stories and not allowed for others",
+		MsgNotGroupMember:                     "you are not a member of this group",
+		MsgGroupIDRequired:                    "group id is required",
+		MsgGroupNotFound:                      "group not found",
+		MsgGroupMemberNotFound:                "user is not a member of this group",
+		MsgNotGroupOwner:                      "only the group owner can manage its members",
+		MsgGroupOwnerCannotLeave:              "the group owner cannot leave the group",
+		MsgFailedToCreateGroup:                "failed to create group",
+		MsgFailedToGetGroups:                  "failed to get groups",
+		MsgFailedToUpdateGroupMembers:         "failed to update group members",
+		MsgFailedToGetGroupStories:            "failed to get group stories",
+		MsgCannotHideSelf:                     "you cannot hide your own stories",
+		MsgAuthorNotHidden:                    "this user's stories are not hidden",
+		MsgFailedToHideStories:                "failed to hide stories",
+		MsgFailedToUnhideStories:              "failed to unhide stories",
+		MsgFailedToGetHiddenAuthors:           "failed to get hidden authors",
+		MsgOnlyAuthorEdit:                     "only the author can edit this story",
+		MsgStoryVersionRequired:               "send the version being edited in If-Match or version",
+		MsgInvalidIfMatch:                     "If-Match must be a story version, such as \"3\"",
+		MsgStoryVersionConflict:               "the story was edited since that version; fetch it and try again",
+		MsgEncryptedStoryNotEditable:          "encrypted stories cannot be edited",
+		MsgAudienceRequiresVisibility:         "audience_user_ids and group_id can only be changed along with visibility",
+		MsgFailedToUpdateStory:                "failed to update story",
+		MsgUnknownTopic:                       "unknown WebSocket topic",
+		MsgUnknownField:                       "fields names an unknown field: %q",
+		MsgCannotFollowSelf:                   "you cannot follow yourself",
+		MsgFailedToDiscoverUsers:              "failed to discover users",
+		MsgVisibilityRequired:                 "visibility is required unless you set a default visibility",
+		MsgFailedToGetStorySettings:           "failed to get story settings",
+		MsgFailedToUpdateStorySettings:        "failed to update story settings",
+		MsgExportNotFound:                     "export not found",
+		MsgInvalidRecapLimit:                  "limit must be a number from 1 to 52",
+		MsgFailedToGetRecaps:                  "failed to get weekly recaps",
+		MsgInvalidFeedCursor:                  "cursor must be the X-Next-Cursor of a previous feed page",
+		MsgStoryConsumed:                      "you have viewed this story as many times as it allows",
+		MsgScreenshotSignalsDisabled:          "screenshot signals are turned off",
+		MsgFailedToRecordScreenshot:           "failed to record screenshot",
+		MsgReshareNotAllowed:                  "the author does not allow this story to be reshared",
+		MsgViewLimitedNotShareable:            "stories limiting how often each viewer may open them cannot be shared by link",
+		MsgReplyNotAllowed:                    "the author does not allow replies to this story",
+		MsgSelfReplyNotAllowed:                "you cannot reply to your own story",
+		MsgFailedToSendAnnouncement:           "Failed to send the announcement",
+		MsgReplyNotDelivered:                  "The reply could not be delivered, please try again",
+		MsgEncryptedStoryLocation:             "encrypted stories cannot carry a location; place_name, latitude and longitude must be empty",
+		MsgFailedToSetAvatar:                  "failed to update the avatar",
+		MsgServiceStarting:                    "service is starting",
+	},
+	"es": {
+		MsgUserNotAuthenticated:               "usuario no autenticado",
+		MsgAuthHeaderRequired:                 "se requiere el encabezado Authorization",
+		MsgInvalidAuthHeaderFormat:            "formato de encabezado de autorización no válido",
+		MsgTokenNotProvided:                   "no se proporcionó el token",
+		MsgInvalidToken:                       "token no válido",
+		MsgTokenRevoked:                       "el token ha sido revocado",
+		MsgInvalidTenant:                      "ID de inquilino no válido",
+		MsgInvalidCredentials:                 "correo electrónico o contraseña no válidos",
+		MsgFailedToHashPassword:               "no se pudo procesar la contraseña",
+		MsgFailedToGenerateToken:              "no se pudo generar el token",
+		MsgRateLimitExceeded:                  "límite de solicitudes excedido",
+		MsgAccessDenied:                       "acceso denegado",
+		MsgTicketNotProvided:                  "no se proporcionó el ticket",
+		MsgInvalidTicket:                      "ticket no válido o caducado",
+		MsgFailedToIssueTicket:                "no se pudo emitir el ticket de conexión",
+		MsgSessionNotFound:                    "sesión no encontrada",
+		MsgFailedToCreateSession:              "no se pudo crear la sesión",
+		MsgFailedToListSessions:               "no se pudieron listar las sesiones",
+		MsgFailedToRevokeSession:              "no se pudo revocar la sesión",
+		MsgTokenScopeDenied:                   "los permisos del token no permiten esta solicitud",
+		MsgAPITokenNotFound:                   "token de API no encontrado",
+		MsgFailedToCreateAPIToken:             "no se pudo crear el token de API",
+		MsgFailedToListAPITokens:              "no se pudieron listar los tokens de API",
+		MsgFailedToRevokeAPIToken:             "no se pudo revocar el token de API",
+		MsgRequestBodyEmpty:                   "el cuerpo de la solicitud no puede estar vacío",
+		MsgServerBusy:                         "el servidor está ocupado, inténtalo más tarde",
+		MsgStoryIDRequired:                    "se requiere el ID de la historia",
+		MsgStoryNotFound:                      "historia no encontrada",
+		MsgStoryForbidden:                     "no tienes permiso para ver esta historia",
+		MsgStoryHasNoLink:                     "la historia no tiene enlace",
+		MsgOnlyAuthorHighlight:                "solo el autor puede destacar esta historia",
+		MsgOnlyAuthorDelete:                   "solo el autor puede eliminar esta historia",
+		MsgFailedToDeleteStory:                "no se pudo eliminar la historia",
+		MsgOnlyAuthorShare:                    "solo el autor puede compartir esta historia",
+		MsgOnlyAuthorViewers:                  "solo el autor puede ver quién vio esta historia",
+		MsgFailedToGetViewers:                 "no se pudieron obtener los espectadores de la historia",
+		MsgSelfReactionNotAllowed:             "no puedes reaccionar a tu propia historia",
+		MsgReactionNotFound:                   "no has reaccionado a esta historia",
+		MsgFailedToShareStory:                 "no se pudo compartir la historia",
+		MsgShareLinkNotFound:                  "enlace compartido no encontrado o ya no es válido",
+		MsgShareLinkLoginRequired:             "inicia sesión para ver esta historia compartida",
+		MsgOEmbedURLInvalid:                   "url debe enlazar a una historia pública o compartida",
+		MsgOEmbedFormatUnsupported:            "solo se admite el formato json",
+		MsgInvalidLatitude:                    "lat debe ser un número entre -90 y 90",
+		MsgInvalidLongitude:                   "lng debe ser un número entre -180 y 180",
+		MsgInvalidNearbyRadius:                "radius debe ser un número positivo de metros hasta 50000",
+		MsgFailedToGetFeedTrays:               "no se pudieron obtener las bandejas de historias",
+		MsgInvalidSince:                       "since debe ser una marca de tiempo RFC 3339 o un cursor de una respuesta anterior",
+		MsgFailedToGetFeedChanges:             "no se pudieron obtener los cambios del feed",
+		MsgUserIDRequired:                     "se requiere user_id",
+		MsgUserNotFound:                       "usuario no encontrado",
+		MsgFollowNotFound:                     "relación de seguimiento no encontrada",
+		MsgFailedToFollowUser:                 "no se pudo seguir al usuario",
+		MsgFailedToUnfollowUser:               "no se pudo dejar de seguir al usuario",
+		MsgFailedToGetUserStats:               "no se pudieron obtener las estadísticas del usuario",
+		MsgExportFormatUnsupported:            "solo se admite el formato csv",
+		MsgInvalidExportRange:                 "from y to deben ser días AAAA-MM-DD, from no posterior a to y a lo sumo 366 días de diferencia",
+		MsgFailedToExportStats:                "no se pudieron exportar las estadísticas",
+		MsgFailedToGetProfile:                 "no se pudo obtener el perfil del usuario",
+		MsgFailedToGetPrivacySettings:         "no se pudo obtener la configuración de privacidad",
+		MsgFailedToUpdatePrivacySettings:      "no se pudo actualizar la configuración de privacidad",
+		MsgFailedToGetNotificationSettings:    "no se pudo obtener la configuración de notificaciones",
+		MsgFailedToUpdateNotificationSettings: "no se pudo actualizar la configuración de notificaciones",
+		MsgInvalidUnsubscribeLink:             "enlace para darse de baja no válido",
+		MsgObjectKeyRequired:                  "se requiere la clave del objeto",
+		MsgMediaNotFound:                      "archivo multimedia no encontrado",
+		MsgFailedToListMedia:                  "no se pudieron listar los archivos multimedia",
+		MsgInvalidMediaListLimit:              "limit debe ser un número del 1 al 1000",
+		MsgInvalidContinuationToken:           "token de continuación no válido",
+		MsgFailedToDeleteMedia:                "no se pudo eliminar el archivo multimedia",
+		MsgFailedToGenerateDownload:           "no se pudo generar la URL de descarga",
+		MsgFailedToRecordUpload:               "no se pudo registrar la subida",
+		MsgFailedToConfirmUpload:              "no se pudo confirmar la subida",
+		MsgUploadFailed:                       "la subida falló; solicita una nueva URL de subida",
+		MsgUploadTooLarge:                     "el archivo subido supera el tamaño permitido",
+		MsgFailedToGetUploadStatus:            "no se pudo obtener el estado de la subida",
+		MsgNoReconciliationReport:             "el trabajo de conciliación de medios aún no se ha ejecutado",
+		MsgFailedToGetReconciliationReport:    "no se pudo obtener el informe de conciliación de medios",
+		MsgEncryptionRequiresPrivate:          "solo las historias PRIVATE pueden cifrarse",
+		MsgEncryptedStoryPlaintext:            "las historias cifradas llevan su texto en el sobre; text y link_url deben estar vacíos",
+		MsgRecipientKeysMismatch:              "recipient_keys debe tener exactamente una clave para el autor y cada miembro de la audiencia",
+		MsgEnvelopeNotFound:                   "no hay contenido cifrado para ti en esta historia",
+		MsgFailedToGetEnvelope:                "no se pudo obtener el sobre de la historia",
+		MsgPublicKeyNotFound:                  "el usuario no ha publicado una clave pública",
+		MsgFailedToGetPublicKey:               "no se pudo obtener la clave pública",
+		MsgFailedToSetPublicKey:               "no se pudo publicar la clave pública",
+		MsgImpersonationReadOnly:              "los tokens de suplantación solo pueden usarse para leer",
+		MsgCannotImpersonateAdmin:             "no se puede suplantar a un administrador",
+		MsgFailedToImpersonate:                "no se pudo emitir el token de suplantación",
+		MsgFailedToGetImpersonations:          "no se pudo obtener el registro de suplantaciones",
+		MsgInvalidImpersonationLimit:          "limit debe ser un número del 1 al 200",
+		MsgFailedToGetLimits:                  "no se pudieron obtener los límites de uso",
+		MsgInternalError:                      "error interno del servidor",
+		MsgInvalidLimit:                       "el límite debe ser un número de 1 a %d",
+		MsgStoryNotArchived:                   "la historia no está archivada",
+		MsgArchivedStoryAuthorGone:            "el autor de la historia archivada ya no existe",
+		MsgFailedToRestoreStory:               "no se pudo restaurar la historia",
+		MsgFailedToRecordImpressions:          "no se pudieron registrar las impresiones",
+		MsgFailedToGetClientStats:             "no se pudieron obtener las estadísticas de clientes",
+		MsgFailedToGetAbuseFlags:              "no se pudieron obtener las alertas de abuso",
+		MsgAbuseFlagNotFound:                  "alerta de abuso no encontrada",
+		MsgFailedToReviewAbuseFlag:            "no se pudo revisar la alerta de abuso",
+		MsgFailedToLiftThrottle:               "no se pudo levantar la limitación",
+		MsgMediaNotOwned:                      "media_key no es una subida tuya",
+		MsgMediaNotConfirmed:                  "confirma la subida del archivo multimedia antes de publicarlo",
+		MsgReshareNotPublic:                   "solo se pueden compartir historias públicas activas",
+		MsgSelfReshareNotAllowed:              "no puedes volver a compartir tu propia historia",
+		MsgAlreadyReshared:                    "ya compartiste esta historia",
+		MsgFailedToReshareStory:               "no se pudo compartir la historia",
+		MsgGroupIDMismatch:                    "group_id es obligatorio para las historias GROUP y no se permite en las demás",
+		MsgNotGroupMember:                     "no eres miembro de este grupo",
+		MsgGroupIDRequired:                    "el id del grupo es obligatorio",
+		MsgGroupNotFound:                      "grupo no encontrado",
+		MsgGroupMemberNotFound:                "el usuario no es miembro de este grupo",
+		MsgNotGroupOwner:                      "solo el propietario del grupo puede gestionar sus miembros",
+		MsgGroupOwnerCannotLeave:              "el propietario del grupo no puede abandonarlo",
+		MsgFailedToCreateGroup:                "no se pudo crear el grupo",
+		MsgFailedToGetGroups:                  "no se pudieron obtener los grupos",
+		MsgFailedToUpdateGroupMembers:         "no se pudieron actualizar los miembros del grupo",
+		MsgFailedToGetGroupStories:            "no se pudieron obtener las historias del grupo",
+		MsgCannotHideSelf:                     "no puedes ocultar tus propias historias",
+		MsgAuthorNotHidden:                    "las historias de este usuario no están ocultas",
+		MsgFailedToHideStories:                "no se pudieron ocultar las historias",
+		MsgFailedToUnhideStories:              "no se pudieron mostrar las historias",
+		MsgFailedToGetHiddenAuthors:           "no se pudieron obtener los autores ocultos",
+		MsgOnlyAuthorEdit:                     "solo el autor puede editar esta historia",
+		MsgStoryVersionRequired:               "envía la versión que se edita en If-Match o version",
+		MsgInvalidIfMatch:                     "If-Match debe ser una versión de la historia, como \"3\"",
+		MsgStoryVersionConflict:               "la historia se editó después de esa versión; vuelve a obtenerla e inténtalo de nuevo",
+		MsgEncryptedStoryNotEditable:          "las historias cifradas no se pueden editar",
+		MsgAudienceRequiresVisibility:         "audience_user_ids y group_id solo se pueden cambiar junto con visibility",
+		MsgFailedToUpdateStory:                "no se pudo actualizar la historia",
+		MsgUnknownTopic:                       "tema de WebSocket desconocido",
+		MsgUnknownField:                       "fields nombra un campo desconocido: %q",
+		MsgCannotFollowSelf:                   "no puedes seguirte a ti mismo",
+		MsgFailedToDiscoverUsers:              "no se pudieron buscar los usuarios",
+		MsgVisibilityRequired:                 "visibility es obligatorio salvo que configures una visibilidad predeterminada",
+		MsgFailedToGetStorySettings:           "no se pudo obtener la configuración de historias",
+		MsgFailedToUpdateStorySettings:        "no se pudo actualizar la configuración de historias",
+		MsgExportNotFound:                     "exportación no encontrada",
+		MsgInvalidRecapLimit:                  "limit debe ser un número del 1 al 52",
+		MsgFailedToGetRecaps:                  "no se pudieron obtener los resúmenes semanales",
+		MsgInvalidFeedCursor:                  "cursor debe ser el X-Next-Cursor de una página anterior del feed",
+		MsgStoryConsumed:                      "ya has visto esta historia tantas veces como permite",
+		MsgScreenshotSignalsDisabled:          "los avisos de capturas de pantalla están desactivados",
+		MsgFailedToRecordScreenshot:           "no se pudo registrar la captura de pantalla",
+		MsgReshareNotAllowed:                  "el autor no permite compartir esta historia",
+		MsgViewLimitedNotShareable:            "las historias que limitan cuántas veces puede abrirlas cada espectador no se pueden compartir por enlace",
+		MsgReplyNotAllowed:                    "el autor no permite respuestas a esta historia",
+		MsgSelfReplyNotAllowed:                "no puedes responder a tu propia historia",
+		MsgFailedToSendAnnouncement:           "No se pudo enviar el anuncio",
+		MsgReplyNotDelivered:                  "No se pudo entregar la respuesta, inténtalo de nuevo",
+		MsgEncryptedStoryLocation:             "las historias cifradas no pueden llevar ubicación; place_name, latitude y longitude deben estar vacíos",
+		MsgFailedToSetAvatar:                  "no se pudo actualizar el avatar",
+		MsgServiceStarting:                    "el servicio se está iniciando",
+	},
+	"fr": {
+		MsgUserNotAuthenticated:               "utilisateur non authentifié",
+		MsgAuthHeaderRequired:                 "l'en-tête Authorization est requis",
+		MsgInvalidAuthHeaderFormat:            "format de l'en-tête d'autorisation invalide",
+		MsgTokenNotProvided:                   "jeton non fourni",
+		MsgInvalidToken:                       "jeton invalide",
+		MsgTokenRevoked:                       "le jeton a été révoqué",
+		MsgInvalidTenant:                      "identifiant de locataire invalide",
+		MsgInvalidCredentials:                 "adresse e-mail ou mot de passe invalide",
+		MsgFailedToHashPassword:               "échec du traitement du mot de passe",
+		MsgFailedToGenerateToken:              "échec de la génération du jeton",
+		MsgRateLimitExceeded:                  "limite de requêtes dépassée",
+		MsgAccessDenied:                       "accès refusé",
+		MsgTicketNotProvided:                  "ticket non fourni",
+		MsgInvalidTicket:                      "ticket invalide ou expiré",
+		MsgFailedToIssueTicket:                "impossible d'émettre le ticket de connexion",
+		MsgSessionNotFound:                    "session introuvable",
+		MsgFailedToCreateSession:              "impossible de créer la session",
+		MsgFailedToListSessions:               "impossible de lister les sessions",
+		MsgFailedToRevokeSession:              "impossible de révoquer la session",
+		MsgTokenScopeDenied:                   "les droits du jeton n'autorisent pas cette requête",
+		MsgAPITokenNotFound:                   "jeton d'API introuvable",
+		MsgFailedToCreateAPIToken:             "impossible de créer le jeton d'API",
+		MsgFailedToListAPITokens:              "impossible de lister les jetons d'API",
+		MsgFailedToRevokeAPIToken:             "impossible de révoquer le jeton d'API",
+		MsgRequestBodyEmpty:                   "le corps de la requête ne peut pas être vide",
+		MsgServerBusy:                         "le serveur est occupé, réessayez plus tard",
+		MsgStoryIDRequired:                    "l'identifiant de la story est requis",
+		MsgStoryNotFound:                      "story introuvable",
+		MsgStoryForbidden:                     "vous n'avez pas la permission de voir cette story",
+		MsgStoryHasNoLink:                     "la story n'a pas de lien",
+		MsgOnlyAuthorHighlight:                "seul l'auteur peut mettre cette story à la une",
+		MsgOnlyAuthorDelete:                   "seul l'auteur peut supprimer cette story",
+		MsgFailedToDeleteStory:                "impossible de supprimer la story",
+		MsgOnlyAuthorShare:                    "seul l'auteur peut partager cette story",
+		MsgOnlyAuthorViewers:                  "seul l'auteur peut voir qui a vu cette story",
+		MsgFailedToGetViewers:                 "impossible d'obtenir les spectateurs de la story",
+		MsgSelfReactionNotAllowed:             "vous ne pouvez pas réagir à votre propre story",
+		MsgReactionNotFound:                   "vous n'avez pas réagi à cette story",
+		MsgFailedToShareStory:                 "impossible de partager la story",
+		MsgShareLinkNotFound:                  "lien de partage introuvable ou expiré",
+		MsgShareLinkLoginRequired:             "connectez-vous pour voir cette story partagée",
+		MsgOEmbedURLInvalid:                   "url doit pointer vers une story publique ou partagée",
+		MsgOEmbedFormatUnsupported:            "seul le format json est pris en charge",
+		MsgInvalidLatitude:                    "lat doit être un nombre entre -90 et 90",
+		MsgInvalidLongitude:                   "lng doit être un nombre entre -180 et 180",
+		MsgInvalidNearbyRadius:                "radius doit être un nombre positif de mètres jusqu'à 50000",
+		MsgFailedToGetFeedTrays:               "impossible d'obtenir les plateaux de stories",
+		MsgInvalidSince:                       "since doit être un horodatage RFC 3339 ou un curseur d'une réponse précédente",
+		MsgFailedToGetFeedChanges:             "impossible d'obtenir les modifications du fil",
+		MsgUserIDRequired:                     "user_id est requis",
+		MsgUserNotFound:                       "utilisateur introuvable",
+		MsgFollowNotFound:                     "relation d'abonnement introuvable",
+		MsgFailedToFollowUser:                 "impossible de suivre l'utilisateur",
+		MsgFailedToUnfollowUser:               "impossible de ne plus suivre l'utilisateur",
+		MsgFailedToGetUserStats:               "impossible d'obtenir les statistiques de l'utilisateur",
+		MsgExportFormatUnsupported:            "seul le format csv est pris en charge",
+		MsgInvalidExportRange:                 "from et to doivent être des jours AAAA-MM-JJ, from au plus tard to et au plus 366 jours d'écart",
+		MsgFailedToExportStats:                "impossible d'exporter les statistiques",
+		MsgFailedToGetProfile:                 "impossible d'obtenir le profil de l'utilisateur",
+		MsgFailedToGetPrivacySettings:         "impossible d'obtenir les paramètres de confidentialité",
+		MsgFailedToUpdatePrivacySettings:      "impossible de mettre à jour les paramètres de confidentialité",
+		MsgFailedToGetNotificationSettings:    "impossible d'obtenir les paramètres de notification",
+		MsgFailedToUpdateNotificationSettings: "impossible de mettre à jour les paramètres de notification",
+		MsgInvalidUnsubscribeLink:             "lien de désinscription invalide",
+		MsgObjectKeyRequired:                  "la clé de l'objet est requise",
+		MsgMediaNotFound:                      "média introuvable",
+		MsgFailedToListMedia:                  "impossible de lister les fichiers médias",
+		MsgInvalidMediaListLimit:              "limit doit être un nombre de 1 à 1000",
+		MsgInvalidContinuationToken:           "jeton de continuation invalide",
+		MsgFailedToDeleteMedia:                "impossible de supprimer le fichier média",
+		MsgFailedToGenerateDownload:           "impossible de générer l'URL de téléchargement",
+		MsgFailedToRecordUpload:               "impossible d'enregistrer le téléversement",
+		MsgFailedToConfirmUpload:              "impossible de confirmer le téléversement",
+		MsgUploadFailed:                       "le téléversement a échoué ; demandez une nouvelle URL de téléversement",
+		MsgUploadTooLarge:                     "le fichier téléversé dépasse la taille autorisée",
+		MsgFailedToGetUploadStatus:            "impossible d'obtenir l'état du téléversement",
+		MsgNoReconciliationReport:             "la tâche de rapprochement des médias n'a pas encore été exécutée",
+		MsgFailedToGetReconciliationReport:    "impossible d'obtenir le rapport de rapprochement des médias",
+		MsgEncryptionRequiresPrivate:          "seules les stories PRIVATE peuvent être chiffrées",
+		MsgEncryptedStoryPlaintext:            "les stories chiffrées portent leur texte dans l'enveloppe ; text et link_url doivent être vides",
+		MsgRecipientKeysMismatch:              "recipient_keys doit contenir exactement une clé pour l'auteur et chaque membre de l'audience",
+		MsgEnvelopeNotFound:                   "aucun contenu chiffré pour vous dans cette story",
+		MsgFailedToGetEnvelope:                "impossible de récupérer l'enveloppe de la story",
+		MsgPublicKeyNotFound:                  "l'utilisateur n'a pas publié de clé publique",
+		MsgFailedToGetPublicKey:               "impossible de récupérer la clé publique",
+		MsgFailedToSetPublicKey:               "impossible de publier la clé publique",
+		MsgImpersonationReadOnly:              "les jetons d'usurpation ne peuvent servir qu'à lire",
+		MsgCannotImpersonateAdmin:             "les administrateurs ne peuvent pas être usurpés",
+		MsgFailedToImpersonate:                "impossible d'émettre le jeton d'usurpation",
+		MsgFailedToGetImpersonations:          "impossible de récupérer le journal des usurpations",
+		MsgInvalidImpersonationLimit:          "limit doit être un nombre de 1 à 200",
+		MsgFailedToGetLimits:                  "impossible de récupérer les limites de débit",
+		MsgInternalError:                      "erreur interne du serveur",
+		MsgInvalidLimit:                       "la limite doit être un nombre de 1 à %d",
+		MsgStoryNotArchived:                   "la story n'est pas archivée",
+		MsgArchivedStoryAuthorGone:            "l'auteur de la story archivée n'existe plus",
+		MsgFailedToRestoreStory:               "impossible de restaurer la story",
+		MsgFailedToRecordImpressions:          "impossible d'enregistrer les impressions",
+		MsgFailedToGetClientStats:             "impossible d'obtenir les statistiques des clients",
+		MsgFailedToGetAbuseFlags:              "impossible d'obtenir les signalements d'abus",
+		MsgAbuseFlagNotFound:                  "signalement d'abus introuvable",
+		MsgFailedToReviewAbuseFlag:            "impossible d'examiner le signalement d'abus",
+		MsgFailedToLiftThrottle:               "impossible de lever la limitation",
+		MsgMediaNotOwned:                      "media_key n'est pas un de vos téléversements",
+		MsgMediaNotConfirmed:                  "confirmez le téléversement du média avant de le publier",
+		MsgReshareNotPublic:                   "seules les stories publiques actives peuvent être repartagées",
+		MsgSelfReshareNotAllowed:              "vous ne pouvez pas repartager votre propre story",
+		MsgAlreadyReshared:                    "vous avez déjà repartagé cette story",
+		MsgFailedToReshareStory:               "impossible de repartager la story",
+		MsgGroupIDMismatch:                    "group_id est requis pour les stories GROUP et interdit pour les autres",
+		MsgNotGroupMember:                     "vous n'êtes pas membre de ce groupe",
+		MsgGroupIDRequired:                    "l'id du groupe est requis",
+		MsgGroupNotFound:                      "groupe introuvable",
+		MsgGroupMemberNotFound:                "l'utilisateur n'est pas membre de ce groupe",
+		MsgNotGroupOwner:                      "seul le propriétaire du groupe peut gérer ses membres",
+		MsgGroupOwnerCannotLeave:              "le propriétaire du groupe ne peut pas le quitter",
+		MsgFailedToCreateGroup:                "échec de la création du groupe",
+		MsgFailedToGetGroups:                  "échec de la récupération des groupes",
+		MsgFailedToUpdateGroupMembers:         "échec de la mise à jour des membres du groupe",
+		MsgFailedToGetGroupStories:            "échec de la récupération des stories du groupe",
+		MsgCannotHideSelf:                     "vous ne pouvez pas masquer vos propres stories",
+		MsgAuthorNotHidden:                    "les stories de cet utilisateur ne sont pas masquées",
+		MsgFailedToHideStories:                "échec du masquage des stories",
+		MsgFailedToUnhideStories:              "échec de l'affichage des stories",
+		MsgFailedToGetHiddenAuthors:           "échec de la récupération des auteurs masqués",
+		MsgOnlyAuthorEdit:                     "seul l'auteur peut modifier cette story",
+		MsgStoryVersionRequired:               "envoyez la version modifiée dans If-Match ou version",
+		MsgInvalidIfMatch:                     "If-Match doit être une version de la story, comme \"3\"",
+		MsgStoryVersionConflict:               "la story a été modifiée depuis cette version ; récupérez-la et réessayez",
+		MsgEncryptedStoryNotEditable:          "les stories chiffrées ne peuvent pas être modifiées",
+		MsgAudienceRequiresVisibility:         "audience_user_ids et group_id ne peuvent être modifiés qu'avec visibility",
+		MsgFailedToUpdateStory:                "impossible de mettre à jour la story",
+		MsgUnknownTopic:                       "sujet WebSocket inconnu",
+		MsgUnknownField:                       "fields nomme un champ inconnu : %q",
+		MsgCannotFollowSelf:                   "vous ne pouvez pas vous suivre vous-même",
+		MsgFailedToDiscoverUsers:              "impossible de rechercher les utilisateurs",
+		MsgVisibilityRequired:                 "visibility est obligatoire sauf si vous avez défini une visibilité par défaut",
+		MsgFailedToGetStorySettings:           "impossible d'obtenir les paramètres des stories",
+		MsgFailedToUpdateStorySettings:        "impossible de mettre à jour les paramètres des stories",
+		MsgExportNotFound:                     "export introuvable",
+		MsgInvalidRecapLimit:                  "limit doit être un nombre de 1 à 52",
+		MsgFailedToGetRecaps:                  "impossible de récupérer les récapitulatifs hebdomadaires",
+		MsgInvalidFeedCursor:                  "cursor doit être le X-Next-Cursor d'une page précédente du fil",
+		MsgStoryConsumed:                      "vous avez vu cette story autant de fois qu'elle le permet",
+		MsgScreenshotSignalsDisabled:          "les signalements de captures d'écran sont désactivés",
+		MsgFailedToRecordScreenshot:           "impossible d'enregistrer la capture d'écran",
+		MsgReshareNotAllowed:                  "l'auteur n'autorise pas le repartage de cette story",
+		MsgViewLimitedNotShareable:            "les stories qui limitent le nombre d'ouvertures par spectateur ne peuvent pas être partagées par lien",
+		MsgReplyNotAllowed:                    "l'auteur n'autorise pas les réponses à cette story",
+		MsgSelfReplyNotAllowed:                "vous ne pouvez pas répondre à votre propre story",
+		MsgFailedToSendAnnouncement:           "Impossible d'envoyer l'annonce",
+		MsgReplyNotDelivered:                  "La réponse n'a pas pu être envoyée, veuillez réessayer",
+		MsgEncryptedStoryLocation:             "les stories chiffrées ne peuvent pas porter de lieu ; place_name, latitude et longitude doivent être vides",
+		MsgFailedToSetAvatar:                  "impossible de mettre à jour l'avatar",
+		MsgServiceStarting:                    "le service est en cours de démarrage",
+	},
+}
@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+type contextKey string
+
+const LocaleKey contextKey = "locale"
+
+// DefaultLocale is used when the client sends no usable Accept-Language header
+const DefaultLocale = "en"
+
+// supportedTags lists the locales with a message catalog, default first
+var supportedTags = []language.Tag{
+	language.English,
+	language.Spanish,
+	language.French,
+}
+
+var matcher = language.NewMatcher(supportedTags)
+
+// Middleware negotiates the response locale from the Accept-Language header
+// and stores it in the request context
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := NegotiateLocale(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", locale)
+
+		ctx := context.WithValue(r.Context(), LocaleKey, locale)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NegotiateLocale picks the best supported locale for an Accept-Language header value
+func NegotiateLocale(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, _ := matcher.Match(tags...)
+	base, _ := supportedTags[index].Base()
+	return base.String()
+}
+
+// LocaleFromContext returns the negotiated locale, falling back to DefaultLocale
+func LocaleFromContext(ctx context.Context) string {
+	locale, ok := ctx.Value(LocaleKey).(string)
+	if !ok || locale == "" {
+		return DefaultLocale
+	}
+	return locale
+}
+
+// T returns the catalog message for key in the request locale
+func T(ctx context.Context, key MessageKey) string {
+	if messages, ok := catalog[LocaleFromContext(ctx)]; ok {
+		if message, ok := messages[key]; ok {
+			return message
+		}
+	}
+	return catalog[DefaultLocale][key]
+}
+
+// Error returns the catalog message for key in the request locale as an error
+func Error(ctx context.Context, key MessageKey) error {
+	return errors.New(T(ctx, key))
+}
+
+// Errorf returns the catalog message for key in the request locale as an
+// error, with its placeholders filled in from args
+func Errorf(ctx context.Context, key MessageKey, args ...any) error {
+	return fmt.Errorf(T(ctx, key), args...)
+}
@@ -0,0 +1,45 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"fr-CA", "fr"},
+		{"de-DE,fr;q=0.5", "fr"},
+		{"ja", "en"},
+		{"not a language", "en"},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateLocale(tt.header); got != tt.want {
+			t.Errorf("NegotiateLocale(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT_FallsBackToDefaultLocale(t *testing.T) {
+	ctx := context.WithValue(context.Background(), LocaleKey, "es")
+	if got := T(ctx, MsgStoryNotFound); got != "historia no encontrada" {
+		t.Fatalf("Expected Spanish message, got %q", got)
+	}
+
+	ctx = context.WithValue(context.Background(), LocaleKey, "ja")
+	if got := T(ctx, MsgStoryNotFound); got != "story not found" {
+		t.Fatalf("Expected English fallback, got %q", got)
+	}
+}
+
+func TestErrorf_FillsPlaceholders(t *testing.T) {
+	ctx := context.WithValue(context.Background(), LocaleKey, "fr")
+	if got := Errorf(ctx, MsgInvalidLimit, 50).Error(); got != "la limite doit être un nombre de 1 à 50" {
+		t.Fatalf("Expected French message with the limit, got %q", got)
+	}
+}
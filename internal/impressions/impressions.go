@@ -0,0 +1,190 @@
+package impressions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// Impressions waiting to be written are kept in Redis under
+// cache.ImpressionsBufferKey. The buffer is a hash of storyID:userID to the
+// unix time the story was first seen; a flush moves it aside, to
+// cache.ImpressionsFlushingKey, so new impressions start a fresh buffer while
+// it is written.
+
+// Buffer collects tray impressions in Redis, so the request recording them
+// never waits on the database
+type Buffer struct {
+	redis *redis.Client
+	key   string
+}
+
+// NewBuffer creates a buffer in the given Redis under keys
+func NewBuffer(redisClient *redis.Client, keys cache.Keys) *Buffer {
+	return &Buffer{redis: redisClient, key: keys.Key(cache.ImpressionsBufferKey)}
+}
+
+// Add buffers that the stories appeared in userID's tray and returns how many
+// distinct stories were given. A story already buffered for the user keeps
+// the time it was first seen.
+func (b *Buffer) Add(ctx context.Context, userID string, storyIDs []string, seenAt time.Time) (int, error) {
+	seen := make(map[string]bool, len(storyIDs))
+	pipe := b.redis.Pipeline()
+	for _, storyID := range storyIDs {
+		if seen[storyID] {
+			continue
+		}
+		seen[storyID] = true
+		pipe.HSetNX(ctx, b.key, storyID+":"+userID, seenAt.Unix())
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to buffer impressions: %w", err)
+	}
+	return len(seen), nil
+}
+
+// Flusher periodically writes buffered impressions to the database in
+// batches. A flush that fails leaves its impressions in Redis to be written
+// by the next one; writing an impression twice has no effect.
+type Flusher struct {
+	store       storage.ViewStore
+	redis       *redis.Client
+	bufferKey   string
+	flushingKey string
+	interval    time.Duration
+	batchSize   int
+}
+
+// NewFlusher creates a flusher writing the impressions buffered in the given
+// Redis under keys to store
+func NewFlusher(store storage.ViewStore, redisClient *redis.Client, keys cache.Keys, cfg config.Impressions) *Flusher {
+	return &Flusher{
+		store:       store,
+		redis:       redisClient,
+		bufferKey:   keys.Key(cache.ImpressionsBufferKey),
+		flushingKey: keys.Key(cache.ImpressionsFlushingKey),
+		interval:    time.Duration(cfg.FlushInterval) * time.Second,
+		batchSize:   cfg.BatchSize,
+	}
+}
+
+// Start flushes every interval until the context is cancelled
+func (f *Flusher) Start(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	slog.Info("Impression flusher started", slog.String("interval", f.interval.String()), slog.Int("batch_size", f.batchSize))
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Impression flusher shutting down")
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		written, err := f.FlushOnce(ctx)
+		if err != nil {
+			metrics.ObserveWorkerBatch(metrics.JobImpressions, start, 1)
+			slog.Error("Failed to flush impressions", slog.String("error", err.Error()), slog.Int("written", written))
+			continue
+		}
+		metrics.ObserveWorkerBatch(metrics.JobImpressions, start, 0)
+		if written > 0 {
+			slog.Info("Flushed impressions", slog.Int("written", written))
+		}
+	}
+}
+
+// FlushOnce writes the buffered impressions and returns how many it wrote.
+// Impressions left over from a failed flush are written first, and those
+// buffered since wait for the next flush.
+func (f *Flusher) FlushOnce(ctx context.Context) (int, error) {
+	// Nothing is moved while an earlier flush's impressions remain
+	err := f.redis.RenameNX(ctx, f.bufferKey, f.flushingKey).Err()
+	if err != nil && !strings.Contains(err.Error(), "no such key") {
+		return 0, fmt.Errorf("failed to move impression buffer: %w", err)
+	}
+
+	written := 0
+	batch := make([]types.Impression, 0, f.batchSize)
+	write := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := f.store.RecordImpressions(batch); err != nil {
+			return fmt.Errorf("failed to record impressions: %w", err)
+		}
+		written += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	var cursor uint64
+	for {
+		fields, next, err := f.redis.HScan(ctx, f.flushingKey, cursor, "", int64(f.batchSize)).Result()
+		if err != nil {
+			return written, fmt.Errorf("failed to read impression buffer: %w", err)
+		}
+
+		// HSCAN returns field, value pairs
+		for i := 0; i+1 < len(fields); i += 2 {
+			impression, ok := parseImpression(fields[i], fields[i+1])
+			if !ok {
+				slog.Warn("Skipping malformed buffered impression", slog.String("field", fields[i]))
+				continue
+			}
+			batch = append(batch, impression)
+			if len(batch) >= f.batchSize {
+				if err := write(); err != nil {
+					return written, err
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	if err := write(); err != nil {
+		return written, err
+	}
+
+	if err := f.redis.Del(ctx, f.flushingKey).Err(); err != nil {
+		return written, fmt.Errorf("failed to clear impression buffer: %w", err)
+	}
+	return written, nil
+}
+
+// parseImpression reads a buffer entry written by Buffer.Add. IDs that could
+// not be database IDs are rejected here, since one would fail the whole batch.
+func parseImpression(field, value string) (types.Impression, bool) {
+	storyID, userID, ok := strings.Cut(field, ":")
+	if !ok || !isID(storyID) || !isID(userID) {
+		return types.Impression{}, false
+	}
+	seenAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return types.Impression{}, false
+	}
+	return types.Impression{StoryID: storyID, UserID: userID, SeenAt: time.Unix(seenAt, 0).UTC()}, true
+}
+
+// isID reports whether s fits the database's INTEGER IDs
+func isID(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 32)
+	return err == nil
+}
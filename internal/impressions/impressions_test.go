@@ -0,0 +1,161 @@
+package impressions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// testKeys builds the keys of an environment prefixed "test"
+var testKeys = cache.NewKeys("test")
+
+// fakeStore records the batches of impressions written, failing while err is set
+type fakeStore struct {
+	batches [][]types.Impression
+	err     error
+}
+
+func (s *fakeStore) RecordStoryView(storyID, viewerID string) error {
+	return nil
+}
+
+func (s *fakeStore) GetStoryViewers(storyID string) ([]types.StoryViewer, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) RecordLinkClick(storyID, userID string) error {
+	return nil
+}
+
+func (s *fakeStore) RecordScreenshot(storyID, userID, kind string) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeStore) RecordImpressions(impressions []types.Impression) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, append([]types.Impression(nil), impressions...))
+	return nil
+}
+
+func (s *fakeStore) written() map[string]types.Impression {
+	written := make(map[string]types.Impression)
+	for _, batch := range s.batches {
+		for _, impression := range batch {
+			written[impression.StoryID+":"+impression.UserID] = impression
+		}
+	}
+	return written
+}
+
+func newRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	redisClient, _ := redistest.New(t)
+	return redisClient
+}
+
+func TestBuffer_Add(t *testing.T) {
+	redisClient := newRedis(t)
+	ctx := context.Background()
+	buffer := NewBuffer(redisClient, testKeys)
+	first := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	accepted, err := buffer.Add(ctx, "7", []string{"1", "2", "1"}, first)
+	if err != nil {
+		t.Fatalf("Failed to buffer impressions: %v", err)
+	}
+	if accepted != 2 {
+		t.Errorf("Expected 2 distinct stories accepted, got %d", accepted)
+	}
+
+	// Seeing a story again keeps the first time it was seen
+	if _, err := buffer.Add(ctx, "7", []string{"2"}, first.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to buffer impressions: %v", err)
+	}
+
+	buffered, err := redisClient.HGetAll(ctx, "test:impressions:buffer").Result()
+	if err != nil {
+		t.Fatalf("Failed to read buffer: %v", err)
+	}
+	if len(buffered) != 2 || buffered["2:7"] != "1790856000" {
+		t.Errorf("Expected 2 impressions first seen at 1790856000, got %v", buffered)
+	}
+}
+
+func TestFlusher_FlushOnce(t *testing.T) {
+	redisClient := newRedis(t)
+	ctx := context.Background()
+	buffer := NewBuffer(redisClient, testKeys)
+	store := &fakeStore{}
+	flusher := NewFlusher(store, redisClient, testKeys, config.Impressions{BatchSize: 2})
+
+	if written, err := flusher.FlushOnce(ctx); err != nil || written != 0 {
+		t.Fatalf("Expected an empty buffer to flush nothing, got %d, %v", written, err)
+	}
+
+	now := time.Now()
+	buffer.Add(ctx, "7", []string{"1", "2", "3"}, now)
+	buffer.Add(ctx, "8", []string{"1"}, now)
+	redisClient.HSet(ctx, flusher.bufferKey, "garbage", "x")
+	redisClient.HSet(ctx, flusher.bufferKey, "99999999999:7", now.Unix())
+
+	written, err := flusher.FlushOnce(ctx)
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if written != 4 {
+		t.Errorf("Expected 4 impressions written, got %d", written)
+	}
+	for _, batch := range store.batches {
+		if len(batch) > 2 {
+			t.Errorf("Expected batches of at most 2, got %d", len(batch))
+		}
+	}
+	if got := store.written(); len(got) != 4 || got["1:8"].SeenAt.Unix() != now.Unix() {
+		t.Errorf("Expected every buffered impression written with its time, got %v", got)
+	}
+	if n, _ := redisClient.Exists(ctx, flusher.bufferKey, flusher.flushingKey).Result(); n != 0 {
+		t.Errorf("Expected the buffer to be cleared, got %d keys left", n)
+	}
+}
+
+func TestFlusher_FlushOnceRetriesFailedFlush(t *testing.T) {
+	redisClient := newRedis(t)
+	ctx := context.Background()
+	buffer := NewBuffer(redisClient, testKeys)
+	store := &fakeStore{err: errors.New("database unavailable")}
+	flusher := NewFlusher(store, redisClient, testKeys, config.Impressions{BatchSize: 100})
+
+	buffer.Add(ctx, "7", []string{"1"}, time.Now())
+	if _, err := flusher.FlushOnce(ctx); err == nil {
+		t.Fatal("Expected the failed write to be reported")
+	}
+
+	// Impressions buffered after the failure wait for the following flush
+	buffer.Add(ctx, "7", []string{"2"}, time.Now())
+	store.err = nil
+
+	written, err := flusher.FlushOnce(ctx)
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if _, ok := store.written()["1:7"]; written != 1 || !ok {
+		t.Errorf("Expected only the leftover impression written, got %d: %v", written, store.written())
+	}
+
+	written, err = flusher.FlushOnce(ctx)
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if _, ok := store.written()["2:7"]; written != 1 || !ok {
+		t.Errorf("Expected the newer impression written next, got %d: %v", written, store.written())
+	}
+}
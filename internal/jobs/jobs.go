@@ -0,0 +1,179 @@
+// Package jobs runs background work queued in Postgres, so it survives
+// restarts without a message broker. Any number of workers, in any process,
+// claim due jobs with FOR UPDATE SKIP LOCKED, so a job runs on one worker at
+// a time; failed jobs are retried with backoff until they run out of attempts.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+)
+
+// Job states
+const (
+	StatusQueued  = "queued"  // waiting for its scheduled time, including before a retry
+	StatusRunning = "running" // claimed by a worker until its lease runs out
+	StatusDone    = "done"
+	StatusFailed  = "failed" // gave up on; LastError says why
+)
+
+// ErrNotFound is returned for jobs that do not exist, or no longer do once
+// their retention is over
+var ErrNotFound = errors.New("job not found")
+
+// Job is a unit of queued work of a kind, described by its payload
+type Job struct {
+	ID          string
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int // runs started so far, including the current one
+	MaxAttempts int
+	LastError   string
+	ScheduledAt time.Time
+	CreatedAt   time.Time
+	FinishedAt  *time.Time // nil until done or failed
+}
+
+// Decode unmarshals the job's payload into v
+func (j Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s payload: %w", j.Kind, err))
+	}
+	return nil
+}
+
+// permanentError is a failure retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure retrying cannot fix, such as a payload
+// that does not decode, so the job fails without using up its attempts
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Queue adds jobs to the jobs table and looks them up
+type Queue struct {
+	db          *sql.DB
+	maxAttempts int
+}
+
+// NewQueue creates a queue over the jobs table of db, giving each job the
+// configured number of attempts
+func NewQueue(db *sql.DB, cfg config.Jobs) *Queue {
+	return &Queue{db: db, maxAttempts: max(cfg.MaxAttempts, 1)}
+}
+
+// Enqueue queues a job of kind to run as soon as a worker is free and returns
+// its ID. The payload is stored as JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (string, error) {
+	return q.EnqueueAt(ctx, kind, payload, time.Time{})
+}
+
+// EnqueueAt queues a job of kind to run no earlier than at, or as soon as a
+// worker is free when at is zero, and returns its ID
+func (q *Queue) EnqueueAt(ctx context.Context, kind string, payload any, at time.Time) (string, error) {
+	id, _, err := q.insert(ctx, kind, nil, payload, at)
+	return id, err
+}
+
+// EnqueueOnce queues a job of kind like EnqueueAt unless a job of the same
+// kind and key was queued before and is still kept, so workers racing to
+// queue the same work queue it once. It reports whether the job was queued.
+func (q *Queue) EnqueueOnce(ctx context.Context, kind, key string, payload any, at time.Time) (string, bool, error) {
+	return q.insert(ctx, kind, key, payload, at)
+}
+
+// insert adds a job, with a unique key unless key is nil
+func (q *Queue) insert(ctx context.Context, kind string, key any, payload any, at time.Time) (string, bool, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to encode %s payload: %w", kind, err)
+	}
+
+	var scheduledAt any = sq.Expr("CURRENT_TIMESTAMP")
+	if !at.IsZero() {
+		scheduledAt = at.UTC()
+	}
+
+	sqlStr, args, err := postgres.StatementBuilder.
+		Insert("jobs").
+		Columns("kind", "unique_key", "payload", "max_attempts", "scheduled_at").
+		Values(kind, key, string(data), q.maxAttempts, scheduledAt).
+		Suffix("ON CONFLICT (kind, unique_key) WHERE unique_key IS NOT NULL DO NOTHING RETURNING id::text").
+		ToSql()
+	if err != nil {
+		return "", false, err
+	}
+
+	var id string
+	err = q.db.QueryRowContext(ctx, sqlStr, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+	return id, true, nil
+}
+
+// Get returns the job with the given ID, or ErrNotFound
+func (q *Queue) Get(ctx context.Context, id string) (Job, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return Job{}, ErrNotFound
+	}
+
+	sqlStr, args, err := postgres.StatementBuilder.
+		Select(jobColumns...).
+		From("jobs").
+		Where("id = ?::bigint", id).
+		ToSql()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job, err := scanJob(q.db.QueryRowContext(ctx, sqlStr, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	return job, err
+}
+
+// jobColumns are the columns scanJob reads
+var jobColumns = []string{
+	"id::text", "kind", "payload", "status", "attempts", "max_attempts",
+	"COALESCE(last_error, '')", "scheduled_at", "created_at", "finished_at",
+}
+
+// scanJob scans a row of jobColumns
+func scanJob(row interface{ Scan(dest ...any) error }) (Job, error) {
+	var job Job
+	var payload []byte
+	err := row.Scan(&job.ID, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError,
+		postgres.Time(&job.ScheduledAt), postgres.Time(&job.CreatedAt), postgres.NullTime(&job.FinishedAt))
+	if err != nil {
+		return Job{}, err
+	}
+
+	job.Payload = payload
+	return job, nil
+}
@@ -0,0 +1,233 @@
+//go:build integration
+
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+)
+
+func TestJobsIntegration(t *testing.T) {
+	cfg := testutil.NewConfig()
+	storage := testutil.StartPostgres(t, cfg)
+	db := storage.GetDB()
+	queue := jobs.NewQueue(db, cfg.Jobs)
+	ctx := context.Background()
+
+	get := func(t *testing.T, id string) jobs.Job {
+		t.Helper()
+		job, err := queue.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get %s failed: %v", id, err)
+		}
+		return job
+	}
+
+	t.Run("RunsDueJobs", func(t *testing.T) {
+		var got []string
+		worker := jobs.NewWorker(db, cfg.Jobs)
+		worker.Handle("test.echo", func(ctx context.Context, job jobs.Job) error {
+			var payload struct{ Word string }
+			if err := job.Decode(&payload); err != nil {
+				return err
+			}
+			got = append(got, payload.Word)
+			return nil
+		})
+
+		first, err := queue.Enqueue(ctx, "test.echo", map[string]string{"Word": "hello"})
+		if err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+		later, err := queue.EnqueueAt(ctx, "test.echo", map[string]string{"Word": "later"}, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("EnqueueAt failed: %v", err)
+		}
+		if _, err := queue.Enqueue(ctx, "test.unhandled", nil); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		if n := worker.RunOnce(ctx); n != 1 {
+			t.Errorf("Expected only the due job with a handler to run, ran %d", n)
+		}
+		if len(got) != 1 || got[0] != "hello" {
+			t.Errorf("Expected the handler to get the payload, got %v", got)
+		}
+
+		job := get(t, first)
+		if job.Status != jobs.StatusDone || job.Attempts != 1 || job.FinishedAt == nil {
+			t.Errorf("Expected the job to be done after one attempt, got %+v", job)
+		}
+		if job := get(t, later); job.Status != jobs.StatusQueued || job.Attempts != 0 {
+			t.Errorf("Expected the scheduled job to wait, got %+v", job)
+		}
+	})
+
+	t.Run("RetriesWithBackoff", func(t *testing.T) {
+		calls := 0
+		worker := jobs.NewWorker(db, cfg.Jobs)
+		worker.Handle("test.flaky", func(ctx context.Context, job jobs.Job) error {
+			calls++
+			if calls < 2 {
+				return errors.New("server unavailable")
+			}
+			return nil
+		})
+
+		id, err := queue.Enqueue(ctx, "test.flaky", nil)
+		if err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		worker.RunOnce(ctx)
+		job := get(t, id)
+		if job.Status != jobs.StatusQueued || job.Attempts != 1 || job.LastError != "server unavailable" {
+			t.Fatalf("Expected the job to wait for a retry, got %+v", job)
+		}
+		if !job.ScheduledAt.After(job.CreatedAt) {
+			t.Errorf("Expected the retry to be scheduled after a backoff, got %v", job.ScheduledAt)
+		}
+		if n := worker.RunOnce(ctx); n != 0 {
+			t.Errorf("Expected no retry before the backoff, ran %d", n)
+		}
+
+		time.Sleep(time.Duration(cfg.Jobs.Backoff)*time.Second + 200*time.Millisecond)
+		worker.RunOnce(ctx)
+		if job := get(t, id); job.Status != jobs.StatusDone || job.Attempts != 2 || job.LastError != "" {
+			t.Errorf("Expected the retry to succeed, got %+v", job)
+		}
+	})
+
+	t.Run("FailsPermanently", func(t *testing.T) {
+		worker := jobs.NewWorker(db, cfg.Jobs)
+		worker.Handle("test.invalid", func(ctx context.Context, job jobs.Job) error {
+			var payload struct{ Count int }
+			return job.Decode(&payload)
+		})
+
+		id, err := queue.Enqueue(ctx, "test.invalid", map[string]string{"Count": "many"})
+		if err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		worker.RunOnce(ctx)
+		job := get(t, id)
+		if job.Status != jobs.StatusFailed || job.Attempts != 1 || job.LastError == "" || job.FinishedAt == nil {
+			t.Errorf("Expected the job to fail without retrying, got %+v", job)
+		}
+	})
+
+	t.Run("TakesOverExpiredLeases", func(t *testing.T) {
+		ran := 0
+		worker := jobs.NewWorker(db, cfg.Jobs)
+		worker.Handle("test.abandoned", func(ctx context.Context, job jobs.Job) error {
+			ran++
+			return nil
+		})
+
+		retried, err := queue.Enqueue(ctx, "test.abandoned", nil)
+		if err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+		exhausted, err := queue.Enqueue(ctx, "test.abandoned", nil)
+		if err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		// As if a worker died part way through each job
+		abandon := func(id string, attempts int) {
+			_, err := db.Exec(`UPDATE jobs SET status = 'running', attempts = $1,
+				locked_until = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE id = $2::bigint`, attempts, id)
+			if err != nil {
+				t.Fatalf("Failed to abandon job %s: %v", id, err)
+			}
+		}
+		abandon(retried, 1)
+		abandon(exhausted, cfg.Jobs.MaxAttempts)
+
+		worker.RunOnce(ctx)
+		if ran != 1 {
+			t.Errorf("Expected only the job with attempts left to run, ran %d", ran)
+		}
+		if job := get(t, retried); job.Status != jobs.StatusDone || job.Attempts != 2 {
+			t.Errorf("Expected the abandoned job to be taken over, got %+v", job)
+		}
+		if job := get(t, exhausted); job.Status != jobs.StatusFailed || job.LastError == "" {
+			t.Errorf("Expected the job abandoned on its last attempt to fail, got %+v", job)
+		}
+	})
+
+	t.Run("ConcurrentWorkers", func(t *testing.T) {
+		const total = 40
+
+		var mu sync.Mutex
+		runs := make(map[string]int)
+		handler := func(ctx context.Context, job jobs.Job) error {
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			runs[job.ID]++
+			mu.Unlock()
+			return nil
+		}
+
+		for i := 0; i < total; i++ {
+			if _, err := queue.Enqueue(ctx, "test.concurrent", map[string]string{"n": strconv.Itoa(i)}); err != nil {
+				t.Fatalf("Enqueue failed: %v", err)
+			}
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			worker := jobs.NewWorker(db, cfg.Jobs)
+			worker.Handle("test.concurrent", handler)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for worker.RunOnce(ctx) > 0 {
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(runs) != total {
+			t.Errorf("Expected all %d jobs to run, ran %d", total, len(runs))
+		}
+		for id, n := range runs {
+			if n != 1 {
+				t.Errorf("Expected job %s to run once, ran %d times", id, n)
+			}
+		}
+	})
+
+	t.Run("EnqueueOnce", func(t *testing.T) {
+		key := "once-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		id, created, err := queue.EnqueueOnce(ctx, "test.once", key, nil, time.Now().Add(time.Hour))
+		if err != nil || !created || id == "" {
+			t.Fatalf("Expected the job to be queued, got %q, %v, %v", id, created, err)
+		}
+		if _, created, err := queue.EnqueueOnce(ctx, "test.once", key, nil, time.Time{}); err != nil || created {
+			t.Errorf("Expected the same key not to be queued again, got %v, %v", created, err)
+		}
+		if _, created, err := queue.EnqueueOnce(ctx, "test.other", key, nil, time.Time{}); err != nil || !created {
+			t.Errorf("Expected keys to be scoped to a kind, got %v, %v", created, err)
+		}
+		if job := get(t, id); job.Status != jobs.StatusQueued || !job.ScheduledAt.After(time.Now()) {
+			t.Errorf("Expected the first job to keep its schedule, got %+v", job)
+		}
+	})
+
+	t.Run("UnknownJob", func(t *testing.T) {
+		for _, id := range []string{"999999", "not-a-number"} {
+			if _, err := queue.Get(ctx, id); !errors.Is(err, jobs.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for %q, got %v", id, err)
+			}
+		}
+	})
+}
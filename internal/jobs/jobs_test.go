@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+func TestPermanent(t *testing.T) {
+	err := errors.New("bad payload")
+	if IsPermanent(err) {
+		t.Error("Expected a plain error not to be permanent")
+	}
+
+	wrapped := fmt.Errorf("decode: %w", Permanent(err))
+	if !IsPermanent(wrapped) {
+		t.Error("Expected a wrapped permanent error to be permanent")
+	}
+	if !errors.Is(wrapped, err) || wrapped.Error() != "decode: bad payload" {
+		t.Errorf("Expected the permanent error to wrap the original, got %v", wrapped)
+	}
+}
+
+func TestJob_Decode(t *testing.T) {
+	var payload struct {
+		ObjectKey string `json:"object_key"`
+	}
+
+	job := Job{Kind: "media.delete", Payload: json.RawMessage(`{"object_key":"users/1/media/a.jpg"}`)}
+	if err := job.Decode(&payload); err != nil || payload.ObjectKey != "users/1/media/a.jpg" {
+		t.Errorf("Expected the payload to decode, got %+v (%v)", payload, err)
+	}
+
+	job.Payload = json.RawMessage(`[1, 2]`)
+	if err := job.Decode(&payload); !IsPermanent(err) {
+		t.Errorf("Expected an undecodable payload to fail permanently, got %v", err)
+	}
+}
+
+func TestWorker_RetryDelay(t *testing.T) {
+	w := NewWorker(nil, config.Jobs{Backoff: 30})
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := w.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWorker_Call(t *testing.T) {
+	w := NewWorker(nil, config.Jobs{})
+	w.Handle("boom", func(ctx context.Context, job Job) error {
+		panic("out of range")
+	})
+
+	err := w.call(context.Background(), Job{Kind: "boom"})
+	if err == nil || err.Error() != "job panicked: out of range" {
+		t.Errorf("Expected the panic to become an error, got %v", err)
+	}
+}
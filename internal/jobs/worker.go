@@ -0,0 +1,278 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+)
+
+// maxBackoff caps the delay before a retry, however many attempts failed
+const maxBackoff = time.Hour
+
+// Handler runs a job. Returning an error retries the job after a backoff
+// until it runs out of attempts, unless the error is marked Permanent.
+type Handler func(ctx context.Context, job Job) error
+
+// Worker claims due jobs of the kinds it has handlers for and runs them. A
+// claimed job is leased to the worker; if the worker dies, the job is taken
+// over by another once the lease runs out.
+type Worker struct {
+	db        *sql.DB
+	handlers  map[string]Handler
+	interval  time.Duration
+	batchSize int
+	backoff   time.Duration
+	lease     time.Duration
+	retention time.Duration
+}
+
+// NewWorker creates a worker for the jobs table of db
+func NewWorker(db *sql.DB, cfg config.Jobs) *Worker {
+	return &Worker{
+		db:        db,
+		handlers:  make(map[string]Handler),
+		interval:  time.Duration(cfg.PollInterval) * time.Second,
+		batchSize: cfg.BatchSize,
+		backoff:   time.Duration(cfg.Backoff) * time.Second,
+		lease:     time.Duration(cfg.Lease) * time.Second,
+		retention: time.Duration(cfg.Retention) * time.Second,
+	}
+}
+
+// Handle makes the worker run jobs of kind with handler. Jobs of kinds
+// without a handler are left for workers that have one.
+func (w *Worker) Handle(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// Start runs due jobs every poll interval until the context is cancelled,
+// dropping finished jobs once their retention is over
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Job worker started", slog.String("interval", w.interval.String()), slog.Any("kinds", w.kinds()))
+
+	for {
+		// Keep going while full batches are claimed, so a backlog drains
+		// without waiting a poll interval per batch
+		for w.RunOnce(ctx) == w.batchSize && ctx.Err() == nil {
+		}
+		w.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Job worker shutting down")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims a batch of due jobs, runs them one after another and
+// returns how many it claimed
+func (w *Worker) RunOnce(ctx context.Context) int {
+	w.failAbandoned(ctx)
+
+	claimed, err := w.claim(ctx)
+	if err != nil {
+		slog.Error("Failed to claim jobs", slog.String("error", err.Error()))
+		return 0
+	}
+
+	for _, job := range claimed {
+		w.run(ctx, job)
+	}
+	return len(claimed)
+}
+
+// kinds returns the kinds the worker has handlers for, sorted
+func (w *Worker) kinds() []string {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// claim leases up to a batch of due jobs to the worker: queued jobs whose
+// time has come, and running jobs whose worker's lease ran out with attempts
+// left. Jobs claimed by other workers are skipped rather than waited for.
+func (w *Worker) claim(ctx context.Context) ([]Job, error) {
+	due := sq.Select("id").
+		From("jobs").
+		Where(sq.Expr("kind = ANY(?)", pq.Array(w.kinds()))).
+		Where("scheduled_at <= CURRENT_TIMESTAMP").
+		Where(sq.Or{
+			sq.Eq{"status": StatusQueued},
+			sq.And{
+				sq.Eq{"status": StatusRunning},
+				sq.Expr("locked_until < CURRENT_TIMESTAMP"),
+				sq.Expr("attempts < max_attempts"),
+			},
+		}).
+		OrderBy("scheduled_at", "id").
+		Limit(uint64(w.batchSize)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	sqlStr, args, err := postgres.StatementBuilder.
+		Update("jobs").
+		Set("status", StatusRunning).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("locked_until", sq.Expr("CURRENT_TIMESTAMP + make_interval(secs => ?)", w.lease.Seconds())).
+		Where(sq.Expr("id IN (?)", due)).
+		Suffix("RETURNING " + strings.Join(jobColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Run them in the order they were due
+	slices.SortFunc(claimed, func(a, b Job) int { return a.ScheduledAt.Compare(b.ScheduledAt) })
+	return claimed, nil
+}
+
+// run runs a claimed job within its lease and records how it went
+func (w *Worker) run(ctx context.Context, job Job) {
+	start := time.Now()
+
+	runCtx, cancel := context.WithTimeout(ctx, w.lease)
+	err := w.call(runCtx, job)
+	cancel()
+
+	switch {
+	case err == nil:
+		metrics.ObserveQueuedJob(job.Kind, metrics.QueueDone, start)
+		w.finish(ctx, job, postgres.StatementBuilder.Update("jobs").
+			Set("status", StatusDone).
+			Set("last_error", nil).
+			Set("finished_at", sq.Expr("CURRENT_TIMESTAMP")))
+
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		metrics.ObserveQueuedJob(job.Kind, metrics.QueueFailed, start)
+		slog.Error("Job failed",
+			slog.String("job_id", job.ID),
+			slog.String("kind", job.Kind),
+			slog.Int("attempt", job.Attempts),
+			slog.String("error", err.Error()))
+		w.finish(ctx, job, postgres.StatementBuilder.Update("jobs").
+			Set("status", StatusFailed).
+			Set("last_error", err.Error()).
+			Set("finished_at", sq.Expr("CURRENT_TIMESTAMP")))
+
+	default:
+		delay := w.retryDelay(job.Attempts)
+		metrics.ObserveQueuedJob(job.Kind, metrics.QueueRetried, start)
+		slog.Warn("Job failed, retrying",
+			slog.String("job_id", job.ID),
+			slog.String("kind", job.Kind),
+			slog.Int("attempt", job.Attempts),
+			slog.Duration("retry_in", delay),
+			slog.String("error", err.Error()))
+		w.finish(ctx, job, postgres.StatementBuilder.Update("jobs").
+			Set("status", StatusQueued).
+			Set("last_error", err.Error()).
+			Set("scheduled_at", sq.Expr("CURRENT_TIMESTAMP + make_interval(secs => ?)", delay.Seconds())))
+	}
+}
+
+// call runs the job's handler, turning a panic into an error
+func (w *Worker) call(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return w.handlers[job.Kind](ctx, job)
+}
+
+// finish releases a job's lease with update. A job whose lease ran out and
+// was taken over by another worker has moved on to a later attempt, so the
+// update is left out rather than overwrite it.
+func (w *Worker) finish(ctx context.Context, job Job, update sq.UpdateBuilder) {
+	sqlStr, args, err := update.
+		Set("locked_until", nil).
+		Where("id = ?::bigint", job.ID).
+		Where(sq.Eq{"attempts": job.Attempts}).
+		ToSql()
+	if err == nil {
+		_, err = w.db.ExecContext(ctx, sqlStr, args...)
+	}
+	if err != nil {
+		slog.Error("Failed to record job result", slog.String("job_id", job.ID), slog.String("error", err.Error()))
+	}
+}
+
+// retryDelay is how long a job waits after failing its attempt-th run: the
+// backoff, doubled for every further attempt, up to maxBackoff
+func (w *Worker) retryDelay(attempt int) time.Duration {
+	delay := w.backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// failAbandoned fails jobs whose worker's lease ran out on their last attempt
+func (w *Worker) failAbandoned(ctx context.Context) {
+	sqlStr, args, err := postgres.StatementBuilder.
+		Update("jobs").
+		Set("status", StatusFailed).
+		Set("last_error", "lease expired on the last attempt").
+		Set("locked_until", nil).
+		Set("finished_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"status": StatusRunning}).
+		Where("locked_until < CURRENT_TIMESTAMP").
+		Where("attempts >= max_attempts").
+		ToSql()
+	if err == nil {
+		_, err = w.db.ExecContext(ctx, sqlStr, args...)
+	}
+	if err != nil {
+		slog.Error("Failed to fail abandoned jobs", slog.String("error", err.Error()))
+	}
+}
+
+// purge deletes jobs that finished longer ago than the retention
+func (w *Worker) purge(ctx context.Context) {
+	sqlStr, args, err := postgres.StatementBuilder.
+		Delete("jobs").
+		Where(sq.Eq{"status": []string{StatusDone, StatusFailed}}).
+		Where("finished_at < CURRENT_TIMESTAMP - make_interval(secs => ?)", w.retention.Seconds()).
+		ToSql()
+	if err == nil {
+		_, err = w.db.ExecContext(ctx, sqlStr, args...)
+	}
+	if err != nil {
+		slog.Error("Failed to purge finished jobs", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+// Message is an HTML email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Headers map[string]string // extra headers, e.g. List-Unsubscribe
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns an SMTP mailer for the configured server, or a mailer that only
+// logs messages when no server is configured (e.g. in development)
+func New(cfg config.Mail) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail from address %q: %w", cfg.From, err)
+	}
+
+	if cfg.SMTPAddress == "" {
+		return &LogMailer{from: from}, nil
+	}
+
+	host, _, err := net.SplitHostPort(cfg.SMTPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address %q: %w", cfg.SMTPAddress, err)
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+
+	return &SMTPMailer{address: cfg.SMTPAddress, auth: auth, from: from}, nil
+}
+
+// SMTPMailer sends emails through an SMTP server, using STARTTLS when offered
+type SMTPMailer struct {
+	address string
+	auth    smtp.Auth
+	from    *mail.Address
+}
+
+// Send delivers the message. net/smtp has no context support, so ctx is only
+// checked before sending.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.address, m.auth, m.from.Address, []string{msg.To}, format(m.from, msg, time.Now()))
+}
+
+// LogMailer logs emails instead of sending them
+type LogMailer struct {
+	from *mail.Address
+}
+
+// Send logs the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.Info("Email not sent, no SMTP server configured",
+		slog.String("from", m.from.String()),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject))
+	return nil
+}
+
+// format renders the message as an RFC 5322 email with an HTML body
+func format(from *mail.Address, msg Message, date time.Time) []byte {
+	headers := map[string]string{
+		"From":         from.String(),
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         date.Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": `text/html; charset="utf-8"`,
+	}
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.HTML)
+
+	return buf.Bytes()
+}
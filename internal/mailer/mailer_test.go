@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+)
+
+func TestFormat(t *testing.T) {
+	from := &mail.Address{Name: "Stories", Address: "no-reply@stories.local"}
+	msg := Message{
+		To:      "ana@example.com",
+		Subject: "Você tem novos seguidores",
+		HTML:    "<p>Hello</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://stories.local/unsubscribe>"},
+	}
+
+	raw := string(format(from, msg, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Failed to parse formatted message: %v\n%s", err, raw)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Expected subject %q, got %q (%v)", msg.Subject, subject, err)
+	}
+	if got := parsed.Header.Get("List-Unsubscribe"); got != msg.Headers["List-Unsubscribe"] {
+		t.Errorf("Expected List-Unsubscribe header, got %q", got)
+	}
+	if got := parsed.Header.Get("From"); got != `"Stories" <no-reply@stories.local>` {
+		t.Errorf("Unexpected From header %q", got)
+	}
+	if !strings.HasSuffix(raw, "\r\n\r\n<p>Hello</p>") {
+		t.Errorf("Expected the HTML body after the headers, got %q", raw)
+	}
+}
+
+func TestNew(t *testing.T) {
+	m, err := New(config.Mail{From: "Stories <no-reply@stories.local>"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := m.(*LogMailer); !ok {
+		t.Errorf("Expected a LogMailer without an SMTP server, got %T", m)
+	}
+	if err := m.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hi"}); err != nil {
+		t.Errorf("LogMailer.Send failed: %v", err)
+	}
+
+	m, err = New(config.Mail{SMTPAddress: "smtp.example.com:587", From: "no-reply@stories.local"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := m.(*SMTPMailer); !ok {
+		t.Errorf("Expected an SMTPMailer, got %T", m)
+	}
+
+	if _, err := New(config.Mail{From: "not an address"}); err == nil {
+		t.Error("Expected an invalid from address to be rejected")
+	}
+}
+
+// recordingMailer records the messages it is asked to send
+type recordingMailer struct {
+	sent []Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestSendJob(t *testing.T) {
+	recorder := &recordingMailer{}
+	send := SendJob(recorder)
+
+	job := jobs.Job{
+		Kind:    JobSend,
+		Payload: json.RawMessage(`{"To":"ana@example.com","Subject":"Hi","HTML":"<p>Hello</p>"}`),
+	}
+	if err := send(context.Background(), job); err != nil {
+		t.Fatalf("SendJob failed: %v", err)
+	}
+	if len(recorder.sent) != 1 || recorder.sent[0].To != "ana@example.com" || recorder.sent[0].HTML != "<p>Hello</p>" {
+		t.Errorf("Expected the queued message to be sent, got %+v", recorder.sent)
+	}
+
+	job.Payload = json.RawMessage(`"not a message"`)
+	if err := send(context.Background(), job); !jobs.IsPermanent(err) {
+		t.Errorf("Expected an undecodable message to fail permanently, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+)
+
+// JobSend is the kind of job that sends a queued email
+const JobSend = "email.send"
+
+// QueuedMailer queues emails as jobs instead of sending them, so an email
+// whose server is down is retried rather than lost
+type QueuedMailer struct {
+	queue *jobs.Queue
+}
+
+// NewQueued returns a mailer queueing emails on queue; a worker running
+// SendJob sends them
+func NewQueued(queue *jobs.Queue) *QueuedMailer {
+	return &QueuedMailer{queue: queue}
+}
+
+// Send queues the message
+func (m *QueuedMailer) Send(ctx context.Context, msg Message) error {
+	_, err := m.queue.Enqueue(ctx, JobSend, msg)
+	return err
+}
+
+// SendJob returns a job handler sending queued emails with m
+func SendJob(m Mailer) jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var msg Message
+		if err := job.Decode(&msg); err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	}
+}
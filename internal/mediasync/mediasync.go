@@ -0,0 +1,328 @@
+package mediasync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/minio/minio-go/v7"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+)
+
+// maxSamples caps each orphan list in a report
+const maxSamples = 100
+
+// ErrNoReport is returned by LoadReport before the job has first run
+var ErrNoReport = errors.New("no reconciliation report yet")
+
+// Bucket is the part of a tenant's media bucket the reconciler uses
+type Bucket interface {
+	EachObject(ctx context.Context, fn func(minio.ObjectInfo) error) error
+	DeleteObject(objectKey string) error
+}
+
+// Reconciler compares the objects in every tenant's media bucket with the
+// upload records in the database. Objects without a record and confirmed
+// records without an object are reported. Initiated uploads whose URL expired
+// are marked uploaded if their object is there and failed otherwise. Uploads
+// left unconfirmed past the TTL are reported and, if configured, deleted
+// along with their object. In a dry run nothing is marked or deleted and the
+// report says what would have been.
+type Reconciler struct {
+	store             storage.MediaStore
+	bucket            func(tenantID string) (Bucket, error)
+	redis             *redis.Client
+	keys              cache.Keys
+	interval          time.Duration
+	uploadURLTTL      time.Duration
+	unconfirmedTTL    time.Duration
+	deleteUnconfirmed bool
+	dryRun            bool
+}
+
+// options says what a run may change
+type options struct {
+	deleteUnconfirmed bool // delete uploads left unconfirmed past the TTL
+	dryRun            bool // change nothing, reporting what would change
+}
+
+// NewReconciler creates a reconciler for the media service's buckets, saving
+// each tenant's report to Redis under its keys
+func NewReconciler(store storage.MediaStore, service *mediaService.Service, redisClient *redis.Client, keys cache.Keys, cfg config.Media) *Reconciler {
+	return &Reconciler{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			return service.ForTenant(tenantID)
+		},
+		redis:             redisClient,
+		keys:              keys,
+		interval:          time.Duration(cfg.Reconcile.Interval) * time.Second,
+		uploadURLTTL:      service.UploadURLTTL(),
+		unconfirmedTTL:    time.Duration(cfg.Reconcile.UnconfirmedTTL) * time.Second,
+		deleteUnconfirmed: cfg.Reconcile.DeleteUnconfirmed,
+		dryRun:            cfg.Reconcile.DryRun,
+	}
+}
+
+// Start reconciles every interval until the context is cancelled
+func (rc *Reconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(rc.interval)
+	defer ticker.Stop()
+
+	slog.Info("Media reconciler started", slog.String("interval", rc.interval.String()), slog.Bool("delete_unconfirmed", rc.deleteUnconfirmed), slog.Bool("dry_run", rc.dryRun))
+
+	for {
+		start := time.Now()
+		report, tenants := rc.RunOnce(ctx)
+		metrics.ObserveWorkerBatch(metrics.JobMediaReconcile, start, len(report.Errors))
+		slog.Info("Reconciled media",
+			slog.Int("objects_scanned", report.ObjectsScanned),
+			slog.Int("uploads_scanned", report.UploadsScanned),
+			slog.Int("untracked", report.UntrackedCount),
+			slog.Int("missing", report.MissingCount),
+			slog.Int("expired", report.ExpiredCount),
+			slog.Int("unconfirmed", report.UnconfirmedCount),
+			slog.Int("deleted", report.DeletedCount),
+			slog.Int("errors", len(report.Errors)))
+
+		for tenantID, tenantReport := range tenants {
+			if err := rc.saveReport(ctx, tenantID, tenantReport); err != nil {
+				slog.Error("Failed to save media reconciliation report", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Media reconciler shutting down")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce reconciles every tenant and returns what it found in all of them
+// and in each one, by tenant ID. A tenant that cannot be checked is recorded
+// in the reports' errors and skipped.
+func (rc *Reconciler) RunOnce(ctx context.Context) (media.ReconciliationReport, map[string]media.ReconciliationReport) {
+	opts := options{deleteUnconfirmed: rc.deleteUnconfirmed, dryRun: rc.dryRun}
+	report := newReport(opts)
+
+	tenantIDs, err := rc.store.GetTenantIDs()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("list tenants: %s", err))
+	}
+
+	tenants := make(map[string]media.ReconciliationReport, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		tenantReport := newReport(opts)
+		rc.reconcile(ctx, tenantID, opts, &tenantReport)
+		tenantReport.FinishedAt = time.Now().UTC()
+		tenants[tenantID] = tenantReport
+		merge(&report, tenantReport)
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	return report, tenants
+}
+
+// merge adds a tenant's report to the report of the whole run
+func merge(report *media.ReconciliationReport, tenantReport media.ReconciliationReport) {
+	report.Tenants += tenantReport.Tenants
+	report.ObjectsScanned += tenantReport.ObjectsScanned
+	report.UploadsScanned += tenantReport.UploadsScanned
+	report.UntrackedCount += tenantReport.UntrackedCount
+	report.Untracked = appendSamples(report.Untracked, tenantReport.Untracked)
+	report.MissingCount += tenantReport.MissingCount
+	report.Missing = appendSamples(report.Missing, tenantReport.Missing)
+	report.ExpiredCount += tenantReport.ExpiredCount
+	report.UnconfirmedCount += tenantReport.UnconfirmedCount
+	report.DeletedCount += tenantReport.DeletedCount
+	report.Deleted = appendSamples(report.Deleted, tenantReport.Deleted)
+	report.Errors = append(report.Errors, tenantReport.Errors...)
+}
+
+// appendSamples appends orphans to samples, keeping at most maxSamples
+func appendSamples(samples, orphans []media.Orphan) []media.Orphan {
+	return append(samples, orphans[:min(len(orphans), maxSamples-len(samples))]...)
+}
+
+// CollectGarbage reconciles the tenant now, deleting every upload left
+// unconfirmed past the TTL whether or not the job is configured to, and
+// returns what it found. With dryRun nothing is changed. Its report is not
+// saved in place of the job's.
+func (rc *Reconciler) CollectGarbage(ctx context.Context, tenantID string, dryRun bool) media.ReconciliationReport {
+	report := newReport(options{deleteUnconfirmed: true, dryRun: dryRun})
+	rc.reconcile(ctx, tenantID, options{deleteUnconfirmed: true, dryRun: dryRun}, &report)
+	report.FinishedAt = time.Now().UTC()
+	return report
+}
+
+// newReport starts the report of a run with opts
+func newReport(opts options) media.ReconciliationReport {
+	return media.ReconciliationReport{
+		StartedAt: time.Now().UTC(),
+		DryRun:    opts.dryRun,
+		Untracked: []media.Orphan{},
+		Missing:   []media.Orphan{},
+		Deleted:   []media.Orphan{},
+	}
+}
+
+// reconcile adds the tenant to the report, recording its failure in the
+// report's errors
+func (rc *Reconciler) reconcile(ctx context.Context, tenantID string, opts options, report *media.ReconciliationReport) {
+	if err := rc.reconcileTenant(ctx, tenantID, opts, report); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("tenant %s: %s", tenantID, err))
+	}
+	report.Tenants++
+}
+
+// reconcileTenant adds what it finds in one tenant to the report
+func (rc *Reconciler) reconcileTenant(ctx context.Context, tenantID string, opts options, report *media.ReconciliationReport) error {
+	bucket, err := rc.bucket(tenantID)
+	if err != nil {
+		return err
+	}
+
+	records, err := rc.store.GetMediaUploads(tenantID)
+	if err != nil {
+		return fmt.Errorf("list uploads: %w", err)
+	}
+	report.UploadsScanned += len(records)
+
+	uploads := make(map[string]media.MediaUpload, len(records))
+	for _, upload := range records {
+		uploads[upload.ObjectKey] = upload
+	}
+
+	// Sizes of the objects that have an upload record
+	seen := make(map[string]int64, len(records))
+	err = bucket.EachObject(ctx, func(object minio.ObjectInfo) error {
+		report.ObjectsScanned++
+		if _, ok := uploads[object.Key]; ok {
+			seen[object.Key] = object.Size
+			return nil
+		}
+		report.UntrackedCount++
+		if len(report.Untracked) < maxSamples {
+			report.Untracked = append(report.Untracked, media.Orphan{ObjectKey: object.Key, TenantID: tenantID, Since: object.LastModified.UTC()})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list objects: %w", err)
+	}
+
+	now := time.Now().UTC()
+	cutoff := now.Add(-rc.unconfirmedTTL)
+	for _, upload := range records {
+		size, found := seen[upload.ObjectKey]
+
+		if upload.Status == media.UploadInitiated && upload.CreatedAt.Before(now.Add(-rc.uploadURLTTL)) {
+			// The upload URL no longer works, so the upload is as done as it
+			// will get
+			status := media.UploadFailed
+			if found {
+				status = media.UploadUploaded
+			}
+			// A dry run goes on as though the status had been set
+			updated := upload
+			updated.Status, updated.Size = status, size
+			var err error
+			if !opts.dryRun {
+				updated, err = rc.store.SetMediaUploadStatus(upload.ObjectKey, status, size)
+			}
+			switch {
+			case err == nil:
+				upload = updated
+				if status == media.UploadFailed {
+					report.ExpiredCount++
+				}
+			case !errors.Is(err, sql.ErrNoRows):
+				report.Errors = append(report.Errors, fmt.Sprintf("expire upload %s: %s", upload.ObjectKey, err))
+			}
+		}
+
+		if upload.Confirmed() {
+			if !found {
+				report.MissingCount++
+				if len(report.Missing) < maxSamples {
+					report.Missing = append(report.Missing, media.Orphan{ObjectKey: upload.ObjectKey, TenantID: tenantID, Since: upload.CreatedAt})
+				}
+			}
+			continue
+		}
+
+		if !upload.CreatedAt.Before(cutoff) {
+			continue
+		}
+		report.UnconfirmedCount++
+		if !opts.deleteUnconfirmed {
+			continue
+		}
+		if opts.dryRun {
+			deleted(report, upload)
+			continue
+		}
+
+		if found {
+			if err := bucket.DeleteObject(upload.ObjectKey); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("delete object %s: %s", upload.ObjectKey, err))
+				continue
+			}
+		}
+		if err := rc.store.DeleteMediaUpload(upload.ObjectKey); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("delete upload %s: %s", upload.ObjectKey, err))
+			continue
+		}
+		deleted(report, upload)
+	}
+
+	return nil
+}
+
+// deleted counts an upload deleted, or in a dry run one that would be
+func deleted(report *media.ReconciliationReport, upload media.MediaUpload) {
+	report.DeletedCount++
+	if len(report.Deleted) < maxSamples {
+		report.Deleted = append(report.Deleted, media.Orphan{ObjectKey: upload.ObjectKey, TenantID: upload.TenantID, Since: upload.CreatedAt})
+	}
+}
+
+// saveReport replaces the tenant's stored report with the given one
+func (rc *Reconciler) saveReport(ctx context.Context, tenantID string, report media.ReconciliationReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return rc.redis.Set(ctx, rc.keys.ForTenant(tenantID).Key(cache.ReconciliationReportKey), data, 0).Err()
+}
+
+// LoadReport returns the tenant's report of the last reconciliation run saved
+// under keys, or ErrNoReport if there has been none
+func LoadReport(ctx context.Context, redisClient *redis.Client, keys cache.Keys, tenantID string) (media.ReconciliationReport, error) {
+	var report media.ReconciliationReport
+
+	data, err := redisClient.Get(ctx, keys.ForTenant(tenantID).Key(cache.ReconciliationReportKey)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return report, ErrNoReport
+	}
+	if err != nil {
+		return report, fmt.Errorf("failed to load reconciliation report: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("invalid reconciliation report: %w", err)
+	}
+	return report, nil
+}
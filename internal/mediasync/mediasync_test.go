@@ -0,0 +1,255 @@
+package mediasync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+)
+
+// fakeStore serves fixed upload records and records status changes and
+// deletions
+type fakeStore struct {
+	uploads  map[string][]media.MediaUpload
+	statuses map[string]string
+	deleted  []string
+}
+
+func (s *fakeStore) CreateMediaUpload(userID, objectKey, contentType string) error {
+	return nil
+}
+
+func (s *fakeStore) ConfirmMediaUpload(userID, objectKey string, size int64) (media.MediaUpload, error) {
+	return media.MediaUpload{}, nil
+}
+
+func (s *fakeStore) GetMediaUpload(userID, objectKey string) (media.MediaUpload, error) {
+	return media.MediaUpload{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) SetMediaUploadStatus(objectKey, status string, size int64) (media.MediaUpload, error) {
+	s.statuses[objectKey] = status
+	return media.MediaUpload{ObjectKey: objectKey, Status: status, Size: size, CreatedAt: time.Now().UTC().Add(-48 * time.Hour)}, nil
+}
+
+func (s *fakeStore) GetMediaUploads(tenantID string) ([]media.MediaUpload, error) {
+	return s.uploads[tenantID], nil
+}
+
+func (s *fakeStore) GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) DeleteMediaUpload(objectKey string) error {
+	s.deleted = append(s.deleted, objectKey)
+	return nil
+}
+
+func (s *fakeStore) GetTenantIDs() ([]string, error) {
+	return []string{"default", "acme"}, nil
+}
+
+// fakeBucket lists fixed object keys and records deletions
+type fakeBucket struct {
+	keys    []string
+	deleted []string
+}
+
+func (b *fakeBucket) EachObject(ctx context.Context, fn func(minio.ObjectInfo) error) error {
+	for _, key := range b.keys {
+		if err := fn(minio.ObjectInfo{Key: key}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *fakeBucket) DeleteObject(objectKey string) error {
+	b.deleted = append(b.deleted, objectKey)
+	return nil
+}
+
+func TestReconciler_RunOnce(t *testing.T) {
+	now := time.Now().UTC()
+	confirmed := now.Add(-time.Hour)
+
+	store := &fakeStore{
+		uploads: map[string][]media.MediaUpload{
+			"default": {
+				{ObjectKey: "users/1/media/kept.jpg", Status: media.UploadConfirmed, CreatedAt: now.Add(-48 * time.Hour), ConfirmedAt: &confirmed},
+				{ObjectKey: "users/1/media/gone.jpg", Status: media.UploadConfirmed, CreatedAt: now.Add(-48 * time.Hour), ConfirmedAt: &confirmed},
+				{ObjectKey: "users/1/media/abandoned.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-48 * time.Hour)},
+				{ObjectKey: "users/1/media/never-uploaded.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-48 * time.Hour)},
+				{ObjectKey: "users/1/media/in-progress.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-time.Minute)},
+			},
+		},
+		statuses: make(map[string]string),
+	}
+	buckets := map[string]*fakeBucket{
+		"default": {keys: []string{"users/1/media/abandoned.jpg", "users/1/media/in-progress.jpg", "users/1/media/kept.jpg", "users/1/media/stray.jpg"}},
+		"acme":    {keys: []string{"users/7/media/stray.png"}},
+	}
+
+	rc := &Reconciler{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			return buckets[tenantID], nil
+		},
+		uploadURLTTL:      time.Hour,
+		unconfirmedTTL:    24 * time.Hour,
+		deleteUnconfirmed: true,
+	}
+	report, tenants := rc.RunOnce(context.Background())
+
+	if report.Tenants != 2 || report.ObjectsScanned != 5 || report.UploadsScanned != 5 {
+		t.Errorf("Expected 2 tenants, 5 objects and 5 uploads scanned, got %+v", report)
+	}
+	if report.UntrackedCount != 2 || len(report.Untracked) != 2 {
+		t.Errorf("Expected 2 untracked objects, got %+v", report.Untracked)
+	}
+	// Each tenant's report only holds its own orphans
+	if acme := tenants["acme"]; acme.Tenants != 1 || acme.UntrackedCount != 1 || acme.Untracked[0].ObjectKey != "users/7/media/stray.png" || acme.DeletedCount != 0 {
+		t.Errorf("Expected only acme's stray object in its report, got %+v", acme)
+	}
+	if defaultReport := tenants["default"]; defaultReport.UntrackedCount != 1 || defaultReport.MissingCount != 1 || defaultReport.DeletedCount != 2 {
+		t.Errorf("Expected the default tenant's orphans in its report, got %+v", defaultReport)
+	}
+	if report.MissingCount != 1 || report.Missing[0].ObjectKey != "users/1/media/gone.jpg" {
+		t.Errorf("Expected gone.jpg to be missing, got %+v", report.Missing)
+	}
+	if report.ExpiredCount != 1 {
+		t.Errorf("Expected 1 expired upload, got %d", report.ExpiredCount)
+	}
+	// Uploads whose URL expired are settled; the one in progress is left alone
+	if len(store.statuses) != 2 || store.statuses["users/1/media/abandoned.jpg"] != media.UploadUploaded ||
+		store.statuses["users/1/media/never-uploaded.jpg"] != media.UploadFailed {
+		t.Errorf("Expected abandoned.jpg uploaded and never-uploaded.jpg failed, got %v", store.statuses)
+	}
+	if report.UnconfirmedCount != 2 || report.DeletedCount != 2 {
+		t.Errorf("Expected 2 stale unconfirmed uploads deleted, got %d of %d", report.DeletedCount, report.UnconfirmedCount)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", report.Errors)
+	}
+
+	if len(store.deleted) != 2 {
+		t.Errorf("Expected 2 upload records deleted, got %v", store.deleted)
+	}
+	// Only the abandoned upload had an object to delete
+	if deleted := buckets["default"].deleted; len(deleted) != 1 || deleted[0] != "users/1/media/abandoned.jpg" {
+		t.Errorf("Expected only abandoned.jpg to be deleted from the bucket, got %v", deleted)
+	}
+}
+
+func TestReconciler_RunOnceReportOnly(t *testing.T) {
+	store := &fakeStore{
+		uploads: map[string][]media.MediaUpload{
+			"default": {{ObjectKey: "users/1/media/abandoned.jpg", Status: media.UploadUploaded, CreatedAt: time.Now().UTC().Add(-48 * time.Hour)}},
+		},
+		statuses: make(map[string]string),
+	}
+	bucket := &fakeBucket{keys: []string{"users/1/media/abandoned.jpg"}}
+
+	rc := &Reconciler{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			if tenantID == "acme" {
+				return nil, errors.New("bucket unavailable")
+			}
+			return bucket, nil
+		},
+		unconfirmedTTL: 24 * time.Hour,
+	}
+	report, _ := rc.RunOnce(context.Background())
+
+	if report.UnconfirmedCount != 1 || report.DeletedCount != 0 {
+		t.Errorf("Expected 1 unconfirmed upload reported but not deleted, got %+v", report)
+	}
+	if len(store.deleted) != 0 || len(bucket.deleted) != 0 {
+		t.Errorf("Expected nothing deleted, got records %v and objects %v", store.deleted, bucket.deleted)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("Expected the unavailable tenant to be reported, got %v", report.Errors)
+	}
+}
+
+func TestReconciler_DryRun(t *testing.T) {
+	now := time.Now().UTC()
+	store := &fakeStore{
+		uploads: map[string][]media.MediaUpload{
+			"default": {
+				{ObjectKey: "users/1/media/abandoned.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-48 * time.Hour)},
+				{ObjectKey: "users/1/media/never-uploaded.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-48 * time.Hour)},
+				{ObjectKey: "users/1/media/in-progress.jpg", Status: media.UploadInitiated, CreatedAt: now.Add(-time.Minute)},
+			},
+		},
+		statuses: make(map[string]string),
+	}
+	bucket := &fakeBucket{keys: []string{"users/1/media/abandoned.jpg"}}
+
+	rc := &Reconciler{
+		store: store,
+		bucket: func(tenantID string) (Bucket, error) {
+			return bucket, nil
+		},
+		uploadURLTTL:   time.Hour,
+		unconfirmedTTL: 24 * time.Hour,
+	}
+
+	// Collecting garbage deletes stale uploads even though the job is not
+	// configured to; a dry run only reports them
+	report := rc.CollectGarbage(context.Background(), "default", true)
+
+	if !report.DryRun || report.Tenants != 1 || report.ExpiredCount != 1 {
+		t.Errorf("Expected a dry run of one tenant with 1 expired upload, got %+v", report)
+	}
+	if report.UnconfirmedCount != 2 || report.DeletedCount != 2 || len(report.Deleted) != 2 {
+		t.Errorf("Expected 2 stale uploads that would be deleted, got %+v", report)
+	}
+	if len(store.statuses) != 0 || len(store.deleted) != 0 || len(bucket.deleted) != 0 {
+		t.Errorf("Expected a dry run to change nothing, got statuses %v, records %v and objects %v", store.statuses, store.deleted, bucket.deleted)
+	}
+
+	report = rc.CollectGarbage(context.Background(), "default", false)
+
+	if report.DryRun || report.DeletedCount != 2 || len(store.deleted) != 2 || len(bucket.deleted) != 1 {
+		t.Errorf("Expected 2 stale uploads and 1 object deleted, got %+v", report)
+	}
+}
+
+func TestReport_SaveAndLoad(t *testing.T) {
+	redisClient, _ := redistest.New(t)
+	ctx := context.Background()
+	keys := cache.NewKeys("staging")
+
+	if _, err := LoadReport(ctx, redisClient, keys, "default"); !errors.Is(err, ErrNoReport) {
+		t.Fatalf("Expected ErrNoReport before the first run, got %v", err)
+	}
+
+	rc := &Reconciler{redis: redisClient, keys: keys}
+	saved := media.ReconciliationReport{Tenants: 1, UntrackedCount: 1, Untracked: []media.Orphan{{ObjectKey: "users/1/media/stray.jpg", TenantID: "default"}}}
+	if err := rc.saveReport(ctx, "default", saved); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+
+	if _, err := LoadReport(ctx, redisClient, cache.NewKeys(""), "default"); !errors.Is(err, ErrNoReport) {
+		t.Errorf("Expected another environment not to see the report, got %v", err)
+	}
+	if _, err := LoadReport(ctx, redisClient, keys, "acme"); !errors.Is(err, ErrNoReport) {
+		t.Errorf("Expected another tenant not to see the report, got %v", err)
+	}
+
+	loaded, err := LoadReport(ctx, redisClient, keys, "default")
+	if err != nil {
+		t.Fatalf("Failed to load report: %v", err)
+	}
+	if loaded.Tenants != 1 || len(loaded.Untracked) != 1 || loaded.Untracked[0].ObjectKey != "users/1/media/stray.jpg" {
+		t.Errorf("Expected the saved report back, got %+v", loaded)
+	}
+}
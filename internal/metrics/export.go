@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// defaultPushInterval is used when push_interval is not a positive number of
+// seconds, which a ticker cannot run on
+const defaultPushInterval = 15 * time.Second
+
+// Exporter makes a worker binary's metrics available to Prometheus, which
+// cannot scrape the worker through the stories service. It serves them in
+// the OpenMetrics format on a local port, pushes them to a Pushgateway, or
+// both, as configured.
+type Exporter struct {
+	server   *http.Server
+	pusher   *push.Pusher
+	interval time.Duration
+}
+
+// NewExporter creates an exporter for the default registry. Pushed metrics
+// are grouped under cfg.Job and the host name, so each worker instance keeps
+// its own series.
+func NewExporter(cfg config.WorkerMetrics) *Exporter {
+	e := &Exporter{interval: time.Duration(cfg.PushInterval) * time.Second}
+	if e.interval <= 0 {
+		e.interval = defaultPushInterval
+	}
+
+	if cfg.Address != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+		e.server = &http.Server{Addr: cfg.Address, Handler: mux}
+	}
+
+	if cfg.Pushgateway != "" {
+		instance, err := os.Hostname()
+		if err != nil {
+			instance = "unknown"
+		}
+		e.pusher = push.New(cfg.Pushgateway, cfg.Job).
+			Gatherer(prometheus.DefaultGatherer).
+			Grouping("instance", instance)
+	}
+	return e
+}
+
+// Start starts serving metrics, which goes on until Shutdown, and pushes them
+// every interval until ctx is done
+func (e *Exporter) Start(ctx context.Context) {
+	if e.server != nil {
+		go func() {
+			slog.Info("Serving worker metrics", slog.String("address", e.server.Addr))
+			if err := e.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Worker metrics server failed", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	if e.pusher == nil {
+		return
+	}
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.push(ctx)
+		}
+	}
+}
+
+// Shutdown stops serving and pushes once more, so the gateway holds the
+// counts from the last batches before the worker exits
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	if e.pusher != nil {
+		e.push(ctx)
+	}
+	if e.server != nil {
+		return e.server.Shutdown(ctx)
+	}
+	return nil
+}
+
+// push replaces the worker's metrics on the gateway
+func (e *Exporter) push(ctx context.Context) {
+	if err := e.pusher.PushContext(ctx); err != nil {
+		slog.Warn("Failed to push worker metrics", slog.String("error", err.Error()))
+	}
+}
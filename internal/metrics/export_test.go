@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+func TestExporter_ServesOpenMetrics(t *testing.T) {
+	exporter := NewExporter(config.WorkerMetrics{Address: "127.0.0.1:0"})
+	StoriesExpired(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	exporter.server.Handler.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics, got %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "stories_worker_stories_expired_total") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("Expected the worker counters in OpenMetrics, got %q", body)
+	}
+}
+
+func TestExporter_DefaultPushInterval(t *testing.T) {
+	exporter := NewExporter(config.WorkerMetrics{Pushgateway: "http://pushgateway:9091", PushInterval: 0})
+	if exporter.interval != defaultPushInterval {
+		t.Errorf("Expected the default interval for push_interval 0, got %v", exporter.interval)
+	}
+}
+
+func TestExporter_Pushes(t *testing.T) {
+	pushes := make(chan string, 10)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/metrics/job/test-worker/instance/") && len(body) > 0 {
+			pushes <- r.URL.Path
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	exporter := NewExporter(config.WorkerMetrics{Pushgateway: gateway.URL, PushInterval: 1, Job: "test-worker"})
+	if exporter.server != nil {
+		t.Fatal("Expected no metrics server without an address")
+	}
+	exporter.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		exporter.Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-pushes:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a push every interval")
+	}
+	cancel()
+	<-done
+
+	// Shutting down pushes the final counts
+	for len(pushes) > 0 {
+		<-pushes
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if len(pushes) != 1 {
+		t.Errorf("Expected one push on shutdown, got %d", len(pushes))
+	}
+}
@@ -0,0 +1,310 @@
+package metrics
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Feed results, used as the result label of the feed and cache metrics
+const (
+	ResultHit   = "hit"   // served from the cache
+	ResultMiss  = "miss"  // served from the database
+	ResultError = "error" // failed
+)
+
+// Query results, used as the result label of the storage metrics
+const (
+	ResultOK = "ok"
+)
+
+// Event results, used as the result label of the event metrics
+const (
+	EventDelivered    = "delivered"     // handed to the hub, relay or digest queue
+	EventRetried      = "retried"       // failed and scheduled for another attempt
+	EventDeadLettered = "dead_lettered" // given up on and logged
+)
+
+var (
+	feedDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_feed_request_duration_seconds",
+		Help:    "Time feed handlers take to produce a feed, by handler and result (hit, miss, error).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"handler", "result"})
+
+	feedStories = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_feed_request_stories",
+		Help:    "Number of stories feed handlers return, by handler and result (hit, miss).",
+		Buckets: []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	}, []string{"handler", "result"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_storage_query_duration_seconds",
+		Help:    "Time feed queries take in the database, by query and result (ok, error).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query", "result"})
+
+	queryRows = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_storage_query_rows",
+		Help:    "Number of rows successful feed queries return, by query.",
+		Buckets: []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	}, []string{"query"})
+
+	txRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_storage_tx_retries_total",
+		Help: "Database transactions run again after a serialization failure or deadlock, by reason.",
+	}, []string{"reason"})
+
+	rateLimitRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_ratelimit_rejected_total",
+		Help: "Requests and WebSocket connection attempts turned away by a rate limit, by action.",
+	}, []string{"action"})
+
+	rateLimitFallback = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stories_ratelimit_fallback_active",
+		Help: "1 while Redis is unavailable and rate limits are enforced by per-instance buckets, 0 otherwise.",
+	})
+
+	concurrencyRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_http_concurrency_rejected_total",
+		Help: "Requests turned away with 503 because too many were in flight, by scope (global or the route name).",
+	}, []string{"scope"})
+
+	eventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_events_published_total",
+		Help: "Event delivery attempts by event type and result (delivered, retried, dead_lettered).",
+	}, []string{"event_type", "result"})
+
+	eventRetriesPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stories_events_retries_pending",
+		Help: "Events waiting for another delivery attempt.",
+	})
+
+	wsQueueDepth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stories_ws_send_queue_depth",
+		Help:    "Messages waiting in a WebSocket client's send queue each time its write pump picks one up.",
+		Buckets: []float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256},
+	})
+
+	wsWriteDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stories_ws_write_duration_seconds",
+		Help:    "Time a WebSocket write pump takes to write a frame to the peer.",
+		Buckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5, 10},
+	})
+
+	wsDroppedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "stories_ws_dropped_events_total",
+		Help: "Events dropped because a WebSocket client's send queue was full.",
+	})
+
+	wsSlowConsumerDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "stories_ws_slow_consumer_disconnects_total",
+		Help: "WebSocket clients disconnected because their send queue stayed full.",
+	})
+
+	abuseThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_abuse_throttled_total",
+		Help: "Accounts shadow-throttled by the abuse detector, by the action that tripped it (follow, unfollow, view).",
+	}, []string{"action"})
+
+	abuseShadowed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_abuse_shadowed_requests_total",
+		Help: "Requests from throttled accounts answered without being carried out, by action.",
+	}, []string{"action"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_cache_lookups_total",
+		Help: "Reads of the Redis cache, by cache (the key namespace) and result (hit, miss).",
+	}, []string{"cache", "result"})
+
+	experimentExposures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_experiment_exposures_total",
+		Help: "Users first served a variant of an experiment, counted once a day each, by experiment and variant.",
+	}, []string{"experiment", "variant"})
+)
+
+// Totals are running counts of what the ops WebSocket topic reports, kept
+// next to the Prometheus metrics so they can be read without a scrape
+type Totals struct {
+	FeedHits            uint64 // feed requests served from the cache
+	FeedMisses          uint64 // feed requests served from the database
+	RateLimitRejections uint64 // requests and connection attempts rate limited
+}
+
+var feedHits, feedMisses, rateLimitRejections atomic.Uint64
+
+// CurrentTotals returns the running counts since the process started
+func CurrentTotals() Totals {
+	return Totals{
+		FeedHits:            feedHits.Load(),
+		FeedMisses:          feedMisses.Load(),
+		RateLimitRejections: rateLimitRejections.Load(),
+	}
+}
+
+// CacheCounts are the running hits and misses of one cache
+type CacheCounts struct {
+	Hits   uint64
+	Misses uint64
+}
+
+var cacheCounts = struct {
+	sync.Mutex
+	byCache map[string]*CacheCounts
+}{byCache: make(map[string]*CacheCounts)}
+
+// CacheLookups returns the running hits and misses of every cache read since
+// the process started, by cache
+func CacheLookups() map[string]CacheCounts {
+	cacheCounts.Lock()
+	defer cacheCounts.Unlock()
+
+	lookups := make(map[string]CacheCounts, len(cacheCounts.byCache))
+	for cache, counts := range cacheCounts.byCache {
+		lookups[cache] = *counts
+	}
+	return lookups
+}
+
+// slowQueryThreshold is the duration above which queries are logged, in
+// nanoseconds; zero disables the log
+var slowQueryThreshold atomic.Int64
+
+// SetSlowQueryThreshold logs every observed query that takes longer than
+// threshold; zero disables the log
+func SetSlowQueryThreshold(threshold time.Duration) {
+	slowQueryThreshold.Store(int64(threshold))
+}
+
+// ObserveFeed records how long a feed handler took since start, how many
+// stories it returned and whether it was served from the cache
+func ObserveFeed(handler, result string, start time.Time, stories int) {
+	feedDuration.WithLabelValues(handler, result).Observe(time.Since(start).Seconds())
+	switch result {
+	case ResultHit:
+		feedHits.Add(1)
+	case ResultMiss:
+		feedMisses.Add(1)
+	}
+	if result != ResultError {
+		feedStories.WithLabelValues(handler, result).Observe(float64(stories))
+	}
+}
+
+// ObserveQuery records how long a storage query took since start and how many
+// rows it returned, and logs it when it is slow
+func ObserveQuery(query string, start time.Time, rows int, err error) {
+	elapsed := time.Since(start)
+
+	result := ResultOK
+	if err != nil {
+		result = ResultError
+	} else {
+		queryRows.WithLabelValues(query).Observe(float64(rows))
+	}
+	queryDuration.WithLabelValues(query, result).Observe(elapsed.Seconds())
+
+	if threshold := time.Duration(slowQueryThreshold.Load()); threshold > 0 && elapsed > threshold {
+		slog.Warn("Slow query",
+			slog.String("query", query),
+			slog.Duration("duration", elapsed),
+			slog.Int("rows", rows),
+			slog.String("result", result))
+	}
+}
+
+// TxRetried counts a database transaction run again, for reason
+// (serialization_failure or deadlock)
+func TxRetried(reason string) {
+	txRetries.WithLabelValues(reason).Inc()
+}
+
+// RateLimited counts a request or connection attempt of action turned away
+// by a rate limit
+func RateLimited(action string) {
+	rateLimitRejected.WithLabelValues(action).Inc()
+	rateLimitRejections.Add(1)
+}
+
+// SetRateLimitFallback records whether rate limiting has fallen back to
+// per-instance buckets
+func SetRateLimitFallback(active bool) {
+	if active {
+		rateLimitFallback.Set(1)
+	} else {
+		rateLimitFallback.Set(0)
+	}
+}
+
+// ConcurrencyRejected counts a request turned away by the concurrency limit
+// of scope
+func ConcurrencyRejected(scope string) {
+	concurrencyRejected.WithLabelValues(scope).Inc()
+}
+
+// ObserveEvent counts a delivery attempt of an event of eventType with result
+func ObserveEvent(eventType, result string) {
+	eventsPublished.WithLabelValues(eventType, result).Inc()
+}
+
+// SetEventRetriesPending records how many events are waiting to be retried
+func SetEventRetriesPending(pending int) {
+	eventRetriesPending.Set(float64(pending))
+}
+
+// ObserveWebSocketWrite records how many messages were queued when a write
+// pump picked one up and how long the write took since start
+func ObserveWebSocketWrite(depth int, start time.Time) {
+	wsQueueDepth.Observe(float64(depth))
+	wsWriteDuration.Observe(time.Since(start).Seconds())
+}
+
+// WebSocketEventDropped counts an event dropped at a full send queue
+func WebSocketEventDropped() {
+	wsDroppedEvents.Inc()
+}
+
+// WebSocketSlowConsumer counts a client disconnected as a slow consumer
+func WebSocketSlowConsumer() {
+	wsSlowConsumerDisconnects.Inc()
+}
+
+// AbuseThrottled counts an account throttled for exceeding the limit of action
+func AbuseThrottled(action string) {
+	abuseThrottled.WithLabelValues(action).Inc()
+}
+
+// AbuseShadowed counts a request of action from a throttled account that was
+// answered without being carried out
+func AbuseShadowed(action string) {
+	abuseShadowed.WithLabelValues(action).Inc()
+}
+
+// ExperimentExposed counts a user served variant of experiment for the first
+// time that day
+func ExperimentExposed(experiment, variant string) {
+	experimentExposures.WithLabelValues(experiment, variant).Inc()
+}
+
+// CacheLookup counts a read of cache with result (hit or miss)
+func CacheLookup(cache, result string) {
+	cacheLookups.WithLabelValues(cache, result).Inc()
+
+	cacheCounts.Lock()
+	defer cacheCounts.Unlock()
+	counts, ok := cacheCounts.byCache[cache]
+	if !ok {
+		counts = &CacheCounts{}
+		cacheCounts.byCache[cache] = counts
+	}
+	switch result {
+	case ResultHit:
+		counts.Hits++
+	case ResultMiss:
+		counts.Misses++
+	}
+}
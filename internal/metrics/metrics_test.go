@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveQuery(t *testing.T) {
+	start := time.Now()
+	ObserveQuery("test_query", start, 3, nil)
+	ObserveQuery("test_query", start, 0, errors.New("boom"))
+
+	if n := testutil.CollectAndCount(queryDuration, "stories_storage_query_duration_seconds"); n < 2 {
+		t.Errorf("Expected ok and error duration series, got %d", n)
+	}
+
+	// Rows are only recorded for successful queries
+	if n := testutil.CollectAndCount(queryRows, "stories_storage_query_rows"); n != 1 {
+		t.Errorf("Expected one rows series, got %d", n)
+	}
+}
+
+func TestObserveFeed(t *testing.T) {
+	start := time.Now()
+	ObserveFeed("test_feed", ResultHit, start, 10)
+	ObserveFeed("test_feed", ResultMiss, start, 10)
+	ObserveFeed("test_feed", ResultError, start, 0)
+
+	if n := testutil.CollectAndCount(feedDuration, "stories_feed_request_duration_seconds"); n != 3 {
+		t.Errorf("Expected a duration series per result, got %d", n)
+	}
+	if n := testutil.CollectAndCount(feedStories, "stories_feed_request_stories"); n != 2 {
+		t.Errorf("Expected story counts for hits and misses only, got %d", n)
+	}
+}
+
+func TestSetRateLimitFallback(t *testing.T) {
+	SetRateLimitFallback(true)
+	if v := testutil.ToFloat64(rateLimitFallback); v != 1 {
+		t.Errorf("Expected fallback gauge 1, got %v", v)
+	}
+
+	SetRateLimitFallback(false)
+	if v := testutil.ToFloat64(rateLimitFallback); v != 0 {
+		t.Errorf("Expected fallback gauge 0, got %v", v)
+	}
+}
+
+func TestWebSocketSlowConsumer(t *testing.T) {
+	dropped := testutil.ToFloat64(wsDroppedEvents)
+	disconnects := testutil.ToFloat64(wsSlowConsumerDisconnects)
+
+	WebSocketEventDropped()
+	WebSocketEventDropped()
+	WebSocketSlowConsumer()
+
+	if v := testutil.ToFloat64(wsDroppedEvents) - dropped; v != 2 {
+		t.Errorf("Expected 2 dropped events, got %v", v)
+	}
+	if v := testutil.ToFloat64(wsSlowConsumerDisconnects) - disconnects; v != 1 {
+		t.Errorf("Expected 1 slow consumer disconnect, got %v", v)
+	}
+}
+
+func TestCurrentTotals(t *testing.T) {
+	before := CurrentTotals()
+	rejected := testutil.ToFloat64(rateLimitRejected.WithLabelValues("test_action"))
+
+	start := time.Now()
+	ObserveFeed("test_feed", ResultHit, start, 1)
+	ObserveFeed("test_feed", ResultHit, start, 1)
+	ObserveFeed("test_feed", ResultMiss, start, 1)
+	ObserveFeed("test_feed", ResultError, start, 0)
+	RateLimited("test_action")
+
+	after := CurrentTotals()
+	if hits, misses := after.FeedHits-before.FeedHits, after.FeedMisses-before.FeedMisses; hits != 2 || misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %d and %d", hits, misses)
+	}
+	if n := after.RateLimitRejections - before.RateLimitRejections; n != 1 {
+		t.Errorf("Expected 1 rate limit rejection, got %d", n)
+	}
+	if v := testutil.ToFloat64(rateLimitRejected.WithLabelValues("test_action")) - rejected; v != 1 {
+		t.Errorf("Expected the rejection counted by action, got %v", v)
+	}
+}
+
+func TestCacheLookup(t *testing.T) {
+	before := CacheLookups()["test_cache"]
+
+	CacheLookup("test_cache", ResultHit)
+	CacheLookup("test_cache", ResultHit)
+	CacheLookup("test_cache", ResultMiss)
+
+	after := CacheLookups()["test_cache"]
+	if hits, misses := after.Hits-before.Hits, after.Misses-before.Misses; hits != 2 || misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %d and %d", hits, misses)
+	}
+	if v := testutil.ToFloat64(cacheLookups.WithLabelValues("test_cache", ResultHit)); v < 2 {
+		t.Errorf("Expected the hits counted by cache, got %v", v)
+	}
+}
+
+func TestObserveWorkerBatch(t *testing.T) {
+	failures := testutil.ToFloat64(workerFailures.WithLabelValues("test_job"))
+	expired := testutil.ToFloat64(storiesExpired)
+
+	start := time.Now()
+	ObserveWorkerBatch("test_job", start, 0)
+	ObserveWorkerBatch("test_job", start, 3)
+	StoriesExpired(5)
+
+	if n := testutil.CollectAndCount(workerBatchDuration, "stories_worker_batch_duration_seconds"); n < 1 {
+		t.Errorf("Expected a batch duration series, got %d", n)
+	}
+	if v := testutil.ToFloat64(workerFailures.WithLabelValues("test_job")) - failures; v != 3 {
+		t.Errorf("Expected 3 failures, got %v", v)
+	}
+	if v := testutil.ToFloat64(storiesExpired) - expired; v != 5 {
+		t.Errorf("Expected 5 expired stories, got %v", v)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Worker jobs, used as the job label of the worker metrics
+const (
+	JobExpiryWarnings = "expiry_warnings"
+	JobExpire         = "expire"
+	JobArchive        = "archive"
+	JobImpressions    = "impressions"
+	JobMediaReconcile = "media_reconcile"
+)
+
+// Queued job results, used as the result label of the job queue metrics
+const (
+	QueueDone    = "done"    // ran successfully
+	QueueRetried = "retried" // failed and scheduled to run again
+	QueueFailed  = "failed"  // failed for the last time
+)
+
+var (
+	workerBatchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_worker_batch_duration_seconds",
+		Help:    "Time a worker batch takes, by job.",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"job"})
+
+	workerFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_worker_failures_total",
+		Help: "Failures worker batches ran into, by job; a batch may fail several times and carry on.",
+	}, []string{"job"})
+
+	storiesExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "stories_worker_stories_expired_total",
+		Help: "Stories the worker soft-deleted once they expired.",
+	})
+
+	queuedJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stories_queue_jobs_total",
+		Help: "Runs of jobs from the Postgres job queue, by kind and result (done, retried, failed).",
+	}, []string{"kind", "result"})
+
+	queuedJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stories_queue_job_duration_seconds",
+		Help:    "Time jobs from the Postgres job queue take to run, by kind.",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"kind"})
+)
+
+// ObserveWorkerBatch records how long a batch of job took since start and the
+// failures it ran into
+func ObserveWorkerBatch(job string, start time.Time, failures int) {
+	workerBatchDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	if failures > 0 {
+		workerFailures.WithLabelValues(job).Add(float64(failures))
+	}
+}
+
+// StoriesExpired counts stories soft-deleted once they expired
+func StoriesExpired(n int) {
+	storiesExpired.Add(float64(n))
+}
+
+// ObserveQueuedJob records a run of a queued job of kind that took since start
+// and ended with result
+func ObserveQueuedJob(kind, result string, start time.Time) {
+	queuedJobs.WithLabelValues(kind, result).Inc()
+	queuedJobDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
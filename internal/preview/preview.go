@@ -0,0 +1,164 @@
+// Package preview describes stories for other platforms: an HTML page with
+// OpenGraph tags that link unfurlers read, and the matching oEmbed response
+package preview
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"log/slog"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// SiteName is how previews name this service
+const SiteName = "Stories"
+
+// Media links in previews stay valid about as long as the story itself
+const mediaURLTTL = 24 * time.Hour
+
+// maxDescription is the longest description, in characters, a preview carries
+const maxDescription = 200
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var page = template.Must(template.ParseFS(templateFS, "templates/story.html"))
+
+// Meta is what a story preview shows
+type Meta struct {
+	SiteName    string
+	Title       string
+	Description string
+	AuthorName  string
+	PageURL     string // The page the preview is for
+	ImageURL    string
+	VideoURL    string
+	OEmbedURL   string
+}
+
+// OEmbed is an oEmbed 1.0 link response
+type OEmbed struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name,omitempty"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// Builder builds previews of stories
+type Builder struct {
+	media     *mediaService.Service
+	publicURL string
+}
+
+// NewBuilder creates a preview builder. Page and oEmbed URLs are based on
+// publicURL, and media links are presigned with media when it is set.
+func NewBuilder(media *mediaService.Service, publicURL string) *Builder {
+	return &Builder{media: media, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// PublicURL returns the base URL of preview pages
+func (b *Builder) PublicURL() string {
+	return b.publicURL
+}
+
+// StoryPath returns the path of a public story's preview page
+func StoryPath(storyID string) string {
+	return "/stories/" + storyID + "/preview"
+}
+
+// Story describes a story by author, shown at pagePath
+func (b *Builder) Story(story types.Story, author users.User, pagePath string) Meta {
+	meta := Meta{
+		SiteName:    SiteName,
+		Title:       DisplayName(author.Email) + "'s story",
+		Description: truncate(story.Text, maxDescription),
+		AuthorName:  DisplayName(author.Email),
+		PageURL:     b.publicURL + pagePath,
+		OEmbedURL:   b.publicURL + "/oembed?" + url.Values{"url": {b.publicURL + pagePath}}.Encode(),
+	}
+	if story.PlaceName != "" {
+		meta.Title += " at " + story.PlaceName
+	}
+
+	if story.MediaKey != "" && b.media != nil {
+		if mediaURL := b.mediaURL(author.TenantID, story.MediaKey); mediaURL != "" {
+			if strings.HasPrefix(mime.TypeByExtension(path.Ext(story.MediaKey)), "video/") {
+				meta.VideoURL = mediaURL
+			} else {
+				meta.ImageURL = mediaURL
+			}
+		}
+	}
+
+	return meta
+}
+
+// Placeholder describes a page whose story cannot be shown, such as one
+// behind a link that requires sign-in
+func (b *Builder) Placeholder(title string) Meta {
+	return Meta{SiteName: SiteName, Title: title}
+}
+
+// OEmbed returns the oEmbed response for a preview
+func (b *Builder) OEmbed(meta Meta) OEmbed {
+	return OEmbed{
+		Version:      "1.0",
+		Type:         "link",
+		Title:        meta.Title,
+		AuthorName:   meta.AuthorName,
+		ProviderName: SiteName,
+		ProviderURL:  b.publicURL,
+		ThumbnailURL: meta.ImageURL,
+	}
+}
+
+// Render writes the preview page for meta
+func Render(w io.Writer, meta Meta) error {
+	return page.Execute(w, meta)
+}
+
+// DisplayName is the name previews show for a user: the local part of their
+// email, so the full address is never published
+func DisplayName(email string) string {
+	name, _, _ := strings.Cut(email, "@")
+	return name
+}
+
+// mediaURL presigns a link to a story's media in its author's tenant bucket,
+// or returns "" if that fails, since a preview is still useful without it
+func (b *Builder) mediaURL(tenantID, mediaKey string) string {
+	media, err := b.media.ForTenant(tenantID)
+	if err != nil {
+		slog.Warn("Failed to open media bucket for preview", slog.String("error", err.Error()))
+		return ""
+	}
+
+	mediaURL, err := media.GeneratePresignedDownloadURL(mediaKey, mediaURLTTL)
+	if err != nil {
+		slog.Warn("Failed to presign media for preview", slog.String("error", err.Error()), slog.String("media_key", mediaKey))
+		return ""
+	}
+	return mediaURL.String()
+}
+
+// truncate shortens text to at most max characters, ending with an ellipsis
+// when it was cut
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
@@ -0,0 +1,54 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+func TestBuilder_Story(t *testing.T) {
+	builder := NewBuilder(nil, "https://stories.example/")
+	story := types.Story{ID: "7", Text: strings.Repeat("a", 300), PlaceName: "Paris"}
+
+	meta := builder.Story(story, users.User{Email: "alice@example.com"}, StoryPath("7"))
+	if meta.AuthorName != "alice" {
+		t.Errorf("Expected author name without the email domain, got %q", meta.AuthorName)
+	}
+	if meta.Title != "alice's story at Paris" {
+		t.Errorf("Unexpected title %q", meta.Title)
+	}
+	if n := len([]rune(meta.Description)); n != maxDescription {
+		t.Errorf("Expected a %d character description, got %d", maxDescription, n)
+	}
+	if meta.PageURL != "https://stories.example/stories/7/preview" {
+		t.Errorf("Unexpected page URL %q", meta.PageURL)
+	}
+	if meta.OEmbedURL != "https://stories.example/oembed?url=https%3A%2F%2Fstories.example%2Fstories%2F7%2Fpreview" {
+		t.Errorf("Unexpected oEmbed URL %q", meta.OEmbedURL)
+	}
+
+	embed := builder.OEmbed(meta)
+	if embed.Version != "1.0" || embed.Type != "link" || embed.AuthorName != "alice" || embed.ProviderURL != "https://stories.example" {
+		t.Errorf("Unexpected oEmbed response %+v", embed)
+	}
+}
+
+func TestRender_EscapesStoryText(t *testing.T) {
+	var page strings.Builder
+	err := Render(&page, Meta{SiteName: SiteName, Title: "alice's story", Description: `"><script>alert(1)</script>`, ImageURL: "https://media.example/a.png"})
+	if err != nil {
+		t.Fatalf("Failed to render preview: %v", err)
+	}
+
+	html := page.String()
+	if strings.Contains(html, "<script>") {
+		t.Error("Expected story text to be escaped")
+	}
+	for _, tag := range []string{`property="og:title"`, `property="og:description"`, `property="og:image" content="https://media.example/a.png"`} {
+		if !strings.Contains(html, tag) {
+			t.Errorf("Expected preview to contain %s", tag)
+		}
+	}
+}
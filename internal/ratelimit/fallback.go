@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+)
+
+// Breaker is a circuit breaker around Redis. After threshold consecutive
+// failures it opens, and rate limiters sharing it answer from their local
+// buckets instead of calling Redis. Once cooldown has passed a single call is
+// let through to probe Redis; a success closes the breaker, a failure keeps
+// it open for another cooldown.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time // or when the last probe was let through
+}
+
+// NewBreaker creates a closed breaker
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether the next call should go to Redis
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	// Let this call probe Redis and hold the others back for another cooldown
+	b.openedAt = b.now()
+	return true
+}
+
+// Success records a successful Redis call, closing the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.open {
+		b.open = false
+		metrics.SetRateLimitFallback(false)
+		slog.Info("Redis is back, rate limiting resumed in Redis")
+	}
+}
+
+// Failure records a failed Redis call, opening the breaker after threshold
+// consecutive failures
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.open {
+		b.openedAt = b.now()
+		return
+	}
+	if b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = b.now()
+		metrics.SetRateLimitFallback(true)
+		slog.Warn("Redis unavailable, rate limiting falls back to per-instance buckets",
+			slog.Int("failures", b.failures),
+			slog.Duration("retry_after", b.cooldown))
+	}
+}
+
+// Open reports whether the breaker is open
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// localBuckets are per-instance token buckets with the same capacity and
+// refill as the Redis ones, used while Redis is unavailable. Since every
+// instance keeps its own, a user spread over several instances gets more
+// than the limit until Redis is back.
+type localBuckets struct {
+	capacity int64
+	refill   int64
+	window   time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+	swept   time.Time
+}
+
+type localBucket struct {
+	tokens     int64
+	lastRefill time.Time
+}
+
+func newLocalBuckets(capacity, refill int64, window time.Duration) *localBuckets {
+	return &localBuckets{
+		capacity: capacity,
+		refill:   refill,
+		window:   window,
+		buckets:  make(map[string]*localBucket),
+	}
+}
+
+// take consumes a token from key's bucket, reporting whether one was left
+func (l *localBuckets) take(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &localBucket{tokens: l.capacity, lastRefill: now}
+		l.buckets[key] = bucket
+	}
+	if added := l.refilled(bucket, now); added >= l.capacity-bucket.tokens {
+		bucket.tokens = l.capacity
+		bucket.lastRefill = now
+	} else if added > 0 {
+		// Only move on by the time the added tokens took, so the time towards
+		// the next one is kept
+		bucket.tokens += added
+		bucket.lastRefill = bucket.lastRefill.Add(time.Duration(added * int64(l.window) / l.refill))
+	}
+
+	if bucket.tokens <= 0 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// remaining returns the tokens left in key's bucket
+func (l *localBuckets) remaining(key string, now time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		return l.capacity
+	}
+	return min(l.capacity, bucket.tokens+l.refilled(bucket, now))
+}
+
+// reset drops key's bucket
+func (l *localBuckets) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, key)
+}
+
+// refilled returns the tokens bucket has earned since its last refill
+func (l *localBuckets) refilled(bucket *localBucket, now time.Time) int64 {
+	elapsed := now.Sub(bucket.lastRefill)
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(elapsed) * l.refill / int64(l.window)
+}
+
+// sweep drops buckets that have refilled completely, at most once per
+// window; l.mu must be held
+func (l *localBuckets) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	l.swept = now
+	for key, bucket := range l.buckets {
+		if bucket.tokens+l.refilled(bucket, now) >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	if breaker.Open() || !breaker.Allow() {
+		t.Fatal("Expected the breaker to stay closed below the threshold")
+	}
+	breaker.Failure()
+	if !breaker.Open() || breaker.Allow() {
+		t.Fatal("Expected the breaker to open at the threshold")
+	}
+
+	// After the cooldown a single probe goes through
+	now = now.Add(time.Minute)
+	if !breaker.Allow() {
+		t.Fatal("Expected a probe after the cooldown")
+	}
+	if breaker.Allow() {
+		t.Fatal("Expected only one probe per cooldown")
+	}
+
+	// A failed probe keeps it open for another cooldown
+	breaker.Failure()
+	now = now.Add(time.Minute / 2)
+	if breaker.Allow() {
+		t.Fatal("Expected the breaker to stay open after a failed probe")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.Allow() {
+		t.Fatal("Expected a probe after the second cooldown")
+	}
+	breaker.Success()
+	if breaker.Open() || !breaker.Allow() {
+		t.Fatal("Expected a successful probe to close the breaker")
+	}
+}
+
+func TestLocalBuckets(t *testing.T) {
+	now := time.Now()
+	buckets := newLocalBuckets(3, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !buckets.take("user", now) {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	if buckets.take("user", now) {
+		t.Fatal("Expected the 4th request to be denied")
+	}
+	if remaining := buckets.remaining("user", now); remaining != 0 {
+		t.Errorf("Expected 0 remaining, got %d", remaining)
+	}
+	if !buckets.take("other", now) {
+		t.Error("Expected another key to have its own bucket")
+	}
+
+	// A third of the window refills one token
+	now = now.Add(20 * time.Second)
+	if remaining := buckets.remaining("user", now); remaining != 1 {
+		t.Errorf("Expected 1 remaining after refill, got %d", remaining)
+	}
+	if !buckets.take("user", now) {
+		t.Error("Expected a refilled token to be allowed")
+	}
+
+	// The half token earned along with a whole one counts towards the next
+	now = now.Add(30 * time.Second)
+	if !buckets.take("user", now) {
+		t.Error("Expected a refilled token to be allowed")
+	}
+	now = now.Add(10 * time.Second)
+	if !buckets.take("user", now) {
+		t.Error("Expected the half token left over to be completed")
+	}
+	if buckets.take("user", now) {
+		t.Error("Expected no token left")
+	}
+
+	// Full buckets are swept once a window has passed
+	now = now.Add(2 * time.Minute)
+	buckets.take("user", now)
+	if _, ok := buckets.buckets["other"]; ok {
+		t.Error("Expected the refilled bucket to be swept")
+	}
+}
+
+func TestTokenBucket_FallsBackWhenRedisIsDown(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer redisClient.Close()
+
+	breaker := NewBreaker(1, time.Minute)
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 2, 2).WithBreaker(breaker)
+	ctx := context.Background()
+
+	if allowed, err := bucket.Allow(ctx, "user", "action"); err != nil || !allowed {
+		t.Fatalf("Expected the first request to be allowed, got %v, %v", allowed, err)
+	}
+
+	mr.Close()
+
+	// The failing call opens the breaker and is answered locally, as are the
+	// calls after it
+	for i := 0; i < 2; i++ {
+		allowed, err := bucket.Allow(ctx, "user", "action")
+		if err != nil {
+			t.Fatalf("Expected no error while Redis is down, got %v", err)
+		}
+		if !allowed {
+			t.Fatalf("Expected local request %d to be allowed", i+1)
+		}
+	}
+	if !breaker.Open() {
+		t.Fatal("Expected the breaker to open")
+	}
+	if allowed, _ := bucket.Allow(ctx, "user", "action"); allowed {
+		t.Error("Expected the local bucket to enforce the limit")
+	}
+	if remaining, err := bucket.GetRemaining(ctx, "user", "action"); err != nil || remaining != 0 {
+		t.Errorf("Expected 0 remaining from the local bucket, got %d, %v", remaining, err)
+	}
+}
+
+func TestTokenBucket_WithoutBreakerReturnsErrors(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer redisClient.Close()
+	mr.Close()
+
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 2, 2)
+	if _, err := bucket.Allow(context.Background(), "user", "action"); err == nil {
+		t.Error("Expected an error without a breaker")
+	}
+}
@@ -6,30 +6,80 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
 )
 
 // TokenBucket represents a token bucket rate limiter
 type TokenBucket struct {
 	redis    *redis.Client
+	keys     cache.Keys
 	capacity int64         // Maximum number of tokens
 	refill   int64         // Number of tokens to refill per minute
 	window   time.Duration // Time window for refilling (1 minute)
+
+	breaker *Breaker      // Optional; when set, Redis failures fall back to local
+	local   *localBuckets // Per-instance buckets used while the breaker is open
+}
+
+// Status is how much of a rate limit is left
+type Status struct {
+	Limit     int64         // Actions allowed in a burst
+	Remaining int64         // Actions allowed right now
+	Window    time.Duration // How long an empty bucket takes to refill
+	Reset     time.Duration // Until the full limit is available again
 }
 
-// NewTokenBucket creates a new token bucket rate limiter
-func NewTokenBucket(redisClient *redis.Client, capacity, refillRate int64) *TokenBucket {
+// NewTokenBucket creates a new token bucket rate limiter keeping its buckets
+// under keys
+func NewTokenBucket(redisClient *redis.Client, keys cache.Keys, capacity, refillRate int64) *TokenBucket {
 	return &TokenBucket{
 		redis:    redisClient,
+		keys:     keys,
 		capacity: capacity,
 		refill:   refillRate,
 		window:   time.Minute,
+		local:    newLocalBuckets(capacity, refillRate, time.Minute),
+	}
+}
+
+// WithBreaker makes the bucket fall back to per-instance buckets instead of
+// failing while breaker reports Redis as unavailable. Limiters talking to the
+// same Redis should share a breaker.
+func (tb *TokenBucket) WithBreaker(breaker *Breaker) *TokenBucket {
+	tb.breaker = breaker
+	return tb
+}
+
+// redisAvailable reports whether calls should go to Redis
+func (tb *TokenBucket) redisAvailable() bool {
+	return tb.breaker == nil || tb.breaker.Allow()
+}
+
+// redisFailed records a failed Redis call and reports whether the local
+// buckets should answer instead. A cancelled request says nothing about
+// Redis, so it is neither counted nor answered locally.
+func (tb *TokenBucket) redisFailed(ctx context.Context) bool {
+	if tb.breaker == nil || ctx.Err() != nil {
+		return false
+	}
+	tb.breaker.Failure()
+	return true
+}
+
+// redisSucceeded records a successful Redis call
+func (tb *TokenBucket) redisSucceeded() {
+	if tb.breaker != nil {
+		tb.breaker.Success()
 	}
 }
 
 // Allow checks if the user can perform an action based on rate limiting
 // Returns true if action is allowed, false otherwise
 func (tb *TokenBucket) Allow(ctx context.Context, userID, action string) (bool, error) {
-	key := fmt.Sprintf("rate_limit:%s:%s", userID, action)
+	key := tb.keys.Key(cache.RateLimitKey, userID, action)
+	if !tb.redisAvailable() {
+		return tb.local.take(key, time.Now()), nil
+	}
 
 	// Lua script for atomic token bucket operations
 	luaScript := `
@@ -73,8 +123,12 @@ func (tb *TokenBucket) Allow(ctx context.Context, userID, action string) (bool,
 		tb.capacity, tb.refill, int64(tb.window.Seconds()), now).Result()
 
 	if err != nil {
+		if tb.redisFailed(ctx) {
+			return tb.local.take(key, time.Now()), nil
+		}
 		return false, fmt.Errorf("rate limit check failed: %w", err)
 	}
+	tb.redisSucceeded()
 
 	allowed, ok := result.(int64)
 	if !ok {
@@ -86,7 +140,10 @@ func (tb *TokenBucket) Allow(ctx context.Context, userID, action string) (bool,
 
 // GetRemaining returns the number of remaining tokens for a user action
 func (tb *TokenBucket) GetRemaining(ctx context.Context, userID, action string) (int64, error) {
-	key := fmt.Sprintf("rate_limit:%s:%s", userID, action)
+	key := tb.keys.Key(cache.RateLimitKey, userID, action)
+	if !tb.redisAvailable() {
+		return tb.local.remaining(key, time.Now()), nil
+	}
 
 	luaScript := `
 		local key = KEYS[1]
@@ -116,8 +173,12 @@ func (tb *TokenBucket) GetRemaining(ctx context.Context, userID, action string)
 		tb.capacity, tb.refill, int64(tb.window.Seconds()), now).Result()
 
 	if err != nil {
+		if tb.redisFailed(ctx) {
+			return tb.local.remaining(key, time.Now()), nil
+		}
 		return 0, fmt.Errorf("failed to get remaining tokens: %w", err)
 	}
+	tb.redisSucceeded()
 
 	remaining, ok := result.(int64)
 	if !ok {
@@ -127,8 +188,23 @@ func (tb *TokenBucket) GetRemaining(ctx context.Context, userID, action string)
 	return remaining, nil
 }
 
+// Status returns how much of the user's limit for action is left
+func (tb *TokenBucket) Status(ctx context.Context, userID, action string) (Status, error) {
+	remaining, err := tb.GetRemaining(ctx, userID, action)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Limit: tb.capacity, Remaining: remaining, Window: tb.window}
+	if missing := tb.capacity - remaining; missing > 0 && tb.refill > 0 {
+		status.Reset = time.Duration((missing*int64(tb.window) + tb.refill - 1) / tb.refill)
+	}
+	return status, nil
+}
+
 // Reset clears the rate limit for a specific user action
 func (tb *TokenBucket) Reset(ctx context.Context, userID, action string) error {
-	key := fmt.Sprintf("rate_limit:%s:%s", userID, action)
+	key := tb.keys.Key(cache.RateLimitKey, userID, action)
+	tb.local.reset(key)
 	return tb.redis.Del(ctx, key).Err()
 }
@@ -3,43 +3,17 @@ package ratelimit
 import (
 	"context"
 	"testing"
+	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
 )
 
-// setupTestRedis creates an in-memory Redis server for testing
-func setupTestRedis(t *testing.T) (*redis.Client, func()) {
-	mr, err := miniredis.Run()
-	if err != nil {
-		t.Fatalf("Failed to start miniredis: %v", err)
-	}
-
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: mr.Addr(),
-		DB:   0,
-	})
-
-	// Test connection
-	_, err = redisClient.Ping(context.Background()).Result()
-	if err != nil {
-		t.Fatalf("Failed to connect to test Redis: %v", err)
-	}
-
-	cleanup := func() {
-		redisClient.Close()
-		mr.Close()
-	}
-
-	return redisClient, cleanup
-}
-
 func TestTokenBucket_Allow(t *testing.T) {
-	redisClient, cleanup := setupTestRedis(t)
-	defer cleanup()
+	redisClient, _ := redistest.New(t)
 
 	// Create token bucket with 5 tokens, refill 5 per minute
-	bucket := NewTokenBucket(redisClient, 5, 5)
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 5, 5)
 
 	ctx := context.Background()
 	userID := "test_user"
@@ -76,10 +50,9 @@ func TestTokenBucket_Allow(t *testing.T) {
 }
 
 func TestTokenBucket_GetRemaining(t *testing.T) {
-	redisClient, cleanup := setupTestRedis(t)
-	defer cleanup()
+	redisClient, _ := redistest.New(t)
 
-	bucket := NewTokenBucket(redisClient, 10, 10)
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 10, 10)
 
 	ctx := context.Background()
 	userID := "test_user_2"
@@ -110,10 +83,9 @@ func TestTokenBucket_GetRemaining(t *testing.T) {
 }
 
 func TestTokenBucket_Reset(t *testing.T) {
-	redisClient, cleanup := setupTestRedis(t)
-	defer cleanup()
+	redisClient, _ := redistest.New(t)
 
-	bucket := NewTokenBucket(redisClient, 5, 5)
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 5, 5)
 
 	ctx := context.Background()
 	userID := "test_user_3"
@@ -139,3 +111,34 @@ func TestTokenBucket_Reset(t *testing.T) {
 		t.Fatalf("Expected 5 remaining tokens after reset, got %d", remaining)
 	}
 }
+
+func TestTokenBucket_Status(t *testing.T) {
+	redisClient, _ := redistest.New(t)
+
+	bucket := NewTokenBucket(redisClient, cache.NewKeys(""), 5, 5)
+
+	ctx := context.Background()
+	userID := "test_user_4"
+	action := "test_action_4"
+
+	status, err := bucket.Status(ctx, userID, action)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Limit != 5 || status.Remaining != 5 || status.Reset != 0 || status.Window != time.Minute {
+		t.Fatalf("Expected a full bucket of 5 per minute, got %+v", status)
+	}
+
+	// Consume 2 tokens, which take 12 seconds each to refill
+	for i := 0; i < 2; i++ {
+		bucket.Allow(ctx, userID, action)
+	}
+
+	status, err = bucket.Status(ctx, userID, action)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Remaining != 3 || status.Reset != 24*time.Second {
+		t.Fatalf("Expected 3 remaining and a reset in 24s, got %+v", status)
+	}
+}
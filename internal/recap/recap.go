@@ -0,0 +1,159 @@
+// Package recap sends creators a recap of their week: their top story, the
+// reach of their stories and how their followers changed. Recaps are made by
+// jobs on the job queue once each week ends, Monday at midnight UTC, and are
+// delivered as a WebSocket event and, to users who opt in, an email.
+package recap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// Recap job kinds
+const (
+	JobWeekly  = "recap.weekly"  // queues a JobCreator job for each creator active during a week
+	JobCreator = "recap.creator" // makes and delivers one creator's recap of a week
+)
+
+// Limits on the number of recaps listed at once, about a quarter and a year
+const (
+	DefaultListLimit = 12
+	MaxListLimit     = 52
+)
+
+// Queue queues recap jobs
+type Queue interface {
+	EnqueueOnce(ctx context.Context, kind, key string, payload any, at time.Time) (string, bool, error)
+}
+
+// Store is the data recaps are made from and the recipients they go to
+type Store interface {
+	storage.RecapStore
+	GetUserByID(userID string) (users.User, error)
+	GetNotificationSettings(userID string) (users.NotificationSettings, error)
+}
+
+// Publisher tells connected creators their recap is ready
+type Publisher interface {
+	PublishWeeklyRecap(userID string, recap *users.WeeklyRecap) error
+}
+
+// Mailer emails recaps to creators who opted in
+type Mailer interface {
+	SendWeeklyRecap(ctx context.Context, to users.EmailRecipient, recap users.WeeklyRecap) error
+}
+
+// weekPayload is the payload of a JobWeekly job
+type weekPayload struct {
+	WeekStart string `json:"week_start"` // YYYY-MM-DD
+}
+
+// creatorPayload is the payload of a JobCreator job
+type creatorPayload struct {
+	UserID    string `json:"user_id"`
+	WeekStart string `json:"week_start"` // YYYY-MM-DD
+}
+
+// WeekStart returns the start of the week holding t, Monday at midnight UTC
+func WeekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// Schedule queues the JobWeekly job making the recaps of the week holding
+// now, to run once the week is over. The job is queued once per week, so
+// every worker can call Schedule when it starts.
+func Schedule(ctx context.Context, queue Queue, now time.Time) error {
+	week := WeekStart(now)
+	day := week.Format(time.DateOnly)
+	_, _, err := queue.EnqueueOnce(ctx, JobWeekly, day, weekPayload{WeekStart: day}, week.AddDate(0, 0, 7))
+	return err
+}
+
+// WeeklyJob returns the handler of JobWeekly jobs. It queues a JobCreator job
+// for every creator who posted during the week, then schedules the next
+// week's job, so recaps keep coming once Schedule has been called.
+func WeeklyJob(store storage.RecapStore, queue Queue) jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var payload weekPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		week, err := time.Parse(time.DateOnly, payload.WeekStart)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid week: %w", err))
+		}
+
+		creators, err := store.GetActiveCreators(ctx, week)
+		if err != nil {
+			return fmt.Errorf("get active creators: %w", err)
+		}
+
+		// A retry skips the creators queued by the failed attempt
+		for _, userID := range creators {
+			key := userID + "/" + payload.WeekStart
+			_, _, err := queue.EnqueueOnce(ctx, JobCreator, key, creatorPayload{UserID: userID, WeekStart: payload.WeekStart}, time.Time{})
+			if err != nil {
+				return err
+			}
+		}
+		slog.Info("Queued weekly recaps", slog.String("week_start", payload.WeekStart), slog.Int("creators", len(creators)))
+
+		return Schedule(ctx, queue, week.AddDate(0, 0, 7))
+	}
+}
+
+// CreatorJob returns the handler of JobCreator jobs. It makes the creator's
+// recap, then sends it over WebSocket and, if they opted in, by email. A
+// recap is delivered once; a retry after a failed delivery may repeat the
+// WebSocket event.
+func CreatorJob(store Store, publisher Publisher, mailer Mailer) jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var payload creatorPayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		week, err := time.Parse(time.DateOnly, payload.WeekStart)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid week: %w", err))
+		}
+
+		recap, err := store.SaveWeeklyRecap(ctx, payload.UserID, week)
+		if err != nil {
+			return fmt.Errorf("save recap: %w", err)
+		}
+		if recap.Delivered {
+			return nil
+		}
+
+		if err := publisher.PublishWeeklyRecap(payload.UserID, &recap); err != nil {
+			return fmt.Errorf("publish recap: %w", err)
+		}
+
+		settings, err := store.GetNotificationSettings(payload.UserID)
+		if err != nil {
+			return err
+		}
+		if settings.EmailWeeklyRecap {
+			user, err := store.GetUserByID(payload.UserID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return jobs.Permanent(fmt.Errorf("user %s not found", payload.UserID))
+			} else if err != nil {
+				return err
+			}
+			if err := mailer.SendWeeklyRecap(ctx, users.EmailRecipient{UserID: user.ID, Email: user.Email}, recap); err != nil {
+				return fmt.Errorf("email recap: %w", err)
+			}
+		}
+
+		return store.MarkWeeklyRecapDelivered(ctx, payload.UserID, week)
+	}
+}
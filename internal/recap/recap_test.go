@@ -0,0 +1,217 @@
+package recap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// queued is a job fakeQueue was asked to queue
+type queued struct {
+	kind, key string
+	payload   any
+	at        time.Time
+}
+
+// fakeQueue records jobs, queueing each kind and key once
+type fakeQueue struct {
+	jobs []queued
+}
+
+func (q *fakeQueue) EnqueueOnce(ctx context.Context, kind, key string, payload any, at time.Time) (string, bool, error) {
+	for _, job := range q.jobs {
+		if job.kind == kind && job.key == key {
+			return "", false, nil
+		}
+	}
+	q.jobs = append(q.jobs, queued{kind: kind, key: key, payload: payload, at: at})
+	return "1", true, nil
+}
+
+// fakeStore serves one recap per user and records deliveries
+type fakeStore struct {
+	storage.RecapStore
+	creators  []string
+	recaps    map[string]users.WeeklyRecap
+	settings  users.NotificationSettings
+	delivered []string
+}
+
+func (s *fakeStore) GetActiveCreators(ctx context.Context, weekStart time.Time) ([]string, error) {
+	return s.creators, nil
+}
+
+func (s *fakeStore) SaveWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) {
+	recap, ok := s.recaps[userID]
+	if !ok {
+		return users.WeeklyRecap{}, errors.New("no recap")
+	}
+	return recap, nil
+}
+
+func (s *fakeStore) MarkWeeklyRecapDelivered(ctx context.Context, userID string, weekStart time.Time) error {
+	s.delivered = append(s.delivered, userID+"/"+weekStart.Format(time.DateOnly))
+	return nil
+}
+
+func (s *fakeStore) GetUserByID(userID string) (users.User, error) {
+	return users.User{ID: userID, Email: "user" + userID + "@example.com"}, nil
+}
+
+func (s *fakeStore) GetNotificationSettings(userID string) (users.NotificationSettings, error) {
+	return s.settings, nil
+}
+
+// fakeNotifier records recaps published and emailed
+type fakeNotifier struct {
+	published []string
+	emailed   []string
+	failEmail bool
+}
+
+func (n *fakeNotifier) PublishWeeklyRecap(userID string, recap *users.WeeklyRecap) error {
+	n.published = append(n.published, userID)
+	return nil
+}
+
+func (n *fakeNotifier) SendWeeklyRecap(ctx context.Context, to users.EmailRecipient, recap users.WeeklyRecap) error {
+	if n.failEmail {
+		return errors.New("queue unavailable")
+	}
+	n.emailed = append(n.emailed, to.Email)
+	return nil
+}
+
+// job builds a job of kind with payload
+func job(t *testing.T, kind string, payload any) jobs.Job {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+	return jobs.Job{Kind: kind, Payload: data}
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 4, 22, 0, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{
+		monday,
+		time.Date(2024, 4, 24, 15, 30, 0, 0, time.UTC),
+		time.Date(2024, 4, 28, 23, 59, 59, 0, time.UTC),
+		// Still Sunday in UTC
+		time.Date(2024, 4, 29, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	} {
+		if got := WeekStart(at); !got.Equal(monday) {
+			t.Errorf("WeekStart(%v) = %v, want %v", at, got, monday)
+		}
+	}
+	if got := WeekStart(monday.AddDate(0, 0, 7)); !got.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the next Monday to start a new week, got %v", got)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	queue := &fakeQueue{}
+	for i := 0; i < 2; i++ {
+		if err := Schedule(context.Background(), queue, time.Date(2024, 4, 24, 15, 30, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("Schedule failed: %v", err)
+		}
+	}
+
+	if len(queue.jobs) != 1 {
+		t.Fatalf("Expected the week to be scheduled once, got %+v", queue.jobs)
+	}
+	got := queue.jobs[0]
+	if got.kind != JobWeekly || got.key != "2024-04-22" || !got.at.Equal(time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the recaps of the week of 2024-04-22 to run when it ends, got %+v", got)
+	}
+}
+
+func TestWeeklyJob(t *testing.T) {
+	store := &fakeStore{creators: []string{"3", "7"}}
+	queue := &fakeQueue{}
+	handler := WeeklyJob(store, queue)
+
+	if err := handler(context.Background(), job(t, JobWeekly, weekPayload{WeekStart: "2024-04-22"})); err != nil {
+		t.Fatalf("WeeklyJob failed: %v", err)
+	}
+
+	if len(queue.jobs) != 3 {
+		t.Fatalf("Expected a job per creator and the next week, got %+v", queue.jobs)
+	}
+	for i, userID := range store.creators {
+		got := queue.jobs[i]
+		want := creatorPayload{UserID: userID, WeekStart: "2024-04-22"}
+		if got.kind != JobCreator || got.key != userID+"/2024-04-22" || got.payload != want || !got.at.IsZero() {
+			t.Errorf("Unexpected recap job for user %s: %+v", userID, got)
+		}
+	}
+	if next := queue.jobs[2]; next.kind != JobWeekly || next.key != "2024-04-29" {
+		t.Errorf("Expected the next week to be scheduled, got %+v", next)
+	}
+
+	// Running again, as a retry would, queues nothing twice
+	if err := handler(context.Background(), job(t, JobWeekly, weekPayload{WeekStart: "2024-04-22"})); err != nil {
+		t.Fatalf("WeeklyJob failed: %v", err)
+	}
+	if len(queue.jobs) != 3 {
+		t.Errorf("Expected no duplicate jobs, got %+v", queue.jobs)
+	}
+
+	err := handler(context.Background(), job(t, JobWeekly, weekPayload{WeekStart: "last week"}))
+	if !jobs.IsPermanent(err) {
+		t.Errorf("Expected an invalid week to fail permanently, got %v", err)
+	}
+}
+
+func TestCreatorJob(t *testing.T) {
+	store := &fakeStore{recaps: map[string]users.WeeklyRecap{
+		"3": {WeekStart: "2024-04-22", Views: 10},
+		"4": {WeekStart: "2024-04-22", Views: 2, Delivered: true},
+	}}
+	notifier := &fakeNotifier{}
+	handler := CreatorJob(store, notifier, notifier)
+	ctx := context.Background()
+
+	if err := handler(ctx, job(t, JobCreator, creatorPayload{UserID: "3", WeekStart: "2024-04-22"})); err != nil {
+		t.Fatalf("CreatorJob failed: %v", err)
+	}
+	if len(notifier.published) != 1 || notifier.published[0] != "3" || len(notifier.emailed) != 0 {
+		t.Errorf("Expected only a WebSocket recap without the email opt-in, got %+v", notifier)
+	}
+	if len(store.delivered) != 1 || store.delivered[0] != "3/2024-04-22" {
+		t.Errorf("Expected the recap marked delivered, got %v", store.delivered)
+	}
+
+	// Delivered recaps are not sent again
+	if err := handler(ctx, job(t, JobCreator, creatorPayload{UserID: "4", WeekStart: "2024-04-22"})); err != nil {
+		t.Fatalf("CreatorJob failed: %v", err)
+	}
+	if len(notifier.published) != 1 {
+		t.Errorf("Expected a delivered recap to be skipped, got %v", notifier.published)
+	}
+
+	store.settings.EmailWeeklyRecap = true
+	if err := handler(ctx, job(t, JobCreator, creatorPayload{UserID: "3", WeekStart: "2024-04-22"})); err != nil {
+		t.Fatalf("CreatorJob failed: %v", err)
+	}
+	if len(notifier.emailed) != 1 || notifier.emailed[0] != "user3@example.com" {
+		t.Errorf("Expected the recap emailed to an opted-in user, got %v", notifier.emailed)
+	}
+
+	// A failed email leaves the recap undelivered, so the job is retried
+	notifier.failEmail = true
+	store.delivered = nil
+	if err := handler(ctx, job(t, JobCreator, creatorPayload{UserID: "3", WeekStart: "2024-04-22"})); err == nil || jobs.IsPermanent(err) {
+		t.Errorf("Expected a retryable error, got %v", err)
+	}
+	if len(store.delivered) != 0 {
+		t.Errorf("Expected the recap left undelivered, got %v", store.delivered)
+	}
+}
@@ -0,0 +1,107 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+// testTokens are the token options the test store is created with
+var testTokens = jwt.Options{TTL: 24 * time.Hour, Leeway: 30 * time.Second}
+
+func setupTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	redisClient, mr := redistest.New(t)
+
+	return NewStore(redisClient, cache.NewKeys(""), testTokens), mr
+}
+
+func TestStore_RevokeToken(t *testing.T) {
+	store, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	claims := jwt.Claims{UserID: "42", TokenID: "token-1", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	other := jwt.Claims{UserID: "42", TokenID: "token-2", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	if err := store.RevokeToken(ctx, claims.TokenID, claims.ExpiresAt); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	revoked, err := store.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("Failed to check revocation: %v", err)
+	}
+	if !revoked {
+		t.Error("Expected revoked token to be denied")
+	}
+
+	revoked, err = store.IsRevoked(ctx, other)
+	if err != nil {
+		t.Fatalf("Failed to check revocation: %v", err)
+	}
+	if revoked {
+		t.Error("Expected other tokens of the user to stay valid")
+	}
+
+	// The denylist entry goes away once the token would be rejected anyway,
+	// which is the leeway after it expires
+	mr.FastForward(time.Hour)
+	if !mr.Exists(store.tokenKey(claims.TokenID)) {
+		t.Error("Expected denylist entry to last through the leeway")
+	}
+	mr.FastForward(testTokens.Leeway + time.Second)
+	if mr.Exists(store.tokenKey(claims.TokenID)) {
+		t.Error("Expected denylist entry to expire with the token")
+	}
+}
+
+func TestStore_RevokeUser(t *testing.T) {
+	store, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	before := jwt.Claims{UserID: "42", TokenID: "token-1", IssuedAt: time.Now().Add(-time.Minute)}
+	legacy := jwt.Claims{UserID: "42"}
+	otherUser := jwt.Claims{UserID: "7", TokenID: "token-2", IssuedAt: time.Now().Add(-time.Minute)}
+
+	if err := store.RevokeUser(ctx, "42"); err != nil {
+		t.Fatalf("Failed to revoke user: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		claims jwt.Claims
+		want   bool
+	}{
+		"issued before revocation": {before, true},
+		"issued without iat":       {legacy, true},
+		"other user":               {otherUser, false},
+		"issued after revocation":  {jwt.Claims{UserID: "42", TokenID: "token-3", IssuedAt: time.Now().Add(time.Minute)}, false},
+	} {
+		revoked, err := store.IsRevoked(ctx, tc.claims)
+		if err != nil {
+			t.Fatalf("%s: failed to check revocation: %v", name, err)
+		}
+		if revoked != tc.want {
+			t.Errorf("%s: expected revoked=%v, got %v", name, tc.want, revoked)
+		}
+	}
+
+	if ttl := mr.TTL(store.userKey("42")); ttl != testTokens.MaxAge() {
+		t.Errorf("Expected user revocation to last %v, got %v", testTokens.MaxAge(), ttl)
+	}
+}
+
+func TestStore_RevokeExpiredToken(t *testing.T) {
+	store, mr := setupTestStore(t)
+
+	err := store.RevokeToken(context.Background(), "token-1", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to revoke expired token: %v", err)
+	}
+	if mr.Exists(store.tokenKey("token-1")) {
+		t.Error("Expected no denylist entry for an already expired token")
+	}
+}
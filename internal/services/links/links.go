@@ -0,0 +1,78 @@
+package links
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+// maxURLLength caps the size of links attached to stories
+const maxURLLength = 2048
+
+type Validator struct {
+	allowed []string
+	blocked []string
+}
+
+// NewValidator creates a link validator from the configured domain lists
+func NewValidator(cfg *config.Config) *Validator {
+	return &Validator{
+		allowed: normalizeDomains(cfg.Links.AllowedDomains),
+		blocked: normalizeDomains(cfg.Links.BlockedDomains),
+	}
+}
+
+// Validate checks that a story link is an absolute http(s) URL on a permitted domain
+func (v *Validator) Validate(rawURL string) error {
+	if len(rawURL) > maxURLLength {
+		return fmt.Errorf("link must be at most %d characters", maxURLLength)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("link is not a valid URL")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("link must use http or https")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return errors.New("link must include a host")
+	}
+
+	if matchesAny(host, v.blocked) {
+		return fmt.Errorf("links to %s are not allowed", host)
+	}
+
+	if len(v.allowed) > 0 && !matchesAny(host, v.allowed) {
+		return fmt.Errorf("links to %s are not allowed", host)
+	}
+
+	return nil
+}
+
+// matchesAny reports whether host is one of the domains or a subdomain of one
+func matchesAny(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,55 @@
+package links
+
+import (
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+func newTestValidator(allowed, blocked []string) *Validator {
+	cfg := &config.Config{
+		Links: config.Links{
+			AllowedDomains: allowed,
+			BlockedDomains: blocked,
+		},
+	}
+	return NewValidator(cfg)
+}
+
+func TestValidator_Validate(t *testing.T) {
+	validator := newTestValidator(nil, []string{"bit.ly", "Evil.example."})
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/page", false},
+		{"http://shop.example.org/item?id=1", false},
+		{"ftp://example.com/file", true},
+		{"javascript:alert(1)", true},
+		{"/relative/path", true},
+		{"https://bit.ly/abc", true},
+		{"https://evil.example/landing", true},
+		{"https://cdn.evil.example/landing", true},
+		{"https://notevil.example/landing", false},
+	}
+
+	for _, tt := range tests {
+		err := validator.Validate(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidator_Allowlist(t *testing.T) {
+	validator := newTestValidator([]string{"example.com"}, nil)
+
+	if err := validator.Validate("https://www.example.com/"); err != nil {
+		t.Fatalf("Expected subdomain of allowed domain to pass, got %v", err)
+	}
+
+	if err := validator.Validate("https://example.org/"); err == nil {
+		t.Fatal("Expected domain outside the allowlist to be rejected")
+	}
+}
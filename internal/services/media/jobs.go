@@ -0,0 +1,37 @@
+package media
+
+import (
+	"context"
+
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+)
+
+// JobDelete is the kind of job that deletes an object from a tenant's bucket
+const JobDelete = "media.delete"
+
+// DeletePayload is the payload of a JobDelete job
+type DeletePayload struct {
+	TenantID  string `json:"tenant_id"`
+	ObjectKey string `json:"object_key"`
+}
+
+// DeleteJob returns a job handler deleting objects from the tenant buckets
+// of s. Deleting an object that is already gone succeeds, so a retried job
+// does not fail.
+func (s *Service) DeleteJob() jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var payload DeletePayload
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+
+		service, err := s.ForTenant(payload.TenantID)
+		if err != nil {
+			return err
+		}
+		if err := service.DeleteObject(payload.ObjectKey); err != nil && !IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+}
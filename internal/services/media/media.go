@@ -1,24 +1,44 @@
 package media
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"mime"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
 )
 
+// Media listing page sizes
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 1000
+)
+
+// ErrInvalidContinuationToken is returned for a continuation token that was
+// not issued for the user's listing
+var ErrInvalidContinuationToken = errors.New("invalid continuation token")
+
 type Service struct {
 	client     *minio.Client
 	bucketName string
 	config     *config.Media
 	useSSL     bool
+
+	// Buckets known to exist, shared by every tenant-scoped copy
+	ensured *sync.Map
 }
 
 type UploadInfo struct {
@@ -29,6 +49,17 @@ type UploadInfo struct {
 	ContentType string `json:"content_type"`
 }
 
+// MediaPage is one page of a user's media files, in object key order
+type MediaPage struct {
+	Uploads               []media.MediaUpload
+	NextContinuationToken string // empty on the last page
+}
+
+// UploadLister lists the media uploads recorded for a user
+type UploadLister interface {
+	GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error)
+}
+
 // NewService creates a new media service instance
 func NewService(cfg *config.Config) (*Service, error) {
 	// Initialize MinIO client
@@ -45,6 +76,7 @@ func NewService(cfg *config.Config) (*Service, error) {
 		bucketName: cfg.MinIO.BucketName,
 		config:     &cfg.Media,
 		useSSL:     cfg.MinIO.UseSSL,
+		ensured:    &sync.Map{},
 	}
 
 	// Ensure bucket exists
@@ -55,8 +87,37 @@ func NewService(cfg *config.Config) (*Service, error) {
 	return service, nil
 }
 
+// ForTenant returns a copy of the service storing media in tenantID's bucket,
+// creating the bucket on first use
+func (s *Service) ForTenant(tenantID string) (*Service, error) {
+	scoped := *s
+	scoped.bucketName = tenant.BucketName(s.bucketName, tenantID)
+	if err := scoped.ensureBucket(); err != nil {
+		return nil, fmt.Errorf("failed to ensure tenant bucket exists: %w", err)
+	}
+	return &scoped, nil
+}
+
+// WithBucket returns a copy of the service storing objects in bucketName,
+// such as the story archive, instead of the media bucket. Its ForTenant
+// suffixes bucketName per tenant the same way.
+func (s *Service) WithBucket(bucketName string) *Service {
+	scoped := *s
+	scoped.bucketName = bucketName
+	return &scoped
+}
+
+// BucketName returns the name of the bucket the service stores objects in
+func (s *Service) BucketName() string {
+	return s.bucketName
+}
+
 // ensureBucket creates the bucket if it doesn't exist
 func (s *Service) ensureBucket() error {
+	if _, ok := s.ensured.Load(s.bucketName); ok {
+		return nil
+	}
+
 	ctx := context.Background()
 
 	exists, err := s.client.BucketExists(ctx, s.bucketName)
@@ -71,6 +132,7 @@ func (s *Service) ensureBucket() error {
 		}
 	}
 
+	s.ensured.Store(s.bucketName, struct{}{})
 	return nil
 }
 
@@ -113,7 +175,7 @@ func (s *Service) GenerateObjectKey(userID string, contentType string) string {
 	filename := uuid.New().String() + ext
 
 	// Create object key with user-based folder structure
-	return fmt.Sprintf("users/%s/media/%s", userID, filename)
+	return userMediaPrefix(userID) + filename
 }
 
 // GeneratePresignedUploadURL creates a presigned URL for uploading
@@ -127,7 +189,7 @@ func (s *Service) GeneratePresignedUploadURL(userID string, contentType string)
 	objectKey := s.GenerateObjectKey(userID, contentType)
 
 	// Create presigned URL for upload
-	expiry := time.Duration(s.config.PresignedURLTTL) * time.Second
+	expiry := s.UploadURLTTL()
 
 	presignedURL, err := s.client.PresignedPutObject(
 		context.Background(),
@@ -148,6 +210,16 @@ func (s *Service) GeneratePresignedUploadURL(userID string, contentType string)
 	}, nil
 }
 
+// UploadURLTTL is how long upload URLs stay valid
+func (s *Service) UploadURLTTL() time.Duration {
+	return time.Duration(s.config.PresignedURLTTL) * time.Second
+}
+
+// MaxFileSize is the largest file, in bytes, an upload may be
+func (s *Service) MaxFileSize() int64 {
+	return s.config.MaxFileSize
+}
+
 // GeneratePresignedDownloadURL creates a presigned URL for downloading
 func (s *Service) GeneratePresignedDownloadURL(objectKey string, expiry time.Duration) (*url.URL, error) {
 	return s.client.PresignedGetObject(
@@ -182,6 +254,28 @@ func (s *Service) DeleteObject(objectKey string) error {
 	)
 }
 
+// PutObject stores data under objectKey
+func (s *Service) PutObject(ctx context.Context, objectKey string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucketName, objectKey, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// GetObject reads the whole object stored under objectKey
+func (s *Service) GetObject(ctx context.Context, objectKey string) ([]byte, error) {
+	object, err := s.client.GetObject(ctx, s.bucketName, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
+
+// IsNotFound reports whether err, from GetObjectInfo, means there is no such object
+func IsNotFound(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
+
 // GetObjectInfo returns information about an object
 func (s *Service) GetObjectInfo(objectKey string) (minio.ObjectInfo, error) {
 	return s.client.StatObject(
@@ -192,26 +286,58 @@ func (s *Service) GetObjectInfo(objectKey string) (minio.ObjectInfo, error) {
 	)
 }
 
-// ListUserMedia lists all media files for a specific user
-func (s *Service) ListUserMedia(userID string) ([]minio.ObjectInfo, error) {
-	prefix := fmt.Sprintf("users/%s/media/", userID)
+// userMediaPrefix is the key prefix every object a user uploads shares
+func userMediaPrefix(userID string) string {
+	return fmt.Sprintf("users/%s/media/", userID)
+}
 
-	var objects []minio.ObjectInfo
-	objectsCh := s.client.ListObjects(
-		context.Background(),
-		s.bucketName,
-		minio.ListObjectsOptions{
-			Prefix:    prefix,
-			Recursive: true,
-		},
-	)
+// ListUserMedia lists a page of at most limit of the user's media files in the
+// tenant, starting after the file named by continuationToken (from the start
+// when empty). Files are listed from the upload records in store rather than
+// the bucket, so listing costs no MinIO request; uploads whose file has not
+// been seen yet, or that failed, are left out.
+func ListUserMedia(ctx context.Context, store UploadLister, tenantID, userID, continuationToken string, limit int) (MediaPage, error) {
+	if limit <= 0 || limit > MaxListLimit {
+		limit = DefaultListLimit
+	}
 
-	for object := range objectsCh {
-		if object.Err != nil {
-			return nil, object.Err
+	var startAfter string
+	if continuationToken != "" {
+		key, err := base64.RawURLEncoding.DecodeString(continuationToken)
+		if err != nil || !strings.HasPrefix(string(key), userMediaPrefix(userID)) {
+			return MediaPage{}, ErrInvalidContinuationToken
 		}
-		objects = append(objects, object)
+		startAfter = string(key)
 	}
 
-	return objects, nil
+	// One more than the page tells whether another page follows
+	uploads, err := store.GetUserMediaUploads(ctx, tenantID, userID, startAfter, limit+1)
+	if err != nil {
+		return MediaPage{}, err
+	}
+
+	page := MediaPage{Uploads: uploads}
+	if len(uploads) > limit {
+		page.Uploads = uploads[:limit]
+		page.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(uploads[limit-1].ObjectKey))
+	}
+	return page, nil
+}
+
+// EachObject calls fn with every user media object in the bucket, in object
+// key order, stopping at the first error
+func (s *Service) EachObject(ctx context.Context, fn func(minio.ObjectInfo) error) error {
+	// Cancelling stops the listing if fn fails part way through
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Prefix: "users/", Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		if err := fn(object); err != nil {
+			return err
+		}
+	}
+	return nil
 }
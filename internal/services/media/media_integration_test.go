@@ -0,0 +1,73 @@
+//go:build integration
+
+package media_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+)
+
+func TestMediaServiceIntegration(t *testing.T) {
+	service := testutil.StartMinIO(t, testutil.NewConfig())
+
+	if _, err := service.GeneratePresignedUploadURL("1", "application/zip"); err == nil {
+		t.Error("Expected disallowed content type to be rejected")
+	}
+
+	upload, err := service.GeneratePresignedUploadURL("1", "image/png")
+	if err != nil {
+		t.Fatalf("GeneratePresignedUploadURL failed: %v", err)
+	}
+	if !strings.HasPrefix(upload.ObjectKey, "users/1/media/") {
+		t.Errorf("Unexpected object key: %s", upload.ObjectKey)
+	}
+
+	// Upload through the presigned URL the way a client would
+	content := []byte("not really a png")
+	req, err := http.NewRequest(http.MethodPut, upload.UploadURL, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to build upload request: %v", err)
+	}
+	req.Header.Set("Content-Type", upload.ContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected upload status 200, got %d", resp.StatusCode)
+	}
+
+	info, err := service.GetObjectInfo(upload.ObjectKey)
+	if err != nil {
+		t.Fatalf("GetObjectInfo failed: %v", err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("Expected object size %d, got %d", len(content), info.Size)
+	}
+
+	downloadURL, err := service.GeneratePresignedDownloadURL(upload.ObjectKey, time.Minute)
+	if err != nil {
+		t.Fatalf("GeneratePresignedDownloadURL failed: %v", err)
+	}
+	resp, err = http.Get(downloadURL.String())
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected download status 200, got %d", resp.StatusCode)
+	}
+
+	if err := service.DeleteObject(upload.ObjectKey); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if _, err := service.GetObjectInfo(upload.ObjectKey); err == nil {
+		t.Error("Expected deleted object to be gone")
+	}
+}
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+)
+
+// fakeLister serves upload records kept in object key order
+type fakeLister []media.MediaUpload
+
+func (l fakeLister) GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) {
+	var uploads []media.MediaUpload
+	for _, upload := range l {
+		if upload.TenantID == tenantID && upload.UserID == userID && upload.ObjectKey > after && len(uploads) < limit {
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads, nil
+}
+
+func TestListUserMedia(t *testing.T) {
+	var store fakeLister
+	var keys []string
+	for _, name := range []string{"a.png", "b.png", "c.png", "d.png", "e.png"} {
+		key := userMediaPrefix("2") + name
+		store = append(store, media.MediaUpload{TenantID: "acme", UserID: "2", ObjectKey: key})
+		keys = append(keys, key)
+	}
+	store = append(store, media.MediaUpload{TenantID: "other", UserID: "2", ObjectKey: userMediaPrefix("2") + "f.png"})
+
+	var listed []string
+	token := ""
+	for pages := 1; ; pages++ {
+		page, err := ListUserMedia(context.Background(), store, "acme", "2", token, 2)
+		if err != nil {
+			t.Fatalf("ListUserMedia failed: %v", err)
+		}
+		if len(page.Uploads) > 2 {
+			t.Fatalf("Expected at most 2 uploads per page, got %d", len(page.Uploads))
+		}
+		for _, upload := range page.Uploads {
+			listed = append(listed, upload.ObjectKey)
+		}
+		if page.NextContinuationToken == "" {
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	if !slices.Equal(listed, keys) {
+		t.Errorf("Expected every upload in the tenant once in key order %v, got %v", keys, listed)
+	}
+
+	// Tokens only page through the listing they came from
+	if _, err := ListUserMedia(context.Background(), store, "acme", "1", token, 2); err != ErrInvalidContinuationToken {
+		t.Errorf("Expected ErrInvalidContinuationToken for another user's token, got %v", err)
+	}
+	if _, err := ListUserMedia(context.Background(), store, "acme", "2", "not a token!", 2); err != ErrInvalidContinuationToken {
+		t.Errorf("Expected ErrInvalidContinuationToken for a malformed token, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+)
+
+// DownloadURL is a presigned link to an object and when it stops working
+type DownloadURL struct {
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"` // unix seconds
+}
+
+// URLResolver presigns download URLs for many objects at once, such as every
+// story in a feed, caching them in Redis so a URL is signed once and shared by
+// every response it goes out in
+type URLResolver struct {
+	media *Service
+	redis *redis.Client
+	keys  cache.Keys
+	ttl   time.Duration
+}
+
+// NewURLResolver creates a resolver presigning URLs valid for ttl and caching
+// them under keys
+func NewURLResolver(media *Service, redisClient *redis.Client, keys cache.Keys, ttl time.Duration) *URLResolver {
+	return &URLResolver{media: media, redis: redisClient, keys: keys, ttl: ttl}
+}
+
+// Resolve returns download URLs for objects in tenantID's bucket, by object
+// key. URLs are cached for half their lifetime, so one read from the cache
+// always has at least half of it left. Objects that cannot be presigned are
+// left out.
+func (r *URLResolver) Resolve(ctx context.Context, tenantID string, objectKeys []string) (map[string]DownloadURL, error) {
+	urls := make(map[string]DownloadURL, len(objectKeys))
+	keys := uniqueKeys(objectKeys)
+	if len(keys) == 0 {
+		return urls, nil
+	}
+
+	service, err := r.media.ForTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKeys := make([]string, len(keys))
+	for i, key := range keys {
+		cacheKeys[i] = r.urlCacheKey(service.BucketName(), key)
+	}
+	cached, err := r.redis.MGet(ctx, cacheKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached media URLs: %w", err)
+	}
+
+	now := time.Now()
+	pipe := r.redis.Pipeline()
+	for i, key := range keys {
+		if data, ok := cached[i].(string); ok {
+			var url DownloadURL
+			if err := json.Unmarshal([]byte(data), &url); err == nil {
+				urls[key] = url
+				continue
+			}
+		}
+
+		presigned, err := service.GeneratePresignedDownloadURL(key, r.ttl)
+		if err != nil {
+			slog.Warn("Failed to presign media URL", slog.String("error", err.Error()), slog.String("media_key", key))
+			continue
+		}
+		url := DownloadURL{URL: presigned.String(), ExpiresAt: now.Add(r.ttl).Unix()}
+		urls[key] = url
+
+		data, _ := json.Marshal(url)
+		pipe.Set(ctx, cacheKeys[i], data, r.ttl/2)
+	}
+
+	// URLs that could not be cached are signed again next time
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("Failed to cache media URLs", slog.String("error", err.Error()))
+	}
+
+	return urls, nil
+}
+
+// uniqueKeys returns the non-empty keys, each once, in the order given
+func uniqueKeys(objectKeys []string) []string {
+	seen := make(map[string]bool, len(objectKeys))
+	keys := make([]string, 0, len(objectKeys))
+	for _, key := range objectKeys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// urlCacheKey names the cached URL of an object; tenants' buckets differ, so
+// their URLs never mix
+func (r *URLResolver) urlCacheKey(bucketName, objectKey string) string {
+	return r.keys.Key(cache.MediaURLKey, bucketName, objectKey)
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+)
+
+// newTestService creates a service whose buckets are taken to exist. With the
+// region set, presigning needs no connection to MinIO.
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	client, err := minio.New("localhost:9000", &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create MinIO client: %v", err)
+	}
+
+	ensured := &sync.Map{}
+	ensured.Store("stories", struct{}{})
+	ensured.Store("stories-acme", struct{}{})
+	return &Service{client: client, bucketName: "stories", ensured: ensured}
+}
+
+func TestURLResolver_Resolve(t *testing.T) {
+	redisClient, mr := redistest.New(t)
+
+	resolver := NewURLResolver(newTestService(t), redisClient, cache.NewKeys(""), 10*time.Minute)
+	ctx := context.Background()
+	photo := "users/42/media/photo.jpg"
+	video := "users/42/media/video.mp4"
+
+	urls, err := resolver.Resolve(ctx, "", []string{photo, "", video, photo})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(urls) != 2 {
+		t.Fatalf("Expected 2 URLs, got %d", len(urls))
+	}
+	first := urls[photo]
+	if !strings.Contains(first.URL, "/stories/"+photo) || !strings.Contains(first.URL, "X-Amz-Expires=600") {
+		t.Errorf("Expected a 10 minute URL for the photo, got %s", first.URL)
+	}
+	if until := time.Until(time.Unix(first.ExpiresAt, 0)); until < 9*time.Minute || until > 10*time.Minute {
+		t.Errorf("Expected the URL to expire in 10 minutes, got %v", until)
+	}
+	if ttl := mr.TTL(resolver.urlCacheKey("stories", photo)); ttl != 5*time.Minute {
+		t.Errorf("Expected the URL cached for 5 minutes, got %v", ttl)
+	}
+
+	// A cached URL is served as signed, not signed again
+	time.Sleep(time.Second)
+	urls, err = resolver.Resolve(ctx, "", []string{photo})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if urls[photo] != first {
+		t.Errorf("Expected the cached URL %+v, got %+v", first, urls[photo])
+	}
+
+	// Tenants' buckets are cached apart
+	urls, err = resolver.Resolve(ctx, "acme", []string{photo})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if !strings.Contains(urls[photo].URL, "/stories-acme/"+photo) {
+		t.Errorf("Expected a URL in the acme bucket, got %s", urls[photo].URL)
+	}
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/princekumarofficial/stories-service/internal/cache"
+)
+
+// Platforms clients report at login. Anything else is recorded as
+// PlatformOther, and a login that reports nothing as PlatformUnknown.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+	PlatformDesktop = "desktop"
+	PlatformOther   = "other"
+	PlatformUnknown = "unknown"
+)
+
+// Headers a client may report itself with instead of the login body
+const (
+	HeaderPlatform      = "X-Client-Platform"
+	HeaderClientVersion = "X-Client-Version"
+	HeaderDeviceID      = "X-Device-ID"
+)
+
+// Caps on what is stored for a client
+const (
+	maxClientVersion = 32
+	maxDeviceID      = 128
+)
+
+// clientSeparator joins the fields of a client index entry; normalize strips
+// it from the values
+const clientSeparator = "\x1f"
+
+// Client is the app and device a session logged in from
+type Client struct {
+	DeviceName    string
+	Platform      string
+	ClientVersion string // empty when the client did not report one
+	DeviceID      string // empty when the client did not report one
+}
+
+// ClientFromHeaders reads the client a request reports in its headers,
+// naming the device after the User-Agent
+func ClientFromHeaders(r *http.Request) Client {
+	return Client{
+		DeviceName:    r.UserAgent(),
+		Platform:      r.Header.Get(HeaderPlatform),
+		ClientVersion: r.Header.Get(HeaderClientVersion),
+		DeviceID:      r.Header.Get(HeaderDeviceID),
+	}
+}
+
+// normalize caps the client's fields and maps its platform onto the known ones
+func (c Client) normalize() Client {
+	switch platform := strings.ToLower(strings.TrimSpace(c.Platform)); platform {
+	case PlatformIOS, PlatformAndroid, PlatformWeb, PlatformDesktop:
+		c.Platform = platform
+	case "":
+		c.Platform = PlatformUnknown
+	default:
+		c.Platform = PlatformOther
+	}
+
+	c.DeviceName = truncate(c.DeviceName, maxDeviceName)
+	c.ClientVersion = truncate(clean(c.ClientVersion), maxClientVersion)
+	c.DeviceID = truncate(clean(c.DeviceID), maxDeviceID)
+	return c
+}
+
+// clean drops whitespace and control characters, which also keeps the index
+// separator out of the values
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// truncate cuts s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// clientEntry is a session's member in its tenant's client index, carrying
+// what the breakdown counts so it is built without reading every session
+func clientEntry(sessionID string, client Client) string {
+	return strings.Join([]string{sessionID, client.Platform, client.ClientVersion, client.DeviceID}, clientSeparator)
+}
+
+// VersionUsage counts the active sessions of one client version
+type VersionUsage struct {
+	Version  string `json:"version"` // empty for sessions that did not report one
+	Sessions int    `json:"sessions"`
+	Devices  int    `json:"devices"` // distinct device IDs; sessions without one are not counted
+}
+
+// PlatformUsage counts the active sessions of one platform, by version
+type PlatformUsage struct {
+	Platform string         `json:"platform" enums:"ios,android,web,desktop,other,unknown"`
+	Sessions int            `json:"sessions"`
+	Devices  int            `json:"devices"`  // distinct device IDs; sessions without one are not counted
+	Versions []VersionUsage `json:"versions"` // most sessions first
+}
+
+// ClientBreakdown counts a tenant's active sessions by the platform and
+// client version they logged in with
+type ClientBreakdown struct {
+	Sessions  int             `json:"sessions"`
+	Platforms []PlatformUsage `json:"platforms"` // most sessions first
+}
+
+// ClientBreakdown returns how the tenant's active sessions split across
+// platforms and client versions. Sessions created before clients were
+// recorded are left out.
+func (s *Store) ClientBreakdown(ctx context.Context, tenantID string) (ClientBreakdown, error) {
+	breakdown := ClientBreakdown{Platforms: []PlatformUsage{}}
+
+	key := s.clientsKey(tenantID)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := s.redis.ZRemRangeByScore(ctx, key, "-inf", now).Err(); err != nil {
+		return breakdown, fmt.Errorf("failed to prune client index: %w", err)
+	}
+	entries, err := s.redis.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return breakdown, fmt.Errorf("failed to read client index: %w", err)
+	}
+
+	type usage struct {
+		sessions int
+		devices  map[string]bool
+	}
+	count := func(u *usage, deviceID string) {
+		u.sessions++
+		if deviceID != "" {
+			u.devices[deviceID] = true
+		}
+	}
+	platforms := make(map[string]*usage)
+	versions := make(map[string]map[string]*usage)
+	for _, entry := range entries {
+		fields := strings.Split(entry, clientSeparator)
+		if len(fields) != 4 {
+			continue
+		}
+		platform, version, deviceID := fields[1], fields[2], fields[3]
+
+		if platforms[platform] == nil {
+			platforms[platform] = &usage{devices: make(map[string]bool)}
+			versions[platform] = make(map[string]*usage)
+		}
+		if versions[platform][version] == nil {
+			versions[platform][version] = &usage{devices: make(map[string]bool)}
+		}
+		count(platforms[platform], deviceID)
+		count(versions[platform][version], deviceID)
+		breakdown.Sessions++
+	}
+
+	for platform, p := range platforms {
+		pu := PlatformUsage{Platform: platform, Sessions: p.sessions, Devices: len(p.devices)}
+		for version, v := range versions[platform] {
+			pu.Versions = append(pu.Versions, VersionUsage{Version: version, Sessions: v.sessions, Devices: len(v.devices)})
+		}
+		sort.Slice(pu.Versions, func(i, j int) bool {
+			if pu.Versions[i].Sessions != pu.Versions[j].Sessions {
+				return pu.Versions[i].Sessions > pu.Versions[j].Sessions
+			}
+			return pu.Versions[i].Version < pu.Versions[j].Version
+		})
+		breakdown.Platforms = append(breakdown.Platforms, pu)
+	}
+	sort.Slice(breakdown.Platforms, func(i, j int) bool {
+		if breakdown.Platforms[i].Sessions != breakdown.Platforms[j].Sessions {
+			return breakdown.Platforms[i].Sessions > breakdown.Platforms[j].Sessions
+		}
+		return breakdown.Platforms[i].Platform < breakdown.Platforms[j].Platform
+	})
+
+	return breakdown, nil
+}
+
+// clientsKey is the tenant's index of the clients of its active sessions
+func (s *Store) clientsKey(tenantID string) string {
+	return s.keys.ForTenant(tenantID).Key(cache.SessionClientsKey)
+}
@@ -0,0 +1,232 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+// ErrNotFound is returned when a session does not exist or belongs to another user
+var ErrNotFound = errors.New("session not found")
+
+// maxDeviceName caps the device name stored for a session
+const maxDeviceName = 100
+
+// Session is a device a user logged in from. Its ID is the jti of the token
+// issued at login.
+type Session struct {
+	ID            string    `json:"id"`
+	DeviceName    string    `json:"device_name"`
+	Platform      string    `json:"platform" enums:"ios,android,web,desktop,other,unknown"`
+	ClientVersion string    `json:"client_version"` // empty when the client did not report one
+	DeviceID      string    `json:"device_id"`      // empty when the client did not report one
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Current       bool      `json:"current"` // whether the request was made with this session's token
+}
+
+// Store is a Redis-backed record of the sessions of each user. A session
+// lives as long as its token is accepted; revoking one adds its token to the
+// revocation denylist.
+type Store struct {
+	redis       *redis.Client
+	keys        cache.Keys
+	revocations *revocation.Store
+	tokens      jwt.Options
+}
+
+// NewStore creates a new session store under keys for tokens issued with the
+// given options
+func NewStore(redisClient *redis.Client, keys cache.Keys, revocations *revocation.Store, tokens jwt.Options) *Store {
+	return &Store{
+		redis:       redisClient,
+		keys:        keys,
+		revocations: revocations,
+		tokens:      tokens,
+	}
+}
+
+// touchScript updates a session's last seen time and IP, but only while the
+// session exists so an expired one is not recreated without a TTL
+var touchScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1], "ip", ARGV[2])
+end
+return 0
+`)
+
+// Create records the session of a token issued at login, and indexes it by
+// client for its tenant's ClientBreakdown
+func (s *Store) Create(ctx context.Context, claims jwt.Claims, client Client, ip string) error {
+	client = client.normalize()
+	entry := clientEntry(claims.TokenID, client)
+
+	// Keep the record for as long as the token is accepted
+	until := claims.ExpiresAt.Add(s.tokens.Leeway)
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, s.sessionKey(claims.TokenID),
+		"user_id", claims.UserID,
+		"tenant_id", claims.TenantID,
+		"device_name", client.DeviceName,
+		"platform", client.Platform,
+		"client_version", client.ClientVersion,
+		"device_id", client.DeviceID,
+		"client_entry", entry,
+		"ip", ip,
+		"created_at", claims.IssuedAt.Unix(),
+		"last_seen_at", claims.IssuedAt.Unix(),
+		"expires_at", claims.ExpiresAt.Unix(),
+	)
+	pipe.Expire(ctx, s.sessionKey(claims.TokenID), ttl)
+	pipe.ZAdd(ctx, s.userKey(claims.UserID), &redis.Z{Score: float64(until.Unix()), Member: claims.TokenID})
+	pipe.ZAdd(ctx, s.clientsKey(claims.TenantID), &redis.Z{Score: float64(until.Unix()), Member: entry})
+	// Sessions are created in order of expiry, so the newest one decides when
+	// the indexes can go
+	pipe.Expire(ctx, s.userKey(claims.UserID), ttl)
+	pipe.Expire(ctx, s.clientsKey(claims.TenantID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// Touch marks the session of the token as seen now from ip. Tokens issued
+// before sessions were tracked have no session and are ignored.
+func (s *Store) Touch(ctx context.Context, claims jwt.Claims, ip string) error {
+	if claims.TokenID == "" {
+		return nil
+	}
+
+	err := touchScript.Run(ctx, s.redis, []string{s.sessionKey(claims.TokenID)}, time.Now().Unix(), ip).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's active sessions, most recently seen first.
+// currentID marks the session the request was made with.
+func (s *Store) List(ctx context.Context, userID, currentID string) ([]Session, error) {
+	// Drop sessions whose tokens are no longer accepted
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := s.redis.ZRemRangeByScore(ctx, s.userKey(userID), "-inf", now).Err(); err != nil {
+		return nil, fmt.Errorf("failed to prune sessions: %w", err)
+	}
+
+	ids, err := s.redis.ZRange(ctx, s.userKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	pipe := s.redis.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, s.sessionKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get sessions: %w", err)
+		}
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	for i, id := range ids {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			// Revoked or expired since the index was read
+			continue
+		}
+		sessions = append(sessions, Session{
+			ID:            id,
+			DeviceName:    fields["device_name"],
+			Platform:      platformField(fields),
+			ClientVersion: fields["client_version"],
+			DeviceID:      fields["device_id"],
+			IP:            fields["ip"],
+			CreatedAt:     unixField(fields, "created_at"),
+			LastSeenAt:    unixField(fields, "last_seen_at"),
+			ExpiresAt:     unixField(fields, "expires_at"),
+			Current:       id == currentID,
+		})
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// Revoke ends one of the user's sessions and denies its token
+func (s *Store) Revoke(ctx context.Context, userID, sessionID string) error {
+	fields, err := s.redis.HGetAll(ctx, s.sessionKey(sessionID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if len(fields) == 0 || fields["user_id"] != userID {
+		return ErrNotFound
+	}
+
+	if err := s.revocations.RevokeToken(ctx, sessionID, unixField(fields, "expires_at")); err != nil {
+		return err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, s.sessionKey(sessionID))
+	pipe.ZRem(ctx, s.userKey(userID), sessionID)
+	if entry := fields["client_entry"]; entry != "" {
+		pipe.ZRem(ctx, s.clientsKey(fields["tenant_id"]), entry)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// ClientIP returns the IP address a request came from
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// platformField returns a session's platform, which sessions created before
+// clients were recorded lack
+func platformField(fields map[string]string) string {
+	if platform := fields["platform"]; platform != "" {
+		return platform
+	}
+	return PlatformUnknown
+}
+
+func unixField(fields map[string]string, name string) time.Time {
+	seconds, _ := strconv.ParseInt(fields[name], 10, 64)
+	return time.Unix(seconds, 0).UTC()
+}
+
+func (s *Store) sessionKey(sessionID string) string {
+	return s.keys.Key(cache.SessionKey, sessionID)
+}
+
+func (s *Store) userKey(userID string) string {
+	return s.keys.Key(cache.UserSessionsKey, userID)
+}
@@ -0,0 +1,231 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/revocation"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+)
+
+var testTokens = jwt.Options{TTL: time.Hour, Leeway: 30 * time.Second}
+
+func setupTestStore(t *testing.T) (*Store, *revocation.Store, *miniredis.Miniredis) {
+	redisClient, mr := redistest.New(t)
+
+	revocations := revocation.NewStore(redisClient, cache.NewKeys(""), testTokens)
+	return NewStore(redisClient, cache.NewKeys(""), revocations, testTokens), revocations, mr
+}
+
+func testClaims(userID, tokenID string, issuedAt time.Time) jwt.Claims {
+	return jwt.Claims{UserID: userID, TokenID: tokenID, IssuedAt: issuedAt, ExpiresAt: issuedAt.Add(testTokens.TTL)}
+}
+
+func TestStore_CreateAndList(t *testing.T) {
+	store, _, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	phone := testClaims("42", "token-1", now.Add(-time.Minute))
+	laptop := testClaims("42", "token-2", now.Add(-30*time.Second))
+	if err := store.Create(ctx, phone, Client{DeviceName: "Phone"}, "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := store.Create(ctx, laptop, Client{DeviceName: "Laptop"}, "10.0.0.2"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := store.Create(ctx, testClaims("7", "token-3", now), Client{DeviceName: "Other"}, "10.0.0.3"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// Seeing the phone again moves it to the top and records its new IP
+	if err := store.Touch(ctx, phone, "10.0.0.9"); err != nil {
+		t.Fatalf("Failed to touch session: %v", err)
+	}
+
+	sessions, err := store.List(ctx, "42", laptop.TokenID)
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", sessions)
+	}
+	if sessions[0].ID != phone.TokenID || sessions[0].IP != "10.0.0.9" || sessions[0].Current {
+		t.Errorf("Expected the phone session seen from 10.0.0.9 first, got %+v", sessions[0])
+	}
+	if sessions[1].ID != laptop.TokenID || sessions[1].DeviceName != "Laptop" || !sessions[1].Current {
+		t.Errorf("Expected the current laptop session second, got %+v", sessions[1])
+	}
+	if !sessions[1].ExpiresAt.Equal(laptop.ExpiresAt) || !sessions[1].CreatedAt.Equal(laptop.IssuedAt) {
+		t.Errorf("Expected session times to match the token, got %+v", sessions[1])
+	}
+
+	// Sessions go away once their tokens would be rejected
+	if ttl := mr.TTL(store.sessionKey(laptop.TokenID)); ttl <= time.Until(laptop.ExpiresAt) {
+		t.Errorf("Expected the session to outlive the token by the leeway, got %v", ttl)
+	}
+}
+
+func TestStore_TouchUnknownSession(t *testing.T) {
+	store, _, mr := setupTestStore(t)
+	ctx := context.Background()
+
+	if err := store.Touch(ctx, testClaims("42", "token-1", time.Now()), "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to touch session: %v", err)
+	}
+	if mr.Exists(store.sessionKey("token-1")) {
+		t.Error("Expected touching a missing session not to create it")
+	}
+
+	// Tokens from before sessions were tracked have no ID
+	if err := store.Touch(ctx, jwt.Claims{UserID: "42"}, "10.0.0.1"); err != nil {
+		t.Errorf("Expected tokens without an ID to be ignored, got %v", err)
+	}
+}
+
+func TestStore_Revoke(t *testing.T) {
+	store, revocations, _ := setupTestStore(t)
+	ctx := context.Background()
+
+	claims := testClaims("42", "token-1", time.Now())
+	if err := store.Create(ctx, claims, Client{DeviceName: "Phone"}, "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := store.Revoke(ctx, "7", claims.TokenID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected another user's session to be not found, got %v", err)
+	}
+	if err := store.Revoke(ctx, "42", claims.TokenID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	if err := store.Revoke(ctx, "42", claims.TokenID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a revoked session to be not found, got %v", err)
+	}
+
+	revoked, err := revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("Failed to check revocation: %v", err)
+	}
+	if !revoked {
+		t.Error("Expected the session's token to be revoked")
+	}
+
+	sessions, err := store.List(ctx, "42", "")
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("Expected no sessions after revoking, got %+v", sessions)
+	}
+}
+
+func TestStore_ClientBreakdown(t *testing.T) {
+	store, _, _ := setupTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	logins := []struct {
+		claims jwt.Claims
+		client Client
+	}{
+		{testClaims("1", "token-1", now), Client{Platform: "iOS", ClientVersion: "2.1.0", DeviceID: "phone-1"}},
+		{testClaims("1", "token-2", now), Client{Platform: "ios", ClientVersion: "2.1.0", DeviceID: "phone-1"}},
+		{testClaims("2", "token-3", now), Client{Platform: "ios", ClientVersion: "1.9.4", DeviceID: "phone-2"}},
+		{testClaims("3", "token-4", now), Client{Platform: "web"}},
+		{testClaims("4", "token-5", now), Client{Platform: "smart-fridge", ClientVersion: "0.1 beta"}},
+		{testClaims("5", "token-6", now), Client{}},
+	}
+	for _, login := range logins {
+		if err := store.Create(ctx, login.claims, login.client, "10.0.0.1"); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+	// Another tenant's sessions are counted separately
+	acme := testClaims("9", "token-7", now)
+	acme.TenantID = "acme"
+	if err := store.Create(ctx, acme, Client{Platform: "android"}, "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// Revoked sessions no longer count
+	if err := store.Revoke(ctx, "1", "token-2"); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+
+	breakdown, err := store.ClientBreakdown(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to get client breakdown: %v", err)
+	}
+	if breakdown.Sessions != 5 || len(breakdown.Platforms) != 4 {
+		t.Fatalf("Expected 5 sessions on 4 platforms, got %+v", breakdown)
+	}
+
+	ios := breakdown.Platforms[0]
+	if ios.Platform != PlatformIOS || ios.Sessions != 2 || ios.Devices != 2 || len(ios.Versions) != 2 {
+		t.Errorf("Expected 2 iOS sessions on 2 devices and versions first, got %+v", ios)
+	}
+	if got := breakdown.Platforms[1]; got.Platform != PlatformOther || got.Versions[0].Version != "0.1beta" || got.Devices != 0 {
+		t.Errorf("Expected the unknown platform as other with its version cleaned, got %+v", got)
+	}
+	if got := breakdown.Platforms[2]; got.Platform != PlatformUnknown || got.Sessions != 1 {
+		t.Errorf("Expected a session without a platform as unknown, got %+v", got)
+	}
+
+	sessions, err := store.List(ctx, "2", "")
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Platform != PlatformIOS || sessions[0].ClientVersion != "1.9.4" || sessions[0].DeviceID != "phone-2" {
+		t.Errorf("Expected the session to carry its client, got %+v", sessions)
+	}
+
+	acmeBreakdown, err := store.ClientBreakdown(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Failed to get client breakdown: %v", err)
+	}
+	if acmeBreakdown.Sessions != 1 || acmeBreakdown.Platforms[0].Platform != PlatformAndroid {
+		t.Errorf("Expected one android session in acme, got %+v", acmeBreakdown)
+	}
+}
+
+func TestClientFromHeaders(t *testing.T) {
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("User-Agent", "StoriesApp/3.0")
+	r.Header.Set(HeaderPlatform, "android")
+	r.Header.Set(HeaderClientVersion, "3.0.1")
+	r.Header.Set(HeaderDeviceID, "device-1")
+
+	client := ClientFromHeaders(r)
+	if client != (Client{DeviceName: "StoriesApp/3.0", Platform: "android", ClientVersion: "3.0.1", DeviceID: "device-1"}) {
+		t.Errorf("Expected the client from the headers, got %+v", client)
+	}
+}
+
+func TestTruncate_KeepsCharactersWhole(t *testing.T) {
+	// A two-byte character straddling the cap
+	name := strings.Repeat("a", maxDeviceName-1) + "é"
+
+	truncated := truncate(name, maxDeviceName)
+	if want := name[:maxDeviceName-1]; truncated != want {
+		t.Errorf("Expected the device name cut before the split character, got %q", truncated)
+	}
+	if !utf8.ValidString(truncated) {
+		t.Errorf("Expected a valid UTF-8 device name, got %q", truncated)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if ip := ClientIP(r); ip != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1, got %q", ip)
+	}
+}
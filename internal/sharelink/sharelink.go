@@ -0,0 +1,86 @@
+package sharelink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// ErrInvalidToken is returned when a share token is malformed or forged
+var ErrInvalidToken = errors.New("invalid share link token")
+
+// Signer turns share link IDs into tokens and back. Tokens are signed so link
+// IDs cannot be guessed; expiry and revocation are kept with the link itself.
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer for share link tokens
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Token returns the token for the share link with the given ID
+func (s *Signer) Token(linkID string) string {
+	return linkID + "." + s.sign(linkID)
+}
+
+// Parse verifies a token and returns the ID of the share link it was issued for
+func (s *Signer) Parse(token string) (string, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(s.sign(id))) {
+		return "", ErrInvalidToken
+	}
+	return id, nil
+}
+
+// URL returns the public link for the share link with the given ID
+func (s *Signer) URL(publicURL, linkID string) string {
+	return strings.TrimRight(publicURL, "/") + "/shared/" + s.Token(linkID)
+}
+
+// Store is the storage a share link is opened with
+type Store interface {
+	storage.ShareLinkStore
+	GetStoryByID(storyID string) (types.Story, error)
+}
+
+// Open resolves a token to its share link and the story it points to. It
+// returns ErrInvalidToken for forged tokens and sql.ErrNoRows when the link
+// was revoked or expired, its story has ended or the story limits how often
+// each viewer may open it. Opening a link does not count a view.
+func Open(store Store, signer *Signer, token string) (types.ShareLink, types.Story, error) {
+	linkID, err := signer.Parse(token)
+	if err != nil {
+		return types.ShareLink{}, types.Story{}, err
+	}
+
+	link, err := store.GetActiveShareLink(linkID)
+	if err != nil {
+		return types.ShareLink{}, types.Story{}, err
+	}
+
+	story, err := store.GetStoryByID(link.StoryID)
+	if err != nil {
+		return types.ShareLink{}, types.Story{}, err
+	}
+	if story.MaxViewsPerUser > 0 {
+		return types.ShareLink{}, types.Story{}, sql.ErrNoRows
+	}
+
+	return link, story, nil
+}
+
+func (s *Signer) sign(id string) string {
+	// Prefixed so signatures made with the same secret elsewhere, such as
+	// WebSocket tickets, are never valid share tokens
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("share_link:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
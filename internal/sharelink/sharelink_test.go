@@ -0,0 +1,80 @@
+package sharelink
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// linkStore holds one share link and the story it points to
+type linkStore struct {
+	storage.ShareLinkStore
+	link  types.ShareLink
+	story types.Story
+}
+
+func (s linkStore) GetActiveShareLink(linkID string) (types.ShareLink, error) {
+	if linkID != s.link.ID {
+		return types.ShareLink{}, sql.ErrNoRows
+	}
+	return s.link, nil
+}
+
+func (s linkStore) GetStoryByID(storyID string) (types.Story, error) {
+	return s.story, nil
+}
+
+func TestSigner_RoundTrip(t *testing.T) {
+	signer := NewSigner("test_secret")
+
+	id, err := signer.Parse(signer.Token("42"))
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if id != "42" {
+		t.Fatalf("Expected link 42, got %s", id)
+	}
+}
+
+func TestSigner_RejectsForgedTokens(t *testing.T) {
+	signer := NewSigner("test_secret")
+	token := signer.Token("42")
+
+	other := NewSigner("other_secret").Token("42")
+	for _, bad := range []string{"", "42", "42.", ".sig", token + "x", "43" + token[2:], other} {
+		if _, err := signer.Parse(bad); err != ErrInvalidToken {
+			t.Fatalf("Expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestSigner_URL(t *testing.T) {
+	signer := NewSigner("test_secret")
+
+	url := signer.URL("https://stories.example/", "7")
+	if url != "https://stories.example/shared/"+signer.Token("7") {
+		t.Fatalf("Unexpected share URL %s", url)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	signer := NewSigner("test_secret")
+	store := linkStore{
+		link:  types.ShareLink{ID: "7", StoryID: "42"},
+		story: types.Story{ID: "42", Text: "hello"},
+	}
+
+	link, story, err := Open(store, signer, signer.Token("7"))
+	if err != nil || link.ID != "7" || story.Text != "hello" {
+		t.Fatalf("Expected link 7 to open story 42, got %+v, %+v, %v", link, story, err)
+	}
+
+	// Links would let anyone reopen a view-limited story at will
+	store.story.MaxViewsPerUser = 1
+	if _, _, err := Open(store, signer, signer.Token("7")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected sql.ErrNoRows for a view-limited story, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package startup
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// Dependency states
+const (
+	StateConnecting = "connecting"
+	StateReady      = "ready"
+	StateFailed     = "failed"
+)
+
+// Dependency is how connecting to one dependency is going. Errors of failed
+// attempts are only logged, since /ready is public.
+type Dependency struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+}
+
+// Gate is the server's root handler. It always serves GET /health, and GET
+// /ready with how connecting to each dependency is going. Every other
+// request is answered with 503 until Open hands the gate the API's handler.
+type Gate struct {
+	policy  Policy
+	handler atomic.Pointer[http.Handler]
+
+	mu           sync.Mutex
+	dependencies []Dependency
+}
+
+// NewGate creates a closed gate connecting to dependencies with policy
+func NewGate(policy Policy) *Gate {
+	return &Gate{policy: policy}
+}
+
+// Connect connects to the dependency called name with the gate's policy,
+// recording each attempt for /ready
+func (g *Gate) Connect(ctx context.Context, name string, connect func(context.Context) error) error {
+	g.mu.Lock()
+	i := len(g.dependencies)
+	g.dependencies = append(g.dependencies, Dependency{Name: name, State: StateConnecting})
+	g.mu.Unlock()
+
+	err := g.policy.Retry(ctx, name, connect, func(attempt int, err error) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.dependencies[i].Attempts = attempt
+		g.dependencies[i].State = StateReady
+		if err != nil {
+			g.dependencies[i].State = StateConnecting
+		}
+	})
+	if err != nil {
+		g.mu.Lock()
+		g.dependencies[i].State = StateFailed
+		g.mu.Unlock()
+	}
+	return err
+}
+
+// Open starts passing requests on to handler
+func (g *Gate) Open(handler http.Handler) {
+	g.handler.Store(&handler)
+}
+
+// Dependencies returns the state of every dependency connected to so far
+func (g *Gate) Dependencies() []Dependency {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.dependencies)
+}
+
+func (g *Gate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := g.handler.Load()
+	probe := r.Method == http.MethodGet && (r.URL.Path == "/health" || r.URL.Path == "/ready")
+	if handler != nil && !probe {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+
+	// The API's handler negotiates the locale itself; what the gate answers
+	// is negotiated here
+	i18n.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.serveGate(w, r, handler != nil)
+	})).ServeHTTP(w, r)
+}
+
+// serveGate answers the probes, and every other request while the gate is
+// closed
+func (g *Gate) serveGate(w http.ResponseWriter, r *http.Request, open bool) {
+	switch {
+	case r.URL.Path == "/health":
+		response.WriteJSON(w, http.StatusOK, response.RequestOK("Alive", nil))
+	case r.URL.Path == "/ready" && open:
+		response.WriteJSON(w, http.StatusOK, response.OK("Ready", g.Dependencies()))
+	case r.URL.Path == "/ready":
+		response.WriteJSON(w, http.StatusServiceUnavailable, response.Response{
+			Status: response.StatusError,
+			Error:  i18n.T(r.Context(), i18n.MsgServiceStarting),
+			Data:   g.Dependencies(),
+		})
+	default:
+		w.Header().Set("Retry-After", "5")
+		response.WriteJSON(w, http.StatusServiceUnavailable, response.GeneralError(i18n.Error(r.Context(), i18n.MsgServiceStarting)))
+	}
+}
@@ -0,0 +1,60 @@
+// Package startup connects to the service's dependencies, retrying with
+// backoff while they come up, and gates HTTP traffic until they are ready.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+)
+
+// Policy controls how connecting to a dependency is retried
+type Policy struct {
+	Attempts       int           // 0 retries until the context is done
+	InitialBackoff time.Duration // wait before the first retry, doubled after each one
+	MaxBackoff     time.Duration
+}
+
+// PolicyFromConfig reads the retry policy from the service config
+func PolicyFromConfig(cfg config.Startup) Policy {
+	return Policy{
+		Attempts:       cfg.RetryAttempts,
+		InitialBackoff: time.Duration(cfg.InitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(cfg.MaxBackoff) * time.Millisecond,
+	}
+}
+
+// Retry calls connect until it succeeds, the policy's attempts run out or ctx
+// is done, and returns the last error in the latter cases. onAttempt, if set,
+// is called after every attempt.
+func (p Policy) Retry(ctx context.Context, name string, connect func(context.Context) error, onAttempt func(attempt int, err error)) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if onAttempt != nil {
+			onAttempt(attempt, err)
+		}
+		if err == nil {
+			return nil
+		}
+		if p.Attempts > 0 && attempt >= p.Attempts {
+			return fmt.Errorf("%s not ready after %d attempts: %w", name, attempt, err)
+		}
+
+		slog.Warn("Dependency not ready, retrying",
+			slog.String("dependency", name),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready: %w", name, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
@@ -0,0 +1,143 @@
+package startup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errNotReady = errors.New("connection refused")
+
+// failing returns a connect func failing the first n calls
+func failing(n int, calls *int) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return errNotReady
+		}
+		return nil
+	}
+}
+
+func TestPolicy_Retry(t *testing.T) {
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	cases := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"ready at once", 0, 1, false},
+		{"ready on the last attempt", 2, 3, false},
+		{"never ready", 5, 3, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := policy.Retry(context.Background(), "postgres", failing(tc.failures, &calls), nil)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr && !errors.Is(err, errNotReady) {
+				t.Errorf("Expected the last connection error to be wrapped, got %v", err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("Expected %d attempts, got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestPolicy_RetryUntilCancelled(t *testing.T) {
+	policy := Policy{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := policy.Retry(ctx, "redis", func(context.Context) error {
+		calls++
+		if calls == 5 {
+			cancel()
+		}
+		return errNotReady
+	}, nil)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if calls != 5 {
+		t.Errorf("Expected retries until cancelled, got %d attempts", calls)
+	}
+}
+
+func TestGate(t *testing.T) {
+	gate := NewGate(Policy{Attempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		gate.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	calls := 0
+	if err := gate.Connect(context.Background(), "redis", failing(1, &calls)); err != nil {
+		t.Fatalf("Expected redis to connect, got %v", err)
+	}
+	if err := gate.Connect(context.Background(), "minio", failing(5, &calls)); err == nil {
+		t.Fatal("Expected minio to fail")
+	}
+
+	if rec := get("/health"); rec.Code != http.StatusOK {
+		t.Errorf("Expected /health to be 200 while starting, got %d", rec.Code)
+	}
+	if rec := get("/stories"); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After while starting, got %d", rec.Code)
+	}
+	localized := httptest.NewRequest(http.MethodGet, "/stories", nil)
+	localized.Header.Set("Accept-Language", "es")
+	rec := httptest.NewRecorder()
+	gate.ServeHTTP(rec, localized)
+	if !strings.Contains(rec.Body.String(), "el servicio se está iniciando") {
+		t.Errorf("Expected the 503 in the request locale, got %s", rec.Body.String())
+	}
+
+	rec = get("/ready")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /ready to be 503 while starting, got %d", rec.Code)
+	}
+	var body struct {
+		Data []Dependency `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode /ready: %v", err)
+	}
+	want := []Dependency{
+		{Name: "redis", State: StateReady, Attempts: 2},
+		{Name: "minio", State: StateFailed, Attempts: 2},
+	}
+	if len(body.Data) != len(want) {
+		t.Fatalf("Expected %d dependencies, got %+v", len(want), body.Data)
+	}
+	for i := range want {
+		if body.Data[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], body.Data[i])
+		}
+	}
+
+	gate.Open(api)
+	if rec := get("/stories"); rec.Code != http.StatusTeapot {
+		t.Errorf("Expected requests to reach the API once open, got %d", rec.Code)
+	}
+	if rec := get("/ready"); rec.Code != http.StatusOK {
+		t.Errorf("Expected /ready to be 200 once open, got %d", rec.Code)
+	}
+}
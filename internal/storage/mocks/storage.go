@@ -0,0 +1,1609 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/princekumarofficial/stories-service/internal/storage (interfaces: Storage)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/storage.go -package=mocks . Storage
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	storage "github.com/princekumarofficial/stories-service/internal/storage"
+	types "github.com/princekumarofficial/stories-service/internal/types"
+	media "github.com/princekumarofficial/stories-service/internal/types/media"
+	users "github.com/princekumarofficial/stories-service/internal/types/users"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// AddGroupMembers mocks base method.
+func (m *MockStorage) AddGroupMembers(groupID, ownerID string, userIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMembers", groupID, ownerID, userIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroupMembers indicates an expected call of AddGroupMembers.
+func (mr *MockStorageMockRecorder) AddGroupMembers(groupID, ownerID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMembers", reflect.TypeOf((*MockStorage)(nil).AddGroupMembers), groupID, ownerID, userIDs)
+}
+
+// AddReaction mocks base method.
+func (m *MockStorage) AddReaction(storyID, userID string, emoji types.ReactionType) (types.ReactionType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", storyID, userID, emoji)
+	ret0, _ := ret[0].(types.ReactionType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockStorageMockRecorder) AddReaction(storyID, userID, emoji any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockStorage)(nil).AddReaction), storyID, userID, emoji)
+}
+
+// AddStoryToHighlights mocks base method.
+func (m *MockStorage) AddStoryToHighlights(storyID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStoryToHighlights", storyID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddStoryToHighlights indicates an expected call of AddStoryToHighlights.
+func (mr *MockStorageMockRecorder) AddStoryToHighlights(storyID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStoryToHighlights", reflect.TypeOf((*MockStorage)(nil).AddStoryToHighlights), storyID, userID)
+}
+
+// CanUserViewStory mocks base method.
+func (m *MockStorage) CanUserViewStory(storyID, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CanUserViewStory", storyID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CanUserViewStory indicates an expected call of CanUserViewStory.
+func (mr *MockStorageMockRecorder) CanUserViewStory(storyID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanUserViewStory", reflect.TypeOf((*MockStorage)(nil).CanUserViewStory), storyID, userID)
+}
+
+// ClaimExpiringStories mocks base method.
+func (m *MockStorage) ClaimExpiringStories(within time.Duration) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimExpiringStories", within)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimExpiringStories indicates an expected call of ClaimExpiringStories.
+func (mr *MockStorageMockRecorder) ClaimExpiringStories(within any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimExpiringStories", reflect.TypeOf((*MockStorage)(nil).ClaimExpiringStories), within)
+}
+
+// ClaimQueuedNotifications mocks base method.
+func (m *MockStorage) ClaimQueuedNotifications(userID string) (map[types.EventType]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimQueuedNotifications", userID)
+	ret0, _ := ret[0].(map[types.EventType]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimQueuedNotifications indicates an expected call of ClaimQueuedNotifications.
+func (mr *MockStorageMockRecorder) ClaimQueuedNotifications(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimQueuedNotifications", reflect.TypeOf((*MockStorage)(nil).ClaimQueuedNotifications), userID)
+}
+
+// ConfirmMediaUpload mocks base method.
+func (m *MockStorage) ConfirmMediaUpload(userID, objectKey string, size int64) (media.MediaUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMediaUpload", userID, objectKey, size)
+	ret0, _ := ret[0].(media.MediaUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmMediaUpload indicates an expected call of ConfirmMediaUpload.
+func (mr *MockStorageMockRecorder) ConfirmMediaUpload(userID, objectKey, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMediaUpload", reflect.TypeOf((*MockStorage)(nil).ConfirmMediaUpload), userID, objectKey, size)
+}
+
+// CountArchivableStories mocks base method.
+func (m *MockStorage) CountArchivableStories(tenantID string, olderThan time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountArchivableStories", tenantID, olderThan)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountArchivableStories indicates an expected call of CountArchivableStories.
+func (mr *MockStorageMockRecorder) CountArchivableStories(tenantID, olderThan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountArchivableStories", reflect.TypeOf((*MockStorage)(nil).CountArchivableStories), tenantID, olderThan)
+}
+
+// CreateAPIToken mocks base method.
+func (m *MockStorage) CreateAPIToken(userID, name, tokenHash string, scopes []string, validFor time.Duration) (users.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIToken", userID, name, tokenHash, scopes, validFor)
+	ret0, _ := ret[0].(users.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockStorageMockRecorder) CreateAPIToken(userID, name, tokenHash, scopes, validFor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockStorage)(nil).CreateAPIToken), userID, name, tokenHash, scopes, validFor)
+}
+
+// CreateGroup mocks base method.
+func (m *MockStorage) CreateGroup(ownerID, name string, memberIDs []string) (types.StoryGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ownerID, name, memberIDs)
+	ret0, _ := ret[0].(types.StoryGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockStorageMockRecorder) CreateGroup(ownerID, name, memberIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockStorage)(nil).CreateGroup), ownerID, name, memberIDs)
+}
+
+// CreateMediaUpload mocks base method.
+func (m *MockStorage) CreateMediaUpload(userID, objectKey, contentType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMediaUpload", userID, objectKey, contentType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMediaUpload indicates an expected call of CreateMediaUpload.
+func (mr *MockStorageMockRecorder) CreateMediaUpload(userID, objectKey, contentType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMediaUpload", reflect.TypeOf((*MockStorage)(nil).CreateMediaUpload), userID, objectKey, contentType)
+}
+
+// CreateShareLink mocks base method.
+func (m *MockStorage) CreateShareLink(storyID string, requireLogin bool, validFor time.Duration) (types.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShareLink", storyID, requireLogin, validFor)
+	ret0, _ := ret[0].(types.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShareLink indicates an expected call of CreateShareLink.
+func (mr *MockStorageMockRecorder) CreateShareLink(storyID, requireLogin, validFor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShareLink", reflect.TypeOf((*MockStorage)(nil).CreateShareLink), storyID, requireLogin, validFor)
+}
+
+// CreateStory mocks base method.
+func (m *MockStorage) CreateStory(authorID string, story types.StoryPostRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStory", authorID, story)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStory indicates an expected call of CreateStory.
+func (mr *MockStorageMockRecorder) CreateStory(authorID, story any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStory", reflect.TypeOf((*MockStorage)(nil).CreateStory), authorID, story)
+}
+
+// CreateUser mocks base method.
+func (m *MockStorage) CreateUser(tenantID, email, password string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", tenantID, email, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageMockRecorder) CreateUser(tenantID, email, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), tenantID, email, password)
+}
+
+// DeleteMediaUpload mocks base method.
+func (m *MockStorage) DeleteMediaUpload(objectKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaUpload", objectKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaUpload indicates an expected call of DeleteMediaUpload.
+func (mr *MockStorageMockRecorder) DeleteMediaUpload(objectKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaUpload", reflect.TypeOf((*MockStorage)(nil).DeleteMediaUpload), objectKey)
+}
+
+// DeleteStory mocks base method.
+func (m *MockStorage) DeleteStory(storyID string) (types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStory", storyID)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStory indicates an expected call of DeleteStory.
+func (mr *MockStorageMockRecorder) DeleteStory(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStory", reflect.TypeOf((*MockStorage)(nil).DeleteStory), storyID)
+}
+
+// DiscoverUsers mocks base method.
+func (m *MockStorage) DiscoverUsers(userID string, hashes []string) ([]users.DiscoveredUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverUsers", userID, hashes)
+	ret0, _ := ret[0].([]users.DiscoveredUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverUsers indicates an expected call of DiscoverUsers.
+func (mr *MockStorageMockRecorder) DiscoverUsers(userID, hashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverUsers", reflect.TypeOf((*MockStorage)(nil).DiscoverUsers), userID, hashes)
+}
+
+// ExpireStory mocks base method.
+func (m *MockStorage) ExpireStory(storyID string) (types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireStory", storyID)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireStory indicates an expected call of ExpireStory.
+func (mr *MockStorageMockRecorder) ExpireStory(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireStory", reflect.TypeOf((*MockStorage)(nil).ExpireStory), storyID)
+}
+
+// FlagAbuse mocks base method.
+func (m *MockStorage) FlagAbuse(flag users.AbuseFlag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagAbuse", flag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagAbuse indicates an expected call of FlagAbuse.
+func (mr *MockStorageMockRecorder) FlagAbuse(flag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagAbuse", reflect.TypeOf((*MockStorage)(nil).FlagAbuse), flag)
+}
+
+// FollowUser mocks base method.
+func (m *MockStorage) FollowUser(followerID, followedID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowUser", followerID, followedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FollowUser indicates an expected call of FollowUser.
+func (mr *MockStorageMockRecorder) FollowUser(followerID, followedID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowUser", reflect.TypeOf((*MockStorage)(nil).FollowUser), followerID, followedID)
+}
+
+// FollowUsers mocks base method.
+func (m *MockStorage) FollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowUsers", followerID, followedIDs)
+	ret0, _ := ret[0].([]types.FollowResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FollowUsers indicates an expected call of FollowUsers.
+func (mr *MockStorageMockRecorder) FollowUsers(followerID, followedIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowUsers", reflect.TypeOf((*MockStorage)(nil).FollowUsers), followerID, followedIDs)
+}
+
+// GetAPITokens mocks base method.
+func (m *MockStorage) GetAPITokens(userID string) ([]users.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokens", userID)
+	ret0, _ := ret[0].([]users.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPITokens indicates an expected call of GetAPITokens.
+func (mr *MockStorageMockRecorder) GetAPITokens(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokens", reflect.TypeOf((*MockStorage)(nil).GetAPITokens), userID)
+}
+
+// GetAbuseFlags mocks base method.
+func (m *MockStorage) GetAbuseFlags(adminID string, reviewed bool, limit int) ([]users.AbuseFlag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAbuseFlags", adminID, reviewed, limit)
+	ret0, _ := ret[0].([]users.AbuseFlag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAbuseFlags indicates an expected call of GetAbuseFlags.
+func (mr *MockStorageMockRecorder) GetAbuseFlags(adminID, reviewed, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAbuseFlags", reflect.TypeOf((*MockStorage)(nil).GetAbuseFlags), adminID, reviewed, limit)
+}
+
+// GetActiveCreators mocks base method.
+func (m *MockStorage) GetActiveCreators(ctx context.Context, weekStart time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveCreators", ctx, weekStart)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveCreators indicates an expected call of GetActiveCreators.
+func (mr *MockStorageMockRecorder) GetActiveCreators(ctx, weekStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveCreators", reflect.TypeOf((*MockStorage)(nil).GetActiveCreators), ctx, weekStart)
+}
+
+// GetActiveShareLink mocks base method.
+func (m *MockStorage) GetActiveShareLink(linkID string) (types.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveShareLink", linkID)
+	ret0, _ := ret[0].(types.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveShareLink indicates an expected call of GetActiveShareLink.
+func (mr *MockStorageMockRecorder) GetActiveShareLink(linkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveShareLink", reflect.TypeOf((*MockStorage)(nil).GetActiveShareLink), linkID)
+}
+
+// GetAllPublicStories mocks base method.
+func (m *MockStorage) GetAllPublicStories(tenantID string) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllPublicStories", tenantID)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllPublicStories indicates an expected call of GetAllPublicStories.
+func (mr *MockStorageMockRecorder) GetAllPublicStories(tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPublicStories", reflect.TypeOf((*MockStorage)(nil).GetAllPublicStories), tenantID)
+}
+
+// GetArchivableStories mocks base method.
+func (m *MockStorage) GetArchivableStories(tenantID string, olderThan time.Duration, limit int) ([]types.ArchivedStory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivableStories", tenantID, olderThan, limit)
+	ret0, _ := ret[0].([]types.ArchivedStory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchivableStories indicates an expected call of GetArchivableStories.
+func (mr *MockStorageMockRecorder) GetArchivableStories(tenantID, olderThan, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivableStories", reflect.TypeOf((*MockStorage)(nil).GetArchivableStories), tenantID, olderThan, limit)
+}
+
+// GetArchiveEntry mocks base method.
+func (m *MockStorage) GetArchiveEntry(storyID string) (types.ArchiveEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchiveEntry", storyID)
+	ret0, _ := ret[0].(types.ArchiveEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchiveEntry indicates an expected call of GetArchiveEntry.
+func (mr *MockStorageMockRecorder) GetArchiveEntry(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchiveEntry", reflect.TypeOf((*MockStorage)(nil).GetArchiveEntry), storyID)
+}
+
+// GetAuthorAffinity mocks base method.
+func (m *MockStorage) GetAuthorAffinity(userID string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorAffinity", userID)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthorAffinity indicates an expected call of GetAuthorAffinity.
+func (mr *MockStorageMockRecorder) GetAuthorAffinity(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorAffinity", reflect.TypeOf((*MockStorage)(nil).GetAuthorAffinity), userID)
+}
+
+// GetFeedChanges mocks base method.
+func (m *MockStorage) GetFeedChanges(ctx context.Context, userID string, since time.Time) (types.FeedChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedChanges", ctx, userID, since)
+	ret0, _ := ret[0].(types.FeedChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedChanges indicates an expected call of GetFeedChanges.
+func (mr *MockStorageMockRecorder) GetFeedChanges(ctx, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedChanges", reflect.TypeOf((*MockStorage)(nil).GetFeedChanges), ctx, userID, since)
+}
+
+// GetFeedTrays mocks base method.
+func (m *MockStorage) GetFeedTrays(ctx context.Context, userID string) ([]types.FeedTray, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedTrays", ctx, userID)
+	ret0, _ := ret[0].([]types.FeedTray)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedTrays indicates an expected call of GetFeedTrays.
+func (mr *MockStorageMockRecorder) GetFeedTrays(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedTrays", reflect.TypeOf((*MockStorage)(nil).GetFeedTrays), ctx, userID)
+}
+
+// GetFollowerDigests mocks base method.
+func (m *MockStorage) GetFollowerDigests(limit int) ([]users.FollowerDigest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollowerDigests", limit)
+	ret0, _ := ret[0].([]users.FollowerDigest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollowerDigests indicates an expected call of GetFollowerDigests.
+func (mr *MockStorageMockRecorder) GetFollowerDigests(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowerDigests", reflect.TypeOf((*MockStorage)(nil).GetFollowerDigests), limit)
+}
+
+// GetGroup mocks base method.
+func (m *MockStorage) GetGroup(groupID, userID string) (types.StoryGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", groupID, userID)
+	ret0, _ := ret[0].(types.StoryGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockStorageMockRecorder) GetGroup(groupID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockStorage)(nil).GetGroup), groupID, userID)
+}
+
+// GetGroupMembers mocks base method.
+func (m *MockStorage) GetGroupMembers(groupID string) ([]types.GroupMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", groupID)
+	ret0, _ := ret[0].([]types.GroupMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers.
+func (mr *MockStorageMockRecorder) GetGroupMembers(groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockStorage)(nil).GetGroupMembers), groupID)
+}
+
+// GetGroupStories mocks base method.
+func (m *MockStorage) GetGroupStories(groupID, userID string) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupStories", groupID, userID)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupStories indicates an expected call of GetGroupStories.
+func (mr *MockStorageMockRecorder) GetGroupStories(groupID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupStories", reflect.TypeOf((*MockStorage)(nil).GetGroupStories), groupID, userID)
+}
+
+// GetGroups mocks base method.
+func (m *MockStorage) GetGroups(userID string) ([]types.StoryGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", userID)
+	ret0, _ := ret[0].([]types.StoryGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockStorageMockRecorder) GetGroups(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockStorage)(nil).GetGroups), userID)
+}
+
+// GetHiddenAuthors mocks base method.
+func (m *MockStorage) GetHiddenAuthors(userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHiddenAuthors", userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHiddenAuthors indicates an expected call of GetHiddenAuthors.
+func (mr *MockStorageMockRecorder) GetHiddenAuthors(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHiddenAuthors", reflect.TypeOf((*MockStorage)(nil).GetHiddenAuthors), userID)
+}
+
+// GetImpersonationEvents mocks base method.
+func (m *MockStorage) GetImpersonationEvents(adminID, userID string, limit int) ([]users.ImpersonationEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImpersonationEvents", adminID, userID, limit)
+	ret0, _ := ret[0].([]users.ImpersonationEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImpersonationEvents indicates an expected call of GetImpersonationEvents.
+func (mr *MockStorageMockRecorder) GetImpersonationEvents(adminID, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImpersonationEvents", reflect.TypeOf((*MockStorage)(nil).GetImpersonationEvents), adminID, userID, limit)
+}
+
+// GetMediaUpload mocks base method.
+func (m *MockStorage) GetMediaUpload(userID, objectKey string) (media.MediaUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaUpload", userID, objectKey)
+	ret0, _ := ret[0].(media.MediaUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaUpload indicates an expected call of GetMediaUpload.
+func (mr *MockStorageMockRecorder) GetMediaUpload(userID, objectKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaUpload", reflect.TypeOf((*MockStorage)(nil).GetMediaUpload), userID, objectKey)
+}
+
+// GetMediaUploads mocks base method.
+func (m *MockStorage) GetMediaUploads(tenantID string) ([]media.MediaUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaUploads", tenantID)
+	ret0, _ := ret[0].([]media.MediaUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaUploads indicates an expected call of GetMediaUploads.
+func (mr *MockStorageMockRecorder) GetMediaUploads(tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaUploads", reflect.TypeOf((*MockStorage)(nil).GetMediaUploads), tenantID)
+}
+
+// GetNearbyPublicStories mocks base method.
+func (m *MockStorage) GetNearbyPublicStories(ctx context.Context, tenantID, viewerID string, lat, lng, radiusMeters float64) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNearbyPublicStories", ctx, tenantID, viewerID, lat, lng, radiusMeters)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNearbyPublicStories indicates an expected call of GetNearbyPublicStories.
+func (mr *MockStorageMockRecorder) GetNearbyPublicStories(ctx, tenantID, viewerID, lat, lng, radiusMeters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNearbyPublicStories", reflect.TypeOf((*MockStorage)(nil).GetNearbyPublicStories), ctx, tenantID, viewerID, lat, lng, radiusMeters)
+}
+
+// GetNotificationSettings mocks base method.
+func (m *MockStorage) GetNotificationSettings(userID string) (users.NotificationSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationSettings", userID)
+	ret0, _ := ret[0].(users.NotificationSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationSettings indicates an expected call of GetNotificationSettings.
+func (mr *MockStorageMockRecorder) GetNotificationSettings(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationSettings", reflect.TypeOf((*MockStorage)(nil).GetNotificationSettings), userID)
+}
+
+// GetPrivacySettings mocks base method.
+func (m *MockStorage) GetPrivacySettings(userID string) (users.PrivacySettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrivacySettings", userID)
+	ret0, _ := ret[0].(users.PrivacySettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrivacySettings indicates an expected call of GetPrivacySettings.
+func (mr *MockStorageMockRecorder) GetPrivacySettings(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivacySettings", reflect.TypeOf((*MockStorage)(nil).GetPrivacySettings), userID)
+}
+
+// GetPublicKey mocks base method.
+func (m *MockStorage) GetPublicKey(viewerID, userID string) (users.PublicKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublicKey", viewerID, userID)
+	ret0, _ := ret[0].(users.PublicKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublicKey indicates an expected call of GetPublicKey.
+func (mr *MockStorageMockRecorder) GetPublicKey(viewerID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicKey", reflect.TypeOf((*MockStorage)(nil).GetPublicKey), viewerID, userID)
+}
+
+// GetPublicProfile mocks base method.
+func (m *MockStorage) GetPublicProfile(viewerID, userID string) (users.PublicProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublicProfile", viewerID, userID)
+	ret0, _ := ret[0].(users.PublicProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublicProfile indicates an expected call of GetPublicProfile.
+func (mr *MockStorageMockRecorder) GetPublicProfile(viewerID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicProfile", reflect.TypeOf((*MockStorage)(nil).GetPublicProfile), viewerID, userID)
+}
+
+// GetQueuedNotificationUsers mocks base method.
+func (m *MockStorage) GetQueuedNotificationUsers() (map[string]users.NotificationSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueuedNotificationUsers")
+	ret0, _ := ret[0].(map[string]users.NotificationSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueuedNotificationUsers indicates an expected call of GetQueuedNotificationUsers.
+func (mr *MockStorageMockRecorder) GetQueuedNotificationUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuedNotificationUsers", reflect.TypeOf((*MockStorage)(nil).GetQueuedNotificationUsers))
+}
+
+// GetShareLinks mocks base method.
+func (m *MockStorage) GetShareLinks(storyID string) ([]types.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShareLinks", storyID)
+	ret0, _ := ret[0].([]types.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShareLinks indicates an expected call of GetShareLinks.
+func (mr *MockStorageMockRecorder) GetShareLinks(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShareLinks", reflect.TypeOf((*MockStorage)(nil).GetShareLinks), storyID)
+}
+
+// GetStoriesByAuthor mocks base method.
+func (m *MockStorage) GetStoriesByAuthor(authorID string) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoriesByAuthor", authorID)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoriesByAuthor indicates an expected call of GetStoriesByAuthor.
+func (mr *MockStorageMockRecorder) GetStoriesByAuthor(authorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoriesByAuthor", reflect.TypeOf((*MockStorage)(nil).GetStoriesByAuthor), authorID)
+}
+
+// GetStoriesByIDs mocks base method.
+func (m *MockStorage) GetStoriesByIDs(storyIDs []string) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoriesByIDs", storyIDs)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoriesByIDs indicates an expected call of GetStoriesByIDs.
+func (mr *MockStorageMockRecorder) GetStoriesByIDs(storyIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoriesByIDs", reflect.TypeOf((*MockStorage)(nil).GetStoriesByIDs), storyIDs)
+}
+
+// GetStoriesForUser mocks base method.
+func (m *MockStorage) GetStoriesForUser(ctx context.Context, userID string) ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoriesForUser", ctx, userID)
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoriesForUser indicates an expected call of GetStoriesForUser.
+func (mr *MockStorageMockRecorder) GetStoriesForUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoriesForUser", reflect.TypeOf((*MockStorage)(nil).GetStoriesForUser), ctx, userID)
+}
+
+// GetStoryAudience mocks base method.
+func (m *MockStorage) GetStoryAudience(storyID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryAudience", storyID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryAudience indicates an expected call of GetStoryAudience.
+func (mr *MockStorageMockRecorder) GetStoryAudience(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryAudience", reflect.TypeOf((*MockStorage)(nil).GetStoryAudience), storyID)
+}
+
+// GetStoryByID mocks base method.
+func (m *MockStorage) GetStoryByID(storyID string) (types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryByID", storyID)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryByID indicates an expected call of GetStoryByID.
+func (mr *MockStorageMockRecorder) GetStoryByID(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryByID", reflect.TypeOf((*MockStorage)(nil).GetStoryByID), storyID)
+}
+
+// GetStoryEnvelope mocks base method.
+func (m *MockStorage) GetStoryEnvelope(storyID, userID string) (types.StoryEnvelope, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryEnvelope", storyID, userID)
+	ret0, _ := ret[0].(types.StoryEnvelope)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryEnvelope indicates an expected call of GetStoryEnvelope.
+func (mr *MockStorageMockRecorder) GetStoryEnvelope(storyID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryEnvelope", reflect.TypeOf((*MockStorage)(nil).GetStoryEnvelope), storyID, userID)
+}
+
+// GetStoryForViewer mocks base method.
+func (m *MockStorage) GetStoryForViewer(storyID, viewerID string) (types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryForViewer", storyID, viewerID)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryForViewer indicates an expected call of GetStoryForViewer.
+func (mr *MockStorageMockRecorder) GetStoryForViewer(storyID, viewerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryForViewer", reflect.TypeOf((*MockStorage)(nil).GetStoryForViewer), storyID, viewerID)
+}
+
+// GetStorySettings mocks base method.
+func (m *MockStorage) GetStorySettings(userID string) (types.StorySettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStorySettings", userID)
+	ret0, _ := ret[0].(types.StorySettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStorySettings indicates an expected call of GetStorySettings.
+func (mr *MockStorageMockRecorder) GetStorySettings(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorySettings", reflect.TypeOf((*MockStorage)(nil).GetStorySettings), userID)
+}
+
+// GetStoryViewers mocks base method.
+func (m *MockStorage) GetStoryViewers(storyID string) ([]types.StoryViewer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryViewers", storyID)
+	ret0, _ := ret[0].([]types.StoryViewer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryViewers indicates an expected call of GetStoryViewers.
+func (mr *MockStorageMockRecorder) GetStoryViewers(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryViewers", reflect.TypeOf((*MockStorage)(nil).GetStoryViewers), storyID)
+}
+
+// GetTenantIDs mocks base method.
+func (m *MockStorage) GetTenantIDs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTenantIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTenantIDs indicates an expected call of GetTenantIDs.
+func (mr *MockStorageMockRecorder) GetTenantIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTenantIDs", reflect.TypeOf((*MockStorage)(nil).GetTenantIDs))
+}
+
+// GetTenantUsers mocks base method.
+func (m *MockStorage) GetTenantUsers(tenantID string, userIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTenantUsers", tenantID, userIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTenantUsers indicates an expected call of GetTenantUsers.
+func (mr *MockStorageMockRecorder) GetTenantUsers(tenantID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTenantUsers", reflect.TypeOf((*MockStorage)(nil).GetTenantUsers), tenantID, userIDs)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStorage) GetUserByEmail(tenantID, email string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", tenantID, email)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStorageMockRecorder) GetUserByEmail(tenantID, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStorage)(nil).GetUserByEmail), tenantID, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStorage) GetUserByID(userID string) (users.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", userID)
+	ret0, _ := ret[0].(users.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStorageMockRecorder) GetUserByID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStorage)(nil).GetUserByID), userID)
+}
+
+// GetUserFollowees mocks base method.
+func (m *MockStorage) GetUserFollowees(userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFollowees", userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFollowees indicates an expected call of GetUserFollowees.
+func (mr *MockStorageMockRecorder) GetUserFollowees(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFollowees", reflect.TypeOf((*MockStorage)(nil).GetUserFollowees), userID)
+}
+
+// GetUserFollowers mocks base method.
+func (m *MockStorage) GetUserFollowers(userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFollowers", userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFollowers indicates an expected call of GetUserFollowers.
+func (mr *MockStorageMockRecorder) GetUserFollowers(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFollowers", reflect.TypeOf((*MockStorage)(nil).GetUserFollowers), userID)
+}
+
+// GetUserMediaUploads mocks base method.
+func (m *MockStorage) GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMediaUploads", ctx, tenantID, userID, after, limit)
+	ret0, _ := ret[0].([]media.MediaUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMediaUploads indicates an expected call of GetUserMediaUploads.
+func (mr *MockStorageMockRecorder) GetUserMediaUploads(ctx, tenantID, userID, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMediaUploads", reflect.TypeOf((*MockStorage)(nil).GetUserMediaUploads), ctx, tenantID, userID, after, limit)
+}
+
+// GetUserProfile mocks base method.
+func (m *MockStorage) GetUserProfile(userID string) (users.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserProfile", userID)
+	ret0, _ := ret[0].(users.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserProfile indicates an expected call of GetUserProfile.
+func (mr *MockStorageMockRecorder) GetUserProfile(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserProfile", reflect.TypeOf((*MockStorage)(nil).GetUserProfile), userID)
+}
+
+// GetUserStats mocks base method.
+func (m *MockStorage) GetUserStats(userID string) (users.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", userID)
+	ret0, _ := ret[0].(users.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockStorageMockRecorder) GetUserStats(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockStorage)(nil).GetUserStats), userID)
+}
+
+// GetWeeklyRecaps mocks base method.
+func (m *MockStorage) GetWeeklyRecaps(ctx context.Context, userID string, limit int) ([]users.WeeklyRecap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeeklyRecaps", ctx, userID, limit)
+	ret0, _ := ret[0].([]users.WeeklyRecap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWeeklyRecaps indicates an expected call of GetWeeklyRecaps.
+func (mr *MockStorageMockRecorder) GetWeeklyRecaps(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeeklyRecaps", reflect.TypeOf((*MockStorage)(nil).GetWeeklyRecaps), ctx, userID, limit)
+}
+
+// GetWeeklyStatsRecipients mocks base method.
+func (m *MockStorage) GetWeeklyStatsRecipients(limit int) ([]users.EmailRecipient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeeklyStatsRecipients", limit)
+	ret0, _ := ret[0].([]users.EmailRecipient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWeeklyStatsRecipients indicates an expected call of GetWeeklyStatsRecipients.
+func (mr *MockStorageMockRecorder) GetWeeklyStatsRecipients(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeeklyStatsRecipients", reflect.TypeOf((*MockStorage)(nil).GetWeeklyStatsRecipients), limit)
+}
+
+// HideStories mocks base method.
+func (m *MockStorage) HideStories(userID, authorID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HideStories", userID, authorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HideStories indicates an expected call of HideStories.
+func (mr *MockStorageMockRecorder) HideStories(userID, authorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HideStories", reflect.TypeOf((*MockStorage)(nil).HideStories), userID, authorID)
+}
+
+// InTx mocks base method.
+func (m *MockStorage) InTx(ctx context.Context, fn func(storage.Storage) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTx indicates an expected call of InTx.
+func (mr *MockStorageMockRecorder) InTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTx", reflect.TypeOf((*MockStorage)(nil).InTx), ctx, fn)
+}
+
+// IsFollowing mocks base method.
+func (m *MockStorage) IsFollowing(followerID, followedID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFollowing", followerID, followedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFollowing indicates an expected call of IsFollowing.
+func (mr *MockStorageMockRecorder) IsFollowing(followerID, followedID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFollowing", reflect.TypeOf((*MockStorage)(nil).IsFollowing), followerID, followedID)
+}
+
+// MarkFollowersEmailed mocks base method.
+func (m *MockStorage) MarkFollowersEmailed(userID string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFollowersEmailed", userID, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFollowersEmailed indicates an expected call of MarkFollowersEmailed.
+func (mr *MockStorageMockRecorder) MarkFollowersEmailed(userID, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFollowersEmailed", reflect.TypeOf((*MockStorage)(nil).MarkFollowersEmailed), userID, until)
+}
+
+// MarkStoriesArchived mocks base method.
+func (m *MockStorage) MarkStoriesArchived(tenantID, objectKey string, storyIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkStoriesArchived", tenantID, objectKey, storyIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkStoriesArchived indicates an expected call of MarkStoriesArchived.
+func (mr *MockStorageMockRecorder) MarkStoriesArchived(tenantID, objectKey, storyIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStoriesArchived", reflect.TypeOf((*MockStorage)(nil).MarkStoriesArchived), tenantID, objectKey, storyIDs)
+}
+
+// MarkWeeklyRecapDelivered mocks base method.
+func (m *MockStorage) MarkWeeklyRecapDelivered(ctx context.Context, userID string, weekStart time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWeeklyRecapDelivered", ctx, userID, weekStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWeeklyRecapDelivered indicates an expected call of MarkWeeklyRecapDelivered.
+func (mr *MockStorageMockRecorder) MarkWeeklyRecapDelivered(ctx, userID, weekStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWeeklyRecapDelivered", reflect.TypeOf((*MockStorage)(nil).MarkWeeklyRecapDelivered), ctx, userID, weekStart)
+}
+
+// MarkWeeklyStatsEmailed mocks base method.
+func (m *MockStorage) MarkWeeklyStatsEmailed(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWeeklyStatsEmailed", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWeeklyStatsEmailed indicates an expected call of MarkWeeklyStatsEmailed.
+func (mr *MockStorageMockRecorder) MarkWeeklyStatsEmailed(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWeeklyStatsEmailed", reflect.TypeOf((*MockStorage)(nil).MarkWeeklyStatsEmailed), userID)
+}
+
+// QueueNotification mocks base method.
+func (m *MockStorage) QueueNotification(userID string, event *types.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueNotification", userID, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QueueNotification indicates an expected call of QueueNotification.
+func (mr *MockStorageMockRecorder) QueueNotification(userID, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueNotification", reflect.TypeOf((*MockStorage)(nil).QueueNotification), userID, event)
+}
+
+// RecordExposure mocks base method.
+func (m *MockStorage) RecordExposure(experiment, variant, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordExposure", experiment, variant, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordExposure indicates an expected call of RecordExposure.
+func (mr *MockStorageMockRecorder) RecordExposure(experiment, variant, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordExposure", reflect.TypeOf((*MockStorage)(nil).RecordExposure), experiment, variant, userID)
+}
+
+// RecordImpersonation mocks base method.
+func (m *MockStorage) RecordImpersonation(event users.ImpersonationEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImpersonation", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImpersonation indicates an expected call of RecordImpersonation.
+func (mr *MockStorageMockRecorder) RecordImpersonation(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImpersonation", reflect.TypeOf((*MockStorage)(nil).RecordImpersonation), event)
+}
+
+// RecordImpressions mocks base method.
+func (m *MockStorage) RecordImpressions(impressions []types.Impression) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImpressions", impressions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImpressions indicates an expected call of RecordImpressions.
+func (mr *MockStorageMockRecorder) RecordImpressions(impressions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImpressions", reflect.TypeOf((*MockStorage)(nil).RecordImpressions), impressions)
+}
+
+// RecordLinkClick mocks base method.
+func (m *MockStorage) RecordLinkClick(storyID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLinkClick", storyID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLinkClick indicates an expected call of RecordLinkClick.
+func (mr *MockStorageMockRecorder) RecordLinkClick(storyID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLinkClick", reflect.TypeOf((*MockStorage)(nil).RecordLinkClick), storyID, userID)
+}
+
+// RecordScreenshot mocks base method.
+func (m *MockStorage) RecordScreenshot(storyID, userID, kind string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordScreenshot", storyID, userID, kind)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordScreenshot indicates an expected call of RecordScreenshot.
+func (mr *MockStorageMockRecorder) RecordScreenshot(storyID, userID, kind any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScreenshot", reflect.TypeOf((*MockStorage)(nil).RecordScreenshot), storyID, userID, kind)
+}
+
+// RecordShareLinkView mocks base method.
+func (m *MockStorage) RecordShareLinkView(linkID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordShareLinkView", linkID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordShareLinkView indicates an expected call of RecordShareLinkView.
+func (mr *MockStorageMockRecorder) RecordShareLinkView(linkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordShareLinkView", reflect.TypeOf((*MockStorage)(nil).RecordShareLinkView), linkID)
+}
+
+// RecordStoryView mocks base method.
+func (m *MockStorage) RecordStoryView(storyID, viewerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordStoryView", storyID, viewerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordStoryView indicates an expected call of RecordStoryView.
+func (mr *MockStorageMockRecorder) RecordStoryView(storyID, viewerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordStoryView", reflect.TypeOf((*MockStorage)(nil).RecordStoryView), storyID, viewerID)
+}
+
+// ReleaseExpiryWarning mocks base method.
+func (m *MockStorage) ReleaseExpiryWarning(storyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiryWarning", storyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseExpiryWarning indicates an expected call of ReleaseExpiryWarning.
+func (mr *MockStorageMockRecorder) ReleaseExpiryWarning(storyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiryWarning", reflect.TypeOf((*MockStorage)(nil).ReleaseExpiryWarning), storyID)
+}
+
+// RemoveGroupMember mocks base method.
+func (m *MockStorage) RemoveGroupMember(groupID, actorID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupMember", groupID, actorID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember.
+func (mr *MockStorageMockRecorder) RemoveGroupMember(groupID, actorID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockStorage)(nil).RemoveGroupMember), groupID, actorID, userID)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockStorage) RemoveReaction(storyID, userID string) (types.ReactionType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", storyID, userID)
+	ret0, _ := ret[0].(types.ReactionType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockStorageMockRecorder) RemoveReaction(storyID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockStorage)(nil).RemoveReaction), storyID, userID)
+}
+
+// ReshareStory mocks base method.
+func (m *MockStorage) ReshareStory(userID, storyID string, reshare types.ReshareRequest) (string, types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReshareStory", userID, storyID, reshare)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(types.Story)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReshareStory indicates an expected call of ReshareStory.
+func (mr *MockStorageMockRecorder) ReshareStory(userID, storyID, reshare any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReshareStory", reflect.TypeOf((*MockStorage)(nil).ReshareStory), userID, storyID, reshare)
+}
+
+// RestoreArchivedStory mocks base method.
+func (m *MockStorage) RestoreArchivedStory(story types.ArchivedStory) (types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreArchivedStory", story)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreArchivedStory indicates an expected call of RestoreArchivedStory.
+func (mr *MockStorageMockRecorder) RestoreArchivedStory(story any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreArchivedStory", reflect.TypeOf((*MockStorage)(nil).RestoreArchivedStory), story)
+}
+
+// ReviewAbuseFlag mocks base method.
+func (m *MockStorage) ReviewAbuseFlag(adminID, flagID string) (users.AbuseFlag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewAbuseFlag", adminID, flagID)
+	ret0, _ := ret[0].(users.AbuseFlag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewAbuseFlag indicates an expected call of ReviewAbuseFlag.
+func (mr *MockStorageMockRecorder) ReviewAbuseFlag(adminID, flagID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewAbuseFlag", reflect.TypeOf((*MockStorage)(nil).ReviewAbuseFlag), adminID, flagID)
+}
+
+// RevokeAPIToken mocks base method.
+func (m *MockStorage) RevokeAPIToken(userID, tokenID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIToken", userID, tokenID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIToken indicates an expected call of RevokeAPIToken.
+func (mr *MockStorageMockRecorder) RevokeAPIToken(userID, tokenID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIToken", reflect.TypeOf((*MockStorage)(nil).RevokeAPIToken), userID, tokenID)
+}
+
+// RevokeShareLink mocks base method.
+func (m *MockStorage) RevokeShareLink(storyID, linkID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeShareLink", storyID, linkID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeShareLink indicates an expected call of RevokeShareLink.
+func (mr *MockStorageMockRecorder) RevokeShareLink(storyID, linkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeShareLink", reflect.TypeOf((*MockStorage)(nil).RevokeShareLink), storyID, linkID)
+}
+
+// SaveWeeklyRecap mocks base method.
+func (m *MockStorage) SaveWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWeeklyRecap", ctx, userID, weekStart)
+	ret0, _ := ret[0].(users.WeeklyRecap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWeeklyRecap indicates an expected call of SaveWeeklyRecap.
+func (mr *MockStorageMockRecorder) SaveWeeklyRecap(ctx, userID, weekStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWeeklyRecap", reflect.TypeOf((*MockStorage)(nil).SaveWeeklyRecap), ctx, userID, weekStart)
+}
+
+// SetAvatarURL mocks base method.
+func (m *MockStorage) SetAvatarURL(userID, avatarURL string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatarURL", userID, avatarURL)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAvatarURL indicates an expected call of SetAvatarURL.
+func (mr *MockStorageMockRecorder) SetAvatarURL(userID, avatarURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatarURL", reflect.TypeOf((*MockStorage)(nil).SetAvatarURL), userID, avatarURL)
+}
+
+// SetMediaUploadStatus mocks base method.
+func (m *MockStorage) SetMediaUploadStatus(objectKey, status string, size int64) (media.MediaUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMediaUploadStatus", objectKey, status, size)
+	ret0, _ := ret[0].(media.MediaUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMediaUploadStatus indicates an expected call of SetMediaUploadStatus.
+func (mr *MockStorageMockRecorder) SetMediaUploadStatus(objectKey, status, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMediaUploadStatus", reflect.TypeOf((*MockStorage)(nil).SetMediaUploadStatus), objectKey, status, size)
+}
+
+// SetNotificationSettings mocks base method.
+func (m *MockStorage) SetNotificationSettings(userID string, settings users.NotificationSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationSettings", userID, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationSettings indicates an expected call of SetNotificationSettings.
+func (mr *MockStorageMockRecorder) SetNotificationSettings(userID, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationSettings", reflect.TypeOf((*MockStorage)(nil).SetNotificationSettings), userID, settings)
+}
+
+// SetPrivacySettings mocks base method.
+func (m *MockStorage) SetPrivacySettings(userID string, settings users.PrivacySettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrivacySettings", userID, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrivacySettings indicates an expected call of SetPrivacySettings.
+func (mr *MockStorageMockRecorder) SetPrivacySettings(userID, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrivacySettings", reflect.TypeOf((*MockStorage)(nil).SetPrivacySettings), userID, settings)
+}
+
+// SetPublicKey mocks base method.
+func (m *MockStorage) SetPublicKey(userID string, key users.PublicKeyRequest) (users.PublicKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPublicKey", userID, key)
+	ret0, _ := ret[0].(users.PublicKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPublicKey indicates an expected call of SetPublicKey.
+func (mr *MockStorageMockRecorder) SetPublicKey(userID, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPublicKey", reflect.TypeOf((*MockStorage)(nil).SetPublicKey), userID, key)
+}
+
+// SetUserAdmin mocks base method.
+func (m *MockStorage) SetUserAdmin(userID string, isAdmin bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserAdmin", userID, isAdmin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserAdmin indicates an expected call of SetUserAdmin.
+func (mr *MockStorageMockRecorder) SetUserAdmin(userID, isAdmin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAdmin", reflect.TypeOf((*MockStorage)(nil).SetUserAdmin), userID, isAdmin)
+}
+
+// SoftDeleteExpiredStories mocks base method.
+func (m *MockStorage) SoftDeleteExpiredStories() ([]types.Story, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteExpiredStories")
+	ret0, _ := ret[0].([]types.Story)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteExpiredStories indicates an expected call of SoftDeleteExpiredStories.
+func (mr *MockStorageMockRecorder) SoftDeleteExpiredStories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteExpiredStories", reflect.TypeOf((*MockStorage)(nil).SoftDeleteExpiredStories))
+}
+
+// StreamDailyStoryMetrics mocks base method.
+func (m *MockStorage) StreamDailyStoryMetrics(ctx context.Context, userID string, from, to time.Time, fn func(users.DailyStoryMetrics) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamDailyStoryMetrics", ctx, userID, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamDailyStoryMetrics indicates an expected call of StreamDailyStoryMetrics.
+func (mr *MockStorageMockRecorder) StreamDailyStoryMetrics(ctx, userID, from, to, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamDailyStoryMetrics", reflect.TypeOf((*MockStorage)(nil).StreamDailyStoryMetrics), ctx, userID, from, to, fn)
+}
+
+// StreamStoriesForUser mocks base method.
+func (m *MockStorage) StreamStoriesForUser(ctx context.Context, userID string, fn func(types.Story) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamStoriesForUser", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamStoriesForUser indicates an expected call of StreamStoriesForUser.
+func (mr *MockStorageMockRecorder) StreamStoriesForUser(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamStoriesForUser", reflect.TypeOf((*MockStorage)(nil).StreamStoriesForUser), ctx, userID, fn)
+}
+
+// UnfollowUser mocks base method.
+func (m *MockStorage) UnfollowUser(followerID, followedID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfollowUser", followerID, followedID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnfollowUser indicates an expected call of UnfollowUser.
+func (mr *MockStorageMockRecorder) UnfollowUser(followerID, followedID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowUser", reflect.TypeOf((*MockStorage)(nil).UnfollowUser), followerID, followedID)
+}
+
+// UnfollowUsers mocks base method.
+func (m *MockStorage) UnfollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfollowUsers", followerID, followedIDs)
+	ret0, _ := ret[0].([]types.FollowResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfollowUsers indicates an expected call of UnfollowUsers.
+func (mr *MockStorageMockRecorder) UnfollowUsers(followerID, followedIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowUsers", reflect.TypeOf((*MockStorage)(nil).UnfollowUsers), followerID, followedIDs)
+}
+
+// UnhideStories mocks base method.
+func (m *MockStorage) UnhideStories(userID, authorID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnhideStories", userID, authorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnhideStories indicates an expected call of UnhideStories.
+func (mr *MockStorageMockRecorder) UnhideStories(userID, authorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnhideStories", reflect.TypeOf((*MockStorage)(nil).UnhideStories), userID, authorID)
+}
+
+// UpdateStory mocks base method.
+func (m *MockStorage) UpdateStory(storyID string, version int, update types.StoryUpdateRequest) (types.Story, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStory", storyID, version, update)
+	ret0, _ := ret[0].(types.Story)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateStory indicates an expected call of UpdateStory.
+func (mr *MockStorageMockRecorder) UpdateStory(storyID, version, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStory", reflect.TypeOf((*MockStorage)(nil).UpdateStory), storyID, version, update)
+}
+
+// UpdateStorySettings mocks base method.
+func (m *MockStorage) UpdateStorySettings(userID string, update types.StorySettingsUpdate) (types.StorySettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStorySettings", userID, update)
+	ret0, _ := ret[0].(types.StorySettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStorySettings indicates an expected call of UpdateStorySettings.
+func (mr *MockStorageMockRecorder) UpdateStorySettings(userID, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStorySettings", reflect.TypeOf((*MockStorage)(nil).UpdateStorySettings), userID, update)
+}
+
+// UseAPIToken mocks base method.
+func (m *MockStorage) UseAPIToken(tokenHash string) (users.APITokenOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAPIToken", tokenHash)
+	ret0, _ := ret[0].(users.APITokenOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseAPIToken indicates an expected call of UseAPIToken.
+func (mr *MockStorageMockRecorder) UseAPIToken(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAPIToken", reflect.TypeOf((*MockStorage)(nil).UseAPIToken), tokenHash)
+}
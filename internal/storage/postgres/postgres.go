@@ -1,50 +1,86 @@
 package postgres
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-
-	_ "github.com/lib/pq"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
 	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
 )
 
 type Postgres struct {
 	Db *sql.DB
+
+	// tx is the transaction of the unit of work this copy was handed by InTx;
+	// nil outside one
+	tx *sql.Tx
+
+	// Interactions decides whether authors' views of and reactions to their
+	// own stories are recorded and counted
+	Interactions config.Interactions
+
+	// FeedChangesLag is how far behind the database clock GetFeedChanges
+	// reads, so writes still committing are not passed over
+	FeedChangesLag time.Duration
 }
 
+var _ storage.Storage = (*Postgres)(nil)
+
 // GetDB returns the underlying database connection
 func (p *Postgres) GetDB() *sql.DB {
 	return p.Db
 }
 
 func NewPostgres(cfg *config.Config) (*Postgres, error) {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+	// Columns are TIMESTAMP without a time zone, so every session stores and
+	// reads them in UTC whatever the server's default is
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
 		cfg.PGSQL.Host, cfg.PGSQL.Port, cfg.PGSQL.User, cfg.PGSQL.Password, cfg.PGSQL.DBName, cfg.PGSQL.SSLMode)
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatal(err)
 		return nil, err
 	}
 
-	err = db.Ping()
-	if err != nil {
-		log.Fatal(err)
+	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	log.Println("Connected to Postgres database")
 
-	// Create tables if they don't exist
-	pg := &Postgres{Db: db}
-	err = pg.CreateTables()
-	if err != nil {
-		log.Fatal("Failed to create tables:", err)
+	// Create tables if they don't exist, unless migrations are run separately
+	pg := &Postgres{Db: db, Interactions: cfg.Interactions, FeedChangesLag: time.Duration(cfg.Feed.ChangesLag) * time.Second}
+	if !cfg.PGSQL.AutoMigrate {
+		return pg, nil
+	}
+	if err := pg.CreateTables(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
-	return &Postgres{Db: db}, nil
+	return pg, nil
+}
+
+// Close closes the database connection pool
+func (p *Postgres) Close() error {
+	return p.Db.Close()
 }
 
 func (p *Postgres) CreateTables() error {
@@ -63,7 +99,7 @@ func (p *Postgres) CreateTables() error {
 			author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
 			text TEXT,
 			media_key VARCHAR(255),
-			visibility VARCHAR(50) NOT NULL CHECK (visibility IN ('FRIENDS', 'PRIVATE', 'PUBLIC')),
+			visibility VARCHAR(50) NOT NULL CHECK (visibility IN ('FOLLOWERS', 'FRIENDS', 'GROUP', 'PRIVATE', 'PUBLIC')),
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP DEFAULT (CURRENT_TIMESTAMP + INTERVAL '24 hours'),
 			deleted_at TIMESTAMP NULL
@@ -95,6 +131,283 @@ func (p *Postgres) CreateTables() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY (follower_id, followed_id)
 		);`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS expiry_warned_at TIMESTAMP NULL;`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS link_url TEXT NULL;`,
+		`CREATE TABLE IF NOT EXISTS story_link_clicks (
+			id SERIAL PRIMARY KEY,
+			story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			clicked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS story_highlights (
+			story_id INTEGER PRIMARY KEY REFERENCES stories(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION NULL;`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION NULL;`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS place_name VARCHAR(255) NULL;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NULL;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_view_receipts BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_from_discovery BOOLEAN NOT NULL DEFAULT FALSE;`,
+		// Contact discovery matches users.ContactHash of each email; hash
+		// the emails of users who signed up before it existed
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_hash CHAR(64) NULL;`,
+		`UPDATE users SET email_hash = encode(sha256(convert_to(lower(trim(email)), 'UTF8')), 'hex') WHERE email_hash IS NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_users_tenant_email_hash ON users (tenant_id, email_hash);`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(32) NOT NULL DEFAULT 'default';`,
+		// Emails are unique within a tenant rather than across the deployment
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email ON users (tenant_id, email);`,
+		`CREATE TABLE IF NOT EXISTS notification_settings (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			quiet_hours_start VARCHAR(5) NOT NULL DEFAULT '',
+			quiet_hours_end VARCHAR(5) NOT NULL DEFAULT '',
+			timezone VARCHAR(64) NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS queued_notifications (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			event_type VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			queued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE notification_settings ADD COLUMN IF NOT EXISTS email_new_followers BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE notification_settings ADD COLUMN IF NOT EXISTS email_weekly_stats BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE notification_settings ADD COLUMN IF NOT EXISTS followers_emailed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;`,
+		`ALTER TABLE notification_settings ADD COLUMN IF NOT EXISTS stats_emailed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;`,
+		`CREATE TABLE IF NOT EXISTS story_share_links (
+			id SERIAL PRIMARY KEY,
+			story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+			require_login BOOLEAN NOT NULL DEFAULT FALSE,
+			view_count INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_story_share_links_story ON story_share_links (story_id);`,
+		`CREATE TABLE IF NOT EXISTS media_uploads (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			tenant_id VARCHAR(32) NOT NULL,
+			object_key TEXT NOT NULL UNIQUE,
+			content_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			confirmed_at TIMESTAMP NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_media_uploads_tenant ON media_uploads (tenant_id);`,
+		`ALTER TABLE media_uploads ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'initiated';`,
+		`UPDATE media_uploads SET status = 'confirmed' WHERE confirmed_at IS NOT NULL AND status = 'initiated';`,
+		`CREATE INDEX IF NOT EXISTS idx_media_uploads_user ON media_uploads (user_id, object_key);`,
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			scopes TEXT[] NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP NULL,
+			expires_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens (user_id);`,
+		`CREATE TABLE IF NOT EXISTS user_public_keys (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			algorithm VARCHAR(64) NOT NULL,
+			public_key TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`CREATE TABLE IF NOT EXISTS story_envelopes (
+			story_id INTEGER PRIMARY KEY REFERENCES stories(id) ON DELETE CASCADE,
+			algorithm VARCHAR(64) NOT NULL,
+			ciphertext TEXT NOT NULL,
+			nonce TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS story_recipient_keys (
+			story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			wrapped_key TEXT NOT NULL,
+			PRIMARY KEY (story_id, user_id)
+		);`,
+		// The audit trail outlives the accounts it mentions, so it has no
+		// foreign keys
+		`CREATE TABLE IF NOT EXISTS impersonation_events (
+			id SERIAL PRIMARY KEY,
+			token_id VARCHAR(64) NOT NULL,
+			admin_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			tenant_id VARCHAR(64) NOT NULL,
+			action VARCHAR(16) NOT NULL CHECK (action IN ('issued', 'used')),
+			reason TEXT NOT NULL DEFAULT '',
+			method VARCHAR(10) NOT NULL DEFAULT '',
+			path TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_impersonation_events_tenant_created ON impersonation_events (tenant_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS abuse_flags (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			tenant_id VARCHAR(64) NOT NULL,
+			action VARCHAR(16) NOT NULL,
+			count INTEGER NOT NULL,
+			throttled_until TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reviewed_at TIMESTAMP NULL,
+			reviewed_by INTEGER NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_abuse_flags_tenant_created ON abuse_flags (tenant_id, created_at);`,
+		// Where each archived story went, to restore it; the story and its
+		// author may both be gone, so there are no foreign keys
+		`CREATE TABLE IF NOT EXISTS archived_stories (
+			story_id INTEGER PRIMARY KEY,
+			tenant_id VARCHAR(32) NOT NULL,
+			author_id INTEGER NOT NULL,
+			object_key TEXT NOT NULL,
+			archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		// Stories that appeared in a user's tray, once per user; reach as
+		// opposed to the opens in story_views
+		`CREATE TABLE IF NOT EXISTS story_impressions (
+			story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (story_id, user_id)
+		);`,
+		// Reshares point at the original story; archiving the original
+		// leaves them without attribution
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS parent_story_id INTEGER NULL REFERENCES stories(id) ON DELETE SET NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_stories_parent ON stories (parent_story_id) WHERE parent_story_id IS NOT NULL;`,
+		// Groups members share stories in; GROUP stories name theirs
+		`CREATE TABLE IF NOT EXISTS story_groups (
+			id SERIAL PRIMARY KEY,
+			tenant_id VARCHAR(32) NOT NULL,
+			owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS story_group_members (
+			group_id INTEGER NOT NULL REFERENCES story_groups(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_story_group_members_user ON story_group_members (user_id);`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS group_id INTEGER NULL REFERENCES story_groups(id) ON DELETE CASCADE;`,
+		`CREATE INDEX IF NOT EXISTS idx_stories_group_created_id ON stories (group_id, created_at DESC, id DESC) WHERE group_id IS NOT NULL;`,
+		`DROP INDEX IF EXISTS idx_stories_group;`,
+		// Authors each user hid from their feed without unfollowing them
+		`CREATE TABLE IF NOT EXISTS hidden_authors (
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			hidden_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, author_id)
+		);`,
+		// Bumped by every edit so concurrent edits cannot overwrite each other
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`,
+		// First time each user was served a variant of an experiment
+		`CREATE TABLE IF NOT EXISTS experiment_exposures (
+			experiment VARCHAR(64) NOT NULL,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			variant VARCHAR(32) NOT NULL,
+			exposed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (experiment, user_id)
+		);`,
+		// Defaults for stories posted without a visibility, audience or lifetime
+		`CREATE TABLE IF NOT EXISTS story_settings (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			default_visibility VARCHAR(50) NOT NULL DEFAULT '',
+			default_audience INTEGER[] NOT NULL DEFAULT '{}',
+			default_expires_in_hours INTEGER NOT NULL DEFAULT 0
+		);`,
+		// Background work for internal/jobs; payloads are JSON per kind
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id BIGSERIAL PRIMARY KEY,
+			kind VARCHAR(64) NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			status VARCHAR(16) NOT NULL DEFAULT 'queued',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL,
+			last_error TEXT,
+			scheduled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			locked_until TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (kind, scheduled_at) WHERE status IN ('queued', 'running');`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_finished ON jobs (finished_at) WHERE finished_at IS NOT NULL;`,
+		// Jobs enqueued once per key, such as the weekly recap of each week
+		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS unique_key VARCHAR(128) NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique ON jobs (kind, unique_key) WHERE unique_key IS NOT NULL;`,
+		// One recap per creator and week, kept so later recaps can compare
+		// follower counts
+		`CREATE TABLE IF NOT EXISTS weekly_recaps (
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			week_start DATE NOT NULL,
+			posted INTEGER NOT NULL,
+			views INTEGER NOT NULL,
+			reach INTEGER NOT NULL,
+			followers INTEGER NOT NULL,
+			follower_delta INTEGER NOT NULL,
+			top_story_id INTEGER NULL,
+			top_story_views INTEGER NOT NULL DEFAULT 0,
+			top_story_reactions INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP NULL,
+			PRIMARY KEY (user_id, week_start)
+		);`,
+		`ALTER TABLE notification_settings ADD COLUMN IF NOT EXISTS email_weekly_recap BOOLEAN NOT NULL DEFAULT FALSE;`,
+		// View-once and replay-limited stories: how many times each viewer
+		// may open the story, and how many times each has
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS max_views_per_user INTEGER NULL CHECK (max_views_per_user > 0);`,
+		`ALTER TABLE story_views ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 1;`,
+		// Screenshots and screen recordings clients reported, once per user
+		// and kind, for insights
+		`CREATE TABLE IF NOT EXISTS story_screenshots (
+			story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind VARCHAR(32) NOT NULL,
+			captured_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (story_id, user_id, kind)
+		);`,
+		// What authors let viewers do with each story
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS allow_reshare BOOLEAN NOT NULL DEFAULT TRUE;`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS allow_reply BOOLEAN NOT NULL DEFAULT TRUE;`,
+		`ALTER TABLE stories ADD COLUMN IF NOT EXISTS allow_screenshot_notification BOOLEAN NOT NULL DEFAULT TRUE;`,
+		// Allow FOLLOWERS and GROUP on tables created before they existed
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'stories_visibility_check'
+				AND pg_get_constraintdef(oid) LIKE '%GROUP%') THEN
+				ALTER TABLE stories DROP CONSTRAINT IF EXISTS stories_visibility_check;
+				ALTER TABLE stories ADD CONSTRAINT stories_visibility_check
+					CHECK (visibility IN ('FOLLOWERS', 'FRIENDS', 'GROUP', 'PRIVATE', 'PUBLIC'));
+			END IF;
+		END $$;`,
+		// RecordStoryView relies on one view row per viewer; drop duplicates
+		// recorded before the constraint existed, then enforce it
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_story_views_story_viewer') THEN
+				DELETE FROM story_views a USING story_views b
+				WHERE a.story_id = b.story_id AND a.viewer_id = b.viewer_id AND a.id > b.id;
+				CREATE UNIQUE INDEX idx_story_views_story_viewer ON story_views (story_id, viewer_id);
+			END IF;
+		END $$;`,
+		// AddReaction upserts one reaction per user and story; keep the latest
+		// of any duplicates left by the old delete-then-insert, then enforce it
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_reactions_story_user') THEN
+				DELETE FROM reactions a USING reactions b
+				WHERE a.story_id = b.story_id AND a.user_id = b.user_id AND a.id < b.id;
+				CREATE UNIQUE INDEX idx_reactions_story_user ON reactions (story_id, user_id);
+			END IF;
+		END $$;`,
 	}
 
 	for _, q := range queries {
@@ -103,8 +416,14 @@ func (p *Postgres) CreateTables() error {
 		}
 	}
 
+	// Enable extensions used for geo queries
+	err := p.CreateExtensions()
+	if err != nil {
+		log.Printf("Warning: Failed to create extensions, nearby stories will be unavailable: %v", err)
+	}
+
 	// Create indexes for better performance
-	err := p.CreateIndexes()
+	err = p.CreateIndexes()
 	if err != nil {
 		log.Printf("Warning: Failed to create some indexes: %v", err)
 		// Don't return error as indexes are not critical for basic functionality
@@ -113,20 +432,36 @@ func (p *Postgres) CreateTables() error {
 	return nil
 }
 
+// CreateExtensions enables the Postgres extensions used for location queries
+func (p *Postgres) CreateExtensions() error {
+	extensions := []string{
+		`CREATE EXTENSION IF NOT EXISTS cube`,
+		`CREATE EXTENSION IF NOT EXISTS earthdistance`,
+	}
+
+	for _, q := range extensions {
+		if _, err := p.Db.Exec(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // CreateIndexes creates database indexes for better query performance
 func (p *Postgres) CreateIndexes() error {
 	indexes := []string{
-		// Index on stories(author_id, created_at DESC) for efficient author story queries
-		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_author_created 
-		 ON stories (author_id, created_at DESC)`,
+		// Index on stories(author_id, created_at DESC, id DESC) for efficient author story queries
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_author_created_id 
+		 ON stories (author_id, created_at DESC, id DESC)`,
 
 		// Index on stories(expires_at) for cleanup operations
 		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_expires_at 
 		 ON stories (expires_at)`,
 
-		// Partial index on stories where deleted_at is null (active stories only)
-		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_active 
-		 ON stories (id, created_at DESC) WHERE deleted_at IS NULL`,
+		// Partial index on active stories in feed order
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_active_created_id 
+		 ON stories (created_at DESC, id DESC) WHERE deleted_at IS NULL`,
 
 		// Index on story_views(story_id) for view count queries
 		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_story_views_story_id 
@@ -140,13 +475,13 @@ func (p *Postgres) CreateIndexes() error {
 		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_follows_follower_id 
 		 ON follows (follower_id)`,
 
-		// Additional composite index for story visibility and created_at
-		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_visibility_created 
-		 ON stories (visibility, created_at DESC) WHERE deleted_at IS NULL`,
+		// Additional composite index for story visibility in feed order
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_visibility_created_id 
+		 ON stories (visibility, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
 
 		// Index for user story queries with visibility
-		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_author_visibility_created 
-		 ON stories (author_id, visibility, created_at DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_author_visibility_created_id 
+		 ON stories (author_id, visibility, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
 
 		// Index for story audience queries
 		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_story_audience_user_id 
@@ -159,6 +494,34 @@ func (p *Postgres) CreateIndexes() error {
 		// Index for follows by followed_id (reverse lookup)
 		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_follows_followed_id 
 		 ON follows (followed_id)`,
+
+		// Partial index for finding stories that still need an expiry warning
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_expiry_warning 
+		 ON stories (expires_at) WHERE expiry_warned_at IS NULL AND deleted_at IS NULL`,
+
+		// Index on story_link_clicks(story_id) for click count queries
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_story_link_clicks_story_id 
+		 ON story_link_clicks (story_id)`,
+
+		// Geo index on public located stories for nearby queries (requires earthdistance)
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_location 
+		 ON stories USING gist (ll_to_earth(latitude, longitude))
+		 WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND visibility = 'PUBLIC' AND deleted_at IS NULL`,
+
+		// Index for a tenant's public stories
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_stories_tenant_created_id 
+		 ON stories (tenant_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+
+		// Index for claiming a user's queued notifications
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_queued_notifications_user_id 
+		 ON queued_notifications (user_id)`,
+
+		// Indexes replaced by the ones above, which break created_at ties by id
+		`DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_created`,
+		`DROP INDEX CONCURRENTLY IF EXISTS idx_stories_active`,
+		`DROP INDEX CONCURRENTLY IF EXISTS idx_stories_visibility_created`,
+		`DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_visibility_created`,
+		`DROP INDEX CONCURRENTLY IF EXISTS idx_stories_tenant_created`,
 	}
 
 	for _, indexQuery := range indexes {
@@ -175,17 +538,22 @@ func (p *Postgres) CreateIndexes() error {
 // DropIndexes drops all custom indexes (useful for maintenance)
 func (p *Postgres) DropIndexes() error {
 	indexes := []string{
-		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_created",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_created_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_expires_at",
-		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_active",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_active_created_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_story_views_story_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_reactions_story_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_follows_follower_id",
-		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_visibility_created",
-		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_visibility_created",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_visibility_created_id",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_author_visibility_created_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_story_audience_user_id",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_reactions_user_story",
 		"DROP INDEX CONCURRENTLY IF EXISTS idx_follows_followed_id",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_expiry_warning",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_story_link_clicks_story_id",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_location",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_stories_tenant_created_id",
+		"DROP INDEX CONCURRENTLY IF EXISTS idx_queued_notifications_user_id",
 	}
 
 	for _, dropQuery := range indexes {
@@ -204,7 +572,7 @@ func (p *Postgres) CheckIndexes() (map[string]bool, error) {
 	query := `
 	SELECT indexname 
 	FROM pg_indexes 
-	WHERE tablename IN ('stories', 'story_views', 'reactions', 'follows', 'story_audience')
+	WHERE tablename IN ('stories', 'story_views', 'reactions', 'follows', 'story_audience', 'story_link_clicks', 'queued_notifications')
 	AND indexname LIKE 'idx_%'
 	`
 
@@ -215,17 +583,22 @@ func (p *Postgres) CheckIndexes() (map[string]bool, error) {
 	defer rows.Close()
 
 	indexes := map[string]bool{
-		"idx_stories_author_created":            false,
-		"idx_stories_expires_at":                false,
-		"idx_stories_active":                    false,
-		"idx_story_views_story_id":              false,
-		"idx_reactions_story_id":                false,
-		"idx_follows_follower_id":               false,
-		"idx_stories_visibility_created":        false,
-		"idx_stories_author_visibility_created": false,
-		"idx_story_audience_user_id":            false,
-		"idx_reactions_user_story":              false,
-		"idx_follows_followed_id":               false,
+		"idx_stories_author_created_id":            false,
+		"idx_stories_expires_at":                   false,
+		"idx_stories_active_created_id":            false,
+		"idx_story_views_story_id":                 false,
+		"idx_reactions_story_id":                   false,
+		"idx_follows_follower_id":                  false,
+		"idx_stories_visibility_created_id":        false,
+		"idx_stories_author_visibility_created_id": false,
+		"idx_story_audience_user_id":               false,
+		"idx_reactions_user_story":                 false,
+		"idx_follows_followed_id":                  false,
+		"idx_stories_expiry_warning":               false,
+		"idx_story_link_clicks_story_id":           false,
+		"idx_stories_location":                     false,
+		"idx_stories_tenant_created_id":            false,
+		"idx_queued_notifications_user_id":         false,
 	}
 
 	for rows.Next() {
@@ -242,59 +615,319 @@ func (p *Postgres) CheckIndexes() (map[string]bool, error) {
 	return indexes, nil
 }
 
-func (p *Postgres) CreateStory(authorID, text, mediaKey string, visibility types.Visibility, audienceUserIDs []string) (string, error) {
+func (p *Postgres) CreateStory(authorID string, story types.StoryPostRequest) (string, error) {
+	ctx := context.TODO()
 	var storyID int
-	query := `
-	INSERT INTO stories (author_id, text, media_key, visibility)
-	VALUES ($1, $2, $3, $4)
-	RETURNING id
-	`
-	queryAudience := `
-	INSERT INTO story_audience (story_id, user_id)
-	VALUES ($1, $2)
-	`
 
-	// Start a transaction
-	tx, err := p.Db.Begin()
+	insertStory := StatementBuilder.
+		Insert("stories").
+		Columns("author_id", "tenant_id", "text", "media_key", "visibility", "link_url", "latitude", "longitude", "place_name", "encrypted", "group_id", "max_views_per_user",
+			"allow_reshare", "allow_reply", "allow_screenshot_notification", "expires_at").
+		Values(authorID, tenantOf(authorID), story.Text, story.MediaKey, story.Visibility, sq.Expr("NULLIF(?, '')", story.LinkURL),
+			story.Latitude, story.Longitude, sq.Expr("NULLIF(?, '')", story.PlaceName), story.Encrypted != nil, sq.Expr("NULLIF(?, '')::integer", story.GroupID),
+			sq.Expr("NULLIF(?::integer, 0)", story.MaxViewsPerUser),
+			allowed(story.AllowReshare), allowed(story.AllowReply), allowed(story.AllowScreenshotNotification),
+			sq.Expr("CURRENT_TIMESTAMP + make_interval(hours => ?::integer)", cmp.Or(story.ExpiresInHours, types.DefaultStoryLifetimeHours))).
+		Suffix("RETURNING id")
+
+	err := p.transact(ctx, func(tx queryer) error {
+		// Media must be an upload the author confirmed, locked so
+		// reconciliation cannot remove its record while the story is inserted
+		if story.MediaKey != "" {
+			if err := checkStoryMedia(ctx, tx, authorID, story.MediaKey); err != nil {
+				return err
+			}
+		}
+
+		// Group stories go into one of the author's groups, locked so the
+		// author cannot leave it while the story is inserted
+		if story.GroupID != "" {
+			if err := checkGroupMember(ctx, tx, story.GroupID, authorID); err != nil {
+				return err
+			}
+		}
+
+		// Insert the story
+		if err := queryRow(ctx, tx, insertStory, &storyID); err != nil {
+			return err
+		}
+
+		// Insert audience user IDs if visibility is PRIVATE
+		if story.Visibility == types.VisibilityPrivate && len(story.AudienceUserIDs) > 0 {
+			insertAudience := StatementBuilder.Insert("story_audience").Columns("story_id", "user_id")
+			for _, userID := range story.AudienceUserIDs {
+				insertAudience = insertAudience.Values(storyID, userID)
+			}
+
+			if _, err := exec(ctx, tx, insertAudience); err != nil {
+				return err
+			}
+		}
+
+		// Store the envelope of an end-to-end encrypted story with its keys
+		if envelope := story.Encrypted; envelope != nil {
+			insertEnvelope := StatementBuilder.
+				Insert("story_envelopes").
+				Columns("story_id", "algorithm", "ciphertext", "nonce").
+				Values(storyID, envelope.Algorithm, envelope.Ciphertext, envelope.Nonce)
+
+			if _, err := exec(ctx, tx, insertEnvelope); err != nil {
+				return err
+			}
+
+			insertKeys := StatementBuilder.Insert("story_recipient_keys").Columns("story_id", "user_id", "wrapped_key")
+			for _, key := range envelope.RecipientKeys {
+				insertKeys = insertKeys.Values(storyID, key.UserID, key.WrappedKey)
+			}
+
+			if _, err := exec(ctx, tx, insertKeys); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
-	defer func() {
+
+	return fmt.Sprintf("%d", storyID), nil
+}
+
+// ReshareStory shares storyID as a new story by userID with the original's
+// media and the reshare's caption and audience. Resharing a reshare shares the
+// original it points to, so reshares never chain. Only active public stories
+// by someone else can be reshared, once per active reshare, and not those
+// limiting how often each viewer may open them or whose author turned off
+// resharing.
+func (p *Postgres) ReshareStory(userID, storyID string, reshare types.ReshareRequest) (reshareID string, original types.Story, err error) {
+	ctx := context.TODO()
+
+	err = p.transact(ctx, func(tx queryer) error {
+		// Lock the original so it cannot be deleted while it is reshared
+		var err error
+		original, err = queryStory(ctx, tx, StatementBuilder.
+			Select(StoryColumns("s")...).
+			From("stories s").
+			Where("s.id = COALESCE((SELECT parent_story_id FROM stories WHERE id = ?::integer), ?::integer)", storyID, storyID).
+			Suffix("FOR SHARE"))
 		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+			return err
+		}
+
+		if original.AuthorID == userID {
+			return storage.ErrSelfReshare
+		}
+
+		var active bool
+		err = queryRow(ctx, tx, StatementBuilder.
+			Select("expires_at > NOW() AND deleted_at IS NULL").
+			From("stories").
+			Where("id = ?::integer", original.ID), &active)
+		if err != nil {
+			return err
+		}
+		if !active || original.Visibility != types.VisibilityPublic || original.Encrypted || original.MaxViewsPerUser > 0 {
+			return storage.ErrReshareNotPublic
+		}
+		if !original.AllowReshare {
+			return storage.ErrReshareNotAllowed
+		}
+
+		var reshared bool
+		err = queryRow(ctx, tx, StatementBuilder.
+			Select().
+			Column(sq.Expr("EXISTS (?)", sq.Select("1").From("stories").
+				Where("author_id = ?::integer AND parent_story_id = ?::integer", userID, original.ID).
+				Where("deleted_at IS NULL AND expires_at > NOW()"))), &reshared)
+		if err != nil {
+			return err
+		}
+		if reshared {
+			return storage.ErrAlreadyReshared
+		}
+
+		if reshare.GroupID != "" {
+			if err := checkGroupMember(ctx, tx, reshare.GroupID, userID); err != nil {
+				return err
+			}
+		}
+
+		var id int
+		err = queryRow(ctx, tx, StatementBuilder.
+			Insert("stories").
+			Columns("author_id", "tenant_id", "text", "media_key", "visibility", "parent_story_id", "group_id").
+			Values(userID, tenantOf(userID), reshare.Text, original.MediaKey, reshare.Visibility, original.ID, sq.Expr("NULLIF(?, '')::integer", reshare.GroupID)).
+			Suffix("RETURNING id"), &id)
+		if err != nil {
+			return err
+		}
+		reshareID = fmt.Sprintf("%d", id)
+
+		if reshare.Visibility == types.VisibilityPrivate && len(reshare.AudienceUserIDs) > 0 {
+			insertAudience := StatementBuilder.Insert("story_audience").Columns("story_id", "user_id")
+			for _, audienceID := range reshare.AudienceUserIDs {
+				insertAudience = insertAudience.Values(id, audienceID)
+			}
+
+			if _, err := exec(ctx, tx, insertAudience); err != nil {
+				return err
+			}
 		}
-	}()
 
-	// Insert the story
-	err = tx.QueryRow(query, authorID, text, mediaKey, visibility).Scan(&storyID)
+		return nil
+	})
 	if err != nil {
-		return "", err
+		return "", original, err
 	}
 
-	// Insert audience user IDs if visibility is PRIVATE or FRIENDS
-	if visibility == types.VisibilityPrivate || visibility == types.VisibilityFriends {
-		for _, userID := range audienceUserIDs {
-			_, err := tx.Exec(queryAudience, storyID, userID)
+	return reshareID, original, nil
+}
+
+// UpdateStory applies update to an active story if it is still at version and
+// bumps its version. A new visibility replaces the story's audience and group,
+// and the users who could see the story before and no longer can are
+// returned with it. If the story was edited since version, it is returned as
+// it is now with storage.ErrVersionConflict.
+func (p *Postgres) UpdateStory(storyID string, version int, update types.StoryUpdateRequest) (story types.Story, revoked []string, err error) {
+	ctx := context.TODO()
+
+	err = p.transact(ctx, func(tx queryer) error {
+		// Lock the story so concurrent edits are checked against each other's
+		// versions one at a time
+		var err error
+		story, err = queryStory(ctx, tx, selectStories().
+			Where(sq.Eq{"s.id": storyID}).
+			Where("s.expires_at > NOW()").
+			Suffix("FOR UPDATE"))
+		if err != nil {
+			return err
+		}
+		if story.Version != version {
+			return storage.ErrVersionConflict
+		}
+
+		if update.Visibility == types.VisibilityGroup {
+			if err := checkGroupMember(ctx, tx, update.GroupID, story.AuthorID); err != nil {
+				return err
+			}
+		}
+
+		// Who may see the story now, to tell who the new visibility shuts
+		// out; a public story shuts out no one
+		var viewers []string
+		if update.Visibility != "" && update.Visibility != types.VisibilityPublic {
+			viewers, err = queryStrings(ctx, tx, storyViewers(storyID))
+			if err != nil {
+				return err
+			}
+		}
+
+		query := StatementBuilder.
+			Update("stories s").
+			Set("version", sq.Expr("s.version + 1")).
+			Where("s.id = ?::integer", storyID).
+			Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+		if update.Text != nil {
+			query = query.Set("text", *update.Text)
+		}
+		if update.LinkURL != nil {
+			query = query.Set("link_url", sq.Expr("NULLIF(?, '')", *update.LinkURL))
+		}
+		if update.Visibility != "" {
+			query = query.
+				Set("visibility", update.Visibility).
+				Set("group_id", sq.Expr("NULLIF(?, '')::integer", update.GroupID))
+		}
+
+		story, err = queryStory(ctx, tx, query)
+		if err != nil {
+			return err
+		}
+
+		if update.Visibility != "" {
+			_, err = exec(ctx, tx, StatementBuilder.
+				Delete("story_audience").
+				Where("story_id = ?::integer", storyID))
+			if err != nil {
+				return err
+			}
+
+			if update.Visibility == types.VisibilityPrivate && len(update.AudienceUserIDs) > 0 {
+				insertAudience := StatementBuilder.Insert("story_audience").Columns("story_id", "user_id")
+				for _, userID := range update.AudienceUserIDs {
+					insertAudience = insertAudience.Values(storyID, userID)
+				}
+
+				if _, err := exec(ctx, tx, insertAudience); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(viewers) > 0 {
+			remaining, err := queryStrings(ctx, tx, storyViewers(storyID).Where("u.id = ANY(?::integer[])", pq.Array(viewers)))
 			if err != nil {
-				return "", err
+				return err
 			}
+			revoked = slices.DeleteFunc(viewers, func(userID string) bool {
+				_, found := slices.BinarySearchFunc(remaining, userID, compareIDs)
+				return found
+			})
 		}
+
+		return nil
+	})
+	return story, revoked, err
+}
+
+// checkGroupMember returns storage.ErrNotGroupMember unless userID belongs to
+// the group, locking the membership until the transaction ends
+func checkGroupMember(ctx context.Context, db queryer, groupID, userID string) error {
+	var member int
+	err := queryRow(ctx, db, StatementBuilder.
+		Select("1").
+		From("story_group_members").
+		Where("group_id = ?::integer AND user_id = ?::integer", groupID, userID).
+		Suffix("FOR SHARE"), &member)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNotGroupMember
 	}
+	return err
+}
 
-	return fmt.Sprintf("%d", storyID), nil
+// checkStoryMedia returns storage.ErrMediaNotOwned unless objectKey is an
+// upload the author started, and storage.ErrMediaNotConfirmed unless they
+// confirmed it
+func checkStoryMedia(ctx context.Context, db queryer, authorID, objectKey string) error {
+	var status string
+	err := queryRow(ctx, db, StatementBuilder.
+		Select("status").
+		From("media_uploads").
+		Where("user_id = ?::integer", authorID).
+		Where(sq.Eq{"object_key": objectKey}).
+		Suffix("FOR SHARE"), &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrMediaNotOwned
+	}
+	if err != nil {
+		return err
+	}
+	if status != media.UploadConfirmed {
+		return storage.ErrMediaNotConfirmed
+	}
+	return nil
 }
 
-func (p *Postgres) CreateUser(email, password string) (string, error) {
+func (p *Postgres) CreateUser(tenantID, email, password string) (string, error) {
 	var userID int
-	query := `
-	INSERT INTO users (email, password)
-	VALUES ($1, $2)
-	RETURNING id
-	`
+	query := StatementBuilder.
+		Insert("users").
+		Columns("tenant_id", "email", "email_hash", "password").
+		Values(tenantID, email, users.ContactHash(email), password).
+		Suffix("RETURNING id")
 
-	err := p.Db.QueryRow(query, email, password).Scan(&userID)
+	err := queryRow(context.TODO(), p.db(), query, &userID)
 	if err != nil {
 		return "", err
 	}
@@ -302,14 +935,15 @@ func (p *Postgres) CreateUser(email, password string) (string, error) {
 	return fmt.Sprintf("%d", userID), nil
 }
 
-func (p *Postgres) GetUserByEmail(email string) (string, string, error) {
+func (p *Postgres) GetUserByEmail(tenantID, email string) (string, string, error) {
 	var userID int
 	var hashedPassword string
-	query := `
-	SELECT id, password FROM users WHERE email = $1
-	`
+	query := StatementBuilder.
+		Select("id", "password").
+		From("users").
+		Where(sq.Eq{"tenant_id": tenantID, "email": email})
 
-	err := p.Db.QueryRow(query, email).Scan(&userID, &hashedPassword)
+	err := queryRow(context.TODO(), p.db(), query, &userID, &hashedPassword)
 	if err != nil {
 		return "", "", err
 	}
@@ -317,332 +951,2738 @@ func (p *Postgres) GetUserByEmail(email string) (string, string, error) {
 	return fmt.Sprintf("%d", userID), hashedPassword, nil
 }
 
-func (p *Postgres) GetAllPublicStories() ([]types.Story, error) {
-	query := `
-	SELECT id, author_id, text, media_key, visibility, created_at, expires_at, COALESCE(deleted_at::TEXT, '') as deleted_at
-	FROM stories
-	WHERE visibility = 'PUBLIC' AND deleted_at IS NULL
-	ORDER BY created_at DESC
-	`
-	rows, err := p.Db.Query(query)
+// GetUserByID returns the user's account details
+func (p *Postgres) GetUserByID(userID string) (users.User, error) {
+	var user users.User
+	query := StatementBuilder.
+		Select("id", "tenant_id", "email", "password", "created_at", "is_admin").
+		From("users").
+		Where(sq.Eq{"id": userID})
+
+	err := queryRow(context.TODO(), p.db(), query, &user.ID, &user.TenantID, &user.Email, &user.Password, Timestamp(&user.CreatedAt), &user.IsAdmin)
 	if err != nil {
-		return nil, err
+		return users.User{}, err
 	}
-	defer rows.Close()
 
-	var stories []types.Story
-	for rows.Next() {
-		var s types.Story
-		err := rows.Scan(&s.ID, &s.AuthorID, &s.Text, &s.MediaKey, &s.Visibility, &s.CreatedAt, &s.ExpiresAt, &s.DeletedAt)
-		if err != nil {
-			return nil, err
-		}
-		stories = append(stories, s)
+	return user, nil
+}
+
+// GetUserProfile returns the user's account with their follow and active
+// story counts, or sql.ErrNoRows for unknown users
+func (p *Postgres) GetUserProfile(userID string) (users.Profile, error) {
+	var profile users.Profile
+	query := StatementBuilder.
+		Select("u.id", "u.tenant_id", "u.email", "u.created_at", "u.is_admin", "COALESCE(u.avatar_url, '')").
+		Column("(SELECT COUNT(*) FROM follows f WHERE f.followed_id = u.id)").
+		Column("(SELECT COUNT(*) FROM follows f WHERE f.follower_id = u.id)").
+		Column("(SELECT COUNT(*) FROM stories s WHERE s.author_id = u.id AND s.deleted_at IS NULL)").
+		From("users u").
+		Where(sq.Eq{"u.id": userID})
+
+	err := queryRow(context.TODO(), p.db(), query, &profile.ID, &profile.TenantID, &profile.Email, Timestamp(&profile.CreatedAt), &profile.IsAdmin, &profile.AvatarURL,
+		&profile.Followers, &profile.Following, &profile.Stories)
+	if err != nil {
+		return users.Profile{}, err
 	}
-	return stories, nil
+
+	return profile, nil
 }
 
-func (p *Postgres) GetStoriesForUser(userID string) ([]types.Story, error) {
-	query := `
-	SELECT DISTINCT s.id, s.author_id, s.text, s.media_key, s.visibility, s.created_at, s.expires_at, COALESCE(s.deleted_at::TEXT, '') as deleted_at
-	FROM stories s
-	LEFT JOIN story_audience sa ON s.id = sa.story_id
-	LEFT JOIN follows f ON s.author_id = f.followed_id
-	WHERE 
-		s.deleted_at IS NULL AND (
-			s.visibility = 'PUBLIC'
-			OR (s.visibility = 'FRIENDS' AND f.follower_id = $1::integer)
-			OR (s.visibility = 'PRIVATE' AND sa.user_id = $1)
-			OR s.author_id = $1::integer
-		)
-	ORDER BY s.created_at DESC
-	`
-	rows, err := p.Db.Query(query, userID)
+// GetPublicProfile returns userID's profile as viewerID sees it, or
+// storage.ErrUserNotFound if there is no such user in the viewer's tenant
+func (p *Postgres) GetPublicProfile(viewerID, userID string) (users.PublicProfile, error) {
+	var profile users.PublicProfile
+	query := StatementBuilder.
+		Select("u.id", "u.email", "COALESCE(u.avatar_url, '')", "u.created_at").
+		Column("(SELECT COUNT(*) FROM follows f WHERE f.followed_id = u.id)").
+		Column("(SELECT COUNT(*) FROM follows f WHERE f.follower_id = u.id)").
+		Column(sq.Expr("(SELECT COUNT(*) FROM stories s WHERE s.author_id = u.id AND s.visibility = ? AND s.deleted_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP)", types.VisibilityPublic)).
+		Column(sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ?::integer AND f.followed_id = u.id)", viewerID)).
+		Column(sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = u.id AND f.followed_id = ?::integer)", viewerID)).
+		From("users u").
+		Where(sq.Eq{"u.id": userID}).
+		Where(InTenantOf("u.tenant_id", viewerID))
+
+	err := queryRow(context.TODO(), p.db(), query, &profile.ID, &profile.Email, &profile.AvatarURL, Timestamp(&profile.CreatedAt),
+		&profile.Followers, &profile.Following, &profile.PublicStories, &profile.IsFollowing, &profile.FollowsYou)
+	if errors.Is(err, sql.ErrNoRows) {
+		return users.PublicProfile{}, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return users.PublicProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// DiscoverUsers returns the users of userID's tenant whose email hash is one
+// of hashes, other than userID and those hidden from discovery
+func (p *Postgres) DiscoverUsers(userID string, hashes []string) ([]users.DiscoveredUser, error) {
+	query := StatementBuilder.
+		Select("u.email_hash", "u.id::TEXT", "COALESCE(u.avatar_url, '')").
+		Column(sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ?::integer AND f.followed_id = u.id)", userID)).
+		From("users u").
+		Where(sq.Eq{"u.email_hash": hashes, "u.hide_from_discovery": false}).
+		Where(InTenantOf("u.tenant_id", userID)).
+		Where("u.id <> ?::integer", userID).
+		OrderBy("u.id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var stories []types.Story
+	discovered := []users.DiscoveredUser{}
 	for rows.Next() {
-		var s types.Story
-		err := rows.Scan(&s.ID, &s.AuthorID, &s.Text, &s.MediaKey, &s.Visibility, &s.CreatedAt, &s.ExpiresAt, &s.DeletedAt)
-		if err != nil {
+		var user users.DiscoveredUser
+		if err := rows.Scan(&user.Hash, &user.UserID, &user.AvatarURL, &user.IsFollowing); err != nil {
 			return nil, err
 		}
-		stories = append(stories, s)
+		discovered = append(discovered, user)
 	}
-	return stories, nil
+	return discovered, rows.Err()
 }
 
-func (p *Postgres) GetStoryByID(storyID string) (types.Story, error) {
-	query := `
-	SELECT id, author_id, text, media_key, visibility, created_at, expires_at, COALESCE(deleted_at::TEXT, '') as deleted_at
-	FROM stories
-	WHERE id = $1 AND deleted_at IS NULL
-	`
-	var s types.Story
-	err := p.Db.QueryRow(query, storyID).Scan(&s.ID, &s.AuthorID, &s.Text, &s.MediaKey, &s.Visibility, &s.CreatedAt, &s.ExpiresAt, &s.DeletedAt)
-	if err != nil {
-		return s, err
+// GetTenantUsers returns the IDs of userIDs that belong to users of tenantID
+func (p *Postgres) GetTenantUsers(tenantID string, userIDs []string) ([]string, error) {
+	ids := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if _, err := strconv.ParseInt(userID, 10, 32); err == nil {
+			ids = append(ids, userID)
+		}
 	}
-	return s, nil
-}
 
-func (p *Postgres) CanUserViewStory(storyID, userID string) (bool, error) {
-	query := `
-	SELECT s.visibility, s.author_id,
-		   (CASE WHEN sa.user_id IS NOT NULL THEN true ELSE false END) AS in_audience
-	FROM stories s
-	LEFT JOIN story_audience sa ON s.id = sa.story_id AND sa.user_id = $2::integer
-	WHERE s.id = $1 AND s.deleted_at IS NULL
-	`
+	query := StatementBuilder.
+		Select("id::TEXT").
+		From("users").
+		Where(sq.Eq{"id": ids, "tenant_id": tenantID})
 
-	var visibility types.Visibility
-	var authorID string
-	var inAudience bool
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
 
-	err := p.Db.QueryRow(query, storyID, userID).Scan(&visibility, &authorID, &inAudience)
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Check permission rules based on visibility and graph
-	switch visibility {
-	case types.VisibilityPublic:
-		return true, nil
-	case types.VisibilityFriends:
-		// User can view if they are the author or in the audience
-		return authorID == userID || inAudience, nil
-	case types.VisibilityPrivate:
-		// User can view if they are the author or in the audience
-		return authorID == userID || inAudience, nil
-	default:
-		return false, nil
+	found := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		found = append(found, userID)
 	}
+	return found, rows.Err()
 }
 
-func (p *Postgres) RecordStoryView(storyID, viewerID string) error {
-	query := `
-	INSERT INTO story_views (story_id, viewer_id)
-	VALUES ($1, $2)
-	ON CONFLICT (story_id, viewer_id) DO NOTHING
-	`
-	_, err := p.Db.Exec(query, storyID, viewerID)
-	return err
-}
+// SetAvatarURL sets or, when avatarURL is empty, removes the user's avatar
+func (p *Postgres) SetAvatarURL(userID, avatarURL string) error {
+	var value any
+	if avatarURL != "" {
+		value = avatarURL
+	}
+	query := StatementBuilder.
+		Update("users").
+		Set("avatar_url", value).
+		Where(sq.Eq{"id": userID})
 
-func (p *Postgres) AddReaction(storyID, userID string, emoji types.ReactionType) error {
-	// First, remove any existing reaction from this user for this story
-	deleteQuery := `DELETE FROM reactions WHERE story_id = $1 AND user_id = $2`
-	_, err := p.Db.Exec(deleteQuery, storyID, userID)
+	result, err := exec(context.TODO(), p.db(), query)
 	if err != nil {
 		return err
 	}
 
-	// Then add the new reaction
-	insertQuery := `
-	INSERT INTO reactions (story_id, user_id, reaction_type)
-	VALUES ($1, $2, $3)
-	`
-	_, err = p.Db.Exec(insertQuery, storyID, userID, string(emoji))
-	return err
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
-// SoftDeleteExpiredStories marks expired stories as deleted and returns the count
-func (p *Postgres) SoftDeleteExpiredStories() (int, error) {
-	query := `
-	UPDATE stories 
-	SET deleted_at = CURRENT_TIMESTAMP 
-	WHERE expires_at < CURRENT_TIMESTAMP 
-	AND deleted_at IS NULL
-	`
+// SetUserAdmin grants or revokes admin rights, returning sql.ErrNoRows for unknown users
+func (p *Postgres) SetUserAdmin(userID string, isAdmin bool) error {
+	query := StatementBuilder.
+		Update("users").
+		Set("is_admin", isAdmin).
+		Where(sq.Eq{"id": userID})
 
-	result, err := p.Db.Exec(query)
+	result, err := exec(context.TODO(), p.db(), query)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return 0, err
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
 
-	return int(rowsAffected), nil
+	return nil
 }
 
-// GetUserStats returns user statistics for the last 7 days
-func (p *Postgres) GetUserStats(userID string) (int, int, int, map[string]int, error) {
-	var posted, views, uniqueViewers int
-	reactionCounts := make(map[string]int)
+func (p *Postgres) GetAllPublicStories(tenantID string) ([]types.Story, error) {
+	query := selectStories().
+		Where(sq.Eq{"s.visibility": types.VisibilityPublic, "s.tenant_id": tenantID}).
+		OrderBy(FeedOrder("s")...)
+
+	return queryStories(context.TODO(), p.db(), query)
+}
+
+func (p *Postgres) GetStoriesForUser(ctx context.Context, userID string) ([]types.Story, error) {
+	query := selectFeedStories(userID).
+		Where(InFeedOf(userID)).
+		OrderBy(FeedOrder("s")...)
+
+	var stories []types.Story
+	start := time.Now()
+	err := scanEach(ctx, p.db(), query, scanFeedStory, func(s types.Story) error {
+		stories = append(stories, s)
+		return nil
+	})
+	metrics.ObserveQuery("stories_for_user", start, len(stories), err)
+	return stories, err
+}
+
+// StreamStoriesForUser calls fn with each story of the user's feed, in feed
+// order, as rows are scanned instead of loading the whole feed
+func (p *Postgres) StreamStoriesForUser(ctx context.Context, userID string, fn func(types.Story) error) error {
+	query := selectFeedStories(userID).
+		Where(InFeedOf(userID)).
+		OrderBy(FeedOrder("s")...)
+
+	rows := 0
+	start := time.Now()
+	err := scanEach(ctx, p.db(), query, scanFeedStory, func(s types.Story) error {
+		rows++
+		return fn(s)
+	})
+	metrics.ObserveQuery("stories_for_user_stream", start, rows, err)
+	return err
+}
+
+// GetFeedTrays returns one tray per followed author with active stories the
+// user may see, except authors they hid, authors with unseen stories first and
+// then by their latest story and, for ties, by author ID
+func (p *Postgres) GetFeedTrays(ctx context.Context, userID string) ([]types.FeedTray, error) {
+	query := StatementBuilder.
+		Select("s.author_id", "u.email", "COALESCE(u.avatar_url, '')", "COUNT(*)").
+		Column(sq.Expr("COUNT(*) FILTER (WHERE NOT EXISTS (SELECT 1 FROM story_views v WHERE v.story_id = s.id AND v.viewer_id = ?::integer)) AS unseen", userID)).
+		Column("MAX(s.created_at) AS latest").
+		From("stories s").
+		Join("users u ON u.id = s.author_id").
+		Where(sq.Eq{"s.deleted_at": nil}).
+		Where("s.expires_at > CURRENT_TIMESTAMP").
+		Where("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ?::integer AND f.followed_id = s.author_id)", userID).
+		Where(InFeedOf(userID)).
+		GroupBy("s.author_id", "u.email", "u.avatar_url").
+		OrderBy("unseen > 0 DESC", "latest DESC", "s.author_id DESC")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trays := []types.FeedTray{}
+	for rows.Next() {
+		var tray types.FeedTray
+		if err := rows.Scan(&tray.AuthorID, &tray.AuthorEmail, &tray.AvatarURL, &tray.StoryCount, &tray.UnseenCount, Timestamp(&tray.LatestStoryAt)); err != nil {
+			return nil, err
+		}
+		tray.Seen = tray.UnseenCount == 0
+		trays = append(trays, tray)
+	}
+
+	return trays, rows.Err()
+}
+
+// GetFeedChanges returns the stories created in the user's feed after since,
+// and those the feed held at since that have been deleted or expired since.
+// Both are bounded by the database clock at the time of the call less
+// FeedChangesLag, which the returned cursor records so the next poll picks up
+// exactly where this one stopped. Rows are stamped when their transaction
+// starts, so without the lag a write committing after the call could carry a
+// time the cursor has already passed.
+func (p *Postgres) GetFeedChanges(ctx context.Context, userID string, since time.Time) (types.FeedChanges, error) {
+	start := time.Now()
+	since = since.UTC()
+
+	var until time.Time
+	if err := p.db().QueryRowContext(ctx, "SELECT LOCALTIMESTAMP").Scan(&until); err != nil {
+		return types.FeedChanges{}, err
+	}
+	until = until.Add(-p.FeedChangesLag)
+	if until.Before(since) {
+		until = since
+	}
+
+	var created []types.Story
+	err := scanEach(ctx, p.db(), selectViewerStories(userID).
+		Where(InFeedOf(userID)).
+		Where("s.created_at > ?", since).
+		Where("s.created_at <= ?", until).
+		OrderBy(FeedOrder("s")...), scanViewerStory, func(s types.Story) error {
+		created = append(created, s)
+		return nil
+	})
+	if err != nil {
+		return types.FeedChanges{}, err
+	}
+
+	removed, err := p.removedStories(ctx, userID, since, until)
+	metrics.ObserveQuery("feed_changes", start, len(created)+len(removed), err)
+	if err != nil {
+		return types.FeedChanges{}, err
+	}
+
+	if created == nil {
+		created = []types.Story{}
+	}
+	return types.FeedChanges{
+		Created: created,
+		Removed: removed,
+		Cursor:  types.FormatTime(until),
+	}, nil
+}
+
+// removedStories returns the stories visible to the user that existed at since
+// and were deleted or expired by until. The expiry worker soft deletes stories
+// after they expire, while authors delete them before, which tells the two apart.
+func (p *Postgres) removedStories(ctx context.Context, userID string, since, until time.Time) ([]types.RemovedStory, error) {
+	query := StatementBuilder.
+		Select("s.id", "s.author_id", "s.deleted_at",
+			"CASE WHEN s.expires_at <= s.deleted_at THEN '"+types.RemovalExpired+"' ELSE '"+types.RemovalDeleted+"' END").
+		From("stories s").
+		Where(InFeedOf(userID)).
+		Where("s.created_at <= ?", since).
+		Where("s.deleted_at > ?", since).
+		Where("s.deleted_at <= ?", until).
+		OrderBy("s.deleted_at DESC", "s.id DESC")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	removed := []types.RemovedStory{}
+	for rows.Next() {
+		var story types.RemovedStory
+		if err := rows.Scan(&story.StoryID, &story.AuthorID, Timestamp(&story.RemovedAt), &story.Reason); err != nil {
+			return nil, err
+		}
+		removed = append(removed, story)
+	}
+
+	return removed, rows.Err()
+}
+
+// GetNearbyPublicStories returns the tenant's active public stories tagged within
+// radius meters of the given point, closest first, as viewerID sees them
+func (p *Postgres) GetNearbyPublicStories(ctx context.Context, tenantID, viewerID string, lat, lng, radiusMeters float64) ([]types.Story, error) {
+	query := selectViewerStories(viewerID).
+		Where(sq.Eq{"s.visibility": types.VisibilityPublic, "s.tenant_id": tenantID}).
+		Where(sq.NotEq{"s.latitude": nil, "s.longitude": nil}).
+		Where("s.expires_at > CURRENT_TIMESTAMP").
+		Where("earth_box(ll_to_earth(?, ?), ?) @> ll_to_earth(s.latitude, s.longitude)", lat, lng, radiusMeters).
+		Where("earth_distance(ll_to_earth(?, ?), ll_to_earth(s.latitude, s.longitude)) <= ?", lat, lng, radiusMeters).
+		OrderByClause("earth_distance(ll_to_earth(?, ?), ll_to_earth(s.latitude, s.longitude)) ASC", lat, lng).
+		OrderBy(FeedOrder("s")...).
+		Limit(100)
+
+	var stories []types.Story
+	err := scanEach(ctx, p.db(), query, scanViewerStory, func(s types.Story) error {
+		stories = append(stories, s)
+		return nil
+	})
+	return stories, err
+}
+
+func (p *Postgres) GetStoryByID(storyID string) (types.Story, error) {
+	query := selectStories().Where(sq.Eq{"s.id": storyID})
+
+	return queryStory(context.TODO(), p.db(), query)
+}
+
+// GetStoryForViewer returns a story as viewerID may see it: once they have
+// opened it as many times as its max_views_per_user allows, it is consumed
+// and comes back without its text, media or link. Its author never uses it up.
+func (p *Postgres) GetStoryForViewer(storyID, viewerID string) (types.Story, error) {
+	query := selectViewerStories(viewerID).Where(sq.Eq{"s.id": storyID})
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return types.Story{}, err
+	}
+	return scanViewerStory(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// GetStoriesByIDs returns the stories among storyIDs that are not deleted, in
+// no particular order; unknown and deleted IDs are skipped. Expired stories are
+// returned until the expiry worker deletes them.
+func (p *Postgres) GetStoriesByIDs(storyIDs []string) ([]types.Story, error) {
+	if len(storyIDs) == 0 {
+		return nil, nil
+	}
+
+	query := selectStories().Where(sq.Eq{"s.id": storyIDs})
+
+	return queryStories(context.TODO(), p.db(), query)
+}
+
+// GetStoryAudience returns the users other than its author who may have the
+// story in their feed, deleted or expired or not: whoever its visibility lets
+// see it, by the same rules as VisibleTo, except that a public story is only
+// in the feeds of the author's followers
+func (p *Postgres) GetStoryAudience(storyID string) ([]string, error) {
+	query := storyViewers(storyID).Where(sq.Or{
+		sq.NotEq{"s.visibility": types.VisibilityPublic},
+		sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = u.id AND f.followed_id = s.author_id)"),
+	})
+
+	return queryStrings(context.TODO(), p.db(), query)
+}
+
+// CanUserViewStory reports whether userID may see an active story given its
+// visibility and the follow graph. Stories in other tenants are reported as
+// not found (sql.ErrNoRows).
+func (p *Postgres) CanUserViewStory(storyID, userID string) (bool, error) {
+	query := StatementBuilder.
+		Select().
+		Column(sq.Expr("(?) AS can_view", visibilityRules(userID))).
+		From("stories s").
+		Where(sq.Eq{"s.id": storyID, "s.deleted_at": nil}).
+		Where(InTenantOf("s.tenant_id", userID))
+
+	var canView bool
+	err := queryRow(context.TODO(), p.db(), query, &canView)
+	if err != nil {
+		return false, err
+	}
+
+	return canView, nil
+}
+
+// RecordStoryView records that viewerID has seen a story, once per viewer.
+// Authors' views of their own stories are ignored unless
+// Interactions.CountSelfViews is set. On stories with max_views_per_user each
+// view after the first also counts as a replay, and a view beyond the limit
+// returns ErrStoryConsumed; the check and the count are one statement, so
+// concurrent views cannot both take the last one.
+func (p *Postgres) RecordStoryView(storyID, viewerID string) error {
+	ctx := context.TODO()
+
+	var authorID string
+	var maxViews sql.NullInt64
+	err := queryRow(ctx, p.db(), StatementBuilder.
+		Select("author_id::text", "max_views_per_user").
+		From("stories").
+		Where("id = ?::integer", storyID), &authorID, &maxViews)
+	if err != nil {
+		return err
+	}
+
+	own := authorID == viewerID
+	if own && !p.Interactions.CountSelfViews {
+		return nil
+	}
+
+	query := StatementBuilder.
+		Insert("story_views").
+		Columns("story_id", "viewer_id").
+		Values(storyID, viewerID)
+	if !maxViews.Valid || own {
+		_, err := exec(ctx, p.db(), query.Suffix("ON CONFLICT (story_id, viewer_id) DO NOTHING"))
+		return err
+	}
+
+	result, err := exec(ctx, p.db(), query.Suffix(
+		"ON CONFLICT (story_id, viewer_id) DO UPDATE SET view_count = story_views.view_count + 1 WHERE story_views.view_count < ?",
+		maxViews.Int64))
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return storage.ErrStoryConsumed
+	}
+	return nil
+}
+
+// GetStoryViewers returns who viewed a story, latest first. Viewers who hide
+// their view receipts are left out, though their views still count in stats,
+// and so is the author when self-views are counted.
+func (p *Postgres) GetStoryViewers(storyID string) ([]types.StoryViewer, error) {
+	query := StatementBuilder.
+		Select("u.id", "u.email", "COALESCE(u.avatar_url, '')", "v.viewed_at").
+		From("story_views v").
+		Join("stories s ON s.id = v.story_id").
+		Join("users u ON u.id = v.viewer_id").
+		Where(sq.Eq{"v.story_id": storyID}).
+		Where("v.viewer_id <> s.author_id").
+		Where(sq.Eq{"u.hide_view_receipts": false}).
+		OrderBy("v.viewed_at DESC", "u.id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	viewers := []types.StoryViewer{}
+	for rows.Next() {
+		var viewer types.StoryViewer
+		if err := rows.Scan(&viewer.UserID, &viewer.Email, &viewer.AvatarURL, Timestamp(&viewer.ViewedAt)); err != nil {
+			return nil, err
+		}
+		viewers = append(viewers, viewer)
+	}
+
+	return viewers, rows.Err()
+}
+
+// AddReaction sets userID's reaction to a story, replacing any earlier one in
+// a single statement, and returns the reaction it replaced (empty if none).
+// The existing row is locked while it is read, so concurrent reactions from
+// the same user each see the one they replaced. Authors reacting to their own
+// stories get storage.ErrSelfReaction unless Interactions.AllowSelfReactions
+// is set.
+func (p *Postgres) AddReaction(storyID, userID string, emoji types.ReactionType) (types.ReactionType, error) {
+	if !p.Interactions.AllowSelfReactions {
+		own, err := p.isAuthor(storyID, userID)
+		if err != nil {
+			return "", err
+		}
+		if own {
+			return "", storage.ErrSelfReaction
+		}
+	}
+
+	query := StatementBuilder.
+		Insert("reactions").
+		Prefix("WITH previous AS (SELECT reaction_type FROM reactions WHERE story_id = ? AND user_id = ? FOR UPDATE)", storyID, userID).
+		Columns("story_id", "user_id", "reaction_type").
+		Values(storyID, userID, string(emoji)).
+		Suffix(`ON CONFLICT (story_id, user_id) DO UPDATE
+			SET reaction_type = EXCLUDED.reaction_type, reacted_at = CURRENT_TIMESTAMP
+			RETURNING (SELECT reaction_type FROM previous)`)
+
+	var previous sql.NullString
+	if err := queryRow(context.TODO(), p.db(), query, &previous); err != nil {
+		return "", err
+	}
+
+	return types.ReactionType(previous.String), nil
+}
+
+// RemoveReaction deletes userID's reaction to a story and returns it, or
+// sql.ErrNoRows if they had not reacted
+func (p *Postgres) RemoveReaction(storyID, userID string) (types.ReactionType, error) {
+	query := StatementBuilder.
+		Delete("reactions").
+		Where(sq.Eq{"story_id": storyID, "user_id": userID}).
+		Suffix("RETURNING reaction_type")
+
+	var removed string
+	if err := queryRow(context.TODO(), p.db(), query, &removed); err != nil {
+		return "", err
+	}
+
+	return types.ReactionType(removed), nil
+}
+
+// isAuthor reports whether userID wrote the story; it is false for stories
+// that do not exist
+func (p *Postgres) isAuthor(storyID, userID string) (bool, error) {
+	query := StatementBuilder.
+		Select().
+		Column(sq.Expr("EXISTS (SELECT 1 FROM stories WHERE id = ? AND author_id = ?) AS own", storyID, userID))
+
+	var own bool
+	err := queryRow(context.TODO(), p.db(), query, &own)
+	return own, err
+}
+
+// RecordScreenshot records that userID captured a story on screen in the way
+// kind names, and reports whether it was the first such capture of theirs, so
+// authors are told once. Authors capturing their own stories are ignored.
+func (p *Postgres) RecordScreenshot(storyID, userID, kind string) (bool, error) {
+	query := StatementBuilder.
+		Insert("story_screenshots").
+		Columns("story_id", "user_id", "kind").
+		Select(StatementBuilder.
+			Select("id").
+			Column("?::integer", userID).
+			Column("?", kind).
+			From("stories").
+			Where("id = ?::integer AND author_id <> ?::integer", storyID, userID)).
+		Suffix("ON CONFLICT (story_id, user_id, kind) DO NOTHING")
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// RecordLinkClick records a click on a story's attached link
+func (p *Postgres) RecordLinkClick(storyID, userID string) error {
+	query := StatementBuilder.
+		Insert("story_link_clicks").
+		Columns("story_id", "user_id").
+		Values(storyID, userID)
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// RecordImpressions records, in one transaction, that stories appeared in
+// users' trays. Each user's impressions are written with a single statement
+// that skips stories they cannot see and keeps the first time a story was
+// seen. Authors' impressions of their own stories are ignored unless
+// Interactions.CountSelfViews is set.
+func (p *Postgres) RecordImpressions(impressions []types.Impression) error {
+	byUser := make(map[string][]types.Impression)
+	for _, impression := range impressions {
+		byUser[impression.UserID] = append(byUser[impression.UserID], impression)
+	}
+	if len(byUser) == 0 {
+		return nil
+	}
+
+	ctx := context.TODO()
+	return p.transact(ctx, func(tx queryer) error {
+		for userID, seen := range byUser {
+			values := make([]string, len(seen))
+			args := make([]any, 0, 2*len(seen))
+			for i, impression := range seen {
+				values[i] = "(?::integer, ?::timestamp)"
+				args = append(args, impression.StoryID, impression.SeenAt.UTC())
+			}
+
+			stories := sq.Select("s.id").Column("?::integer", userID).Column("i.seen_at").
+				From("stories s").
+				JoinClause("JOIN (VALUES "+strings.Join(values, ", ")+") AS i(story_id, seen_at) ON i.story_id = s.id", args...).
+				Where(VisibleTo(userID))
+			if !p.Interactions.CountSelfViews {
+				stories = stories.Where("s.author_id <> ?::integer", userID)
+			}
+
+			_, err := exec(ctx, tx, StatementBuilder.
+				Insert("story_impressions").
+				Columns("story_id", "user_id", "seen_at").
+				Select(stories).
+				Suffix("ON CONFLICT (story_id, user_id) DO NOTHING"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// shareLinkColumns are the share link columns, in the order scanShareLink expects
+var shareLinkColumns = []string{
+	"l.id",
+	"l.story_id",
+	"l.require_login",
+	"l.view_count",
+	"l.created_at",
+	"l.expires_at",
+	"l.revoked_at",
+}
+
+func scanShareLink(row rowScanner) (types.ShareLink, error) {
+	var l types.ShareLink
+	err := row.Scan(&l.ID, &l.StoryID, &l.RequireLogin, &l.ViewCount, Timestamp(&l.CreatedAt), Timestamp(&l.ExpiresAt), Timestamp(&l.RevokedAt))
+	return l, err
+}
+
+// CreateShareLink creates a share link for a story that is valid for the given duration
+func (p *Postgres) CreateShareLink(storyID string, requireLogin bool, validFor time.Duration) (types.ShareLink, error) {
+	query := StatementBuilder.
+		Insert("story_share_links AS l").
+		Columns("story_id", "require_login", "expires_at").
+		Values(storyID, requireLogin, sq.Expr("CURRENT_TIMESTAMP + (? * INTERVAL '1 second')", int64(validFor.Seconds()))).
+		Suffix("RETURNING " + strings.Join(shareLinkColumns, ", "))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return types.ShareLink{}, err
+	}
+	return scanShareLink(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// GetShareLinks returns every share link created for a story, including
+// expired and revoked ones, newest first
+func (p *Postgres) GetShareLinks(storyID string) ([]types.ShareLink, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(shareLinkColumns...).
+		From("story_share_links l").
+		Where(sq.Eq{"l.story_id": storyID}).
+		OrderBy("l.created_at DESC", "l.id DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []types.ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// RevokeShareLink revokes one of a story's share links, or returns
+// sql.ErrNoRows if the story has no such unrevoked link
+func (p *Postgres) RevokeShareLink(storyID, linkID string) error {
+	query := StatementBuilder.
+		Update("story_share_links").
+		Set("revoked_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": linkID, "story_id": storyID, "revoked_at": nil})
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetActiveShareLink returns a share link that is neither revoked nor expired
+// and whose story is still active, or sql.ErrNoRows otherwise
+func (p *Postgres) GetActiveShareLink(linkID string) (types.ShareLink, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(shareLinkColumns...).
+		From("story_share_links l").
+		Join("stories s ON s.id = l.story_id").
+		Where(sq.Eq{"l.id": linkID, "l.revoked_at": nil, "s.deleted_at": nil}).
+		Where("l.expires_at > CURRENT_TIMESTAMP").
+		Where("s.expires_at > CURRENT_TIMESTAMP").
+		ToSql()
+	if err != nil {
+		return types.ShareLink{}, err
+	}
+	return scanShareLink(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// RecordShareLinkView counts a story view made through a share link
+func (p *Postgres) RecordShareLinkView(linkID string) error {
+	query := StatementBuilder.
+		Update("story_share_links").
+		Set("view_count", sq.Expr("view_count + 1")).
+		Where(sq.Eq{"id": linkID})
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// apiTokenColumns are the API token columns, in the order scanAPIToken expects
+var apiTokenColumns = []string{
+	"id",
+	"name",
+	"scopes",
+	"created_at",
+	"last_used_at",
+	"expires_at",
+}
+
+func scanAPIToken(row rowScanner) (users.APIToken, error) {
+	var t users.APIToken
+	err := row.Scan(&t.ID, &t.Name, pq.Array(&t.Scopes), Timestamp(&t.CreatedAt), Timestamp(&t.LastUsedAt), Timestamp(&t.ExpiresAt))
+	return t, err
+}
+
+// CreateAPIToken stores a new API token under the hash of its secret. It
+// never expires when validFor is 0.
+func (p *Postgres) CreateAPIToken(userID, name, tokenHash string, scopes []string, validFor time.Duration) (users.APIToken, error) {
+	var expiresAt any
+	if validFor != 0 {
+		expiresAt = sq.Expr("CURRENT_TIMESTAMP + (? * INTERVAL '1 second')", int64(validFor.Seconds()))
+	}
+
+	sqlStr, args, err := StatementBuilder.
+		Insert("api_tokens").
+		Columns("user_id", "name", "token_hash", "scopes", "expires_at").
+		Values(userID, name, tokenHash, pq.Array(scopes), expiresAt).
+		Suffix("RETURNING " + strings.Join(apiTokenColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return users.APIToken{}, err
+	}
+	return scanAPIToken(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// GetAPITokens returns the user's unrevoked API tokens, expired ones
+// included, newest first
+func (p *Postgres) GetAPITokens(userID string) ([]users.APIToken, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(apiTokenColumns...).
+		From("api_tokens").
+		Where(sq.Eq{"user_id": userID, "revoked_at": nil}).
+		OrderBy("created_at DESC", "id DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []users.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeAPIToken revokes one of the user's API tokens, or returns
+// sql.ErrNoRows if the user has no such unrevoked token
+func (p *Postgres) RevokeAPIToken(userID, tokenID string) error {
+	query := StatementBuilder.
+		Update("api_tokens").
+		Set("revoked_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": tokenID, "user_id": userID, "revoked_at": nil})
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UseAPIToken returns who the API token with the given hash authenticates as
+// and records that it was used, or returns sql.ErrNoRows if there is no such
+// token or it was revoked or has expired
+func (p *Postgres) UseAPIToken(tokenHash string) (users.APITokenOwner, error) {
+	query := StatementBuilder.
+		Update("api_tokens t").
+		Set("last_used_at", sq.Expr("CURRENT_TIMESTAMP")).
+		From("users u").
+		Where("u.id = t.user_id").
+		Where(sq.Eq{"t.token_hash": tokenHash, "t.revoked_at": nil}).
+		Where("(t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP)").
+		Suffix("RETURNING t.id, t.user_id, u.tenant_id, t.scopes")
+
+	var owner users.APITokenOwner
+	err := queryRow(context.TODO(), p.db(), query, &owner.TokenID, &owner.UserID, &owner.TenantID, pq.Array(&owner.Scopes))
+	return owner, err
+}
+
+// SoftDeleteExpiredStories marks expired stories as deleted and returns them.
+// Highlights are kept: they leave feeds once expired but are not deleted.
+func (p *Postgres) SoftDeleteExpiredStories() ([]types.Story, error) {
+	query := StatementBuilder.
+		Update("stories s").
+		Set("deleted_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("s.expires_at < CURRENT_TIMESTAMP").
+		Where(sq.Eq{"s.deleted_at": nil}).
+		Where("NOT EXISTS (SELECT 1 FROM story_highlights h WHERE h.story_id = s.id)").
+		Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+
+	return queryStories(context.TODO(), p.db(), query)
+}
+
+// DeleteStory soft deletes an active story and returns it, or sql.ErrNoRows
+// if there is no such active story
+func (p *Postgres) DeleteStory(storyID string) (types.Story, error) {
+	query := StatementBuilder.
+		Update("stories s").
+		Set("deleted_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"s.id": storyID, "s.deleted_at": nil}).
+		Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+
+	return queryStory(context.TODO(), p.db(), query)
+}
+
+// ExpireStory expires and soft deletes an active story immediately and returns
+// it, or sql.ErrNoRows if there is no such active story
+func (p *Postgres) ExpireStory(storyID string) (types.Story, error) {
+	query := StatementBuilder.
+		Update("stories s").
+		Set("expires_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Set("deleted_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"s.id": storyID, "s.deleted_at": nil}).
+		Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+
+	return queryStory(context.TODO(), p.db(), query)
+}
+
+// GetArchivableStories returns up to limit of the tenant's stories deleted or
+// expired more than olderThan ago, oldest first. Highlights are kept, and so
+// are encrypted stories, whose envelopes the archive does not hold.
+func (p *Postgres) GetArchivableStories(tenantID string, olderThan time.Duration, limit int) ([]types.ArchivedStory, error) {
+	query := StatementBuilder.
+		Select(StoryColumns("s")...).
+		Columns(
+			"s.tenant_id",
+			"COALESCE((SELECT ARRAY_AGG(sa.user_id::TEXT ORDER BY sa.user_id) FROM story_audience sa WHERE sa.story_id = s.id), '{}')",
+			"(SELECT COUNT(*) FROM story_views v WHERE v.story_id = s.id)",
+			"(SELECT COUNT(*) FROM reactions r WHERE r.story_id = s.id)",
+		).
+		From("stories s").
+		Where(archivable(tenantID, olderThan)).
+		OrderBy("s.deleted_at", "s.id").
+		Limit(uint64(limit))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []types.ArchivedStory
+	for rows.Next() {
+		var story types.ArchivedStory
+		fields := append(StoryFields(&story.Story), &story.TenantID, pq.Array(&story.AudienceUserIDs), &story.ViewCount, &story.ReactionCount)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+// CountArchivableStories counts the stories GetArchivableStories would return
+// without a limit
+func (p *Postgres) CountArchivableStories(tenantID string, olderThan time.Duration) (int, error) {
+	query := StatementBuilder.
+		Select("COUNT(*)").
+		From("stories s").
+		Where(archivable(tenantID, olderThan))
+
+	var count int
+	err := queryRow(context.TODO(), p.db(), query, &count)
+	return count, err
+}
+
+// archivable matches the tenant's stories s deleted or expired more than
+// olderThan ago, except highlights and encrypted stories
+func archivable(tenantID string, olderThan time.Duration) sq.And {
+	return sq.And{
+		sq.Eq{"s.tenant_id": tenantID, "s.encrypted": false},
+		sq.Expr("s.deleted_at < CURRENT_TIMESTAMP - (? * INTERVAL '1 second')", int64(olderThan.Seconds())),
+		sq.Expr("NOT EXISTS (SELECT 1 FROM story_highlights h WHERE h.story_id = s.id)"),
+	}
+}
+
+// MarkStoriesArchived records that the tenant's stories are held by the
+// archive object and deletes them, with their views, reactions and audience
+func (p *Postgres) MarkStoriesArchived(tenantID, objectKey string, storyIDs []string) error {
+	if len(storyIDs) == 0 {
+		return nil
+	}
+	ctx := context.TODO()
+	archived := sq.Eq{"id": storyIDs, "tenant_id": tenantID}
+
+	return p.transact(ctx, func(tx queryer) error {
+		// A story archived again, after its first export was not recorded,
+		// points at the latest object
+		_, err := exec(ctx, tx, StatementBuilder.
+			Insert("archived_stories").
+			Columns("story_id", "tenant_id", "author_id", "object_key").
+			Select(sq.Select("id", "tenant_id", "author_id").Column("?::TEXT", objectKey).From("stories").Where(archived)).
+			Suffix("ON CONFLICT (story_id) DO UPDATE SET object_key = EXCLUDED.object_key, archived_at = CURRENT_TIMESTAMP"))
+		if err != nil {
+			return err
+		}
+
+		_, err = exec(ctx, tx, StatementBuilder.Delete("stories").Where(archived))
+		return err
+	})
+}
+
+// GetArchiveEntry returns where an archived story is held, or sql.ErrNoRows
+// if it is not archived
+func (p *Postgres) GetArchiveEntry(storyID string) (types.ArchiveEntry, error) {
+	query := StatementBuilder.
+		Select("story_id", "tenant_id", "object_key", "archived_at").
+		From("archived_stories").
+		Where("story_id = ?::integer", storyID)
+
+	var entry types.ArchiveEntry
+	err := queryRow(context.TODO(), p.db(), query, &entry.StoryID, &entry.TenantID, &entry.ObjectKey, Timestamp(&entry.ArchivedAt))
+	return entry, err
+}
+
+// RestoreArchivedStory puts an archived story back as it was when archived,
+// under its old ID, and forgets its archive entry. Audience members who have
+// since left are dropped; views and reactions are not restored. Returns
+// ErrUserNotFound if the author is gone.
+func (p *Postgres) RestoreArchivedStory(story types.ArchivedStory) (restored types.Story, err error) {
+	ctx := context.TODO()
+
+	insertStory := StatementBuilder.
+		Insert("stories AS s").
+		Columns("id", "author_id", "tenant_id", "text", "media_key", "visibility", "created_at", "expires_at", "deleted_at",
+			"link_url", "latitude", "longitude", "place_name", "encrypted", "max_views_per_user",
+			"allow_reshare", "allow_reply", "allow_screenshot_notification", "parent_story_id", "group_id").
+		Values(story.ID, story.AuthorID, story.TenantID, story.Text, story.MediaKey, story.Visibility, story.CreatedAt, story.ExpiresAt,
+			story.DeletedAt, sq.Expr("NULLIF(?, '')", story.LinkURL),
+			story.Latitude, story.Longitude, sq.Expr("NULLIF(?, '')", story.PlaceName), story.Encrypted, sq.Expr("NULLIF(?::integer, 0)", story.MaxViewsPerUser),
+			story.AllowReshare, story.AllowReply, story.AllowScreenshotNotification,
+			// The original may have been archived since, and the group deleted
+			sq.Expr("(SELECT id FROM stories WHERE id = NULLIF(?, '')::integer)", story.ParentStoryID),
+			sq.Expr("(SELECT id FROM story_groups WHERE id = NULLIF(?, '')::integer)", story.GroupID)).
+		Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+
+	err = p.transact(ctx, func(tx queryer) error {
+		var err error
+		restored, err = queryStory(ctx, tx, insertStory)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+			return storage.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(story.AudienceUserIDs) > 0 {
+			_, err = exec(ctx, tx, StatementBuilder.
+				Insert("story_audience").
+				Columns("story_id", "user_id").
+				Select(sq.Select().Column("?::integer", story.ID).Column("id").From("users").Where(sq.Eq{"id": story.AudienceUserIDs})))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = exec(ctx, tx, StatementBuilder.Delete("archived_stories").Where("story_id = ?::integer", story.ID))
+		return err
+	})
+	return restored, err
+}
+
+// ClaimExpiringStories marks stories expiring within the given window as warned
+// and returns them, so each author is notified at most once per story. A story
+// whose warning could not be sent is released with ReleaseExpiryWarning.
+func (p *Postgres) ClaimExpiringStories(within time.Duration) ([]types.Story, error) {
+	query := StatementBuilder.
+		Update("stories s").
+		Set("expiry_warned_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("s.expires_at > CURRENT_TIMESTAMP").
+		Where("s.expires_at <= CURRENT_TIMESTAMP + (? * INTERVAL '1 second')", int64(within.Seconds())).
+		Where(sq.Eq{"s.expiry_warned_at": nil, "s.deleted_at": nil}).
+		Suffix("RETURNING " + strings.Join(StoryColumns("s"), ", "))
+
+	return queryStories(context.TODO(), p.db(), query)
+}
+
+// ReleaseExpiryWarning clears the warned mark ClaimExpiringStories set on a
+// story, so it is claimed again while it has yet to expire
+func (p *Postgres) ReleaseExpiryWarning(storyID string) error {
+	query := StatementBuilder.
+		Update("stories").
+		Set("expiry_warned_at", nil).
+		Where(sq.Eq{"id": storyID})
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// AddStoryToHighlights keeps a story in the author's highlights
+func (p *Postgres) AddStoryToHighlights(storyID, userID string) error {
+	query := StatementBuilder.
+		Insert("story_highlights").
+		Columns("story_id", "user_id").
+		Values(storyID, userID).
+		Suffix("ON CONFLICT (story_id) DO NOTHING")
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetStoriesByAuthor returns every story the user has posted, including expired
+// and deleted ones, newest first
+func (p *Postgres) GetStoriesByAuthor(authorID string) ([]types.Story, error) {
+	query := StatementBuilder.
+		Select(StoryColumns("s")...).
+		From("stories s").
+		Where(sq.Eq{"s.author_id": authorID}).
+		OrderBy(FeedOrder("s")...)
+
+	return queryStories(context.TODO(), p.db(), query)
+}
+
+// authorActivitySince counts rows of table (aliased t) on the author's active
+// stories whose timeColumn falls in the stats window
+func authorActivitySince(table, countExpr, timeColumn, authorID string) sq.SelectBuilder {
+	return StatementBuilder.
+		Select(countExpr).
+		From(table + " t").
+		Join("stories s ON t.story_id = s.id").
+		Where(sq.Eq{"s.author_id": authorID, "s.deleted_at": nil}).
+		Where("t." + timeColumn + " >= NOW() - INTERVAL '7 days'")
+}
+
+// viewsSince aggregates views of the author's active stories in the stats
+// window, leaving out the author's own unless Interactions.CountSelfViews is set
+func (p *Postgres) viewsSince(countExpr, authorID string) sq.SelectBuilder {
+	query := authorActivitySince("story_views", countExpr, "viewed_at", authorID)
+	if !p.Interactions.CountSelfViews {
+		query = query.Where("t.viewer_id <> s.author_id")
+	}
+	return query
+}
+
+// StreamDailyStoryMetrics calls fn with the activity each of the user's
+// stories received on each day from through to, in UTC, ordered by day and
+// then story. Expired and deleted stories are included, and days without
+// activity are skipped. Self-views and self-reactions are left out unless the
+// interactions config counts them, as in GetUserStats.
+func (p *Postgres) StreamDailyStoryMetrics(ctx context.Context, userID string, from, to time.Time, fn func(users.DailyStoryMetrics) error) error {
+	activity := `(
+		SELECT story_id, viewed_at AS at, viewer_id AS actor_id, 'view' AS kind FROM story_views
+		UNION ALL SELECT story_id, reacted_at, user_id, 'reaction' FROM reactions
+		UNION ALL SELECT story_id, clicked_at, user_id, 'click' FROM story_link_clicks
+		UNION ALL SELECT story_id, seen_at, user_id, 'impression' FROM story_impressions
+		UNION ALL SELECT parent_story_id, created_at, author_id, 'reshare' FROM stories WHERE parent_story_id IS NOT NULL
+		UNION ALL SELECT story_id, captured_at, user_id, 'screenshot' FROM story_screenshots
+	) a`
+
+	query := StatementBuilder.
+		Select("to_char(a.at::date, 'YYYY-MM-DD') AS day", "s.id",
+			"COUNT(*) FILTER (WHERE a.kind = 'view')",
+			"COUNT(DISTINCT a.actor_id) FILTER (WHERE a.kind = 'view')",
+			"COUNT(*) FILTER (WHERE a.kind = 'reaction')",
+			"COUNT(*) FILTER (WHERE a.kind = 'click')",
+			"COUNT(*) FILTER (WHERE a.kind = 'impression')",
+			"COUNT(*) FILTER (WHERE a.kind = 'reshare')",
+			"COUNT(*) FILTER (WHERE a.kind = 'screenshot')").
+		From("stories s").
+		Join(activity+" ON a.story_id = s.id").
+		Where("s.author_id = ?::integer", userID).
+		Where("a.at >= ?", from.UTC().Truncate(24*time.Hour)).
+		Where("a.at < ?", to.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)).
+		GroupBy("day", "s.id").
+		OrderBy("day", "s.id")
+	if !p.Interactions.CountSelfViews {
+		query = query.Where("NOT (a.kind = 'view' AND a.actor_id = s.author_id)")
+	}
+	if !p.Interactions.AllowSelfReactions {
+		query = query.Where("NOT (a.kind = 'reaction' AND a.actor_id = s.author_id)")
+	}
+
+	count := 0
+	start := time.Now()
+	err := eachDailyStoryMetrics(ctx, p.db(), query, func(m users.DailyStoryMetrics) error {
+		count++
+		return fn(m)
+	})
+	metrics.ObserveQuery("daily_story_metrics", start, count, err)
+	return err
+}
+
+// eachDailyStoryMetrics runs a StreamDailyStoryMetrics query and calls fn with
+// each row as it is scanned, stopping at the first error fn returns
+func eachDailyStoryMetrics(ctx context.Context, db queryer, query sq.Sqlizer, fn func(users.DailyStoryMetrics) error) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m users.DailyStoryMetrics
+		if err := rows.Scan(&m.Day, &m.StoryID, &m.Views, &m.UniqueViewers, &m.Reactions, &m.LinkClicks, &m.Impressions, &m.Reshares, &m.Screenshots); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetUserStats returns user statistics for the last 7 days
+func (p *Postgres) GetUserStats(userID string) (users.UserStats, error) {
+	ctx := context.TODO()
+	stats := users.UserStats{
+		ReactionCounts: make(map[string]int),
+	}
 
 	// Get count of stories posted in last 7 days
-	postedQuery := `
-		SELECT COUNT(*) 
-		FROM stories 
-		WHERE author_id = $1 
-		AND created_at >= NOW() - INTERVAL '7 days'
-		AND deleted_at IS NULL
-	`
-	err := p.Db.QueryRow(postedQuery, userID).Scan(&posted)
+	postedQuery := StatementBuilder.
+		Select("COUNT(*)").
+		From("stories").
+		Where(sq.Eq{"author_id": userID, "deleted_at": nil}).
+		Where("created_at >= NOW() - INTERVAL '7 days'")
+	err := queryRow(ctx, p.db(), postedQuery, &stats.Posted)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get total views on user's stories in last 7 days
+	viewsQuery := p.viewsSince("COUNT(t.id)", userID)
+	err = queryRow(ctx, p.db(), viewsQuery, &stats.Views)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get unique viewers on user's stories in last 7 days
+	uniqueViewersQuery := p.viewsSince("COUNT(DISTINCT t.viewer_id)", userID)
+	err = queryRow(ctx, p.db(), uniqueViewersQuery, &stats.UniqueViewers)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get link clicks on user's stories in last 7 days
+	linkClicksQuery := authorActivitySince("story_link_clicks", "COUNT(t.id)", "clicked_at", userID)
+	err = queryRow(ctx, p.db(), linkClicksQuery, &stats.LinkClicks)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get tray impressions of user's stories in last 7 days, and how many
+	// users they reached
+	impressionsQuery := authorActivitySince("story_impressions", "COUNT(*)", "seen_at", userID).
+		Column("COUNT(DISTINCT t.user_id)")
+	err = queryRow(ctx, p.db(), impressionsQuery, &stats.Impressions, &stats.Reach)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get reshares of user's stories in last 7 days
+	resharesQuery := StatementBuilder.
+		Select("COUNT(r.id)").
+		From("stories r").
+		Join("stories s ON r.parent_story_id = s.id").
+		Where(sq.Eq{"s.author_id": userID, "s.deleted_at": nil, "r.deleted_at": nil}).
+		Where("r.created_at >= NOW() - INTERVAL '7 days'")
+	err = queryRow(ctx, p.db(), resharesQuery, &stats.Reshares)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get screenshots and screen recordings of user's stories in last 7 days
+	screenshotsQuery := authorActivitySince("story_screenshots", "COUNT(*)", "captured_at", userID)
+	err = queryRow(ctx, p.db(), screenshotsQuery, &stats.Screenshots)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	// Get reaction breakdown for user's stories in last 7 days
+	reactionsQuery := authorActivitySince("reactions", "t.reaction_type", "reacted_at", userID).
+		Column("COUNT(t.id)").
+		GroupBy("t.reaction_type")
+	if !p.Interactions.AllowSelfReactions {
+		// Reactions recorded before self-reactions were disallowed
+		reactionsQuery = reactionsQuery.Where("t.user_id <> s.author_id")
+	}
+	sqlStr, args, err := reactionsQuery.ToSql()
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reactionType string
+		var count int
+		err := rows.Scan(&reactionType, &count)
+		if err != nil {
+			return users.UserStats{}, err
+		}
+		stats.ReactionCounts[reactionType] = count
+	}
+
+	return stats, rows.Err()
+}
+
+// FollowUser creates a follow relationship between two users, reporting
+// whether it is new. Following again changes nothing.
+func (p *Postgres) FollowUser(followerID, followedID string) (bool, error) {
+	if followerID == followedID {
+		return false, storage.ErrSelfFollow
+	}
+	if !validUserID(followedID) {
+		return false, storage.ErrUserNotFound
+	}
+
+	// Users can only follow people in their own tenant
+	var sameTenant bool
+	check := StatementBuilder.
+		Select().
+		Column(sq.Expr("EXISTS (?)", sq.Select("1").From("users u").
+			Where(sq.Eq{"u.id": followedID}).
+			Where(InTenantOf("u.tenant_id", followerID))))
+	if err := queryRow(context.TODO(), p.db(), check, &sameTenant); err != nil {
+		return false, err
+	}
+	if !sameTenant {
+		return false, storage.ErrUserNotFound
+	}
+
+	query := StatementBuilder.
+		Insert("follows").
+		Columns("follower_id", "followed_id").
+		Values(followerID, followedID).
+		Suffix("ON CONFLICT (follower_id, followed_id) DO NOTHING")
+
+	result, err := exec(context.TODO(), p.db(), query)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation: deleted since the check
+		return false, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// UnfollowUser removes a follow relationship between two users
+func (p *Postgres) UnfollowUser(followerID, followedID string) error {
+	query := StatementBuilder.
+		Delete("follows").
+		Where(sq.Eq{"follower_id": followerID, "followed_id": followedID})
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("follow relationship not found")
+	}
+
+	return nil
+}
+
+// FollowUsers makes followerID follow every user of followedIDs in their
+// tenant with one multi-row insert, reporting the outcome for each ID
+func (p *Postgres) FollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	targets := sq.Select("u.id").
+		From("users u").
+		Where(sq.Eq{"u.id": followable(followerID, followedIDs)}).
+		Where(InTenantOf("u.tenant_id", followerID))
+	inserted := sq.Insert("follows").
+		Columns("follower_id", "followed_id").
+		Select(sq.Select().Column("?::integer", followerID).Column("id").From("targets")).
+		Suffix("ON CONFLICT (follower_id, followed_id) DO NOTHING RETURNING followed_id")
+
+	// Every user found, and whether the follow is new
+	query := StatementBuilder.
+		Select("t.id::TEXT", "i.followed_id IS NOT NULL").
+		PrefixExpr(sq.Expr("WITH targets AS (?), inserted AS (?)", targets, inserted)).
+		From("targets t").
+		LeftJoin("inserted i ON i.followed_id = t.id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		var followed bool
+		if err := rows.Scan(&userID, &followed); err != nil {
+			return nil, err
+		}
+		found[userID] = followed
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return followResults(followerID, followedIDs, func(userID string) string {
+		followed, ok := found[userID]
+		switch {
+		case !ok:
+			return types.FollowNotFound
+		case followed:
+			return types.FollowFollowed
+		default:
+			return types.FollowAlreadyFollowing
+		}
+	}), nil
+}
+
+// UnfollowUsers makes followerID stop following every user of followedIDs
+// with one delete, reporting the outcome for each ID
+func (p *Postgres) UnfollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) {
+	unfollowed, err := queryStrings(context.TODO(), p.db(), StatementBuilder.
+		Delete("follows").
+		Where(sq.Eq{"follower_id": followerID, "followed_id": followable(followerID, followedIDs)}).
+		Suffix("RETURNING followed_id::TEXT"))
+	if err != nil {
+		return nil, err
+	}
+
+	return followResults(followerID, followedIDs, func(userID string) string {
+		if slices.Contains(unfollowed, userID) {
+			return types.FollowUnfollowed
+		}
+		return types.FollowNotFollowing
+	}), nil
+}
+
+// validUserID reports whether userID fits the integer users.id column, so
+// casting it in a query cannot fail
+func validUserID(userID string) bool {
+	_, err := strconv.ParseInt(userID, 10, 32)
+	return err == nil
+}
+
+// followable returns the IDs of followedIDs that followerID could follow:
+// well-formed ones other than their own
+func followable(followerID string, followedIDs []string) []string {
+	ids := make([]string, 0, len(followedIDs))
+	for _, userID := range followedIDs {
+		if validUserID(userID) && userID != followerID {
+			ids = append(ids, userID)
+		}
+	}
+	return ids
+}
+
+// followResults returns the outcome for each distinct ID of followedIDs, in
+// order: status for those followable, self or not_found for the rest
+func followResults(followerID string, followedIDs []string, status func(userID string) string) []types.FollowResult {
+	results := make([]types.FollowResult, 0, len(followedIDs))
+	seen := make(map[string]bool, len(followedIDs))
+	for _, userID := range followedIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		result := types.FollowResult{UserID: userID}
+		if userID == followerID {
+			result.Status = types.FollowSelf
+		} else if !validUserID(userID) {
+			result.Status = types.FollowNotFound
+		} else {
+			result.Status = status(userID)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// IsFollowing checks if one user follows another
+func (p *Postgres) IsFollowing(followerID, followedID string) (bool, error) {
+	follows := sq.Select("1").
+		From("follows").
+		Where(sq.Eq{"follower_id": followerID, "followed_id": followedID})
+	query := StatementBuilder.Select().Column(sq.Expr("EXISTS(?)", follows))
+
+	var exists bool
+	err := queryRow(context.TODO(), p.db(), query, &exists)
+	return exists, err
+}
+
+// GetUserFollowees returns list of user IDs that this user follows
+func (p *Postgres) GetUserFollowees(userID string) ([]string, error) {
+	query := StatementBuilder.
+		Select("followed_id").
+		From("follows").
+		Where(sq.Eq{"follower_id": userID}).
+		OrderBy("created_at DESC")
+
+	return queryStrings(context.TODO(), p.db(), query)
+}
+
+// GetUserFollowers returns list of user IDs that follow this user
+func (p *Postgres) GetUserFollowers(userID string) ([]string, error) {
+	query := StatementBuilder.
+		Select("follower_id").
+		From("follows").
+		Where(sq.Eq{"followed_id": userID}).
+		OrderBy("created_at DESC")
+
+	return queryStrings(context.TODO(), p.db(), query)
+}
+
+// storyGroupColumns are the columns of story_groups, aliased g, that
+// scanStoryGroup expects
+var storyGroupColumns = []string{
+	"g.id",
+	"g.name",
+	"g.owner_id",
+	"(SELECT COUNT(*) FROM story_group_members m WHERE m.group_id = g.id)",
+	"g.created_at",
+}
+
+func scanStoryGroup(row rowScanner) (types.StoryGroup, error) {
+	var g types.StoryGroup
+	err := row.Scan(&g.ID, &g.Name, &g.OwnerID, &g.MemberCount, Timestamp(&g.CreatedAt))
+	return g, err
+}
+
+// selectGroupsOf starts a query for the groups userID is a member of
+func selectGroupsOf(userID string) sq.SelectBuilder {
+	return StatementBuilder.
+		Select(storyGroupColumns...).
+		From("story_groups g").
+		Join("story_group_members gm ON gm.group_id = g.id").
+		Where("gm.user_id = ?::integer", userID)
+}
+
+// CreateGroup creates a group in the owner's tenant, with the owner and
+// memberIDs as its first members
+func (p *Postgres) CreateGroup(ownerID, name string, memberIDs []string) (group types.StoryGroup, err error) {
+	ctx := context.TODO()
+
+	err = p.transact(ctx, func(tx queryer) error {
+		var id int
+		err := queryRow(ctx, tx, StatementBuilder.
+			Insert("story_groups").
+			Columns("tenant_id", "owner_id", "name").
+			Values(tenantOf(ownerID), ownerID, name).
+			Suffix("RETURNING id"), &id)
+		if err != nil {
+			return err
+		}
+		groupID := fmt.Sprintf("%d", id)
+
+		if err := addGroupMembers(ctx, tx, groupID, append([]string{ownerID}, memberIDs...)); err != nil {
+			return err
+		}
+
+		sqlStr, args, err := selectGroupsOf(ownerID).Where("g.id = ?::integer", groupID).ToSql()
+		if err != nil {
+			return err
+		}
+		group, err = scanStoryGroup(tx.QueryRowContext(ctx, sqlStr, args...))
+		return err
+	})
+	return group, err
+}
+
+// addGroupMembers adds users to a group, skipping those already in it. Unless
+// every user is in the group's tenant, none are added and it returns
+// storage.ErrUserNotFound.
+func addGroupMembers(ctx context.Context, db queryer, groupID string, userIDs []string) error {
+	inTenant := sq.Select("u.id").
+		From("users u").
+		Where(sq.Eq{"u.id": userIDs}).
+		Where("u.tenant_id = (SELECT tenant_id FROM story_groups WHERE id = ?::integer)", groupID)
+
+	var found int
+	err := queryRow(ctx, db, StatementBuilder.Select("COUNT(*)").FromSelect(inTenant, "t"), &found)
+	if err != nil {
+		return err
+	}
+	distinct := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		distinct[userID] = true
+	}
+	if found != len(distinct) {
+		return storage.ErrUserNotFound
+	}
+
+	_, err = exec(ctx, db, StatementBuilder.
+		Insert("story_group_members").
+		Columns("group_id", "user_id").
+		Select(sq.Select().Column("?::integer", groupID).Column("u.id").From("users u").Where(sq.Eq{"u.id": userIDs})).
+		Suffix("ON CONFLICT (group_id, user_id) DO NOTHING"))
+	return err
+}
+
+// groupOwner returns the owner of a group memberID belongs to, or
+// sql.ErrNoRows if they are not in it
+func groupOwner(ctx context.Context, db queryer, groupID, memberID string) (string, error) {
+	var ownerID string
+	err := queryRow(ctx, db, StatementBuilder.
+		Select("g.owner_id").
+		From("story_groups g").
+		Join("story_group_members gm ON gm.group_id = g.id").
+		Where("g.id = ?::integer AND gm.user_id = ?::integer", groupID, memberID), &ownerID)
+	return ownerID, err
+}
+
+// GetGroups returns the groups the user belongs to, newest first
+func (p *Postgres) GetGroups(userID string) ([]types.StoryGroup, error) {
+	query := selectGroupsOf(userID).OrderBy("g.created_at DESC", "g.id DESC")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []types.StoryGroup{}
+	for rows.Next() {
+		group, err := scanStoryGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// GetGroup returns a group the user belongs to, or sql.ErrNoRows
+func (p *Postgres) GetGroup(groupID, userID string) (types.StoryGroup, error) {
+	sqlStr, args, err := selectGroupsOf(userID).Where("g.id = ?::integer", groupID).ToSql()
+	if err != nil {
+		return types.StoryGroup{}, err
+	}
+	return scanStoryGroup(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// GetGroupMembers returns the members of a group, earliest to join first
+func (p *Postgres) GetGroupMembers(groupID string) ([]types.GroupMember, error) {
+	query := StatementBuilder.
+		Select("gm.user_id", "u.email", "gm.joined_at").
+		From("story_group_members gm").
+		Join("users u ON u.id = gm.user_id").
+		Where("gm.group_id = ?::integer", groupID).
+		OrderBy("gm.joined_at", "gm.user_id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []types.GroupMember{}
+	for rows.Next() {
+		var member types.GroupMember
+		if err := rows.Scan(&member.UserID, &member.Email, Timestamp(&member.JoinedAt)); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// AddGroupMembers adds users to a group on behalf of its owner. It returns
+// sql.ErrNoRows unless ownerID is in the group and storage.ErrNotGroupOwner
+// unless they own it.
+func (p *Postgres) AddGroupMembers(groupID, ownerID string, userIDs []string) error {
+	ctx := context.TODO()
+
+	owner, err := groupOwner(ctx, p.db(), groupID, ownerID)
+	if err != nil {
+		return err
+	}
+	if owner != ownerID {
+		return storage.ErrNotGroupOwner
+	}
+
+	return addGroupMembers(ctx, p.db(), groupID, userIDs)
+}
+
+// RemoveGroupMember removes userID from a group on behalf of actorID, who
+// must be them or the group's owner. It returns sql.ErrNoRows unless both are
+// in the group.
+func (p *Postgres) RemoveGroupMember(groupID, actorID, userID string) error {
+	ctx := context.TODO()
+
+	owner, err := groupOwner(ctx, p.db(), groupID, actorID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case userID == owner:
+		return storage.ErrGroupOwnerLeaving
+	case actorID != userID && actorID != owner:
+		return storage.ErrNotGroupOwner
+	}
+
+	result, err := exec(ctx, p.db(), StatementBuilder.
+		Delete("story_group_members").
+		Where("group_id = ?::integer AND user_id = ?::integer", groupID, userID))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetGroupStories returns the active stories posted into a group the user
+// belongs to, newest first, or sql.ErrNoRows if they are not in it. Stories
+// by former members stay in the group.
+func (p *Postgres) GetGroupStories(groupID, userID string) ([]types.Story, error) {
+	ctx := context.TODO()
+
+	if _, err := groupOwner(ctx, p.db(), groupID, userID); err != nil {
+		return nil, err
+	}
+
+	query := selectViewerStories(userID).
+		Where("s.group_id = ?::integer", groupID).
+		Where(sq.Eq{"s.visibility": types.VisibilityGroup}).
+		Where("s.expires_at > CURRENT_TIMESTAMP").
+		OrderBy(FeedOrder("s")...)
+
+	start := time.Now()
+	var stories []types.Story
+	err := scanEach(ctx, p.db(), query, scanViewerStory, func(s types.Story) error {
+		stories = append(stories, s)
+		return nil
+	})
+	metrics.ObserveQuery("group_stories", start, len(stories), err)
+	if stories == nil && err == nil {
+		stories = []types.Story{}
+	}
+	return stories, err
+}
+
+// HideStories hides authorID's stories from userID's feed without touching
+// the follow graph. Hiding an author again is a no-op.
+func (p *Postgres) HideStories(userID, authorID string) error {
+	// Users can only hide people in their own tenant
+	var sameTenant bool
+	check := StatementBuilder.
+		Select().
+		Column(sq.Expr("EXISTS (?)", sq.Select("1").From("users u").
+			Where(sq.Eq{"u.id": authorID}).
+			Where(InTenantOf("u.tenant_id", userID))))
+	if err := queryRow(context.TODO(), p.db(), check, &sameTenant); err != nil {
+		return err
+	}
+	if !sameTenant {
+		return storage.ErrUserNotFound
+	}
+
+	query := StatementBuilder.
+		Insert("hidden_authors").
+		Columns("user_id", "author_id").
+		Values(userID, authorID).
+		Suffix("ON CONFLICT (user_id, author_id) DO NOTHING")
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// UnhideStories brings a hidden author's stories back into the user's feed,
+// or returns sql.ErrNoRows if they were not hidden
+func (p *Postgres) UnhideStories(userID, authorID string) error {
+	query := StatementBuilder.
+		Delete("hidden_authors").
+		Where("user_id = ?::integer AND author_id = ?::integer", userID, authorID)
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetHiddenAuthors returns the IDs of the authors the user hid, latest first
+func (p *Postgres) GetHiddenAuthors(userID string) ([]string, error) {
+	query := StatementBuilder.
+		Select("author_id").
+		From("hidden_authors").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("hidden_at DESC")
+
+	return queryStrings(context.TODO(), p.db(), query)
+}
+
+// GetPrivacySettings returns the user's privacy settings
+func (p *Postgres) GetPrivacySettings(userID string) (users.PrivacySettings, error) {
+	query := StatementBuilder.
+		Select("hide_view_receipts", "hide_from_discovery").
+		From("users").
+		Where("id = ?::integer", userID)
+
+	var settings users.PrivacySettings
+	err := queryRow(context.TODO(), p.db(), query, &settings.HideViewReceipts, &settings.HideFromDiscovery)
+	return settings, err
+}
+
+// SetPrivacySettings replaces the user's privacy settings
+func (p *Postgres) SetPrivacySettings(userID string, settings users.PrivacySettings) error {
+	query := StatementBuilder.
+		Update("users").
+		Set("hide_view_receipts", settings.HideViewReceipts).
+		Set("hide_from_discovery", settings.HideFromDiscovery).
+		Where("id = ?::integer", userID)
+
+	result, err := exec(context.TODO(), p.db(), query)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetPublicKey publishes the user's public key, replacing any earlier one
+func (p *Postgres) SetPublicKey(userID string, key users.PublicKeyRequest) (users.PublicKey, error) {
+	query := StatementBuilder.
+		Insert("user_public_keys").
+		Columns("user_id", "algorithm", "public_key").
+		Values(userID, key.Algorithm, key.Key).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			algorithm = EXCLUDED.algorithm,
+			public_key = EXCLUDED.public_key,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING user_id, algorithm, public_key, updated_at`)
+
+	var published users.PublicKey
+	err := queryRow(context.TODO(), p.db(), query, &published.UserID, &published.Algorithm, &published.Key, Timestamp(&published.UpdatedAt))
+	return published, err
+}
+
+// GetPublicKey returns the public key userID published, or sql.ErrNoRows if
+// they have none or are outside the viewer's tenant
+func (p *Postgres) GetPublicKey(viewerID, userID string) (users.PublicKey, error) {
+	query := StatementBuilder.
+		Select("k.user_id", "k.algorithm", "k.public_key", "k.updated_at").
+		From("user_public_keys k").
+		Join("users u ON u.id = k.user_id").
+		Where(sq.Eq{"k.user_id": userID}).
+		Where(InTenantOf("u.tenant_id", viewerID))
+
+	var key users.PublicKey
+	err := queryRow(context.TODO(), p.db(), query, &key.UserID, &key.Algorithm, &key.Key, Timestamp(&key.UpdatedAt))
+	return key, err
+}
+
+// GetStoryEnvelope returns an active encrypted story's envelope with the
+// content key wrapped for userID, or sql.ErrNoRows if the user may not see
+// the story or has no key for it
+func (p *Postgres) GetStoryEnvelope(storyID, userID string) (types.StoryEnvelope, error) {
+	query := StatementBuilder.
+		Select("s.id", "s.author_id", "e.algorithm", "e.ciphertext", "e.nonce", "k.wrapped_key").
+		From("stories s").
+		Join("story_envelopes e ON e.story_id = s.id").
+		Join("story_recipient_keys k ON k.story_id = s.id").
+		Where(sq.Eq{"s.id": storyID, "s.deleted_at": nil}).
+		Where("k.user_id = ?::integer", userID).
+		Where(VisibleTo(userID))
+
+	var envelope types.StoryEnvelope
+	err := queryRow(context.TODO(), p.db(), query, &envelope.StoryID, &envelope.AuthorID, &envelope.Algorithm,
+		&envelope.Ciphertext, &envelope.Nonce, &envelope.WrappedKey)
+	return envelope, err
+}
+
+// RecordImpersonation adds an entry to the impersonation audit trail, in the
+// tenant of the impersonated user
+func (p *Postgres) RecordImpersonation(event users.ImpersonationEvent) error {
+	query := StatementBuilder.
+		Insert("impersonation_events").
+		Columns("token_id", "admin_id", "user_id", "tenant_id", "action", "reason", "method", "path").
+		Values(event.TokenID, event.AdminID, event.UserID, tenantOf(event.UserID), event.Action, event.Reason, event.Method, event.Path)
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetImpersonationEvents returns up to limit entries of the impersonation
+// audit trail in the admin's tenant, newest first, only those about userID
+// unless it is empty
+func (p *Postgres) GetImpersonationEvents(adminID, userID string, limit int) ([]users.ImpersonationEvent, error) {
+	query := StatementBuilder.
+		Select("id", "token_id", "admin_id", "user_id", "action", "reason", "method", "path", "created_at").
+		From("impersonation_events").
+		Where(InTenantOf("tenant_id", adminID)).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit))
+	if userID != "" {
+		query = query.Where("user_id = ?::integer", userID)
+	}
+
+	sqlStr, args, err := query.ToSql()
 	if err != nil {
-		return 0, 0, 0, nil, err
+		return nil, err
 	}
 
-	// Get total views on user's stories in last 7 days
-	viewsQuery := `
-		SELECT COUNT(sv.id)
-		FROM story_views sv
-		JOIN stories s ON sv.story_id = s.id
-		WHERE s.author_id = $1 
-		AND sv.viewed_at >= NOW() - INTERVAL '7 days'
-		AND s.deleted_at IS NULL
-	`
-	err = p.Db.QueryRow(viewsQuery, userID).Scan(&views)
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
 	if err != nil {
-		return 0, 0, 0, nil, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Get unique viewers on user's stories in last 7 days
-	uniqueViewersQuery := `
-		SELECT COUNT(DISTINCT sv.viewer_id)
-		FROM story_views sv
-		JOIN stories s ON sv.story_id = s.id
-		WHERE s.author_id = $1 
-		AND sv.viewed_at >= NOW() - INTERVAL '7 days'
-		AND s.deleted_at IS NULL
-	`
-	err = p.Db.QueryRow(uniqueViewersQuery, userID).Scan(&uniqueViewers)
+	events := []users.ImpersonationEvent{}
+	for rows.Next() {
+		var e users.ImpersonationEvent
+		if err := rows.Scan(&e.ID, &e.TokenID, &e.AdminID, &e.UserID, &e.Action, &e.Reason, &e.Method, &e.Path, Timestamp(&e.CreatedAt)); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// abuseFlagColumns are the abuse_flags columns scanAbuseFlag expects
+var abuseFlagColumns = []string{
+	"id",
+	"user_id",
+	"action",
+	"count",
+	"throttled_until",
+	"created_at",
+	"reviewed_at",
+	"COALESCE(reviewed_by::TEXT, '')",
+}
+
+func scanAbuseFlag(row rowScanner) (users.AbuseFlag, error) {
+	var f users.AbuseFlag
+	err := row.Scan(&f.ID, &f.UserID, &f.Action, &f.Count, Timestamp(&f.ThrottledUntil), Timestamp(&f.CreatedAt), Timestamp(&f.ReviewedAt), &f.ReviewedBy)
+	return f, err
+}
+
+// FlagAbuse records a throttled account for review, in the user's tenant
+func (p *Postgres) FlagAbuse(flag users.AbuseFlag) error {
+	query := StatementBuilder.
+		Insert("abuse_flags").
+		Columns("user_id", "tenant_id", "action", "count", "throttled_until").
+		Values(flag.UserID, tenantOf(flag.UserID), flag.Action, flag.Count, flag.ThrottledUntil)
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetAbuseFlags returns up to limit abuse flags in the admin's tenant, newest
+// first: the reviewed ones if reviewed is set and the open ones otherwise
+func (p *Postgres) GetAbuseFlags(adminID string, reviewed bool, limit int) ([]users.AbuseFlag, error) {
+	query := StatementBuilder.
+		Select(abuseFlagColumns...).
+		From("abuse_flags").
+		Where(InTenantOf("tenant_id", adminID)).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit))
+	if reviewed {
+		query = query.Where(sq.NotEq{"reviewed_at": nil})
+	} else {
+		query = query.Where(sq.Eq{"reviewed_at": nil})
+	}
+
+	sqlStr, args, err := query.ToSql()
 	if err != nil {
-		return 0, 0, 0, nil, err
+		return nil, err
 	}
 
-	// Get reaction breakdown for user's stories in last 7 days
-	reactionsQuery := `
-		SELECT r.reaction_type, COUNT(r.id)
-		FROM reactions r
-		JOIN stories s ON r.story_id = s.id
-		WHERE s.author_id = $1 
-		AND r.reacted_at >= NOW() - INTERVAL '7 days'
-		AND s.deleted_at IS NULL
-		GROUP BY r.reaction_type
-	`
-	rows, err := p.Db.Query(reactionsQuery, userID)
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
 	if err != nil {
-		return 0, 0, 0, nil, err
+		return nil, err
 	}
 	defer rows.Close()
 
+	flags := []users.AbuseFlag{}
 	for rows.Next() {
-		var reactionType string
-		var count int
-		err := rows.Scan(&reactionType, &count)
+		flag, err := scanAbuseFlag(rows)
 		if err != nil {
-			return 0, 0, 0, nil, err
+			return nil, err
 		}
-		reactionCounts[reactionType] = count
+		flags = append(flags, flag)
 	}
+	return flags, rows.Err()
+}
 
-	return posted, views, uniqueViewers, reactionCounts, nil
+// ReviewAbuseFlag marks an open abuse flag in the admin's tenant as reviewed
+// by them and returns it, or sql.ErrNoRows if there is no such open flag
+func (p *Postgres) ReviewAbuseFlag(adminID, flagID string) (users.AbuseFlag, error) {
+	query := StatementBuilder.
+		Update("abuse_flags").
+		Set("reviewed_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Set("reviewed_by", adminID).
+		Where("id = ?::integer", flagID).
+		Where(sq.Eq{"reviewed_at": nil}).
+		Where(InTenantOf("tenant_id", adminID)).
+		Suffix("RETURNING " + strings.Join(abuseFlagColumns, ", "))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return users.AbuseFlag{}, err
+	}
+	return scanAbuseFlag(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
 }
 
-// FollowUser creates a follow relationship between two users
-func (p *Postgres) FollowUser(followerID, followedID string) error {
-	if followerID == followedID {
-		return fmt.Errorf("users cannot follow themselves")
+// RecordExposure records that the user was served variant of experiment,
+// keeping the first exposure if they were already recorded
+func (p *Postgres) RecordExposure(experiment, variant, userID string) error {
+	query := StatementBuilder.
+		Insert("experiment_exposures").
+		Columns("experiment", "user_id", "variant").
+		Values(experiment, userID, variant).
+		Suffix("ON CONFLICT (experiment, user_id) DO NOTHING")
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetAuthorAffinity returns how much the user engaged with each author's
+// stories over the last 30 days: one point per story viewed and two per
+// reaction. Their own stories and authors they never engaged with are left
+// out.
+func (p *Postgres) GetAuthorAffinity(userID string) (map[string]int, error) {
+	ctx := context.TODO()
+	engagement := sq.Expr(`WITH engagement AS (
+		SELECT story_id, 1 AS points FROM story_views
+			WHERE viewer_id = ?::integer AND viewed_at >= NOW() - INTERVAL '30 days'
+		UNION ALL SELECT story_id, 2 FROM reactions
+			WHERE user_id = ?::integer AND reacted_at >= NOW() - INTERVAL '30 days'
+	)`, userID, userID)
+
+	query := StatementBuilder.
+		Select("s.author_id", "SUM(e.points)").
+		PrefixExpr(engagement).
+		From("engagement e").
+		Join("stories s ON s.id = e.story_id").
+		Where("s.author_id <> ?::integer", userID).
+		GroupBy("s.author_id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
 	}
 
-	query := `
-		INSERT INTO follows (follower_id, followed_id)
-		VALUES ($1, $2)
-		ON CONFLICT (follower_id, followed_id) DO NOTHING
-	`
-	_, err := p.Db.Exec(query, followerID, followedID)
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	affinity := make(map[string]int)
+	for rows.Next() {
+		var authorID string
+		var points int
+		if err := rows.Scan(&authorID, &points); err != nil {
+			return nil, err
+		}
+		affinity[authorID] = points
+	}
+	return affinity, rows.Err()
+}
+
+// mediaUploadColumns are the media_uploads columns scanMediaUpload expects
+var mediaUploadColumns = []string{"id", "user_id", "tenant_id", "object_key", "content_type", "status", "size", "created_at", "confirmed_at"}
+
+// scanMediaUpload scans a row selected with mediaUploadColumns
+func scanMediaUpload(row rowScanner) (media.MediaUpload, error) {
+	var u media.MediaUpload
+	var confirmedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.UserID, &u.TenantID, &u.ObjectKey, &u.ContentType, &u.Status, &u.Size, &u.CreatedAt, &confirmedAt)
+	if confirmedAt.Valid {
+		u.ConfirmedAt = &confirmedAt.Time
+	}
+	return u, err
+}
+
+// CreateMediaUpload records an upload URL issued to the user, in their tenant
+func (p *Postgres) CreateMediaUpload(userID, objectKey, contentType string) error {
+	query := StatementBuilder.
+		Insert("media_uploads").
+		Columns("user_id", "tenant_id", "object_key", "content_type").
+		Values(userID, tenantOf(userID), objectKey, contentType)
+
+	_, err := exec(context.TODO(), p.db(), query)
 	return err
 }
 
-// UnfollowUser removes a follow relationship between two users
-func (p *Postgres) UnfollowUser(followerID, followedID string) error {
-	query := `
-		DELETE FROM follows
-		WHERE follower_id = $1 AND followed_id = $2
-	`
-	result, err := p.Db.Exec(query, followerID, followedID)
+// ConfirmMediaUpload marks the user's upload of objectKey as confirmed with
+// the given size. Confirming again updates the size.
+func (p *Postgres) ConfirmMediaUpload(userID, objectKey string, size int64) (media.MediaUpload, error) {
+	query := StatementBuilder.
+		Update("media_uploads").
+		Set("status", media.UploadConfirmed).
+		Set("size", size).
+		Set("confirmed_at", sq.Expr("COALESCE(confirmed_at, CURRENT_TIMESTAMP)")).
+		Where("user_id = ?::integer", userID).
+		Where(sq.Eq{"object_key": objectKey}).
+		Suffix("RETURNING " + strings.Join(mediaUploadColumns, ", "))
+
+	sqlStr, args, err := query.ToSql()
 	if err != nil {
-		return err
+		return media.MediaUpload{}, err
 	}
+	return scanMediaUpload(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
 
-	rowsAffected, err := result.RowsAffected()
+// GetMediaUpload returns the record of the user's upload of objectKey
+func (p *Postgres) GetMediaUpload(userID, objectKey string) (media.MediaUpload, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(mediaUploadColumns...).
+		From("media_uploads").
+		Where("user_id = ?::integer", userID).
+		Where(sq.Eq{"object_key": objectKey}).
+		ToSql()
+	if err != nil {
+		return media.MediaUpload{}, err
+	}
+	return scanMediaUpload(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// SetMediaUploadStatus moves an upload that is not yet confirmed or failed to
+// the given status, recording the size of its file
+func (p *Postgres) SetMediaUploadStatus(objectKey, status string, size int64) (media.MediaUpload, error) {
+	sqlStr, args, err := StatementBuilder.
+		Update("media_uploads").
+		Set("status", status).
+		Set("size", size).
+		Where(sq.Eq{"object_key": objectKey, "status": []string{media.UploadInitiated, media.UploadUploaded}}).
+		Suffix("RETURNING " + strings.Join(mediaUploadColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return media.MediaUpload{}, err
+	}
+	return scanMediaUpload(p.db().QueryRowContext(context.TODO(), sqlStr, args...))
+}
+
+// GetMediaUploads returns every upload record in the tenant, by object key
+func (p *Postgres) GetMediaUploads(tenantID string) ([]media.MediaUpload, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(mediaUploadColumns...).
+		From("media_uploads").
+		Where(sq.Eq{"tenant_id": tenantID}).
+		OrderBy("object_key").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []media.MediaUpload{}
+	for rows.Next() {
+		upload, err := scanMediaUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+// GetUserMediaUploads returns at most limit of the user's uploads in the
+// tenant whose file has been uploaded, in object key order after the given key
+func (p *Postgres) GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) {
+	sqlStr, args, err := StatementBuilder.
+		Select(mediaUploadColumns...).
+		From("media_uploads").
+		Where("user_id = ?::integer", userID).
+		Where(sq.Eq{"tenant_id": tenantID, "status": []string{media.UploadUploaded, media.UploadConfirmed}}).
+		Where(sq.Gt{"object_key": after}).
+		OrderBy("object_key").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []media.MediaUpload{}
+	for rows.Next() {
+		upload, err := scanMediaUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+// DeleteMediaUpload removes the record of an upload
+func (p *Postgres) DeleteMediaUpload(objectKey string) error {
+	_, err := exec(context.TODO(), p.db(), StatementBuilder.Delete("media_uploads").Where(sq.Eq{"object_key": objectKey}))
+	return err
+}
+
+// GetTenantIDs returns every tenant that has users
+func (p *Postgres) GetTenantIDs() ([]string, error) {
+	return queryStrings(context.TODO(), p.db(), StatementBuilder.
+		Select("DISTINCT tenant_id").
+		From("users").
+		OrderBy("tenant_id"))
+}
+
+// GetStorySettings returns the user's story settings, which are empty (no
+// defaults) until the user sets them
+func (p *Postgres) GetStorySettings(userID string) (types.StorySettings, error) {
+	query := StatementBuilder.
+		Select("default_visibility", "default_audience", "default_expires_in_hours").
+		From("story_settings").
+		Where(sq.Eq{"user_id": userID})
+
+	settings, err := scanStorySettings(context.TODO(), p.db(), query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.StorySettings{}, nil
+	}
+	return settings, err
+}
+
+// UpdateStorySettings changes the story settings the update sets, keeping
+// the others, and returns them all
+func (p *Postgres) UpdateStorySettings(userID string, update types.StorySettingsUpdate) (types.StorySettings, error) {
+	settings := map[string]any{}
+	if update.DefaultVisibility != nil {
+		settings["default_visibility"] = *update.DefaultVisibility
+	}
+	if update.DefaultAudienceUserIDs != nil {
+		settings["default_audience"] = sq.Expr("?::integer[]", pq.Array(*update.DefaultAudienceUserIDs))
+	}
+	if update.DefaultExpiresInHours != nil {
+		settings["default_expires_in_hours"] = *update.DefaultExpiresInHours
+	}
+	if len(settings) == 0 {
+		return p.GetStorySettings(userID)
+	}
+
+	columns := slices.Sorted(maps.Keys(settings))
+	insert := StatementBuilder.Insert("story_settings").Columns(append([]string{"user_id"}, columns...)...)
+	values := []any{userID}
+	var assignments []string
+	for _, column := range columns {
+		values = append(values, settings[column])
+		assignments = append(assignments, column+" = EXCLUDED."+column)
+	}
+	query := insert.Values(values...).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET " + strings.Join(assignments, ", ") +
+			" RETURNING default_visibility, default_audience, default_expires_in_hours")
+
+	return scanStorySettings(context.TODO(), p.db(), query)
+}
+
+// scanStorySettings reads the story settings returned by query
+func scanStorySettings(ctx context.Context, db queryer, query sq.Sqlizer) (types.StorySettings, error) {
+	var settings types.StorySettings
+	err := queryRow(ctx, db, query, &settings.DefaultVisibility, pq.Array(&settings.DefaultAudienceUserIDs), &settings.DefaultExpiresInHours)
+	return settings, err
+}
+
+// GetNotificationSettings returns the user's notification settings, which are
+// empty (quiet hours disabled) until the user sets them
+func (p *Postgres) GetNotificationSettings(userID string) (users.NotificationSettings, error) {
+	query := StatementBuilder.
+		Select("quiet_hours_start", "quiet_hours_end", "timezone", "email_new_followers", "email_weekly_stats", "email_weekly_recap").
+		From("notification_settings").
+		Where(sq.Eq{"user_id": userID})
+
+	var settings users.NotificationSettings
+	err := queryRow(context.TODO(), p.db(), query, &settings.QuietHoursStart, &settings.QuietHoursEnd, &settings.Timezone,
+		&settings.EmailNewFollowers, &settings.EmailWeeklyStats, &settings.EmailWeeklyRecap)
+	if errors.Is(err, sql.ErrNoRows) {
+		return users.NotificationSettings{}, nil
+	}
+	return settings, err
+}
+
+// SetNotificationSettings replaces the user's notification settings. Opting
+// in to an email starts it from now, so users are not emailed about the past.
+func (p *Postgres) SetNotificationSettings(userID string, settings users.NotificationSettings) error {
+	query := StatementBuilder.
+		Insert("notification_settings").
+		Columns("user_id", "quiet_hours_start", "quiet_hours_end", "timezone", "email_new_followers", "email_weekly_stats", "email_weekly_recap").
+		Values(userID, settings.QuietHoursStart, settings.QuietHoursEnd, settings.Timezone,
+			settings.EmailNewFollowers, settings.EmailWeeklyStats, settings.EmailWeeklyRecap).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			followers_emailed_at = CASE WHEN EXCLUDED.email_new_followers AND NOT notification_settings.email_new_followers
+				THEN CURRENT_TIMESTAMP ELSE notification_settings.followers_emailed_at END,
+			stats_emailed_at = CASE WHEN EXCLUDED.email_weekly_stats AND NOT notification_settings.email_weekly_stats
+				THEN CURRENT_TIMESTAMP ELSE notification_settings.stats_emailed_at END,
+			email_new_followers = EXCLUDED.email_new_followers,
+			email_weekly_stats = EXCLUDED.email_weekly_stats,
+			email_weekly_recap = EXCLUDED.email_weekly_recap,
+			updated_at = CURRENT_TIMESTAMP`)
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// QueueNotification holds an event back for the user's next digest
+func (p *Postgres) QueueNotification(userID string, event *types.Event) error {
+	payload, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("follow relationship not found")
+	query := StatementBuilder.
+		Insert("queued_notifications").
+		Columns("user_id", "event_type", "payload").
+		Values(userID, event.Type, payload)
+
+	_, err = exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetQueuedNotificationUsers returns the notification settings of every user
+// with queued notifications, keyed by user ID
+func (p *Postgres) GetQueuedNotificationUsers() (map[string]users.NotificationSettings, error) {
+	query := StatementBuilder.
+		Select("DISTINCT q.user_id",
+			"COALESCE(ns.quiet_hours_start, '')",
+			"COALESCE(ns.quiet_hours_end, '')",
+			"COALESCE(ns.timezone, '')").
+		From("queued_notifications q").
+		LeftJoin("notification_settings ns ON ns.user_id = q.user_id")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := make(map[string]users.NotificationSettings)
+	for rows.Next() {
+		var userID string
+		var settings users.NotificationSettings
+		if err := rows.Scan(&userID, &settings.QuietHoursStart, &settings.QuietHoursEnd, &settings.Timezone); err != nil {
+			return nil, err
+		}
+		pending[userID] = settings
+	}
+
+	return pending, rows.Err()
 }
 
-// IsFollowing checks if one user follows another
-func (p *Postgres) IsFollowing(followerID, followedID string) (bool, error) {
-	query := `
-		SELECT EXISTS(
-			SELECT 1 FROM follows
-			WHERE follower_id = $1 AND followed_id = $2
-		)
-	`
-	var exists bool
-	err := p.Db.QueryRow(query, followerID, followedID).Scan(&exists)
-	return exists, err
+// ClaimQueuedNotifications deletes the user's queued notifications and returns
+// how many there were of each event type, so each is summarized only once
+func (p *Postgres) ClaimQueuedNotifications(userID string) (map[types.EventType]int, error) {
+	query := StatementBuilder.
+		Delete("queued_notifications").
+		Where(sq.Eq{"user_id": userID}).
+		Suffix("RETURNING event_type")
+
+	eventTypes, err := queryStrings(context.TODO(), p.db(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[types.EventType]int)
+	for _, eventType := range eventTypes {
+		counts[types.EventType(eventType)]++
+	}
+
+	return counts, nil
 }
 
-// GetUserFollowees returns list of user IDs that this user follows
-func (p *Postgres) GetUserFollowees(userID string) ([]string, error) {
-	query := `
-		SELECT followed_id 
-		FROM follows 
-		WHERE follower_id = $1
-		ORDER BY created_at DESC
-	`
-	rows, err := p.Db.Query(query, userID)
+// GetFollowerDigests returns users opted in to new follower emails who gained
+// followers since their last one
+func (p *Postgres) GetFollowerDigests(limit int) ([]users.FollowerDigest, error) {
+	query := StatementBuilder.
+		Select("u.id", "u.email", "COUNT(f.follower_id)", "MAX(f.created_at)").
+		From("notification_settings ns").
+		Join("users u ON u.id = ns.user_id").
+		Join("follows f ON f.followed_id = ns.user_id AND f.created_at > ns.followers_emailed_at").
+		Where("ns.email_new_followers").
+		GroupBy("u.id", "u.email").
+		OrderBy("u.id").
+		Limit(uint64(limit))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var followees []string
+	var digests []users.FollowerDigest
 	for rows.Next() {
-		var followedID string
-		if err := rows.Scan(&followedID); err != nil {
+		var digest users.FollowerDigest
+		if err := rows.Scan(&digest.UserID, &digest.Email, &digest.NewFollowers, &digest.Until); err != nil {
 			return nil, err
 		}
-		followees = append(followees, followedID)
+		digests = append(digests, digest)
 	}
-	return followees, nil
+
+	return digests, rows.Err()
 }
 
-// GetUserFollowers returns list of user IDs that follow this user
-func (p *Postgres) GetUserFollowers(userID string) ([]string, error) {
-	query := `
-		SELECT follower_id 
-		FROM follows 
-		WHERE followed_id = $1
-		ORDER BY created_at DESC
-	`
-	rows, err := p.Db.Query(query, userID)
+// MarkFollowersEmailed records that the user was emailed about followers up
+// to the follow at until
+func (p *Postgres) MarkFollowersEmailed(userID string, until time.Time) error {
+	query := StatementBuilder.
+		Update("notification_settings").
+		Set("followers_emailed_at", until).
+		Where(sq.Eq{"user_id": userID})
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetWeeklyStatsRecipients returns users opted in to weekly stats emails who
+// have not had one in the last week
+func (p *Postgres) GetWeeklyStatsRecipients(limit int) ([]users.EmailRecipient, error) {
+	query := StatementBuilder.
+		Select("u.id", "u.email").
+		From("notification_settings ns").
+		Join("users u ON u.id = ns.user_id").
+		Where("ns.email_weekly_stats").
+		Where("ns.stats_emailed_at <= NOW() - INTERVAL '7 days'").
+		OrderBy("u.id").
+		Limit(uint64(limit))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(context.TODO(), sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []users.EmailRecipient
+	for rows.Next() {
+		var recipient users.EmailRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.Email); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, rows.Err()
+}
+
+// MarkWeeklyStatsEmailed records that the user was just sent their weekly stats
+func (p *Postgres) MarkWeeklyStatsEmailed(userID string) error {
+	query := StatementBuilder.
+		Update("notification_settings").
+		Set("stats_emailed_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"user_id": userID})
+
+	_, err := exec(context.TODO(), p.db(), query)
+	return err
+}
+
+// GetActiveCreators returns the IDs of users who posted a story during the
+// week starting at weekStart, including stories since expired or deleted
+func (p *Postgres) GetActiveCreators(ctx context.Context, weekStart time.Time) ([]string, error) {
+	from, until := recapWeek(weekStart)
+	query := StatementBuilder.
+		Select("author_id::text").
+		From("stories").
+		Where("created_at >= ?", from).
+		Where("created_at < ?", until).
+		GroupBy("author_id").
+		OrderBy("author_id")
+
+	return queryStrings(ctx, p.db(), query)
+}
+
+// SaveWeeklyRecap sums up the user's week starting at weekStart and saves
+// it, or returns the recap saved before, so a recap is made once per week.
+// Views follow the interactions config as in StreamDailyStoryMetrics.
+func (p *Postgres) SaveWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) {
+	recap, err := p.getWeeklyRecap(ctx, userID, weekStart)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return recap, err
+	}
+
+	from, until := recapWeek(weekStart)
+	recap = users.WeeklyRecap{}
+
+	postedQuery := StatementBuilder.
+		Select("COUNT(*)").
+		From("stories").
+		Where("author_id = ?::integer", userID).
+		Where("created_at >= ?", from).
+		Where("created_at < ?", until)
+	if err := queryRow(ctx, p.db(), postedQuery, &recap.Posted); err != nil {
+		return users.WeeklyRecap{}, err
+	}
+
+	// Views and the top story come from the daily metrics of the export
+	type storyActivity struct{ views, reactions int }
+	activity := make(map[string]*storyActivity)
+	err = p.StreamDailyStoryMetrics(ctx, userID, from, until.AddDate(0, 0, -1), func(m users.DailyStoryMetrics) error {
+		story, ok := activity[m.StoryID]
+		if !ok {
+			story = &storyActivity{}
+			activity[m.StoryID] = story
+		}
+		story.views += m.Views
+		story.reactions += m.Reactions
+		recap.Views += m.Views
+		return nil
+	})
+	if err != nil {
+		return users.WeeklyRecap{}, err
+	}
+	for storyID, story := range activity {
+		if story.views == 0 {
+			continue
+		}
+		top := recap.TopStory
+		if top == nil || story.views > top.Views ||
+			story.views == top.Views && (story.reactions > top.Reactions ||
+				story.reactions == top.Reactions && lessID(storyID, top.ID)) {
+			recap.TopStory = &users.RecapStory{ID: storyID, Views: story.views, Reactions: story.reactions}
+		}
+	}
+
+	reachQuery := StatementBuilder.
+		Select("COUNT(DISTINCT si.user_id)").
+		From("story_impressions si").
+		Join("stories s ON s.id = si.story_id").
+		Where("s.author_id = ?::integer", userID).
+		Where("si.seen_at >= ?", from).
+		Where("si.seen_at < ?", until)
+	if err := queryRow(ctx, p.db(), reachQuery, &recap.Reach); err != nil {
+		return users.WeeklyRecap{}, err
+	}
+
+	// Unfollows leave no trace, so the delta compares with the previous
+	// recap's count; the first recap counts the follows of the week
+	var gained int
+	followersQuery := StatementBuilder.
+		Select("COUNT(*)").
+		Column(sq.Expr("COUNT(*) FILTER (WHERE created_at >= ? AND created_at < ?)", from, until)).
+		From("follows").
+		Where("followed_id = ?::integer", userID)
+	if err := queryRow(ctx, p.db(), followersQuery, &recap.Followers, &gained); err != nil {
+		return users.WeeklyRecap{}, err
+	}
+	recap.FollowerDelta = gained
+	previous, err := p.getWeeklyRecap(ctx, userID, from.AddDate(0, 0, -7))
+	if err == nil {
+		recap.FollowerDelta = recap.Followers - previous.Followers
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return users.WeeklyRecap{}, err
+	}
+
+	var topID any
+	var topViews, topReactions int
+	if recap.TopStory != nil {
+		topID, topViews, topReactions = recap.TopStory.ID, recap.TopStory.Views, recap.TopStory.Reactions
+	}
+	insert := StatementBuilder.
+		Insert("weekly_recaps").
+		Columns("user_id", "week_start", "posted", "views", "reach", "followers", "follower_delta",
+			"top_story_id", "top_story_views", "top_story_reactions").
+		Values(userID, from.Format(time.DateOnly), recap.Posted, recap.Views, recap.Reach, recap.Followers, recap.FollowerDelta,
+			topID, topViews, topReactions).
+		Suffix("ON CONFLICT (user_id, week_start) DO NOTHING")
+	if _, err := exec(ctx, p.db(), insert); err != nil {
+		return users.WeeklyRecap{}, err
+	}
+
+	// Another worker may have saved the week first; theirs is kept
+	return p.getWeeklyRecap(ctx, userID, weekStart)
+}
+
+// MarkWeeklyRecapDelivered records that the user was notified of their recap
+// of the week starting at weekStart
+func (p *Postgres) MarkWeeklyRecapDelivered(ctx context.Context, userID string, weekStart time.Time) error {
+	query := StatementBuilder.
+		Update("weekly_recaps").
+		Set("delivered_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("user_id = ?::integer", userID).
+		Where("week_start = ?::date", weekStart.UTC().Format(time.DateOnly))
+
+	_, err := exec(ctx, p.db(), query)
+	return err
+}
+
+// GetWeeklyRecaps returns the user's latest weekly recaps, newest first
+func (p *Postgres) GetWeeklyRecaps(ctx context.Context, userID string, limit int) ([]users.WeeklyRecap, error) {
+	query := weeklyRecapsQuery().
+		Where("user_id = ?::integer", userID).
+		OrderBy("week_start DESC").
+		Limit(uint64(limit))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db().QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var followers []string
+	recaps := []users.WeeklyRecap{}
 	for rows.Next() {
-		var followerID string
-		if err := rows.Scan(&followerID); err != nil {
+		recap, err := scanWeeklyRecap(rows)
+		if err != nil {
 			return nil, err
 		}
-		followers = append(followers, followerID)
+		recaps = append(recaps, recap)
+	}
+	return recaps, rows.Err()
+}
+
+// getWeeklyRecap returns the user's saved recap of the week starting at
+// weekStart, or sql.ErrNoRows
+func (p *Postgres) getWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) {
+	query := weeklyRecapsQuery().
+		Where("user_id = ?::integer", userID).
+		Where("week_start = ?::date", weekStart.UTC().Format(time.DateOnly))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return users.WeeklyRecap{}, err
+	}
+	return scanWeeklyRecap(p.db().QueryRowContext(ctx, sqlStr, args...))
+}
+
+// weeklyRecapsQuery selects the columns scanWeeklyRecap reads
+func weeklyRecapsQuery() sq.SelectBuilder {
+	return StatementBuilder.
+		Select("to_char(week_start, 'YYYY-MM-DD')", "to_char(week_start + 6, 'YYYY-MM-DD')",
+			"posted", "views", "reach", "followers", "follower_delta",
+			"COALESCE(top_story_id::text, '')", "top_story_views", "top_story_reactions",
+			"created_at", "delivered_at IS NOT NULL").
+		From("weekly_recaps")
+}
+
+// scanWeeklyRecap scans a row of weeklyRecapsQuery
+func scanWeeklyRecap(row interface{ Scan(dest ...any) error }) (users.WeeklyRecap, error) {
+	var recap users.WeeklyRecap
+	var top users.RecapStory
+	err := row.Scan(&recap.WeekStart, &recap.WeekEnd, &recap.Posted, &recap.Views, &recap.Reach, &recap.Followers,
+		&recap.FollowerDelta, &top.ID, &top.Views, &top.Reactions, Timestamp(&recap.CreatedAt), &recap.Delivered)
+	if err != nil {
+		return users.WeeklyRecap{}, err
+	}
+	if top.ID != "" {
+		recap.TopStory = &top
+	}
+	return recap, nil
+}
+
+// recapWeek returns the bounds of the week starting on the day of weekStart,
+// in UTC
+func recapWeek(weekStart time.Time) (from, until time.Time) {
+	from = weekStart.UTC().Truncate(24 * time.Hour)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// lessID orders numeric IDs by value
+func lessID(a, b string) bool {
+	return compareIDs(a, b) < 0
+}
+
+// compareIDs compares numeric IDs by value
+func compareIDs(a, b string) int {
+	if len(a) != len(b) {
+		return cmp.Compare(len(a), len(b))
 	}
-	return followers, nil
+	return strings.Compare(a, b)
 }
@@ -0,0 +1,1815 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/testutil"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+func TestPostgresIntegration(t *testing.T) {
+	store := testutil.StartPostgres(t, testutil.NewConfig())
+
+	author := testutil.CreateUser(t, store, testutil.UniqueEmail("author"))
+	follower := testutil.CreateUser(t, store, testutil.UniqueEmail("follower"))
+	friend := testutil.CreateUser(t, store, testutil.UniqueEmail("friend"))
+	stranger := testutil.CreateUser(t, store, testutil.UniqueEmail("stranger"))
+	testutil.Follow(t, store, follower, author)
+	testutil.Follow(t, store, friend, author)
+	testutil.Follow(t, store, author, friend)
+
+	public := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+	followers := testutil.CreateStory(t, store, author, types.VisibilityFollowers)
+	friends := testutil.CreateStory(t, store, author, types.VisibilityFriends)
+	private := testutil.CreateStory(t, store, author, types.VisibilityPrivate, stranger)
+
+	t.Run("GetUserByEmail", func(t *testing.T) {
+		email := testutil.UniqueEmail("lookup")
+		userID := testutil.CreateUser(t, store, email)
+
+		gotID, hashedPassword, err := store.GetUserByEmail(tenant.Default, email)
+		if err != nil {
+			t.Fatalf("GetUserByEmail failed: %v", err)
+		}
+		if gotID != userID || hashedPassword == "" {
+			t.Errorf("Expected user %s with a password hash, got %s %q", userID, gotID, hashedPassword)
+		}
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		// The same email can register in another tenant
+		email := testutil.UniqueEmail("member")
+		testutil.CreateUser(t, store, email)
+		member := testutil.CreateTenantUser(t, store, "acme", email)
+
+		if gotID, _, err := store.GetUserByEmail("acme", email); err != nil || gotID != member {
+			t.Fatalf("Expected acme user %s, got %s (%v)", member, gotID, err)
+		}
+
+		acmeStory := testutil.CreateStory(t, store, member, types.VisibilityPublic)
+
+		publicStories, err := store.GetAllPublicStories("acme")
+		if err != nil {
+			t.Fatalf("GetAllPublicStories failed: %v", err)
+		}
+		if got := testutil.StoryIDs(publicStories); !slices.Equal(got, []string{acmeStory}) {
+			t.Errorf("Expected acme public stories [%s], got %v", acmeStory, got)
+		}
+
+		canView, err := store.CanUserViewStory(acmeStory, stranger)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("CanUserViewStory failed: %v", err)
+		}
+		if canView {
+			t.Error("Expected public story to be hidden from other tenants")
+		}
+
+		feed, err := store.GetStoriesForUser(context.Background(), member)
+		if err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+		if got := testutil.StoryIDs(feed); !slices.Equal(got, []string{acmeStory}) {
+			t.Errorf("Expected acme feed [%s], got %v", acmeStory, got)
+		}
+
+		if _, err := store.FollowUser(member, author); !errors.Is(err, storage.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound following across tenants, got %v", err)
+		}
+	})
+
+	t.Run("SetUserAdmin", func(t *testing.T) {
+		userID := testutil.CreateUser(t, store, testutil.UniqueEmail("admin"))
+
+		if err := store.SetUserAdmin(userID, true); err != nil {
+			t.Fatalf("SetUserAdmin failed: %v", err)
+		}
+		user, err := store.GetUserByID(userID)
+		if err != nil {
+			t.Fatalf("GetUserByID failed: %v", err)
+		}
+		if !user.IsAdmin {
+			t.Error("Expected user to be an admin")
+		}
+
+		if err := store.SetUserAdmin("999999", true); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for unknown user, got %v", err)
+		}
+	})
+
+	t.Run("SetAvatarURL", func(t *testing.T) {
+		userID := testutil.CreateUser(t, store, testutil.UniqueEmail("avatar"))
+
+		if err := store.SetAvatarURL(userID, "https://cdn.example.com/a.png"); err != nil {
+			t.Fatalf("SetAvatarURL failed: %v", err)
+		}
+		profile, err := store.GetUserProfile(userID)
+		if err != nil {
+			t.Fatalf("GetUserProfile failed: %v", err)
+		}
+		if profile.AvatarURL != "https://cdn.example.com/a.png" {
+			t.Errorf("Expected the avatar on the profile, got %q", profile.AvatarURL)
+		}
+
+		if err := store.SetAvatarURL(userID, ""); err != nil {
+			t.Fatalf("SetAvatarURL failed: %v", err)
+		}
+		public, err := store.GetPublicProfile(userID, userID)
+		if err != nil {
+			t.Fatalf("GetPublicProfile failed: %v", err)
+		}
+		if public.AvatarURL != "" {
+			t.Errorf("Expected the avatar removed, got %q", public.AvatarURL)
+		}
+
+		if err := store.SetAvatarURL("999999", ""); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for unknown user, got %v", err)
+		}
+	})
+
+	t.Run("DeleteStory", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		storyID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+
+		story, err := store.DeleteStory(storyID)
+		if err != nil {
+			t.Fatalf("DeleteStory failed: %v", err)
+		}
+		if story.ID != storyID || story.AuthorID != poster || story.DeletedAt == nil {
+			t.Errorf("Expected deleted story %s, got %+v", storyID, story)
+		}
+
+		if _, err := store.GetStoryByID(storyID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected a deleted story to be gone, got %v", err)
+		}
+		if _, err := store.DeleteStory(storyID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for a deleted story, got %v", err)
+		}
+	})
+
+	t.Run("ExpireStory", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		storyID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+
+		story, err := store.ExpireStory(storyID)
+		if err != nil {
+			t.Fatalf("ExpireStory failed: %v", err)
+		}
+		if story.ID != storyID || story.DeletedAt == nil {
+			t.Errorf("Expected deleted story %s, got %+v", storyID, story)
+		}
+
+		if _, err := store.ExpireStory(storyID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for an expired story, got %v", err)
+		}
+
+		// Expired stories stay in the author's history
+		stories, err := store.GetStoriesByAuthor(poster)
+		if err != nil {
+			t.Fatalf("GetStoriesByAuthor failed: %v", err)
+		}
+		if got := testutil.StoryIDs(stories); !slices.Equal(got, []string{storyID}) {
+			t.Errorf("Expected stories [%s], got %v", storyID, got)
+		}
+	})
+
+	t.Run("NearbyPublicStories", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		lat, lng := 48.8584, 2.2945
+		post := func() string {
+			storyID, err := store.CreateStory(poster, types.StoryPostRequest{Text: "at the tower", Visibility: types.VisibilityPublic, Latitude: &lat, Longitude: &lng})
+			if err != nil {
+				t.Fatalf("CreateStory failed: %v", err)
+			}
+			return storyID
+		}
+		fresh, expired := post(), post()
+
+		// Expired stories are left until the worker deletes them
+		if _, err := store.Db.Exec(`UPDATE stories SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, expired); err != nil {
+			t.Fatalf("Failed to expire story: %v", err)
+		}
+
+		stories, err := store.GetNearbyPublicStories(context.Background(), tenant.Default, stranger, lat, lng, 1000)
+		if err != nil {
+			t.Fatalf("GetNearbyPublicStories failed: %v", err)
+		}
+		if got := testutil.StoryIDs(stories); !slices.Contains(got, fresh) || slices.Contains(got, expired) {
+			t.Errorf("Expected story %s and not the expired %s, got %v", fresh, expired, got)
+		}
+	})
+
+	t.Run("RecordImpressions", func(t *testing.T) {
+		seenAt := time.Now().UTC()
+		impressions := []types.Impression{
+			{StoryID: public, UserID: follower, SeenAt: seenAt},
+			{StoryID: followers, UserID: follower, SeenAt: seenAt},
+			{StoryID: friends, UserID: follower, SeenAt: seenAt}, // not visible to the follower
+			{StoryID: private, UserID: follower, SeenAt: seenAt}, // not in the audience
+			{StoryID: public, UserID: stranger, SeenAt: seenAt},
+			{StoryID: public, UserID: author, SeenAt: seenAt}, // self-impressions are not counted
+		}
+		// Writing the same impressions again changes nothing
+		for range 2 {
+			if err := store.RecordImpressions(impressions); err != nil {
+				t.Fatalf("RecordImpressions failed: %v", err)
+			}
+		}
+
+		stats, err := store.GetUserStats(author)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Impressions != 3 || stats.Reach != 2 {
+			t.Errorf("Expected 3 impressions reaching 2 users, got %d and %d", stats.Impressions, stats.Reach)
+		}
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		// A tenant of its own, so no other test's stories are archivable
+		poster := testutil.CreateTenantUser(t, store, "archive", testutil.UniqueEmail("poster"))
+		member := testutil.CreateTenantUser(t, store, "archive", testutil.UniqueEmail("member"))
+		gone := testutil.CreateStory(t, store, poster, types.VisibilityPrivate, member)
+		highlight := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		active := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		if err := store.RecordStoryView(gone, member); err != nil {
+			t.Fatalf("RecordStoryView failed: %v", err)
+		}
+		if err := store.AddStoryToHighlights(highlight, poster); err != nil {
+			t.Fatalf("AddStoryToHighlights failed: %v", err)
+		}
+		for _, storyID := range []string{gone, highlight} {
+			if _, err := store.ExpireStory(storyID); err != nil {
+				t.Fatalf("ExpireStory failed: %v", err)
+			}
+		}
+
+		if stories, err := store.GetArchivableStories("archive", time.Hour, 10); err != nil || len(stories) != 0 {
+			t.Fatalf("Expected nothing archivable an hour after expiry, got %v (%v)", stories, err)
+		}
+
+		if count, err := store.CountArchivableStories("archive", time.Hour); err != nil || count != 0 {
+			t.Fatalf("Expected a count of 0 an hour after expiry, got %d (%v)", count, err)
+		}
+
+		// Only the expired story outside the highlights is due
+		if count, err := store.CountArchivableStories("archive", 0); err != nil || count != 1 {
+			t.Fatalf("Expected a count of 1, got %d (%v)", count, err)
+		}
+		stories, err := store.GetArchivableStories("archive", 0, 10)
+		if err != nil {
+			t.Fatalf("GetArchivableStories failed: %v", err)
+		}
+		if len(stories) != 1 || stories[0].ID != gone || stories[0].TenantID != "archive" ||
+			!slices.Equal(stories[0].AudienceUserIDs, []string{member}) || stories[0].ViewCount != 1 {
+			t.Fatalf("Expected story %s with its audience and view, got %+v", gone, stories)
+		}
+
+		if err := store.MarkStoriesArchived("archive", "stories/object.jsonl.gz", []string{gone}); err != nil {
+			t.Fatalf("MarkStoriesArchived failed: %v", err)
+		}
+		authored, err := store.GetStoriesByAuthor(poster)
+		if err != nil {
+			t.Fatalf("GetStoriesByAuthor failed: %v", err)
+		}
+		if got := testutil.StoryIDs(authored); slices.Contains(got, gone) || !slices.Contains(got, active) {
+			t.Errorf("Expected the archived story gone from the author's history, got %v", got)
+		}
+		entry, err := store.GetArchiveEntry(gone)
+		if err != nil || entry.TenantID != "archive" || entry.ObjectKey != "stories/object.jsonl.gz" {
+			t.Errorf("Expected an archive entry for %s, got %+v (%v)", gone, entry, err)
+		}
+
+		restored, err := store.RestoreArchivedStory(stories[0])
+		if err != nil {
+			t.Fatalf("RestoreArchivedStory failed: %v", err)
+		}
+		if restored.ID != gone || restored.DeletedAt == nil || restored.Visibility != types.VisibilityPrivate {
+			t.Errorf("Expected story %s restored as expired, got %+v", gone, restored)
+		}
+		if _, err := store.GetArchiveEntry(gone); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected the archive entry to be dropped, got %v", err)
+		}
+	})
+
+	t.Run("FeedChanges", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		watcher := testutil.CreateUser(t, store, testutil.UniqueEmail("watcher"))
+		testutil.Follow(t, store, watcher, poster)
+		deleted := testutil.CreateStory(t, store, poster, types.VisibilityFollowers)
+		expired := testutil.CreateStory(t, store, poster, types.VisibilityFollowers)
+
+		before, err := store.GetFeedChanges(context.Background(), watcher, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("GetFeedChanges failed: %v", err)
+		}
+		since, err := time.Parse(time.RFC3339Nano, before.Cursor)
+		if err != nil {
+			t.Fatalf("Expected an RFC 3339 cursor, got %q", before.Cursor)
+		}
+
+		created := testutil.CreateStory(t, store, poster, types.VisibilityFollowers)
+		testutil.CreateStory(t, store, poster, types.VisibilityPrivate)
+		if _, err := store.DeleteStory(deleted); err != nil {
+			t.Fatalf("DeleteStory failed: %v", err)
+		}
+		if _, err := store.ExpireStory(expired); err != nil {
+			t.Fatalf("ExpireStory failed: %v", err)
+		}
+
+		changes, err := store.GetFeedChanges(context.Background(), watcher, since)
+		if err != nil {
+			t.Fatalf("GetFeedChanges failed: %v", err)
+		}
+		if got := testutil.StoryIDs(changes.Created); !slices.Equal(got, []string{created}) {
+			t.Errorf("Expected created stories [%s], got %v", created, got)
+		}
+		reasons := map[string]string{}
+		for _, removed := range changes.Removed {
+			reasons[removed.StoryID] = removed.Reason
+		}
+		if len(reasons) != 2 || reasons[deleted] != types.RemovalDeleted || reasons[expired] != types.RemovalExpired {
+			t.Errorf("Expected %s deleted and %s expired, got %+v", deleted, expired, changes.Removed)
+		}
+
+		// Polling again from the new cursor finds nothing
+		next, err := time.Parse(time.RFC3339Nano, changes.Cursor)
+		if err != nil {
+			t.Fatalf("Expected an RFC 3339 cursor, got %q", changes.Cursor)
+		}
+		changes, err = store.GetFeedChanges(context.Background(), watcher, next)
+		if err != nil {
+			t.Fatalf("GetFeedChanges failed: %v", err)
+		}
+		if len(changes.Created) != 0 || len(changes.Removed) != 0 {
+			t.Errorf("Expected no changes after the cursor, got %+v", changes)
+		}
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		writer := testutil.CreateUser(t, store, testutil.UniqueEmail("writer"))
+		reader := testutil.CreateUser(t, store, testutil.UniqueEmail("reader"))
+		testutil.Follow(t, store, reader, writer)
+		if err := store.SetNotificationSettings(writer, users.NotificationSettings{Timezone: "Asia/Kolkata"}); err != nil {
+			t.Fatalf("SetNotificationSettings failed: %v", err)
+		}
+		story := testutil.CreateStory(t, store, writer, types.VisibilityPublic)
+
+		feed, err := store.GetStoriesForUser(context.Background(), reader)
+		if err != nil {
+			t.Fatalf("GetStoriesForUser failed: %v", err)
+		}
+		if len(feed) != 1 || feed[0].ID != story {
+			t.Fatalf("Expected only story %s in the feed, got %v", story, testutil.StoryIDs(feed))
+		}
+
+		for name, at := range map[string]time.Time{"created_at": feed[0].CreatedAt, "expires_at": feed[0].ExpiresAt} {
+			if at.IsZero() || at.Location() != time.UTC {
+				t.Errorf("Expected %s in UTC, got %v", name, at)
+			}
+		}
+		if !feed[0].ExpiresAt.After(feed[0].CreatedAt) {
+			t.Errorf("Expected the story to expire after it was created, got %v and %v", feed[0].CreatedAt, feed[0].ExpiresAt)
+		}
+		if feed[0].DeletedAt != nil {
+			t.Errorf("Expected no deleted_at, got %v", feed[0].DeletedAt)
+		}
+		if feed[0].Author == nil || feed[0].Author.UTCOffset != "+05:30" {
+			t.Errorf("Expected the author's UTC offset, got %+v", feed[0].Author)
+		}
+	})
+
+	t.Run("FeedVisibility", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			userID string
+			want   []string
+		}{
+			{"author sees everything", author, []string{private, friends, followers, public}},
+			{"follower sees followers stories", follower, []string{followers, public}},
+			{"mutual follower sees friends stories", friend, []string{friends, followers, public}},
+			{"audience member sees private story", stranger, []string{private, public}},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				stories, err := store.GetStoriesForUser(context.Background(), tc.userID)
+				if err != nil {
+					t.Fatalf("GetStoriesForUser failed: %v", err)
+				}
+				if got := testutil.StoryIDs(stories); !slices.Equal(got, tc.want) {
+					t.Errorf("Expected stories %v, got %v", tc.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("CanUserViewStory", func(t *testing.T) {
+		canView, err := store.CanUserViewStory(private, follower)
+		if err != nil {
+			t.Fatalf("CanUserViewStory failed: %v", err)
+		}
+		if canView {
+			t.Error("Expected follower outside the audience not to see a private story")
+		}
+
+		canView, err = store.CanUserViewStory(followers, follower)
+		if err != nil {
+			t.Fatalf("CanUserViewStory failed: %v", err)
+		}
+		if !canView {
+			t.Error("Expected follower to see a followers story")
+		}
+
+		canView, err = store.CanUserViewStory(friends, follower)
+		if err != nil {
+			t.Fatalf("CanUserViewStory failed: %v", err)
+		}
+		if canView {
+			t.Error("Expected one-way follower not to see a friends story")
+		}
+
+		canView, err = store.CanUserViewStory(friends, friend)
+		if err != nil {
+			t.Fatalf("CanUserViewStory failed: %v", err)
+		}
+		if !canView {
+			t.Error("Expected mutual follower to see a friends story")
+		}
+
+		_, err = store.CanUserViewStory("999999", follower)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for a missing story, got %v", err)
+		}
+	})
+
+	t.Run("ViewsAndReactions", func(t *testing.T) {
+		// Views are idempotent per viewer
+		for i := 0; i < 2; i++ {
+			if err := store.RecordStoryView(public, follower); err != nil {
+				t.Fatalf("RecordStoryView failed: %v", err)
+			}
+		}
+		// A first reaction replaces nothing
+		previous, err := store.AddReaction(public, follower, types.ReactionHeart)
+		if err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+		if previous != "" {
+			t.Errorf("Expected no previous reaction, got %q", previous)
+		}
+
+		// Concurrent reactions from one user still leave a single row, and
+		// each reports the one it replaced: the first the heart, the rest
+		// a laugh
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			replaced = map[types.ReactionType]int{}
+		)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				previous, err := store.AddReaction(public, follower, types.ReactionLaugh)
+				if err != nil {
+					t.Errorf("Concurrent AddReaction failed: %v", err)
+					return
+				}
+				mu.Lock()
+				replaced[previous]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		if replaced[types.ReactionHeart] != 1 || replaced[types.ReactionLaugh] != 9 {
+			t.Errorf("Expected one reaction to replace the heart and nine a laugh, got %v", replaced)
+		}
+
+		// Reacting again replaces the reaction and reports the one replaced
+		previous, err = store.AddReaction(public, follower, types.ReactionFire)
+		if err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+		if previous != types.ReactionLaugh {
+			t.Errorf("Expected previous reaction %q, got %q", types.ReactionLaugh, previous)
+		}
+
+		stats, err := store.GetUserStats(author)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Posted != 4 || stats.Views != 1 || stats.UniqueViewers != 1 {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+		if len(stats.ReactionCounts) != 1 || stats.ReactionCounts[string(types.ReactionFire)] != 1 {
+			t.Errorf("Expected one 🔥 reaction, got %v", stats.ReactionCounts)
+		}
+	})
+
+	t.Run("StorySettings", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("settings"))
+
+		if settings, err := store.GetStorySettings(poster); err != nil || !reflect.DeepEqual(settings, types.StorySettings{}) {
+			t.Fatalf("Expected no defaults before any are set, got %+v, %v", settings, err)
+		}
+
+		private, hours := types.VisibilityPrivate, 12
+		settings, err := store.UpdateStorySettings(poster, types.StorySettingsUpdate{
+			DefaultVisibility:      &private,
+			DefaultAudienceUserIDs: &[]string{follower},
+			DefaultExpiresInHours:  &hours,
+		})
+		want := types.StorySettings{DefaultVisibility: private, DefaultAudienceUserIDs: []string{follower}, DefaultExpiresInHours: 12}
+		if err != nil || !reflect.DeepEqual(settings, want) {
+			t.Fatalf("Expected %+v, got %+v, %v", want, settings, err)
+		}
+
+		// Fields left out keep their value; empty ones clear it
+		cleared := types.Visibility("")
+		settings, err = store.UpdateStorySettings(poster, types.StorySettingsUpdate{DefaultVisibility: &cleared})
+		want.DefaultVisibility = ""
+		if err != nil || !reflect.DeepEqual(settings, want) {
+			t.Errorf("Expected %+v, got %+v, %v", want, settings, err)
+		}
+		if got, err := store.GetStorySettings(poster); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %+v to be stored, got %+v, %v", want, got, err)
+		}
+
+		// Stories live for their own lifetime, or a day
+		for hours, want := range map[int]time.Duration{0: 24 * time.Hour, 3: 3 * time.Hour} {
+			storyID, err := store.CreateStory(poster, types.StoryPostRequest{Visibility: types.VisibilityPublic, ExpiresInHours: hours})
+			if err != nil {
+				t.Fatalf("CreateStory failed: %v", err)
+			}
+			story, err := store.GetStoryByID(storyID)
+			if err != nil {
+				t.Fatalf("GetStoryByID failed: %v", err)
+			}
+			if lifetime := story.ExpiresAt.Sub(story.CreatedAt); lifetime != want {
+				t.Errorf("Expected expires_in_hours %d to live %s, got %s", hours, want, lifetime)
+			}
+		}
+	})
+
+	t.Run("HiddenViewReceipts", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		lurker := testutil.CreateUser(t, store, testutil.UniqueEmail("lurker"))
+		storyID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+
+		if err := store.SetPrivacySettings(lurker, users.PrivacySettings{HideViewReceipts: true}); err != nil {
+			t.Fatalf("SetPrivacySettings failed: %v", err)
+		}
+		if settings, err := store.GetPrivacySettings(lurker); err != nil || !settings.HideViewReceipts {
+			t.Fatalf("Expected view receipts to be hidden, got %+v, %v", settings, err)
+		}
+		for _, viewer := range []string{follower, lurker} {
+			if err := store.RecordStoryView(storyID, viewer); err != nil {
+				t.Fatalf("RecordStoryView failed: %v", err)
+			}
+		}
+
+		viewers, err := store.GetStoryViewers(storyID)
+		if err != nil {
+			t.Fatalf("GetStoryViewers failed: %v", err)
+		}
+		if len(viewers) != 1 || viewers[0].UserID != follower {
+			t.Errorf("Expected only %s among the viewers, got %+v", follower, viewers)
+		}
+
+		// Hidden views still count
+		stats, err := store.GetUserStats(poster)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Views != 2 || stats.UniqueViewers != 2 {
+			t.Errorf("Expected 2 views by 2 viewers, got %+v", stats)
+		}
+
+		if err := store.SetPrivacySettings("999999", users.PrivacySettings{}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for an unknown user, got %v", err)
+		}
+	})
+
+	t.Run("DiscoverUsers", func(t *testing.T) {
+		seeker := testutil.CreateUser(t, store, testutil.UniqueEmail("seeker"))
+		friendEmail := testutil.UniqueEmail("Friend")
+		friend := testutil.CreateUser(t, store, friendEmail)
+		shyEmail := testutil.UniqueEmail("shy")
+		shy := testutil.CreateUser(t, store, shyEmail)
+		elsewhereEmail := testutil.UniqueEmail("discover-elsewhere")
+		testutil.CreateTenantUser(t, store, "discover-co", elsewhereEmail)
+		if _, err := store.FollowUser(seeker, friend); err != nil {
+			t.Fatalf("FollowUser failed: %v", err)
+		}
+		if err := store.SetPrivacySettings(shy, users.PrivacySettings{HideFromDiscovery: true}); err != nil {
+			t.Fatalf("SetPrivacySettings failed: %v", err)
+		}
+
+		hashes := []string{
+			users.ContactHash(friendEmail),
+			users.ContactHash(shyEmail),
+			users.ContactHash(elsewhereEmail),
+			users.ContactHash("nobody@example.com"),
+		}
+		discovered, err := store.DiscoverUsers(seeker, hashes)
+		if err != nil {
+			t.Fatalf("DiscoverUsers failed: %v", err)
+		}
+		want := []users.DiscoveredUser{{Hash: users.ContactHash(friendEmail), UserID: friend, IsFollowing: true}}
+		if !slices.Equal(discovered, want) {
+			t.Errorf("DiscoverUsers() = %+v, want %+v", discovered, want)
+		}
+
+		// Users never find themselves
+		discovered, err = store.DiscoverUsers(friend, []string{users.ContactHash(friendEmail)})
+		if err != nil {
+			t.Fatalf("DiscoverUsers failed: %v", err)
+		}
+		if len(discovered) != 0 {
+			t.Errorf("Expected no match for the caller's own email, got %+v", discovered)
+		}
+	})
+
+	t.Run("RemoveReaction", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		story := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		if _, err := store.AddReaction(story, follower, types.ReactionSad); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+
+		removed, err := store.RemoveReaction(story, follower)
+		if err != nil {
+			t.Fatalf("RemoveReaction failed: %v", err)
+		}
+		if removed != types.ReactionSad {
+			t.Errorf("Expected removed reaction %q, got %q", types.ReactionSad, removed)
+		}
+
+		if _, err := store.RemoveReaction(story, follower); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows once the reaction is gone, got %v", err)
+		}
+	})
+
+	t.Run("SelfInteractions", func(t *testing.T) {
+		creator := testutil.CreateUser(t, store, testutil.UniqueEmail("creator"))
+		story := testutil.CreateStory(t, store, creator, types.VisibilityPublic)
+
+		// By default authors' own views are not recorded and they cannot react
+		if err := store.RecordStoryView(story, creator); err != nil {
+			t.Fatalf("RecordStoryView failed: %v", err)
+		}
+		if _, err := store.AddReaction(story, creator, types.ReactionHeart); !errors.Is(err, storage.ErrSelfReaction) {
+			t.Errorf("Expected storage.ErrSelfReaction, got %v", err)
+		}
+		stats, err := store.GetUserStats(creator)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Views != 0 || len(stats.ReactionCounts) != 0 {
+			t.Errorf("Expected no self-interactions in stats, got %+v", stats)
+		}
+
+		store.Interactions = config.Interactions{CountSelfViews: true, AllowSelfReactions: true}
+		defer func() { store.Interactions = config.Interactions{} }()
+
+		if err := store.RecordStoryView(story, creator); err != nil {
+			t.Fatalf("RecordStoryView failed: %v", err)
+		}
+		if _, err := store.AddReaction(story, creator, types.ReactionHeart); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+		stats, err = store.GetUserStats(creator)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Views != 1 || stats.ReactionCounts[string(types.ReactionHeart)] != 1 {
+			t.Errorf("Expected the self-view and self-reaction in stats, got %+v", stats)
+		}
+	})
+
+	t.Run("FeedTrays", func(t *testing.T) {
+		// The follower viewed the public story above
+		trays, err := store.GetFeedTrays(context.Background(), follower)
+		if err != nil {
+			t.Fatalf("GetFeedTrays failed: %v", err)
+		}
+		if len(trays) != 1 || trays[0].AuthorID != author || trays[0].StoryCount != 2 || trays[0].UnseenCount != 1 || trays[0].Seen {
+			t.Fatalf("Expected one unseen tray of 2 stories by the author, got %+v", trays)
+		}
+		if trays[0].LatestStoryAt == "" {
+			t.Errorf("Expected the latest story time, got %+v", trays[0])
+		}
+
+		if err := store.RecordStoryView(followers, follower); err != nil {
+			t.Fatalf("RecordStoryView failed: %v", err)
+		}
+		trays, err = store.GetFeedTrays(context.Background(), follower)
+		if err != nil {
+			t.Fatalf("GetFeedTrays failed: %v", err)
+		}
+		if len(trays) != 1 || trays[0].UnseenCount != 0 || !trays[0].Seen {
+			t.Errorf("Expected the tray to be seen after viewing every story, got %+v", trays)
+		}
+
+		// Trays only list followed authors, even when their stories are visible
+		trays, err = store.GetFeedTrays(context.Background(), stranger)
+		if err != nil {
+			t.Fatalf("GetFeedTrays failed: %v", err)
+		}
+		if len(trays) != 0 {
+			t.Errorf("Expected no trays for a user following nobody, got %+v", trays)
+		}
+	})
+
+	t.Run("QueuedNotifications", func(t *testing.T) {
+		settings, err := store.GetNotificationSettings(author)
+		if err != nil {
+			t.Fatalf("GetNotificationSettings failed: %v", err)
+		}
+		if settings != (users.NotificationSettings{}) {
+			t.Errorf("Expected quiet hours to be disabled by default, got %+v", settings)
+		}
+
+		quiet := users.NotificationSettings{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Europe/Paris"}
+		if err := store.SetNotificationSettings(author, quiet); err != nil {
+			t.Fatalf("SetNotificationSettings failed: %v", err)
+		}
+
+		for _, eventType := range []types.EventType{types.EventStoryViewed, types.EventStoryViewed, types.EventStoryReacted} {
+			if err := store.QueueNotification(author, types.NewEvent(eventType, nil)); err != nil {
+				t.Fatalf("QueueNotification failed: %v", err)
+			}
+		}
+
+		pending, err := store.GetQueuedNotificationUsers()
+		if err != nil {
+			t.Fatalf("GetQueuedNotificationUsers failed: %v", err)
+		}
+		if pending[author] != quiet {
+			t.Errorf("Expected author's settings among pending digests, got %+v", pending)
+		}
+
+		counts, err := store.ClaimQueuedNotifications(author)
+		if err != nil {
+			t.Fatalf("ClaimQueuedNotifications failed: %v", err)
+		}
+		if counts[types.EventStoryViewed] != 2 || counts[types.EventStoryReacted] != 1 {
+			t.Errorf("Unexpected queued notification counts: %v", counts)
+		}
+
+		// Claimed notifications are only summarized once
+		counts, err = store.ClaimQueuedNotifications(author)
+		if err != nil {
+			t.Fatalf("ClaimQueuedNotifications failed: %v", err)
+		}
+		if len(counts) != 0 {
+			t.Errorf("Expected no notifications left to claim, got %v", counts)
+		}
+	})
+
+	t.Run("FollowerDigests", func(t *testing.T) {
+		creator := testutil.CreateUser(t, store, testutil.UniqueEmail("creator"))
+		testutil.Follow(t, store, stranger, creator) // Before opting in, so never emailed
+
+		if err := store.SetNotificationSettings(creator, users.NotificationSettings{EmailNewFollowers: true}); err != nil {
+			t.Fatalf("SetNotificationSettings failed: %v", err)
+		}
+		testutil.Follow(t, store, follower, creator)
+		testutil.Follow(t, store, friend, creator)
+
+		digestFor := func() *users.FollowerDigest {
+			digests, err := store.GetFollowerDigests(100)
+			if err != nil {
+				t.Fatalf("GetFollowerDigests failed: %v", err)
+			}
+			for _, digest := range digests {
+				if digest.UserID == creator {
+					return &digest
+				}
+			}
+			return nil
+		}
+
+		digest := digestFor()
+		if digest == nil || digest.NewFollowers != 2 {
+			t.Fatalf("Expected a digest of 2 new followers, got %+v", digest)
+		}
+
+		if err := store.MarkFollowersEmailed(creator, digest.Until); err != nil {
+			t.Fatalf("MarkFollowersEmailed failed: %v", err)
+		}
+		if digest := digestFor(); digest != nil {
+			t.Errorf("Expected no digest after emailing, got %+v", digest)
+		}
+	})
+
+	t.Run("StoryMedia", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("media-poster"))
+		key := "users/" + poster + "/media/photo.jpg"
+		if err := store.CreateMediaUpload(poster, key, "image/jpeg"); err != nil {
+			t.Fatalf("CreateMediaUpload failed: %v", err)
+		}
+		post := func(authorID string) error {
+			_, err := store.CreateStory(authorID, types.StoryPostRequest{MediaKey: key, Visibility: types.VisibilityPublic})
+			return err
+		}
+
+		if err := post(poster); !errors.Is(err, storage.ErrMediaNotConfirmed) {
+			t.Errorf("Expected storage.ErrMediaNotConfirmed before confirming, got %v", err)
+		}
+		if _, err := store.ConfirmMediaUpload(poster, key, 1024); err != nil {
+			t.Fatalf("ConfirmMediaUpload failed: %v", err)
+		}
+		if err := post(stranger); !errors.Is(err, storage.ErrMediaNotOwned) {
+			t.Errorf("Expected storage.ErrMediaNotOwned for another user's media, got %v", err)
+		}
+		if err := post(poster); err != nil {
+			t.Errorf("Expected confirmed media to be posted, got %v", err)
+		}
+	})
+
+	t.Run("Reshare", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("reshare-poster"))
+		original := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		hidden := testutil.CreateStory(t, store, poster, types.VisibilityFriends)
+		reshare := types.ReshareRequest{Text: "look at this", Visibility: types.VisibilityPublic}
+
+		reshareID, parent, err := store.ReshareStory(stranger, original, reshare)
+		if err != nil {
+			t.Fatalf("ReshareStory failed: %v", err)
+		}
+		if parent.ID != original || parent.AuthorID != poster {
+			t.Errorf("Expected the original story by the poster, got %+v", parent)
+		}
+		story, err := store.GetStoryByID(reshareID)
+		if err != nil {
+			t.Fatalf("GetStoryByID failed: %v", err)
+		}
+		if story.ParentStoryID != original || story.AuthorID != stranger || story.Text != "look at this" {
+			t.Errorf("Expected the stranger's reshare of %s, got %+v", original, story)
+		}
+
+		if _, _, err := store.ReshareStory(stranger, original, reshare); !errors.Is(err, storage.ErrAlreadyReshared) {
+			t.Errorf("Expected storage.ErrAlreadyReshared resharing twice, got %v", err)
+		}
+
+		// A reshare of a reshare points at the original, which keeps chains flat
+		chainedID, parent, err := store.ReshareStory(friend, reshareID, reshare)
+		if err != nil {
+			t.Fatalf("ReshareStory of a reshare failed: %v", err)
+		}
+		if parent.ID != original {
+			t.Errorf("Expected the reshare to point at %s, got %s", original, parent.ID)
+		}
+		if _, _, err := store.ReshareStory(poster, chainedID, reshare); !errors.Is(err, storage.ErrSelfReshare) {
+			t.Errorf("Expected storage.ErrSelfReshare resharing your own story back, got %v", err)
+		}
+		if _, _, err := store.ReshareStory(stranger, hidden, reshare); !errors.Is(err, storage.ErrReshareNotPublic) {
+			t.Errorf("Expected storage.ErrReshareNotPublic for a friends story, got %v", err)
+		}
+
+		stats, err := store.GetUserStats(poster)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Reshares != 2 {
+			t.Errorf("Expected 2 reshares of the poster's stories, got %d", stats.Reshares)
+		}
+	})
+
+	t.Run("Groups", func(t *testing.T) {
+		owner := testutil.CreateUser(t, store, testutil.UniqueEmail("group-owner"))
+		member := testutil.CreateUser(t, store, testutil.UniqueEmail("group-member"))
+		outsider := testutil.CreateUser(t, store, testutil.UniqueEmail("group-outsider"))
+		elsewhere := testutil.CreateTenantUser(t, store, "group-co", testutil.UniqueEmail("group-elsewhere"))
+
+		if _, err := store.CreateGroup(owner, "Ski trip", []string{member, elsewhere}); !errors.Is(err, storage.ErrUserNotFound) {
+			t.Errorf("Expected storage.ErrUserNotFound for a member in another tenant, got %v", err)
+		}
+		group, err := store.CreateGroup(owner, "Ski trip", []string{member})
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+		if group.OwnerID != owner || group.MemberCount != 2 {
+			t.Errorf("Expected a group of 2 owned by %s, got %+v", owner, group)
+		}
+
+		post := func(authorID string) (string, error) {
+			return store.CreateStory(authorID, types.StoryPostRequest{Text: "on the slopes", Visibility: types.VisibilityGroup, GroupID: group.ID})
+		}
+		storyID, err := post(member)
+		if err != nil {
+			t.Fatalf("CreateStory into the group failed: %v", err)
+		}
+		if _, err := post(outsider); !errors.Is(err, storage.ErrNotGroupMember) {
+			t.Errorf("Expected storage.ErrNotGroupMember posting from outside, got %v", err)
+		}
+
+		canView := func(userID string) bool {
+			t.Helper()
+			ok, err := store.CanUserViewStory(storyID, userID)
+			if err != nil {
+				t.Fatalf("CanUserViewStory failed: %v", err)
+			}
+			return ok
+		}
+		if !canView(owner) || canView(outsider) {
+			t.Errorf("Expected only members to see the group story")
+		}
+		stories, err := store.GetGroupStories(group.ID, owner)
+		if err != nil {
+			t.Fatalf("GetGroupStories failed: %v", err)
+		}
+		if len(stories) != 1 || stories[0].ID != storyID || stories[0].GroupID != group.ID {
+			t.Errorf("Expected the member's story in the group, got %+v", stories)
+		}
+		if _, err := store.GetGroupStories(group.ID, outsider); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for an outsider, got %v", err)
+		}
+
+		if err := store.AddGroupMembers(group.ID, member, []string{outsider}); !errors.Is(err, storage.ErrNotGroupOwner) {
+			t.Errorf("Expected storage.ErrNotGroupOwner adding as a member, got %v", err)
+		}
+		if err := store.AddGroupMembers(group.ID, owner, []string{outsider, member}); err != nil {
+			t.Fatalf("AddGroupMembers failed: %v", err)
+		}
+		if !canView(outsider) {
+			t.Error("Expected a new member to see stories posted before they joined")
+		}
+
+		if err := store.RemoveGroupMember(group.ID, member, outsider); !errors.Is(err, storage.ErrNotGroupOwner) {
+			t.Errorf("Expected storage.ErrNotGroupOwner removing someone else as a member, got %v", err)
+		}
+		if err := store.RemoveGroupMember(group.ID, owner, owner); !errors.Is(err, storage.ErrGroupOwnerLeaving) {
+			t.Errorf("Expected storage.ErrGroupOwnerLeaving for the owner, got %v", err)
+		}
+		if err := store.RemoveGroupMember(group.ID, member, member); err != nil {
+			t.Fatalf("RemoveGroupMember failed: %v", err)
+		}
+		members, err := store.GetGroupMembers(group.ID)
+		if err != nil {
+			t.Fatalf("GetGroupMembers failed: %v", err)
+		}
+		if len(members) != 2 || members[0].UserID != owner || members[1].UserID != outsider {
+			t.Errorf("Expected the owner and the new member left, got %+v", members)
+		}
+		if stories, _ := store.GetGroupStories(group.ID, outsider); len(stories) != 1 {
+			t.Errorf("Expected the story to stay after its author left, got %d", len(stories))
+		}
+
+		groups, err := store.GetGroups(member)
+		if err != nil {
+			t.Fatalf("GetGroups failed: %v", err)
+		}
+		if len(groups) != 0 {
+			t.Errorf("Expected no groups after leaving, got %+v", groups)
+		}
+	})
+
+	t.Run("GetStoryAudience", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			storyID  string
+			audience []string
+		}{
+			{"public", public, []string{follower, friend}},
+			{"followers", followers, []string{follower, friend}},
+			{"friends", friends, []string{friend}},
+			{"private", private, []string{stranger}},
+		} {
+			audience, err := store.GetStoryAudience(tc.storyID)
+			if err != nil {
+				t.Fatalf("GetStoryAudience failed for %s: %v", tc.name, err)
+			}
+			slices.Sort(audience)
+			slices.Sort(tc.audience)
+			if !slices.Equal(audience, tc.audience) {
+				t.Errorf("Expected the %s story audience %v, got %v", tc.name, tc.audience, audience)
+			}
+		}
+	})
+
+	t.Run("UpdateStory", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("edit-poster"))
+		storyID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		text := "edited"
+
+		story, _, err := store.UpdateStory(storyID, 1, types.StoryUpdateRequest{Text: &text})
+		if err != nil {
+			t.Fatalf("UpdateStory failed: %v", err)
+		}
+		if story.Text != text || story.Version != 2 || story.Visibility != types.VisibilityPublic {
+			t.Errorf("Expected the edited public story at version 2, got %+v", story)
+		}
+
+		stale := "stale"
+		story, _, err = store.UpdateStory(storyID, 1, types.StoryUpdateRequest{Text: &stale})
+		if !errors.Is(err, storage.ErrVersionConflict) {
+			t.Fatalf("Expected storage.ErrVersionConflict for version 1, got %v", err)
+		}
+		if story.Text != text || story.Version != 2 {
+			t.Errorf("Expected the story as it is now with the conflict, got %+v", story)
+		}
+
+		// A new visibility replaces the audience
+		story, revoked, err := store.UpdateStory(storyID, 2, types.StoryUpdateRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{stranger}})
+		if err != nil {
+			t.Fatalf("UpdateStory to PRIVATE failed: %v", err)
+		}
+		if story.Visibility != types.VisibilityPrivate || story.Text != text {
+			t.Errorf("Expected a private story keeping its text, got %+v", story)
+		}
+		// Everyone in the tenant could see it, and only the audience still can
+		if !slices.Contains(revoked, follower) || slices.Contains(revoked, stranger) || slices.Contains(revoked, poster) {
+			t.Errorf("Expected the follower and not the audience or author shut out, got %v", revoked)
+		}
+
+		// Widening the audience shuts no one out
+		if _, revoked, err := store.UpdateStory(storyID, 3, types.StoryUpdateRequest{Visibility: types.VisibilityPublic}); err != nil || len(revoked) != 0 {
+			t.Errorf("Expected no one shut out of a public story, got %v (%v)", revoked, err)
+		}
+		if _, revoked, err := store.UpdateStory(storyID, 4, types.StoryUpdateRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{stranger}}); err != nil || !slices.Contains(revoked, follower) {
+			t.Errorf("Expected the follower shut out again, got %v (%v)", revoked, err)
+		}
+		if _, revoked, err := store.UpdateStory(storyID, 5, types.StoryUpdateRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{stranger, follower}}); err != nil || len(revoked) != 0 {
+			t.Errorf("Expected no one shut out by adding to the audience, got %v (%v)", revoked, err)
+		}
+		for userID, want := range map[string]bool{stranger: true, follower: false} {
+			if ok, err := store.CanUserViewStory(storyID, userID); err != nil || ok != want {
+				t.Errorf("Expected CanUserViewStory(%s) = %v, got %v (%v)", userID, want, ok, err)
+			}
+		}
+
+		if _, _, err := store.UpdateStory(storyID, 6, types.StoryUpdateRequest{Visibility: types.VisibilityGroup, GroupID: "999999"}); !errors.Is(err, storage.ErrNotGroupMember) {
+			t.Errorf("Expected storage.ErrNotGroupMember for another group, got %v", err)
+		}
+		if _, err := store.DeleteStory(storyID); err != nil {
+			t.Fatalf("DeleteStory failed: %v", err)
+		}
+		if _, _, err := store.UpdateStory(storyID, 6, types.StoryUpdateRequest{Text: &text}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for a deleted story, got %v", err)
+		}
+	})
+
+	t.Run("HiddenAuthors", func(t *testing.T) {
+		viewer := testutil.CreateUser(t, store, testutil.UniqueEmail("hide-viewer"))
+		author := testutil.CreateUser(t, store, testutil.UniqueEmail("hide-author"))
+		elsewhere := testutil.CreateTenantUser(t, store, "hide-co", testutil.UniqueEmail("hide-elsewhere"))
+		if _, err := store.FollowUser(viewer, author); err != nil {
+			t.Fatalf("FollowUser failed: %v", err)
+		}
+		storyID := testutil.CreateStory(t, store, author, types.VisibilityPublic)
+
+		inFeed := func() bool {
+			t.Helper()
+			stories, err := store.GetStoriesForUser(context.Background(), viewer)
+			if err != nil {
+				t.Fatalf("GetStoriesForUser failed: %v", err)
+			}
+			trays, err := store.GetFeedTrays(context.Background(), viewer)
+			if err != nil {
+				t.Fatalf("GetFeedTrays failed: %v", err)
+			}
+			inStories := slices.ContainsFunc(stories, func(s types.Story) bool { return s.ID == storyID })
+			inTrays := slices.ContainsFunc(trays, func(tray types.FeedTray) bool { return tray.AuthorID == author })
+			if inStories != inTrays {
+				t.Fatalf("Expected the feed and trays to agree, got stories=%v trays=%v", inStories, inTrays)
+			}
+			return inStories
+		}
+		if !inFeed() {
+			t.Fatal("Expected the followed author's story in the feed")
+		}
+
+		if err := store.HideStories(viewer, elsewhere); !errors.Is(err, storage.ErrUserNotFound) {
+			t.Errorf("Expected storage.ErrUserNotFound for a user in another tenant, got %v", err)
+		}
+		for range 2 {
+			if err := store.HideStories(viewer, author); err != nil {
+				t.Fatalf("HideStories failed: %v", err)
+			}
+		}
+		if inFeed() {
+			t.Error("Expected the hidden author's story to leave the feed")
+		}
+		if ok, err := store.CanUserViewStory(storyID, viewer); err != nil || !ok {
+			t.Errorf("Expected the hidden story to stay viewable directly, got %v, %v", ok, err)
+		}
+		hidden, err := store.GetHiddenAuthors(viewer)
+		if err != nil {
+			t.Fatalf("GetHiddenAuthors failed: %v", err)
+		}
+		if len(hidden) != 1 || hidden[0] != author {
+			t.Errorf("Expected only %s hidden, got %v", author, hidden)
+		}
+
+		if err := store.UnhideStories(viewer, author); err != nil {
+			t.Fatalf("UnhideStories failed: %v", err)
+		}
+		if !inFeed() {
+			t.Error("Expected the story back in the feed after unhiding")
+		}
+		if err := store.UnhideStories(viewer, author); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows unhiding twice, got %v", err)
+		}
+	})
+
+	t.Run("AbuseFlags", func(t *testing.T) {
+		admin := testutil.CreateTenantUser(t, store, "abuse-co", testutil.UniqueEmail("abuse-admin"))
+		churner := testutil.CreateTenantUser(t, store, "abuse-co", testutil.UniqueEmail("churner"))
+
+		flag := users.AbuseFlag{UserID: churner, Action: "follow", Count: 301, ThrottledUntil: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+		if err := store.FlagAbuse(flag); err != nil {
+			t.Fatalf("FlagAbuse failed: %v", err)
+		}
+
+		open, err := store.GetAbuseFlags(admin, false, 10)
+		if err != nil {
+			t.Fatalf("GetAbuseFlags failed: %v", err)
+		}
+		if len(open) != 1 || open[0].UserID != churner || open[0].Count != 301 || open[0].ReviewedAt != "" {
+			t.Fatalf("Expected one open flag for the churner, got %+v", open)
+		}
+		if others, err := store.GetAbuseFlags(follower, false, 10); err != nil || len(others) != 0 {
+			t.Errorf("Expected no flags in another tenant, got %+v (%v)", others, err)
+		}
+
+		if _, err := store.ReviewAbuseFlag(follower, open[0].ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows reviewing from another tenant, got %v", err)
+		}
+		reviewed, err := store.ReviewAbuseFlag(admin, open[0].ID)
+		if err != nil {
+			t.Fatalf("ReviewAbuseFlag failed: %v", err)
+		}
+		if reviewed.ReviewedBy != admin || reviewed.ReviewedAt == "" {
+			t.Errorf("Expected the flag reviewed by %s, got %+v", admin, reviewed)
+		}
+		if _, err := store.ReviewAbuseFlag(admin, open[0].ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows reviewing twice, got %v", err)
+		}
+
+		done, err := store.GetAbuseFlags(admin, true, 10)
+		if err != nil {
+			t.Fatalf("GetAbuseFlags failed: %v", err)
+		}
+		if len(done) != 1 || done[0].ID != open[0].ID {
+			t.Errorf("Expected the reviewed flag listed, got %+v", done)
+		}
+	})
+
+	t.Run("Experiments", func(t *testing.T) {
+		reader := testutil.CreateUser(t, store, testutil.UniqueEmail("affinity-reader"))
+		favourite := testutil.CreateUser(t, store, testutil.UniqueEmail("affinity-favourite"))
+		occasional := testutil.CreateUser(t, store, testutil.UniqueEmail("affinity-occasional"))
+
+		for range 2 {
+			if err := store.RecordExposure("feed_ranking", "ranked", reader); err != nil {
+				t.Fatalf("RecordExposure failed: %v", err)
+			}
+		}
+		if err := store.RecordExposure("feed_ranking", "chronological", reader); err != nil {
+			t.Fatalf("RecordExposure of another variant failed: %v", err)
+		}
+		var variant string
+		var exposures int
+		if err := store.Db.QueryRow(`SELECT MIN(variant), COUNT(*) FROM experiment_exposures WHERE experiment = 'feed_ranking' AND user_id = $1`, reader).Scan(&variant, &exposures); err != nil {
+			t.Fatalf("Failed to read exposures: %v", err)
+		}
+		if exposures != 1 || variant != "ranked" {
+			t.Errorf("Expected only the first exposure kept, got %d with %q", exposures, variant)
+		}
+
+		loved := testutil.CreateStory(t, store, favourite, types.VisibilityPublic)
+		seen := testutil.CreateStory(t, store, occasional, types.VisibilityPublic)
+		own := testutil.CreateStory(t, store, reader, types.VisibilityPublic)
+		for _, storyID := range []string{loved, seen, own} {
+			if err := store.RecordStoryView(storyID, reader); err != nil {
+				t.Fatalf("RecordStoryView failed: %v", err)
+			}
+		}
+		for _, storyID := range []string{loved, own} {
+			if _, err := store.AddReaction(storyID, reader, types.ReactionHeart); err != nil {
+				t.Fatalf("AddReaction failed: %v", err)
+			}
+		}
+		// Engagement older than 30 days no longer counts
+		if _, err := store.Db.Exec(`INSERT INTO story_views (story_id, viewer_id, viewed_at) VALUES ($1, $2, NOW() - INTERVAL '31 days')`, seen, reader); err != nil {
+			t.Fatalf("Failed to backdate a view: %v", err)
+		}
+
+		affinity, err := store.GetAuthorAffinity(reader)
+		if err != nil {
+			t.Fatalf("GetAuthorAffinity failed: %v", err)
+		}
+		want := map[string]int{favourite: 3, occasional: 1}
+		if len(affinity) != len(want) || affinity[favourite] != want[favourite] || affinity[occasional] != want[occasional] {
+			t.Errorf("Expected affinity %v without the reader's own stories, got %v", want, affinity)
+		}
+	})
+
+	t.Run("APITokens", func(t *testing.T) {
+		owner := testutil.CreateTenantUser(t, store, "acme", testutil.UniqueEmail("bot-owner"))
+
+		created, err := store.CreateAPIToken(owner, "deploy bot", "hash-read", []string{"read", "post"}, 0)
+		if err != nil {
+			t.Fatalf("CreateAPIToken failed: %v", err)
+		}
+		if created.ExpiresAt != "" || !slices.Equal(created.Scopes, []string{"read", "post"}) {
+			t.Errorf("Expected a non-expiring read/post token, got %+v", created)
+		}
+		expired, err := store.CreateAPIToken(owner, "old bot", "hash-expired", []string{"read"}, -time.Hour)
+		if err != nil {
+			t.Fatalf("CreateAPIToken failed: %v", err)
+		}
+
+		got, err := store.UseAPIToken("hash-read")
+		if err != nil {
+			t.Fatalf("UseAPIToken failed: %v", err)
+		}
+		if got.TokenID != created.ID || got.UserID != owner || got.TenantID != "acme" {
+			t.Errorf("Expected token %s of %s in acme, got %+v", created.ID, owner, got)
+		}
+		if _, err := store.UseAPIToken("hash-expired"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for an expired token, got %v", err)
+		}
+
+		tokens, err := store.GetAPITokens(owner)
+		if err != nil {
+			t.Fatalf("GetAPITokens failed: %v", err)
+		}
+		if len(tokens) != 2 || tokens[0].ID != expired.ID || tokens[1].LastUsedAt == "" {
+			t.Errorf("Expected both tokens newest first with the used one marked, got %+v", tokens)
+		}
+
+		if err := store.RevokeAPIToken(follower, created.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows revoking another user's token, got %v", err)
+		}
+		if err := store.RevokeAPIToken(owner, created.ID); err != nil {
+			t.Fatalf("RevokeAPIToken failed: %v", err)
+		}
+		if _, err := store.UseAPIToken("hash-read"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows for a revoked token, got %v", err)
+		}
+	})
+
+	t.Run("FollowGraph", func(t *testing.T) {
+		alice := testutil.CreateUser(t, store, testutil.UniqueEmail("graph-alice"))
+		bob := testutil.CreateUser(t, store, testutil.UniqueEmail("graph-bob"))
+		carol := testutil.CreateUser(t, store, testutil.UniqueEmail("graph-carol"))
+		elsewhere := testutil.CreateTenantUser(t, store, "graph-co", testutil.UniqueEmail("graph-elsewhere"))
+
+		follows := []struct {
+			name               string
+			follower, followed string
+			wantNew            bool
+			wantErr            bool
+			wantErrIs          error
+		}{
+			{name: "follows someone", follower: alice, followed: bob, wantNew: true},
+			{name: "following again is a no-op", follower: alice, followed: bob},
+			{name: "follows back", follower: bob, followed: alice, wantNew: true},
+			{name: "follows a third user", follower: alice, followed: carol, wantNew: true},
+			{name: "cannot follow yourself", follower: alice, followed: alice, wantErr: true, wantErrIs: storage.ErrSelfFollow},
+			{name: "cannot follow across tenants", follower: alice, followed: elsewhere, wantErr: true, wantErrIs: storage.ErrUserNotFound},
+			{name: "cannot follow a missing user", follower: alice, followed: "999999999", wantErr: true, wantErrIs: storage.ErrUserNotFound},
+			{name: "cannot follow a malformed ID", follower: alice, followed: "bob", wantErr: true, wantErrIs: storage.ErrUserNotFound},
+		}
+		for _, tc := range follows {
+			t.Run(tc.name, func(t *testing.T) {
+				followed, err := store.FollowUser(tc.follower, tc.followed)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("FollowUser() error = %v, wantErr %v", err, tc.wantErr)
+				}
+				if tc.wantErrIs != nil && !errors.Is(err, tc.wantErrIs) {
+					t.Errorf("FollowUser() error = %v, want %v", err, tc.wantErrIs)
+				}
+				if followed != tc.wantNew {
+					t.Errorf("FollowUser() = %v, want %v", followed, tc.wantNew)
+				}
+			})
+		}
+
+		edges := []struct {
+			follower, followed string
+			want               bool
+		}{
+			{alice, bob, true},
+			{bob, alice, true},
+			{alice, carol, true},
+			{carol, alice, false},
+			{alice, alice, false},
+			{alice, elsewhere, false},
+		}
+		for _, tc := range edges {
+			got, err := store.IsFollowing(tc.follower, tc.followed)
+			if err != nil {
+				t.Fatalf("IsFollowing failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("IsFollowing(%s, %s) = %v, want %v", tc.follower, tc.followed, got, tc.want)
+			}
+		}
+
+		lists := []struct {
+			name string
+			list func(string) ([]string, error)
+			user string
+			want []string
+		}{
+			{"alice's followees", store.GetUserFollowees, alice, []string{bob, carol}},
+			{"alice's followers", store.GetUserFollowers, alice, []string{bob}},
+			{"carol's followees", store.GetUserFollowees, carol, nil},
+			{"carol's followers", store.GetUserFollowers, carol, []string{alice}},
+		}
+		for _, tc := range lists {
+			got, err := tc.list(tc.user)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(tc.want))
+			if !slices.Equal(got, want) {
+				t.Errorf("%s = %v, want %v", tc.name, got, want)
+			}
+		}
+	})
+
+	t.Run("FollowBatch", func(t *testing.T) {
+		importer := testutil.CreateUser(t, store, testutil.UniqueEmail("batch-importer"))
+		known := testutil.CreateUser(t, store, testutil.UniqueEmail("batch-known"))
+		fresh := testutil.CreateUser(t, store, testutil.UniqueEmail("batch-fresh"))
+		elsewhere := testutil.CreateTenantUser(t, store, "batch-co", testutil.UniqueEmail("batch-elsewhere"))
+		if _, err := store.FollowUser(importer, known); err != nil {
+			t.Fatalf("FollowUser failed: %v", err)
+		}
+
+		results, err := store.FollowUsers(importer, []string{fresh, known, importer, elsewhere, "999999999", "bob", fresh})
+		if err != nil {
+			t.Fatalf("FollowUsers failed: %v", err)
+		}
+		want := []types.FollowResult{
+			{UserID: fresh, Status: types.FollowFollowed},
+			{UserID: known, Status: types.FollowAlreadyFollowing},
+			{UserID: importer, Status: types.FollowSelf},
+			{UserID: elsewhere, Status: types.FollowNotFound},
+			{UserID: "999999999", Status: types.FollowNotFound},
+			{UserID: "bob", Status: types.FollowNotFound},
+		}
+		if !slices.Equal(results, want) {
+			t.Errorf("FollowUsers() = %v, want %v", results, want)
+		}
+
+		followees, err := store.GetUserFollowees(importer)
+		if err != nil {
+			t.Fatalf("GetUserFollowees failed: %v", err)
+		}
+		slices.Sort(followees)
+		if want := slices.Sorted(slices.Values([]string{known, fresh})); !slices.Equal(followees, want) {
+			t.Errorf("Expected followees %v, got %v", want, followees)
+		}
+
+		results, err = store.UnfollowUsers(importer, []string{known, fresh, elsewhere, importer})
+		if err != nil {
+			t.Fatalf("UnfollowUsers failed: %v", err)
+		}
+		want = []types.FollowResult{
+			{UserID: known, Status: types.FollowUnfollowed},
+			{UserID: fresh, Status: types.FollowUnfollowed},
+			{UserID: elsewhere, Status: types.FollowNotFollowing},
+			{UserID: importer, Status: types.FollowSelf},
+		}
+		if !slices.Equal(results, want) {
+			t.Errorf("UnfollowUsers() = %v, want %v", results, want)
+		}
+	})
+
+	t.Run("StatsWindow", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("window-poster"))
+		fan := testutil.CreateUser(t, store, testutil.UniqueEmail("window-fan"))
+		lapsed := testutil.CreateUser(t, store, testutil.UniqueEmail("window-lapsed"))
+		recent := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		old := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+
+		for _, view := range []struct{ storyID, viewerID string }{{recent, fan}, {old, fan}, {recent, lapsed}} {
+			if err := store.RecordStoryView(view.storyID, view.viewerID); err != nil {
+				t.Fatalf("RecordStoryView failed: %v", err)
+			}
+		}
+		if _, err := store.AddReaction(recent, fan, types.ReactionHeart); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+		if _, err := store.AddReaction(recent, lapsed, types.ReactionFire); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+		for _, userID := range []string{fan, lapsed} {
+			if err := store.RecordLinkClick(recent, userID); err != nil {
+				t.Fatalf("RecordLinkClick failed: %v", err)
+			}
+		}
+
+		// Move the old story and everything the lapsed user did out of the
+		// 7-day window
+		for _, stmt := range []struct {
+			query string
+			args  []any
+		}{
+			{"UPDATE stories SET created_at = NOW() - INTERVAL '8 days' WHERE id = $1", []any{old}},
+			{"UPDATE story_views SET viewed_at = NOW() - INTERVAL '8 days' WHERE viewer_id = $1", []any{lapsed}},
+			{"UPDATE reactions SET reacted_at = NOW() - INTERVAL '8 days' WHERE user_id = $1", []any{lapsed}},
+			{"UPDATE story_link_clicks SET clicked_at = NOW() - INTERVAL '8 days' WHERE user_id = $1", []any{lapsed}},
+		} {
+			if _, err := store.Db.Exec(stmt.query, stmt.args...); err != nil {
+				t.Fatalf("Failed to backdate: %v", err)
+			}
+		}
+
+		stats, err := store.GetUserStats(poster)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		tests := []struct {
+			name string
+			got  int
+			want int
+		}{
+			{"posted leaves out stories older than 7 days", stats.Posted, 1},
+			{"views count recent views of old stories", stats.Views, 2},
+			{"unique viewers", stats.UniqueViewers, 1},
+			{"link clicks", stats.LinkClicks, 1},
+			{"recent reactions", stats.ReactionCounts[string(types.ReactionHeart)], 1},
+			{"old reactions", stats.ReactionCounts[string(types.ReactionFire)], 0},
+		}
+		for _, tc := range tests {
+			if tc.got != tc.want {
+				t.Errorf("%s: got %d, want %d", tc.name, tc.got, tc.want)
+			}
+		}
+	})
+
+	t.Run("UnitOfWork", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("unit"))
+		failed := errors.New("something later failed")
+		post := types.StoryPostRequest{Text: "in a unit", Visibility: types.VisibilityPublic}
+
+		// A failure rolls back every write, including the story's own transaction
+		err := store.InTx(context.Background(), func(tx storage.Storage) error {
+			if _, err := tx.CreateStory(poster, post); err != nil {
+				return err
+			}
+			if _, err := tx.FollowUser(poster, author); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("Expected InTx to return fn's error, got %v", err)
+		}
+		if stories, err := store.GetStoriesByAuthor(poster); err != nil || len(stories) != 0 {
+			t.Errorf("Expected the story to be rolled back, got %v (%v)", stories, err)
+		}
+		if following, err := store.IsFollowing(poster, author); err != nil || following {
+			t.Errorf("Expected the follow to be rolled back, got %v (%v)", following, err)
+		}
+
+		// A nested unit joins the outer one and commits with it
+		var storyID string
+		err = store.InTx(context.Background(), func(tx storage.Storage) error {
+			if _, err := tx.FollowUser(poster, author); err != nil {
+				return err
+			}
+			return tx.InTx(context.Background(), func(tx storage.Storage) (err error) {
+				storyID, err = tx.CreateStory(poster, post)
+				return err
+			})
+		})
+		if err != nil {
+			t.Fatalf("InTx failed: %v", err)
+		}
+		if stories, err := store.GetStoriesByAuthor(poster); err != nil || !slices.Equal(testutil.StoryIDs(stories), []string{storyID}) {
+			t.Errorf("Expected story %s to be committed, got %v (%v)", storyID, stories, err)
+		}
+		if following, err := store.IsFollowing(poster, author); err != nil || !following {
+			t.Errorf("Expected the follow to be committed, got %v (%v)", following, err)
+		}
+	})
+
+	t.Run("UnitOfWorkDeadlockRetry", func(t *testing.T) {
+		first := testutil.CreateUser(t, store, testutil.UniqueEmail("lock-first"))
+		second := testutil.CreateUser(t, store, testutil.UniqueEmail("lock-second"))
+
+		// Two units lock the same users in opposite orders, each waiting on
+		// its first attempt until the other holds its first lock. Postgres
+		// aborts one as a deadlock, and that one is run again.
+		var locked sync.WaitGroup
+		locked.Add(2)
+		lockBoth := func(a, b string, attempts *int) error {
+			return store.InTx(context.Background(), func(tx storage.Storage) error {
+				*attempts++
+				if err := tx.SetPrivacySettings(a, users.PrivacySettings{HideViewReceipts: true}); err != nil {
+					return err
+				}
+				if *attempts == 1 {
+					locked.Done()
+					locked.Wait()
+				}
+				return tx.SetPrivacySettings(b, users.PrivacySettings{HideViewReceipts: true})
+			})
+		}
+
+		var wg sync.WaitGroup
+		var attemptsA, attemptsB int
+		var errA, errB error
+		wg.Add(2)
+		go func() { defer wg.Done(); errA = lockBoth(first, second, &attemptsA) }()
+		go func() { defer wg.Done(); errB = lockBoth(second, first, &attemptsB) }()
+		wg.Wait()
+
+		if errA != nil || errB != nil {
+			t.Fatalf("Expected both units to commit, got %v and %v", errA, errB)
+		}
+		if attemptsA+attemptsB != 3 {
+			t.Errorf("Expected one unit to be run again, got %d and %d attempts", attemptsA, attemptsB)
+		}
+	})
+
+	t.Run("Unfollow", func(t *testing.T) {
+		if err := store.UnfollowUser(follower, author); err != nil {
+			t.Fatalf("UnfollowUser failed: %v", err)
+		}
+		if err := store.UnfollowUser(follower, author); err == nil {
+			t.Error("Expected an error when unfollowing twice")
+		}
+
+		following, err := store.IsFollowing(follower, author)
+		if err != nil {
+			t.Fatalf("IsFollowing failed: %v", err)
+		}
+		if following {
+			t.Error("Expected follow relationship to be removed")
+		}
+	})
+
+	t.Run("WeeklyRecaps", func(t *testing.T) {
+		ctx := context.Background()
+		creator := testutil.CreateUser(t, store, testutil.UniqueEmail("recap-creator"))
+		fan := testutil.CreateUser(t, store, testutil.UniqueEmail("recap-fan"))
+		other := testutil.CreateUser(t, store, testutil.UniqueEmail("recap-other"))
+		top := testutil.CreateStory(t, store, creator, types.VisibilityPublic)
+		second := testutil.CreateStory(t, store, creator, types.VisibilityPublic)
+		testutil.Follow(t, store, fan, creator)
+		testutil.Follow(t, store, other, creator)
+
+		for _, view := range []struct{ storyID, viewerID string }{{top, fan}, {top, other}, {second, fan}} {
+			if err := store.RecordStoryView(view.storyID, view.viewerID); err != nil {
+				t.Fatalf("RecordStoryView failed: %v", err)
+			}
+		}
+		seenAt := time.Now().UTC()
+		if err := store.RecordImpressions([]types.Impression{
+			{StoryID: top, UserID: fan, SeenAt: seenAt},
+			{StoryID: second, UserID: fan, SeenAt: seenAt},
+			{StoryID: top, UserID: other, SeenAt: seenAt},
+		}); err != nil {
+			t.Fatalf("RecordImpressions failed: %v", err)
+		}
+
+		// A week starting today holds everything above
+		week := time.Now().UTC().Truncate(24 * time.Hour)
+		creators, err := store.GetActiveCreators(ctx, week)
+		if err != nil {
+			t.Fatalf("GetActiveCreators failed: %v", err)
+		}
+		if !slices.Contains(creators, creator) || slices.Contains(creators, fan) {
+			t.Errorf("Expected the creator and not the fan to be active, got %v", creators)
+		}
+
+		recap, err := store.SaveWeeklyRecap(ctx, creator, week)
+		if err != nil {
+			t.Fatalf("SaveWeeklyRecap failed: %v", err)
+		}
+		want := users.WeeklyRecap{
+			WeekStart:     week.Format(time.DateOnly),
+			WeekEnd:       week.AddDate(0, 0, 6).Format(time.DateOnly),
+			Posted:        2,
+			Views:         3,
+			Reach:         2,
+			Followers:     2,
+			FollowerDelta: 2,
+			TopStory:      &users.RecapStory{ID: top, Views: 2},
+			CreatedAt:     recap.CreatedAt,
+		}
+		if !reflect.DeepEqual(recap, want) {
+			t.Errorf("Unexpected recap:\n got %+v\nwant %+v", recap, want)
+		}
+
+		// The recap is made once, however the week goes on
+		if _, err := store.FollowUser(testutil.CreateUser(t, store, testutil.UniqueEmail("recap-late")), creator); err != nil {
+			t.Fatalf("FollowUser failed: %v", err)
+		}
+		if err := store.MarkWeeklyRecapDelivered(ctx, creator, week); err != nil {
+			t.Fatalf("MarkWeeklyRecapDelivered failed: %v", err)
+		}
+		again, err := store.SaveWeeklyRecap(ctx, creator, week)
+		if err != nil {
+			t.Fatalf("SaveWeeklyRecap failed: %v", err)
+		}
+		if again.Followers != 2 || !again.Delivered {
+			t.Errorf("Expected the saved, delivered recap, got %+v", again)
+		}
+
+		// The next week's delta is measured against this recap
+		next, err := store.SaveWeeklyRecap(ctx, creator, week.AddDate(0, 0, 7))
+		if err != nil {
+			t.Fatalf("SaveWeeklyRecap failed: %v", err)
+		}
+		if next.Posted != 0 || next.Views != 0 || next.TopStory != nil || next.Followers != 3 || next.FollowerDelta != 1 {
+			t.Errorf("Unexpected recap of the next week: %+v", next)
+		}
+
+		recaps, err := store.GetWeeklyRecaps(ctx, creator, 10)
+		if err != nil {
+			t.Fatalf("GetWeeklyRecaps failed: %v", err)
+		}
+		if len(recaps) != 2 || recaps[0].WeekStart != next.WeekStart || recaps[1].WeekStart != recap.WeekStart {
+			t.Errorf("Expected both recaps, newest first, got %+v", recaps)
+		}
+		if recaps, err := store.GetWeeklyRecaps(ctx, fan, 10); err != nil || recaps == nil || len(recaps) != 0 {
+			t.Errorf("Expected no recaps for the fan, got %v, %v", recaps, err)
+		}
+	})
+
+	t.Run("ViewLimit", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		viewer := testutil.CreateUser(t, store, testutil.UniqueEmail("viewer"))
+		storyID, err := store.CreateStory(poster, types.StoryPostRequest{Text: "twice", Visibility: types.VisibilityPublic, MaxViewsPerUser: 2})
+		if err != nil {
+			t.Fatalf("CreateStory failed: %v", err)
+		}
+
+		story, err := store.GetStoryForViewer(storyID, viewer)
+		if err != nil {
+			t.Fatalf("GetStoryForViewer failed: %v", err)
+		}
+		if story.MaxViewsPerUser != 2 || story.Consumed || story.Text != "twice" {
+			t.Errorf("Expected an unconsumed story allowing 2 views, got %+v", story)
+		}
+
+		// Concurrent views cannot open it more times than it allows
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var opened, consumed int
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := store.RecordStoryView(storyID, viewer)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					opened++
+				case errors.Is(err, storage.ErrStoryConsumed):
+					consumed++
+				default:
+					t.Errorf("RecordStoryView failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if opened != 2 || consumed != 3 {
+			t.Errorf("Expected 2 views and 3 refused, got %d and %d", opened, consumed)
+		}
+
+		story, err = store.GetStoryForViewer(storyID, viewer)
+		if err != nil {
+			t.Fatalf("GetStoryForViewer failed: %v", err)
+		}
+		if !story.Consumed || story.Text != "" || story.MaxViewsPerUser != 2 {
+			t.Errorf("Expected a consumed story without its text, got %+v", story)
+		}
+
+		// Feeds show it consumed to the viewer alone
+		for userID, want := range map[string]bool{viewer: true, poster: false} {
+			feed, err := store.GetStoriesForUser(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("GetStoriesForUser failed: %v", err)
+			}
+			i := slices.IndexFunc(feed, func(s types.Story) bool { return s.ID == storyID })
+			if i < 0 || feed[i].Consumed != want || (feed[i].Text == "") != want {
+				t.Errorf("Expected the story consumed %v in the feed of %s, got %+v", want, userID, feed)
+			}
+		}
+
+		// Replays are one viewer in stats, and the author never uses it up
+		if viewers, err := store.GetStoryViewers(storyID); err != nil || len(viewers) != 1 {
+			t.Errorf("Expected one viewer, got %v (%v)", viewers, err)
+		}
+		if story, err := store.GetStoryForViewer(storyID, poster); err != nil || story.Consumed {
+			t.Errorf("Expected the author's story unconsumed, got %+v (%v)", story, err)
+		}
+
+		// Limited stories cannot be reshared around the limit
+		if _, _, err := store.ReshareStory(viewer, storyID, types.ReshareRequest{Visibility: types.VisibilityPublic}); !errors.Is(err, storage.ErrReshareNotPublic) {
+			t.Errorf("Expected ErrReshareNotPublic, got %v", err)
+		}
+	})
+
+	t.Run("Screenshots", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		viewer := testutil.CreateUser(t, store, testutil.UniqueEmail("viewer"))
+		storyID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+
+		// Each kind is recorded once per viewer, and authors' own never
+		for _, tc := range []struct {
+			userID, kind string
+			first        bool
+		}{
+			{viewer, types.CaptureScreenshot, true},
+			{viewer, types.CaptureScreenshot, false},
+			{viewer, types.CaptureRecording, true},
+			{poster, types.CaptureScreenshot, false},
+		} {
+			first, err := store.RecordScreenshot(storyID, tc.userID, tc.kind)
+			if err != nil {
+				t.Fatalf("RecordScreenshot failed: %v", err)
+			}
+			if first != tc.first {
+				t.Errorf("Expected first %v for %s by %s, got %v", tc.first, tc.kind, tc.userID, first)
+			}
+		}
+
+		stats, err := store.GetUserStats(poster)
+		if err != nil {
+			t.Fatalf("GetUserStats failed: %v", err)
+		}
+		if stats.Screenshots != 2 {
+			t.Errorf("Expected 2 screenshots in stats, got %d", stats.Screenshots)
+		}
+
+		today := time.Now().UTC()
+		var screenshots int
+		err = store.StreamDailyStoryMetrics(context.Background(), poster, today, today, func(m users.DailyStoryMetrics) error {
+			screenshots += m.Screenshots
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StreamDailyStoryMetrics failed: %v", err)
+		}
+		if screenshots != 2 {
+			t.Errorf("Expected 2 screenshots in the daily metrics, got %d", screenshots)
+		}
+	})
+
+	t.Run("Permissions", func(t *testing.T) {
+		poster := testutil.CreateUser(t, store, testutil.UniqueEmail("poster"))
+		resharer := testutil.CreateUser(t, store, testutil.UniqueEmail("resharer"))
+
+		// Stories allow everything unless told otherwise
+		openID := testutil.CreateStory(t, store, poster, types.VisibilityPublic)
+		open, err := store.GetStoryByID(openID)
+		if err != nil {
+			t.Fatalf("GetStoryByID failed: %v", err)
+		}
+		if !open.AllowReshare || !open.AllowReply || !open.AllowScreenshotNotification {
+			t.Errorf("Expected a story allowing everything, got %+v", open)
+		}
+
+		no := false
+		closedID, err := store.CreateStory(poster, types.StoryPostRequest{
+			Text:                        "mine alone",
+			Visibility:                  types.VisibilityPublic,
+			AllowReshare:                &no,
+			AllowScreenshotNotification: &no,
+		})
+		if err != nil {
+			t.Fatalf("CreateStory failed: %v", err)
+		}
+		closed, err := store.GetStoryByID(closedID)
+		if err != nil {
+			t.Fatalf("GetStoryByID failed: %v", err)
+		}
+		if closed.AllowReshare || !closed.AllowReply || closed.AllowScreenshotNotification {
+			t.Errorf("Expected only replies allowed, got %+v", closed)
+		}
+
+		if _, _, err := store.ReshareStory(resharer, closedID, types.ReshareRequest{Visibility: types.VisibilityPublic}); !errors.Is(err, storage.ErrReshareNotAllowed) {
+			t.Errorf("Expected ErrReshareNotAllowed, got %v", err)
+		}
+		if _, _, err := store.ReshareStory(resharer, openID, types.ReshareRequest{Visibility: types.VisibilityPublic}); err != nil {
+			t.Errorf("ReshareStory failed: %v", err)
+		}
+	})
+}
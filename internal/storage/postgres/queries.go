@@ -0,0 +1,349 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// StatementBuilder builds Postgres statements with $n placeholders. Builders
+// nested inside sq.Expr must use the plain sq package instead, since only the
+// outermost statement should number its placeholders.
+var StatementBuilder = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// StoryColumns returns the story columns, in the order StoryFields expects,
+// for a stories table aliased as alias
+func StoryColumns(alias string) []string {
+	return []string{
+		alias + ".id",
+		alias + ".author_id",
+		alias + ".text",
+		alias + ".media_key",
+		alias + ".visibility",
+		alias + ".created_at",
+		alias + ".expires_at",
+		alias + ".deleted_at",
+		"COALESCE(" + alias + ".link_url, '') AS link_url",
+		alias + ".latitude",
+		alias + ".longitude",
+		"COALESCE(" + alias + ".place_name, '') AS place_name",
+		alias + ".encrypted",
+		"COALESCE(" + alias + ".parent_story_id::TEXT, '') AS parent_story_id",
+		"COALESCE(" + alias + ".group_id::TEXT, '') AS group_id",
+		alias + ".version",
+		"COALESCE(" + alias + ".max_views_per_user, 0) AS max_views_per_user",
+		alias + ".allow_reshare",
+		alias + ".allow_reply",
+		alias + ".allow_screenshot_notification",
+	}
+}
+
+// StoryFields returns scan destinations for the columns listed by StoryColumns
+func StoryFields(s *types.Story) []any {
+	return []any{
+		&s.ID, &s.AuthorID, &s.Text, &s.MediaKey, &s.Visibility, Time(&s.CreatedAt), Time(&s.ExpiresAt), NullTime(&s.DeletedAt),
+		&s.LinkURL, &s.Latitude, &s.Longitude, &s.PlaceName, &s.Encrypted, &s.ParentStoryID, &s.GroupID,
+		&s.Version, &s.MaxViewsPerUser, &s.AllowReshare, &s.AllowReply, &s.AllowScreenshotNotification,
+	}
+}
+
+// ConsumedBy selects whether viewerID has opened a story, aliased alias, as
+// many times as its max_views_per_user allows. Its author never uses it up.
+func ConsumedBy(alias, viewerID string) sq.Sqlizer {
+	return sq.Expr(alias+".max_views_per_user IS NOT NULL AND "+alias+".author_id <> ?::integer AND "+
+		"COALESCE((SELECT v.view_count FROM story_views v WHERE v.story_id = "+alias+".id AND v.viewer_id = ?::integer), 0) >= "+alias+".max_views_per_user",
+		viewerID, viewerID)
+}
+
+// ConsumedField returns the scan destination for ConsumedBy. It must follow
+// the story's own columns, since a consumed story is stripped of its text,
+// media and link as it is scanned.
+func ConsumedField(s *types.Story) any {
+	return consumed{s}
+}
+
+// consumed scans ConsumedBy into a story, stripping it if it was used up
+type consumed struct {
+	s *types.Story
+}
+
+func (c consumed) Scan(src any) error {
+	var used sql.NullBool
+	if err := used.Scan(src); err != nil {
+		return err
+	}
+	if c.s.Consumed = used.Bool; c.s.Consumed {
+		c.s.Text, c.s.MediaKey, c.s.LinkURL = "", "", ""
+	}
+	return nil
+}
+
+// tenantOf selects the tenant userID belongs to, for use as a value in a query
+func tenantOf(userID string) sq.Sqlizer {
+	return sq.Expr("(SELECT tenant_id FROM users WHERE id = ?::integer)", userID)
+}
+
+// InTenantOf matches rows whose column holds the tenant userID belongs to
+func InTenantOf(column, userID string) sq.Sqlizer {
+	return sq.Expr(column+" = (SELECT tenant_id FROM users WHERE id = ?::integer)", userID)
+}
+
+// VisibleTo matches stories (aliased s) that userID may see, which are always
+// in the user's own tenant
+func VisibleTo(userID string) sq.Sqlizer {
+	return sq.And{
+		InTenantOf("s.tenant_id", userID),
+		visibilityRules(userID),
+	}
+}
+
+// InFeedOf matches stories (aliased s) that belong in userID's feed: those
+// VisibleTo them whose author they have not hidden
+func InFeedOf(userID string) sq.Sqlizer {
+	return sq.And{
+		VisibleTo(userID),
+		sq.Expr("NOT EXISTS (SELECT 1 FROM hidden_authors h WHERE h.user_id = ?::integer AND h.author_id = s.author_id)", userID),
+	}
+}
+
+// FeedOrder orders stories (aliased alias) as feeds list them: newest first
+// and, between stories created at the same instant, by ID, highest first, so
+// pages cut by a types.FeedCursor neither skip nor repeat stories
+func FeedOrder(alias string) []string {
+	return []string{alias + ".created_at DESC", alias + ".id DESC"}
+}
+
+// AfterCursor matches stories (aliased alias) that come after cursor in
+// FeedOrder
+func AfterCursor(alias string, cursor types.FeedCursor) sq.Sqlizer {
+	return sq.Expr("("+alias+".created_at, "+alias+".id) < (?, ?::integer)", cursor.CreatedAt.UTC(), cursor.ID)
+}
+
+// visibilityRules matches stories (aliased s) whose visibility lets userID see
+// them, given the follow graph between the user and the author and the groups
+// the user belongs to
+func visibilityRules(userID string) sq.Sqlizer {
+	return viewerRules("?::integer", userID)
+}
+
+// viewerRules is visibilityRules for the viewer given by the SQL expression
+// viewer and its args, such as a column of a joined users table
+func viewerRules(viewer string, args ...any) sq.Sqlizer {
+	followsAuthor := sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = "+viewer+" AND f.followed_id = s.author_id)", args...)
+	followedByAuthor := sq.Expr("EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = s.author_id AND f.followed_id = "+viewer+")", args...)
+	inAudience := sq.Expr("EXISTS (SELECT 1 FROM story_audience sa WHERE sa.story_id = s.id AND sa.user_id = "+viewer+")", args...)
+	inGroup := sq.Expr("EXISTS (SELECT 1 FROM story_group_members gm WHERE gm.group_id = s.group_id AND gm.user_id = "+viewer+")", args...)
+
+	return sq.Or{
+		sq.Eq{"s.visibility": types.VisibilityPublic},
+		sq.And{sq.Eq{"s.visibility": types.VisibilityFollowers}, followsAuthor},
+		sq.And{sq.Eq{"s.visibility": types.VisibilityFriends}, followsAuthor, followedByAuthor},
+		sq.And{sq.Eq{"s.visibility": types.VisibilityPrivate}, inAudience},
+		sq.And{sq.Eq{"s.visibility": types.VisibilityGroup}, inGroup},
+		sq.Expr("s.author_id = "+viewer, args...),
+	}
+}
+
+// storyViewers selects the IDs of the users other than its author who may
+// see the story, by the same rules as VisibleTo
+func storyViewers(storyID string) sq.SelectBuilder {
+	return StatementBuilder.
+		Select("u.id::text").
+		From("stories s").
+		Join("users u ON u.tenant_id = s.tenant_id AND u.id <> s.author_id").
+		Where("s.id = ?::integer", storyID).
+		Where(viewerRules("u.id")).
+		OrderBy("u.id")
+}
+
+// allowed reads a story permission of a post request, which allows what it
+// leaves out
+func allowed(permission *bool) bool {
+	return permission == nil || *permission
+}
+
+// selectStories starts a query for active stories aliased as s
+func selectStories() sq.SelectBuilder {
+	return StatementBuilder.
+		Select(StoryColumns("s")...).
+		From("stories s").
+		Where(sq.Eq{"s.deleted_at": nil})
+}
+
+// scanStory scans a row selected with StoryColumns into a story
+func scanStory(row rowScanner) (types.Story, error) {
+	var s types.Story
+	err := row.Scan(StoryFields(&s)...)
+	return s, err
+}
+
+// selectViewerStories starts a query for active stories aliased s as viewerID
+// sees them, scanned by scanViewerStory, so stories they used up come back
+// consumed
+func selectViewerStories(viewerID string) sq.SelectBuilder {
+	return selectStories().Column(ConsumedBy("s", viewerID))
+}
+
+// scanViewerStory scans a row selected by selectViewerStories into a story
+func scanViewerStory(row rowScanner) (types.Story, error) {
+	var s types.Story
+	err := row.Scan(append(StoryFields(&s), ConsumedField(&s))...)
+	return s, err
+}
+
+// AuthorColumns lists the columns of a story's author, aliased u, scanned by
+// AuthorFields
+var AuthorColumns = []string{
+	"u.email",
+	"COALESCE(u.avatar_url, '')",
+	"COALESCE((SELECT ns.timezone FROM notification_settings ns WHERE ns.user_id = u.id), '')",
+}
+
+// AuthorFields returns scan destinations for AuthorColumns, setting the
+// story's author. They must follow the story's own columns, since the
+// author's UTC offset is taken at the story's creation time.
+func AuthorFields(s *types.Story) []any {
+	s.Author = &types.StoryAuthor{}
+	return []any{&s.Author.Email, &s.Author.AvatarURL, authorOffset{s}}
+}
+
+// authorOffset scans the author's time zone into their UTC offset when the
+// story was posted
+type authorOffset struct {
+	s *types.Story
+}
+
+func (a authorOffset) Scan(src any) error {
+	var zone sql.NullString
+	if err := zone.Scan(src); err != nil {
+		return err
+	}
+	a.s.Author.UTCOffset = types.UTCOffset(zone.String, a.s.CreatedAt)
+	return nil
+}
+
+// selectFeedStories starts a query for stories as viewerID sees them with
+// their authors, scanned by scanFeedStory. The author is joined in the same
+// query, so a feed of any length costs one round trip.
+func selectFeedStories(viewerID string) sq.SelectBuilder {
+	return selectViewerStories(viewerID).
+		Columns(AuthorColumns...).
+		Join("users u ON u.id = s.author_id")
+}
+
+// scanFeedStory scans a row selected by selectFeedStories into a story with
+// its author
+func scanFeedStory(row rowScanner) (types.Story, error) {
+	var s types.Story
+	fields := append(StoryFields(&s), ConsumedField(&s))
+	err := row.Scan(append(fields, AuthorFields(&s)...)...)
+	s.Author.ID = s.AuthorID
+	return s, err
+}
+
+// queryStories runs a query selecting StoryColumns and scans every row
+func queryStories(ctx context.Context, db queryer, query sq.Sqlizer) ([]types.Story, error) {
+	var stories []types.Story
+	err := eachStory(ctx, db, query, func(s types.Story) error {
+		stories = append(stories, s)
+		return nil
+	})
+	return stories, err
+}
+
+// eachStory runs a query selecting StoryColumns and calls fn with each row as
+// it is scanned, stopping at the first error fn returns
+func eachStory(ctx context.Context, db queryer, query sq.Sqlizer, fn func(types.Story) error) error {
+	return scanEach(ctx, db, query, scanStory, fn)
+}
+
+// scanEach runs a query, scans each row with scan and calls fn with the
+// result, stopping at the first error fn returns
+func scanEach(ctx context.Context, db queryer, query sq.Sqlizer, scan func(rowScanner) (types.Story, error), fn func(types.Story) error) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryStory runs a query selecting StoryColumns and scans the single row
+func queryStory(ctx context.Context, db queryer, query sq.Sqlizer) (types.Story, error) {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return types.Story{}, err
+	}
+	return scanStory(db.QueryRowContext(ctx, sqlStr, args...))
+}
+
+// queryRow runs a query and scans its single row into dest
+func queryRow(ctx context.Context, db queryer, query sq.Sqlizer, dest ...any) error {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, sqlStr, args...).Scan(dest...)
+}
+
+// queryStrings runs a query selecting a single text column
+func queryStrings(ctx context.Context, db queryer, query sq.Sqlizer) ([]string, error) {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// exec runs a statement that returns no rows
+func exec(ctx context.Context, db queryer, query sq.Sqlizer) (sql.Result, error) {
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, sqlStr, args...)
+}
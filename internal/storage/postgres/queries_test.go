@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+func TestStoryColumnsMatchFields(t *testing.T) {
+	var story types.Story
+	if columns, fields := len(StoryColumns("s")), len(StoryFields(&story)); columns != fields {
+		t.Fatalf("StoryColumns has %d columns but StoryFields has %d destinations", columns, fields)
+	}
+}
+
+func TestVisibleTo(t *testing.T) {
+	query := selectStories().Where(VisibleTo("42"))
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	want := "WHERE s.deleted_at IS NULL AND (s.tenant_id = (SELECT tenant_id FROM users WHERE id = $1::integer) " +
+		"AND (s.visibility = $2 " +
+		"OR (s.visibility = $3 AND EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = $4::integer AND f.followed_id = s.author_id)) " +
+		"OR (s.visibility = $5 AND EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = $6::integer AND f.followed_id = s.author_id) " +
+		"AND EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = s.author_id AND f.followed_id = $7::integer)) " +
+		"OR (s.visibility = $8 AND EXISTS (SELECT 1 FROM story_audience sa WHERE sa.story_id = s.id AND sa.user_id = $9::integer)) " +
+		"OR (s.visibility = $10 AND EXISTS (SELECT 1 FROM story_group_members gm WHERE gm.group_id = s.group_id AND gm.user_id = $11::integer)) " +
+		"OR s.author_id = $12::integer))"
+	if !strings.HasSuffix(sqlStr, want) {
+		t.Fatalf("Unexpected visibility predicate:\n%s", sqlStr)
+	}
+	if len(args) != 12 {
+		t.Fatalf("Unexpected args: %v", args)
+	}
+	for _, i := range []int{0, 3, 5, 6, 8, 10, 11} {
+		if args[i] != "42" {
+			t.Fatalf("Expected user ID at arg %d, got %v", i, args)
+		}
+	}
+	wantVisibility := map[int]types.Visibility{1: types.VisibilityPublic, 2: types.VisibilityFollowers, 4: types.VisibilityFriends, 7: types.VisibilityPrivate, 9: types.VisibilityGroup}
+	for i, visibility := range wantVisibility {
+		if args[i] != visibility {
+			t.Fatalf("Expected %s at arg %d, got %v", visibility, i, args)
+		}
+	}
+}
+
+func TestStoryViewers(t *testing.T) {
+	sqlStr, args, err := storyViewers("7").ToSql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	// The rules are VisibleTo's, with each user in the tenant as the viewer
+	for _, want := range []string{
+		"JOIN users u ON u.tenant_id = s.tenant_id AND u.id <> s.author_id",
+		"f.follower_id = u.id AND f.followed_id = s.author_id",
+		"sa.story_id = s.id AND sa.user_id = u.id",
+		"OR s.author_id = u.id)",
+	} {
+		if !strings.Contains(sqlStr, want) {
+			t.Errorf("Expected %q in:\n%s", want, sqlStr)
+		}
+	}
+	if len(args) != 6 || args[0] != "7" || args[1] != types.VisibilityPublic {
+		t.Errorf("Expected the story ID and the five visibilities as args, got %v", args)
+	}
+}
+
+func TestAfterCursor(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("", 2*3600))
+	query := selectStories().Where(AfterCursor("s", types.FeedCursor{CreatedAt: at, ID: "42"})).OrderBy(FeedOrder("s")...)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if want := "WHERE s.deleted_at IS NULL AND (s.created_at, s.id) < ($1, $2::integer) ORDER BY s.created_at DESC, s.id DESC"; !strings.HasSuffix(sqlStr, want) {
+		t.Fatalf("Unexpected cursor query:\n%s", sqlStr)
+	}
+	if len(args) != 2 || !args[0].(time.Time).Equal(at) || args[0].(time.Time).Location() != time.UTC || args[1] != "42" {
+		t.Fatalf("Expected the cursor's UTC time and ID, got %v", args)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	cases := map[string]struct {
+		src     any
+		want    string
+		wantErr bool
+	}{
+		"utc":      {time.Date(2024, 3, 9, 16, 35, 1, 120000000, time.UTC), "2024-03-09T16:35:01.12Z", false},
+		"offset":   {time.Date(2024, 3, 9, 22, 5, 1, 0, time.FixedZone("", 5*3600+1800)), "2024-03-09T16:35:01Z", false},
+		"null":     {nil, "", false},
+		"not time": {"2024-03-09 16:35:01", "", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := "unset"
+			err := Timestamp(&got).Scan(tc.src)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFollowable(t *testing.T) {
+	got := followable("1", []string{"1", "2", "abc", "2147483647", "2147483648", "99999999999"})
+	if !slices.Equal(got, []string{"2", "2147483647"}) {
+		t.Errorf("Expected only other IDs that fit an integer, got %v", got)
+	}
+}
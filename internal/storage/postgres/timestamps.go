@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// Timestamp returns a scan destination writing a TIMESTAMP column to dst in
+// types.TimeLayout, or "" when it is NULL. Columns hold UTC times, since every
+// connection runs in the UTC time zone.
+func Timestamp(dst *string) sql.Scanner {
+	return timestamp{dst}
+}
+
+// Time returns a scan destination writing a TIMESTAMP column to dst in UTC
+func Time(dst *time.Time) sql.Scanner {
+	return utcTime{dst}
+}
+
+type utcTime struct {
+	dst *time.Time
+}
+
+func (t utcTime) Scan(src any) error {
+	at, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T as a time", src)
+	}
+	*t.dst = at.UTC()
+	return nil
+}
+
+// NullTime returns a scan destination writing a nullable TIMESTAMP column to
+// dst in UTC, or nil when it is NULL
+func NullTime(dst **time.Time) sql.Scanner {
+	return nullTime{dst}
+}
+
+type nullTime struct {
+	dst **time.Time
+}
+
+func (t nullTime) Scan(src any) error {
+	if src == nil {
+		*t.dst = nil
+		return nil
+	}
+	var at time.Time
+	if err := (utcTime{&at}).Scan(src); err != nil {
+		return err
+	}
+	*t.dst = &at
+	return nil
+}
+
+type timestamp struct {
+	dst *string
+}
+
+func (t timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*t.dst = ""
+	case time.Time:
+		*t.dst = types.FormatTime(v)
+	default:
+		return fmt.Errorf("cannot scan %T as a timestamp", src)
+	}
+	return nil
+}
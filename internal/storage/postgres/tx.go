@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+)
+
+// maxTxAttempts caps how often a transaction that failed to serialize or
+// deadlocked is run
+const maxTxAttempts = 3
+
+// txRetryDelay is the base of the jittered pause before a transaction is run
+// again, growing with each attempt
+const txRetryDelay = 20 * time.Millisecond
+
+// InTx runs fn as a unit of work: every statement run through the store fn is
+// given, including those of methods with a transaction of their own, runs in
+// one transaction, committed if fn returns nil and rolled back otherwise.
+// Inside a unit InTx joins it. A unit that fails to serialize or deadlocks is
+// run again from the start, so fn must only write through the store and leave
+// other side effects until InTx returns. The transaction holds a single
+// connection, so fn must not use the store from several goroutines, and a
+// statement that fails aborts the unit even if fn handles its error.
+func (p *Postgres) InTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	if p.tx != nil {
+		return fn(p)
+	}
+
+	return p.retry(ctx, func(tx *sql.Tx) error {
+		unit := *p
+		unit.tx = tx
+		return fn(&unit)
+	})
+}
+
+// transact runs fn in a transaction, committed if fn returns nil and rolled
+// back otherwise, and returns the commit's error as well as fn's. Like a unit
+// of work it is run again if it fails to serialize or deadlocks, so fn must
+// be safe to repeat. Inside a unit fn runs in the unit's transaction, which
+// the unit retries as a whole.
+func (p *Postgres) transact(ctx context.Context, fn func(tx queryer) error) error {
+	if p.tx != nil {
+		return fn(p.tx)
+	}
+
+	return p.retry(ctx, func(tx *sql.Tx) error {
+		return fn(tx)
+	})
+}
+
+// retry runs fn in a new transaction until it commits, fails for a reason
+// other than a serialization failure or deadlock, or has been tried
+// maxTxAttempts times
+func (p *Postgres) retry(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := p.runTx(ctx, fn)
+		reason, retryable := retryReason(err)
+		if !retryable || attempt == maxTxAttempts {
+			return err
+		}
+		metrics.TxRetried(reason)
+
+		delay := time.Duration(attempt) * txRetryDelay
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay/2 + rand.N(delay/2)):
+		}
+	}
+}
+
+// runTx runs fn once in a new transaction and commits or rolls it back. A
+// rollback that fails is reported along with fn's error.
+func (p *Postgres) runTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := p.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rollbackErr))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// retryReason reports whether err is a serialization failure or deadlock,
+// which running the transaction again may get past, and names which
+func retryReason(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+	switch pqErr.Code {
+	case "40001":
+		return "serialization_failure", true
+	case "40P01":
+		return "deadlock", true
+	}
+	return "", false
+}
+
+// db returns where statements run: the unit of work's transaction inside one,
+// the connection pool otherwise
+func (p *Postgres) db() queryer {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.Db
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRetryReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+		wantRetry  bool
+	}{
+		{"no error", nil, "", false},
+		{"serialization failure", &pq.Error{Code: "40001"}, "serialization_failure", true},
+		{"deadlock", &pq.Error{Code: "40P01"}, "deadlock", true},
+		{"wrapped deadlock", fmt.Errorf("commit: %w", &pq.Error{Code: "40P01"}), "deadlock", true},
+		{"unique violation", &pq.Error{Code: "23505"}, "", false},
+		{"not a Postgres error", sql.ErrNoRows, "", false},
+		{"joined with a rollback error", errors.Join(&pq.Error{Code: "40001"}, sql.ErrConnDone), "serialization_failure", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, retry := retryReason(tt.err)
+			if reason != tt.wantReason || retry != tt.wantRetry {
+				t.Errorf("retryReason() = %q, %v, want %q, %v", reason, retry, tt.wantReason, tt.wantRetry)
+			}
+		})
+	}
+}
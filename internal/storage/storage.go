@@ -1,24 +1,292 @@
 package storage
 
-import "github.com/princekumarofficial/stories-service/internal/types"
+import (
+	"context"
+	"errors"
+	"time"
 
-type Storage interface {
-	CreateStory(authorID, text, mediaKey string, visibility types.Visibility, audienceUserIDs []string) (string, error)
-	CreateUser(email, password string) (string, error)
-	GetUserByEmail(email string) (string, string, error)
-	GetAllPublicStories() ([]types.Story, error)
-	GetStoriesForUser(userID string) ([]types.Story, error)
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/media"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+)
+
+// ErrUserNotFound is returned when a user does not exist, or belongs to
+// another tenant than the one acting on it
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrSelfFollow is returned when a user follows themselves
+var ErrSelfFollow = errors.New("users cannot follow themselves")
+
+// ErrSelfReaction is returned when an author reacts to their own story while
+// the interactions config does not allow it
+var ErrSelfReaction = errors.New("authors cannot react to their own stories")
+
+// ErrReshareNotPublic is returned when a story to reshare is not an active
+// public story
+var ErrReshareNotPublic = errors.New("only active public stories can be reshared")
+
+// ErrReshareNotAllowed is returned when a story's author turned off resharing
+var ErrReshareNotAllowed = errors.New("the author does not allow this story to be reshared")
+
+// ErrSelfReshare is returned when a user reshares their own story, directly
+// or through someone else's reshare of it, which would loop back to them
+var ErrSelfReshare = errors.New("users cannot reshare their own stories")
+
+// ErrAlreadyReshared is returned when a user reshares a story their active
+// reshare of it already shares
+var ErrAlreadyReshared = errors.New("story already reshared")
+
+// ErrMediaNotOwned is returned when a story's media_key is not an upload its
+// author started
+var ErrMediaNotOwned = errors.New("media was not uploaded by the author")
+
+// ErrMediaNotConfirmed is returned when a story's media_key is an upload its
+// author has not confirmed
+var ErrMediaNotConfirmed = errors.New("media upload is not confirmed")
+
+// ErrNotGroupMember is returned when a user posts into, or manages, a group
+// they are not a member of
+var ErrNotGroupMember = errors.New("user is not a member of the group")
+
+// ErrNotGroupOwner is returned when a member other than the owner changes
+// who else is in a group
+var ErrNotGroupOwner = errors.New("only the group owner can manage its members")
+
+// ErrGroupOwnerLeaving is returned when a group's owner is removed from it;
+// a group always keeps its owner
+var ErrGroupOwnerLeaving = errors.New("the group owner cannot leave the group")
+
+// ErrVersionConflict is returned when a story is edited from a version other
+// than its current one, because someone else edited it first
+var ErrVersionConflict = errors.New("story was edited since that version")
+
+// ErrStoryConsumed is returned when a viewer opens a story more times than
+// its max_views_per_user allows
+var ErrStoryConsumed = errors.New("story was viewed as many times as it allows")
+
+// StoryStore creates, reads and expires stories
+type StoryStore interface {
+	CreateStory(authorID string, story types.StoryPostRequest) (string, error)                        // ErrMediaNotOwned or ErrMediaNotConfirmed unless the media is the author's confirmed upload; ErrNotGroupMember for another group
+	GetStorySettings(userID string) (types.StorySettings, error)                                      // Empty until the user sets them
+	UpdateStorySettings(userID string, update types.StorySettingsUpdate) (types.StorySettings, error) // The settings after the update
+	GetAllPublicStories(tenantID string) ([]types.Story, error)
+	GetStoriesForUser(ctx context.Context, userID string) ([]types.Story, error)
+	StreamStoriesForUser(ctx context.Context, userID string, fn func(types.Story) error) error     // Calls fn per story as rows are scanned
+	GetFeedTrays(ctx context.Context, userID string) ([]types.FeedTray, error)                     // One entry per followed author with visible stories
+	GetFeedChanges(ctx context.Context, userID string, since time.Time) (types.FeedChanges, error) // Stories created, deleted or expired since a point in time
 	GetStoryByID(storyID string) (types.Story, error)
+	GetStoryForViewer(storyID, viewerID string) (types.Story, error) // Consumed, without its content, once the viewer used up their views
+	GetStoriesByIDs(storyIDs []string) ([]types.Story, error)        // Stories not deleted, in no particular order
+	GetNearbyPublicStories(ctx context.Context, tenantID, viewerID string, lat, lng, radiusMeters float64) ([]types.Story, error)
 	CanUserViewStory(storyID, userID string) (bool, error)
-	RecordStoryView(storyID, viewerID string) error
-	AddReaction(storyID, userID string, emoji types.ReactionType) error
-	GetUserStats(userID string) (int, int, int, map[string]int, error)
-	// Follow methods
-	FollowUser(followerID, followedID string) error
+	GetStoryAudience(storyID string) ([]string, error) // Who may have the story in their feed, other than its author
+	AddStoryToHighlights(storyID, userID string) error
+	GetStoriesByAuthor(authorID string) ([]types.Story, error) // Includes expired and deleted stories
+	// ReshareStory shares the story, or the original a reshare points to, as
+	// a new story by userID, returning its ID and the original
+	ReshareStory(userID, storyID string, reshare types.ReshareRequest) (string, types.Story, error)
+	// UpdateStory edits an active story if it is still at version, returning
+	// it as edited with the users a new visibility shut out, or as it is now
+	// along with ErrVersionConflict
+	UpdateStory(storyID string, version int, update types.StoryUpdateRequest) (types.Story, []string, error)
+	// Ephemerality methods
+	DeleteStory(storyID string) (types.Story, error)
+	ExpireStory(storyID string) (types.Story, error)
+	SoftDeleteExpiredStories() ([]types.Story, error)
+	ClaimExpiringStories(within time.Duration) ([]types.Story, error)
+	ReleaseExpiryWarning(storyID string) error // Lets a claimed story whose warning was not sent be claimed again
+}
+
+// GroupStore manages the groups users share stories in and who belongs to
+// them. Groups outside the user's membership are reported as not found
+// (sql.ErrNoRows) to everyone but their members.
+type GroupStore interface {
+	CreateGroup(ownerID, name string, memberIDs []string) (types.StoryGroup, error) // ErrUserNotFound when a member is outside the owner's tenant
+	GetGroups(userID string) ([]types.StoryGroup, error)                            // Groups the user belongs to, newest first
+	GetGroup(groupID, userID string) (types.StoryGroup, error)
+	GetGroupMembers(groupID string) ([]types.GroupMember, error)     // Earliest to join first
+	AddGroupMembers(groupID, ownerID string, userIDs []string) error // ErrNotGroupOwner for other members; ErrUserNotFound outside the tenant
+	RemoveGroupMember(groupID, actorID, userID string) error         // Members may remove themselves, the owner anyone but themselves
+	GetGroupStories(groupID, userID string) ([]types.Story, error)   // Active GROUP stories posted into it, newest first
+}
+
+// UserStore manages accounts and per-user statistics
+type UserStore interface {
+	CreateUser(tenantID, email, password string) (string, error)
+	GetUserByEmail(tenantID, email string) (string, string, error)
+	GetUserByID(userID string) (users.User, error)
+	GetUserProfile(userID string) (users.Profile, error)
+	GetPublicProfile(viewerID, userID string) (users.PublicProfile, error)        // ErrUserNotFound outside the viewer's tenant
+	DiscoverUsers(userID string, hashes []string) ([]users.DiscoveredUser, error) // Users of userID's tenant whose email hashes to one of hashes, except those hidden from discovery
+	GetTenantUsers(tenantID string, userIDs []string) ([]string, error)           // Those of userIDs that are users of the tenant
+	SetUserAdmin(userID string, isAdmin bool) error
+	SetAvatarURL(userID, avatarURL string) error // An empty URL removes the avatar; sql.ErrNoRows for an unknown user
+	GetUserStats(userID string) (users.UserStats, error)
+	StreamDailyStoryMetrics(ctx context.Context, userID string, from, to time.Time, fn func(users.DailyStoryMetrics) error) error // Days from through to, by day then story
+}
+
+// GraphStore manages the follow graph between users and the authors each
+// user hid from their feed
+type GraphStore interface {
+	FollowUser(followerID, followedID string) (bool, error) // True unless already following; ErrSelfFollow for themselves, ErrUserNotFound outside the follower's tenant
 	UnfollowUser(followerID, followedID string) error
+	FollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error)   // One result per distinct ID, in order, from a single statement
+	UnfollowUsers(followerID string, followedIDs []string) ([]types.FollowResult, error) // One result per distinct ID, in order, from a single statement
 	IsFollowing(followerID, followedID string) (bool, error)
 	GetUserFollowees(userID string) ([]string, error) // Get list of users this user follows
 	GetUserFollowers(userID string) ([]string, error) // Get list of users following this user
-	// Ephemerality methods
-	SoftDeleteExpiredStories() (int, error)
+	HideStories(userID, authorID string) error        // ErrUserNotFound outside the user's tenant
+	UnhideStories(userID, authorID string) error      // sql.ErrNoRows unless the author was hidden
+	GetHiddenAuthors(userID string) ([]string, error) // Authors whose stories are left out of the user's feed
+}
+
+// ReactionStore records reactions to stories
+type ReactionStore interface {
+	AddReaction(storyID, userID string, emoji types.ReactionType) (types.ReactionType, error) // Returns the replaced reaction, if any
+	RemoveReaction(storyID, userID string) (types.ReactionType, error)                        // Returns the removed reaction; sql.ErrNoRows if there was none
+}
+
+// ViewStore records story views and link clicks
+type ViewStore interface {
+	RecordStoryView(storyID, viewerID string) error              // ErrStoryConsumed beyond the story's max_views_per_user
+	GetStoryViewers(storyID string) ([]types.StoryViewer, error) // Latest first; leaves out viewers hiding their view receipts
+	RecordLinkClick(storyID, userID string) error
+	RecordScreenshot(storyID, userID, kind string) (bool, error) // Whether it is the user's first of its kind on the story; ignores authors
+	RecordImpressions(impressions []types.Impression) error      // Skips stories the user cannot see and impressions already recorded
+}
+
+// ShareLinkStore manages the public links authors create for their stories
+type ShareLinkStore interface {
+	CreateShareLink(storyID string, requireLogin bool, validFor time.Duration) (types.ShareLink, error)
+	GetShareLinks(storyID string) ([]types.ShareLink, error)
+	RevokeShareLink(storyID, linkID string) error
+	GetActiveShareLink(linkID string) (types.ShareLink, error) // Unrevoked, unexpired and on an active story
+	RecordShareLinkView(linkID string) error
+}
+
+// APITokenStore keeps the API tokens users create for programmatic access,
+// looked up by the hash of the token
+type APITokenStore interface {
+	CreateAPIToken(userID, name, tokenHash string, scopes []string, validFor time.Duration) (users.APIToken, error) // Never expires when validFor is 0
+	GetAPITokens(userID string) ([]users.APIToken, error)                                                           // Unrevoked ones, expired included
+	RevokeAPIToken(userID, tokenID string) error                                                                    // sql.ErrNoRows if the user has no such token
+	UseAPIToken(tokenHash string) (users.APITokenOwner, error)                                                      // Records the use; sql.ErrNoRows unless active
+}
+
+// PrivacyStore keeps the settings users choose for what others learn about
+// their activity
+type PrivacyStore interface {
+	GetPrivacySettings(userID string) (users.PrivacySettings, error)
+	SetPrivacySettings(userID string, settings users.PrivacySettings) error // sql.ErrNoRows for an unknown user
+}
+
+// EncryptionStore keeps users' public keys and the envelopes of end-to-end
+// encrypted stories, which CreateStory stores along with the story
+type EncryptionStore interface {
+	SetPublicKey(userID string, key users.PublicKeyRequest) (users.PublicKey, error)
+	GetPublicKey(viewerID, userID string) (users.PublicKey, error)        // sql.ErrNoRows if none was published in the viewer's tenant
+	GetStoryEnvelope(storyID, userID string) (types.StoryEnvelope, error) // sql.ErrNoRows unless the story is active and has a key for the user
+}
+
+// AuditStore keeps the trail of admins impersonating users
+type AuditStore interface {
+	RecordImpersonation(event users.ImpersonationEvent) error
+	GetImpersonationEvents(adminID, userID string, limit int) ([]users.ImpersonationEvent, error) // In the admin's tenant, newest first; every user when userID is empty
+}
+
+// AbuseStore keeps the accounts the abuse detector flagged for review
+type AbuseStore interface {
+	FlagAbuse(flag users.AbuseFlag) error
+	GetAbuseFlags(adminID string, reviewed bool, limit int) ([]users.AbuseFlag, error) // In the admin's tenant, newest first
+	ReviewAbuseFlag(adminID, flagID string) (users.AbuseFlag, error)                   // sql.ErrNoRows unless an open flag in the admin's tenant
+}
+
+// ExperimentStore records who was exposed to the server-side experiments and
+// reads the signals the experimental feed ranking uses
+type ExperimentStore interface {
+	RecordExposure(experiment, variant, userID string) error // Keeps the first exposure per user and experiment
+	GetAuthorAffinity(userID string) (map[string]int, error) // Weighted views and reactions by the user per author over the last 30 days
+}
+
+// ArchiveStore moves stories long gone from feeds out of the hot tables into
+// the cold archive, and back again
+type ArchiveStore interface {
+	GetArchivableStories(tenantID string, olderThan time.Duration, limit int) ([]types.ArchivedStory, error) // Deleted or expired before then, except highlights and encrypted stories; oldest first
+	CountArchivableStories(tenantID string, olderThan time.Duration) (int, error)                            // How many GetArchivableStories would return without a limit
+	MarkStoriesArchived(tenantID, objectKey string, storyIDs []string) error                                 // Records the object holding them and deletes them
+	GetArchiveEntry(storyID string) (types.ArchiveEntry, error)                                              // sql.ErrNoRows unless the story is archived
+	RestoreArchivedStory(story types.ArchivedStory) (types.Story, error)                                     // ErrUserNotFound once the author is gone
+}
+
+// MediaStore keeps a record of every media upload, so uploads can be confirmed
+// and the bucket reconciled against them
+type MediaStore interface {
+	CreateMediaUpload(userID, objectKey, contentType string) error
+	ConfirmMediaUpload(userID, objectKey string, size int64) (media.MediaUpload, error)   // sql.ErrNoRows unless the user started the upload
+	GetMediaUpload(userID, objectKey string) (media.MediaUpload, error)                   // sql.ErrNoRows unless the user started the upload
+	SetMediaUploadStatus(objectKey, status string, size int64) (media.MediaUpload, error) // sql.ErrNoRows once confirmed or failed
+	GetMediaUploads(tenantID string) ([]media.MediaUpload, error)
+	GetUserMediaUploads(ctx context.Context, tenantID, userID, after string, limit int) ([]media.MediaUpload, error) // Uploaded or confirmed, by object key
+	DeleteMediaUpload(objectKey string) error
+	GetTenantIDs() ([]string, error) // Every tenant with users
+}
+
+// NotificationStore keeps notification settings and the notifications held
+// back during quiet hours for the daily digest
+type NotificationStore interface {
+	GetNotificationSettings(userID string) (users.NotificationSettings, error)
+	SetNotificationSettings(userID string, settings users.NotificationSettings) error
+	QueueNotification(userID string, event *types.Event) error
+	GetQueuedNotificationUsers() (map[string]users.NotificationSettings, error) // Users with queued notifications, by ID
+	ClaimQueuedNotifications(userID string) (map[types.EventType]int, error)    // Deletes and counts them by type
+}
+
+// EmailStore finds users due an email notification and records what was sent,
+// in batches of at most limit users
+type EmailStore interface {
+	GetFollowerDigests(limit int) ([]users.FollowerDigest, error)
+	MarkFollowersEmailed(userID string, until time.Time) error
+	GetWeeklyStatsRecipients(limit int) ([]users.EmailRecipient, error)
+	MarkWeeklyStatsEmailed(userID string) error
+}
+
+// RecapStore makes and keeps creators' weekly recaps. Weeks start on
+// Mondays, at midnight UTC.
+type RecapStore interface {
+	GetActiveCreators(ctx context.Context, weekStart time.Time) ([]string, error)                       // Users who posted a story during the week
+	SaveWeeklyRecap(ctx context.Context, userID string, weekStart time.Time) (users.WeeklyRecap, error) // Makes the recap once; later calls return the saved one
+	MarkWeeklyRecapDelivered(ctx context.Context, userID string, weekStart time.Time) error
+	GetWeeklyRecaps(ctx context.Context, userID string, limit int) ([]users.WeeklyRecap, error) // Newest first
+}
+
+// Transactor runs several writes as one unit of work, so a handler that makes
+// more than one either applies all of them or none
+type Transactor interface {
+	InTx(ctx context.Context, fn func(tx Storage) error) error // Commits if fn returns nil, rolls back otherwise
+}
+
+// Storage is the full data layer; backends and wrappers such as the cache
+// implement all of it, while consumers should depend on the narrowest store they need
+//
+//go:generate go run go.uber.org/mock/mockgen -destination=mocks/storage.go -package=mocks . Storage
+type Storage interface {
+	StoryStore
+	GroupStore
+	UserStore
+	GraphStore
+	ReactionStore
+	ViewStore
+	ShareLinkStore
+	APITokenStore
+	PrivacyStore
+	MediaStore
+	EncryptionStore
+	AuditStore
+	AbuseStore
+	ExperimentStore
+	ArchiveStore
+	NotificationStore
+	EmailStore
+	RecapStore
+	Transactor
 }
@@ -0,0 +1,71 @@
+// Package tenant carries the tenant (community) a request belongs to.
+//
+// Tenants are optional: users and tokens without one belong to Default,
+// whose data keeps the un-prefixed cache keys and the configured bucket.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Default is the tenant of users created without one
+const Default = "default"
+
+// HeaderName is the request header naming the tenant on unauthenticated
+// requests such as signup and login; authenticated requests use the token's claim
+const HeaderName = "X-Tenant-ID"
+
+// validID keeps tenant IDs usable in cache keys and bucket names
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+type contextKey struct{}
+
+// Valid reports whether id is a well-formed tenant ID
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// WithTenant returns a copy of ctx carrying the tenant ID
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant carried by ctx, or Default
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+// FromRequest returns the tenant named by the request's tenant header, or
+// Default when the header is absent
+func FromRequest(r *http.Request) (string, error) {
+	id := r.Header.Get(HeaderName)
+	if id == "" {
+		return Default, nil
+	}
+	if !Valid(id) {
+		return "", fmt.Errorf("invalid tenant ID %q", id)
+	}
+	return id, nil
+}
+
+// KeyPrefix returns the prefix namespacing a tenant's cache keys
+func KeyPrefix(id string) string {
+	if id == "" || id == Default {
+		return ""
+	}
+	return fmt.Sprintf("tenant:%s:", id)
+}
+
+// BucketName returns the object storage bucket holding a tenant's media
+func BucketName(base, id string) string {
+	if id == "" || id == Default {
+		return base
+	}
+	return fmt.Sprintf("%s-%s", base, id)
+}
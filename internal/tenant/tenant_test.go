@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	cases := map[string]bool{
+		"default":     true,
+		"acme":        true,
+		"book-club-2": true,
+		"":            false,
+		"-acme":       false,
+		"Acme":        false,
+		"acme_corp":   false,
+		"acme:corp":   false,
+		"a-very-long-tenant-identifier-that-is-too-long": false,
+	}
+
+	for id, want := range cases {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("Expected %q without a tenant, got %q", Default, got)
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	if got := FromContext(ctx); got != "acme" {
+		t.Errorf("Expected acme, got %q", got)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	req := httptest.NewRequest("POST", "/login", nil)
+	if got, err := FromRequest(req); err != nil || got != Default {
+		t.Errorf("Expected %q without a header, got %q (%v)", Default, got, err)
+	}
+
+	req.Header.Set(HeaderName, "acme")
+	if got, err := FromRequest(req); err != nil || got != "acme" {
+		t.Errorf("Expected acme, got %q (%v)", got, err)
+	}
+
+	req.Header.Set(HeaderName, "ACME:*")
+	if _, err := FromRequest(req); err == nil {
+		t.Error("Expected an error for a malformed tenant ID")
+	}
+}
+
+func TestScoping(t *testing.T) {
+	if got := KeyPrefix(Default); got != "" {
+		t.Errorf("Expected no key prefix for the default tenant, got %q", got)
+	}
+	if got := KeyPrefix("acme"); got != "tenant:acme:" {
+		t.Errorf("Expected tenant:acme: key prefix, got %q", got)
+	}
+	if got := BucketName("stories-media", Default); got != "stories-media" {
+		t.Errorf("Expected the base bucket for the default tenant, got %q", got)
+	}
+	if got := BucketName("stories-media", "acme"); got != "stories-media-acme" {
+		t.Errorf("Expected stories-media-acme, got %q", got)
+	}
+}
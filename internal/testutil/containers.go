@@ -0,0 +1,202 @@
+// Package testutil starts the service's backing stores for integration tests
+// and provides fixtures for seeding them.
+//
+// Postgres and MinIO run in throwaway Docker containers via testcontainers;
+// Redis is served by miniredis through redistest. Tests using these helpers
+// should carry the "integration" build tag and are skipped when Docker is
+// unavailable.
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/testcontainers/testcontainers-go"
+	tcminio "github.com/testcontainers/testcontainers-go/modules/minio"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/princekumarofficial/stories-service/internal/config"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+)
+
+// Container images, kept in step with docker-compose.yaml
+const (
+	PostgresImage = "postgres:15-alpine"
+	MinIOImage    = "minio/minio:RELEASE.2024-09-13T20-26-02Z"
+)
+
+// How long to wait for a container to become ready
+const startupTimeout = 2 * time.Minute
+
+// NewConfig returns a config with test defaults; the Start helpers fill in
+// the connection settings of the stores they start
+func NewConfig() *config.Config {
+	return &config.Config{
+		Env:       "test",
+		JWTSecret: "integration_test_secret",
+		PGSQL: config.PQSQL{
+			User:        "postgres",
+			Password:    "password",
+			DBName:      "stories_test",
+			SSLMode:     "disable",
+			AutoMigrate: true,
+		},
+		MinIO: config.MinIO{
+			AccessKeyID:     "minioadmin",
+			SecretAccessKey: "minioadmin",
+			BucketName:      "stories-media-test",
+		},
+		Media: config.Media{
+			MaxFileSize:      10485760,
+			AllowedMimeTypes: []string{"image/jpeg", "image/png", "image/gif", "video/mp4", "video/mpeg"},
+			PresignedURLTTL:  3600,
+			FeedURLTTL:       900,
+		},
+		WebSocket: config.WebSocket{
+			TicketTTL: 30,
+		},
+		Mail: config.Mail{
+			PublicURL: "http://stories.test",
+		},
+		JWT: config.JWT{
+			Issuer:   "stories-service",
+			Audience: "stories-service",
+			TTL:      86400,
+			Leeway:   30,
+		},
+		Feed: config.Feed{
+			DefaultLimit: 50,
+			MaxLimit:     200,
+		},
+		Jobs: config.Jobs{
+			PollInterval: 1,
+			BatchSize:    10,
+			MaxAttempts:  3,
+			Backoff:      1,
+			Lease:        60,
+			Retention:    3600,
+		},
+		Cache: config.Cache{
+			FolloweesTTL:     300,
+			FeedTTL:          45,
+			StoryTTL:         600,
+			StatsTTL:         120,
+			ProfileTTL:       120,
+			HiddenTTL:        300,
+			AffinityTTL:      600,
+			NotificationsTTL: 300,
+			Jitter:           10,
+		},
+	}
+}
+
+// skipWithoutDocker skips t when no Docker daemon is reachable. testcontainers
+// panics rather than skipping when it cannot find a Docker host at all.
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// StartPostgres starts a Postgres container, points cfg at it and returns a
+// storage with the schema created. Everything is torn down when t finishes.
+func StartPostgres(t *testing.T, cfg *config.Config) *postgres.Postgres {
+	t.Helper()
+	skipWithoutDocker(t)
+	ctx := context.Background()
+
+	container, err := tcpostgres.Run(ctx, PostgresImage,
+		tcpostgres.WithDatabase(cfg.PGSQL.DBName),
+		tcpostgres.WithUsername(cfg.PGSQL.User),
+		tcpostgres.WithPassword(cfg.PGSQL.Password),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(startupTimeout)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start Postgres container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Logf("Failed to terminate Postgres container: %v", err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get Postgres host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		t.Fatalf("Failed to get Postgres port: %v", err)
+	}
+	cfg.PGSQL.Host = host
+	cfg.PGSQL.Port = port.Port()
+
+	storage, err := postgres.NewPostgres(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to Postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		storage.Close()
+	})
+
+	return storage
+}
+
+// StartRedis starts an in-memory Redis server, points cfg at it and returns a
+// connected client. The server is exposed so tests can fast-forward TTLs.
+func StartRedis(t testing.TB, cfg *config.Config) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	redisClient, mr := redistest.New(t)
+	cfg.Redis.Address = mr.Addr()
+	return redisClient, mr
+}
+
+// StartMinIO starts a MinIO container, points cfg at it and returns a media
+// service with its bucket created
+func StartMinIO(t *testing.T, cfg *config.Config) *mediaService.Service {
+	t.Helper()
+	skipWithoutDocker(t)
+	ctx := context.Background()
+
+	container, err := tcminio.Run(ctx, MinIOImage,
+		tcminio.WithUsername(cfg.MinIO.AccessKeyID),
+		tcminio.WithPassword(cfg.MinIO.SecretAccessKey),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start MinIO container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Logf("Failed to terminate MinIO container: %v", err)
+		}
+	})
+
+	endpoint, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get MinIO endpoint: %v", err)
+	}
+	cfg.MinIO.Endpoint = endpoint
+	cfg.MinIO.UseSSL = false
+
+	service, err := mediaService.NewService(cfg)
+	if err != nil {
+		t.Fatalf("Failed to initialize media service: %v", err)
+	}
+
+	return service
+}
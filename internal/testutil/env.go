@@ -0,0 +1,170 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/config"
+	"github.com/princekumarofficial/stories-service/internal/events"
+	"github.com/princekumarofficial/stories-service/internal/exports"
+	"github.com/princekumarofficial/stories-service/internal/http/router"
+	"github.com/princekumarofficial/stories-service/internal/jobs"
+	mediaService "github.com/princekumarofficial/stories-service/internal/services/media"
+	"github.com/princekumarofficial/stories-service/internal/storage/postgres"
+	"github.com/princekumarofficial/stories-service/internal/utils/jwt"
+	"github.com/princekumarofficial/stories-service/internal/websocket"
+	"github.com/princekumarofficial/stories-service/internal/wsticket"
+)
+
+// Env is a fully wired stories service backed by real stores and served by
+// an httptest server
+type Env struct {
+	Config    *config.Config
+	Storage   *postgres.Postgres
+	Redis     *redis.Client
+	Miniredis *miniredis.Miniredis
+	Media     *mediaService.Service
+	Hub       *websocket.Hub
+	Jobs      *jobs.Worker // not started; call RunOnce to run queued jobs
+	Server    *httptest.Server
+}
+
+// NewEnv starts every backing store and serves the full router. Starting
+// containers takes a few seconds, so share one Env across subtests.
+func NewEnv(t *testing.T) *Env {
+	t.Helper()
+
+	cfg := NewConfig()
+	storage := StartPostgres(t, cfg)
+	redisClient, mr := StartRedis(t, cfg)
+	media := StartMinIO(t, cfg)
+	redisKeys := cache.NewKeys(cfg.Redis.KeyPrefix)
+
+	hub := websocket.NewHub()
+	go hub.Run()
+	t.Cleanup(func() {
+		hub.Shutdown(context.Background())
+	})
+
+	warmCtx, stopWarmer := context.WithCancel(context.Background())
+	t.Cleanup(stopWarmer)
+	cacheService := cache.NewCacheService(storage, redisClient, redisKeys, cache.TTLsFromConfig(cfg.Cache))
+	warmer := cache.NewWarmer(cacheService)
+	go warmer.Start(warmCtx)
+
+	opsCtx, stopOps := context.WithCancel(context.Background())
+	t.Cleanup(stopOps)
+	ops := websocket.NewOpsReporter(hub, 100*time.Millisecond).WithAdmins(storage)
+	go ops.Start(opsCtx)
+
+	jobWorker := jobs.NewWorker(storage.GetDB(), cfg.Jobs)
+	jobWorker.Handle(mediaService.JobDelete, media.DeleteJob())
+	jobWorker.Handle(exports.JobStats, exports.StatsJob(storage, media))
+
+	publisher := events.NewEventPublisher(hub).WithQuietHours(cacheService).WithViewReceipts(storage)
+
+	handler := router.New(router.Dependencies{
+		Config:       cfg,
+		Storage:      storage,
+		Redis:        redisClient,
+		Media:        media,
+		Hub:          hub,
+		Publisher:    publisher,
+		TicketIssuer: wsticket.NewIssuer(redisClient, redisKeys, cfg.JWTSecret, time.Duration(cfg.WebSocket.TicketTTL)*time.Second),
+		Warmer:       warmer,
+		Ops:          ops,
+		Digester:     events.NewDigester(cacheService, publisher, hub, time.Minute),
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &Env{
+		Config:    cfg,
+		Storage:   storage,
+		Redis:     redisClient,
+		Miniredis: mr,
+		Media:     media,
+		Hub:       hub,
+		Jobs:      jobWorker,
+		Server:    server,
+	}
+}
+
+// Token returns a bearer token for userID in their tenant, signed with the env's secret
+func (e *Env) Token(t testing.TB, userID string) string {
+	t.Helper()
+
+	user, err := e.Storage.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("Failed to get user %s: %v", userID, err)
+	}
+
+	scopes := jwt.UserScopes
+	if user.IsAdmin {
+		scopes = append(slices.Clip(scopes), jwt.ScopeAdmin)
+	}
+	token, _, err := jwt.CreateToken(userID, user.TenantID, scopes, jwt.OptionsFromConfig(e.Config))
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	return token
+}
+
+// Do sends a request to the env's server. body is encoded as JSON when it is
+// not nil, and token is sent as a bearer token when it is not empty.
+func (e *Env) Do(t testing.TB, method, path, token string, body any) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, e.Server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request %s %s failed: %v", method, path, err)
+	}
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+// DecodeJSON decodes a response body into a T, failing the test on error
+func DecodeJSON[T any](t testing.TB, resp *http.Response) T {
+	t.Helper()
+
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	return v
+}
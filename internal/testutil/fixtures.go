@@ -0,0 +1,78 @@
+package testutil
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/tenant"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/utils/password"
+)
+
+// DefaultPassword is the plain-text password of users created by CreateUser
+const DefaultPassword = "password123"
+
+// userSeq keeps fixture emails unique within a test binary
+var userSeq atomic.Int64
+
+// UniqueEmail returns an email address no other fixture has used
+func UniqueEmail(name string) string {
+	return fmt.Sprintf("%s+%d@example.com", name, userSeq.Add(1))
+}
+
+// CreateUser creates a user in the default tenant with DefaultPassword and returns their ID
+func CreateUser(t testing.TB, store storage.UserStore, email string) string {
+	t.Helper()
+	return CreateTenantUser(t, store, tenant.Default, email)
+}
+
+// CreateTenantUser creates a user in tenantID with DefaultPassword and returns their ID
+func CreateTenantUser(t testing.TB, store storage.UserStore, tenantID, email string) string {
+	t.Helper()
+
+	hashedPassword, err := password.HashPassword(DefaultPassword)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	userID, err := store.CreateUser(tenantID, email, hashedPassword)
+	if err != nil {
+		t.Fatalf("Failed to create user %s: %v", email, err)
+	}
+	return userID
+}
+
+// CreateStory creates a text story with the given visibility and audience and returns its ID
+func CreateStory(t testing.TB, store storage.StoryStore, authorID string, visibility types.Visibility, audience ...string) string {
+	t.Helper()
+
+	storyID, err := store.CreateStory(authorID, types.StoryPostRequest{
+		Text:            fmt.Sprintf("%s story by %s", visibility, authorID),
+		Visibility:      visibility,
+		AudienceUserIDs: audience,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create story: %v", err)
+	}
+	return storyID
+}
+
+// Follow makes followerID follow followedID
+func Follow(t testing.TB, store storage.GraphStore, followerID, followedID string) {
+	t.Helper()
+
+	if _, err := store.FollowUser(followerID, followedID); err != nil {
+		t.Fatalf("Failed to follow %s -> %s: %v", followerID, followedID, err)
+	}
+}
+
+// StoryIDs returns the IDs of stories in order
+func StoryIDs(stories []types.Story) []string {
+	ids := make([]string, len(stories))
+	for i, story := range stories {
+		ids[i] = story.ID
+	}
+	return ids
+}
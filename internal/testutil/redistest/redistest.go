@@ -0,0 +1,30 @@
+// Package redistest serves Redis from miniredis for unit tests. Unlike
+// testutil it starts no containers, so tests need no Docker or build tag.
+package redistest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// New starts a miniredis server and returns a client connected to it; both
+// are closed when the test ends. The server is returned too, so tests can
+// inspect keys and fast-forward TTLs.
+func New(t testing.TB) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		redisClient.Close()
+		mr.Close()
+	})
+
+	return redisClient, mr
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a feed cursor that was not made by String
+var ErrInvalidCursor = errors.New("invalid feed cursor")
+
+// FeedCursor marks a story's place in a feed. Feeds list stories newest
+// first and stories created at the same instant by ID, highest first, so
+// the creation time and ID of the last story served tell where the next page
+// starts even when many stories share a timestamp.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorAt returns the cursor of story, from which a page continues with
+// the stories after it
+func CursorAt(story Story) FeedCursor {
+	return FeedCursor{CreatedAt: story.CreatedAt, ID: story.ID}
+}
+
+// String encodes the cursor for clients, which should treat it as opaque
+func (c FeedCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(FormatTime(c.CreatedAt) + "|" + c.ID))
+}
+
+// ParseFeedCursor decodes a cursor made by String
+func ParseFeedCursor(s string) (FeedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(TimeLayout, at)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n < 1 || strconv.FormatInt(n, 10) != id {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+	return FeedCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// Precedes reports whether story comes after the cursor in feed order
+func (c FeedCursor) Precedes(story Story) bool {
+	if !story.CreatedAt.Equal(c.CreatedAt) {
+		return story.CreatedAt.Before(c.CreatedAt)
+	}
+	// IDs are canonical integers, so the shorter is the lower
+	if len(story.ID) != len(c.ID) {
+		return len(story.ID) < len(c.ID)
+	}
+	return story.ID < c.ID
+}
+
+// RankCursor marks a place in a ranked feed, which has no order to continue
+// from: the time the first page was ranked at, which later pages rank the feed
+// at again so stories keep their places, and how many stories were served.
+type RankCursor struct {
+	RankedAt time.Time
+	Offset   int
+}
+
+// rankCursorPrefix tells rank cursors from feed cursors once decoded
+const rankCursorPrefix = "ranked|"
+
+// String encodes the cursor for clients, which should treat it as opaque
+func (c RankCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(rankCursorPrefix + FormatTime(c.RankedAt) + "|" + strconv.Itoa(c.Offset)))
+}
+
+// ParseRankCursor decodes a cursor made by String
+func ParseRankCursor(s string) (RankCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	rest, ok := strings.CutPrefix(string(data), rankCursorPrefix)
+	if !ok {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	at, offset, ok := strings.Cut(rest, "|")
+	if !ok {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	rankedAt, err := time.Parse(TimeLayout, at)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 1 {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	return RankCursor{RankedAt: rankedAt, Offset: n}, nil
+}
@@ -6,8 +6,24 @@ import "time"
 type EventType string
 
 const (
-	EventStoryViewed  EventType = "story.viewed"
-	EventStoryReacted EventType = "story.reacted"
+	EventStoryViewed      EventType = "story.viewed"
+	EventStoryReacted     EventType = "story.reacted"
+	EventStoryUnreacted   EventType = "story.unreacted"
+	EventStoryReshared    EventType = "story.reshared"
+	EventStoryScreenshot  EventType = "story.screenshotted"
+	EventStoryReplied     EventType = "story.replied"
+	EventReactionBatch    EventType = "story.reactions"
+	EventStoryExpiring    EventType = "story.expiring"
+	EventStoryDeleted     EventType = "story.deleted"
+	EventStoryExpired     EventType = "story.expired"
+	EventStoryRevoked     EventType = "story.revoked"
+	EventUserFollowed     EventType = "user.followed"
+	EventUserUnfollowed   EventType = "user.unfollowed"
+	EventDigest           EventType = "notification.digest"
+	EventAnnouncement     EventType = "system.announcement"
+	EventOpsMetrics       EventType = "ops.metrics"
+	EventRateLimitWarning EventType = "rate_limit.warning"
+	EventWeeklyRecap      EventType = "recap.weekly"
 )
 
 // Event represents a real-time event that can be sent over WebSocket
@@ -32,11 +48,129 @@ type StoryReactedEvent struct {
 	ReactedAt string       `json:"reacted_at"`
 }
 
+// StoryUnreactedEvent represents when a user takes back their reaction to a story
+type StoryUnreactedEvent struct {
+	StoryID     string       `json:"story_id"`
+	UserID      string       `json:"user_id"`
+	Emoji       ReactionType `json:"emoji"` // The reaction removed
+	UnreactedAt string       `json:"unreacted_at"`
+}
+
+// StoryResharedEvent tells an author someone shared their story to their own
+// audience
+type StoryResharedEvent struct {
+	StoryID    string `json:"story_id"`   // The author's story
+	ReshareID  string `json:"reshare_id"` // The new story sharing it
+	UserID     string `json:"user_id"`
+	ResharedAt string `json:"reshared_at"`
+}
+
+// StoryRepliedEvent carries a reply to an author's story
+type StoryRepliedEvent struct {
+	StoryID   string `json:"story_id"`
+	UserID    string `json:"user_id"`
+	Text      string `json:"text"`
+	RepliedAt string `json:"replied_at"`
+}
+
+// StoryScreenshotEvent tells an author someone captured their story on screen
+type StoryScreenshotEvent struct {
+	StoryID    string `json:"story_id"`
+	UserID     string `json:"user_id"`
+	Kind       string `json:"kind"` // screenshot or screen_recording
+	CapturedAt string `json:"captured_at"`
+}
+
+// ReactionBatchEvent summarizes the reactions to a user's stories that arrived
+// within one batching window, sent instead of a story.reacted event each
+type ReactionBatchEvent struct {
+	Total   int                  `json:"total"`
+	Stories []StoryReactionCount `json:"stories"` // In order of first reaction
+	Since   string               `json:"since"`
+}
+
+// StoryReactionCount counts the reactions to one story in a batch, by emoji
+type StoryReactionCount struct {
+	StoryID string               `json:"story_id"`
+	Count   int                  `json:"count"`
+	Emojis  map[ReactionType]int `json:"emojis"`
+}
+
+// StoryExpiringEvent is sent to the author shortly before their story expires
+type StoryExpiringEvent struct {
+	StoryID   string        `json:"story_id"`
+	ExpiresAt string        `json:"expires_at"`
+	Actions   []EventAction `json:"actions"`
+}
+
+// StoryRemovedEvent tells clients that may be showing a story that it was
+// deleted by its author or expired, or that its author shut the user out of
+// it, so they can drop it
+type StoryRemovedEvent struct {
+	StoryID  string `json:"story_id"`
+	AuthorID string `json:"author_id"`
+}
+
+// FollowEvent is sent to a user when someone follows or unfollows them
+type FollowEvent struct {
+	FollowerID string `json:"follower_id"`
+	FollowedID string `json:"followed_id,omitempty"` // left out of events sent to everyone a batch follow reached
+}
+
+// RateLimitWarningEvent warns a user that they have used most of a rate
+// limit, so clients can slow down before requests are turned away
+type RateLimitWarningEvent struct {
+	Action       string `json:"action"`
+	Limit        int64  `json:"limit"`
+	Remaining    int64  `json:"remaining"`
+	ResetAt      string `json:"reset_at"`      // when the full limit is available again
+	ResetSeconds int64  `json:"reset_seconds"` // the same, from now
+}
+
+// DigestEvent summarizes the notifications held back during a user's quiet hours
+type DigestEvent struct {
+	Counts  map[EventType]int `json:"counts"`
+	Summary string            `json:"summary"` // e.g. "12 views, 3 reactions"
+}
+
+// Announcement levels, from least to most urgent
+const (
+	AnnouncementInfo     = "info"
+	AnnouncementWarning  = "warning"
+	AnnouncementCritical = "critical"
+)
+
+// AnnouncementEvent is a message from the operators to connected clients,
+// such as a maintenance notice or feature flags to apply
+type AnnouncementEvent struct {
+	Title   string          `json:"title,omitempty"`
+	Message string          `json:"message"`
+	Level   string          `json:"level"`
+	Flags   map[string]bool `json:"flags,omitempty"`
+}
+
+// AnnouncementRequest is the body of an admin announcement. Without user IDs
+// it goes to every connected client of the admin's tenant.
+type AnnouncementRequest struct {
+	Title   string          `json:"title" validate:"max=100"`
+	Message string          `json:"message" validate:"required,max=1000"`
+	Level   string          `json:"level" validate:"omitempty,oneof=info warning critical"` // defaults to info
+	Flags   map[string]bool `json:"flags"`
+	UserIDs []string        `json:"user_ids" validate:"max=10000"`
+}
+
+// EventAction describes a follow-up request a client can offer from a notification
+type EventAction struct {
+	Type   string `json:"type"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
 // NewEvent creates a new event with the current timestamp
 func NewEvent(eventType EventType, data interface{}) *Event {
 	return &Event{
 		Type:      eventType,
 		Data:      data,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: FormatTime(time.Now()),
 	}
 }
@@ -2,28 +2,63 @@ package media
 
 import "time"
 
-// MediaUpload represents a media file upload record in the database
+// Upload states, in the order an upload normally goes through them
+const (
+	UploadInitiated = "initiated" // upload URL issued, file not seen yet
+	UploadUploaded  = "uploaded"  // file found in the bucket, not confirmed yet
+	UploadConfirmed = "confirmed" // client confirmed the upload
+	UploadFailed    = "failed"    // URL expired unused, or the file was rejected
+)
+
+// MediaUpload represents a media file upload record in the database. A record
+// is created when an upload URL is issued and confirmed once the client has
+// uploaded the file.
 type MediaUpload struct {
-	ID          uint64    `json:"id" db:"id"`
-	UserID      string    `json:"user_id" db:"user_id"`
-	ObjectKey   string    `json:"object_key" db:"object_key"`
-	FileName    string    `json:"file_name" db:"file_name"`
-	ContentType string    `json:"content_type" db:"content_type"`
-	Size        int64     `json:"size" db:"size"`
-	UploadedAt  time.Time `json:"uploaded_at" db:"uploaded_at"`
-	URL         string    `json:"url" db:"url"`
-	IsDeleted   bool      `json:"is_deleted" db:"is_deleted"`
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	TenantID    string     `json:"tenant_id"`
+	ObjectKey   string     `json:"object_key"`
+	ContentType string     `json:"content_type"`
+	Status      string     `json:"status" enums:"initiated,uploaded,confirmed,failed"`
+	Size        int64      `json:"size"` // 0 until the file is found
+	CreatedAt   time.Time  `json:"created_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at"` // nil until confirmed
 }
 
-// MediaUploadRequest represents a request to create a media upload record
-type MediaUploadRequest struct {
-	ObjectKey   string `json:"object_key" validate:"required"`
-	FileName    string `json:"file_name" validate:"required"`
-	ContentType string `json:"content_type" validate:"required"`
-	Size        int64  `json:"size" validate:"required,min=1"`
+// Confirmed reports whether the upload has been confirmed
+func (u MediaUpload) Confirmed() bool {
+	return u.ConfirmedAt != nil
 }
 
 // ConfirmUploadRequest represents a request to confirm a successful upload
 type ConfirmUploadRequest struct {
-	ObjectKey string `json:"object_key" validate:"required"`
+	ObjectKey string `json:"object_key" validate:"required,media_key"`
+}
+
+// Orphan is an object or upload record the reconciliation job found without
+// its counterpart
+type Orphan struct {
+	ObjectKey string    `json:"object_key"`
+	TenantID  string    `json:"tenant_id"`
+	Since     time.Time `json:"since"` // when the object was last modified or the record created
+}
+
+// ReconciliationReport is what one run of the media reconciliation job found.
+// Orphan lists are capped; the counts are not.
+type ReconciliationReport struct {
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	Tenants          int       `json:"tenants"`
+	ObjectsScanned   int       `json:"objects_scanned"`
+	UploadsScanned   int       `json:"uploads_scanned"`
+	UntrackedCount   int       `json:"untracked_count"`   // objects in the bucket without an upload record
+	Untracked        []Orphan  `json:"untracked"`         // a sample of them
+	MissingCount     int       `json:"missing_count"`     // confirmed uploads whose object is gone
+	Missing          []Orphan  `json:"missing"`           // a sample of them
+	ExpiredCount     int       `json:"expired_count"`     // initiated uploads whose URL expired unused, now failed
+	UnconfirmedCount int       `json:"unconfirmed_count"` // uploads left unconfirmed past the TTL
+	DeletedCount     int       `json:"deleted_count"`     // of those, how many were deleted
+	Deleted          []Orphan  `json:"deleted"`           // a sample of them
+	DryRun           bool      `json:"dry_run,omitempty"` // nothing was changed; expired and deleted are what would have been
+	Errors           []string  `json:"errors,omitempty"`  // tenants or objects that could not be checked
 }
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeLayout is how every timestamp is sent: RFC 3339 in UTC, as
+// encoding/json writes the time.Time fields of stories
+const TimeLayout = time.RFC3339Nano
+
+// FormatTime formats t in TimeLayout
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// UTCOffset returns the offset from UTC of the IANA time zone at t, such as
+// +05:30, or "" if zone is empty or unknown
+func UTCOffset(zone string, at time.Time) string {
+	if zone == "" {
+		return ""
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return ""
+	}
+
+	_, offset := at.In(loc).Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
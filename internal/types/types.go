@@ -1,22 +1,137 @@
 package types
 
+import "time"
+
 type Visibility string
 
 const (
-	VisibilityPublic  Visibility = "PUBLIC"
-	VisibilityFriends Visibility = "FRIENDS"
-	VisibilityPrivate Visibility = "PRIVATE"
+	VisibilityPublic    Visibility = "PUBLIC"
+	VisibilityFollowers Visibility = "FOLLOWERS" // Anyone who follows the author
+	VisibilityFriends   Visibility = "FRIENDS"   // Mutual follows only
+	VisibilityPrivate   Visibility = "PRIVATE"
+	VisibilityGroup     Visibility = "GROUP" // Members of the story's group
 )
 
 type Story struct {
-	ID         string     `json:"id"`
-	AuthorID   string     `json:"author_id"`
-	Text       string     `json:"text"`
-	MediaKey   string     `json:"media_key"`
-	Visibility Visibility `json:"visibility"`
-	CreatedAt  string     `json:"created_at"`
-	ExpiresAt  string     `json:"expires_at"`
-	DeletedAt  string     `json:"deleted_at"`
+	ID            string       `json:"id"`
+	AuthorID      string       `json:"author_id"`
+	Text          string       `json:"text"`
+	MediaKey      string       `json:"media_key"`
+	Visibility    Visibility   `json:"visibility"`
+	CreatedAt     time.Time    `json:"created_at"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	DeletedAt     *time.Time   `json:"deleted_at"` // nil until deleted
+	LinkURL       string       `json:"link_url"`
+	Latitude      *float64     `json:"latitude"`
+	Longitude     *float64     `json:"longitude"`
+	PlaceName     string       `json:"place_name"`
+	Encrypted     bool         `json:"encrypted"`                 // text is empty; recipients fetch the envelope instead
+	ParentStoryID string       `json:"parent_story_id,omitempty"` // story this one reshares, with its media
+	GroupID       string       `json:"group_id,omitempty"`        // group a GROUP story was posted into
+	Version       int          `json:"version"`                   // bumped by every edit; send it back in If-Match to edit the story
+	Author        *StoryAuthor `json:"author,omitempty"`          // only in feeds
+
+	// How many times each viewer may open the story, 1 for view-once; 0 for no limit
+	MaxViewsPerUser int `json:"max_views_per_user,omitempty"`
+
+	// What the author lets viewers do with the story, chosen when posting it
+	AllowReshare                bool `json:"allow_reshare"`
+	AllowReply                  bool `json:"allow_reply"`                   // false makes POST /stories/{id}/reply return 403
+	AllowScreenshotNotification bool `json:"allow_screenshot_notification"` // tell the author when a viewer reports a screenshot
+	// Set wherever the viewer is sent the story once they used up their views; text, media_key and link_url are then empty
+	Consumed bool `json:"consumed,omitempty"`
+
+	// Presigned download link for media_key, only in feeds asked for with media_urls=true
+	MediaURL          string `json:"media_url,omitempty"`
+	MediaURLExpiresAt int64  `json:"media_url_expires_at,omitempty"` // unix seconds
+}
+
+// StoryAuthor is the profile of a story's author sent along with the story in
+// feeds, so clients need no request per author to show who posted it
+type StoryAuthor struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`           // empty when the author has no avatar
+	UTCOffset string `json:"utc_offset,omitempty"` // the author's offset from UTC when they posted, such as +05:30; omitted without a time zone in their notification settings
+}
+
+// ArchivedStory is a story as exported to the cold archive, with its audience,
+// interaction counts and where its media lives. The media itself stays put.
+type ArchivedStory struct {
+	Story
+	TenantID        string   `json:"tenant_id"`
+	AudienceUserIDs []string `json:"audience_user_ids,omitempty"`
+	ViewCount       int      `json:"view_count"`
+	ReactionCount   int      `json:"reaction_count"`
+	MediaBucket     string   `json:"media_bucket,omitempty"` // bucket holding media_key
+}
+
+// ArchiveEntry records which archive object holds an archived story
+type ArchiveEntry struct {
+	StoryID    string `json:"story_id"`
+	TenantID   string `json:"tenant_id"`
+	ObjectKey  string `json:"object_key"`
+	ArchivedAt string `json:"archived_at"`
+}
+
+// FeedTray summarizes a followed author's active stories the way a story
+// tray shows them: one bubble per author, ringed while any story is unseen
+type FeedTray struct {
+	AuthorID      string `json:"author_id"`
+	AuthorEmail   string `json:"author_email"`
+	AvatarURL     string `json:"avatar_url"` // empty when the author has no avatar
+	StoryCount    int    `json:"story_count"`
+	UnseenCount   int    `json:"unseen_count"`
+	Seen          bool   `json:"seen"` // whether the user has viewed every story
+	LatestStoryAt string `json:"latest_story_at"`
+}
+
+// StoryViewer is a user who viewed a story, as listed to its author
+type StoryViewer struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"` // empty when the viewer has no avatar
+	ViewedAt  string `json:"viewed_at"`
+}
+
+// Impression records that a story appeared in a user's tray, whether or not
+// they opened it
+type Impression struct {
+	StoryID string
+	UserID  string
+	SeenAt  time.Time
+}
+
+// ImpressionBatchRequest lists the stories a client showed in the user's tray
+type ImpressionBatchRequest struct {
+	StoryIDs []string `json:"story_ids" validate:"required,min=1,max=100,dive,numeric"`
+}
+
+// ImpressionBatchResponse is returned when impressions are buffered
+type ImpressionBatchResponse struct {
+	Accepted int `json:"accepted"` // distinct stories buffered; ones the user cannot see are dropped when they are written
+}
+
+// Reasons a story left a feed, as reported by RemovedStory
+const (
+	RemovalDeleted = "deleted" // removed by its author
+	RemovalExpired = "expired" // reached its expiry
+)
+
+// FeedChanges lists how a user's feed changed since a point in time, for
+// clients that poll instead of refetching the whole feed
+type FeedChanges struct {
+	Created []Story        `json:"created"` // newest first
+	Removed []RemovedStory `json:"removed"` // stories the feed held at that point that are gone now
+	Cursor  string         `json:"cursor"`  // pass as since on the next poll
+}
+
+// RemovedStory identifies a story that left a feed and why
+type RemovedStory struct {
+	StoryID   string `json:"story_id"`
+	AuthorID  string `json:"author_id"`
+	Reason    string `json:"reason" enums:"deleted,expired"`
+	RemovedAt string `json:"removed_at"`
 }
 
 // StoryWithMeta extends Story with preloaded metadata to avoid N+1 queries
@@ -26,8 +141,9 @@ type StoryWithMeta struct {
 	AuthorEmail string `json:"author_email"`
 
 	// Story statistics
-	ViewCount     int `json:"view_count"`
-	ReactionCount int `json:"reaction_count"`
+	ViewCount         int            `json:"view_count"`
+	ReactionCount     int            `json:"reaction_count"`
+	ReactionBreakdown map[string]int `json:"reaction_breakdown"` // Reaction count per emoji
 
 	// User-specific flags
 	UserHasViewed bool   `json:"user_has_viewed"`
@@ -35,10 +151,177 @@ type StoryWithMeta struct {
 }
 
 type StoryPostRequest struct {
+	Text            string            `json:"text"`
+	MediaKey        string            `validate:"omitempty,media_key" json:"media_key"`
+	Visibility      Visibility        `validate:"omitempty,visibility" json:"visibility"`                   // your default visibility when left out
+	AudienceUserIDs []string          `validate:"omitempty,max=100,dive,numeric" json:"audience_user_ids"`  // PRIVATE stories only, and required for them; your default audience when left out
+	ExpiresInHours  int               `validate:"omitempty,min=1,max=48" json:"expires_in_hours,omitempty"` // your default lifetime when left out, else 24 hours
+	LinkURL         string            `json:"link_url"`
+	Latitude        *float64          `validate:"required_with=Longitude,omitempty,latitude" json:"latitude"`
+	Longitude       *float64          `validate:"required_with=Latitude,omitempty,longitude" json:"longitude"`
+	PlaceName       string            `validate:"max=255" json:"place_name"`
+	Encrypted       *EncryptedContent `json:"encrypted,omitempty"`                                            // PRIVATE stories only; text, link_url and location must then be empty
+	GroupID         string            `validate:"omitempty,numeric" json:"group_id,omitempty"`                // GROUP stories only, one of the author's groups
+	MaxViewsPerUser int               `validate:"omitempty,min=1,max=10" json:"max_views_per_user,omitempty"` // 1 for view-once; no limit when left out
+
+	// Story permissions, each allowed when left out
+	AllowReshare                *bool `json:"allow_reshare,omitempty"`
+	AllowReply                  *bool `json:"allow_reply,omitempty"`
+	AllowScreenshotNotification *bool `json:"allow_screenshot_notification,omitempty"`
+}
+
+// DefaultStoryLifetimeHours is how long stories stay active unless their
+// author chooses otherwise
+const DefaultStoryLifetimeHours = 24
+
+// StorySettings are what a user's stories get when posted without a
+// visibility, an audience or a lifetime
+type StorySettings struct {
+	DefaultVisibility      Visibility `json:"default_visibility,omitempty"`       // empty when every story must name one
+	DefaultAudienceUserIDs []string   `json:"default_audience_user_ids"`          // for PRIVATE stories, such as close friends
+	DefaultExpiresInHours  int        `json:"default_expires_in_hours,omitempty"` // 0 for 24 hours
+}
+
+// Apply fills in what story leaves out from the settings. An audience is
+// only filled in for PRIVATE stories that send none at all, and is then
+// never nil, so validating the story again tells an empty default apart.
+func (s StorySettings) Apply(story *StoryPostRequest) {
+	if story.Visibility == "" {
+		story.Visibility = s.DefaultVisibility
+	}
+	if story.Visibility == VisibilityPrivate && story.AudienceUserIDs == nil {
+		story.AudienceUserIDs = append([]string{}, s.DefaultAudienceUserIDs...)
+	}
+	if story.ExpiresInHours == 0 {
+		story.ExpiresInHours = s.DefaultExpiresInHours
+	}
+}
+
+// StorySettingsUpdate changes some of a user's story settings. Fields left
+// out keep their value; an empty value clears the default.
+type StorySettingsUpdate struct {
+	DefaultVisibility      *Visibility `validate:"omitempty,default_visibility" json:"default_visibility,omitempty"`
+	DefaultAudienceUserIDs *[]string   `validate:"omitempty,max=100,dive,numeric" json:"default_audience_user_ids,omitempty"`
+	DefaultExpiresInHours  *int        `validate:"omitempty,min=0,max=48" json:"default_expires_in_hours,omitempty"`
+}
+
+// StoryUpdateRequest edits one of your stories. Fields left out keep their
+// value. A new visibility replaces the story's audience and group, so send
+// audience_user_ids or group_id along with it as when posting.
+type StoryUpdateRequest struct {
+	Text            *string    `json:"text,omitempty"`
+	LinkURL         *string    `json:"link_url,omitempty"` // empty removes the link
+	Visibility      Visibility `validate:"omitempty,visibility" json:"visibility,omitempty"`
+	AudienceUserIDs []string   `validate:"max=100,dive,numeric" json:"audience_user_ids,omitempty"` // PRIVATE only, and required for it
+	GroupID         string     `validate:"omitempty,numeric" json:"group_id,omitempty"`             // GROUP only
+	Version         int        `validate:"omitempty,min=1" json:"version,omitempty"`                // the version being edited, for clients that cannot send If-Match
+}
+
+// ReshareRequest shares another user's public story to your own audience,
+// with an optional caption
+type ReshareRequest struct {
 	Text            string     `json:"text"`
-	MediaKey        string     `json:"media_key"`
-	Visibility      Visibility `validate:"required" json:"visibility"`
-	AudienceUserIDs []string   `validate:"required" json:"audience_user_ids"`
+	Visibility      Visibility `validate:"required,visibility" json:"visibility"`
+	AudienceUserIDs []string   `validate:"max=100,dive,numeric" json:"audience_user_ids"` // PRIVATE reshares only, and required for them
+	GroupID         string     `validate:"omitempty,numeric" json:"group_id,omitempty"`   // GROUP reshares only
+}
+
+// ReshareResponse identifies a new reshare and the story it reshares
+type ReshareResponse struct {
+	ID            string `json:"id"`
+	ParentStoryID string `json:"parent_story_id"`
+}
+
+// StoryGroup is a shared collection its members post stories into, such as
+// an event or a circle of close friends. Stories posted into a group with
+// GROUP visibility are seen by its members only.
+type StoryGroup struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	OwnerID     string `json:"owner_id"`
+	MemberCount int    `json:"member_count"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// GroupMember is a member of a story group
+type GroupMember struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	JoinedAt string `json:"joined_at"`
+}
+
+// CreateGroupRequest names a new group and who joins its owner in it
+type CreateGroupRequest struct {
+	Name      string   `validate:"required,max=100" json:"name"`
+	MemberIDs []string `validate:"max=100,dive,numeric" json:"member_ids"`
+}
+
+// GroupMembersRequest lists users to add to a group
+type GroupMembersRequest struct {
+	UserIDs []string `validate:"required,min=1,max=100,dive,numeric" json:"user_ids"`
+}
+
+// EncryptedContent is the envelope of an end-to-end encrypted story: its text
+// sealed with a content key, and that key wrapped with the public key of each
+// recipient. Media is encrypted with the same key before it is uploaded. The
+// service stores all of it as sent and never sees the content key.
+type EncryptedContent struct {
+	Algorithm     string         `json:"algorithm" validate:"required,max=64"` // chosen by the clients, such as xchacha20poly1305
+	Ciphertext    string         `json:"ciphertext" validate:"required,base64,max=65536"`
+	Nonce         string         `json:"nonce" validate:"required,base64,max=256"`
+	RecipientKeys []RecipientKey `json:"recipient_keys" validate:"required,min=1,dive"` // one per audience member and one for the author
+}
+
+// CoversExactly reports whether the content key is wrapped once for the author
+// and each member of the audience, and for nobody else
+func (c *EncryptedContent) CoversExactly(authorID string, audienceUserIDs []string) bool {
+	want := map[string]bool{authorID: true}
+	for _, userID := range audienceUserIDs {
+		want[userID] = true
+	}
+
+	seen := make(map[string]bool, len(c.RecipientKeys))
+	for _, key := range c.RecipientKeys {
+		if !want[key.UserID] || seen[key.UserID] {
+			return false
+		}
+		seen[key.UserID] = true
+	}
+	return len(seen) == len(want)
+}
+
+// RecipientKey is a story's content key wrapped for one recipient
+type RecipientKey struct {
+	UserID     string `json:"user_id" validate:"required,numeric"`
+	WrappedKey string `json:"wrapped_key" validate:"required,base64,max=1024"`
+}
+
+// StoryEnvelope is an encrypted story's content as one recipient fetches it
+type StoryEnvelope struct {
+	StoryID    string `json:"story_id"`
+	AuthorID   string `json:"author_id"`
+	Algorithm  string `json:"algorithm"`
+	Ciphertext string `json:"ciphertext"`
+	Nonce      string `json:"nonce"`
+	WrappedKey string `json:"wrapped_key"` // the content key wrapped for the caller
+}
+
+// ShareLink lets whoever holds its token view a story regardless of the
+// story's visibility, until it expires or the author revokes it
+type ShareLink struct {
+	ID           string `json:"id"`
+	StoryID      string `json:"story_id"`
+	URL          string `json:"url"`
+	RequireLogin bool   `json:"require_login"` // Only signed-in users may open it
+	ViewCount    int    `json:"view_count"`
+	CreatedAt    string `json:"created_at"`
+	ExpiresAt    string `json:"expires_at"`
+	RevokedAt    string `json:"revoked_at"`
+}
+
+type ShareLinkRequest struct {
+	ExpiresInHours int  `validate:"omitempty,min=1,max=168" json:"expires_in_hours"` // Defaults to 24
+	RequireLogin   bool `json:"require_login"`
 }
 
 type ReactionType string
@@ -61,7 +344,30 @@ type Reaction struct {
 }
 
 type ReactionRequest struct {
-	Emoji ReactionType `json:"emoji" validate:"required"`
+	Emoji ReactionType `json:"emoji" validate:"required,reaction_emoji"`
+}
+
+// ReactionResponse is returned when a reaction is added or replaced
+type ReactionResponse struct {
+	StoryID       string       `json:"story_id"`
+	Emoji         ReactionType `json:"emoji"`
+	PreviousEmoji ReactionType `json:"previous_emoji,omitempty"` // The reaction it replaced, if any
+}
+
+// Ways a client can report a story was captured on screen
+const (
+	CaptureScreenshot = "screenshot"
+	CaptureRecording  = "screen_recording"
+)
+
+// ReplyRequest is a private reply to a story, sent to its author
+type ReplyRequest struct {
+	Text string `json:"text" validate:"required,max=1000"`
+}
+
+// ScreenshotRequest reports that the user captured a story on screen
+type ScreenshotRequest struct {
+	Kind string `json:"kind" validate:"required,oneof=screenshot screen_recording"`
 }
 
 type Follow struct {
@@ -69,3 +375,25 @@ type Follow struct {
 	FollowedID string `json:"followed_id"`
 	CreatedAt  string `json:"created_at"`
 }
+
+// FollowBatchRequest lists users to follow or unfollow at once, such as the
+// matches of a contact import
+type FollowBatchRequest struct {
+	UserIDs []string `validate:"required,min=1,max=100,dive,numeric" json:"user_ids"`
+}
+
+// Outcomes for one user of a batch follow or unfollow
+const (
+	FollowFollowed         = "followed"
+	FollowAlreadyFollowing = "already_following"
+	FollowUnfollowed       = "unfollowed"
+	FollowNotFollowing     = "not_following"
+	FollowSelf             = "self"
+	FollowNotFound         = "not_found" // no such user in the follower's tenant
+)
+
+// FollowResult is the outcome for one user of a batch follow or unfollow
+type FollowResult struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status" enums:"followed,already_following,unfollowed,not_following,self,not_found"`
+}
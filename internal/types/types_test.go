@@ -0,0 +1,180 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncryptedContent_CoversExactly(t *testing.T) {
+	keys := func(userIDs ...string) *EncryptedContent {
+		content := &EncryptedContent{}
+		for _, userID := range userIDs {
+			content.RecipientKeys = append(content.RecipientKeys, RecipientKey{UserID: userID, WrappedKey: "a2V5"})
+		}
+		return content
+	}
+
+	cases := []struct {
+		name     string
+		content  *EncryptedContent
+		audience []string
+		want     bool
+	}{
+		{"author and audience", keys("1", "2", "3"), []string{"2", "3"}, true},
+		{"author listed in audience", keys("1", "2"), []string{"1", "2"}, true},
+		{"missing author", keys("2", "3"), []string{"2", "3"}, false},
+		{"missing audience member", keys("1", "2"), []string{"2", "3"}, false},
+		{"outsider", keys("1", "2", "9"), []string{"2"}, false},
+		{"duplicate", keys("1", "2", "2"), []string{"2"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.content.CoversExactly("1", tc.audience); got != tc.want {
+				t.Errorf("Expected CoversExactly = %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStorySettings_Apply(t *testing.T) {
+	settings := StorySettings{
+		DefaultVisibility:      VisibilityPrivate,
+		DefaultAudienceUserIDs: []string{"7", "12"},
+		DefaultExpiresInHours:  6,
+	}
+
+	cases := []struct {
+		name  string
+		story StoryPostRequest
+		want  StoryPostRequest
+	}{
+		{"everything left out", StoryPostRequest{},
+			StoryPostRequest{Visibility: VisibilityPrivate, AudienceUserIDs: []string{"7", "12"}, ExpiresInHours: 6}},
+		{"chosen values kept", StoryPostRequest{Visibility: VisibilityPrivate, AudienceUserIDs: []string{"3"}, ExpiresInHours: 48},
+			StoryPostRequest{Visibility: VisibilityPrivate, AudienceUserIDs: []string{"3"}, ExpiresInHours: 48}},
+		{"empty audience kept", StoryPostRequest{Visibility: VisibilityPrivate, AudienceUserIDs: []string{}},
+			StoryPostRequest{Visibility: VisibilityPrivate, AudienceUserIDs: []string{}, ExpiresInHours: 6}},
+		{"no audience for other visibilities", StoryPostRequest{Visibility: VisibilityFollowers},
+			StoryPostRequest{Visibility: VisibilityFollowers, ExpiresInHours: 6}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings.Apply(&tc.story)
+			if !reflect.DeepEqual(tc.story, tc.want) {
+				t.Errorf("Expected %+v, got %+v", tc.want, tc.story)
+			}
+		})
+	}
+
+	// Without defaults nothing is filled in, but a PRIVATE story's audience
+	// is no longer left out
+	story := StoryPostRequest{}
+	StorySettings{}.Apply(&story)
+	if story.Visibility != "" || story.AudienceUserIDs != nil || story.ExpiresInHours != 0 {
+		t.Errorf("Expected empty settings to leave the story alone, got %+v", story)
+	}
+	story = StoryPostRequest{Visibility: VisibilityPrivate}
+	StorySettings{}.Apply(&story)
+	if story.AudienceUserIDs == nil || len(story.AudienceUserIDs) != 0 {
+		t.Errorf("Expected an empty audience, got %#v", story.AudienceUserIDs)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2024, 3, 9, 22, 5, 1, 120000000, time.FixedZone("IST", 5*3600+1800))
+
+	if got := FormatTime(at); got != "2024-03-09T16:35:01.12Z" {
+		t.Errorf("Expected RFC 3339 in UTC, got %q", got)
+	}
+}
+
+func TestUTCOffset(t *testing.T) {
+	winter := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	summer := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		zone string
+		at   time.Time
+		want string
+	}{
+		{"half hour", "Asia/Kolkata", winter, "+05:30"},
+		{"negative", "America/New_York", winter, "-05:00"},
+		{"daylight saving", "America/New_York", summer, "-04:00"},
+		{"utc", "UTC", winter, "+00:00"},
+		{"unset", "", winter, ""},
+		{"unknown", "Mars/Olympus", winter, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := UTCOffset(tc.zone, tc.at); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFeedCursor(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	cursor := FeedCursor{CreatedAt: at, ID: "42"}
+
+	parsed, err := ParseFeedCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseFeedCursor failed: %v", err)
+	}
+	if !parsed.CreatedAt.Equal(at) || parsed.ID != "42" {
+		t.Errorf("Expected the cursor back, got %+v", parsed)
+	}
+
+	for _, invalid := range []string{"", "!!", "bm90IGEgY3Vyc29y", FeedCursor{CreatedAt: at, ID: "0"}.String(), FeedCursor{CreatedAt: at, ID: "042"}.String(), FeedCursor{CreatedAt: at, ID: "x"}.String()} {
+		if _, err := ParseFeedCursor(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		story Story
+		want  bool
+	}{
+		{"older", Story{ID: "99", CreatedAt: at.Add(-time.Microsecond)}, true},
+		{"newer", Story{ID: "1", CreatedAt: at.Add(time.Microsecond)}, false},
+		{"same time, lower ID", Story{ID: "41", CreatedAt: at}, true},
+		{"same time, shorter ID", Story{ID: "9", CreatedAt: at}, true},
+		{"same time, higher ID", Story{ID: "100", CreatedAt: at}, false},
+		{"the cursor's story", Story{ID: "42", CreatedAt: at}, false},
+	}
+	for _, tc := range cases {
+		if got := cursor.Precedes(tc.story); got != tc.want {
+			t.Errorf("%s: expected Precedes = %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRankCursor(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	cursor := RankCursor{RankedAt: at, Offset: 50}
+
+	parsed, err := ParseRankCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseRankCursor failed: %v", err)
+	}
+	if !parsed.RankedAt.Equal(at) || parsed.Offset != 50 {
+		t.Errorf("Expected the cursor back, got %+v", parsed)
+	}
+
+	// Neither kind of cursor passes for the other
+	feedCursor := FeedCursor{CreatedAt: at, ID: "42"}.String()
+	if _, err := ParseFeedCursor(cursor.String()); err == nil {
+		t.Error("Expected a rank cursor to be rejected as a feed cursor")
+	}
+	for _, invalid := range []string{"", "!!", feedCursor, RankCursor{RankedAt: at}.String(), RankCursor{RankedAt: at, Offset: -1}.String()} {
+		if _, err := ParseRankCursor(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package users
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ContactHash is the identifier clients send for a contact's email address
+// during discovery: the hex SHA-256 of the address trimmed and lowercased
+func ContactHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// DiscoverRequest carries the hashed contacts of the caller's address book,
+// each the ContactHash of an email address
+type DiscoverRequest struct {
+	Hashes []string `json:"hashes" validate:"required,min=1,max=500,dive,len=64,hexadecimal"`
+}
+
+// DiscoveredUser is a registered user matching one of the caller's contacts
+type DiscoveredUser struct {
+	Hash        string `json:"hash"` // the contact hash that matched
+	UserID      string `json:"user_id"`
+	AvatarURL   string `json:"avatar_url"` // empty when the user has no avatar
+	IsFollowing bool   `json:"is_following"`
+}
@@ -1,25 +1,308 @@
 package users
 
+import (
+	"fmt"
+	"time"
+)
+
 type SignUpRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
 }
 
 type SignInRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Email         string `json:"email" validate:"required,email"`
+	Password      string `json:"password" validate:"required,min=6"`
+	DeviceName    string `json:"device_name,omitempty" validate:"omitempty,max=100"`                          // shown in the session list; defaults to the User-Agent
+	Platform      string `json:"platform,omitempty" validate:"omitempty,oneof=ios android web desktop other"` // defaults to the X-Client-Platform header
+	ClientVersion string `json:"client_version,omitempty" validate:"omitempty,max=32"`                        // defaults to the X-Client-Version header
+	DeviceID      string `json:"device_id,omitempty" validate:"omitempty,max=128"`                            // a stable identifier of the device; defaults to the X-Device-ID header
 }
 
 type User struct {
 	ID        string `json:"id"`
+	TenantID  string `json:"tenant_id"`
 	Email     string `json:"email"`
-	Password  string `json:"password"`
+	Password  string `json:"-"`
 	CreatedAt string `json:"created_at"`
+	IsAdmin   bool   `json:"is_admin"`
+}
+
+// Profile is a user's account together with their follow and story counts
+type Profile struct {
+	User
+	AvatarURL string `json:"avatar_url"` // empty when the user has no avatar
+	Followers int    `json:"followers"`
+	Following int    `json:"following"`
+	Stories   int    `json:"stories"` // Active stories
+}
+
+// PublicProfile is what other users in the tenant see of an account, with how
+// the viewer and the user follow each other
+type PublicProfile struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	AvatarURL     string `json:"avatar_url"` // empty when the user has no avatar
+	CreatedAt     string `json:"created_at"`
+	Followers     int    `json:"followers"`
+	Following     int    `json:"following"`
+	PublicStories int    `json:"public_stories"` // Active public stories
+	IsFollowing   bool   `json:"is_following"`   // The viewer follows them
+	FollowsYou    bool   `json:"follows_you"`    // They follow the viewer
+}
+
+// LoginResponse is returned by a successful login
+type LoginResponse struct {
+	UserID    string `json:"user_id"`
+	Token     string `json:"token"`
+	TokenType string `json:"token_type"`
+	ExpiresAt string `json:"expires_at"` // RFC 3339
+	User      User   `json:"user"`
+}
+
+// APIToken is a long-lived token a user created for programmatic access. The
+// token itself is only returned when it is created.
+type APIToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Token      string   `json:"token,omitempty"` // Only set in the response that creates it
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at"` // empty when never used
+	ExpiresAt  string   `json:"expires_at"`   // empty when it does not expire
+}
+
+// APITokenRequest creates an API token. Tokens with the read scope may make
+// GET requests, and those with the post scope may create stories and upload
+// their media.
+type APITokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read post"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"` // Never expires when empty
+}
+
+// APITokenOwner is who an API token authenticates as
+type APITokenOwner struct {
+	TokenID  string
+	UserID   string
+	TenantID string
+	Scopes   []string
+}
+
+// ImpersonationRequest asks for a token to act as a user while reproducing a
+// problem they reported
+type ImpersonationRequest struct {
+	Reason     string `json:"reason" validate:"required,max=500"`            // Recorded in the audit trail, such as a ticket number
+	TTLMinutes int    `json:"ttl_minutes" validate:"omitempty,min=1,max=60"` // Defaults to 15
+}
+
+// ImpersonationToken lets an admin make read-only requests as a user
+type ImpersonationToken struct {
+	Token          string `json:"token"`
+	TokenType      string `json:"token_type"`
+	ExpiresAt      string `json:"expires_at"` // RFC 3339
+	UserID         string `json:"user_id"`
+	ImpersonatorID string `json:"impersonator_id"`
+}
+
+// Impersonation audit actions
+const (
+	ImpersonationIssued = "issued" // An admin was issued a token
+	ImpersonationUsed   = "used"   // A request was made with the token
+)
+
+// ImpersonationEvent is an entry in the audit trail of admins acting as users
+type ImpersonationEvent struct {
+	ID        string `json:"id"`
+	TokenID   string `json:"token_id"`
+	AdminID   string `json:"admin_id"`
+	UserID    string `json:"user_id"`
+	Action    string `json:"action"`
+	Reason    string `json:"reason,omitempty"` // Set when the token is issued
+	Method    string `json:"method,omitempty"` // Set when the token is used
+	Path      string `json:"path,omitempty"`   // Set when the token is used
+	CreatedAt string `json:"created_at"`
+}
+
+// AbuseFlag records an account the abuse detector throttled, for an admin to
+// review
+type AbuseFlag struct {
+	ID             string `json:"id"`
+	UserID         string `json:"user_id"`
+	Action         string `json:"action" enums:"follow,unfollow,view"`
+	Count          int    `json:"count"` // actions in the hour before the account was throttled
+	ThrottledUntil string `json:"throttled_until"`
+	CreatedAt      string `json:"created_at"`
+	ReviewedAt     string `json:"reviewed_at,omitempty"`
+	ReviewedBy     string `json:"reviewed_by,omitempty"`
+}
+
+
+// What a rate limit counts actions by
+const (
+	RateLimitPerUser = "user"
+	RateLimitPerIP   = "ip"
+)
+
+// RateLimit is how much of an action's rate limit the caller has left
+type RateLimit struct {
+	Action        string `json:"action"`
+	Per           string `json:"per"`            // user or ip
+	Limit         int64  `json:"limit"`          // Actions allowed in a burst
+	Remaining     int64  `json:"remaining"`      // Actions allowed right now
+	WindowSeconds int64  `json:"window_seconds"` // How long the full limit takes to refill
+	ResetSeconds  int64  `json:"reset_seconds"`  // Until the full limit is available again
 }
 
 type UserStats struct {
-	Posted         int                    `json:"posted"`
-	Views          int                    `json:"views"`
-	UniqueViewers  int                    `json:"unique_viewers"`
-	ReactionCounts map[string]int         `json:"reaction_counts"`
+	Posted         int            `json:"posted"`
+	Views          int            `json:"views"`
+	UniqueViewers  int            `json:"unique_viewers"`
+	LinkClicks     int            `json:"link_clicks"`
+	Impressions    int            `json:"impressions"` // times a story appeared in a tray, once per user and story
+	Reach          int            `json:"reach"`       // users whose tray showed any of the stories; compare with unique_viewers, who opened one
+	Reshares       int            `json:"reshares"`    // stories sharing one of the user's, not counting deleted ones
+	Screenshots    int            `json:"screenshots"` // screenshots and screen recordings reported, once per user, story and kind
+	ReactionCounts map[string]int `json:"reaction_counts"`
+}
+
+// DailyStoryMetrics is one row of a creator insights export: the activity one
+// story received on one day (UTC)
+type DailyStoryMetrics struct {
+	Day           string // YYYY-MM-DD
+	StoryID       string
+	Views         int
+	UniqueViewers int
+	Reactions     int
+	LinkClicks    int
+	Impressions   int // users the story appeared to in their tray
+	Reshares      int
+	Screenshots   int // screenshots and screen recordings reported
+}
+
+// StatsExportRequest starts building an insights export in the background
+type StatsExportRequest struct {
+	From string `json:"from,omitempty"` // first day, YYYY-MM-DD; defaults to 29 days before to
+	To   string `json:"to,omitempty"`   // last day, YYYY-MM-DD; defaults to today
+}
+
+// StatsExport is an insights export being built in the background
+type StatsExport struct {
+	ID          string `json:"id"`
+	Status      string `json:"status" enums:"queued,running,done,failed"` // queued again while waiting to retry a failed attempt
+	From        string `json:"from"`
+	To          string `json:"to"`
+	CreatedAt   string `json:"created_at"`
+	DownloadURL string `json:"download_url,omitempty"` // the CSV file, once done
+	ExpiresAt   int64  `json:"expires_at,omitempty"`   // when download_url stops working, Unix seconds
+}
+
+// WeeklyRecap sums up a creator's week, Monday to Sunday in UTC
+type WeeklyRecap struct {
+	WeekStart     string      `json:"week_start"`          // Monday, YYYY-MM-DD
+	WeekEnd       string      `json:"week_end"`            // Sunday, YYYY-MM-DD
+	Posted        int         `json:"posted"`              // stories posted during the week, including since expired ones
+	Views         int         `json:"views"`               // views during the week of any of the creator's stories
+	Reach         int         `json:"reach"`               // users whose tray showed any of the stories during the week
+	Followers     int         `json:"followers"`           // when the recap was made
+	FollowerDelta int         `json:"follower_delta"`      // since the previous recap; for the first, followers gained during the week
+	TopStory      *RecapStory `json:"top_story,omitempty"` // the most viewed story; absent when no story was viewed
+	CreatedAt     string      `json:"created_at"`
+	Delivered     bool        `json:"-"` // the creator was notified of it
+}
+
+// RecapStory is the top story of a weekly recap, with its activity that week
+type RecapStory struct {
+	ID        string `json:"id"`
+	Views     int    `json:"views"`
+	Reactions int    `json:"reactions"`
+}
+
+// AvatarRequest sets the picture shown next to a user's stories and profile.
+// The service stores the URL as sent; an empty one removes the avatar.
+type AvatarRequest struct {
+	AvatarURL string `json:"avatar_url" validate:"omitempty,http_url,max=2048"`
+}
+
+// PrivacySettings controls what other users learn about a user's activity.
+// Views with hidden receipts still count in the author's stats, but the author
+// is not told who viewed.
+type PrivacySettings struct {
+	HideViewReceipts  bool `json:"hide_view_receipts"`
+	HideFromDiscovery bool `json:"hide_from_discovery"` // never matched by contact discovery
+}
+
+// PublicKey is the key a user publishes so authors of end-to-end encrypted
+// stories can wrap story keys for them. The service stores it as sent.
+type PublicKey struct {
+	UserID    string `json:"user_id"`
+	Algorithm string `json:"algorithm"` // chosen by the clients, such as x25519
+	Key       string `json:"key"`       // base64
+	UpdatedAt string `json:"updated_at"`
+}
+
+// PublicKeyRequest publishes or replaces the caller's public key
+type PublicKeyRequest struct {
+	Algorithm string `json:"algorithm" validate:"required,max=64"`
+	Key       string `json:"key" validate:"required,base64,max=1024"`
+}
+
+// NotificationSettings controls when a user's notifications are delivered.
+// Quiet hours are HH:MM local times in Timezone (UTC when empty); a start
+// after the end spans midnight, and leaving both empty disables them.
+// Emails are opt-in.
+type NotificationSettings struct {
+	QuietHoursStart   string `json:"quiet_hours_start" validate:"required_with=QuietHoursEnd,omitempty,clock"`
+	QuietHoursEnd     string `json:"quiet_hours_end" validate:"required_with=QuietHoursStart,omitempty,clock"`
+	Timezone          string `json:"timezone" validate:"omitempty,timezone"`
+	EmailNewFollowers bool   `json:"email_new_followers"`
+	EmailWeeklyStats  bool   `json:"email_weekly_stats"`
+	EmailWeeklyRecap  bool   `json:"email_weekly_recap"`
+}
+
+// EmailRecipient is a user who opted in to an email notification
+type EmailRecipient struct {
+	UserID string
+	Email  string
+}
+
+// FollowerDigest counts the followers a user gained since their last new
+// followers email, up to the follow at Until
+type FollowerDigest struct {
+	EmailRecipient
+	NewFollowers int
+	Until        time.Time
+}
+
+// InQuietHours reports whether t falls within the user's quiet hours
+func (s NotificationSettings) InQuietHours(t time.Time) bool {
+	start, err := minuteOfDay(s.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := minuteOfDay(s.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+
+	if start <= end {
+		return start <= now && now < end
+	}
+	return now >= start || now < end
+}
+
+// minuteOfDay parses an HH:MM clock time into minutes since midnight
+func minuteOfDay(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time %q: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
 }
@@ -0,0 +1,52 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationSettings_InQuietHours(t *testing.T) {
+	overnight := NotificationSettings{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Asia/Kolkata"}
+	daytime := NotificationSettings{QuietHoursStart: "09:00", QuietHoursEnd: "17:30"}
+
+	cases := []struct {
+		name     string
+		settings NotificationSettings
+		at       string // UTC
+		want     bool
+	}{
+		{"disabled", NotificationSettings{}, "2024-05-01T03:00:00Z", false},
+		{"overnight before start", overnight, "2024-05-01T16:00:00Z", false},  // 21:30 IST
+		{"overnight after start", overnight, "2024-05-01T16:30:00Z", true},    // 22:00 IST
+		{"overnight after midnight", overnight, "2024-05-01T20:00:00Z", true}, // 01:30 IST
+		{"overnight at end", overnight, "2024-05-02T01:30:00Z", false},        // 07:00 IST
+		{"daytime in UTC", daytime, "2024-05-01T12:00:00Z", true},
+		{"daytime at end", daytime, "2024-05-01T17:30:00Z", false},
+		{"malformed", NotificationSettings{QuietHoursStart: "25:00", QuietHoursEnd: "07:00"}, "2024-05-01T03:00:00Z", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339, tc.at)
+			if err != nil {
+				t.Fatalf("Failed to parse time: %v", err)
+			}
+			if got := tc.settings.InQuietHours(at); got != tc.want {
+				t.Errorf("Expected InQuietHours(%s) = %v, got %v", tc.at, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestContactHash(t *testing.T) {
+	const want = "ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976" // sha256 of alice@example.com
+
+	for _, email := range []string{"alice@example.com", "Alice@Example.COM", "  alice@example.com\n"} {
+		if got := ContactHash(email); got != want {
+			t.Errorf("ContactHash(%q) = %s, want %s", email, got, want)
+		}
+	}
+	if ContactHash("bob@example.com") == want {
+		t.Error("Expected different addresses to hash differently")
+	}
+}
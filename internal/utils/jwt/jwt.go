@@ -2,31 +2,161 @@ package jwt
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/princekumarofficial/stories-service/internal/config"
 )
 
-func CreateToken(username string, secretKey string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"username": username,
-			"exp":      time.Now().Add(time.Hour * 24).Unix(),
-		})
+// signingMethod is the only algorithm tokens are signed and accepted with
+var signingMethod = jwt.SigningMethodHS256
 
-	tokenString, err := token.SignedString([]byte(secretKey))
+// Scopes a token can carry, each allowing a group of routes
+const (
+	ScopeStoriesWrite = "stories:write" // Creating, deleting and sharing stories
+	ScopeMediaWrite   = "media:write"   // Uploading and deleting media
+	ScopeAdmin        = "admin"         // Admin routes
+)
+
+// UserScopes are granted to every user that logs in. Tokens issued before
+// scopes were added are treated as carrying them.
+var UserScopes = []string{ScopeStoriesWrite, ScopeMediaWrite}
+
+// Options control how tokens are issued and verified
+type Options struct {
+	Secret   string
+	Issuer   string
+	Audience string
+	TTL      time.Duration // how long an issued token stays valid
+	Leeway   time.Duration // clock skew tolerated when checking exp and iat
+}
+
+// OptionsFromConfig reads the token options from the service config
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		Secret:   cfg.JWTSecret,
+		Issuer:   cfg.JWT.Issuer,
+		Audience: cfg.JWT.Audience,
+		TTL:      time.Duration(cfg.JWT.TTL) * time.Second,
+		Leeway:   time.Duration(cfg.JWT.Leeway) * time.Second,
+	}
+}
+
+// MaxAge is the longest a token is accepted after it was issued
+func (o Options) MaxAge() time.Duration {
+	return o.TTL + o.Leeway
+}
+
+// Claims are the claims the service reads from a verified token
+type Claims struct {
+	UserID    string
+	TenantID  string // empty for tokens issued before tenants were added
+	TokenID   string // jti; empty for tokens issued before IDs were added
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// ImpersonatorID is the admin acting as the user, read from the act
+	// claim; empty unless the token was issued to impersonate the user
+	ImpersonatorID string
+}
+
+// Impersonated reports whether an admin was issued the token to act as the user
+func (c Claims) Impersonated() bool {
+	return c.ImpersonatorID != ""
+}
+
+// HasScope reports whether the token carries scope
+func (c Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// CreateToken issues a signed token for the user carrying scopes and returns
+// it with its claims
+func CreateToken(username string, tenantID string, scopes []string, opts Options) (string, Claims, error) {
+	return sign(newClaims(username, tenantID, scopes, opts.TTL), opts)
+}
+
+// CreateImpersonationToken issues a token that lets an admin act as the user
+// for ttl. It carries no scopes, so it cannot write or reach admin routes, and
+// names the admin in an act claim (RFC 8693).
+func CreateImpersonationToken(userID, tenantID, adminID string, ttl time.Duration, opts Options) (string, Claims, error) {
+	claims := newClaims(userID, tenantID, nil, ttl)
+	claims.ImpersonatorID = adminID
+	return sign(claims, opts)
+}
+
+// newClaims returns the claims of a new token valid for ttl
+func newClaims(userID, tenantID string, scopes []string, ttl time.Duration) Claims {
+	now := time.Now().Truncate(time.Second)
+	return Claims{
+		UserID:    userID,
+		TenantID:  tenantID,
+		TokenID:   uuid.NewString(),
+		Scopes:    append([]string{}, scopes...),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// sign signs the claims and returns the token with them
+func sign(claims Claims, opts Options) (string, Claims, error) {
+	mapClaims := jwt.MapClaims{
+		"username": claims.UserID,
+		"tenant":   claims.TenantID,
+		"jti":      claims.TokenID,
+		"scope":    strings.Join(claims.Scopes, " "),
+		"iss":      opts.Issuer,
+		"aud":      opts.Audience,
+		"iat":      claims.IssuedAt.Unix(),
+		"exp":      claims.ExpiresAt.Unix(),
+	}
+	if claims.Impersonated() {
+		mapClaims["act"] = map[string]string{"sub": claims.ImpersonatorID}
+	}
+
+	tokenString, err := jwt.NewWithClaims(signingMethod, mapClaims).SignedString([]byte(opts.Secret))
 	if err != nil {
-		return "", err
+		return "", Claims{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
-func VerifyToken(tokenString string, secretKey string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+// parse verifies the token's signature, issuer, audience and timestamps.
+// Tokens must carry exp and iat, and alg=none or any algorithm other than
+// signingMethod is rejected before the signature is checked.
+func parse(tokenString string, opts Options) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString,
+		func(token *jwt.Token) (interface{}, error) {
+			if token.Method.Alg() != signingMethod.Alg() {
+				return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+			}
+			return []byte(opts.Secret), nil
+		},
+		jwt.WithValidMethods([]string{signingMethod.Alg()}),
+		jwt.WithIssuer(opts.Issuer),
+		jwt.WithAudience(opts.Audience),
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// WithIssuedAt only checks iat when it is present
+	if iat, err := token.Claims.GetIssuedAt(); err != nil || iat == nil {
+		return nil, fmt.Errorf("token has no issued at time")
+	}
+
+	return token, nil
+}
 
+func VerifyToken(tokenString string, opts Options) error {
+	token, err := parse(tokenString, opts)
 	if err != nil {
 		return err
 	}
@@ -38,29 +168,55 @@ func VerifyToken(tokenString string, secretKey string) error {
 	return nil
 }
 
-// ExtractUserIDFromToken extracts the user ID from a valid JWT token
-func ExtractUserIDFromToken(tokenString string, secretKey string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-
+// ParseToken verifies a token and returns its claims
+func ParseToken(tokenString string, opts Options) (Claims, error) {
+	token, err := parse(tokenString, opts)
 	if err != nil {
-		return "", err
+		return Claims{}, err
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("invalid token")
+		return Claims{}, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", fmt.Errorf("invalid token claims")
+		return Claims{}, fmt.Errorf("invalid token claims")
 	}
 
 	username, ok := claims["username"].(string)
 	if !ok {
-		return "", fmt.Errorf("username not found in token")
+		return Claims{}, fmt.Errorf("username not found in token")
+	}
+
+	parsed := Claims{UserID: username}
+	parsed.TenantID, _ = claims["tenant"].(string)
+	parsed.TokenID, _ = claims["jti"].(string)
+	if scope, ok := claims["scope"]; ok {
+		scopes, _ := scope.(string)
+		parsed.Scopes = strings.Fields(scopes)
+	} else {
+		parsed.Scopes = append([]string{}, UserScopes...)
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		parsed.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		parsed.ExpiresAt = exp.Time
+	}
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		parsed.ImpersonatorID, _ = act["sub"].(string)
+	}
+
+	return parsed, nil
+}
+
+// ExtractUserIDFromToken extracts the user ID from a valid JWT token
+func ExtractUserIDFromToken(tokenString string, opts Options) (string, error) {
+	claims, err := ParseToken(tokenString, opts)
+	if err != nil {
+		return "", err
 	}
 
-	return username, nil
+	return claims.UserID, nil
 }
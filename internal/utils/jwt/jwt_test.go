@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testOptions = Options{
+	Secret:   "secret",
+	Issuer:   "stories-service",
+	Audience: "stories-service",
+	TTL:      24 * time.Hour,
+	Leeway:   30 * time.Second,
+}
+
+// signed signs claims with method and the test secret
+func signed(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+
+	key := interface{}([]byte(testOptions.Secret))
+	if method == jwt.SigningMethodNone {
+		key = jwt.UnsafeAllowNoneSignatureType
+	}
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+// validClaims returns claims the test options accept, with overrides applied
+func validClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"username": "42",
+		"iss":      testOptions.Issuer,
+		"aud":      testOptions.Audience,
+		"iat":      now.Unix(),
+		"exp":      now.Add(time.Hour).Unix(),
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+	}
+	return claims
+}
+
+func TestCreateToken(t *testing.T) {
+	token, claims, err := CreateToken("42", "acme", []string{ScopeStoriesWrite, ScopeAdmin}, testOptions)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if claims.TokenID == "" || claims.ExpiresAt.Sub(claims.IssuedAt) != testOptions.TTL {
+		t.Errorf("Expected a token ID and a %s lifetime, got %+v", testOptions.TTL, claims)
+	}
+
+	parsed, err := ParseToken(token, testOptions)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, claims) {
+		t.Errorf("Expected parsed claims %+v to match issued claims %+v", parsed, claims)
+	}
+	if !parsed.ExpiresAt.After(time.Now()) {
+		t.Errorf("Expected the token to expire in the future, got %s", parsed.ExpiresAt)
+	}
+
+	otherSecret := testOptions
+	otherSecret.Secret = "other-secret"
+	if _, err := ParseToken(token, otherSecret); err == nil {
+		t.Error("Expected a token signed with another secret to be rejected")
+	}
+}
+
+func TestCreateImpersonationToken(t *testing.T) {
+	token, claims, err := CreateImpersonationToken("42", "acme", "7", 15*time.Minute, testOptions)
+	if err != nil {
+		t.Fatalf("CreateImpersonationToken failed: %v", err)
+	}
+	if !claims.Impersonated() || claims.ExpiresAt.Sub(claims.IssuedAt) != 15*time.Minute {
+		t.Errorf("Expected an impersonation token valid for 15m, got %+v", claims)
+	}
+
+	parsed, err := ParseToken(token, testOptions)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, claims) {
+		t.Errorf("Expected parsed claims %+v to match issued claims %+v", parsed, claims)
+	}
+	if parsed.ImpersonatorID != "7" || len(parsed.Scopes) != 0 {
+		t.Errorf("Expected admin 7 acting with no scopes, got %+v", parsed)
+	}
+
+	// Regular tokens and malformed act claims are not impersonated
+	for name, act := range map[string]interface{}{
+		"no act claim":    nil,
+		"act not object":  "7",
+		"act without sub": map[string]interface{}{"iss": "7"},
+	} {
+		claims, err := ParseToken(signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"act": act})), testOptions)
+		if err != nil {
+			t.Fatalf("%s: ParseToken failed: %v", name, err)
+		}
+		if claims.Impersonated() {
+			t.Errorf("%s: expected the token not to be impersonated, got %q", name, claims.ImpersonatorID)
+		}
+	}
+}
+
+func TestParseTokenScopes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		claims jwt.MapClaims
+		want   []string
+	}{
+		"scoped":                {jwt.MapClaims{"scope": "media:write admin"}, []string{ScopeMediaWrite, ScopeAdmin}},
+		"no scopes":             {jwt.MapClaims{"scope": ""}, []string{}},
+		"issued before scopes":  {nil, UserScopes},
+		"malformed scope claim": {jwt.MapClaims{"scope": 42}, []string{}},
+	} {
+		claims, err := ParseToken(signed(t, jwt.SigningMethodHS256, validClaims(tc.claims)), testOptions)
+		if err != nil {
+			t.Fatalf("%s: ParseToken failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(claims.Scopes, tc.want) {
+			t.Errorf("%s: expected scopes %v, got %v", name, tc.want, claims.Scopes)
+		}
+	}
+
+	claims := Claims{Scopes: UserScopes}
+	if !claims.HasScope(ScopeStoriesWrite) || claims.HasScope(ScopeAdmin) {
+		t.Errorf("Expected user scopes to include %s and not %s", ScopeStoriesWrite, ScopeAdmin)
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		token string
+		valid bool
+	}{
+		"valid":                 {signed(t, jwt.SigningMethodHS256, validClaims(nil)), true},
+		"alg none":              {signed(t, jwt.SigningMethodNone, validClaims(nil)), false},
+		"other HMAC algorithm":  {signed(t, jwt.SigningMethodHS512, validClaims(nil)), false},
+		"wrong issuer":          {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"iss": "someone-else"})), false},
+		"missing issuer":        {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"iss": nil})), false},
+		"wrong audience":        {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"aud": "someone-else"})), false},
+		"missing audience":      {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"aud": nil})), false},
+		"missing expiry":        {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"exp": nil})), false},
+		"expired within leeway": {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()})), true},
+		"expired past leeway":   {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})), false},
+		"issued within leeway":  {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"iat": now.Add(10 * time.Second).Unix()})), true},
+		"issued in the future":  {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"iat": now.Add(time.Minute).Unix()})), false},
+		"missing issued at":     {signed(t, jwt.SigningMethodHS256, validClaims(jwt.MapClaims{"iat": nil})), false},
+	} {
+		_, err := ParseToken(tc.token, testOptions)
+		if tc.valid && err != nil {
+			t.Errorf("%s: expected the token to be accepted, got %v", name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+}
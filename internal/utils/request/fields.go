@@ -0,0 +1,116 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// Fields are the top-level JSON fields a client asked for with the fields
+// query parameter, such as ?fields=id,media_key,author. A nil Fields keeps
+// every field.
+type Fields map[string]bool
+
+// ParseFields reads the fields query parameter, naming fields of dto's JSON
+// encoding. An unknown field gets a 400 response and false, so handlers can
+// simply return.
+func ParseFields(w http.ResponseWriter, r *http.Request, dto any) (Fields, bool) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, true
+	}
+
+	known := jsonFields(reflect.TypeOf(dto))
+	fields := make(Fields)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Errorf(r.Context(), i18n.MsgUnknownField, name)))
+			return nil, false
+		}
+		fields[name] = true
+	}
+	if len(fields) == 0 {
+		return nil, true
+	}
+	return fields, true
+}
+
+// Select returns data, a DTO or a slice of them, encoded with only the
+// selected fields of each. Without a selection data is returned as is.
+func (f Fields) Select(data any) (any, error) {
+	if f == nil {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &list); err == nil {
+		for _, item := range list {
+			f.trim(item)
+		}
+		return list, nil
+	}
+
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &item); err != nil {
+		return nil, fmt.Errorf("cannot select fields of %T", data)
+	}
+	f.trim(item)
+	return item, nil
+}
+
+// trim drops the fields of item that were not selected
+func (f Fields) trim(item map[string]json.RawMessage) {
+	for name := range item {
+		if !f[name] {
+			delete(item, name)
+		}
+	}
+}
+
+// jsonFields returns the names of the fields t encodes to JSON, including
+// those of embedded structs
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			for name := range jsonFields(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		names[tag] = true
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+package request
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fieldsInner struct {
+	CreatedAt string `json:"created_at"`
+}
+
+type fieldsDTO struct {
+	fieldsInner
+	ID       string `json:"id"`
+	MediaKey string `json:"media_key,omitempty"`
+	Secret   string `json:"-"`
+}
+
+func TestParseFields(t *testing.T) {
+	cases := map[string]struct {
+		query  string
+		want   []string
+		wantOK bool
+	}{
+		"absent":   {"", nil, true},
+		"one":      {"?fields=id", []string{"id"}, true},
+		"several":  {"?fields=id,%20media_key", []string{"id", "media_key"}, true},
+		"embedded": {"?fields=created_at", []string{"created_at"}, true},
+		"empty":    {"?fields=,", nil, true},
+		"unknown":  {"?fields=id,nope", nil, false},
+		"ignored":  {"?fields=Secret", nil, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			fields, ok := ParseFields(rec, httptest.NewRequest(http.MethodGet, "/feed"+tc.query, nil), fieldsDTO{})
+
+			if ok != tc.wantOK {
+				t.Fatalf("Expected ok %v, got %v", tc.wantOK, ok)
+			}
+			if !ok && rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", rec.Code)
+			}
+			if tc.want == nil && fields != nil {
+				t.Errorf("Expected no selection, got %v", fields)
+			}
+			for _, name := range tc.want {
+				if !fields[name] {
+					t.Errorf("Expected %q to be selected, got %v", name, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestFields_Select(t *testing.T) {
+	fields := Fields{"id": true, "created_at": true}
+	dto := fieldsDTO{fieldsInner: fieldsInner{CreatedAt: "now"}, ID: "1", MediaKey: "key"}
+
+	one, err := fields.Select(&dto)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if got := encode(t, one); got != `{"created_at":"now","id":"1"}` {
+		t.Errorf("Expected the selected fields, got %s", got)
+	}
+
+	many, err := fields.Select([]fieldsDTO{dto, dto})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if got := encode(t, many); got != `[{"created_at":"now","id":"1"},{"created_at":"now","id":"1"}]` {
+		t.Errorf("Expected the selected fields of each, got %s", got)
+	}
+
+	all, err := Fields(nil).Select(dto)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if _, ok := all.(fieldsDTO); !ok {
+		t.Errorf("Expected data unchanged without a selection, got %T", all)
+	}
+}
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(b)
+}
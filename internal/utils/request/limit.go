@@ -0,0 +1,32 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+// Limits are the page sizes a list endpoint serves by default and at most
+type Limits struct {
+	Default int
+	Max     int
+}
+
+// Limit reads the limit query parameter, defaulting to l.Default. A limit that
+// is not a number from 1 to l.Max gets a 400 response and false, so handlers
+// can simply return.
+func (l Limits) Limit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	param := r.URL.Query().Get("limit")
+	if param == "" {
+		return min(l.Default, l.Max), true
+	}
+
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit < 1 || limit > l.Max {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Errorf(r.Context(), i18n.MsgInvalidLimit, l.Max)))
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,48 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimits_Limit(t *testing.T) {
+	limits := Limits{Default: 50, Max: 200}
+
+	cases := map[string]struct {
+		query     string
+		wantLimit int
+		wantOK    bool
+	}{
+		"default":     {"", 50, true},
+		"within max":  {"?limit=10", 10, true},
+		"at max":      {"?limit=200", 200, true},
+		"above max":   {"?limit=201", 0, false},
+		"zero":        {"?limit=0", 0, false},
+		"negative":    {"?limit=-5", 0, false},
+		"not numeric": {"?limit=ten", 0, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			limit, ok := limits.Limit(rec, httptest.NewRequest(http.MethodGet, "/feed"+tc.query, nil))
+
+			if ok != tc.wantOK || limit != tc.wantLimit {
+				t.Errorf("Expected limit %d and ok %v, got %d and %v", tc.wantLimit, tc.wantOK, limit, ok)
+			}
+			if !tc.wantOK && rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestLimits_DefaultAboveMax(t *testing.T) {
+	limits := Limits{Default: 50, Max: 20}
+
+	limit, ok := limits.Limit(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if !ok || limit != 20 {
+		t.Errorf("Expected the default to be capped at 20, got %d", limit)
+	}
+}
@@ -0,0 +1,48 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+	"github.com/princekumarofficial/stories-service/internal/validation"
+)
+
+// DecodeJSON decodes the JSON request body into a T and validates it. On failure
+// it writes a 400 response (with per-field errors for validation failures) and
+// returns false, so handlers can simply return.
+func DecodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, bool) {
+	var req T
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if errors.Is(err, io.EOF) {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(i18n.Error(r.Context(), i18n.MsgRequestBodyEmpty)))
+		return req, false
+	} else if err != nil {
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		return req, false
+	}
+
+	return req, Validate(w, r, req)
+}
+
+// Validate validates v, which handlers do again after filling in part of a
+// decoded request. On failure it writes a 400 response like DecodeJSON and
+// returns false.
+func Validate(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := validation.Validator().Struct(v)
+	if err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			response.WriteJSON(w, http.StatusBadRequest, response.ValidationError(ve, validation.Translator(r.Context())))
+			return false
+		}
+		response.WriteJSON(w, http.StatusBadRequest, response.GeneralError(err))
+		return false
+	}
+
+	return true
+}
@@ -3,15 +3,25 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
 type Response struct {
-	Status  string      `json:"status"`
-	Error   string      `json:"error,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Message string      `json:"message,omitempty"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+// FieldError describes a single failed validation rule on a request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 const (
@@ -34,15 +44,51 @@ func GeneralError(err error) Response {
 	}
 }
 
-func ValidationError(errs validator.ValidationErrors) Response {
-	var errorMessages string
+// ValidationError builds an error response with one translated entry per failed field rule
+func ValidationError(errs validator.ValidationErrors, trans ut.Translator) Response {
+	fieldErrors := make([]FieldError, 0, len(errs))
+	errorMessages := make([]string, 0, len(errs))
 	for _, err := range errs {
-		errorMessages += err.Field() + ": " + err.Tag() + "; "
+		message := err.Translate(trans)
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   fieldPath(err),
+			Rule:    err.Tag(),
+			Message: message,
+		})
+		errorMessages = append(errorMessages, message)
 	}
 
 	return Response{
 		Status: StatusError,
-		Error:  errorMessages,
+		Error:  strings.Join(errorMessages, "; "),
+		Errors: fieldErrors,
+	}
+}
+
+// fieldPath returns the field's path without the top-level struct name,
+// e.g. "audience_user_ids[0]" rather than "StoryPostRequest.audience_user_ids[0]"
+func fieldPath(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return err.Field()
+}
+
+// Envelope is a success response whose data has type T. It encodes like
+// Response, so clients decode every endpoint the same way.
+type Envelope[T any] struct {
+	Status  string `json:"status"`
+	Data    T      `json:"data"`
+	Message string `json:"message,omitempty"`
+}
+
+// OK builds a success response carrying data of type T
+func OK[T any](message string, data T) Envelope[T] {
+	return Envelope[T]{
+		Status:  StatusSuccess,
+		Data:    data,
+		Message: message,
 	}
 }
 
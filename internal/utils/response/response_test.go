@@ -0,0 +1,30 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOK_MatchesRequestOK(t *testing.T) {
+	data := map[string]string{"id": "42"}
+
+	typed, err := json.Marshal(OK("User created successfully", data))
+	if err != nil {
+		t.Fatalf("Failed to encode OK: %v", err)
+	}
+	untyped, err := json.Marshal(RequestOK("User created successfully", data))
+	if err != nil {
+		t.Fatalf("Failed to encode RequestOK: %v", err)
+	}
+	if string(typed) != string(untyped) {
+		t.Errorf("Expected OK to encode like RequestOK, got %s and %s", typed, untyped)
+	}
+
+	var decoded Envelope[map[string]string]
+	if err := json.Unmarshal(typed, &decoded); err != nil {
+		t.Fatalf("Failed to decode envelope: %v", err)
+	}
+	if decoded.Status != StatusSuccess || decoded.Data["id"] != "42" {
+		t.Errorf("Unexpected envelope: %+v", decoded)
+	}
+}
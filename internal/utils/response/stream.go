@@ -0,0 +1,103 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// streamFlushEvery is how many lines a stream buffers before flushing
+const streamFlushEvery = 50
+
+// NDJSONStream writes a 200 response as newline-delimited JSON, one value
+// per line, flushing as it goes so clients can consume lines before the
+// response ends. Errors after the first line can no longer change the
+// status, so they are reported as a final line instead.
+type NDJSONStream struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	pending int
+}
+
+// NewNDJSONStream starts an NDJSON response on w
+func NewNDJSONStream(w http.ResponseWriter) *NDJSONStream {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	return &NDJSONStream{
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Write encodes value as the next line
+func (s *NDJSONStream) Write(value interface{}) error {
+	if err := s.encoder.Encode(value); err != nil {
+		return err
+	}
+
+	s.pending++
+	if s.pending >= streamFlushEvery {
+		s.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered lines to the client
+func (s *NDJSONStream) Flush() {
+	s.pending = 0
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CSVStream writes a 200 response as a CSV file download, one record per
+// line, flushing as it goes. A failure after the header can no longer change
+// the status, so callers should abort the response rather than let a
+// truncated file look complete.
+type CSVStream struct {
+	w       http.ResponseWriter
+	writer  *csv.Writer
+	pending int
+}
+
+// NewCSVStream starts a CSV download of filename on w, beginning with the
+// header record
+func NewCSVStream(w http.ResponseWriter, filename string, header []string) (*CSVStream, error) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	s := &CSVStream{
+		w:      w,
+		writer: csv.NewWriter(w),
+	}
+	return s, s.Write(header)
+}
+
+// Write writes record as the next line
+func (s *CSVStream) Write(record []string) error {
+	if err := s.writer.Write(record); err != nil {
+		return err
+	}
+
+	s.pending++
+	if s.pending >= streamFlushEvery {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered lines to the client
+func (s *CSVStream) Flush() error {
+	s.pending = 0
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
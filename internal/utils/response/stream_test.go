@@ -0,0 +1,75 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONStream(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := NewNDJSONStream(recorder)
+
+	for i := 0; i < streamFlushEvery+1; i++ {
+		if err := stream.Write(map[string]int{"n": i}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if !recorder.Flushed {
+		t.Error("Expected the stream to flush after a full batch of lines")
+	}
+	stream.Flush()
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected content type application/x-ndjson, got %q", ct)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+	if len(lines) != streamFlushEvery+1 {
+		t.Fatalf("Expected %d lines, got %d", streamFlushEvery+1, len(lines))
+	}
+	for i, line := range lines {
+		var value map[string]int
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			t.Fatalf("Line %d is not JSON: %v", i, err)
+		}
+		if value["n"] != i {
+			t.Errorf("Line %d: expected n=%d, got %d", i, i, value["n"])
+		}
+	}
+}
+
+func TestCSVStream(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream, err := NewCSVStream(recorder, "insights.csv", []string{"day", "note"})
+	if err != nil {
+		t.Fatalf("NewCSVStream failed: %v", err)
+	}
+	if err := stream.Write([]string{"2024-05-01", "commas, and \"quotes\""}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := stream.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if ct := recorder.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected content type text/csv, got %q", ct)
+	}
+	if cd := recorder.Header().Get("Content-Disposition"); cd != `attachment; filename=insights.csv` {
+		t.Errorf("Unexpected content disposition %q", cd)
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Body is not CSV: %v", err)
+	}
+	if len(records) != 2 || records[1][1] != `commas, and "quotes"` {
+		t.Errorf("Unexpected records %q", records)
+	}
+}
@@ -0,0 +1,201 @@
+// Package validation holds the validator request decoding shares, with the
+// service's custom rules and their messages in every supported locale. It
+// replaces the validator the i18n package used to set up, so that i18n stays
+// a message catalog and does not import the request types the rules check.
+package validation
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// clockPattern matches a 24-hour HH:MM clock time
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// mediaKeyPattern matches object keys generated by the media service
+var mediaKeyPattern = regexp.MustCompile(`^users/[0-9]+/media/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}(\.[a-z0-9]+)?$`)
+
+var (
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+)
+
+// customTranslations holds the messages for custom rules per locale
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"visibility":         "{0} must be one of PUBLIC, FOLLOWERS, FRIENDS, PRIVATE, GROUP",
+		"default_visibility": "{0} must be empty or one of PUBLIC, FOLLOWERS, FRIENDS, PRIVATE",
+		"private_audience":   "{0} must name at least one user for PRIVATE stories",
+		"audience_private":   "{0} can only be set for PRIVATE stories",
+		"reaction_emoji":     "{0} must be one of 👍 ❤️ 😂 😮 😢 🔥",
+		"media_key":          "{0} must be a media key returned by /media/upload-url",
+		"clock":              "{0} must be a 24-hour time such as 22:30",
+		"timezone":           "{0} must be an IANA time zone such as Europe/Paris",
+	},
+	"es": {
+		"visibility":         "{0} debe ser uno de PUBLIC, FOLLOWERS, FRIENDS, PRIVATE, GROUP",
+		"default_visibility": "{0} debe estar vacío o ser uno de PUBLIC, FOLLOWERS, FRIENDS, PRIVATE",
+		"private_audience":   "{0} debe incluir al menos un usuario en las historias PRIVATE",
+		"audience_private":   "{0} solo se puede indicar en historias PRIVATE",
+		"reaction_emoji":     "{0} debe ser uno de 👍 ❤️ 😂 😮 😢 🔥",
+		"media_key":          "{0} debe ser una clave devuelta por /media/upload-url",
+		"clock":              "{0} debe ser una hora de 24 horas como 22:30",
+		"timezone":           "{0} debe ser una zona horaria IANA como Europe/Madrid",
+	},
+	"fr": {
+		"visibility":         "{0} doit être l'un de PUBLIC, FOLLOWERS, FRIENDS, PRIVATE, GROUP",
+		"default_visibility": "{0} doit être vide ou l'un de PUBLIC, FOLLOWERS, FRIENDS, PRIVATE",
+		"private_audience":   "{0} doit désigner au moins un utilisateur pour les stories PRIVATE",
+		"audience_private":   "{0} ne peut être indiqué que pour les stories PRIVATE",
+		"reaction_emoji":     "{0} doit être l'un de 👍 ❤️ 😂 😮 😢 🔥",
+		"media_key":          "{0} doit être une clé renvoyée par /media/upload-url",
+		"clock":              "{0} doit être une heure au format 24 heures comme 22:30",
+		"timezone":           "{0} doit être un fuseau horaire IANA comme Europe/Paris",
+	},
+}
+
+func init() {
+	validate = validator.New()
+
+	// Report JSON field names so messages match what clients sent
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	mustRegister("visibility", isValidVisibility)
+	mustRegister("default_visibility", isValidDefaultVisibility)
+	mustRegister("reaction_emoji", isValidReactionEmoji)
+	mustRegister("media_key", isValidMediaKey)
+	mustRegister("clock", isValidClock)
+	validate.RegisterStructValidation(validateStoryAudience, types.StoryPostRequest{}, types.ReshareRequest{}, types.StoryUpdateRequest{})
+
+	enLocale := en.New()
+	uni = ut.New(enLocale, enLocale, es.New(), fr.New())
+
+	registrations := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"es": es_translations.RegisterDefaultTranslations,
+		"fr": fr_translations.RegisterDefaultTranslations,
+	}
+
+	for locale, register := range registrations {
+		trans, _ := uni.GetTranslator(locale)
+		if err := register(validate, trans); err != nil {
+			log.Fatalf("failed to register %s validation translations: %s", locale, err)
+		}
+		registerCustomTranslations(trans, customTranslations[locale])
+	}
+}
+
+func mustRegister(tag string, fn validator.Func) {
+	if err := validate.RegisterValidation(tag, fn); err != nil {
+		log.Fatalf("failed to register %s validation: %s", tag, err)
+	}
+}
+
+func registerCustomTranslations(trans ut.Translator, messages map[string]string) {
+	for tag, message := range messages {
+		err := validate.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error {
+				return ut.Add(tag, message, true)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				t, _ := ut.T(fe.Tag(), fe.Field())
+				return t
+			},
+		)
+		if err != nil {
+			log.Fatalf("failed to register %s translation: %s", tag, err)
+		}
+	}
+}
+
+func isValidVisibility(fl validator.FieldLevel) bool {
+	switch types.Visibility(fl.Field().String()) {
+	case types.VisibilityPublic, types.VisibilityFollowers, types.VisibilityFriends, types.VisibilityPrivate, types.VisibilityGroup:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidDefaultVisibility accepts the visibilities a story can get without
+// naming a group, or none to clear the default
+func isValidDefaultVisibility(fl validator.FieldLevel) bool {
+	visibility := types.Visibility(fl.Field().String())
+	return visibility == "" || (visibility != types.VisibilityGroup && isValidVisibility(fl))
+}
+
+func isValidReactionEmoji(fl validator.FieldLevel) bool {
+	switch types.ReactionType(fl.Field().String()) {
+	case types.ReactionThumbsUp, types.ReactionHeart, types.ReactionLaugh,
+		types.ReactionSurprised, types.ReactionSad, types.ReactionFire:
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidMediaKey(fl validator.FieldLevel) bool {
+	return mediaKeyPattern.MatchString(fl.Field().String())
+}
+
+func isValidClock(fl validator.FieldLevel) bool {
+	return clockPattern.MatchString(fl.Field().String())
+}
+
+// validateStoryAudience checks a story's audience against its visibility:
+// PRIVATE stories need one and no others may have one. A new story may
+// leave its audience out for the author's default to fill in, so it is
+// validated again once defaults are applied; an edit without a visibility
+// keeps the story's audience and is checked by its handler.
+func validateStoryAudience(sl validator.StructLevel) {
+	var visibility types.Visibility
+	var audience []string
+	mayOmit := false
+	switch story := sl.Current().Interface().(type) {
+	case types.StoryPostRequest:
+		visibility, audience, mayOmit = story.Visibility, story.AudienceUserIDs, story.AudienceUserIDs == nil
+	case types.ReshareRequest:
+		visibility, audience = story.Visibility, story.AudienceUserIDs
+	case types.StoryUpdateRequest:
+		visibility, audience = story.Visibility, story.AudienceUserIDs
+	}
+
+	switch {
+	case visibility == "":
+	case visibility == types.VisibilityPrivate && len(audience) == 0 && !mayOmit:
+		sl.ReportError(audience, "audience_user_ids", "AudienceUserIDs", "private_audience", "")
+	case visibility != types.VisibilityPrivate && len(audience) > 0:
+		sl.ReportError(audience, "audience_user_ids", "AudienceUserIDs", "audience_private", "")
+	}
+}
+
+// Validator returns the shared validator with custom rules and translations registered
+func Validator() *validator.Validate {
+	return validate
+}
+
+// Translator returns the validation message translator for the request locale
+func Translator(ctx context.Context) ut.Translator {
+	trans, _ := uni.GetTranslator(i18n.LocaleFromContext(ctx))
+	return trans
+}
@@ -0,0 +1,175 @@
+package validation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/princekumarofficial/stories-service/internal/i18n"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"github.com/princekumarofficial/stories-service/internal/utils/response"
+)
+
+func validationErrors(t *testing.T, v interface{}) validator.ValidationErrors {
+	t.Helper()
+
+	err := Validator().Struct(v)
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	return ve
+}
+
+func TestCustomRules(t *testing.T) {
+	valid := types.StoryPostRequest{
+		MediaKey:        "users/42/media/550e8400-e29b-41d4-a716-446655440000.jpg",
+		Visibility:      types.VisibilityFriends,
+		AudienceUserIDs: []string{},
+	}
+	if err := Validator().Struct(valid); err != nil {
+		t.Fatalf("Expected valid story request, got %v", err)
+	}
+
+	invalid := types.StoryPostRequest{
+		MediaKey:        "../../etc/passwd",
+		Visibility:      "EVERYONE",
+		AudienceUserIDs: []string{},
+	}
+	ve := validationErrors(t, invalid)
+
+	rules := map[string]string{}
+	for _, fe := range ve {
+		rules[fe.Field()] = fe.Tag()
+	}
+	if rules["media_key"] != "media_key" || rules["visibility"] != "visibility" {
+		t.Fatalf("Expected media_key and visibility rule failures, got %v", rules)
+	}
+
+	if err := Validator().Struct(types.ReactionRequest{Emoji: "🙃"}); err == nil {
+		t.Fatal("Expected unsupported emoji to be rejected")
+	}
+	if err := Validator().Struct(types.ReactionRequest{Emoji: types.ReactionFire}); err != nil {
+		t.Fatalf("Expected supported emoji to pass, got %v", err)
+	}
+}
+
+func TestValidationError_FieldErrors(t *testing.T) {
+	ve := validationErrors(t, types.ReactionRequest{Emoji: "🙃"})
+
+	ctx := context.WithValue(context.Background(), i18n.LocaleKey, "es")
+	resp := response.ValidationError(ve, Translator(ctx))
+
+	if len(resp.Errors) != 1 {
+		t.Fatalf("Expected 1 field error, got %d", len(resp.Errors))
+	}
+
+	fieldErr := resp.Errors[0]
+	if fieldErr.Field != "emoji" || fieldErr.Rule != "reaction_emoji" {
+		t.Fatalf("Unexpected field error: %+v", fieldErr)
+	}
+	if !strings.Contains(fieldErr.Message, "debe ser uno de") {
+		t.Fatalf("Expected Spanish message, got %q", fieldErr.Message)
+	}
+	if resp.Error != fieldErr.Message {
+		t.Fatalf("Expected summary error to match the field message, got %q", resp.Error)
+	}
+}
+
+func TestNotificationSettingsRules(t *testing.T) {
+	valid := []users.NotificationSettings{
+		{},
+		{QuietHoursStart: "22:00", QuietHoursEnd: "07:30", Timezone: "Asia/Kolkata"},
+		{QuietHoursStart: "00:00", QuietHoursEnd: "23:59"},
+	}
+	for _, settings := range valid {
+		if err := Validator().Struct(settings); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", settings, err)
+		}
+	}
+
+	invalid := map[string]users.NotificationSettings{
+		"clock":         {QuietHoursStart: "24:00", QuietHoursEnd: "07:00"},
+		"required_with": {QuietHoursStart: "22:00"},
+		"timezone":      {QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Mars/Olympus"},
+	}
+	for rule, settings := range invalid {
+		ve := validationErrors(t, settings)
+		if ve[0].Tag() != rule {
+			t.Errorf("Expected %+v to fail %s, got %s", settings, rule, ve[0].Tag())
+		}
+	}
+}
+
+func TestStorySettingsUpdateRules(t *testing.T) {
+	private, cleared := types.VisibilityPrivate, types.Visibility("")
+	group, everyone := types.VisibilityGroup, types.Visibility("EVERYONE")
+	hours, none, tooLong := 12, 0, 72
+	audience, badAudience := []string{"7", "12"}, []string{"bob"}
+
+	valid := []types.StorySettingsUpdate{
+		{},
+		{DefaultVisibility: &private, DefaultAudienceUserIDs: &audience, DefaultExpiresInHours: &hours},
+		{DefaultVisibility: &cleared, DefaultAudienceUserIDs: &[]string{}, DefaultExpiresInHours: &none},
+	}
+	for _, update := range valid {
+		if err := Validator().Struct(update); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", update, err)
+		}
+	}
+
+	if ve := validationErrors(t, types.StorySettingsUpdate{DefaultVisibility: &group}); ve[0].Tag() != "default_visibility" {
+		t.Errorf("Expected GROUP to be rejected as a default, got %s", ve[0].Tag())
+	}
+
+	invalid := map[string]types.StorySettingsUpdate{
+		"default_visibility": {DefaultVisibility: &everyone},
+		"numeric":            {DefaultAudienceUserIDs: &badAudience},
+		"max":                {DefaultExpiresInHours: &tooLong},
+	}
+	for rule, update := range invalid {
+		ve := validationErrors(t, update)
+		if ve[0].Tag() != rule {
+			t.Errorf("Expected %+v to fail %s, got %s", update, rule, ve[0].Tag())
+		}
+	}
+}
+
+func TestStoryAudienceRules(t *testing.T) {
+	audience := []string{"7", "12"}
+
+	valid := []any{
+		types.StoryPostRequest{Visibility: types.VisibilityPublic},
+		types.StoryPostRequest{Visibility: types.VisibilityPrivate},
+		types.StoryPostRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: audience},
+		types.ReshareRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: audience},
+		types.StoryUpdateRequest{Version: 1},
+	}
+	for _, story := range valid {
+		if err := Validator().Struct(story); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", story, err)
+		}
+	}
+
+	invalid := map[string]any{
+		"private_audience": types.StoryPostRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: []string{}},
+		"audience_private": types.StoryPostRequest{Visibility: types.VisibilityPublic, AudienceUserIDs: audience},
+		"max":              types.StoryPostRequest{Visibility: types.VisibilityPrivate, AudienceUserIDs: make([]string, 101)},
+	}
+	for rule, story := range invalid {
+		ve := validationErrors(t, story)
+		if ve[0].Tag() != rule {
+			t.Errorf("Expected %+v to fail %s, got %s", story, rule, ve[0].Tag())
+		}
+	}
+
+	// Only a new story waits for its author's default audience
+	if ve := validationErrors(t, types.ReshareRequest{Visibility: types.VisibilityPrivate}); ve[0].Tag() != "private_audience" {
+		t.Errorf("Expected a PRIVATE reshare without an audience to be rejected, got %s", ve[0].Tag())
+	}
+	if ve := validationErrors(t, types.StoryUpdateRequest{Version: 1, Visibility: types.VisibilityPrivate}); ve[0].Tag() != "private_audience" {
+		t.Errorf("Expected a PRIVATE edit without an audience to be rejected, got %s", ve[0].Tag())
+	}
+}
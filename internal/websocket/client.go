@@ -1,12 +1,14 @@
 package websocket
 
 import (
-	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/princekumarofficial/stories-service/internal/metrics"
 	"github.com/princekumarofficial/stories-service/internal/types"
 )
 
@@ -22,8 +24,16 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Number of outbound messages buffered per client. Events past it are
+	// dropped, and a client whose queue stays full is disconnected as a slow
+	// consumer.
+	clientQueueSize = 256
 )
 
+// ErrSlowConsumer is returned when a client's outbound queue is full
+var ErrSlowConsumer = errors.New("client send queue is full")
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -45,30 +55,80 @@ type Client struct {
 	// User ID associated with this connection
 	userID string
 
+	// Tenant of the user, whose broadcasts the connection gets
+	tenant string
+
+	// IP address the connection came from, counted against the per-IP cap
+	remoteIP string
+
+	// Wire format negotiated for this connection
+	encoding Encoding
+
+	// Topic the connection subscribed to, if any; it gets the topic's events
+	// as well as its user's
+	topic string
+
+	// Unix nanoseconds of the last pong or message received from the peer
+	lastSeen atomic.Int64
+
+	// Set once either pump has exited, so the hub can reconcile dead clients
+	closed atomic.Bool
+
+	// Unix nanoseconds since the send queue has been full, 0 while it has room
+	fullSince atomic.Int64
+
+	// Nanoseconds the last frame took to write to the peer
+	writeNanos atomic.Int64
+
+	// Close frame writePump sends once the hub closes the send channel; a zero
+	// code sends an empty close frame. Set by the hub before closing send.
+	closeCode   int
+	closeReason string
+
 	// Hub instance
 	hub *Hub
 }
 
-// NewClient creates a new WebSocket client
-func NewClient(conn *websocket.Conn, userID string, hub *Hub) *Client {
-	return &Client{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		userID: userID,
-		hub:    hub,
+// NewClient creates a new WebSocket client using the encoding negotiated during the upgrade
+func NewClient(conn *websocket.Conn, userID, remoteIP string, hub *Hub) *Client {
+	client := &Client{
+		conn:     conn,
+		send:     make(chan []byte, clientQueueSize),
+		userID:   userID,
+		remoteIP: remoteIP,
+		encoding: encodingFromSubprotocol(conn.Subprotocol()),
+		hub:      hub,
 	}
+	client.touch()
+	return client
+}
+
+// WithTenant sets the tenant of the client's user. It must be called before
+// the client is registered.
+func (c *Client) WithTenant(tenantID string) *Client {
+	c.tenant = tenantID
+	return c
+}
+
+// WithTopic subscribes the client to topic. It must be called before the
+// client is registered.
+func (c *Client) WithTopic(topic string) *Client {
+	c.topic = topic
+	return c
 }
 
 // readPump pumps messages from the websocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.closed.Store(true)
+		c.hub.UnregisterClient(c)
 		c.conn.Close()
 	}()
 
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
+		c.touch()
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
 		return nil
 	})
@@ -81,6 +141,7 @@ func (c *Client) readPump() {
 			}
 			break
 		}
+		c.touch()
 		// For now, we don't handle incoming messages from clients
 		// This is a one-way communication for real-time events
 	}
@@ -90,6 +151,7 @@ func (c *Client) readPump() {
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
+		c.closed.Store(true)
 		ticker.Stop()
 		c.conn.Close()
 	}()
@@ -100,26 +162,38 @@ func (c *Client) writePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				payload := []byte{}
+				if c.closeCode != 0 {
+					payload = websocket.FormatCloseMessage(c.closeCode, c.closeReason)
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, payload)
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
+			start := time.Now()
+			depth := len(c.send) + 1
+
+			w, err := c.conn.NextWriter(c.encoding.messageType())
 			if err != nil {
 				return
 			}
 			w.Write(message)
 
-			// Add queued messages to the current message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+			// Add queued messages to the current message. Binary frames carry
+			// a single MessagePack value each, so only JSON is batched.
+			if c.encoding == EncodingJSON {
+				n := len(c.send)
+				for i := 0; i < n; i++ {
+					w.Write([]byte{'\n'})
+					w.Write(<-c.send)
+				}
 			}
 
 			if err := w.Close(); err != nil {
 				return
 			}
+			c.writeNanos.Store(int64(time.Since(start)))
+			metrics.ObserveWebSocketWrite(depth, start)
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -129,22 +203,52 @@ func (c *Client) writePump() {
 	}
 }
 
-// SendEvent sends an event to this client
+// SendEvent queues an event for this client without blocking. It returns
+// ErrSlowConsumer if the queue is full; the send channel is only ever closed
+// by the hub.
 func (c *Client) SendEvent(event *types.Event) error {
-	data, err := json.Marshal(event)
+	data, err := c.encoding.Marshal(event)
 	if err != nil {
 		return err
 	}
 
+	return c.queue(data)
+}
+
+// queue queues an encoded event without blocking, returning ErrSlowConsumer
+// if the queue is full. It records when the queue was first found full, and
+// clears that once an event fits again.
+func (c *Client) queue(data []byte) error {
 	select {
 	case c.send <- data:
+		c.fullSince.Store(0)
 		return nil
 	default:
-		close(c.send)
-		return websocket.ErrCloseSent
+		c.fullSince.CompareAndSwap(0, time.Now().UnixNano())
+		return ErrSlowConsumer
 	}
 }
 
+// stalled reports whether the send queue is full and has been since at least
+// timeout before now
+func (c *Client) stalled(now time.Time, timeout time.Duration) bool {
+	since := c.fullSince.Load()
+	if since == 0 || len(c.send) < cap(c.send) {
+		return false
+	}
+	return now.Sub(time.Unix(0, since)) >= timeout
+}
+
+// QueueDepth returns how many messages are waiting to be written
+func (c *Client) QueueDepth() int {
+	return len(c.send)
+}
+
+// WriteLatency returns how long the last frame took to write to the peer
+func (c *Client) WriteLatency() time.Duration {
+	return time.Duration(c.writeNanos.Load())
+}
+
 // Start starts the client's read and write pumps
 func (c *Client) Start() {
 	go c.writePump()
@@ -155,3 +259,13 @@ func (c *Client) Start() {
 func (c *Client) UserID() string {
 	return c.userID
 }
+
+// LastSeen returns when the peer was last heard from
+func (c *Client) LastSeen() time.Time {
+	return time.Unix(0, c.lastSeen.Load())
+}
+
+// touch records that the peer is alive
+func (c *Client) touch() {
+	c.lastSeen.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Encoding is the wire format used for event payloads on a connection
+type Encoding string
+
+const (
+	// EncodingJSON sends events as JSON text frames (the default)
+	EncodingJSON Encoding = "json"
+
+	// EncodingMsgPack sends events as MessagePack binary frames
+	EncodingMsgPack Encoding = "msgpack"
+)
+
+// Subprotocols lists the encodings clients can request via Sec-WebSocket-Protocol,
+// in order of server preference
+var Subprotocols = []string{string(EncodingMsgPack), string(EncodingJSON)}
+
+// encodingFromSubprotocol maps the negotiated subprotocol to an encoding,
+// falling back to JSON when the client did not ask for one
+func encodingFromSubprotocol(subprotocol string) Encoding {
+	if Encoding(subprotocol) == EncodingMsgPack {
+		return EncodingMsgPack
+	}
+	return EncodingJSON
+}
+
+// Marshal encodes an event in this encoding
+func (e Encoding) Marshal(event *types.Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil || e != EncodingMsgPack {
+		return data, err
+	}
+
+	// Event payloads are plain structs with JSON tags, so go through a generic
+	// map to keep the same field names in MessagePack
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return msgp.AppendIntf(nil, generic)
+}
+
+// messageType returns the WebSocket frame type used for this encoding
+func (e Encoding) messageType() int {
+	if e == EncodingMsgPack {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestEncoding_MarshalMsgPack(t *testing.T) {
+	event := &types.Event{
+		Type: types.EventStoryReacted,
+		Data: types.StoryReactedEvent{StoryID: "7", UserID: "42", Emoji: "🔥"},
+	}
+
+	data, err := EncodingMsgPack.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+
+	decoded, _, err := msgp.ReadIntfBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to decode MessagePack: %v", err)
+	}
+
+	fields, ok := decoded.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a map, got %T", decoded)
+	}
+	if fields["type"] != string(types.EventStoryReacted) {
+		t.Fatalf("Expected type %s, got %v", types.EventStoryReacted, fields["type"])
+	}
+
+	payload, ok := fields["data"].(map[string]interface{})
+	if !ok || payload["emoji"] != "🔥" || payload["story_id"] != "7" {
+		t.Fatalf("Unexpected payload: %v", fields["data"])
+	}
+}
+
+func TestEncodingFromSubprotocol(t *testing.T) {
+	if encodingFromSubprotocol("msgpack") != EncodingMsgPack {
+		t.Fatal("Expected msgpack subprotocol to select MessagePack")
+	}
+	if encodingFromSubprotocol("") != EncodingJSON {
+		t.Fatal("Expected JSON when no subprotocol was negotiated")
+	}
+}
@@ -1,151 +1,478 @@
 package websocket
 
 import (
+	"context"
+	"errors"
+	"hash/fnv"
 	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/princekumarofficial/stories-service/internal/types"
 )
 
-// Hub maintains the set of active clients and broadcasts messages to the clients
+const (
+	// Number of shards the hub splits its clients across by user ID
+	hubShards = 16
+
+	// Number of broadcasts each shard buffers before new ones are dropped
+	broadcastQueueSize = 1024
+
+	// How often the reaper checks for stale clients
+	reapInterval = 30 * time.Second
+
+	// Clients not heard from within this window are considered dead. Must be
+	// greater than pongWait so readPump gets the first chance to clean up.
+	staleAfter = pongWait + writeWait
+
+	// How long last-seen timestamps are kept for disconnected users
+	lastSeenRetention = 24 * time.Hour
+
+	// How long a client's send queue may stay full before it is disconnected
+	defaultSlowConsumerTimeout = 10 * time.Second
+)
+
+// Close codes sent to connections the hub or handler turns away
+const (
+	CloseTooManyConnections = 4008 // the user or IP is at its connection cap
+	CloseSlowConsumer       = 4009 // the client's send queue stayed full
+	CloseRateLimited        = 4029 // the IP is opening connections too fast
+)
+
+// ErrBroadcastQueueFull is returned when a broadcast is dropped because the
+// hub is not keeping up
+var ErrBroadcastQueueFull = errors.New("broadcast queue is full")
+
+// Errors RegisterClient returns for connections it turns away
+var (
+	ErrTooManyUserConnections = errors.New("too many connections for user")
+	ErrTooManyIPConnections   = errors.New("too many connections from IP")
+	ErrHubStopped             = errors.New("hub is stopped")
+)
+
+// registration is a client waiting to be registered or unregistered and
+// where to report once it is done
+type registration struct {
+	client *Client
+	result chan error
+}
+
+// Hub maintains the set of active clients and broadcasts messages to the
+// clients. Clients are split across shards by user ID, each with its own
+// lock, queues and loop, so registrations and broadcasts to different users
+// do not wait on each other.
 type Hub struct {
-	// Registered clients mapped by user ID
-	clients map[string]*Client
+	// Shards holding the clients, picked by shardFor
+	shards []*shard
 
-	// Register requests from the clients
-	register chan *Client
+	// Number of registered clients per remote IP, across every shard
+	ips   map[string]int
+	ipsMu sync.Mutex
 
-	// Unregister requests from clients
-	unregister chan *Client
+	// Connection caps per user and per IP; 0 is unlimited
+	maxPerUser int
+	maxPerIP   int
 
-	// Mutex to protect clients map
-	mu sync.RWMutex
+	// How long a client's send queue may stay full before it is disconnected
+	slowConsumerTimeout time.Duration
 
-	// Channel to broadcast events
-	broadcast chan *BroadcastMessage
+	// Closed by Shutdown to stop the shard loops; stopped is closed once Run
+	// has returned
+	quit     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// Broadcast counters, updated atomically; delivery counters are kept by
+	// each shard
+	broadcasts        atomic.Uint64
+	droppedBroadcasts atomic.Uint64
+}
+
+// HubStats is a snapshot of the hub's connection and delivery counters
+type HubStats struct {
+	ConnectedClients        int    `json:"connected_clients"`
+	ConnectedUsers          int    `json:"connected_users"`
+	QueuedBroadcasts        int    `json:"queued_broadcasts"`
+	Broadcasts              uint64 `json:"broadcasts"` // broadcasts to users handled, not counting topics
+	DeliveredEvents         uint64 `json:"delivered_events"`
+	DroppedBroadcasts       uint64 `json:"dropped_broadcasts"`
+	DroppedEvents           uint64 `json:"dropped_events"`
+	SlowConsumerDisconnects uint64 `json:"slow_consumer_disconnects"`
+	ReapedClients           uint64 `json:"reaped_clients"`
+	RejectedConnections     uint64 `json:"rejected_connections"` // turned away at a connection cap
+	MaxQueueDepth           int    `json:"max_queue_depth"`      // deepest send queue among connected clients
+	FullQueues              int    `json:"full_queues"`          // connected clients whose send queue is full
+	MaxWriteLatencyMs       int64  `json:"max_write_latency_ms"` // slowest last write among connected clients
 }
 
-// BroadcastMessage represents a message to be broadcast to specific users
+// Presence describes whether a user is connected and when they were last seen
+type Presence struct {
+	UserID   string `json:"user_id"`
+	Online   bool   `json:"online"`
+	LastSeen string `json:"last_seen,omitempty"`
+}
+
+// BroadcastMessage represents a message to be broadcast to specific users, to
+// every connected client when All is set, or to the subscribers of Topic
 type BroadcastMessage struct {
 	UserIDs []string     `json:"user_ids"`
+	All     bool         `json:"all"`
+	Tenant  string       `json:"tenant,omitempty"` // with All, only the clients of this tenant
+	Topic   string       `json:"topic,omitempty"`
 	Event   *types.Event `json:"event"`
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[string]*Client),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		broadcast:  make(chan *BroadcastMessage),
+	h := &Hub{
+		ips:     make(map[string]int),
+		quit:    make(chan struct{}),
+		stopped: make(chan struct{}),
+
+		slowConsumerTimeout: defaultSlowConsumerTimeout,
 	}
+
+	h.shards = make([]*shard, hubShards)
+	for i := range h.shards {
+		h.shards[i] = newShard(h)
+	}
+	return h
+}
+
+// WithConnectionLimits caps the connections one user and one IP may hold at
+// once; 0 leaves either unlimited. It must be called before Run.
+func (h *Hub) WithConnectionLimits(perUser, perIP int) *Hub {
+	h.maxPerUser = perUser
+	h.maxPerIP = perIP
+	return h
 }
 
-// Run starts the hub's main loop
+// WithSlowConsumerTimeout sets how long a client's send queue may stay full
+// before the client is disconnected with CloseSlowConsumer; 0 disconnects it
+// the first time an event does not fit. It must be called before Run.
+func (h *Hub) WithSlowConsumerTimeout(timeout time.Duration) *Hub {
+	h.slowConsumerTimeout = timeout
+	return h
+}
+
+// Run starts the loop of every shard. It returns after Shutdown is called,
+// once every shard has disconnected its clients.
 func (h *Hub) Run() {
-	for {
-		select {
-		case client := <-h.register:
-			h.mu.Lock()
-			// If user already has a connection, close the old one
-			if existingClient, exists := h.clients[client.userID]; exists {
-				close(existingClient.send)
-				slog.Info("Replaced existing WebSocket connection", slog.String("user_id", client.userID))
-			}
-			h.clients[client.userID] = client
-			h.mu.Unlock()
-			slog.Info("WebSocket client connected", slog.String("user_id", client.userID))
-
-		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client.userID]; ok {
-				delete(h.clients, client.userID)
-				close(client.send)
-				slog.Info("WebSocket client disconnected", slog.String("user_id", client.userID))
-			}
-			h.mu.Unlock()
+	defer close(h.stopped)
 
-		case message := <-h.broadcast:
-			h.broadcastToUsers(message.UserIDs, message.Event)
-		}
+	var wg sync.WaitGroup
+	for _, s := range h.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run()
+		}()
+	}
+	wg.Wait()
+
+	slog.Info("WebSocket hub stopped")
+}
+
+// Shutdown stops the hub and disconnects every client, waiting for Run to
+// return or ctx to be done
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.stopOnce.Do(func() {
+		close(h.quit)
+	})
+
+	select {
+	case <-h.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// RegisterClient registers a new client
-func (h *Hub) RegisterClient(client *Client) {
-	h.register <- client
+// shardFor returns the shard holding userID's clients
+func (h *Hub) shardFor(userID string) *shard {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return h.shards[hash.Sum32()%uint32(len(h.shards))]
 }
 
-// UnregisterClient unregisters a client
+// RegisterClient registers a new client, returning ErrTooManyUserConnections
+// or ErrTooManyIPConnections if its user or IP is at the connection cap.
+// Clients registering after shutdown are closed straight away.
+func (h *Hub) RegisterClient(client *Client) error {
+	reg := registration{client: client, result: make(chan error, 1)}
+	select {
+	case h.shardFor(client.userID).register <- reg:
+		return <-reg.result
+	case <-h.quit:
+		close(client.send)
+		return ErrHubStopped
+	}
+}
+
+// UnregisterClient unregisters a client, returning once it is removed so the
+// slot it held under the IP cap is free on every shard
 func (h *Hub) UnregisterClient(client *Client) {
-	h.unregister <- client
+	reg := registration{client: client, result: make(chan error, 1)}
+	select {
+	case h.shardFor(client.userID).unregister <- reg:
+		<-reg.result
+	case <-h.quit:
+		// Shutdown has already removed it
+	}
+}
+
+// reserveIP counts a connection from ip, unless the IP is at the connection
+// cap. Shards share the counts, so the cap holds across them.
+func (h *Hub) reserveIP(ip string) error {
+	if ip == "" {
+		return nil
+	}
+
+	h.ipsMu.Lock()
+	defer h.ipsMu.Unlock()
+
+	if h.maxPerIP > 0 && h.ips[ip] >= h.maxPerIP {
+		return ErrTooManyIPConnections
+	}
+	h.ips[ip]++
+	return nil
+}
+
+// releaseIP stops counting a connection from ip
+func (h *Hub) releaseIP(ip string) {
+	if ip == "" {
+		return
+	}
+
+	h.ipsMu.Lock()
+	defer h.ipsMu.Unlock()
+
+	if h.ips[ip]--; h.ips[ip] <= 0 {
+		delete(h.ips, ip)
+	}
 }
 
 // BroadcastToUsers sends an event to specific users
-func (h *Hub) BroadcastToUsers(userIDs []string, event *types.Event) {
-	message := &BroadcastMessage{
+func (h *Hub) BroadcastToUsers(userIDs []string, event *types.Event) error {
+	return h.enqueue(&BroadcastMessage{
 		UserIDs: userIDs,
 		Event:   event,
-	}
+	})
+}
 
-	select {
-	case h.broadcast <- message:
-	default:
-		slog.Warn("Broadcast channel is full, dropping message")
-	}
+// BroadcastToAll sends an event to every connected client
+func (h *Hub) BroadcastToAll(event *types.Event) error {
+	return h.enqueue(&BroadcastMessage{
+		All:   true,
+		Event: event,
+	})
 }
 
-// BroadcastToUser sends an event to a specific user
-func (h *Hub) BroadcastToUser(userID string, event *types.Event) {
-	h.BroadcastToUsers([]string{userID}, event)
+// BroadcastToTenant sends an event to every connected client of tenantID
+func (h *Hub) BroadcastToTenant(tenantID string, event *types.Event) error {
+	return h.enqueue(&BroadcastMessage{
+		All:    true,
+		Tenant: tenantID,
+		Event:  event,
+	})
 }
 
-// broadcastToUsers is the internal method that actually sends messages to users
-func (h *Hub) broadcastToUsers(userIDs []string, event *types.Event) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+// BroadcastToTopic sends an event to the clients subscribed to topic
+func (h *Hub) BroadcastToTopic(topic string, event *types.Event) error {
+	return h.enqueue(&BroadcastMessage{
+		Topic: topic,
+		Event: event,
+	})
+}
 
-	for _, userID := range userIDs {
-		if client, ok := h.clients[userID]; ok {
-			err := client.SendEvent(event)
-			if err != nil {
-				slog.Error("Failed to send event to client",
-					slog.String("user_id", userID),
-					slog.String("error", err.Error()))
-				// Remove the client if sending fails
-				go func(c *Client) {
-					h.unregister <- c
-				}(client)
-			}
+// shardBroadcast is the part of a broadcast one shard delivers
+type shardBroadcast struct {
+	shard   *shard
+	message *BroadcastMessage
+}
+
+// enqueue hands a broadcast to the shards holding its recipients: every
+// shard for topics and broadcasts to all, and for users only their shards,
+// each with its own users. It is all or nothing: a place is reserved in the
+// queue of every shard first, and if any is full the broadcast is dropped
+// whole and ErrBroadcastQueueFull returned, so a retry never reaches anyone
+// twice.
+func (h *Hub) enqueue(message *BroadcastMessage) error {
+	var parts []shardBroadcast
+	if message.All || message.Topic != "" {
+		for _, s := range h.shards {
+			parts = append(parts, shardBroadcast{s, message})
+		}
+	} else {
+		for s, userIDs := range h.usersByShard(message.UserIDs) {
+			parts = append(parts, shardBroadcast{s, &BroadcastMessage{UserIDs: userIDs, Event: message.Event}})
 		}
 	}
+
+	for i, part := range parts {
+		if part.shard.reserve() {
+			continue
+		}
+		for _, reserved := range parts[:i] {
+			reserved.shard.unreserve()
+		}
+
+		h.droppedBroadcasts.Add(1)
+		slog.Warn("Broadcast queue is full, dropping message",
+			slog.String("event_type", string(message.Event.Type)),
+			slog.Int("recipients", len(message.UserIDs)),
+			slog.Bool("all", message.All),
+			slog.String("topic", message.Topic))
+		return ErrBroadcastQueueFull
+	}
+
+	for _, part := range parts {
+		part.shard.broadcast <- part.message
+	}
+	if message.Topic == "" {
+		h.broadcasts.Add(1)
+	}
+	return nil
+}
+
+// usersByShard groups userIDs by the shard holding their clients
+func (h *Hub) usersByShard(userIDs []string) map[*shard][]string {
+	byShard := make(map[*shard][]string)
+	for _, userID := range userIDs {
+		s := h.shardFor(userID)
+		byShard[s] = append(byShard[s], userID)
+	}
+	return byShard
+}
+
+// BroadcastToUser sends an event to a specific user
+func (h *Hub) BroadcastToUser(userID string, event *types.Event) error {
+	return h.BroadcastToUsers([]string{userID}, event)
 }
 
 // GetConnectedUsers returns a list of currently connected user IDs
 func (h *Hub) GetConnectedUsers() []string {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	users := make([]string, 0, len(h.clients))
-	for userID := range h.clients {
-		users = append(users, userID)
+	var users []string
+	for _, s := range h.shards {
+		s.mu.RLock()
+		for userID := range s.clients {
+			users = append(users, userID)
+		}
+		s.mu.RUnlock()
 	}
 	return users
 }
 
 // IsUserConnected checks if a user is currently connected
 func (h *Hub) IsUserConnected(userID string) bool {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	s := h.shardFor(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	_, exists := h.clients[userID]
+	_, exists := s.clients[userID]
 	return exists
 }
 
 // GetClientCount returns the number of connected clients
 func (h *Hub) GetClientCount() int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	count := 0
+	for _, s := range h.shards {
+		s.mu.RLock()
+		for _, userClients := range s.clients {
+			count += len(userClients)
+		}
+		s.mu.RUnlock()
+	}
+	return count
+}
+
+// GetTopicSubscriberCount returns the number of clients subscribed to topic
+func (h *Hub) GetTopicSubscriberCount(topic string) int {
+	count := 0
+	for _, s := range h.shards {
+		s.mu.RLock()
+		count += len(s.topics[topic])
+		s.mu.RUnlock()
+	}
+	return count
+}
+
+// TopicSubscribers returns the IDs of the users with a client subscribed to
+// topic, each once
+func (h *Hub) TopicSubscribers(topic string) []string {
+	var userIDs []string
+	for _, s := range h.shards {
+		s.mu.RLock()
+		for client := range s.topics[topic] {
+			if !slices.Contains(userIDs, client.userID) {
+				userIDs = append(userIDs, client.userID)
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return userIDs
+}
 
-	return len(h.clients)
+// Unsubscribe takes the user's clients off topic. They stay connected and
+// keep getting the user's own events.
+func (h *Hub) Unsubscribe(topic, userID string) {
+	s := h.shardFor(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscribers := s.topics[topic]
+	for client := range s.clients[userID] {
+		delete(subscribers, client)
+	}
+	if len(subscribers) == 0 {
+		delete(s.topics, topic)
+	}
+}
+
+// GetUserCount returns the number of users with at least one connection
+func (h *Hub) GetUserCount() int {
+	count := 0
+	for _, s := range h.shards {
+		s.mu.RLock()
+		count += len(s.clients)
+		s.mu.RUnlock()
+	}
+	return count
+}
+
+// Stats returns a snapshot of the hub's connection and delivery counters,
+// summed over its shards
+func (h *Hub) Stats() HubStats {
+	stats := HubStats{
+		Broadcasts:        h.broadcasts.Load(),
+		DroppedBroadcasts: h.droppedBroadcasts.Load(),
+	}
+	for _, s := range h.shards {
+		s.addStats(&stats)
+	}
+	return stats
+}
+
+// GetPresence returns whether a user is connected and when they were last seen
+func (h *Hub) GetPresence(userID string) Presence {
+	s := h.shardFor(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	presence := Presence{UserID: userID}
+	if userClients, ok := s.clients[userID]; ok {
+		var lastSeen time.Time
+		for client := range userClients {
+			if seen := client.LastSeen(); seen.After(lastSeen) {
+				lastSeen = seen
+			}
+		}
+		presence.Online = true
+		presence.LastSeen = types.FormatTime(lastSeen)
+	} else if seen, ok := s.lastSeen[userID]; ok {
+		presence.LastSeen = types.FormatTime(seen)
+	}
+	return presence
 }
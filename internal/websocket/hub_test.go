@@ -0,0 +1,415 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+func newTestClient(userID string, hub *Hub) *Client {
+	client := &Client{
+		send:     make(chan []byte, clientQueueSize),
+		userID:   userID,
+		encoding: EncodingJSON,
+		hub:      hub,
+	}
+	client.touch()
+	return client
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// reapAll runs the reaper of every shard as its loop would at now
+func reapAll(hub *Hub, now time.Time) {
+	for _, s := range hub.shards {
+		s.reapStaleClients(now)
+	}
+}
+
+func TestHub_DisconnectsSlowConsumer(t *testing.T) {
+	hub := NewHub().WithSlowConsumerTimeout(50 * time.Millisecond)
+	go hub.Run()
+
+	client := newTestClient("42", hub)
+	hub.RegisterClient(client)
+	waitFor(t, func() bool { return hub.IsUserConnected("42") })
+
+	// Nobody drains the queue, so one event past its capacity is dropped
+	event := &types.Event{Type: types.EventStoryViewed}
+	for i := 0; i <= clientQueueSize; i++ {
+		hub.BroadcastToUser("42", event)
+	}
+
+	waitFor(t, func() bool { return hub.Stats().DroppedEvents == 1 })
+	stats := hub.Stats()
+	if !hub.IsUserConnected("42") || stats.SlowConsumerDisconnects != 0 {
+		t.Fatalf("Expected a briefly full queue to be tolerated, got %+v", stats)
+	}
+	if stats.MaxQueueDepth != clientQueueSize || stats.FullQueues != 1 {
+		t.Fatalf("Expected 1 full queue of %d, got %+v", clientQueueSize, stats)
+	}
+
+	// Once the queue has stayed full past the timeout, the next event
+	// disconnects the client
+	time.Sleep(60 * time.Millisecond)
+	hub.BroadcastToUser("42", event)
+
+	waitFor(t, func() bool { return !hub.IsUserConnected("42") })
+
+	stats = hub.Stats()
+	if stats.SlowConsumerDisconnects != 1 {
+		t.Fatalf("Expected 1 slow consumer disconnect, got %d", stats.SlowConsumerDisconnects)
+	}
+	if stats.DeliveredEvents != clientQueueSize || stats.DroppedEvents != 2 {
+		t.Fatalf("Expected %d delivered and 2 dropped, got %+v", clientQueueSize, stats)
+	}
+	if client.closeCode != CloseSlowConsumer {
+		t.Fatalf("Expected close code %d, got %d", CloseSlowConsumer, client.closeCode)
+	}
+}
+
+func TestHub_ReapSlowConsumer(t *testing.T) {
+	hub := NewHub()
+
+	slow := newTestClient("1", hub)
+	drained := newTestClient("2", hub)
+	for _, c := range []*Client{slow, drained} {
+		hub.shardFor(c.userID).addClient(c)
+		for i := 0; i <= clientQueueSize; i++ {
+			c.queue([]byte("{}"))
+		}
+	}
+	// The second client's write pump has caught up since
+	<-drained.send
+
+	reapAll(hub, time.Now().Add(defaultSlowConsumerTimeout))
+
+	if hub.IsUserConnected("1") || !hub.IsUserConnected("2") {
+		t.Fatalf("Expected only the stalled client to be disconnected, got %v", hub.GetConnectedUsers())
+	}
+	if stats := hub.Stats(); stats.SlowConsumerDisconnects != 1 || stats.ReapedClients != 0 {
+		t.Fatalf("Expected 1 slow consumer disconnect and no reaped clients, got %+v", stats)
+	}
+}
+
+func TestHub_BroadcastToAll(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	jsonClient := newTestClient("1", hub)
+	msgpackClient := newTestClient("2", hub)
+	msgpackClient.encoding = EncodingMsgPack
+	hub.RegisterClient(jsonClient)
+	hub.RegisterClient(msgpackClient)
+	waitFor(t, func() bool { return hub.GetClientCount() == 2 })
+
+	hub.BroadcastToAll(types.NewEvent(types.EventAnnouncement, &types.AnnouncementEvent{Message: "hello", Level: types.AnnouncementInfo}))
+
+	waitFor(t, func() bool { return len(jsonClient.send) == 1 && len(msgpackClient.send) == 1 })
+	if data := <-jsonClient.send; data[0] != '{' {
+		t.Errorf("Expected a JSON frame, got %q", data)
+	}
+	if data := <-msgpackClient.send; data[0] == '{' {
+		t.Errorf("Expected a MessagePack frame, got %q", data)
+	}
+	if delivered := hub.Stats().DeliveredEvents; delivered != 2 {
+		t.Errorf("Expected 2 delivered events, got %d", delivered)
+	}
+}
+
+func TestHub_BroadcastToTenant(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	acme := newTestClient("1", hub).WithTenant("acme")
+	other := newTestClient("2", hub).WithTenant("other")
+	hub.RegisterClient(acme)
+	hub.RegisterClient(other)
+	waitFor(t, func() bool { return hub.GetClientCount() == 2 })
+
+	hub.BroadcastToTenant("acme", types.NewEvent(types.EventAnnouncement, &types.AnnouncementEvent{Message: "hello", Level: types.AnnouncementInfo}))
+
+	waitFor(t, func() bool { return len(acme.send) == 1 })
+	if delivered := hub.Stats().DeliveredEvents; delivered != 1 {
+		t.Errorf("Expected 1 delivered event, got %d", delivered)
+	}
+	if len(other.send) != 0 {
+		t.Errorf("Expected no event for another tenant, got %d", len(other.send))
+	}
+}
+
+func TestHub_BroadcastToTopic(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	dashboard := newTestClient("1", hub).WithTopic(TopicOps)
+	phone := newTestClient("1", hub)
+	other := newTestClient("2", hub)
+	for _, c := range []*Client{dashboard, phone, other} {
+		hub.RegisterClient(c)
+	}
+	if n := hub.GetTopicSubscriberCount(TopicOps); n != 1 {
+		t.Fatalf("Expected 1 ops subscriber, got %d", n)
+	}
+
+	hub.BroadcastToTopic(TopicOps, &types.Event{Type: types.EventOpsMetrics})
+	// A subscribed connection still gets its user's events
+	hub.BroadcastToUser("1", &types.Event{Type: types.EventStoryViewed})
+
+	waitFor(t, func() bool { return len(dashboard.send) == 2 && len(phone.send) == 1 })
+	if len(other.send) != 0 {
+		t.Errorf("Expected no events for an unsubscribed user, got %d", len(other.send))
+	}
+	if broadcasts := hub.Stats().Broadcasts; broadcasts != 1 {
+		t.Errorf("Expected only the user broadcast counted, got %d", broadcasts)
+	}
+
+	hub.UnregisterClient(dashboard)
+	waitFor(t, func() bool { return hub.GetTopicSubscriberCount(TopicOps) == 0 })
+}
+
+func TestHub_UnregisterReplacedClient(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	old := newTestClient("42", hub)
+	hub.RegisterClient(old)
+	replacement := newTestClient("42", hub)
+	hub.RegisterClient(replacement)
+
+	// The old connection's read pump unregisters it after being replaced
+	hub.UnregisterClient(old)
+	hub.BroadcastToUser("42", &types.Event{Type: types.EventStoryViewed})
+
+	waitFor(t, func() bool { return len(replacement.send) == 1 })
+	if !hub.IsUserConnected("42") {
+		t.Fatal("Expected replacement connection to stay registered")
+	}
+}
+
+func TestHub_ConnectionLimits(t *testing.T) {
+	hub := NewHub().WithConnectionLimits(2, 3)
+	go hub.Run()
+
+	connect := func(userID, ip string) (*Client, error) {
+		client := newTestClient(userID, hub)
+		client.remoteIP = ip
+		return client, hub.RegisterClient(client)
+	}
+
+	phone, err := connect("1", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Expected the first connection to register, got %v", err)
+	}
+	laptop, err := connect("1", "192.0.2.2")
+	if err != nil {
+		t.Fatalf("Expected a second connection to register, got %v", err)
+	}
+	if _, err := connect("1", "192.0.2.3"); !errors.Is(err, ErrTooManyUserConnections) {
+		t.Fatalf("Expected ErrTooManyUserConnections past the user cap, got %v", err)
+	}
+
+	// Every connection of a user gets their events
+	hub.BroadcastToUser("1", &types.Event{Type: types.EventStoryViewed})
+	waitFor(t, func() bool { return len(phone.send) == 1 && len(laptop.send) == 1 })
+
+	// Other users behind the same IP count towards its cap
+	neighbour, err := connect("2", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Expected another user on the IP to register, got %v", err)
+	}
+	if _, err := connect("3", "192.0.2.1"); err != nil {
+		t.Fatalf("Expected a third connection on the IP to register, got %v", err)
+	}
+	if _, err := connect("4", "192.0.2.1"); !errors.Is(err, ErrTooManyIPConnections) {
+		t.Fatalf("Expected ErrTooManyIPConnections past the IP cap, got %v", err)
+	}
+
+	// Disconnecting frees a slot
+	hub.UnregisterClient(neighbour)
+	if _, err := connect("4", "192.0.2.1"); err != nil {
+		t.Fatalf("Expected a connection to register once a slot freed up, got %v", err)
+	}
+
+	stats := hub.Stats()
+	if stats.ConnectedClients != 4 || stats.ConnectedUsers != 3 || stats.RejectedConnections != 2 {
+		t.Fatalf("Expected 4 clients of 3 users and 2 rejections, got %+v", stats)
+	}
+}
+
+func TestHub_Shards(t *testing.T) {
+	hub := NewHub().WithConnectionLimits(0, 40)
+	go hub.Run()
+
+	// Enough users to land on several shards, all behind one IP
+	var userIDs []string
+	clients := make(map[string]*Client)
+	shards := make(map[*shard]bool)
+	for i := range 40 {
+		userID := strconv.Itoa(i)
+		client := newTestClient(userID, hub)
+		client.remoteIP = "192.0.2.1"
+		if i%2 == 0 {
+			client.WithTopic(TopicOps)
+		}
+		if err := hub.RegisterClient(client); err != nil {
+			t.Fatalf("RegisterClient failed: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+		clients[userID] = client
+		shards[hub.shardFor(userID)] = true
+	}
+	if len(shards) < 2 {
+		t.Fatalf("Expected users spread over several shards, got %d", len(shards))
+	}
+
+	// The IP cap holds across shards
+	extra := newTestClient("40", hub)
+	extra.remoteIP = "192.0.2.1"
+	if err := hub.RegisterClient(extra); !errors.Is(err, ErrTooManyIPConnections) {
+		t.Fatalf("Expected ErrTooManyIPConnections past the IP cap, got %v", err)
+	}
+
+	// One broadcast reaches users on every shard, and counts once
+	hub.BroadcastToUsers(userIDs, &types.Event{Type: types.EventStoryViewed})
+	hub.BroadcastToTopic(TopicOps, &types.Event{Type: types.EventOpsMetrics})
+	waitFor(t, func() bool { return hub.Stats().DeliveredEvents == 60 })
+	for userID, client := range clients {
+		// Subscribers also get the topic's event
+		want := 1
+		if client.topic != "" {
+			want = 2
+		}
+		if len(client.send) != want {
+			t.Errorf("Expected %d events for user %s, got %d", want, userID, len(client.send))
+		}
+	}
+
+	stats := hub.Stats()
+	if stats.ConnectedClients != 40 || stats.ConnectedUsers != 40 || stats.Broadcasts != 1 || stats.RejectedConnections != 1 {
+		t.Errorf("Expected 40 clients of 40 users, 1 broadcast and 1 rejection, got %+v", stats)
+	}
+	if n := hub.GetTopicSubscriberCount(TopicOps); n != 20 {
+		t.Errorf("Expected 20 ops subscribers, got %d", n)
+	}
+	if users := hub.GetConnectedUsers(); len(users) != 40 {
+		t.Errorf("Expected 40 connected users, got %d", len(users))
+	}
+}
+
+func TestHub_BroadcastAllOrNothing(t *testing.T) {
+	hub := NewHub()
+
+	// Two users on different shards
+	full := newTestClient("1", hub)
+	other := newTestClient("2", hub)
+	for i := 2; hub.shardFor(other.userID) == hub.shardFor(full.userID); i++ {
+		other = newTestClient(strconv.Itoa(i), hub)
+	}
+	for _, c := range []*Client{full, other} {
+		hub.shardFor(c.userID).addClient(c)
+	}
+
+	// Nothing drains the queues until Run, so one shard's fills up
+	event := &types.Event{Type: types.EventStoryViewed}
+	for range broadcastQueueSize {
+		if err := hub.BroadcastToUser(full.userID, event); err != nil {
+			t.Fatalf("Expected room in the queue, got %v", err)
+		}
+	}
+
+	// A broadcast that does not fit on every shard is queued on none
+	recipients := []string{full.userID, other.userID}
+	if err := hub.BroadcastToUsers(recipients, event); !errors.Is(err, ErrBroadcastQueueFull) {
+		t.Fatalf("Expected ErrBroadcastQueueFull, got %v", err)
+	}
+	if queued := len(hub.shardFor(other.userID).broadcast); queued != 0 {
+		t.Fatalf("Expected nothing queued for the other shard, got %d", queued)
+	}
+
+	// Once the queue drains, the retry reaches each user once
+	go hub.Run()
+	waitFor(t, func() bool { return hub.Stats().QueuedBroadcasts == 0 })
+	if err := hub.BroadcastToUsers(recipients, event); err != nil {
+		t.Fatalf("Expected the retry to be queued, got %v", err)
+	}
+	waitFor(t, func() bool { return hub.Stats().QueuedBroadcasts == 0 && len(other.send) > 0 })
+	if len(other.send) != 1 {
+		t.Fatalf("Expected 1 event for user %s after the retry, got %d", other.userID, len(other.send))
+	}
+	if stats := hub.Stats(); stats.DroppedBroadcasts != 1 {
+		t.Fatalf("Expected 1 dropped broadcast, got %+v", stats)
+	}
+}
+
+func TestHub_ReapStaleClients(t *testing.T) {
+	hub := NewHub()
+
+	alive := newTestClient("1", hub)
+	stale := newTestClient("2", hub)
+	stale.lastSeen.Store(time.Now().Add(-2 * staleAfter).UnixNano())
+	dead := newTestClient("3", hub)
+	dead.closed.Store(true)
+
+	for _, c := range []*Client{alive, stale, dead} {
+		hub.shardFor(c.userID).addClient(c)
+	}
+
+	reapAll(hub, time.Now())
+
+	if !hub.IsUserConnected("1") || hub.IsUserConnected("2") || hub.IsUserConnected("3") {
+		t.Fatalf("Expected only user 1 to remain connected, got %v", hub.GetConnectedUsers())
+	}
+	if reaped := hub.Stats().ReapedClients; reaped != 2 {
+		t.Fatalf("Expected 2 reaped clients, got %d", reaped)
+	}
+
+	presence := hub.GetPresence("2")
+	if presence.Online || presence.LastSeen == "" {
+		t.Fatalf("Expected reaped user to be offline with a last-seen time, got %+v", presence)
+	}
+}
+
+func TestHub_Shutdown(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	client := newTestClient("42", hub)
+	hub.RegisterClient(client)
+	waitFor(t, func() bool { return hub.IsUserConnected("42") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if hub.IsUserConnected("42") {
+		t.Fatal("Expected clients to be disconnected on shutdown")
+	}
+	if _, ok := <-client.send; ok {
+		t.Fatal("Expected client send channel to be closed")
+	}
+
+	// Pumps exiting after shutdown must not block, and late clients are closed
+	hub.UnregisterClient(client)
+	late := newTestClient("7", hub)
+	hub.RegisterClient(late)
+	if _, ok := <-late.send; ok {
+		t.Fatal("Expected late client to be closed")
+	}
+}
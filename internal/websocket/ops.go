@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// TopicOps is the topic admins subscribe to for live service metrics
+const TopicOps = "ops"
+
+// OpsMetrics is the payload of an ops.metrics event. Rates and counts cover
+// the interval since the previous snapshot; Hub holds the hub's totals.
+type OpsMetrics struct {
+	Hub                 HubStats `json:"hub"`
+	IntervalSeconds     float64  `json:"interval_seconds"`
+	BroadcastRate       float64  `json:"broadcast_rate"`        // broadcasts to users per second
+	DeliveryRate        float64  `json:"delivery_rate"`         // events written to send queues per second
+	Dropped             uint64   `json:"dropped"`               // broadcasts and events dropped
+	FeedRequests        uint64   `json:"feed_requests"`         // feed requests served from the cache or the database
+	FeedCacheHitRate    float64  `json:"feed_cache_hit_rate"`   // share of FeedRequests served from the cache, 0 to 1
+	RateLimitRejections uint64   `json:"rate_limit_rejections"` // requests and connection attempts rate limited
+}
+
+// OpsReporter streams an OpsMetrics snapshot to the subscribers of TopicOps
+// at a fixed interval
+type OpsReporter struct {
+	hub      *Hub
+	interval time.Duration
+	users    storage.UserStore
+
+	// When the previous snapshot was taken and the counters as of then
+	lastAt     time.Time
+	lastStats  HubStats
+	lastTotals metrics.Totals
+}
+
+// NewOpsReporter creates a reporter sending a snapshot every interval
+func NewOpsReporter(hub *Hub, interval time.Duration) *OpsReporter {
+	return &OpsReporter{
+		hub:        hub,
+		interval:   interval,
+		lastAt:     time.Now(),
+		lastStats:  hub.Stats(),
+		lastTotals: metrics.CurrentTotals(),
+	}
+}
+
+// WithAdmins makes the reporter check before each snapshot that its
+// subscribers are still admins, taking those who are not off the topic
+func (r *OpsReporter) WithAdmins(users storage.UserStore) *OpsReporter {
+	r.users = users
+	return r
+}
+
+// Start reports until ctx is done. Snapshots are only sent while someone is
+// subscribed, but every interval is measured so the first one sent is not
+// skewed.
+func (r *OpsReporter) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			snapshot := r.snapshot(now)
+			r.dropRevokedAdmins()
+			if r.hub.GetTopicSubscriberCount(TopicOps) == 0 {
+				continue
+			}
+			if err := r.hub.BroadcastToTopic(TopicOps, types.NewEvent(types.EventOpsMetrics, snapshot)); err != nil {
+				slog.Warn("Failed to send ops metrics", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// dropRevokedAdmins unsubscribes the users who are no longer admins. Users
+// whose flag cannot be read stay subscribed until the next check.
+func (r *OpsReporter) dropRevokedAdmins() {
+	if r.users == nil {
+		return
+	}
+	for _, userID := range r.hub.TopicSubscribers(TopicOps) {
+		user, err := r.users.GetUserByID(userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("Failed to check ops subscriber", slog.String("error", err.Error()), slog.String("user_id", userID))
+			continue
+		}
+		if err != nil || !user.IsAdmin {
+			slog.Info("Admin rights revoked, leaving the ops topic", slog.String("user_id", userID))
+			r.hub.Unsubscribe(TopicOps, userID)
+		}
+	}
+}
+
+// snapshot measures the interval ending at now and starts the next one
+func (r *OpsReporter) snapshot(now time.Time) OpsMetrics {
+	stats := r.hub.Stats()
+	totals := metrics.CurrentTotals()
+	seconds := now.Sub(r.lastAt).Seconds()
+
+	snapshot := OpsMetrics{
+		Hub:                 stats,
+		IntervalSeconds:     seconds,
+		Dropped:             stats.DroppedBroadcasts + stats.DroppedEvents - r.lastStats.DroppedBroadcasts - r.lastStats.DroppedEvents,
+		FeedRequests:        totals.FeedHits + totals.FeedMisses - r.lastTotals.FeedHits - r.lastTotals.FeedMisses,
+		RateLimitRejections: totals.RateLimitRejections - r.lastTotals.RateLimitRejections,
+	}
+	if seconds > 0 {
+		snapshot.BroadcastRate = float64(stats.Broadcasts-r.lastStats.Broadcasts) / seconds
+		snapshot.DeliveryRate = float64(stats.DeliveredEvents-r.lastStats.DeliveredEvents) / seconds
+	}
+	if snapshot.FeedRequests > 0 {
+		snapshot.FeedCacheHitRate = float64(totals.FeedHits-r.lastTotals.FeedHits) / float64(snapshot.FeedRequests)
+	}
+
+	r.lastAt, r.lastStats, r.lastTotals = now, stats, totals
+	return snapshot
+}
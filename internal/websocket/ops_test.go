@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/storage/mocks"
+	"github.com/princekumarofficial/stories-service/internal/types"
+	"github.com/princekumarofficial/stories-service/internal/types/users"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOpsReporter_Snapshot(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	client := newTestClient("1", hub)
+	hub.RegisterClient(client)
+
+	reporter := NewOpsReporter(hub, time.Second)
+	start := reporter.lastAt
+
+	for range 4 {
+		hub.BroadcastToUser("1", &types.Event{Type: types.EventStoryViewed})
+	}
+	waitFor(t, func() bool { return len(client.send) == 4 })
+	feedStart := time.Now()
+	metrics.ObserveFeed("ops_test", metrics.ResultHit, feedStart, 1)
+	metrics.ObserveFeed("ops_test", metrics.ResultHit, feedStart, 1)
+	metrics.ObserveFeed("ops_test", metrics.ResultHit, feedStart, 1)
+	metrics.ObserveFeed("ops_test", metrics.ResultMiss, feedStart, 1)
+	metrics.RateLimited("ops_test")
+
+	snapshot := reporter.snapshot(start.Add(2 * time.Second))
+	if snapshot.IntervalSeconds != 2 || snapshot.BroadcastRate != 2 || snapshot.DeliveryRate != 2 {
+		t.Errorf("Expected 2 broadcasts and deliveries a second over 2s, got %+v", snapshot)
+	}
+	if snapshot.FeedRequests != 4 || snapshot.FeedCacheHitRate != 0.75 || snapshot.RateLimitRejections != 1 {
+		t.Errorf("Expected 4 feed requests at 75%% hits and 1 rejection, got %+v", snapshot)
+	}
+	if snapshot.Hub.ConnectedClients != 1 {
+		t.Errorf("Expected the hub's totals, got %+v", snapshot.Hub)
+	}
+
+	// The next interval starts from zero
+	snapshot = reporter.snapshot(start.Add(3 * time.Second))
+	if snapshot.BroadcastRate != 0 || snapshot.FeedRequests != 0 || snapshot.FeedCacheHitRate != 0 || snapshot.RateLimitRejections != 0 {
+		t.Errorf("Expected a quiet second interval, got %+v", snapshot)
+	}
+}
+
+func TestOpsReporter_SendsToSubscribers(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewOpsReporter(hub, 10*time.Millisecond).Start(ctx)
+
+	user := newTestClient("1", hub)
+	dashboard := newTestClient("2", hub).WithTopic(TopicOps)
+	hub.RegisterClient(user)
+	hub.RegisterClient(dashboard)
+
+	waitFor(t, func() bool { return len(dashboard.send) > 0 })
+	var event types.Event
+	if err := json.Unmarshal(<-dashboard.send, &event); err != nil || event.Type != types.EventOpsMetrics {
+		t.Errorf("Expected an ops.metrics event, got %+v (%v)", event, err)
+	}
+	if len(user.send) != 0 {
+		t.Errorf("Expected no ops events for a user off the topic, got %d", len(user.send))
+	}
+}
+
+func TestOpsReporter_DropsRevokedAdmins(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	store := mocks.NewMockStorage(gomock.NewController(t))
+	store.EXPECT().GetUserByID("1").Return(users.User{ID: "1", IsAdmin: true}, nil)
+	store.EXPECT().GetUserByID("2").Return(users.User{ID: "2"}, nil)
+	store.EXPECT().GetUserByID("3").Return(users.User{}, errors.New("database down"))
+	reporter := NewOpsReporter(hub, time.Second).WithAdmins(store)
+
+	admin := newTestClient("1", hub).WithTopic(TopicOps)
+	revoked := newTestClient("2", hub).WithTopic(TopicOps)
+	unchecked := newTestClient("3", hub).WithTopic(TopicOps)
+	for _, client := range []*Client{admin, revoked, unchecked} {
+		hub.RegisterClient(client)
+	}
+
+	reporter.dropRevokedAdmins()
+	if count := hub.GetTopicSubscriberCount(TopicOps); count != 2 {
+		t.Errorf("Expected the admin and the unchecked user left on the topic, got %d subscribers", count)
+	}
+	if count := hub.GetClientCount(); count != 3 {
+		t.Errorf("Expected every client to stay connected, got %d", count)
+	}
+}
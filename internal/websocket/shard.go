@@ -0,0 +1,376 @@
+package websocket
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/princekumarofficial/stories-service/internal/metrics"
+	"github.com/princekumarofficial/stories-service/internal/types"
+)
+
+// shard holds the clients of the users hashed to it and runs their
+// registrations, broadcasts and reaping on its own goroutine
+type shard struct {
+	hub *Hub
+
+	// Registered clients by user ID; a user may have several connections
+	clients map[string]map[*Client]struct{}
+
+	// Registered clients subscribed to each topic, also listed in clients
+	topics map[string]map[*Client]struct{}
+
+	// Last-seen timestamps of disconnected users
+	lastSeen map[string]time.Time
+
+	// Protects clients, topics and lastSeen
+	mu sync.RWMutex
+
+	// Register and unregister requests from the shard's clients
+	register   chan registration
+	unregister chan registration
+
+	// Broadcasts reaching the shard's clients
+	broadcast chan *BroadcastMessage
+
+	// Places in broadcast claimed by reserve, given back as the loop takes
+	// broadcasts off it. There are never more than it holds, so a broadcast
+	// sent after reserving never blocks.
+	reserved atomic.Int64
+
+	// Delivery counters, updated atomically and summed by Hub.Stats
+	droppedEvents           atomic.Uint64
+	slowConsumerDisconnects atomic.Uint64
+	deliveredEvents         atomic.Uint64
+	reapedClients           atomic.Uint64
+	rejectedConnections     atomic.Uint64
+}
+
+func newShard(hub *Hub) *shard {
+	return &shard{
+		hub:        hub,
+		clients:    make(map[string]map[*Client]struct{}),
+		topics:     make(map[string]map[*Client]struct{}),
+		lastSeen:   make(map[string]time.Time),
+		register:   make(chan registration),
+		unregister: make(chan registration),
+		broadcast:  make(chan *BroadcastMessage, broadcastQueueSize),
+	}
+}
+
+// run is the shard's main loop. It returns once the hub is shut down and the
+// shard's clients are disconnected.
+func (s *shard) run() {
+	reaper := time.NewTicker(reapInterval)
+	defer reaper.Stop()
+
+	for {
+		select {
+		case reg := <-s.register:
+			s.mu.Lock()
+			err := s.addClient(reg.client)
+			s.mu.Unlock()
+			if err != nil {
+				s.rejectedConnections.Add(1)
+				slog.Warn("Rejected WebSocket connection",
+					slog.String("user_id", reg.client.userID),
+					slog.String("remote_ip", reg.client.remoteIP),
+					slog.String("error", err.Error()))
+			} else {
+				slog.Info("WebSocket client connected", slog.String("user_id", reg.client.userID))
+			}
+			reg.result <- err
+
+		case reg := <-s.unregister:
+			s.mu.Lock()
+			// Only remove the client if the shard has not already removed it
+			if s.hasClient(reg.client) {
+				s.removeClient(reg.client)
+				slog.Info("WebSocket client disconnected", slog.String("user_id", reg.client.userID))
+			}
+			s.mu.Unlock()
+			reg.result <- nil
+
+		case message := <-s.broadcast:
+			s.reserved.Add(-1)
+			switch {
+			case message.Topic != "":
+				s.broadcastToTopic(message.Topic, message.Event)
+			case message.All:
+				s.broadcastToAll(message.Tenant, message.Event)
+			default:
+				s.broadcastToUsers(message.UserIDs, message.Event)
+			}
+
+		case now := <-reaper.C:
+			s.reapStaleClients(now)
+
+		case <-s.hub.quit:
+			s.disconnectAll()
+			return
+		}
+	}
+}
+
+// reserve claims a place in the shard's broadcast queue, reporting false if
+// it is full. A reserved place is either filled by sending a broadcast or
+// given back with unreserve.
+func (s *shard) reserve() bool {
+	for {
+		n := s.reserved.Load()
+		if n >= broadcastQueueSize {
+			return false
+		}
+		if s.reserved.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// unreserve gives back a place claimed by reserve without sending anything
+func (s *shard) unreserve() {
+	s.reserved.Add(-1)
+}
+
+// disconnectAll removes every client; closing their send channels makes the
+// write pumps send a close frame
+func (s *shard) disconnectAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, client := range s.allClients() {
+		s.removeClient(client)
+	}
+}
+
+// broadcastToUsers sends an event to the given users that are connected
+func (s *shard) broadcastToUsers(userIDs []string, event *types.Event) {
+	s.mu.RLock()
+	recipients := make([]*Client, 0, len(userIDs))
+	for _, userID := range userIDs {
+		for client := range s.clients[userID] {
+			recipients = append(recipients, client)
+		}
+	}
+	s.mu.RUnlock()
+
+	s.deliver(recipients, event)
+}
+
+// broadcastToAll sends an event to every client of the shard, or only to
+// those of tenantID unless it is empty
+func (s *shard) broadcastToAll(tenantID string, event *types.Event) {
+	s.mu.RLock()
+	recipients := s.allClients()
+	s.mu.RUnlock()
+
+	if tenantID != "" {
+		recipients = slices.DeleteFunc(recipients, func(client *Client) bool {
+			return client.tenant != tenantID
+		})
+	}
+
+	s.deliver(recipients, event)
+}
+
+// broadcastToTopic sends an event to the shard's clients subscribed to topic
+func (s *shard) broadcastToTopic(topic string, event *types.Event) {
+	s.mu.RLock()
+	recipients := make([]*Client, 0, len(s.topics[topic]))
+	for client := range s.topics[topic] {
+		recipients = append(recipients, client)
+	}
+	s.mu.RUnlock()
+
+	s.deliver(recipients, event)
+}
+
+// deliver is the internal method that actually sends messages to clients,
+// encoding the event once per wire format. It runs on the shard goroutine,
+// which is the only place its clients' send channels are closed, so
+// recipients stay open while it runs.
+func (s *shard) deliver(recipients []*Client, event *types.Event) {
+	var slow []*Client
+	now := time.Now()
+	encoded := make(map[Encoding][]byte)
+
+	for _, client := range recipients {
+		data, ok := encoded[client.encoding]
+		if !ok {
+			var err error
+			data, err = client.encoding.Marshal(event)
+			if err != nil {
+				s.droppedEvents.Add(1)
+				slog.Error("Failed to encode event",
+					slog.String("event_type", string(event.Type)),
+					slog.String("encoding", string(client.encoding)),
+					slog.String("error", err.Error()))
+				continue
+			}
+			encoded[client.encoding] = data
+		}
+
+		if err := client.queue(data); err != nil {
+			// Drop the event; a client that has been this far behind for too
+			// long is disconnected rather than left to hold up everyone else
+			s.droppedEvents.Add(1)
+			metrics.WebSocketEventDropped()
+			if client.stalled(now, s.hub.slowConsumerTimeout) {
+				slow = append(slow, client)
+			}
+			continue
+		}
+		s.deliveredEvents.Add(1)
+	}
+
+	if len(slow) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for _, client := range slow {
+		s.disconnectSlowConsumer(client)
+	}
+	s.mu.Unlock()
+}
+
+// disconnectSlowConsumer removes a client whose send queue stayed full,
+// closing its connection with CloseSlowConsumer; clients are expected to
+// reconnect and refetch state. Callers must hold s.mu.
+func (s *shard) disconnectSlowConsumer(client *Client) {
+	if !s.hasClient(client) {
+		return
+	}
+
+	client.closeCode = CloseSlowConsumer
+	client.closeReason = "slow consumer"
+	s.removeClient(client)
+	s.slowConsumerDisconnects.Add(1)
+	metrics.WebSocketSlowConsumer()
+	slog.Warn("Disconnected slow WebSocket consumer",
+		slog.String("user_id", client.userID),
+		slog.Int("queue_depth", client.QueueDepth()),
+		slog.Duration("write_latency", client.WriteLatency()))
+}
+
+// addClient registers the client unless its user or IP is at the connection
+// cap. Callers must hold s.mu.
+func (s *shard) addClient(client *Client) error {
+	if s.hub.maxPerUser > 0 && len(s.clients[client.userID]) >= s.hub.maxPerUser {
+		return ErrTooManyUserConnections
+	}
+	if err := s.hub.reserveIP(client.remoteIP); err != nil {
+		return err
+	}
+
+	if s.clients[client.userID] == nil {
+		s.clients[client.userID] = make(map[*Client]struct{})
+	}
+	s.clients[client.userID][client] = struct{}{}
+	if client.topic != "" {
+		if s.topics[client.topic] == nil {
+			s.topics[client.topic] = make(map[*Client]struct{})
+		}
+		s.topics[client.topic][client] = struct{}{}
+	}
+	return nil
+}
+
+// hasClient reports whether the client is registered. Callers must hold s.mu.
+func (s *shard) hasClient(client *Client) bool {
+	_, ok := s.clients[client.userID][client]
+	return ok
+}
+
+// allClients returns every registered client. Callers must hold s.mu.
+func (s *shard) allClients() []*Client {
+	clients := make([]*Client, 0, len(s.clients))
+	for _, userClients := range s.clients {
+		for client := range userClients {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// removeClient deletes the client and closes its send channel, remembering
+// when the user was last seen once their last connection is gone. Callers
+// must hold s.mu.
+func (s *shard) removeClient(client *Client) {
+	userClients := s.clients[client.userID]
+	delete(userClients, client)
+	if len(userClients) == 0 {
+		delete(s.clients, client.userID)
+	}
+
+	s.hub.releaseIP(client.remoteIP)
+
+	if subscribers := s.topics[client.topic]; subscribers != nil {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(s.topics, client.topic)
+		}
+	}
+
+	close(client.send)
+	if seen := client.LastSeen(); seen.After(s.lastSeen[client.userID]) {
+		s.lastSeen[client.userID] = seen
+	}
+}
+
+// reapStaleClients removes clients whose pumps have exited or whose peer has
+// stopped answering pings, so a goroutine that died without unregistering
+// cannot leave a ghost connection behind. Slow consumers that have not been
+// sent an event since their queue filled up are disconnected here too. It
+// also prunes old last-seen entries.
+func (s *shard) reapStaleClients(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, client := range s.allClients() {
+		if client.stalled(now, s.hub.slowConsumerTimeout) {
+			s.disconnectSlowConsumer(client)
+			continue
+		}
+		if client.closed.Load() || now.Sub(client.LastSeen()) > staleAfter {
+			s.removeClient(client)
+			s.reapedClients.Add(1)
+			slog.Warn("Reaped stale WebSocket client",
+				slog.String("user_id", client.userID),
+				slog.Time("last_seen", client.LastSeen()))
+		}
+	}
+
+	for userID, seen := range s.lastSeen {
+		if now.Sub(seen) > lastSeenRetention {
+			delete(s.lastSeen, userID)
+		}
+	}
+}
+
+// addStats adds the shard's connections, queues and delivery counters to
+// stats
+func (s *shard) addStats(stats *HubStats) {
+	stats.QueuedBroadcasts += len(s.broadcast)
+	stats.DeliveredEvents += s.deliveredEvents.Load()
+	stats.DroppedEvents += s.droppedEvents.Load()
+	stats.SlowConsumerDisconnects += s.slowConsumerDisconnects.Load()
+	stats.ReapedClients += s.reapedClients.Load()
+	stats.RejectedConnections += s.rejectedConnections.Load()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats.ConnectedUsers += len(s.clients)
+	for _, client := range s.allClients() {
+		stats.ConnectedClients++
+		depth := client.QueueDepth()
+		stats.MaxQueueDepth = max(stats.MaxQueueDepth, depth)
+		if depth == cap(client.send) {
+			stats.FullQueues++
+		}
+		stats.MaxWriteLatencyMs = max(stats.MaxWriteLatencyMs, client.WriteLatency().Milliseconds())
+	}
+}
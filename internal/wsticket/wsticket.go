@@ -0,0 +1,107 @@
+package wsticket
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+)
+
+// ErrInvalidTicket is returned when a ticket is malformed, forged, expired or already used
+var ErrInvalidTicket = errors.New("invalid or expired ticket")
+
+// Ticket is a one-time credential for opening a WebSocket connection
+type Ticket struct {
+	Ticket    string `json:"ticket"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// Issuer issues and redeems single-use WebSocket tickets backed by Redis
+type Issuer struct {
+	redis  *redis.Client
+	keys   cache.Keys
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewIssuer creates a new ticket issuer keeping its tickets under keys.
+// Tickets are signed with secret and can be redeemed once within ttl.
+func NewIssuer(redisClient *redis.Client, keys cache.Keys, secret string, ttl time.Duration) *Issuer {
+	return &Issuer{
+		redis:  redisClient,
+		keys:   keys,
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// holder is who a ticket was issued to, stored under its key
+type holder struct {
+	UserID   string `json:"user_id"`
+	TenantID string `json:"tenant_id"`
+}
+
+// Issue creates a new ticket bound to userID of tenantID
+func (i *Issuer) Issue(ctx context.Context, userID, tenantID string) (Ticket, error) {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return Ticket{}, fmt.Errorf("failed to generate ticket: %w", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(nonce)
+
+	data, err := json.Marshal(holder{UserID: userID, TenantID: tenantID})
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to encode ticket: %w", err)
+	}
+	err = i.redis.Set(ctx, i.ticketKey(id), data, i.ttl).Err()
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to store ticket: %w", err)
+	}
+
+	return Ticket{
+		Ticket:    id + "." + i.sign(id),
+		ExpiresAt: time.Now().Add(i.ttl).Unix(),
+	}, nil
+}
+
+// Redeem validates the ticket and consumes it, returning the user it was
+// issued to and their tenant
+func (i *Issuer) Redeem(ctx context.Context, ticket string) (string, string, error) {
+	id, sig, ok := strings.Cut(ticket, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(i.sign(id))) {
+		return "", "", ErrInvalidTicket
+	}
+
+	// GETDEL makes redemption atomic so a ticket can only be used once
+	data, err := i.redis.GetDel(ctx, i.ticketKey(id)).Result()
+	if err == redis.Nil {
+		return "", "", ErrInvalidTicket
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to redeem ticket: %w", err)
+	}
+
+	var h holder
+	if err := json.Unmarshal([]byte(data), &h); err != nil || h.UserID == "" {
+		return "", "", ErrInvalidTicket
+	}
+	return h.UserID, h.TenantID, nil
+}
+
+func (i *Issuer) sign(id string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (i *Issuer) ticketKey(id string) string {
+	return i.keys.Key(cache.WSTicketKey, id)
+}
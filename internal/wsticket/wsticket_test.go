@@ -0,0 +1,61 @@
+package wsticket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princekumarofficial/stories-service/internal/cache"
+	"github.com/princekumarofficial/stories-service/internal/testutil/redistest"
+)
+
+func setupTestIssuer(t *testing.T) (*Issuer, *miniredis.Miniredis) {
+	redisClient, mr := redistest.New(t)
+
+	return NewIssuer(redisClient, cache.NewKeys(""), "test_secret", 30*time.Second), mr
+}
+
+func TestIssuer_SingleUse(t *testing.T) {
+	issuer, _ := setupTestIssuer(t)
+	ctx := context.Background()
+
+	ticket, err := issuer.Issue(ctx, "42", "acme")
+	if err != nil {
+		t.Fatalf("Failed to issue ticket: %v", err)
+	}
+
+	userID, tenantID, err := issuer.Redeem(ctx, ticket.Ticket)
+	if err != nil {
+		t.Fatalf("Failed to redeem ticket: %v", err)
+	}
+	if userID != "42" || tenantID != "acme" {
+		t.Fatalf("Expected user 42 of acme, got %s of %s", userID, tenantID)
+	}
+
+	if _, _, err := issuer.Redeem(ctx, ticket.Ticket); err != ErrInvalidTicket {
+		t.Fatalf("Expected second redemption to fail, got %v", err)
+	}
+}
+
+func TestIssuer_RejectsForgedAndExpired(t *testing.T) {
+	issuer, mr := setupTestIssuer(t)
+	ctx := context.Background()
+
+	ticket, err := issuer.Issue(ctx, "42", "")
+	if err != nil {
+		t.Fatalf("Failed to issue ticket: %v", err)
+	}
+
+	forged := ticket.Ticket + "x"
+	for _, bad := range []string{"", "no-signature", forged} {
+		if _, _, err := issuer.Redeem(ctx, bad); err != ErrInvalidTicket {
+			t.Errorf("Redeem(%q) expected ErrInvalidTicket, got %v", bad, err)
+		}
+	}
+
+	mr.FastForward(31 * time.Second)
+	if _, _, err := issuer.Redeem(ctx, ticket.Ticket); err != ErrInvalidTicket {
+		t.Fatalf("Expected expired ticket to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package storiesclient is a Go client for the Stories Service API. The
+// operations and models in api.go are generated from docs/swagger.json by
+// cmd/sdkgen; this file holds the hand-written transport they share.
+package storiesclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TenantHeader names the tenant a request is made in
+const TenantHeader = "X-Tenant-ID"
+
+// Client calls the Stories Service API. It is safe for concurrent use once
+// configured.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	tenantID   string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient makes the client send requests with httpClient instead of
+// http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates every request with a bearer token, as returned by Login
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithTenant makes every request in tenantID instead of the default tenant
+func WithTenant(tenantID string) Option {
+	return func(c *Client) {
+		c.tenantID = tenantID
+	}
+}
+
+// New creates a client for the API served at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Authenticated returns a copy of the client that authenticates with token
+func (c *Client) Authenticated(token string) *Client {
+	authed := *c
+	authed.token = token
+	return &authed
+}
+
+// APIError is returned for responses with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+	Errors     []FieldError // per-field validation failures, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("stories API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// envelope is the {status, message, data} wrapper of JSON responses
+type envelope[T any] struct {
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Error   string       `json:"error"`
+	Errors  []FieldError `json:"errors"`
+	Data    T            `json:"data"`
+}
+
+// call sends a request and decodes the data of the response envelope into a T
+func call[T any](ctx context.Context, c *Client, method, path string, query url.Values, body any) (T, error) {
+	var env envelope[T]
+	err := c.do(ctx, method, path, query, body, &env)
+	return env.Data, err
+}
+
+// callRaw sends a request and decodes a response that is not enveloped into a T
+func callRaw[T any](ctx context.Context, c *Client, method, path string, query url.Values, body any) (T, error) {
+	var out T
+	err := c.do(ctx, method, path, query, body, &out)
+	return out, err
+}
+
+// do sends a request, decoding a successful response into out and returning
+// an *APIError otherwise
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "storiesclient-go/"+Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.tenantID != "" {
+		req.Header.Set(TenantHeader, c.tenantID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failure envelope[json.RawMessage]
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == "" {
+			failure.Error = http.StatusText(resp.StatusCode)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: failure.Error, Errors: failure.Errors}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}